@@ -5,23 +5,82 @@ import (
 )
 
 type BackupSpec struct {
-	// Reference to the Database to backup
-	// +kubebuilder:validation:Required
-	DatabaseRef DatabaseReference `json:"databaseRef"`
+	// Reference to the Database to backup (mutually exclusive with globals)
+	// +optional
+	DatabaseRef DatabaseReference `json:"databaseRef,omitempty"`
+
+	// Globals dumps roles and tablespaces of a DBCluster (pg_dumpall --globals-only) instead of a database.
+	// Only allowed in the operator namespace.
+	// +optional
+	Globals *GlobalsBackup `json:"globals,omitempty"`
 
 	// Reference to the BackupStorage to use
 	// +kubebuilder:validation:Required
 	StorageRef StorageReference `json:"storageRef"`
 
+	// Additional BackupStorages the backup is copied to after the upload to storageRef,
+	// e.g. a bucket in another region for disaster recovery
+	// +kubebuilder:validation:MaxItems=5
+	// +optional
+	Replicas []StorageReference `json:"replicas,omitempty"`
+
 	// Filename template for the backup file
 	// Available: .DatabaseName, .Timestamp, .Random (6 chars lowercase alphanumeric)
 	// +kubebuilder:default="{{ .Timestamp }}.sql.gz"
 	// +optional
 	FilenameTemplate string `json:"filenameTemplate,omitempty"`
 
+	// Compression of the backup file (default: gzip). With another codec, a filename ending in
+	// .gz gets the codec's extension instead (.zst, .lz4, or none).
+	// +optional
+	Compression *Compression `json:"compression,omitempty"`
+
 	// Auto-delete after completion. Use with caution in GitOps environments!
 	// +optional
 	TTLAfterCompletion *metav1.Duration `json:"ttlAfterCompletion,omitempty"`
+
+	// Overrides for the backup Job (resources, retries, deadline)
+	// +optional
+	JobTemplate *JobOverrides `json:"jobTemplate,omitempty"`
+
+	// Hooks run in the backup Job before pg_dump, in order
+	// +optional
+	PreHooks []Hook `json:"preHooks,omitempty"`
+
+	// Hooks run in the backup Job after the upload, also when the backup failed
+	// +optional
+	PostHooks []Hook `json:"postHooks,omitempty"`
+}
+
+// GlobalsBackup selects the DBCluster whose roles and tablespaces are dumped
+type GlobalsBackup struct {
+	// +kubebuilder:validation:Required
+	ClusterRef ClusterReference `json:"clusterRef"`
+}
+
+// GlobalsDatabaseName is used as .DatabaseName in storage paths of globals backups
+const GlobalsDatabaseName = "_globals"
+
+// Replica phases
+const (
+	ReplicaCompleted = "Completed"
+	ReplicaFailed    = "Failed"
+)
+
+// ReplicaStatus is the outcome of copying a backup to one replica storage
+type ReplicaStatus struct {
+	// Name of the replica BackupStorage
+	Storage string `json:"storage"`
+
+	// +kubebuilder:validation:Enum=Completed;Failed
+	Phase string `json:"phase"`
+
+	// Path of the backup file in the replica storage
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // StorageReference references a BackupStorage resource
@@ -54,6 +113,15 @@ type BackupStatus struct {
 	// RunID is a unique identifier for this backup run, used in job name and filename
 	RunID string `json:"runId,omitempty"`
 
+	// Results of pre/post hooks, reported by the Job
+	// +optional
+	Hooks []HookResult `json:"hooks,omitempty"`
+
+	// Copies to the replica storages, reported by the Job.
+	// A failed replica does not fail the backup.
+	// +optional
+	Replicas []ReplicaStatus `json:"replicas,omitempty"`
+
 	StartedAt   *metav1.Time `json:"startedAt,omitempty"`
 	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
 
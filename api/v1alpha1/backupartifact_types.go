@@ -0,0 +1,79 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BackupArtifactSpec describes a backup file found in a BackupStorage. It is filled in from the
+// object's tags by the operator and cannot be changed.
+type BackupArtifactSpec struct {
+	// BackupStorage the file is stored in
+	// +kubebuilder:validation:Required
+	StorageRef StorageReference `json:"storageRef"`
+
+	// Path of the file in the storage
+	// +kubebuilder:validation:Required
+	Path string `json:"path"`
+
+	// Size of the file in bytes
+	// +optional
+	Size int64 `json:"size,omitempty"`
+
+	// When the file was last modified in the storage
+	// +optional
+	LastModified metav1.Time `json:"lastModified,omitempty"`
+
+	// DBCluster the backup was taken from
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// PostgreSQL database name (globals backups: "_globals")
+	// +optional
+	Database string `json:"database,omitempty"`
+
+	// Backup resource that created the file, and its namespace
+	// +optional
+	BackupName string `json:"backupName,omitempty"`
+	// +optional
+	BackupNamespace string `json:"backupNamespace,omitempty"`
+
+	// Backup timestamp in YYYYMMDD-HHMMSS format
+	// +optional
+	Timestamp string `json:"timestamp,omitempty"`
+
+	// Compression codec of the file (gzip, zstd, lz4, none)
+	// +optional
+	Compression string `json:"compression,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=bka
+// +kubebuilder:printcolumn:name="Storage",type=string,JSONPath=`.spec.storageRef.name`
+// +kubebuilder:printcolumn:name="Database",type=string,JSONPath=`.spec.database`
+// +kubebuilder:printcolumn:name="Timestamp",type=string,JSONPath=`.spec.timestamp`
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.spec.size`
+// +kubebuilder:printcolumn:name="Path",type=string,JSONPath=`.spec.path`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BackupArtifact is a read-only inventory entry for a backup file in a BackupStorage, kept in sync
+// by the operator. It outlives the Backup that created the file and can be used as a Restore source.
+type BackupArtifact struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="BackupArtifact spec is read-only"
+	Spec BackupArtifactSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BackupArtifactList contains a list of BackupArtifact
+type BackupArtifactList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BackupArtifact `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BackupArtifact{}, &BackupArtifactList{})
+}
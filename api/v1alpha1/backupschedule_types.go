@@ -23,6 +23,16 @@ type RetentionPolicy struct {
 	KeepMonthly *int `json:"keepMonthly,omitempty"`
 }
 
+// BackupReplica is an additional storage the scheduled backups are copied to
+type BackupReplica struct {
+	// +kubebuilder:validation:Required
+	StorageRef StorageReference `json:"storageRef"`
+
+	// Retention of backup files in this storage (default: the schedule's retention)
+	// +optional
+	Retention *RetentionPolicy `json:"retention,omitempty"`
+}
+
 type BackupScheduleSpec struct {
 	// Reference to the Database to backup
 	// +kubebuilder:validation:Required
@@ -43,13 +53,42 @@ type BackupScheduleSpec struct {
 	// +optional
 	FilenameTemplate string `json:"filenameTemplate,omitempty"`
 
+	// Compression of backup files (inherited by created Backups)
+	// +optional
+	Compression *Compression `json:"compression,omitempty"`
+
 	// Retention policy for automatic cleanup of old backups
 	// +optional
 	Retention *RetentionPolicy `json:"retention,omitempty"`
 
+	// Additional storages every backup is copied to (inherited by created Backups)
+	// +kubebuilder:validation:MaxItems=5
+	// +optional
+	Replicas []BackupReplica `json:"replicas,omitempty"`
+
 	// Suspend stops scheduling new backups (does not affect running backups)
 	// +optional
 	Suspend bool `json:"suspend,omitempty"`
+
+	// Overrides for the backup Job (inherited by created Backups)
+	// +optional
+	JobTemplate *JobOverrides `json:"jobTemplate,omitempty"`
+
+	// Pre-backup hooks (inherited by created Backups)
+	// +optional
+	PreHooks []Hook `json:"preHooks,omitempty"`
+
+	// Post-backup hooks (inherited by created Backups)
+	// +optional
+	PostHooks []Hook `json:"postHooks,omitempty"`
+}
+
+// ReplicaRetention returns the retention of a replica storage, defaulting to the schedule's
+func (s *BackupScheduleSpec) ReplicaRetention(replica *BackupReplica) *RetentionPolicy {
+	if replica.Retention != nil {
+		return replica.Retention
+	}
+	return s.Retention
 }
 
 type BackupScheduleStatus struct {
@@ -34,19 +34,94 @@ type AzureStorageConfig struct {
 	StorageAccount string `json:"storageAccount"`
 }
 
+// PVCStorageConfig stores backups on a PersistentVolumeClaim mounted into backup and restore Jobs
+type PVCStorageConfig struct {
+	// Name of the PersistentVolumeClaim in the operator namespace.
+	// It must be ReadWriteMany when several schedules share the storage.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ClaimName string `json:"claimName"`
+
+	// Directory inside the volume backups are stored under (default: volume root)
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
+}
+
+// SFTPStorageConfig stores backups on an SFTP server.
+// The private key is read from credentialsSecretRef (key SSH_PRIVATE_KEY).
+type SFTPStorageConfig struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=22
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	User string `json:"user"`
+
+	// Directory on the server backups are stored under
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// known_hosts entries for the server (e.g. output of ssh-keyscan); the host key is always verified
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	KnownHosts string `json:"knownHosts"`
+}
+
+// Immutability modes
+const (
+	ImmutabilityGovernance = "Governance"
+	ImmutabilityCompliance = "Compliance"
+)
+
+// ImmutabilityConfig protects uploaded backups from deletion, even with the bucket credentials.
+// S3 uses Object Lock, GCS object retention and temporary holds, Azure immutability policies and legal holds.
+type ImmutabilityConfig struct {
+	// Governance: principals with bypass permission can shorten or remove the retention
+	// (S3 governance mode, unlocked policies on GCS and Azure).
+	// Compliance: nobody can delete a backup before its retention ends (S3 compliance mode, locked policies).
+	// +kubebuilder:validation:Enum=Governance;Compliance
+	// +kubebuilder:default=Governance
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// How long each backup cannot be deleted or overwritten after upload, e.g. 720h
+	// +optional
+	RetainFor *metav1.Duration `json:"retainFor,omitempty"`
+
+	// Place a legal hold on each backup. It protects the backup until it is removed in the provider.
+	// +optional
+	LegalHold bool `json:"legalHold,omitempty"`
+}
+
 type BackupStorageSpec struct {
-	// S3 storage configuration (mutually exclusive with gcs and azure)
+	// S3 storage configuration (mutually exclusive with gcs, azure, pvc and sftp)
 	// +optional
 	S3 *S3StorageConfig `json:"s3,omitempty"`
 
-	// GCS storage configuration (mutually exclusive with s3 and azure)
+	// GCS storage configuration (mutually exclusive with s3, azure, pvc and sftp)
 	// +optional
 	GCS *GCSStorageConfig `json:"gcs,omitempty"`
 
-	// Azure Blob storage configuration (mutually exclusive with s3 and gcs)
+	// Azure Blob storage configuration (mutually exclusive with s3, gcs, pvc and sftp)
 	// +optional
 	Azure *AzureStorageConfig `json:"azure,omitempty"`
 
+	// PersistentVolumeClaim storage for on-prem and air-gapped clusters (mutually exclusive with s3, gcs, azure and sftp)
+	// +optional
+	PVC *PVCStorageConfig `json:"pvc,omitempty"`
+
+	// SFTP server storage, e.g. an archive host outside the cloud (mutually exclusive with s3, gcs, azure and pvc)
+	// +optional
+	SFTP *SFTPStorageConfig `json:"sftp,omitempty"`
+
 	// Path template for directory structure
 	// Available: .ClusterName, .DatabaseName, .Year, .Month, .Day
 	// +kubebuilder:default="{{ .ClusterName }}/{{ .DatabaseName }}"
@@ -56,6 +131,16 @@ type BackupStorageSpec struct {
 	// Optional: credentials secret reference. If not set, uses cloud-native auth (OIDC/Pod Identity)
 	// +optional
 	CredentialsSecretRef *SecretReference `json:"credentialsSecretRef,omitempty"`
+
+	// Make backups immutable on upload (ransomware protection).
+	// The bucket or container must support it, e.g. S3 buckets created with Object Lock enabled.
+	// +optional
+	Immutability *ImmutabilityConfig `json:"immutability,omitempty"`
+
+	// Defaults for backup and restore Job pods using this storage
+	// (e.g. serviceAccountName with access to the bucket)
+	// +optional
+	JobTemplate *JobTemplate `json:"jobTemplate,omitempty"`
 }
 
 type BackupStorageStatus struct {
@@ -63,19 +148,71 @@ type BackupStorageStatus struct {
 	Phase   string `json:"phase,omitempty"`
 	Message string `json:"message,omitempty"`
 
-	// Detected provider type (s3, gcs, azure)
+	// Detected provider type (s3, gcs, azure, pvc, sftp)
 	Provider string `json:"provider,omitempty"`
 
 	// Last time the storage was validated
 	LastValidation     metav1.Time `json:"lastValidation,omitempty"`
 	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+
+	// Result of the access probe, one condition per permission (Writable, Readable, Listable, Deletable),
+	// and whether backups are inventoried as BackupArtifacts (Inventoried)
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Result of the last BackupArtifact inventory sync
+	// +optional
+	Artifacts *ArtifactSyncStatus `json:"artifacts,omitempty"`
 }
 
+// ArtifactSyncStatus reports the BackupArtifact inventory of a storage
+type ArtifactSyncStatus struct {
+	// Number of BackupArtifacts for this storage
+	Count int32 `json:"count"`
+
+	// Last time the storage was listed
+	// +optional
+	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Generation of the storage that was synced
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Why the storage was not synced, or which files could not be inventoried
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// BackupStorage conditions, set by probing the storage with a test object
+const (
+	StorageConditionWritable  = "Writable"
+	StorageConditionReadable  = "Readable"
+	StorageConditionListable  = "Listable"
+	StorageConditionDeletable = "Deletable"
+)
+
+// StorageConditionInventoried is set by the BackupArtifact sync. It is False when the operator
+// cannot list the storage, e.g. on a PVC, so its backups never become BackupArtifacts.
+const StorageConditionInventoried = "Inventoried"
+
+// BackupStorage condition reasons
+const (
+	StorageReasonProbeSucceeded   = "ProbeSucceeded"
+	StorageReasonPermissionDenied = "PermissionDenied"
+	StorageReasonProbeFailed      = "ProbeFailed"
+	StorageReasonNotProbed        = "NotProbed"
+
+	StorageReasonSynced       = "Synced"
+	StorageReasonSyncFailed   = "SyncFailed"
+	StorageReasonNotSupported = "NotSupported"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,shortName=bs
 // +kubebuilder:printcolumn:name="Provider",type=string,JSONPath=`.status.provider`
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Artifacts",type=integer,JSONPath=`.status.artifacts.count`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 type BackupStorage struct {
@@ -97,6 +234,12 @@ func (b *BackupStorage) GetProvider() string {
 	if b.Spec.Azure != nil {
 		return "azure"
 	}
+	if b.Spec.PVC != nil {
+		return "pvc"
+	}
+	if b.Spec.SFTP != nil {
+		return "sftp"
+	}
 	return ""
 }
 
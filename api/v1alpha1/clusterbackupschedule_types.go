@@ -0,0 +1,147 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DatabaseSelector selects Databases on a DBCluster
+type DatabaseSelector struct {
+	// Only Databases in these namespaces (empty: all namespaces)
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Only Databases with matching labels (empty: all Databases)
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+type ClusterBackupScheduleSpec struct {
+	// DBCluster whose Databases are backed up
+	// +kubebuilder:validation:Required
+	ClusterRef ClusterReference `json:"clusterRef"`
+
+	// Limits which Databases on the cluster are backed up (default: all)
+	// +optional
+	DatabaseSelector *DatabaseSelector `json:"databaseSelector,omitempty"`
+
+	// Also dump roles and tablespaces (pg_dumpall --globals-only) on every run
+	// +kubebuilder:default=true
+	// +optional
+	IncludeGlobals *bool `json:"includeGlobals,omitempty"`
+
+	// Reference to the BackupStorage to use
+	// +kubebuilder:validation:Required
+	StorageRef StorageReference `json:"storageRef"`
+
+	// Cron schedule in standard cron format (e.g., "0 2 * * *" for 2 AM daily)
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^(\S+\s+){4}\S+$`
+	Schedule string `json:"schedule"`
+
+	// Filename template for backup files (inherited by created Backups)
+	// +kubebuilder:default="{{ .Timestamp }}.sql.gz"
+	// +optional
+	FilenameTemplate string `json:"filenameTemplate,omitempty"`
+
+	// Compression of backup files (inherited by created Backups)
+	// +optional
+	Compression *Compression `json:"compression,omitempty"`
+
+	// Number of Backup resources to keep per database (older completed ones are deleted)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	KeepLast *int `json:"keepLast,omitempty"`
+
+	// Retention of backup files, applied per database (and to the globals).
+	// Object storages are cleaned up by the operator, pvc and sftp storages by the backup Jobs.
+	// +optional
+	Retention *RetentionPolicy `json:"retention,omitempty"`
+
+	// Suspend stops scheduling new runs (does not affect running backups)
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Job pod settings for the created Backups, applied over the BackupStorage jobTemplate
+	// +optional
+	JobTemplate *JobTemplate `json:"jobTemplate,omitempty"`
+}
+
+// GlobalsEnabled returns true unless includeGlobals is explicitly false
+func (s *ClusterBackupScheduleSpec) GlobalsEnabled() bool {
+	return s.IncludeGlobals == nil || *s.IncludeGlobals
+}
+
+// ClusterBackupRunStatus aggregates the Backups created by one scheduled run
+type ClusterBackupRunStatus struct {
+	// Run identifier (scheduled time, YYYYMMDD-HHMM), also the dbtether.io/run label of its Backups
+	Run string `json:"run"`
+
+	// +kubebuilder:validation:Enum=Running;Completed;PartiallyFailed;Failed
+	Phase string `json:"phase"`
+
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+
+	// Failed Backups as namespace/name
+	// +optional
+	FailedBackups []string `json:"failedBackups,omitempty"`
+
+	// Name of the globals Backup in the operator namespace
+	// +optional
+	GlobalsBackup string `json:"globalsBackup,omitempty"`
+}
+
+type ClusterBackupScheduleStatus struct {
+	// +kubebuilder:validation:Enum=Active;Suspended;Failed
+	Phase string `json:"phase,omitempty"`
+
+	Message string `json:"message,omitempty"`
+
+	// Number of Databases matched at the last run
+	Databases int `json:"databases,omitempty"`
+
+	// Time of the last run
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+
+	// Next scheduled run
+	NextScheduledTime *metav1.Time `json:"nextScheduledTime,omitempty"`
+
+	// Progress of the last run
+	// +optional
+	LastRun *ClusterBackupRunStatus `json:"lastRun,omitempty"`
+
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=cbks
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterRef.name`
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+// +kubebuilder:printcolumn:name="Databases",type=integer,JSONPath=`.status.databases`
+// +kubebuilder:printcolumn:name="Last Run",type=string,JSONPath=`.status.lastRun.phase`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Next",type=string,format=date-time,JSONPath=`.status.nextScheduledTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterBackupSchedule backs up all matching Databases of a DBCluster, plus its roles and tablespaces
+type ClusterBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterBackupScheduleSpec   `json:"spec,omitempty"`
+	Status ClusterBackupScheduleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type ClusterBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterBackupSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterBackupSchedule{}, &ClusterBackupScheduleList{})
+}
@@ -0,0 +1,26 @@
+package v1alpha1
+
+// Compression selects how the backup file is compressed.
+// Set on Backup, BackupSchedule or ClusterBackupSchedule; schedules pass it to their Backups.
+type Compression struct {
+	// Codec of the backup file. Restores detect the codec from the file content.
+	// +kubebuilder:validation:Enum=gzip;zstd;lz4;none
+	// +kubebuilder:default=gzip
+	// +optional
+	Codec string `json:"codec,omitempty"`
+
+	// Level of the codec: gzip 1-9, zstd 1-22, lz4 1-9 (default: the codec's default level).
+	// Not allowed with codec none.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=22
+	// +optional
+	Level *int32 `json:"level,omitempty"`
+}
+
+// Compression codecs
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+	CompressionLZ4  = "lz4"
+	CompressionNone = "none"
+)
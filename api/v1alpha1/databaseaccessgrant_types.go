@@ -0,0 +1,124 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DatabaseAccessGrantSpec grants temporary privileges on a Database
+type DatabaseAccessGrantSpec struct {
+	// Database to grant access to
+	// +kubebuilder:validation:Required
+	Database DatabaseReference `json:"database"`
+
+	// UserRef is an existing DatabaseUser in the same namespace that receives the privileges.
+	// If not set, a temporary role is generated and its credentials are written to a Secret.
+	// +optional
+	UserRef *UserReference `json:"userRef,omitempty"`
+
+	// Privileges granted for the duration of the grant
+	// +kubebuilder:validation:Enum=readonly;readwrite;admin
+	// +kubebuilder:default=readwrite
+	Privileges string `json:"privileges,omitempty"`
+
+	// +optional
+	AdditionalGrants []TableGrant `json:"additionalGrants,omitempty"`
+
+	// Duration of the grant, counted from when it became active (e.g., "4h").
+	// Can be changed while the grant is active to extend or shorten it.
+	// +kubebuilder:validation:Required
+	Duration metav1.Duration `json:"duration"`
+
+	// Approval records who approved the access and why
+	// +kubebuilder:validation:Required
+	Approval GrantApproval `json:"approval"`
+}
+
+// UserReference is a reference to a DatabaseUser in the same namespace
+type UserReference struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// GrantApproval is the approval metadata of a DatabaseAccessGrant
+type GrantApproval struct {
+	// ApprovedBy is the person or team that approved the access
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ApprovedBy string `json:"approvedBy"`
+
+	// Reason for the access (e.g., incident description)
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Reason string `json:"reason"`
+
+	// Ticket is an optional incident or change ticket reference
+	// +optional
+	Ticket string `json:"ticket,omitempty"`
+}
+
+type DatabaseAccessGrantStatus struct {
+	// Phase is Pending until privileges are granted, Active while they are in effect,
+	// and Expired once they were revoked
+	// +kubebuilder:validation:Enum=Pending;Active;Expired;Failed
+	Phase   string `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// ClusterName is the DBCluster of the database
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// DatabaseName is the PostgreSQL database name
+	DatabaseName string `json:"databaseName,omitempty"`
+
+	// Username is the PostgreSQL role holding the grant
+	Username string `json:"username,omitempty"`
+
+	// Generated is true when the role was created for this grant (dropped on expiry)
+	// +optional
+	Generated bool `json:"generated,omitempty"`
+
+	// SecretName contains the credentials of a generated role
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// Approval is the approval metadata in effect when the privileges were granted
+	// +optional
+	Approval *GrantApproval `json:"approval,omitempty"`
+
+	GrantedAt          *metav1.Time `json:"grantedAt,omitempty"`
+	ExpiresAt          *metav1.Time `json:"expiresAt,omitempty"`
+	RevokedAt          *metav1.Time `json:"revokedAt,omitempty"`
+	PendingSince       *metav1.Time `json:"pendingSince,omitempty"`
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=dbgrant
+// +kubebuilder:printcolumn:name="User",type=string,JSONPath=`.status.username`
+// +kubebuilder:printcolumn:name="Database",type=string,JSONPath=`.status.databaseName`
+// +kubebuilder:printcolumn:name="Privileges",type=string,JSONPath=`.spec.privileges`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Expires",type=string,JSONPath=`.status.expiresAt`
+// +kubebuilder:printcolumn:name="Approved By",type=string,JSONPath=`.spec.approval.approvedBy`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DatabaseAccessGrant temporarily grants extra privileges on a Database and revokes them on expiry
+type DatabaseAccessGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DatabaseAccessGrantSpec   `json:"spec,omitempty"`
+	Status DatabaseAccessGrantStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type DatabaseAccessGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatabaseAccessGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DatabaseAccessGrant{}, &DatabaseAccessGrantList{})
+}
@@ -0,0 +1,109 @@
+package v1alpha1
+
+import (
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReferencePurpose is what a cross-namespace reference is used for
+// +kubebuilder:validation:Enum=userAccess;restoreSource
+type ReferencePurpose string
+
+const (
+	// ReferencePurposeUserAccess allows DatabaseUsers, DatabaseAccessGrants and DatabaseSessions
+	// to get access to Databases
+	ReferencePurposeUserAccess ReferencePurpose = "userAccess"
+	// ReferencePurposeRestoreSource allows Restores to read Backups and BackupArtifacts
+	// (backupRef, artifactRef or latestFrom)
+	ReferencePurposeRestoreSource ReferencePurpose = "restoreSource"
+)
+
+// Kinds that can be the target of a DatabaseReferenceGrant
+const (
+	ReferenceKindDatabase       = "Database"
+	ReferenceKindBackup         = "Backup"
+	ReferenceKindBackupArtifact = "BackupArtifact"
+)
+
+// DatabaseReferenceGrantSpec lists who may reference Databases and Backups in this namespace
+type DatabaseReferenceGrantSpec struct {
+	// From lists the namespaces allowed to reference resources in this namespace
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	From []ReferenceGrantFrom `json:"from"`
+
+	// To limits the grant to specific resources. Empty allows all Databases, Backups and BackupArtifacts.
+	// +optional
+	To []ReferenceGrantTo `json:"to,omitempty"`
+}
+
+// ReferenceGrantFrom is a namespace allowed to reference resources, and for which purposes
+type ReferenceGrantFrom struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	Purposes []ReferencePurpose `json:"purposes"`
+}
+
+// ReferenceGrantTo is a resource that may be referenced
+type ReferenceGrantTo struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Database;Backup;BackupArtifact
+	Kind string `json:"kind"`
+
+	// Name of the resource; empty allows all resources of this kind
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// Allows returns true if fromNamespace may reference the resource for the given purpose
+func (g *DatabaseReferenceGrant) Allows(fromNamespace string, purpose ReferencePurpose, kind, name string) bool {
+	fromAllowed := slices.ContainsFunc(g.Spec.From, func(from ReferenceGrantFrom) bool {
+		return from.Namespace == fromNamespace && slices.Contains(from.Purposes, purpose)
+	})
+	if !fromAllowed {
+		return false
+	}
+	if len(g.Spec.To) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(g.Spec.To, func(to ReferenceGrantTo) bool {
+		return to.Kind == kind && (to.Name == "" || to.Name == name)
+	})
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=dbrefgrant
+// +kubebuilder:printcolumn:name="From",type=string,JSONPath=`.spec.from[*].namespace`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DatabaseReferenceGrant allows other namespaces to reference Databases and Backups in its namespace
+type DatabaseReferenceGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DatabaseReferenceGrantSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type DatabaseReferenceGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatabaseReferenceGrant `json:"items"`
+}
+
+// Allows returns true if any grant in the list allows the reference
+func (l *DatabaseReferenceGrantList) Allows(fromNamespace string, purpose ReferencePurpose, kind, name string) bool {
+	return slices.ContainsFunc(l.Items, func(g DatabaseReferenceGrant) bool {
+		return g.Allows(fromNamespace, purpose, kind, name)
+	})
+}
+
+func init() {
+	SchemeBuilder.Register(&DatabaseReferenceGrant{}, &DatabaseReferenceGrantList{})
+}
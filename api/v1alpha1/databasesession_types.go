@@ -0,0 +1,111 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DatabaseSessionSpec requests short-lived database access through a proxy in the session's namespace
+type DatabaseSessionSpec struct {
+	// Database to connect to
+	// +kubebuilder:validation:Required
+	Database DatabaseReference `json:"database"`
+
+	// Privileges of the temporary role
+	// +kubebuilder:validation:Enum=readonly;readwrite;admin
+	// +kubebuilder:default=readonly
+	Privileges string `json:"privileges,omitempty"`
+
+	// TTL of the session, counted from when the role and proxy were created (e.g., "2h").
+	// Limited by the operator's maximum session TTL.
+	// +kubebuilder:validation:Required
+	TTL metav1.Duration `json:"ttl"`
+
+	// Reason is logged by the operator when the session starts
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// +optional
+	Proxy SessionProxy `json:"proxy,omitempty"`
+}
+
+// SessionProxy configures the proxy pod that forwards connections to the DBCluster
+type SessionProxy struct {
+	// Type of proxy:
+	// - socat (default): plain TCP forwarder, TLS is negotiated end-to-end with PostgreSQL
+	// - pgbouncer: connection pooler authenticating with the session credentials
+	// +kubebuilder:validation:Enum=socat;pgbouncer
+	// +kubebuilder:default=socat
+	Type string `json:"type,omitempty"`
+
+	// Image overrides the operator's default image for the proxy type
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Port the proxy Service listens on
+	// +kubebuilder:default=5432
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port,omitempty"`
+
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+type DatabaseSessionStatus struct {
+	// +kubebuilder:validation:Enum=Pending;Ready;Expired;Failed
+	Phase   string `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// ClusterName is the DBCluster of the database
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// DatabaseName is the PostgreSQL database name
+	DatabaseName string `json:"databaseName,omitempty"`
+
+	// Username is the temporary PostgreSQL role
+	Username string `json:"username,omitempty"`
+
+	// SecretName contains the connection info (host/port point to the proxy Service)
+	SecretName string `json:"secretName,omitempty"`
+
+	// ServiceName is the proxy Service to port-forward to
+	ServiceName string `json:"serviceName,omitempty"`
+
+	StartedAt          *metav1.Time `json:"startedAt,omitempty"`
+	ExpiresAt          *metav1.Time `json:"expiresAt,omitempty"`
+	EndedAt            *metav1.Time `json:"endedAt,omitempty"`
+	PendingSince       *metav1.Time `json:"pendingSince,omitempty"`
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=dbsession
+// +kubebuilder:printcolumn:name="Database",type=string,JSONPath=`.status.databaseName`
+// +kubebuilder:printcolumn:name="Privileges",type=string,JSONPath=`.spec.privileges`
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.status.serviceName`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Expires",type=string,JSONPath=`.status.expiresAt`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DatabaseSession provides ephemeral database access through a proxy pod, torn down when the TTL expires
+type DatabaseSession struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DatabaseSessionSpec   `json:"spec,omitempty"`
+	Status DatabaseSessionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type DatabaseSessionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatabaseSession `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DatabaseSession{}, &DatabaseSessionList{})
+}
@@ -40,11 +40,20 @@ type DatabaseUserSpec struct {
 	// +kubebuilder:default=-1
 	ConnectionLimit int `json:"connectionLimit,omitempty"`
 
+	// RoleAttributes grants additional PostgreSQL role options via ALTER ROLE.
+	// Privileged attributes must be allowed by the DBCluster (spec.allowedRoleAttributes).
+	// +optional
+	RoleAttributes *RoleAttributes `json:"roleAttributes,omitempty"`
+
 	// +optional
 	// +kubebuilder:validation:Enum=Delete;Retain
 	// +kubebuilder:default=Delete
 	DeletionPolicy string `json:"deletionPolicy,omitempty"`
 
+	// Deletion controls how the role is dropped when deletionPolicy is Delete
+	// +optional
+	Deletion *UserDeletionConfig `json:"deletion,omitempty"`
+
 	// +optional
 	Secret *SecretConfig `json:"secret,omitempty"`
 
@@ -55,6 +64,48 @@ type DatabaseUserSpec struct {
 	// +kubebuilder:validation:Enum=primary;perDatabase
 	// +kubebuilder:default=primary
 	SecretGeneration string `json:"secretGeneration,omitempty"`
+
+	// RolloutTargets lists workloads that consume the credentials and should be
+	// restarted after the password changes (rotation or secret regeneration)
+	// +optional
+	RolloutTargets *RolloutTargets `json:"rolloutTargets,omitempty"`
+
+	// SecretStore copies the credentials to an external secret store.
+	// The Kubernetes Secret is always kept, it remains the source of truth for the password.
+	// +optional
+	SecretStore *SecretStoreConfig `json:"secretStore,omitempty"`
+}
+
+// Deletion strategies for DatabaseUser roles
+const (
+	// UserDeletionStrategyDrop revokes privileges and runs DROP USER
+	UserDeletionStrategyDrop = "drop"
+	// UserDeletionStrategyReassign runs REASSIGN OWNED and DROP OWNED in every database
+	// the role touched before dropping it
+	UserDeletionStrategyReassign = "reassignTo"
+)
+
+// UserDeletionConfig defines how objects owned by the role are handled on deletion
+type UserDeletionConfig struct {
+	// Strategy: drop (default) fails if the role still owns objects;
+	// reassignTo hands owned objects over to another role first
+	// +optional
+	// +kubebuilder:validation:Enum=drop;reassignTo
+	// +kubebuilder:default=drop
+	Strategy string `json:"strategy,omitempty"`
+
+	// ReassignTo is the role that receives the objects: the owner of the database or the role
+	// of another DatabaseUser in the same namespace on the same cluster.
+	// Defaults to the owner of each database.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-z_][a-z0-9_]*$`
+	// +kubebuilder:validation:MaxLength=63
+	ReassignTo string `json:"reassignTo,omitempty"`
+}
+
+// Reassign returns true when owned objects should be reassigned before dropping the role
+func (c *UserDeletionConfig) Reassign() bool {
+	return c != nil && c.Strategy == UserDeletionStrategyReassign
 }
 
 // DatabaseAccess defines access to a single database
@@ -109,6 +160,36 @@ type PasswordConfig struct {
 	// +kubebuilder:validation:Minimum=12
 	// +kubebuilder:validation:Maximum=64
 	Length int `json:"length,omitempty"`
+
+	// Charset of generated passwords (letters and digits are always included):
+	// - safe (default): special characters ._-,^
+	// - alphanumeric: no special characters
+	// - urlSafe: special characters -._~ (no escaping in connection URLs)
+	// - symbols: printable symbols except quotes, backslash and space
+	// +kubebuilder:validation:Enum=safe;alphanumeric;urlSafe;symbols
+	// +kubebuilder:default=safe
+	Charset string `json:"charset,omitempty"`
+
+	// ExcludeChars are never used in generated passwords (e.g., "lIO0" for readability)
+	// +optional
+	// +kubebuilder:validation:MaxLength=64
+	ExcludeChars string `json:"excludeChars,omitempty"`
+
+	// SecretRef takes the password from an existing Secret in the same namespace instead of
+	// generating one (e.g., to keep an application's current password during migration).
+	// The Secret is the source of truth: changes are applied to PostgreSQL and rotation is disabled.
+	// +optional
+	SecretRef *PasswordSecretRef `json:"secretRef,omitempty"`
+}
+
+// PasswordSecretRef references a key in a Secret in the DatabaseUser's namespace
+type PasswordSecretRef struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Key containing the password
+	// +kubebuilder:default=password
+	Key string `json:"key,omitempty"`
 }
 
 type RotationConfig struct {
@@ -117,6 +198,80 @@ type RotationConfig struct {
 	Days int `json:"days"`
 }
 
+// Role attribute names used in DBCluster.spec.allowedRoleAttributes and status.roleAttributes
+const (
+	RoleAttributeReplication = "Replication"
+	RoleAttributeBypassRLS   = "BypassRLS"
+	RoleAttributeCreateDB    = "CreateDB"
+	RoleAttributeCreateRole  = "CreateRole"
+)
+
+// DatabaseUser conditions
+const (
+	// UserConditionRoleAttributesAllowed is False when spec.roleAttributes requests attributes the
+	// DBCluster does not allow; those attributes are revoked from the role
+	UserConditionRoleAttributesAllowed = "RoleAttributesAllowed"
+	// UserConditionReassignTargetAllowed is False when deletion.reassignTo names a role that is neither
+	// the database owner nor managed by a DatabaseUser in the same namespace on the same cluster
+	UserConditionReassignTargetAllowed = "ReassignTargetAllowed"
+)
+
+// DatabaseUser condition reasons
+const (
+	UserReasonAllowed    = "Allowed"
+	UserReasonNotAllowed = "NotAllowed"
+)
+
+// RoleAttributes are PostgreSQL role options on top of the default NOCREATEDB NOCREATEROLE NOINHERIT
+type RoleAttributes struct {
+	// Replication allows streaming and logical replication connections (e.g., Debezium CDC)
+	// +optional
+	Replication bool `json:"replication,omitempty"`
+
+	// BypassRLS makes the role bypass row-level security policies
+	// +optional
+	BypassRLS bool `json:"bypassRLS,omitempty"`
+
+	// CreateDB allows the role to create databases
+	// +optional
+	CreateDB bool `json:"createDB,omitempty"`
+
+	// CreateRole allows the role to create other roles
+	// +optional
+	CreateRole bool `json:"createRole,omitempty"`
+
+	// ValidUntil sets a fixed password expiry (VALID UNTIL)
+	// Mutually exclusive with ValidUntilRotation
+	// +optional
+	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+
+	// ValidUntilRotation expires the password one day after the next scheduled rotation,
+	// so a password that failed to rotate stops working. Requires spec.rotation.
+	// +optional
+	ValidUntilRotation bool `json:"validUntilRotation,omitempty"`
+}
+
+// Enabled returns the names of the privileged attributes that are turned on
+func (a *RoleAttributes) Enabled() []string {
+	if a == nil {
+		return nil
+	}
+	var names []string
+	if a.Replication {
+		names = append(names, RoleAttributeReplication)
+	}
+	if a.BypassRLS {
+		names = append(names, RoleAttributeBypassRLS)
+	}
+	if a.CreateDB {
+		names = append(names, RoleAttributeCreateDB)
+	}
+	if a.CreateRole {
+		names = append(names, RoleAttributeCreateRole)
+	}
+	return names
+}
+
 type SecretConfig struct {
 	// +optional
 	// +kubebuilder:validation:MaxLength=253
@@ -135,6 +290,18 @@ type SecretConfig struct {
 	// +kubebuilder:validation:Enum=Fail;Adopt;Merge
 	// +kubebuilder:default=Fail
 	OnConflict string `json:"onConflict,omitempty"`
+
+	// Data adds secret keys rendered from Go templates, e.g.
+	// DATABASE_URL: "postgresql://{{ .User }}:{{ .Password | urlencode }}@{{ .Host }}:{{ .Port }}/{{ .Database }}?sslmode={{ .SSLMode }}"
+	// Available fields: .Host, .Port, .Database, .User, .Password, .SSLMode, .Databases
+	// Available functions: urlencode, join
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+
+	// SSLMode exposed to Data templates as .SSLMode
+	// +optional
+	// +kubebuilder:validation:Enum=disable;allow;prefer;require;verify-ca;verify-full
+	SSLMode string `json:"sslMode,omitempty"`
 }
 
 type SecretKeys struct {
@@ -150,8 +317,135 @@ type SecretKeys struct {
 	Password string `json:"password,omitempty"`
 }
 
+// RolloutTargets selects Deployments and StatefulSets in the DatabaseUser's namespace
+type RolloutTargets struct {
+	// Strategy controls how workloads are rolled
+	// - restart (default): stamp dbtether.io/restartedAt on the pod template when the password changes
+	// - hash: stamp dbtether.io/secret-hash (content hash of the secret) on the Secret and pod template
+	// +optional
+	// +kubebuilder:validation:Enum=restart;hash
+	// +kubebuilder:default=restart
+	Strategy string `json:"strategy,omitempty"`
+
+	// Selector matches Deployments and StatefulSets by label
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Workloads lists explicit Deployments and StatefulSets
+	// +optional
+	Workloads []WorkloadReference `json:"workloads,omitempty"`
+}
+
+// WorkloadReference is a reference to a Deployment or StatefulSet in the same namespace
+type WorkloadReference struct {
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet
+	Kind string `json:"kind"`
+
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// SecretStoreConfig selects where credentials are written in addition to the Kubernetes Secret
+type SecretStoreConfig struct {
+	// Type of the secret store
+	// - kubernetes (default): only the Kubernetes Secret
+	// - vault: HashiCorp Vault KV engine
+	// - aws-secretsmanager: AWS Secrets Manager
+	// - external-secrets: External Secrets Operator PushSecret
+	// +kubebuilder:validation:Enum=kubernetes;vault;aws-secretsmanager;external-secrets
+	// +kubebuilder:default=kubernetes
+	Type string `json:"type,omitempty"`
+
+	// +optional
+	Vault *VaultSecretStore `json:"vault,omitempty"`
+
+	// +optional
+	AWS *AWSSecretStore `json:"aws,omitempty"`
+
+	// +optional
+	ExternalSecrets *ExternalSecretsPush `json:"externalSecrets,omitempty"`
+}
+
+// ExternalSecretsPush configures a PushSecret handled by the External Secrets Operator
+type ExternalSecretsPush struct {
+	// SecretStoreRef references an ESO SecretStore or ClusterSecretStore
+	// +kubebuilder:validation:Required
+	SecretStoreRef ExternalSecretStoreRef `json:"secretStoreRef"`
+
+	// RemoteKey is the name of the secret in the external store
+	// +kubebuilder:validation:Required
+	RemoteKey string `json:"remoteKey"`
+
+	// +optional
+	// +kubebuilder:default="1h"
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+
+	// DeletionPolicy of the PushSecret: Delete removes the remote secret when the PushSecret is deleted
+	// +optional
+	// +kubebuilder:validation:Enum=Delete;None
+	// +kubebuilder:default=Delete
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+}
+
+type ExternalSecretStoreRef struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// +optional
+	// +kubebuilder:validation:Enum=SecretStore;ClusterSecretStore
+	// +kubebuilder:default=SecretStore
+	Kind string `json:"kind,omitempty"`
+}
+
+// VaultSecretStore selects the secret in the Vault KV v2 engine configured in the operator
+// (Helm value secretStores.vault)
+type VaultSecretStore struct {
+	// Path of the secret, e.g. myapp/db-credentials. It is placed below the operator's
+	// pathPrefix and the DatabaseUser's namespace.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+}
+
+// AWSSecretStore selects the secret in the AWS Secrets Manager configured in the operator
+// (Helm value secretStores.aws)
+type AWSSecretStore struct {
+	// Name of the secret, e.g. myapp/db-credentials. It is placed below the operator's
+	// namePrefix and the DatabaseUser's namespace.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// Recovery window applied when the secret is deleted
+	// +optional
+	// +kubebuilder:validation:Minimum=7
+	// +kubebuilder:validation:Maximum=30
+	// +kubebuilder:default=30
+	RecoveryWindowDays int `json:"recoveryWindowDays,omitempty"`
+}
+
+// SecretStoreStatus reports the last write to the external secret store
+type SecretStoreStatus struct {
+	Type string `json:"type,omitempty"`
+
+	// Location of the secret, e.g. vault:secret/myapp/db-credentials
+	Location string `json:"location,omitempty"`
+
+	// ContentHash of the credentials last written
+	ContentHash string `json:"contentHash,omitempty"`
+
+	// Phase of the sync: Synced, Pending or Failed
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	LastSyncedAt *metav1.Time `json:"lastSyncedAt,omitempty"`
+}
+
 type DatabaseUserStatus struct {
-	// +kubebuilder:validation:Enum=Pending;Creating;Ready;Failed
+	// +kubebuilder:validation:Enum=Pending;Creating;Ready;Failed;Deleting
 	Phase   string `json:"phase,omitempty"`
 	Message string `json:"message,omitempty"`
 
@@ -173,6 +467,61 @@ type DatabaseUserStatus struct {
 	PasswordUpdatedAt  *metav1.Time `json:"passwordUpdatedAt,omitempty"`
 	PendingSince       *metav1.Time `json:"pendingSince,omitempty"`
 	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+
+	// LastRolloutAt is when rolloutTargets were last restarted
+	// +optional
+	LastRolloutAt *metav1.Time `json:"lastRolloutAt,omitempty"`
+
+	// RolledOutWorkloads lists workloads patched in the last rollout (e.g., "Deployment/api")
+	// +optional
+	RolledOutWorkloads []string `json:"rolledOutWorkloads,omitempty"`
+
+	// PendingRolloutAt is set when the password changed and rolloutTargets have not been
+	// restarted yet; the restart is retried until it succeeds
+	// +optional
+	PendingRolloutAt *metav1.Time `json:"pendingRolloutAt,omitempty"`
+
+	// SecretStore reports the external secret store sync (when spec.secretStore is set)
+	// +optional
+	SecretStore *SecretStoreStatus `json:"secretStore,omitempty"`
+
+	// RoleAttributes lists the privileged role attributes currently granted
+	// +optional
+	RoleAttributes []string `json:"roleAttributes,omitempty"`
+
+	// RoleAttributesPolicyHash identifies the DBCluster allowedRoleAttributes last applied, so a
+	// narrowed allow-list is revoked even from users that are otherwise up to date
+	// +optional
+	RoleAttributesPolicyHash string `json:"roleAttributesPolicyHash,omitempty"`
+
+	// ValidUntil is the password expiry applied to the role
+	// +optional
+	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+
+	// Deletion reports per-database cleanup progress while the role is being dropped
+	// +optional
+	Deletion []DatabaseCleanupStatus `json:"deletion,omitempty"`
+
+	// Conditions: RoleAttributesAllowed when spec.roleAttributes requests attributes
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DatabaseCleanupStatus reports REASSIGN OWNED / DROP OWNED progress in one database
+type DatabaseCleanupStatus struct {
+	// DatabaseName is the PostgreSQL database name
+	DatabaseName string `json:"databaseName"`
+
+	// Phase: Pending, Done or Failed
+	// +kubebuilder:validation:Enum=Pending;Done;Failed
+	Phase string `json:"phase,omitempty"`
+
+	// ReassignedTo is the role that received the owned objects
+	// +optional
+	ReassignedTo string `json:"reassignedTo,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // DatabaseAccessStatus represents the status of access to a single database
@@ -19,6 +19,46 @@ type DBClusterSpec struct {
 
 	// +optional
 	CredentialsFromEnv *CredentialsFromEnv `json:"credentialsFromEnv,omitempty"`
+
+	// AllowedRoleAttributes lists the privileged role attributes DatabaseUsers on this
+	// cluster may request via spec.roleAttributes. Empty means none are allowed.
+	// +optional
+	// +kubebuilder:validation:items:Enum=Replication;BypassRLS;CreateDB;CreateRole
+	AllowedRoleAttributes []string `json:"allowedRoleAttributes,omitempty"`
+
+	// PasswordEncryption controls how role passwords are sent to the server
+	// +optional
+	PasswordEncryption *PasswordEncryptionConfig `json:"passwordEncryption,omitempty"`
+
+	// Hooks allows pre/post hooks on backups and restores of this cluster's Databases.
+	// Empty means Backups and Restores with hooks fail.
+	// +optional
+	Hooks *HookPolicy `json:"hooks,omitempty"`
+}
+
+// Password encryption modes
+const (
+	PasswordEncryptionClient = "client"
+	PasswordEncryptionServer = "server"
+)
+
+type PasswordEncryptionConfig struct {
+	// Mode of sending passwords in CREATE/ALTER ROLE:
+	// - client (default): the operator computes the SCRAM-SHA-256 verifier, so the plaintext
+	//   password never reaches the server or its logs (requires PostgreSQL 10+)
+	// - server: the plaintext password is sent and hashed according to the server's password_encryption
+	// +kubebuilder:validation:Enum=client;server
+	// +kubebuilder:default=client
+	Mode string `json:"mode,omitempty"`
+
+	// RequireSCRAM fails the connection check unless the server's password_encryption is scram-sha-256
+	// +optional
+	RequireSCRAM bool `json:"requireSCRAM,omitempty"`
+}
+
+// ServerSide reports whether plaintext passwords are sent for the server to hash
+func (p *PasswordEncryptionConfig) ServerSide() bool {
+	return p != nil && p.Mode == PasswordEncryptionServer
 }
 
 type SecretReference struct {
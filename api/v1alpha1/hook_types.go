@@ -0,0 +1,117 @@
+package v1alpha1
+
+import (
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Hook runs SQL against the target database or sends an HTTP request before or after
+// a backup/restore. Exactly one of sql and http must be set.
+type Hook struct {
+	// Name identifies the hook in status and logs
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// SQL run against the target database (e.g. "SELECT pg_catalog.pg_switch_wal()")
+	// +optional
+	SQL string `json:"sql,omitempty"`
+
+	// HTTP request; any 2xx response is a success
+	// +optional
+	HTTP *HTTPHook `json:"http,omitempty"`
+
+	// Maximum runtime of the hook (default: 30s)
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Abort fails the backup/restore when the hook fails; Continue only records the failure
+	// +kubebuilder:validation:Enum=Abort;Continue
+	// +kubebuilder:default=Abort
+	// +optional
+	OnFailure string `json:"onFailure,omitempty"`
+}
+
+type HTTPHook struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// +kubebuilder:validation:Enum=GET;POST;PUT;PATCH;DELETE
+	// +kubebuilder:default=POST
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// Request headers (stored in plain text in the CR, do not put credentials here)
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Request body
+	// +optional
+	Body string `json:"body,omitempty"`
+}
+
+// HookPolicy is the cluster admin's opt-in for hooks on backups and restores of a DBCluster's
+// Databases. Without it, Backups and Restores with hooks fail.
+type HookPolicy struct {
+	// AllowSQL allows SQL hooks. They run as a per-Job role with readwrite privileges on the
+	// target database only, never with the cluster admin credentials.
+	// +optional
+	AllowSQL bool `json:"allowSQL,omitempty"`
+
+	// AllowedHTTPHosts lists the hosts HTTP hooks may call, as "host", "host:port" or "*.domain".
+	// Empty means HTTP hooks are not allowed. Redirects to other hosts are refused.
+	// +optional
+	AllowedHTTPHosts []string `json:"allowedHTTPHosts,omitempty"`
+}
+
+// AllowsHTTPHost reports whether an HTTP hook may call host ("host" or "host:port" of the URL)
+func (p *HookPolicy) AllowsHTTPHost(host string) bool {
+	if p == nil || host == "" {
+		return false
+	}
+	return MatchHTTPHost(p.AllowedHTTPHosts, host)
+}
+
+// MatchHTTPHost reports whether host ("host" or "host:port") matches one of the allowed entries.
+// An entry without a port matches any port; "*.domain" matches subdomains of domain.
+func MatchHTTPHost(allowed []string, host string) bool {
+	host = strings.ToLower(host)
+	hostname := host
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+		hostname = host[:i]
+	}
+	hostname = strings.Trim(hostname, "[]")
+
+	return slices.ContainsFunc(allowed, func(entry string) bool {
+		entry = strings.ToLower(entry)
+		if suffix, ok := strings.CutPrefix(entry, "*."); ok {
+			return strings.HasSuffix(hostname, "."+suffix)
+		}
+		return entry == host || strings.Trim(entry, "[]") == hostname
+	})
+}
+
+// HookResult is the outcome of one hook, reported by the Job
+type HookResult struct {
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Enum=pre;post
+	Stage string `json:"stage"`
+
+	// +kubebuilder:validation:Enum=Succeeded;Failed;Skipped
+	Phase string `json:"phase"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// +optional
+	Duration string `json:"duration,omitempty"`
+}
+
+// AbortsOnFailure returns true unless onFailure is Continue
+func (h *Hook) AbortsOnFailure() bool {
+	return h.OnFailure != "Continue"
+}
@@ -0,0 +1,118 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// JobTemplate customizes the pods of backup and restore Jobs.
+// Set by cluster admins as operator defaults, on BackupStorage or on ClusterBackupSchedule; the most
+// specific value wins (operator defaults < BackupStorage < ClusterBackupSchedule < JobOverrides).
+type JobTemplate struct {
+	// Resource requests and limits of the backup/restore container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// ServiceAccount of the Job pod (default: dbtether, the operator's SA for IRSA/Workload Identity)
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Pod-level security context
+	// +optional
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// Annotations added to the Job pod (merged across levels)
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Retries before the Job is marked failed (default: 3 for backups, 0 for restores)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// Maximum runtime of the Job in seconds
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+}
+
+// JobOverrides are the Job settings namespaced resources (Backup, BackupSchedule, Restore) may set.
+// Pod identity and placement (serviceAccountName, securityContext, nodeSelector, tolerations, affinity)
+// and annotations only come from a JobTemplate.
+type JobOverrides struct {
+	// Resource requests and limits of the backup/restore container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Retries before the Job is marked failed (default: 3 for backups, 0 for restores)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// Maximum runtime of the Job in seconds
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+}
+
+// JobTemplate returns the overrides as a JobTemplate for MergeJobTemplates (nil for nil overrides)
+func (o *JobOverrides) JobTemplate() *JobTemplate {
+	if o == nil {
+		return nil
+	}
+	return &JobTemplate{
+		Resources:             o.Resources,
+		BackoffLimit:          o.BackoffLimit,
+		ActiveDeadlineSeconds: o.ActiveDeadlineSeconds,
+	}
+}
+
+// MergeJobTemplates combines templates from least to most specific. Set fields of later
+// templates replace earlier ones; annotations are merged key by key. Nil templates are skipped.
+func MergeJobTemplates(templates ...*JobTemplate) JobTemplate {
+	var merged JobTemplate
+	for _, t := range templates {
+		if t == nil {
+			continue
+		}
+		if t.Resources != nil {
+			merged.Resources = t.Resources
+		}
+		if len(t.NodeSelector) > 0 {
+			merged.NodeSelector = t.NodeSelector
+		}
+		if len(t.Tolerations) > 0 {
+			merged.Tolerations = t.Tolerations
+		}
+		if t.Affinity != nil {
+			merged.Affinity = t.Affinity
+		}
+		if t.ServiceAccountName != "" {
+			merged.ServiceAccountName = t.ServiceAccountName
+		}
+		if t.SecurityContext != nil {
+			merged.SecurityContext = t.SecurityContext
+		}
+		for k, v := range t.Annotations {
+			if merged.Annotations == nil {
+				merged.Annotations = map[string]string{}
+			}
+			merged.Annotations[k] = v
+		}
+		if t.BackoffLimit != nil {
+			merged.BackoffLimit = t.BackoffLimit
+		}
+		if t.ActiveDeadlineSeconds != nil {
+			merged.ActiveDeadlineSeconds = t.ActiveDeadlineSeconds
+		}
+	}
+	return merged
+}
@@ -0,0 +1,155 @@
+package v1alpha1
+
+import (
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Notification event reasons
+const (
+	EventBackupCompleted     = "BackupCompleted"
+	EventBackupFailed        = "BackupFailed"
+	EventRestoreCompleted    = "RestoreCompleted"
+	EventRestoreFailed       = "RestoreFailed"
+	EventPasswordRotated     = "PasswordRotated"
+	EventClusterDisconnected = "ClusterDisconnected"
+	EventClusterConnected    = "ClusterConnected"
+)
+
+// NotifyAnnotation subscribes a single resource to channels (comma-separated channel names)
+const NotifyAnnotation = "dbtether.io/notify"
+
+type NotificationChannelSpec struct {
+	// Payload format
+	// - webhook: dbtether JSON event
+	// - slack: Slack incoming webhook message (also Mattermost, Rocket.Chat)
+	// - cloudevents: CloudEvents 1.0 structured JSON
+	// +kubebuilder:validation:Enum=webhook;slack;cloudevents
+	// +kubebuilder:validation:Required
+	Type string `json:"type"`
+
+	// Endpoint URL. Use urlSecretRef instead when the URL contains a token.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Secret with the endpoint URL under the key "url"
+	// +optional
+	URLSecretRef *SecretReference `json:"urlSecretRef,omitempty"`
+
+	// HTTP headers sent with every notification
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Events delivered to this channel (empty: all)
+	// +kubebuilder:validation:items:Enum=BackupCompleted;BackupFailed;RestoreCompleted;RestoreFailed;PasswordRotated;ClusterDisconnected;ClusterConnected
+	// +optional
+	Events []string `json:"events,omitempty"`
+
+	// Resources whose events are delivered. An event is delivered when any subscription matches
+	// (empty: all resources). Resources in allowedNamespaces can also opt in with the
+	// dbtether.io/notify annotation.
+	// +optional
+	Subscriptions []NotificationSubscription `json:"subscriptions,omitempty"`
+
+	// Namespaces whose resources may opt in to this channel with the dbtether.io/notify annotation
+	// ("*": all namespaces; empty: none). Cluster-scoped resources can always opt in.
+	// +optional
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+
+	// Suspend stops deliveries
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// NotificationSubscription selects resources; all set fields must match
+type NotificationSubscription struct {
+	// Resource kinds (Backup, Restore, DatabaseUser, DBCluster; empty: all)
+	// +kubebuilder:validation:items:Enum=Backup;Restore;DatabaseUser;DBCluster
+	// +optional
+	Kinds []string `json:"kinds,omitempty"`
+
+	// Namespaces of the resources (empty: all; cluster-scoped DBClusters never match a namespace list)
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Resource names (empty: all)
+	// +optional
+	Names []string `json:"names,omitempty"`
+
+	// Resource labels
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// Deliver returns true if the channel wants events of this reason
+func (s *NotificationChannelSpec) Deliver(reason string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsOptIn returns true if resources in namespace may subscribe to the channel with the
+// dbtether.io/notify annotation
+func (s *NotificationChannelSpec) AllowsOptIn(namespace string) bool {
+	return namespace == "" || slices.Contains(s.AllowedNamespaces, namespace) || slices.Contains(s.AllowedNamespaces, "*")
+}
+
+type NotificationChannelStatus struct {
+	// +kubebuilder:validation:Enum=Ready;Failed
+	Phase string `json:"phase,omitempty"`
+
+	Message string `json:"message,omitempty"`
+
+	// Notifications delivered to this channel
+	Delivered int64 `json:"delivered,omitempty"`
+
+	// Notifications dropped after all retries failed
+	Failed int64 `json:"failed,omitempty"`
+
+	LastDeliveryTime *metav1.Time `json:"lastDeliveryTime,omitempty"`
+
+	// Error of the last failed delivery
+	LastError string `json:"lastError,omitempty"`
+
+	LastErrorTime *metav1.Time `json:"lastErrorTime,omitempty"`
+
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=nch
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Delivered",type=integer,JSONPath=`.status.delivered`
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.failed`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NotificationChannel delivers operator events (backup failures, password rotations, ...) to an HTTP endpoint
+type NotificationChannel struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NotificationChannelSpec   `json:"spec,omitempty"`
+	Status NotificationChannelStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type NotificationChannelList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NotificationChannel `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NotificationChannel{}, &NotificationChannelList{})
+}
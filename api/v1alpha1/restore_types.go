@@ -14,8 +14,14 @@ type RestoreSource struct {
 	// +optional
 	LatestFrom *LatestFromSource `json:"latestFrom,omitempty"`
 
+	// Reference to a BackupArtifact, which stays available after its Backup was deleted
+	// +optional
+	ArtifactRef *ArtifactReference `json:"artifactRef,omitempty"`
+
 	// Direct path to backup file in storage (e.g., "cluster/database/20260120-140000.sql.gz")
 	// Requires storageRef to be set
+	// The path must belong to a Backup or BackupArtifact shared with the Restore's namespace;
+	// unrecorded paths are only allowed for Restores in the operator namespace
 	// +optional
 	Path string `json:"path,omitempty"`
 
@@ -45,6 +51,16 @@ type BackupReference struct {
 	Namespace string `json:"namespace,omitempty"`
 }
 
+// ArtifactReference references a BackupArtifact resource
+type ArtifactReference struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace of the BackupArtifact (defaults to same namespace as Restore)
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
 // RestoreTarget specifies where to restore to
 type RestoreTarget struct {
 	// Reference to the Database to restore into
@@ -52,6 +68,12 @@ type RestoreTarget struct {
 	DatabaseRef DatabaseReference `json:"databaseRef"`
 }
 
+// Restore replica fallback modes
+const (
+	ReplicaFallbackNotFound = "NotFound"
+	ReplicaFallbackAny      = "Any"
+)
+
 // RestoreSpec defines the desired state of Restore
 type RestoreSpec struct {
 	// Source of the backup to restore from
@@ -71,9 +93,29 @@ type RestoreSpec struct {
 	// +optional
 	OnConflict string `json:"onConflict,omitempty"`
 
+	// When replicas of the source backup are tried
+	// - NotFound: only when the backup file is missing from the primary storage (default)
+	// - Any: on any primary download error, e.g. an unreachable storage or denied credentials
+	// +kubebuilder:validation:Enum=NotFound;Any
+	// +kubebuilder:default=NotFound
+	// +optional
+	ReplicaFallback string `json:"replicaFallback,omitempty"`
+
 	// Auto-delete after completion
 	// +optional
 	TTLAfterCompletion *metav1.Duration `json:"ttlAfterCompletion,omitempty"`
+
+	// Overrides for the restore Job (resources, retries, deadline)
+	// +optional
+	JobTemplate *JobOverrides `json:"jobTemplate,omitempty"`
+
+	// Hooks run in the restore Job before the restore, in order
+	// +optional
+	PreHooks []Hook `json:"preHooks,omitempty"`
+
+	// Hooks run in the restore Job after the restore, also when the restore failed
+	// +optional
+	PostHooks []Hook `json:"postHooks,omitempty"`
 }
 
 // RestoreStatus defines the observed state of Restore
@@ -94,12 +136,24 @@ type RestoreStatus struct {
 	// Path of the backup file being restored
 	SourcePath string `json:"sourcePath,omitempty"`
 
+	// BackupStorage replica the backup was restored from, when the primary object was unavailable
+	// +optional
+	SourceReplica string `json:"sourceReplica,omitempty"`
+
+	// Error of the primary storage download when the backup was restored from a replica
+	// +optional
+	PrimaryError string `json:"primaryError,omitempty"`
+
 	// Duration of the restore operation
 	Duration string `json:"duration,omitempty"`
 
 	// RunID is a unique identifier for this restore run
 	RunID string `json:"runId,omitempty"`
 
+	// Results of pre/post hooks, reported by the Job
+	// +optional
+	Hooks []HookResult `json:"hooks,omitempty"`
+
 	StartedAt   *metav1.Time `json:"startedAt,omitempty"`
 	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
 
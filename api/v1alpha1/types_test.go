@@ -47,6 +47,24 @@ func TestBackupStorage_GetProvider(t *testing.T) {
 			},
 			expected: "azure",
 		},
+		{
+			name: "PVC provider",
+			storage: BackupStorage{
+				Spec: BackupStorageSpec{
+					PVC: &PVCStorageConfig{ClaimName: "backups"},
+				},
+			},
+			expected: "pvc",
+		},
+		{
+			name: "SFTP provider",
+			storage: BackupStorage{
+				Spec: BackupStorageSpec{
+					SFTP: &SFTPStorageConfig{Host: "sftp.example.com", User: "backup", Path: "/srv/backups"},
+				},
+			},
+			expected: "sftp",
+		},
 		{
 			name:     "No provider configured",
 			storage:  BackupStorage{},
@@ -125,6 +143,18 @@ func TestDatabaseUserSpec_DeletionPolicyDefaults(t *testing.T) {
 	}
 }
 
+func TestRoleAttributes_Enabled(t *testing.T) {
+	var nilAttrs *RoleAttributes
+	assert.Empty(t, nilAttrs.Enabled())
+
+	attrs := &RoleAttributes{Replication: true, CreateRole: true}
+	assert.Equal(t, []string{RoleAttributeReplication, RoleAttributeCreateRole}, attrs.Enabled())
+
+	// ValidUntil is not a privileged attribute
+	attrs = &RoleAttributes{ValidUntilRotation: true}
+	assert.Empty(t, attrs.Enabled())
+}
+
 func TestBackupSpec_StorageRef(t *testing.T) {
 	backup := Backup{
 		Spec: BackupSpec{
@@ -235,3 +265,144 @@ func TestRestoreSource_Priority(t *testing.T) {
 		assert.True(t, hasLatestFrom)
 	})
 }
+
+func TestPasswordEncryptionConfig_ServerSide(t *testing.T) {
+	var unset *PasswordEncryptionConfig
+	if unset.ServerSide() {
+		t.Error("nil config should default to client-side SCRAM")
+	}
+	if (&PasswordEncryptionConfig{Mode: PasswordEncryptionClient}).ServerSide() {
+		t.Error("client mode should not be server-side")
+	}
+	if !(&PasswordEncryptionConfig{Mode: PasswordEncryptionServer}).ServerSide() {
+		t.Error("server mode should be server-side")
+	}
+}
+
+func TestUserDeletionConfig_Reassign(t *testing.T) {
+	var unset *UserDeletionConfig
+	if unset.Reassign() {
+		t.Error("nil config should default to drop")
+	}
+	if (&UserDeletionConfig{Strategy: UserDeletionStrategyDrop, ReassignTo: "app_owner"}).Reassign() {
+		t.Error("drop strategy should not reassign even when reassignTo is set")
+	}
+	if !(&UserDeletionConfig{Strategy: UserDeletionStrategyReassign}).Reassign() {
+		t.Error("reassignTo strategy should reassign")
+	}
+}
+
+func TestDatabaseReferenceGrant_Allows(t *testing.T) {
+	grant := &DatabaseReferenceGrant{
+		Spec: DatabaseReferenceGrantSpec{
+			From: []ReferenceGrantFrom{
+				{Namespace: "analytics", Purposes: []ReferencePurpose{ReferencePurposeUserAccess}},
+				{Namespace: "staging", Purposes: []ReferencePurpose{ReferencePurposeRestoreSource}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		from    string
+		purpose ReferencePurpose
+		kind    string
+		target  string
+		want    bool
+	}{
+		{"allowed namespace and purpose", "analytics", ReferencePurposeUserAccess, ReferenceKindDatabase, "main-db", true},
+		{"purpose not granted", "analytics", ReferencePurposeRestoreSource, ReferenceKindBackup, "nightly", false},
+		{"other namespace", "team-beta", ReferencePurposeUserAccess, ReferenceKindDatabase, "main-db", false},
+		{"restore source", "staging", ReferencePurposeRestoreSource, ReferenceKindBackup, "nightly", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, grant.Allows(tt.from, tt.purpose, tt.kind, tt.target))
+		})
+	}
+
+	t.Run("to limits resources", func(t *testing.T) {
+		limited := grant.DeepCopy()
+		limited.Spec.To = []ReferenceGrantTo{
+			{Kind: ReferenceKindDatabase, Name: "main-db"},
+			{Kind: ReferenceKindBackup},
+		}
+		assert.True(t, limited.Allows("analytics", ReferencePurposeUserAccess, ReferenceKindDatabase, "main-db"))
+		assert.False(t, limited.Allows("analytics", ReferencePurposeUserAccess, ReferenceKindDatabase, "billing-db"))
+		assert.True(t, limited.Allows("staging", ReferencePurposeRestoreSource, ReferenceKindBackup, "any-backup"))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		list := &DatabaseReferenceGrantList{Items: []DatabaseReferenceGrant{*grant}}
+		assert.True(t, list.Allows("analytics", ReferencePurposeUserAccess, ReferenceKindDatabase, "main-db"))
+		assert.False(t, (&DatabaseReferenceGrantList{}).Allows("analytics", ReferencePurposeUserAccess, ReferenceKindDatabase, "main-db"))
+	})
+}
+
+func TestMergeJobTemplates(t *testing.T) {
+	one, three := int32(1), int32(3)
+	defaults := &JobTemplate{
+		ServiceAccountName: "backup-sa",
+		NodeSelector:       map[string]string{"pool": "default"},
+		Annotations:        map[string]string{"team": "platform"},
+		BackoffLimit:       &three,
+	}
+	storage := &JobTemplate{ServiceAccountName: "s3-writer", Annotations: map[string]string{"bucket": "prod"}}
+	backup := &JobTemplate{NodeSelector: map[string]string{"pool": "backup"}, BackoffLimit: &one}
+
+	merged := MergeJobTemplates(defaults, storage, nil, backup)
+
+	assert.Equal(t, "s3-writer", merged.ServiceAccountName)
+	assert.Equal(t, map[string]string{"pool": "backup"}, merged.NodeSelector)
+	assert.Equal(t, map[string]string{"team": "platform", "bucket": "prod"}, merged.Annotations)
+	assert.Equal(t, int32(1), *merged.BackoffLimit)
+	assert.Nil(t, merged.ActiveDeadlineSeconds)
+	assert.Equal(t, map[string]string{"team": "platform"}, defaults.Annotations, "inputs must not be modified")
+
+	assert.Equal(t, JobTemplate{}, MergeJobTemplates())
+
+	var noOverrides *JobOverrides
+	assert.Nil(t, noOverrides.JobTemplate())
+	overrides := MergeJobTemplates(storage, (&JobOverrides{BackoffLimit: &one}).JobTemplate())
+	assert.Equal(t, "s3-writer", overrides.ServiceAccountName, "overrides keep the storage identity")
+	assert.Equal(t, int32(1), *overrides.BackoffLimit)
+}
+
+func TestNotificationChannelSpec_Deliver(t *testing.T) {
+	all := &NotificationChannelSpec{}
+	if !all.Deliver(EventPasswordRotated) {
+		t.Error("empty events should deliver everything")
+	}
+	failures := &NotificationChannelSpec{Events: []string{EventBackupFailed, EventRestoreFailed}}
+	if !failures.Deliver(EventRestoreFailed) {
+		t.Error("listed event should be delivered")
+	}
+	if failures.Deliver(EventBackupCompleted) {
+		t.Error("unlisted event should not be delivered")
+	}
+}
+
+func TestHookPolicy_AllowsHTTPHost(t *testing.T) {
+	policy := &HookPolicy{AllowedHTTPHosts: []string{"consumer.team-a.svc", "hooks.example.com:8443", "*.internal.example.com"}}
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"consumer.team-a.svc", true},
+		{"consumer.team-a.svc:8080", true},
+		{"CONSUMER.team-a.svc", true},
+		{"hooks.example.com:8443", true},
+		{"hooks.example.com", false},
+		{"api.internal.example.com", true},
+		{"internal.example.com", false},
+		{"169.254.169.254", false},
+		{"consumer.team-a.svc.evil.com", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, policy.AllowsHTTPHost(tt.host), tt.host)
+	}
+
+	var none *HookPolicy
+	assert.False(t, none.AllowsHTTPHost("consumer.team-a.svc"))
+}
@@ -0,0 +1,329 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
+	"github.com/certainty3452/dbtether/pkg/storage"
+)
+
+// DefaultArtifactSyncInterval is how often each storage is listed for the BackupArtifact inventory
+const DefaultArtifactSyncInterval = 15 * time.Minute
+
+// artifactSyncTimeout bounds the sync of one storage
+const artifactSyncTimeout = 5 * time.Minute
+
+// Label keys for BackupArtifacts
+const (
+	LabelStorage  = "dbtether.io/storage"
+	LabelDatabase = "dbtether.io/database"
+)
+
+// maxFailedKeysInMessage limits how many files that could not be inventoried are named in the status
+const maxFailedKeysInMessage = 3
+
+// StorageOpenFunc creates a client for a storage with the keys of its credentials secret; close
+// releases it
+type StorageOpenFunc func(ctx context.Context, bs *databasesv1alpha1.BackupStorage,
+	credentials map[string][]byte) (storage.StorageClient, func(), error)
+
+// BackupArtifactReconciler lists BackupStorages and keeps one BackupArtifact per backup file, so
+// backups stay restorable by name after their Backup resource is gone
+type BackupArtifactReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Namespace of the operator: credentials secrets are read from it, and artifacts of backups
+	// whose namespace no longer exists are created in it
+	Namespace string
+
+	// Interval between two syncs of a storage (default: DefaultArtifactSyncInterval)
+	Interval time.Duration
+
+	// OpenStorage creates the storage client; nil uses the provider's client
+	OpenStorage StorageOpenFunc
+}
+
+// +kubebuilder:rbac:groups=dbtether.io,resources=backupartifacts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dbtether.io,resources=backupstorages,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbtether.io,resources=backupstorages/status,verbs=get;update;patch
+
+func (r *BackupArtifactReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	// Artifacts of a deleted storage are garbage collected through their owner reference
+	var bs databasesv1alpha1.BackupStorage
+	if err := r.Get(ctx, req.NamespacedName, &bs); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// The storage controller updates the status when the storage becomes Ready
+	if bs.Status.Phase != "Ready" {
+		return ctrl.Result{}, nil
+	}
+	if wait := r.syncDue(&bs, time.Now()); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	original := bs.DeepCopy()
+	condition := metav1.Condition{Type: databasesv1alpha1.StorageConditionInventoried, ObservedGeneration: bs.Generation}
+	var count int32
+	var message string
+	if reason := operatorAccessSkipReason(&bs); reason != "" {
+		message = fmt.Sprintf("not synced: %s", reason)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = databasesv1alpha1.StorageReasonNotSupported
+		condition.Message = fmt.Sprintf("backups are not inventoried as BackupArtifacts because %s; once its Backup "+
+			"is deleted, a backup can only be restored with source.path from namespace %s", reason, r.Namespace)
+	} else {
+		var err error
+		count, message, err = r.sync(ctx, &bs)
+		if err != nil {
+			logger.Info("backup artifact sync failed", "storage", bs.Name, "error", err.Error())
+			message = fmt.Sprintf("sync failed: %v", err)
+			if bs.Status.Artifacts != nil {
+				count = bs.Status.Artifacts.Count
+			}
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = databasesv1alpha1.StorageReasonSyncFailed
+			condition.Message = err.Error()
+		} else {
+			logger.V(1).Info("backup artifacts synced", "storage", bs.Name, "count", count)
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = databasesv1alpha1.StorageReasonSynced
+			condition.Message = message
+		}
+	}
+	meta.SetStatusCondition(&bs.Status.Conditions, condition)
+
+	return r.updateStatus(ctx, original, &bs, count, message)
+}
+
+// syncDue returns how long until the storage must be synced again; zero or less means now
+func (r *BackupArtifactReconciler) syncDue(bs *databasesv1alpha1.BackupStorage, now time.Time) time.Duration {
+	status := bs.Status.Artifacts
+	if status == nil || status.ObservedGeneration != bs.Generation || status.LastSyncTime.IsZero() {
+		return 0
+	}
+	return status.LastSyncTime.Add(r.interval()).Sub(now)
+}
+
+func (r *BackupArtifactReconciler) interval() time.Duration {
+	if r.Interval > 0 {
+		return r.Interval
+	}
+	return DefaultArtifactSyncInterval
+}
+
+// sync lists the storage under the static part of its pathTemplate and creates, replaces or deletes
+// artifacts to match the backup files. Only files tagged by a backup Job are inventoried; tags are
+// only read for files that are new or changed.
+func (r *BackupArtifactReconciler) sync(ctx context.Context, bs *databasesv1alpha1.BackupStorage) (
+	count int32, message string, err error) {
+
+	credentials, err := storageCredentials(ctx, r.Client, r.Namespace, bs)
+	if err != nil {
+		return 0, "", err
+	}
+	open := r.OpenStorage
+	if open == nil {
+		open = openStorageClient
+	}
+	ctx, cancel := context.WithTimeout(ctx, artifactSyncTimeout)
+	defer cancel()
+	storageClient, closeClient, err := open(ctx, bs, credentials)
+	if err != nil {
+		return 0, "", err
+	}
+	defer closeClient()
+
+	objects, err := storageClient.List(ctx, pkgbackup.StaticPrefix(bs.Spec.PathTemplate))
+	if err != nil {
+		return 0, "", err
+	}
+
+	var existing databasesv1alpha1.BackupArtifactList
+	if err := r.List(ctx, &existing, client.MatchingLabels{LabelStorage: bs.Name}); err != nil {
+		return 0, "", fmt.Errorf("failed to list backup artifacts: %w", err)
+	}
+	byName := make(map[string]*databasesv1alpha1.BackupArtifact, len(existing.Items))
+	for i := range existing.Items {
+		byName[existing.Items[i].Name] = &existing.Items[i]
+	}
+
+	logger := log.FromContext(ctx)
+	var failed []string
+	synced := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		name := artifactName(bs.Name, object.Key)
+		current := byName[name]
+		if current != nil && artifactMatches(current, bs.Name, object) {
+			synced[name] = true
+			continue
+		}
+
+		tags, err := storageClient.ReadTags(ctx, object.Key)
+		if err != nil {
+			logger.Info("failed to read backup tags", "storage", bs.Name, "key", object.Key, "error", err.Error())
+			failed = append(failed, object.Key)
+			if current != nil {
+				synced[name] = true
+			}
+			continue
+		}
+		if tags == nil || tags.CreatedBy != "dbtether" {
+			continue
+		}
+
+		// The file was replaced; the spec is read-only, so the artifact is recreated
+		if current != nil {
+			if err := r.Delete(ctx, current); client.IgnoreNotFound(err) != nil {
+				failed = append(failed, object.Key)
+				synced[name] = true
+				continue
+			}
+		}
+		if err := r.createArtifact(ctx, bs, name, object, tags); err != nil {
+			logger.Info("failed to create backup artifact", "storage", bs.Name, "key", object.Key, "error", err.Error())
+			failed = append(failed, object.Key)
+			continue
+		}
+		synced[name] = true
+	}
+
+	for name, artifact := range byName {
+		if synced[name] {
+			continue
+		}
+		if err := r.Delete(ctx, artifact); client.IgnoreNotFound(err) != nil {
+			return 0, "", fmt.Errorf("failed to delete backup artifact %s/%s: %w", artifact.Namespace, name, err)
+		}
+	}
+
+	count = int32(len(synced))
+	if len(failed) > 0 {
+		shown := failed[:min(len(failed), maxFailedKeysInMessage)]
+		message = fmt.Sprintf("%d files not inventoried: %s", len(failed), strings.Join(shown, ", "))
+		if len(failed) > len(shown) {
+			message += ", ..."
+		}
+	}
+	return count, message, nil
+}
+
+// createArtifact creates the artifact in the namespace of the Backup that wrote the file, or in the
+// operator namespace when the file has no namespace tag or the namespace no longer exists
+func (r *BackupArtifactReconciler) createArtifact(ctx context.Context, bs *databasesv1alpha1.BackupStorage,
+	name string, object storage.StorageObject, tags *storage.ObjectTags) error {
+
+	namespace := tags.Namespace
+	if namespace == "" {
+		namespace = r.Namespace
+	}
+	artifact := &databasesv1alpha1.BackupArtifact{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    artifactLabels(bs.Name, tags),
+		},
+		Spec: databasesv1alpha1.BackupArtifactSpec{
+			StorageRef:      databasesv1alpha1.StorageReference{Name: bs.Name},
+			Path:            object.Key,
+			Size:            object.Size,
+			LastModified:    metav1.NewTime(object.LastModified),
+			Cluster:         tags.Cluster,
+			Database:        tags.Database,
+			BackupName:      tags.BackupName,
+			BackupNamespace: tags.Namespace,
+			Timestamp:       tags.Timestamp,
+			Compression:     tags.Compression,
+		},
+	}
+	if err := controllerutil.SetOwnerReference(bs, artifact, r.Scheme); err != nil {
+		return err
+	}
+
+	err := r.Create(ctx, artifact)
+	if errors.IsNotFound(err) && namespace != r.Namespace {
+		artifact.Namespace = r.Namespace
+		artifact.ResourceVersion = ""
+		err = r.Create(ctx, artifact)
+	}
+	return err
+}
+
+// artifactMatches reports whether an artifact still describes the listed file
+func artifactMatches(artifact *databasesv1alpha1.BackupArtifact, storageName string, object storage.StorageObject) bool {
+	spec := &artifact.Spec
+	return spec.StorageRef.Name == storageName &&
+		spec.Path == object.Key &&
+		spec.Size == object.Size &&
+		spec.LastModified.Unix() == object.LastModified.Unix()
+}
+
+// artifactName is stable for a file: the storage name, shortened so the name fits a label value,
+// and a hash of storage and key
+func artifactName(storageName, key string) string {
+	sum := sha256.Sum256([]byte(storageName + "/" + key))
+	prefix := storageName
+	if len(prefix) > 52 {
+		prefix = strings.TrimRight(prefix[:52], "-.")
+	}
+	return fmt.Sprintf("%s-%s", prefix, hex.EncodeToString(sum[:5]))
+}
+
+// artifactLabels allow selecting artifacts by storage, cluster, database and Backup. Tag values that
+// are not valid label values (e.g. long database names) are left out.
+func artifactLabels(storageName string, tags *storage.ObjectTags) map[string]string {
+	labels := map[string]string{LabelStorage: storageName}
+	for key, value := range map[string]string{
+		LabelCluster:    tags.Cluster,
+		LabelDatabase:   tags.Database,
+		LabelBackupName: tags.BackupName,
+	} {
+		if value != "" && len(validation.IsValidLabelValue(value)) == 0 {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// updateStatus records the sync in status.artifacts; original is the storage as read
+func (r *BackupArtifactReconciler) updateStatus(ctx context.Context, original, bs *databasesv1alpha1.BackupStorage,
+	count int32, message string) (ctrl.Result, error) {
+
+	patch := client.MergeFrom(original)
+	bs.Status.Artifacts = &databasesv1alpha1.ArtifactSyncStatus{
+		Count:              count,
+		LastSyncTime:       metav1.Now(),
+		ObservedGeneration: bs.Generation,
+		Message:            message,
+	}
+	if err := r.Status().Patch(ctx, bs, patch); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.interval()}, nil
+}
+
+func (r *BackupArtifactReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("backupartifact").
+		For(&databasesv1alpha1.BackupStorage{}).
+		Complete(r)
+}
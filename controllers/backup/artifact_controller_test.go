@@ -0,0 +1,250 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/storage"
+)
+
+// artifactSyncer reconciles a storage against a mock client and counts how often it is opened
+type artifactSyncer struct {
+	r      *BackupArtifactReconciler
+	store  *storage.MockClient
+	opened int
+}
+
+func newArtifactSyncer(t *testing.T, objs ...client.Object) *artifactSyncer {
+	t.Helper()
+	s := &artifactSyncer{store: storage.NewMockClient()}
+	s.r = &BackupArtifactReconciler{
+		Client: fake.NewClientBuilder().WithScheme(newTestScheme()).
+			WithObjects(objs...).
+			WithStatusSubresource(&databasesv1alpha1.BackupStorage{}).
+			Build(),
+		Scheme:    newTestScheme(),
+		Namespace: testOperatorNS,
+		OpenStorage: func(_ context.Context, _ *databasesv1alpha1.BackupStorage,
+			_ map[string][]byte) (storage.StorageClient, func(), error) {
+			s.opened++
+			return s.store, func() {}, nil
+		},
+	}
+	return s
+}
+
+func (s *artifactSyncer) upload(t *testing.T, key, data string, tags *storage.ObjectTags) {
+	t.Helper()
+	require.NoError(t, s.store.UploadWithTags(context.Background(), key, bytes.NewReader([]byte(data)), tags))
+}
+
+// sync reconciles the storage as if its last sync was due and returns the storage and its artifacts
+func (s *artifactSyncer) sync(t *testing.T) (*databasesv1alpha1.BackupStorage, []databasesv1alpha1.BackupArtifact) {
+	t.Helper()
+	ctx := context.Background()
+	key := types.NamespacedName{Name: testStorageName}
+
+	var bs databasesv1alpha1.BackupStorage
+	require.NoError(t, s.r.Get(ctx, key, &bs))
+	if bs.Status.Artifacts != nil {
+		original := bs.DeepCopy()
+		bs.Status.Artifacts.LastSyncTime = metav1.NewTime(time.Now().Add(-DefaultArtifactSyncInterval))
+		require.NoError(t, s.r.Status().Patch(ctx, &bs, client.MergeFrom(original)))
+	}
+
+	result, err := s.r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultArtifactSyncInterval, result.RequeueAfter)
+
+	require.NoError(t, s.r.Get(ctx, key, &bs))
+	var artifacts databasesv1alpha1.BackupArtifactList
+	require.NoError(t, s.r.List(ctx, &artifacts))
+	return &bs, artifacts.Items
+}
+
+func backupTags(namespace, backupName, timestamp string) *storage.ObjectTags {
+	return &storage.ObjectTags{
+		Database:    "orders",
+		Cluster:     testClusterName,
+		BackupName:  backupName,
+		Namespace:   namespace,
+		Timestamp:   timestamp,
+		CreatedBy:   "dbtether",
+		Compression: "zstd",
+	}
+}
+
+func TestBackupArtifactReconciler_CreatesArtifacts(t *testing.T) {
+	s := newArtifactSyncer(t, newTestStorage(testStorageName))
+	s.upload(t, "test-cluster/orders/20260120-020000.sql.zst", "dump", backupTags(testNamespace, "orders-nightly", "20260120-020000"))
+	s.upload(t, "test-cluster/orders/notes.txt", "not a backup", nil)
+	s.upload(t, "test-cluster/orders/copied.sql.gz", "dump", &storage.ObjectTags{Database: "orders"})
+
+	bs, artifacts := s.sync(t)
+	require.Len(t, artifacts, 1)
+	artifact := artifacts[0]
+
+	assert.Equal(t, artifactName(testStorageName, "test-cluster/orders/20260120-020000.sql.zst"), artifact.Name)
+	assert.Equal(t, testNamespace, artifact.Namespace)
+	assert.Equal(t, databasesv1alpha1.BackupArtifactSpec{
+		StorageRef:      databasesv1alpha1.StorageReference{Name: testStorageName},
+		Path:            "test-cluster/orders/20260120-020000.sql.zst",
+		Size:            4,
+		LastModified:    artifact.Spec.LastModified,
+		Cluster:         testClusterName,
+		Database:        "orders",
+		BackupName:      "orders-nightly",
+		BackupNamespace: testNamespace,
+		Timestamp:       "20260120-020000",
+		Compression:     "zstd",
+	}, artifact.Spec)
+	assert.False(t, artifact.Spec.LastModified.IsZero())
+	assert.Equal(t, map[string]string{
+		LabelStorage:    testStorageName,
+		LabelCluster:    testClusterName,
+		LabelDatabase:   "orders",
+		LabelBackupName: "orders-nightly",
+	}, artifact.Labels)
+	require.Len(t, artifact.OwnerReferences, 1)
+	assert.Equal(t, "BackupStorage", artifact.OwnerReferences[0].Kind)
+
+	require.NotNil(t, bs.Status.Artifacts)
+	assert.Equal(t, int32(1), bs.Status.Artifacts.Count)
+	assert.Empty(t, bs.Status.Artifacts.Message)
+	condition := meta.FindStatusCondition(bs.Status.Conditions, databasesv1alpha1.StorageConditionInventoried)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, databasesv1alpha1.StorageReasonSynced, condition.Reason)
+
+	// The status patch triggers another reconcile, which must not list the storage again
+	opened := s.opened
+	result, err := s.r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: testStorageName}})
+	require.NoError(t, err)
+	assert.Equal(t, opened, s.opened, "storage synced again right after a sync")
+	assert.Positive(t, result.RequeueAfter)
+}
+
+func TestBackupArtifactReconciler_FollowsStorage(t *testing.T) {
+	s := newArtifactSyncer(t, newTestStorage(testStorageName))
+	kept := "test-cluster/orders/20260119-020000.sql.gz"
+	replaced := "test-cluster/orders/20260120-020000.sql.gz"
+	deleted := "test-cluster/orders/20260121-020000.sql.gz"
+	s.upload(t, kept, "dump", backupTags(testNamespace, "orders-1", "20260119-020000"))
+	s.upload(t, replaced, "dump", backupTags(testNamespace, "orders-2", "20260120-020000"))
+	s.upload(t, deleted, "dump", backupTags(testNamespace, "orders-3", "20260121-020000"))
+
+	_, artifacts := s.sync(t)
+	require.Len(t, artifacts, 3)
+
+	// Retention deleted one file; another was overwritten by a later backup
+	require.NoError(t, s.store.Delete(context.Background(), deleted))
+	s.upload(t, replaced, "larger dump", backupTags(testNamespace, "orders-2-rerun", "20260120-020000"))
+
+	bs, artifacts := s.sync(t)
+	require.Len(t, artifacts, 2)
+	byPath := map[string]databasesv1alpha1.BackupArtifact{}
+	for _, artifact := range artifacts {
+		byPath[artifact.Spec.Path] = artifact
+	}
+	assert.Contains(t, byPath, kept)
+	assert.Equal(t, "orders-2-rerun", byPath[replaced].Spec.BackupName)
+	assert.Equal(t, int64(len("larger dump")), byPath[replaced].Spec.Size)
+	assert.Equal(t, int32(2), bs.Status.Artifacts.Count)
+}
+
+func TestBackupArtifactReconciler_OperatorNamespaceWithoutNamespaceTag(t *testing.T) {
+	s := newArtifactSyncer(t, newTestStorage(testStorageName))
+	s.upload(t, "test-cluster/_globals/20260120-020000.sql.gz", "globals", backupTags("", "", "20260120-020000"))
+
+	_, artifacts := s.sync(t)
+	require.Len(t, artifacts, 1)
+	assert.Equal(t, testOperatorNS, artifacts[0].Namespace)
+}
+
+func TestBackupArtifactReconciler_ReadTagsFailure(t *testing.T) {
+	s := newArtifactSyncer(t, newTestStorage(testStorageName))
+	s.upload(t, "test-cluster/orders/20260120-020000.sql.gz", "dump", backupTags(testNamespace, "orders-1", "20260120-020000"))
+	_, artifacts := s.sync(t)
+	require.Len(t, artifacts, 1)
+
+	// Known files are not read again; new ones that cannot be read are reported
+	s.store.TagsError = errors.New("api error AccessDenied: Access Denied")
+	s.upload(t, "test-cluster/orders/20260121-020000.sql.gz", "dump", backupTags(testNamespace, "orders-2", "20260121-020000"))
+
+	bs, artifacts := s.sync(t)
+	assert.Len(t, artifacts, 1)
+	assert.Equal(t, int32(1), bs.Status.Artifacts.Count)
+	assert.Equal(t, "1 files not inventoried: test-cluster/orders/20260121-020000.sql.gz", bs.Status.Artifacts.Message)
+}
+
+func TestBackupArtifactReconciler_ListFailureKeepsArtifacts(t *testing.T) {
+	s := newArtifactSyncer(t, newTestStorage(testStorageName))
+	s.upload(t, "test-cluster/orders/20260120-020000.sql.gz", "dump", backupTags(testNamespace, "orders-1", "20260120-020000"))
+	s.sync(t)
+
+	s.store.ListError = errors.New("dial tcp: i/o timeout")
+	bs, artifacts := s.sync(t)
+	assert.Len(t, artifacts, 1)
+	assert.Equal(t, int32(1), bs.Status.Artifacts.Count)
+	assert.Equal(t, "sync failed: dial tcp: i/o timeout", bs.Status.Artifacts.Message)
+	condition := meta.FindStatusCondition(bs.Status.Conditions, databasesv1alpha1.StorageConditionInventoried)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, databasesv1alpha1.StorageReasonSyncFailed, condition.Reason)
+}
+
+func TestBackupArtifactReconciler_Skips(t *testing.T) {
+	t.Run("storage not ready", func(t *testing.T) {
+		bs := newTestStorage(testStorageName)
+		bs.Status.Phase = "Failed"
+		s := newArtifactSyncer(t, bs)
+
+		result, err := s.r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: testStorageName}})
+		require.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		assert.Zero(t, s.opened)
+	})
+
+	t.Run("pvc storage", func(t *testing.T) {
+		s := newArtifactSyncer(t, newTestPVCStorage(testStorageName, "pg-backups"))
+
+		bs, artifacts := s.sync(t)
+		assert.Empty(t, artifacts)
+		assert.Zero(t, s.opened)
+		assert.Equal(t, "not synced: the operator does not mount the claim", bs.Status.Artifacts.Message)
+
+		// The limitation is reported, not just skipped
+		condition := meta.FindStatusCondition(bs.Status.Conditions, databasesv1alpha1.StorageConditionInventoried)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, databasesv1alpha1.StorageReasonNotSupported, condition.Reason)
+		assert.Contains(t, condition.Message, "the operator does not mount the claim")
+		assert.Contains(t, condition.Message, "source.path from namespace "+testOperatorNS)
+	})
+}
+
+func TestArtifactName(t *testing.T) {
+	name := artifactName("company-s3", "main/orders/20260120-020000.sql.gz")
+	assert.Equal(t, name, artifactName("company-s3", "main/orders/20260120-020000.sql.gz"))
+	assert.NotEqual(t, name, artifactName("company-s3", "main/orders/20260121-020000.sql.gz"))
+	assert.NotEqual(t, name, artifactName("dr-s3", "main/orders/20260120-020000.sql.gz"))
+	assert.True(t, strings.HasPrefix(name, "company-s3-"))
+
+	long := artifactName(strings.Repeat("backups-", 10), "main/orders/20260120-020000.sql.gz")
+	assert.LessOrEqual(t, len(long), 63)
+	assert.NotContains(t, long, "--")
+}
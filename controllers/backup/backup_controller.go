@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/notify"
 )
 
 const backupFinalizer = "dbtether.io/backup-job"
@@ -47,13 +49,25 @@ type BackupReconciler struct {
 	Image                string
 	Namespace            string
 	MaxConcurrentBackups int // limit per DBCluster, default 3
+	// JobDefaults are operator-wide Job pod settings, overridden by BackupStorage,
+	// ClusterBackupSchedule and Backup jobTemplate
+	JobDefaults *databasesv1alpha1.JobTemplate
+	// PGClients selects the pg_dump client by server major version
+	PGClients PGClientConfig
+	// Notifier receives BackupCompleted and BackupFailed events, nil disables notifications
+	Notifier notify.Notifier
 }
 
 func (r *BackupReconciler) maxConcurrent() int {
-	if r.MaxConcurrentBackups <= 0 {
+	return maxConcurrentJobs(r.MaxConcurrentBackups)
+}
+
+// maxConcurrentJobs returns the configured backup Job limit per DBCluster, or the default when unset
+func maxConcurrentJobs(configured int) int {
+	if configured <= 0 {
 		return DefaultMaxConcurrentJobsPerCluster
 	}
-	return r.MaxConcurrentBackups
+	return configured
 }
 
 // +kubebuilder:rbac:groups=dbtether.io,resources=backups,verbs=get;list;watch;create;update;patch;delete
@@ -88,7 +102,7 @@ func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, nil
 	}
 
-	logger.V(1).Info("reconciling backup", "database", backup.Spec.DatabaseRef.Name)
+	logger.V(1).Info("reconciling backup", "database", backupTargetLabel(&backup))
 
 	// If Job already created, just check its status
 	if backup.Status.JobName != "" {
@@ -142,6 +156,25 @@ func (r *BackupReconciler) createBackupJobIfAllowed(ctx context.Context, backup
 		return r.updateStatus(ctx, backup, "Failed", err.Error(), specHash)
 	}
 
+	// Fail before creating a Job that pg_dump would reject
+	pgClient, err := r.PGClients.forBackup(cluster.Status.PostgresVersion)
+	if err != nil {
+		return r.updateStatus(ctx, backup, "Failed", err.Error(), specHash)
+	}
+
+	if err := validateHooks(backup.Spec.PreHooks, backup.Spec.PostHooks, cluster); err != nil {
+		return r.updateStatus(ctx, backup, "Failed", err.Error(), specHash)
+	}
+
+	if err := validateCompression(backup.Spec.Compression); err != nil {
+		return r.updateStatus(ctx, backup, "Failed", err.Error(), specHash)
+	}
+
+	replicas, err := getReplicaStorages(ctx, r.Client, storage.Name, backup.Spec.Replicas)
+	if err != nil {
+		return r.updateStatus(ctx, backup, "Failed", err.Error(), specHash)
+	}
+
 	// Check throttling
 	if result, throttled := r.checkThrottling(ctx, backup, cluster.Name, specHash, logger); throttled {
 		return result, nil
@@ -151,7 +184,7 @@ func (r *BackupReconciler) createBackupJobIfAllowed(ctx context.Context, backup
 	runID := generateRunID()
 
 	// Create Job
-	job, err := r.createBackupJob(ctx, backup, db, cluster, storage, runID)
+	job, err := r.createBackupJob(ctx, backup, db, cluster, storage, replicas, pgClient, runID)
 	if err != nil {
 		return r.handleJobCreationError(ctx, backup, specHash, err, logger)
 	}
@@ -300,6 +333,17 @@ func (r *BackupReconciler) countActiveJobsForCluster(ctx context.Context, cluste
 func (r *BackupReconciler) getResources(ctx context.Context, backup *databasesv1alpha1.Backup) (
 	*databasesv1alpha1.Database, *databasesv1alpha1.DBCluster, *databasesv1alpha1.BackupStorage, error) {
 
+	if backup.Spec.Globals != nil {
+		if backup.Spec.DatabaseRef.Name != "" {
+			return nil, nil, nil, fmt.Errorf("databaseRef and globals are mutually exclusive")
+		}
+		cluster, storage, err := r.getGlobalsResources(ctx, backup)
+		return nil, cluster, storage, err
+	}
+	if backup.Spec.DatabaseRef.Name == "" {
+		return nil, nil, nil, fmt.Errorf("one of databaseRef or globals must be set")
+	}
+
 	// Get Database
 	var db databasesv1alpha1.Database
 	if err := r.Get(ctx, types.NamespacedName{
@@ -345,19 +389,61 @@ func (r *BackupReconciler) getResources(ctx context.Context, backup *databasesv1
 	return &db, &cluster, &storage, nil
 }
 
+// getGlobalsResources returns the DBCluster and BackupStorage of a globals backup.
+// Globals include every role of the cluster, so they are restricted to the operator namespace.
+func (r *BackupReconciler) getGlobalsResources(ctx context.Context, backup *databasesv1alpha1.Backup) (
+	*databasesv1alpha1.DBCluster, *databasesv1alpha1.BackupStorage, error) {
+
+	if backup.Namespace != r.Namespace {
+		return nil, nil, fmt.Errorf("globals backups must be created in the operator namespace %s", r.Namespace)
+	}
+
+	clusterName := backup.Spec.Globals.ClusterRef.Name
+	var cluster databasesv1alpha1.DBCluster
+	if err := r.Get(ctx, types.NamespacedName{Name: clusterName}, &cluster); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("cluster %s not found", clusterName)
+		}
+		return nil, nil, err
+	}
+	if cluster.Status.Phase != "Connected" {
+		return nil, nil, fmt.Errorf("cluster %s is not connected", clusterName)
+	}
+
+	var storage databasesv1alpha1.BackupStorage
+	if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.StorageRef.Name}, &storage); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("backup storage %s not found", backup.Spec.StorageRef.Name)
+		}
+		return nil, nil, err
+	}
+	if storage.Status.Phase != "Ready" {
+		return nil, nil, fmt.Errorf("backup storage %s is not ready (phase: %s)", backup.Spec.StorageRef.Name, storage.Status.Phase)
+	}
+
+	return &cluster, &storage, nil
+}
+
 func (r *BackupReconciler) createBackupJob(ctx context.Context, backup *databasesv1alpha1.Backup,
-	db *databasesv1alpha1.Database, cluster *databasesv1alpha1.DBCluster, storage *databasesv1alpha1.BackupStorage, runID string) (*batchv1.Job, error) {
+	db *databasesv1alpha1.Database, cluster *databasesv1alpha1.DBCluster, storage *databasesv1alpha1.BackupStorage,
+	replicas []*databasesv1alpha1.BackupStorage, pgClient PGClient, runID string) (*batchv1.Job, error) {
 
 	jobName := fmt.Sprintf("backup-%s-%s", backup.Name, runID)
 
+	// Globals are dumped through the maintenance database
+	connectDB, databaseName := "postgres", databasesv1alpha1.GlobalsDatabaseName
+	if db != nil {
+		connectDB, databaseName = db.Status.DatabaseName, db.Status.DatabaseName
+	}
+
 	// Build environment variables (preallocate for common case)
 	env := make([]corev1.EnvVar, 0, 24)
 	env = append(env, []corev1.EnvVar{
 		{Name: "DB_HOST", Value: cluster.Spec.Endpoint},
 		{Name: "DB_PORT", Value: strconv.Itoa(cluster.Spec.Port)},
-		{Name: "DB_NAME", Value: db.Status.DatabaseName},
+		{Name: "DB_NAME", Value: connectDB},
 		{Name: "CLUSTER_NAME", Value: cluster.Name},
-		{Name: "DATABASE_NAME", Value: db.Status.DatabaseName},
+		{Name: "DATABASE_NAME", Value: databaseName},
 		{Name: "PATH_TEMPLATE", Value: storage.Spec.PathTemplate},
 		{Name: "FILENAME_TEMPLATE", Value: backup.Spec.FilenameTemplate},
 		// Metadata for S3 object tags
@@ -375,6 +461,17 @@ func (r *BackupReconciler) createBackupJob(ctx context.Context, backup *database
 
 	// Add storage configuration
 	env = append(env, r.getStorageEnv(storage)...)
+	retention, replicaRetention := r.jobRetention(ctx, backup, storage, replicas)
+	env = append(env, retentionEnv(retention)...)
+	env = append(env, replicasEnv(replicas, nil, replicaRetention)...)
+
+	env = append(env, pgClient.env()...)
+	env = append(env, hooksEnv(backup.Spec.PreHooks, backup.Spec.PostHooks, cluster, runID)...)
+	env = append(env, compressionEnv(backup.Spec.Compression)...)
+
+	if backup.Spec.Globals != nil {
+		env = append(env, corev1.EnvVar{Name: "DUMP_GLOBALS", Value: "true"})
+	}
 
 	backoffLimit := int32(3)
 
@@ -391,7 +488,7 @@ func (r *BackupReconciler) createBackupJob(ctx context.Context, backup *database
 			Labels: map[string]string{
 				LabelBackupName:        backup.Name,
 				LabelBackupNamespace:   backup.Namespace,
-				"dbtether.io/database": backup.Spec.DatabaseRef.Name,
+				"dbtether.io/database": backupTargetLabel(backup),
 				LabelCluster:           cluster.Name,
 			},
 			// No OwnerReference - cross-namespace not allowed
@@ -402,12 +499,11 @@ func (r *BackupReconciler) createBackupJob(ctx context.Context, backup *database
 			TTLSecondsAfterFinished: &ttlSeconds,
 			Template: corev1.PodTemplateSpec{
 				Spec: corev1.PodSpec{
-					RestartPolicy:      corev1.RestartPolicyOnFailure,
-					ServiceAccountName: "dbtether", // Uses operator's SA for IRSA
+					RestartPolicy: corev1.RestartPolicyOnFailure,
 					Containers: []corev1.Container{
 						{
 							Name:  "backup",
-							Image: r.Image,
+							Image: pgClient.image(r.Image),
 							Args:  []string{"--mode=job"},
 							Env:   env,
 						},
@@ -417,6 +513,17 @@ func (r *BackupReconciler) createBackupJob(ctx context.Context, backup *database
 		},
 	}
 
+	scheduleTemplate, err := r.scheduleJobTemplate(ctx, backup)
+	if err != nil {
+		return nil, err
+	}
+	mountPVCStorages(job, storage, replicas)
+	applyJobTemplate(job, databasesv1alpha1.MergeJobTemplates(
+		r.JobDefaults, storage.Spec.JobTemplate, scheduleTemplate, backup.Spec.JobTemplate.JobTemplate()))
+	if err := pinClaimJobs(ctx, r.Client, job); err != nil {
+		return nil, err
+	}
+
 	if err := r.Create(ctx, job); err != nil {
 		return nil, err
 	}
@@ -424,6 +531,51 @@ func (r *BackupReconciler) createBackupJob(ctx context.Context, backup *database
 	return job, nil
 }
 
+// scheduleJobTemplate returns the jobTemplate of the ClusterBackupSchedule that created the backup
+func (r *BackupReconciler) scheduleJobTemplate(ctx context.Context, backup *databasesv1alpha1.Backup) (*databasesv1alpha1.JobTemplate, error) {
+	schedule, err := r.owningClusterSchedule(ctx, backup)
+	if schedule == nil || err != nil {
+		return nil, err
+	}
+	return schedule.Spec.JobTemplate, nil
+}
+
+// owningClusterSchedule returns the ClusterBackupSchedule that created the backup, nil for other Backups.
+// Only Backups the schedule controls, that use its storage and are in a namespace it selects inherit its
+// settings, so a tenant cannot borrow a schedule's pod identity or retention by copying its owner reference.
+func (r *BackupReconciler) owningClusterSchedule(ctx context.Context, backup *databasesv1alpha1.Backup) (
+	*databasesv1alpha1.ClusterBackupSchedule, error) {
+
+	owner := metav1.GetControllerOf(backup)
+	if owner == nil || owner.Kind != "ClusterBackupSchedule" || owner.APIVersion != databasesv1alpha1.GroupVersion.String() {
+		return nil, nil
+	}
+
+	var schedule databasesv1alpha1.ClusterBackupSchedule
+	if err := r.Get(ctx, types.NamespacedName{Name: owner.Name}, &schedule); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if schedule.UID != owner.UID || schedule.Spec.StorageRef.Name != backup.Spec.StorageRef.Name {
+		return nil, nil
+	}
+	if s := schedule.Spec.DatabaseSelector; backup.Namespace != r.Namespace && s != nil && len(s.Namespaces) > 0 &&
+		!slices.Contains(s.Namespaces, backup.Namespace) {
+		return nil, nil
+	}
+	return &schedule, nil
+}
+
+// backupTargetLabel returns the dbtether.io/database label of a backup Job
+func backupTargetLabel(backup *databasesv1alpha1.Backup) string {
+	if backup.Spec.Globals != nil {
+		return "globals"
+	}
+	return backup.Spec.DatabaseRef.Name
+}
+
 func (r *BackupReconciler) getClusterCredentialsEnv(cluster *databasesv1alpha1.DBCluster) []corev1.EnvVar {
 	var env []corev1.EnvVar
 
@@ -455,46 +607,13 @@ func (r *BackupReconciler) getClusterCredentialsEnv(cluster *databasesv1alpha1.D
 }
 
 func (r *BackupReconciler) getStorageEnv(storage *databasesv1alpha1.BackupStorage) []corev1.EnvVar {
-	var env []corev1.EnvVar
-
-	if storage.Spec.S3 != nil {
-		env = append(env,
-			corev1.EnvVar{Name: "STORAGE_TYPE", Value: "s3"},
-			corev1.EnvVar{Name: "S3_BUCKET", Value: storage.Spec.S3.Bucket},
-			corev1.EnvVar{Name: "S3_REGION", Value: storage.Spec.S3.Region},
-		)
-		if storage.Spec.S3.Endpoint != "" {
-			env = append(env, corev1.EnvVar{Name: "S3_ENDPOINT", Value: storage.Spec.S3.Endpoint})
-		}
+	env := storageEnv(storage)
 
-		// Add credentials if specified
-		if storage.Spec.CredentialsSecretRef != nil {
-			env = append(env,
-				corev1.EnvVar{
-					Name: "AWS_ACCESS_KEY_ID",
-					ValueFrom: &corev1.EnvVarSource{
-						SecretKeyRef: &corev1.SecretKeySelector{
-							LocalObjectReference: corev1.LocalObjectReference{Name: storage.Spec.CredentialsSecretRef.Name},
-							Key:                  "AWS_ACCESS_KEY_ID",
-						},
-					},
-				},
-				corev1.EnvVar{
-					Name: "AWS_SECRET_ACCESS_KEY",
-					ValueFrom: &corev1.EnvVarSource{
-						SecretKeyRef: &corev1.SecretKeySelector{
-							LocalObjectReference: corev1.LocalObjectReference{Name: storage.Spec.CredentialsSecretRef.Name},
-							Key:                  "AWS_SECRET_ACCESS_KEY",
-						},
-					},
-				},
-			)
-		}
-		// If no credentials, IRSA/Pod Identity will be used
+	if storage.Spec.Immutability != nil {
+		data, _ := json.Marshal(storage.Spec.Immutability)
+		env = append(env, corev1.EnvVar{Name: "IMMUTABILITY", Value: string(data)})
 	}
 
-	// GCS and Azure support will be added in future versions
-
 	return env
 }
 
@@ -559,7 +678,7 @@ func (r *BackupReconciler) evaluateJobStatus(ctx context.Context, backup *databa
 	}
 
 	if job.Status.Failed > 0 && job.Spec.BackoffLimit != nil && job.Status.Failed >= *job.Spec.BackoffLimit {
-		return r.updateStatus(ctx, backup, "Failed", "backup job failed", specHash)
+		return r.updateStatusFailed(ctx, backup, job, specHash)
 	}
 
 	// Still running
@@ -581,6 +700,7 @@ func (r *BackupReconciler) updateStatusWithJob(ctx context.Context, backup *data
 func (r *BackupReconciler) updateStatusWithJobAndRunID(ctx context.Context, backup *databasesv1alpha1.Backup,
 	phase, message, specHash, jobName, runID string) (ctrl.Result, error) {
 
+	wasFailed := backup.Status.Phase == "Failed"
 	patch := client.MergeFrom(backup.DeepCopy())
 	backup.Status.Phase = phase
 	backup.Status.Message = message
@@ -601,6 +721,10 @@ func (r *BackupReconciler) updateStatusWithJobAndRunID(ctx context.Context, back
 		return ctrl.Result{}, err
 	}
 
+	if phase == "Failed" && !wasFailed {
+		notifyBackup(r.Notifier, backup)
+	}
+
 	if phase == "Running" {
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
@@ -620,7 +744,10 @@ func (r *BackupReconciler) updateStatusCompleted(ctx context.Context, backup *da
 
 	// Core status fields
 	backup.Status.Phase = "Completed"
-	backup.Status.Message = "backup completed successfully"
+	backup.Status.Hooks = hookResultsFromJob(job)
+	backup.Status.Replicas = replicaResultsFromJob(job)
+	backup.Status.Message = "backup completed successfully" + failedHooksSuffix(backup.Status.Hooks) +
+		failedReplicasSuffix(backup.Status.Replicas)
 	backup.Status.SpecHash = specHash
 	backup.Status.ObservedGeneration = backup.Generation
 
@@ -644,6 +771,30 @@ func (r *BackupReconciler) updateStatusCompleted(ctx context.Context, backup *da
 		return ctrl.Result{}, err
 	}
 
+	notifyBackup(r.Notifier, backup)
+	return ctrl.Result{}, nil
+}
+
+// updateStatusFailed marks the backup failed after its Job gave up, keeping the hook results
+func (r *BackupReconciler) updateStatusFailed(ctx context.Context, backup *databasesv1alpha1.Backup,
+	job *batchv1.Job, specHash string) (ctrl.Result, error) {
+
+	patch := client.MergeFrom(backup.DeepCopy())
+
+	backup.Status.Phase = "Failed"
+	backup.Status.Hooks = hookResultsFromJob(job)
+	backup.Status.Message = "backup job failed" + failedHooksSuffix(backup.Status.Hooks)
+	backup.Status.SpecHash = specHash
+	backup.Status.ObservedGeneration = backup.Generation
+
+	now := metav1.Now()
+	backup.Status.CompletedAt = &now
+
+	if err := r.Status().Patch(ctx, backup, patch); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	notifyBackup(r.Notifier, backup)
 	return ctrl.Result{}, nil
 }
 
@@ -1373,3 +1373,30 @@ func newTestReconcilerWithObjects(objs ...client.Object) *BackupReconciler {
 		Namespace: testOperatorNS,
 	}
 }
+
+func TestGetStorageEnv_Immutability(t *testing.T) {
+	r := &BackupReconciler{}
+	storage := newTestStorage(testStorageName)
+
+	for _, e := range r.getStorageEnv(storage) {
+		if e.Name == "IMMUTABILITY" {
+			t.Fatalf("IMMUTABILITY should not be set without immutability, got %q", e.Value)
+		}
+	}
+
+	storage.Spec.Immutability = &databasesv1alpha1.ImmutabilityConfig{
+		Mode:      databasesv1alpha1.ImmutabilityCompliance,
+		RetainFor: &metav1.Duration{Duration: 720 * time.Hour},
+		LegalHold: true,
+	}
+	var got string
+	for _, e := range r.getStorageEnv(storage) {
+		if e.Name == "IMMUTABILITY" {
+			got = e.Value
+		}
+	}
+	want := `{"mode":"Compliance","retainFor":"720h0m0s","legalHold":true}`
+	if got != want {
+		t.Errorf("IMMUTABILITY = %s, want %s", got, want)
+	}
+}
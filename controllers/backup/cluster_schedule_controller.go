@@ -0,0 +1,458 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
+)
+
+// Label keys for Backups created by a ClusterBackupSchedule
+const (
+	LabelClusterSchedule = "dbtether.io/cluster-schedule"
+	LabelRun             = "dbtether.io/run"
+)
+
+// maxBackupNameLength keeps Job names ("backup-{name}-{runID}") within 63 characters
+const maxBackupNameLength = 47
+
+// runFormat is the run identifier of a scheduled time, used in Backup names and the run label
+const runFormat = "20060102-1504"
+
+// Phases of a ClusterBackupSchedule run
+const (
+	runPhaseRunning         = "Running"
+	runPhaseCompleted       = "Completed"
+	runPhasePartiallyFailed = "PartiallyFailed"
+	runPhaseFailed          = "Failed"
+)
+
+// ClusterBackupScheduleReconciler creates a Backup per matching Database of a DBCluster
+// (plus a globals Backup) on every scheduled run. Throttling per cluster is done by the
+// Backup controller, so a run with many databases drains through MaxConcurrentBackups.
+type ClusterBackupScheduleReconciler struct {
+	client.Client
+	Scheme    *runtime.Scheme
+	Log       *zap.SugaredLogger
+	Namespace string // operator namespace, where globals Backups are created
+
+	// OpenStorage creates the storage client for retention cleanup; nil uses the provider's client
+	OpenStorage StorageOpenFunc
+}
+
+// +kubebuilder:rbac:groups=dbtether.io,resources=clusterbackupschedules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dbtether.io,resources=clusterbackupschedules/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=dbtether.io,resources=databases,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbtether.io,resources=backupstorages,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+func (r *ClusterBackupScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var schedule dbtether.ClusterBackupSchedule
+	if err := r.Get(ctx, req.NamespacedName, &schedule); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Created Backups are owned by the schedule and garbage collected with it
+	if !schedule.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	if schedule.Spec.Suspend {
+		return r.updateStatus(ctx, &schedule, &clusterScheduleUpdate{Phase: "Suspended", Message: "Schedule is suspended"})
+	}
+
+	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+	cronSchedule, err := parser.Parse(schedule.Spec.Schedule)
+	if err != nil {
+		return r.updateStatus(ctx, &schedule, &clusterScheduleUpdate{
+			Phase: "Failed", Message: fmt.Sprintf("Invalid cron schedule: %v", err),
+		})
+	}
+
+	lastRun := schedule.CreationTimestamp.Time
+	if schedule.Status.LastBackupTime != nil {
+		lastRun = schedule.Status.LastBackupTime.Time
+	}
+	nextRun := cronSchedule.Next(lastRun)
+	update := &clusterScheduleUpdate{Phase: "Active"}
+
+	if !time.Now().Before(nextRun) {
+		databases, err := r.runBackups(ctx, &schedule, nextRun)
+		if err != nil {
+			logger.Error(err, "failed to create cluster backups")
+			return r.updateStatus(ctx, &schedule, &clusterScheduleUpdate{Phase: "Failed", Message: err.Error()})
+		}
+		now := metav1.Now()
+		count := len(databases)
+		update.LastBackupTime = &now
+		update.Databases = &count
+		// Missed runs are coalesced into this one
+		nextRun = cronSchedule.Next(now.Time)
+		r.cleanupOldBackups(ctx, &schedule)
+		r.cleanupOldFiles(ctx, &schedule, databases)
+	}
+
+	next := metav1.NewTime(nextRun)
+	update.NextScheduledTime = &next
+	update.RequeueAfter = max(time.Until(nextRun), time.Second)
+	return r.updateStatus(ctx, &schedule, update)
+}
+
+// runBackups creates the Backups of one scheduled run and returns the Databases backed up.
+// Existing Backups of the run are kept, so a retried reconcile does not duplicate work.
+func (r *ClusterBackupScheduleReconciler) runBackups(ctx context.Context, schedule *dbtether.ClusterBackupSchedule,
+	scheduledTime time.Time) ([]dbtether.Database, error) {
+
+	logger := log.FromContext(ctx)
+	run := scheduledTime.UTC().Format(runFormat)
+
+	databases, err := r.selectDatabases(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range databases {
+		db := &databases[i]
+		backup := r.newBackup(schedule, db.Namespace, clusterBackupName(schedule.Name, db.Name, run), run)
+		backup.Spec.DatabaseRef = dbtether.DatabaseReference{Name: db.Name}
+		if err := r.createBackup(ctx, schedule, backup); err != nil {
+			return nil, err
+		}
+	}
+
+	if schedule.Spec.GlobalsEnabled() {
+		backup := r.newBackup(schedule, r.Namespace, clusterBackupName(schedule.Name, "globals", run), run)
+		backup.Spec.Globals = &dbtether.GlobalsBackup{ClusterRef: schedule.Spec.ClusterRef}
+		if err := r.createBackup(ctx, schedule, backup); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Info("cluster backup run started", "run", run, "databases", len(databases), "globals", schedule.Spec.GlobalsEnabled())
+	return databases, nil
+}
+
+// selectDatabases returns the Ready Databases on the schedule's cluster matching the selector
+func (r *ClusterBackupScheduleReconciler) selectDatabases(ctx context.Context, schedule *dbtether.ClusterBackupSchedule) ([]dbtether.Database, error) {
+	selector := labels.Everything()
+	var namespaces []string
+	if s := schedule.Spec.DatabaseSelector; s != nil {
+		namespaces = s.Namespaces
+		if s.LabelSelector != nil {
+			var err error
+			if selector, err = metav1.LabelSelectorAsSelector(s.LabelSelector); err != nil {
+				return nil, fmt.Errorf("invalid databaseSelector: %w", err)
+			}
+		}
+	}
+
+	var list dbtether.DatabaseList
+	if err := r.List(ctx, &list, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+
+	var selected []dbtether.Database
+	for _, db := range list.Items {
+		if db.Spec.ClusterRef.Name != schedule.Spec.ClusterRef.Name || !db.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if len(namespaces) > 0 && !slices.Contains(namespaces, db.Namespace) {
+			continue
+		}
+		if db.Status.Phase != "Ready" {
+			log.FromContext(ctx).V(1).Info("skipping database that is not ready", "database", db.Namespace+"/"+db.Name)
+			continue
+		}
+		selected = append(selected, db)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Namespace+"/"+selected[i].Name < selected[j].Namespace+"/"+selected[j].Name
+	})
+	return selected, nil
+}
+
+func (r *ClusterBackupScheduleReconciler) newBackup(schedule *dbtether.ClusterBackupSchedule, namespace, name, run string) *dbtether.Backup {
+	backup := &dbtether.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				LabelClusterSchedule: schedule.Name,
+				LabelRun:             run,
+			},
+		},
+		Spec: dbtether.BackupSpec{
+			StorageRef:       schedule.Spec.StorageRef,
+			FilenameTemplate: schedule.Spec.FilenameTemplate,
+			Compression:      schedule.Spec.Compression.DeepCopy(),
+		},
+	}
+	if channels := schedule.Annotations[dbtether.NotifyAnnotation]; channels != "" {
+		backup.Annotations = map[string]string{dbtether.NotifyAnnotation: channels}
+	}
+	return backup
+}
+
+func (r *ClusterBackupScheduleReconciler) createBackup(ctx context.Context, schedule *dbtether.ClusterBackupSchedule, backup *dbtether.Backup) error {
+	if err := controllerutil.SetControllerReference(schedule, backup, r.Scheme); err != nil {
+		return fmt.Errorf("failed to set owner reference: %w", err)
+	}
+	if err := r.Create(ctx, backup); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create backup %s/%s: %w", backup.Namespace, backup.Name, err)
+	}
+	return nil
+}
+
+// clusterBackupName returns "{schedule}-{target}-{run}", shortened with a hash when the
+// Job name derived from it would exceed 63 characters
+func clusterBackupName(scheduleName, target, run string) string {
+	base := scheduleName + "-" + target
+	if len(base)+1+len(run) <= maxBackupNameLength {
+		return base + "-" + run
+	}
+	sum := sha256.Sum256([]byte(base))
+	keep := maxBackupNameLength - len(run) - 1 - 9 // "-" before run, "-" + 8 hash chars
+	return strings.TrimRight(base[:keep], "-") + "-" + hex.EncodeToString(sum[:4]) + "-" + run
+}
+
+// aggregateLastRun summarizes the Backups of the most recent run
+func (r *ClusterBackupScheduleReconciler) aggregateLastRun(ctx context.Context, schedule *dbtether.ClusterBackupSchedule) (*dbtether.ClusterBackupRunStatus, error) {
+	var backups dbtether.BackupList
+	if err := r.List(ctx, &backups, client.MatchingLabels{LabelClusterSchedule: schedule.Name}); err != nil {
+		return nil, err
+	}
+
+	var lastRun string
+	for _, b := range backups.Items {
+		if owned(&b, schedule) && b.Labels[LabelRun] > lastRun {
+			lastRun = b.Labels[LabelRun]
+		}
+	}
+	if lastRun == "" {
+		return nil, nil
+	}
+
+	status := &dbtether.ClusterBackupRunStatus{Run: lastRun}
+	for _, b := range backups.Items {
+		if !owned(&b, schedule) || b.Labels[LabelRun] != lastRun {
+			continue
+		}
+		status.Total++
+		if b.Spec.Globals != nil {
+			status.GlobalsBackup = b.Name
+		}
+		switch b.Status.Phase {
+		case "Completed":
+			status.Completed++
+		case "Failed":
+			status.Failed++
+			status.FailedBackups = append(status.FailedBackups, b.Namespace+"/"+b.Name)
+		}
+	}
+	sort.Strings(status.FailedBackups)
+
+	switch {
+	case status.Completed+status.Failed < status.Total:
+		status.Phase = runPhaseRunning
+	case status.Failed == 0:
+		status.Phase = runPhaseCompleted
+	case status.Completed == 0:
+		status.Phase = runPhaseFailed
+	default:
+		status.Phase = runPhasePartiallyFailed
+	}
+	return status, nil
+}
+
+// owned guards against Backups of a deleted and recreated schedule with the same name
+func owned(backup *dbtether.Backup, schedule *dbtether.ClusterBackupSchedule) bool {
+	return metav1.IsControlledBy(backup, schedule)
+}
+
+// cleanupOldBackups keeps the newest keepLast finished Backups per database (and for globals)
+func (r *ClusterBackupScheduleReconciler) cleanupOldBackups(ctx context.Context, schedule *dbtether.ClusterBackupSchedule) {
+	if schedule.Spec.KeepLast == nil {
+		return
+	}
+	logger := log.FromContext(ctx)
+
+	var backups dbtether.BackupList
+	if err := r.List(ctx, &backups, client.MatchingLabels{LabelClusterSchedule: schedule.Name}); err != nil {
+		logger.Error(err, "failed to list backups for cleanup")
+		return
+	}
+
+	byTarget := map[string][]*dbtether.Backup{}
+	for i := range backups.Items {
+		b := &backups.Items[i]
+		if !owned(b, schedule) {
+			continue
+		}
+		key := b.Namespace + "/" + backupTargetLabel(b)
+		byTarget[key] = append(byTarget[key], b)
+	}
+
+	for _, group := range byTarget {
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].Labels[LabelRun] > group[j].Labels[LabelRun]
+		})
+		for _, b := range group[min(*schedule.Spec.KeepLast, len(group)):] {
+			if b.Status.Phase != "Completed" && b.Status.Phase != "Failed" {
+				continue
+			}
+			if err := r.Delete(ctx, b); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "failed to delete old backup", "backup", b.Namespace+"/"+b.Name)
+			}
+		}
+	}
+}
+
+// cleanupOldFiles applies the schedule's retention to the backup files of each database of the run,
+// and of the globals, under the database's prefix in the storage. A prefix shared by several databases
+// (a pathTemplate without .DatabaseName) is left alone, retention there would count all of them
+// together. pvc and sftp storages are cleaned up by the backup Jobs instead, see jobRetention.
+func (r *ClusterBackupScheduleReconciler) cleanupOldFiles(ctx context.Context, schedule *dbtether.ClusterBackupSchedule,
+	databases []dbtether.Database) {
+
+	if schedule.Spec.Retention == nil {
+		return
+	}
+	logger := log.FromContext(ctx)
+
+	var backupStorage dbtether.BackupStorage
+	if err := r.Get(ctx, types.NamespacedName{Name: schedule.Spec.StorageRef.Name}, &backupStorage); err != nil {
+		logger.Error(err, "retention cleanup: failed to get backup storage", "storage", schedule.Spec.StorageRef.Name)
+		return
+	}
+	if pkgbackup.CleanedUpByJob(backupStorage.GetProvider()) {
+		return
+	}
+
+	databaseNames := make([]string, 0, len(databases)+1)
+	for _, db := range databases {
+		if db.Status.DatabaseName != "" {
+			databaseNames = append(databaseNames, db.Status.DatabaseName)
+		}
+	}
+	if schedule.Spec.GlobalsEnabled() {
+		databaseNames = append(databaseNames, dbtether.GlobalsDatabaseName)
+	}
+
+	prefixes := map[string][]string{}
+	for _, name := range databaseNames {
+		prefix, err := storagePrefix(&backupStorage, schedule.Spec.ClusterRef.Name, name)
+		if err != nil {
+			logger.Error(err, "retention cleanup: failed to build storage path", "storage", backupStorage.Name)
+			return
+		}
+		prefixes[prefix] = append(prefixes[prefix], name)
+	}
+
+	credentials, err := storageCredentials(ctx, r.Client, r.Namespace, &backupStorage)
+	if err != nil {
+		logger.Error(err, "retention cleanup: failed to read storage credentials", "storage", backupStorage.Name)
+		return
+	}
+	open := r.OpenStorage
+	if open == nil {
+		open = openStorageClient
+	}
+	storageClient, closeClient, err := open(ctx, &backupStorage, credentials)
+	if err != nil {
+		logger.Error(err, "retention cleanup: failed to create storage client", "storage", backupStorage.Name)
+		return
+	}
+	defer closeClient()
+
+	sugar := r.Log
+	if sugar == nil {
+		sugar = zap.NewNop().Sugar()
+	}
+	retentionManager := pkgbackup.NewRetentionManager(sugar.With("schedule", schedule.Name))
+	for prefix, names := range prefixes {
+		if len(names) > 1 {
+			logger.Info("retention cleanup: skipping prefix shared by several databases", "prefix", prefix, "databases", names)
+			continue
+		}
+		toDelete, err := retentionManager.ApplyRetention(ctx, storageClient, strings.TrimSuffix(prefix, "/")+"/",
+			schedule.Spec.Retention)
+		if err != nil {
+			logger.Error(err, "retention cleanup: failed to apply retention policy", "prefix", prefix)
+			continue
+		}
+		if err := retentionManager.DeleteFiles(ctx, storageClient, toDelete); err != nil {
+			logger.Error(err, "retention cleanup: failed to delete some backup files", "prefix", prefix)
+		}
+	}
+}
+
+// clusterScheduleUpdate holds the status fields to set; nil fields are left unchanged
+type clusterScheduleUpdate struct {
+	Phase             string
+	Message           string
+	LastBackupTime    *metav1.Time
+	NextScheduledTime *metav1.Time
+	Databases         *int
+	RequeueAfter      time.Duration
+}
+
+// updateStatus patches the status and refreshes the summary of the last run
+func (r *ClusterBackupScheduleReconciler) updateStatus(ctx context.Context, schedule *dbtether.ClusterBackupSchedule,
+	update *clusterScheduleUpdate) (ctrl.Result, error) {
+
+	lastRun, err := r.aggregateLastRun(ctx, schedule)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	patch := client.MergeFrom(schedule.DeepCopy())
+	schedule.Status.Phase = update.Phase
+	schedule.Status.Message = update.Message
+	schedule.Status.LastRun = lastRun
+	schedule.Status.ObservedGeneration = schedule.Generation
+	if update.LastBackupTime != nil {
+		schedule.Status.LastBackupTime = update.LastBackupTime
+	}
+	if update.NextScheduledTime != nil {
+		schedule.Status.NextScheduledTime = update.NextScheduledTime
+	}
+	if update.Databases != nil {
+		schedule.Status.Databases = *update.Databases
+	}
+	if lastRun != nil && lastRun.Failed > 0 && update.Message == "" {
+		schedule.Status.Message = fmt.Sprintf("%d of %d backups failed in run %s", lastRun.Failed, lastRun.Total, lastRun.Run)
+	}
+
+	if err := r.Status().Patch(ctx, schedule, patch); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: update.RequeueAfter}, nil
+}
+
+func (r *ClusterBackupScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dbtether.ClusterBackupSchedule{}).
+		Owns(&dbtether.Backup{}).
+		Complete(r)
+}
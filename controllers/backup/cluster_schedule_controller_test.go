@@ -0,0 +1,295 @@
+package backup
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/storage"
+)
+
+const testClusterScheduleName = "nightly-all"
+
+func newTestClusterSchedule() *dbtether.ClusterBackupSchedule {
+	lastRun := metav1.NewTime(time.Now().Add(-48 * time.Hour))
+	return &dbtether.ClusterBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: testClusterScheduleName, UID: "cbks-uid"},
+		Spec: dbtether.ClusterBackupScheduleSpec{
+			ClusterRef: dbtether.ClusterReference{Name: testClusterName},
+			StorageRef: dbtether.StorageReference{Name: testStorageName},
+			Schedule:   testCronSchedule,
+		},
+		Status: dbtether.ClusterBackupScheduleStatus{LastBackupTime: &lastRun},
+	}
+}
+
+func newClusterScheduleTestReconciler(objs ...client.Object) *ClusterBackupScheduleReconciler {
+	scheme := newTestScheme()
+	return &ClusterBackupScheduleReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(objs...).
+			WithStatusSubresource(&dbtether.ClusterBackupSchedule{}, &dbtether.Backup{}).
+			Build(),
+		Scheme:    scheme,
+		Namespace: testOperatorNS,
+	}
+}
+
+// newClusterScheduleTestDatabases returns two Ready Databases on the test cluster,
+// one on another cluster and one that is not Ready
+func newClusterScheduleTestDatabases() []client.Object {
+	ordersDB := newTestDatabase("orders-db", "team-a", testClusterName)
+	ordersDB.Labels = map[string]string{"tier": "critical"}
+	reportsDB := newTestDatabase("reports-db", "team-b", testClusterName)
+	otherCluster := newTestDatabase("billing-db", "team-a", "other-cluster")
+	pending := newTestDatabase("new-db", "team-b", testClusterName)
+	pending.Status.Phase = "Pending"
+	return []client.Object{ordersDB, reportsDB, otherCluster, pending}
+}
+
+func reconcileClusterSchedule(t *testing.T, r *ClusterBackupScheduleReconciler) *dbtether.ClusterBackupSchedule {
+	t.Helper()
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testClusterScheduleName}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	var schedule dbtether.ClusterBackupSchedule
+	require.NoError(t, r.Get(ctx, req.NamespacedName, &schedule))
+	return &schedule
+}
+
+func listClusterScheduleBackups(t *testing.T, r *ClusterBackupScheduleReconciler) []dbtether.Backup {
+	t.Helper()
+	var backups dbtether.BackupList
+	require.NoError(t, r.List(context.Background(), &backups, client.MatchingLabels{LabelClusterSchedule: testClusterScheduleName}))
+	return backups.Items
+}
+
+func TestClusterBackupSchedule_CreatesBackupPerDatabase(t *testing.T) {
+	r := newClusterScheduleTestReconciler(append(newClusterScheduleTestDatabases(), newTestClusterSchedule())...)
+
+	schedule := reconcileClusterSchedule(t, r)
+
+	backups := listClusterScheduleBackups(t, r)
+	require.Len(t, backups, 3, "two databases plus globals")
+
+	targets := map[string]dbtether.Backup{}
+	for _, b := range backups {
+		targets[b.Namespace+"/"+backupTargetLabel(&b)] = b
+		assert.True(t, metav1.IsControlledBy(&b, schedule), "backup %s should be owned by the schedule", b.Name)
+		assert.Equal(t, testStorageName, b.Spec.StorageRef.Name)
+	}
+	assert.Contains(t, targets, "team-a/orders-db")
+	assert.Contains(t, targets, "team-b/reports-db")
+	globals, ok := targets[testOperatorNS+"/globals"]
+	require.True(t, ok, "globals backup should be created in the operator namespace")
+	assert.Equal(t, testClusterName, globals.Spec.Globals.ClusterRef.Name)
+
+	assert.Equal(t, "Active", schedule.Status.Phase)
+	assert.Equal(t, 2, schedule.Status.Databases)
+	require.NotNil(t, schedule.Status.LastRun)
+	assert.Equal(t, runPhaseRunning, schedule.Status.LastRun.Phase)
+	assert.Equal(t, 3, schedule.Status.LastRun.Total)
+	assert.Equal(t, globals.Name, schedule.Status.LastRun.GlobalsBackup)
+	assert.True(t, schedule.Status.NextScheduledTime.After(time.Now()))
+}
+
+func TestClusterBackupSchedule_DatabaseSelector(t *testing.T) {
+	schedule := newTestClusterSchedule()
+	noGlobals := false
+	schedule.Spec.IncludeGlobals = &noGlobals
+	schedule.Spec.DatabaseSelector = &dbtether.DatabaseSelector{
+		Namespaces:    []string{"team-a", "team-b"},
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "critical"}},
+	}
+	r := newClusterScheduleTestReconciler(append(newClusterScheduleTestDatabases(), schedule)...)
+
+	reconcileClusterSchedule(t, r)
+
+	backups := listClusterScheduleBackups(t, r)
+	require.Len(t, backups, 1)
+	assert.Equal(t, "orders-db", backups[0].Spec.DatabaseRef.Name)
+}
+
+func TestClusterBackupSchedule_AggregatesRunStatus(t *testing.T) {
+	ctx := context.Background()
+	r := newClusterScheduleTestReconciler(append(newClusterScheduleTestDatabases(), newTestClusterSchedule())...)
+	reconcileClusterSchedule(t, r)
+
+	for _, b := range listClusterScheduleBackups(t, r) {
+		b.Status.Phase = "Completed"
+		if b.Namespace == "team-b" {
+			b.Status.Phase = "Failed"
+		}
+		require.NoError(t, r.Status().Update(ctx, &b))
+	}
+
+	schedule := reconcileClusterSchedule(t, r)
+	require.NotNil(t, schedule.Status.LastRun)
+	assert.Equal(t, runPhasePartiallyFailed, schedule.Status.LastRun.Phase)
+	assert.Equal(t, 2, schedule.Status.LastRun.Completed)
+	assert.Equal(t, 1, schedule.Status.LastRun.Failed)
+	require.Len(t, schedule.Status.LastRun.FailedBackups, 1)
+	assert.True(t, strings.HasPrefix(schedule.Status.LastRun.FailedBackups[0], "team-b/"))
+	assert.Contains(t, schedule.Status.Message, "1 of 3 backups failed")
+	assert.Len(t, listClusterScheduleBackups(t, r), 3, "no new run before the next scheduled time")
+}
+
+func TestClusterBackupSchedule_KeepLast(t *testing.T) {
+	ctx := context.Background()
+	schedule := newTestClusterSchedule()
+	keepLast := 1
+	schedule.Spec.KeepLast = &keepLast
+	r := newClusterScheduleTestReconciler(newTestDatabase("orders-db", "team-a", testClusterName), schedule)
+
+	// Backups of an earlier run, already finished
+	for _, target := range []string{"orders-db", "globals"} {
+		old := r.newBackup(schedule, "team-a", clusterBackupName(schedule.Name, target, "20260101-0200"), "20260101-0200")
+		old.Spec.DatabaseRef.Name = "orders-db"
+		if target == "globals" {
+			old.Namespace = testOperatorNS
+			old.Spec.DatabaseRef.Name = ""
+			old.Spec.Globals = &dbtether.GlobalsBackup{ClusterRef: schedule.Spec.ClusterRef}
+		}
+		require.NoError(t, r.createBackup(ctx, schedule, old))
+		old.Status.Phase = "Completed"
+		require.NoError(t, r.Status().Update(ctx, old))
+	}
+
+	reconcileClusterSchedule(t, r)
+
+	backups := listClusterScheduleBackups(t, r)
+	require.Len(t, backups, 2, "old backups beyond keepLast are deleted once finished")
+	for _, b := range backups {
+		assert.NotEqual(t, "20260101-0200", b.Labels[LabelRun])
+	}
+}
+
+func TestClusterBackupSchedule_RetentionPerDatabase(t *testing.T) {
+	schedule := newTestClusterSchedule()
+	keepLast := 2
+	schedule.Spec.Retention = &dbtether.RetentionPolicy{KeepLast: &keepLast}
+	r := newClusterScheduleTestReconciler(append(newClusterScheduleTestDatabases(), schedule,
+		newTestStorage(testStorageName))...)
+
+	store := storage.NewMockClient()
+	for _, key := range []string{
+		"test-cluster/orders-db/20260101-020000.sql.gz",
+		"test-cluster/orders-db/20260102-020000.sql.gz",
+		"test-cluster/orders-db/20260103-020000.sql.gz",
+		"test-cluster/orders-db-archive/20260101-020000.sql.gz",
+		"test-cluster/reports-db/20260101-020000.sql.gz",
+		"test-cluster/_globals/20260101-020000.sql.gz",
+		"test-cluster/_globals/20260102-020000.sql.gz",
+		"test-cluster/_globals/20260103-020000.sql.gz",
+	} {
+		store.AddObject(key, []byte("dump"), time.Now())
+	}
+	r.OpenStorage = func(context.Context, *dbtether.BackupStorage, map[string][]byte) (storage.StorageClient, func(), error) {
+		return store, func() {}, nil
+	}
+
+	reconcileClusterSchedule(t, r)
+
+	assert.ElementsMatch(t, []string{
+		"test-cluster/orders-db/20260102-020000.sql.gz",
+		"test-cluster/orders-db/20260103-020000.sql.gz",
+		"test-cluster/orders-db-archive/20260101-020000.sql.gz",
+		"test-cluster/reports-db/20260101-020000.sql.gz",
+		"test-cluster/_globals/20260102-020000.sql.gz",
+		"test-cluster/_globals/20260103-020000.sql.gz",
+	}, store.Keys(), "keepLast applies per database; other prefixes are not touched")
+}
+
+func TestClusterBackupSchedule_RetentionSkipsSharedPrefix(t *testing.T) {
+	schedule := newTestClusterSchedule()
+	keepLast := 1
+	schedule.Spec.Retention = &dbtether.RetentionPolicy{KeepLast: &keepLast}
+	clusterOnly := newTestStorage(testStorageName)
+	clusterOnly.Spec.PathTemplate = "backups/{{ .ClusterName }}"
+	r := newClusterScheduleTestReconciler(append(newClusterScheduleTestDatabases(), schedule, clusterOnly)...)
+
+	store := storage.NewMockClient()
+	store.AddObject("backups/test-cluster/20260101-020000.sql.gz", []byte("orders"), time.Now())
+	store.AddObject("backups/test-cluster/20260102-020000.sql.gz", []byte("reports"), time.Now())
+	r.OpenStorage = func(context.Context, *dbtether.BackupStorage, map[string][]byte) (storage.StorageClient, func(), error) {
+		return store, func() {}, nil
+	}
+
+	reconcileClusterSchedule(t, r)
+	assert.Equal(t, 2, store.Count(), "files of several databases under one prefix are kept")
+}
+
+func TestClusterBackupSchedule_Suspended(t *testing.T) {
+	schedule := newTestClusterSchedule()
+	schedule.Spec.Suspend = true
+	r := newClusterScheduleTestReconciler(append(newClusterScheduleTestDatabases(), schedule)...)
+
+	got := reconcileClusterSchedule(t, r)
+	assert.Equal(t, "Suspended", got.Status.Phase)
+	assert.Empty(t, listClusterScheduleBackups(t, r))
+}
+
+func TestClusterBackupName(t *testing.T) {
+	assert.Equal(t, "nightly-orders-db-20260120-0200", clusterBackupName("nightly", "orders-db", "20260120-0200"))
+
+	long := clusterBackupName("nightly-all-databases", "customer-analytics-warehouse", "20260120-0200")
+	assert.LessOrEqual(t, len(long), maxBackupNameLength)
+	assert.True(t, strings.HasSuffix(long, "-20260120-0200"))
+	assert.NotEqual(t, long, clusterBackupName("nightly-all-databases", "customer-analytics-warehouse2", "20260120-0200"),
+		"truncated names must stay unique")
+}
+
+func TestBackupReconciler_GlobalsBackup(t *testing.T) {
+	newGlobalsBackup := func(namespace string) *dbtether.Backup {
+		backup := newTestBackup(testBackupName, namespace)
+		backup.Finalizers = []string{backupFinalizer}
+		backup.Spec.DatabaseRef = dbtether.DatabaseReference{}
+		backup.Spec.Globals = &dbtether.GlobalsBackup{ClusterRef: dbtether.ClusterReference{Name: testClusterName}}
+		return backup
+	}
+
+	t.Run("creates pg_dumpall job", func(t *testing.T) {
+		r := newTestReconciler(newGlobalsBackup(testOperatorNS), newTestCluster(testClusterName),
+			newTestStorage(testStorageName), newTestSecret(testSecretName, testOperatorNS))
+		req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testOperatorNS}}
+		_, err := r.Reconcile(context.Background(), req)
+		require.NoError(t, err)
+
+		var jobs batchv1.JobList
+		require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+		require.Len(t, jobs.Items, 1)
+		env := jobs.Items[0].Spec.Template.Spec.Containers[0].Env
+		assert.Contains(t, env, corev1.EnvVar{Name: "DUMP_GLOBALS", Value: "true"})
+		assert.Contains(t, env, corev1.EnvVar{Name: "DATABASE_NAME", Value: dbtether.GlobalsDatabaseName})
+		assert.Contains(t, env, corev1.EnvVar{Name: "DB_NAME", Value: "postgres"})
+		assert.Equal(t, testClusterName, jobs.Items[0].Labels[LabelCluster], "globals count against the cluster's job limit")
+	})
+
+	t.Run("rejected outside operator namespace", func(t *testing.T) {
+		r := newTestReconciler(newGlobalsBackup(testNamespace), newTestCluster(testClusterName),
+			newTestStorage(testStorageName))
+		req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+		_, err := r.Reconcile(context.Background(), req)
+		require.NoError(t, err)
+
+		var backup dbtether.Backup
+		require.NoError(t, r.Get(context.Background(), req.NamespacedName, &backup))
+		assert.Equal(t, "Failed", backup.Status.Phase)
+		assert.Contains(t, backup.Status.Message, "operator namespace")
+	})
+}
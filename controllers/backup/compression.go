@@ -0,0 +1,37 @@
+package backup
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
+)
+
+// validateCompression rejects a level the codec does not support, before a Job is created
+func validateCompression(compression *databasesv1alpha1.Compression) error {
+	if compression == nil {
+		return nil
+	}
+	return pkgbackup.ValidateCompression(compression.Codec, compressionLevel(compression))
+}
+
+// compressionEnv passes the codec and level to the backup Job; without it the Job uses gzip
+func compressionEnv(compression *databasesv1alpha1.Compression) []corev1.EnvVar {
+	if compression == nil {
+		return nil
+	}
+	env := []corev1.EnvVar{{Name: "COMPRESSION", Value: compression.Codec}}
+	if level := compressionLevel(compression); level > 0 {
+		env = append(env, corev1.EnvVar{Name: "COMPRESSION_LEVEL", Value: strconv.Itoa(level)})
+	}
+	return env
+}
+
+func compressionLevel(compression *databasesv1alpha1.Compression) int {
+	if compression.Level == nil {
+		return 0
+	}
+	return int(*compression.Level)
+}
@@ -0,0 +1,90 @@
+package backup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+func TestBackupReconciler_CompressionPassedToJob(t *testing.T) {
+	level := int32(19)
+	tests := []struct {
+		name        string
+		compression *databasesv1alpha1.Compression
+		wantEnv     map[string]string
+	}{
+		{
+			name:    "default",
+			wantEnv: map[string]string{},
+		},
+		{
+			name:        "zstd with level",
+			compression: &databasesv1alpha1.Compression{Codec: databasesv1alpha1.CompressionZstd, Level: &level},
+			wantEnv:     map[string]string{"COMPRESSION": "zstd", "COMPRESSION_LEVEL": "19"},
+		},
+		{
+			name:        "none",
+			compression: &databasesv1alpha1.Compression{Codec: databasesv1alpha1.CompressionNone},
+			wantEnv:     map[string]string{"COMPRESSION": "none"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := newTestBackup(testBackupName, testNamespace)
+			backup.Finalizers = []string{backupFinalizer}
+			backup.Spec.Compression = tt.compression
+
+			r := newTestReconciler(backup, newTestDatabase(testDBName, testNamespace, testClusterName),
+				newTestCluster(testClusterName), newTestStorage(testStorageName), newTestSecret(testSecretName, testOperatorNS))
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+			_, err := r.Reconcile(context.Background(), req)
+			require.NoError(t, err)
+
+			var jobs batchv1.JobList
+			require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+			require.Len(t, jobs.Items, 1)
+
+			env := jobEnv(&jobs.Items[0])
+			got := map[string]string{}
+			for _, name := range []string{"COMPRESSION", "COMPRESSION_LEVEL"} {
+				if value, ok := env[name]; ok {
+					got[name] = value
+				}
+			}
+			assert.Equal(t, tt.wantEnv, got)
+		})
+	}
+}
+
+func TestBackupReconciler_InvalidCompressionLevel(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Finalizers = []string{backupFinalizer}
+	level := int32(19)
+	backup.Spec.Compression = &databasesv1alpha1.Compression{Codec: databasesv1alpha1.CompressionGzip, Level: &level}
+
+	r := newTestReconciler(backup, newTestDatabase(testDBName, testNamespace, testClusterName),
+		newTestCluster(testClusterName), newTestStorage(testStorageName))
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	var updated databasesv1alpha1.Backup
+	require.NoError(t, r.Get(context.Background(), req.NamespacedName, &updated))
+	assert.Equal(t, "Failed", updated.Status.Phase)
+	assert.Equal(t, "compression level 19 out of range for gzip (1-9)", updated.Status.Message)
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	assert.Empty(t, jobs.Items)
+}
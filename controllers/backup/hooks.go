@@ -0,0 +1,107 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
+)
+
+// validateHooks rejects hooks the Job could not run and hooks the DBCluster's hook policy does not
+// allow, before a Job is created
+func validateHooks(pre, post []databasesv1alpha1.Hook, cluster *databasesv1alpha1.DBCluster) error {
+	stages := []struct {
+		name  string
+		hooks []databasesv1alpha1.Hook
+	}{{pkgbackup.HookStagePre, pre}, {pkgbackup.HookStagePost, post}}
+
+	policy := cluster.Spec.Hooks
+	for _, stage := range stages {
+		seen := map[string]bool{}
+		for _, hook := range stage.hooks {
+			if (hook.SQL == "") == (hook.HTTP == nil) {
+				return fmt.Errorf("%s hook %q: exactly one of sql or http must be set", stage.name, hook.Name)
+			}
+			if seen[hook.Name] {
+				return fmt.Errorf("%s hook %q: duplicate name", stage.name, hook.Name)
+			}
+			seen[hook.Name] = true
+
+			if hook.SQL != "" && (policy == nil || !policy.AllowSQL) {
+				return fmt.Errorf("%s hook %q: SQL hooks are not allowed by DBCluster %s (spec.hooks.allowSQL)",
+					stage.name, hook.Name, cluster.Name)
+			}
+			if hook.HTTP != nil {
+				u, err := url.Parse(hook.HTTP.URL)
+				if err != nil {
+					return fmt.Errorf("%s hook %q: invalid url: %w", stage.name, hook.Name, err)
+				}
+				if !policy.AllowsHTTPHost(u.Host) {
+					return fmt.Errorf("%s hook %q: host %s is not allowed by DBCluster %s (spec.hooks.allowedHTTPHosts)",
+						stage.name, hook.Name, u.Host, cluster.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hooksEnv passes pre/post hooks to the Job as JSON, with the hook policy the Job enforces again
+// (allowed HTTP hosts) and the name of the role SQL hooks run as
+func hooksEnv(pre, post []databasesv1alpha1.Hook, cluster *databasesv1alpha1.DBCluster, runID string) []corev1.EnvVar {
+	if len(pre) == 0 && len(post) == 0 {
+		return nil
+	}
+
+	var env []corev1.EnvVar
+	if len(pre) > 0 {
+		data, _ := json.Marshal(pre)
+		env = append(env, corev1.EnvVar{Name: "PRE_HOOKS", Value: string(data)})
+	}
+	if len(post) > 0 {
+		data, _ := json.Marshal(post)
+		env = append(env, corev1.EnvVar{Name: "POST_HOOKS", Value: string(data)})
+	}
+	env = append(env, corev1.EnvVar{Name: "HOOK_ROLE", Value: pkgbackup.HookRoleName(runID)})
+	if policy := cluster.Spec.Hooks; policy != nil && len(policy.AllowedHTTPHosts) > 0 {
+		data, _ := json.Marshal(policy.AllowedHTTPHosts)
+		env = append(env, corev1.EnvVar{Name: "HOOK_ALLOWED_HTTP_HOSTS", Value: string(data)})
+	}
+	if cluster.Spec.PasswordEncryption.ServerSide() {
+		env = append(env, corev1.EnvVar{Name: "HOOK_PASSWORD_ENCRYPTION", Value: databasesv1alpha1.PasswordEncryptionServer})
+	}
+	return env
+}
+
+// hookResultsFromJob reads the hook results the Job reported in its annotations
+func hookResultsFromJob(job *batchv1.Job) []databasesv1alpha1.HookResult {
+	data := job.Annotations[pkgbackup.HookResultsAnnotation]
+	if data == "" {
+		return nil
+	}
+	var results []databasesv1alpha1.HookResult
+	if err := json.Unmarshal([]byte(data), &results); err != nil {
+		return nil
+	}
+	return results
+}
+
+// failedHooksSuffix lists failed hooks for the status message, e.g. " (failed hooks: pre/pause-consumer)"
+func failedHooksSuffix(results []databasesv1alpha1.HookResult) string {
+	var failed []string
+	for _, result := range results {
+		if result.Phase == pkgbackup.HookFailed {
+			failed = append(failed, result.Stage+"/"+result.Name)
+		}
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (failed hooks: %s)", strings.Join(failed, ", "))
+}
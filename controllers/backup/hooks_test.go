@@ -0,0 +1,227 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
+)
+
+// newHooksCluster returns the test cluster with SQL hooks and HTTP hooks to consumer allowed
+func newHooksCluster() *databasesv1alpha1.DBCluster {
+	cluster := newTestCluster(testClusterName)
+	cluster.Spec.Hooks = &databasesv1alpha1.HookPolicy{AllowSQL: true, AllowedHTTPHosts: []string{"consumer"}}
+	return cluster
+}
+
+func TestValidateHooks(t *testing.T) {
+	sqlHook := databasesv1alpha1.Hook{Name: "switch-wal", SQL: "SELECT pg_catalog.pg_switch_wal()"}
+	httpHook := databasesv1alpha1.Hook{Name: "pause", HTTP: &databasesv1alpha1.HTTPHook{URL: "http://consumer/pause"}}
+	metadataHook := databasesv1alpha1.Hook{Name: "steal", HTTP: &databasesv1alpha1.HTTPHook{URL: "http://169.254.169.254/latest"}}
+
+	tests := []struct {
+		name    string
+		pre     []databasesv1alpha1.Hook
+		post    []databasesv1alpha1.Hook
+		policy  *databasesv1alpha1.HookPolicy
+		wantErr string
+	}{
+		{name: "no hooks"},
+		{
+			name: "sql and http", pre: []databasesv1alpha1.Hook{sqlHook, httpHook}, post: []databasesv1alpha1.Hook{httpHook},
+			policy: newHooksCluster().Spec.Hooks,
+		},
+		{
+			name:    "neither sql nor http",
+			pre:     []databasesv1alpha1.Hook{{Name: "empty"}},
+			wantErr: `pre hook "empty": exactly one of sql or http must be set`,
+		},
+		{
+			name:    "both sql and http",
+			post:    []databasesv1alpha1.Hook{{Name: "both", SQL: "SELECT 1", HTTP: httpHook.HTTP}},
+			wantErr: `post hook "both": exactly one of sql or http must be set`,
+		},
+		{
+			name:    "duplicate name",
+			pre:     []databasesv1alpha1.Hook{sqlHook, sqlHook},
+			policy:  newHooksCluster().Spec.Hooks,
+			wantErr: `pre hook "switch-wal": duplicate name`,
+		},
+		{
+			name:    "sql hooks without policy",
+			pre:     []databasesv1alpha1.Hook{sqlHook},
+			wantErr: `pre hook "switch-wal": SQL hooks are not allowed by DBCluster test-cluster (spec.hooks.allowSQL)`,
+		},
+		{
+			name:    "http hooks without policy",
+			pre:     []databasesv1alpha1.Hook{httpHook},
+			policy:  &databasesv1alpha1.HookPolicy{AllowSQL: true},
+			wantErr: `pre hook "pause": host consumer is not allowed by DBCluster test-cluster (spec.hooks.allowedHTTPHosts)`,
+		},
+		{
+			name:    "http host not allowed",
+			post:    []databasesv1alpha1.Hook{metadataHook},
+			policy:  newHooksCluster().Spec.Hooks,
+			wantErr: `post hook "steal": host 169.254.169.254 is not allowed by DBCluster test-cluster (spec.hooks.allowedHTTPHosts)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := newTestCluster(testClusterName)
+			cluster.Spec.Hooks = tt.policy
+			err := validateHooks(tt.pre, tt.post, cluster)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestHookResultsFromJob(t *testing.T) {
+	results := []databasesv1alpha1.HookResult{
+		{Name: "pause", Stage: "pre", Phase: "Succeeded", Duration: "12ms"},
+		{Name: "resume", Stage: "post", Phase: "Failed", Message: "connection refused"},
+	}
+	data, err := json.Marshal(results)
+	require.NoError(t, err)
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{pkgbackup.HookResultsAnnotation: string(data)},
+	}}
+	assert.Equal(t, results, hookResultsFromJob(job))
+	assert.Equal(t, " (failed hooks: post/resume)", failedHooksSuffix(results))
+
+	assert.Nil(t, hookResultsFromJob(&batchv1.Job{}))
+	assert.Empty(t, failedHooksSuffix(nil))
+}
+
+func TestBackupReconciler_HooksPassedToJob(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Finalizers = []string{backupFinalizer}
+	backup.Spec.PreHooks = []databasesv1alpha1.Hook{{Name: "switch-wal", SQL: "SELECT pg_catalog.pg_switch_wal()"}}
+
+	r := newTestReconciler(backup, newTestDatabase(testDBName, testNamespace, testClusterName),
+		newHooksCluster(), newTestStorage(testStorageName), newTestSecret(testSecretName, testOperatorNS))
+
+	job := reconcileBackupJob(t, r)
+
+	env := map[string]string{}
+	for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	var hooks []databasesv1alpha1.Hook
+	require.NoError(t, json.Unmarshal([]byte(env["PRE_HOOKS"]), &hooks))
+	assert.Equal(t, backup.Spec.PreHooks, hooks)
+	assert.NotContains(t, env, "POST_HOOKS")
+	assert.Equal(t, pkgbackup.HookRoleName(env["RUN_ID"]), env["HOOK_ROLE"])
+	assert.JSONEq(t, `["consumer"]`, env["HOOK_ALLOWED_HTTP_HOSTS"])
+}
+
+func TestBackupReconciler_HooksNotAllowed(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Finalizers = []string{backupFinalizer}
+	backup.Spec.PreHooks = []databasesv1alpha1.Hook{{Name: "switch-wal", SQL: "SELECT pg_catalog.pg_switch_wal()"}}
+
+	r := newTestReconciler(backup, newTestDatabase(testDBName, testNamespace, testClusterName),
+		newTestCluster(testClusterName), newTestStorage(testStorageName), newTestSecret(testSecretName, testOperatorNS))
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	var updated databasesv1alpha1.Backup
+	require.NoError(t, r.Get(context.Background(), req.NamespacedName, &updated))
+	assert.Equal(t, "Failed", updated.Status.Phase)
+	assert.Contains(t, updated.Status.Message, "spec.hooks.allowSQL")
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	assert.Empty(t, jobs.Items)
+}
+
+func TestBackupReconciler_InvalidHooks(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Finalizers = []string{backupFinalizer}
+	backup.Spec.PostHooks = []databasesv1alpha1.Hook{{Name: "notify"}}
+
+	r := newTestReconciler(backup, newTestDatabase(testDBName, testNamespace, testClusterName),
+		newTestCluster(testClusterName), newTestStorage(testStorageName))
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	var updated databasesv1alpha1.Backup
+	require.NoError(t, r.Get(context.Background(), req.NamespacedName, &updated))
+	assert.Equal(t, "Failed", updated.Status.Phase)
+	assert.Contains(t, updated.Status.Message, `post hook "notify"`)
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	assert.Empty(t, jobs.Items)
+}
+
+func TestBackupReconciler_FailedJobReportsHooks(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Finalizers = []string{backupFinalizer}
+
+	data, err := json.Marshal([]databasesv1alpha1.HookResult{
+		{Name: "pause", Stage: "pre", Phase: "Failed", Message: "POST http://consumer/pause returned 503"},
+	})
+	require.NoError(t, err)
+	backoffLimit := int32(3)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "backup-" + testBackupName + "-abcd1234",
+			Namespace:   testOperatorNS,
+			Labels:      map[string]string{LabelBackupName: testBackupName, LabelBackupNamespace: testNamespace},
+			Annotations: map[string]string{pkgbackup.HookResultsAnnotation: string(data)},
+		},
+		Spec:   batchv1.JobSpec{BackoffLimit: &backoffLimit},
+		Status: batchv1.JobStatus{Failed: 3},
+	}
+
+	r := newTestReconciler(backup, job)
+	_, err = r.evaluateJobStatus(context.Background(), backup, job, "hash")
+	require.NoError(t, err)
+
+	var updated databasesv1alpha1.Backup
+	require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(backup), &updated))
+	assert.Equal(t, "Failed", updated.Status.Phase)
+	assert.Equal(t, "backup job failed (failed hooks: pre/pause)", updated.Status.Message)
+	require.Len(t, updated.Status.Hooks, 1)
+	assert.Equal(t, "pause", updated.Status.Hooks[0].Name)
+}
+
+func TestRestoreReconciler_HooksPassedToJob(t *testing.T) {
+	restore := &databasesv1alpha1.Restore{}
+	restore.Name, restore.Namespace = "restore-orders", testNamespace
+	restore.Spec.PostHooks = []databasesv1alpha1.Hook{{Name: "analyze", SQL: "ANALYZE"}}
+
+	r := &RestoreReconciler{Namespace: testOperatorNS, Image: testImage}
+	job, err := r.buildRestoreJob(restore, newTestDatabase(testDBName, testNamespace, testClusterName),
+		newHooksCluster(), newTestStorage(testStorageName), "path/backup.sql.gz", "abcd1234")
+	require.NoError(t, err)
+
+	env := map[string]string{}
+	for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	assert.JSONEq(t, `[{"name":"analyze","sql":"ANALYZE"}]`, env["POST_HOOKS"])
+	assert.Equal(t, "dbtether_hook_abcd1234", env["HOOK_ROLE"])
+	assert.Equal(t, job.Name, env["JOB_NAME"], "the restore Job reports hook results on itself")
+	assert.Equal(t, testOperatorNS, env["JOB_NAMESPACE"])
+}
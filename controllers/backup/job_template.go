@@ -0,0 +1,45 @@
+package backup
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+// DefaultJobServiceAccount is the ServiceAccount of backup/restore pods unless a jobTemplate sets one
+const DefaultJobServiceAccount = "dbtether"
+
+// applyJobTemplate applies a merged JobTemplate to a backup or restore Job.
+// The Job's own BackoffLimit is kept unless the template overrides it.
+func applyJobTemplate(job *batchv1.Job, tmpl databasesv1alpha1.JobTemplate) {
+	podSpec := &job.Spec.Template.Spec
+
+	podSpec.ServiceAccountName = DefaultJobServiceAccount
+	if tmpl.ServiceAccountName != "" {
+		podSpec.ServiceAccountName = tmpl.ServiceAccountName
+	}
+	if tmpl.Resources != nil {
+		for i := range podSpec.Containers {
+			podSpec.Containers[i].Resources = *tmpl.Resources
+		}
+	}
+	podSpec.NodeSelector = tmpl.NodeSelector
+	podSpec.Tolerations = tmpl.Tolerations
+	podSpec.Affinity = tmpl.Affinity
+	podSpec.SecurityContext = tmpl.SecurityContext
+
+	if len(tmpl.Annotations) > 0 {
+		if job.Spec.Template.Annotations == nil {
+			job.Spec.Template.Annotations = map[string]string{}
+		}
+		for k, v := range tmpl.Annotations {
+			job.Spec.Template.Annotations[k] = v
+		}
+	}
+
+	if tmpl.BackoffLimit != nil {
+		backoffLimit := *tmpl.BackoffLimit
+		job.Spec.BackoffLimit = &backoffLimit
+	}
+	job.Spec.ActiveDeadlineSeconds = tmpl.ActiveDeadlineSeconds
+}
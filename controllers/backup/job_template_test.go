@@ -0,0 +1,164 @@
+package backup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+func TestApplyJobTemplate_Defaults(t *testing.T) {
+	backoffLimit := int32(3)
+	job := &batchv1.Job{Spec: batchv1.JobSpec{
+		BackoffLimit: &backoffLimit,
+		Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "backup"}},
+		}},
+	}}
+
+	applyJobTemplate(job, databasesv1alpha1.JobTemplate{})
+
+	assert.Equal(t, DefaultJobServiceAccount, job.Spec.Template.Spec.ServiceAccountName)
+	assert.Equal(t, int32(3), *job.Spec.BackoffLimit)
+	assert.Nil(t, job.Spec.ActiveDeadlineSeconds)
+	assert.Empty(t, job.Spec.Template.Spec.Containers[0].Resources.Limits)
+}
+
+func TestApplyJobTemplate_Overrides(t *testing.T) {
+	backoffLimit, deadline := int32(0), int64(7200)
+	runAsNonRoot := true
+	tmpl := databasesv1alpha1.JobTemplate{
+		ServiceAccountName: "backup-writer",
+		Resources: &corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+		},
+		NodeSelector:          map[string]string{"node-role": "backup"},
+		Tolerations:           []corev1.Toleration{{Key: "backup", Operator: corev1.TolerationOpExists}},
+		SecurityContext:       &corev1.PodSecurityContext{RunAsNonRoot: &runAsNonRoot},
+		Annotations:           map[string]string{"cluster-autoscaler.kubernetes.io/safe-to-evict": "false"},
+		BackoffLimit:          &backoffLimit,
+		ActiveDeadlineSeconds: &deadline,
+	}
+	job := &batchv1.Job{Spec: batchv1.JobSpec{
+		Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "backup"}},
+		}},
+	}}
+
+	applyJobTemplate(job, tmpl)
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, "backup-writer", podSpec.ServiceAccountName)
+	assert.Equal(t, "2Gi", podSpec.Containers[0].Resources.Limits.Memory().String())
+	assert.Equal(t, tmpl.NodeSelector, podSpec.NodeSelector)
+	assert.Equal(t, tmpl.Tolerations, podSpec.Tolerations)
+	assert.True(t, *podSpec.SecurityContext.RunAsNonRoot)
+	assert.Equal(t, "false", job.Spec.Template.Annotations["cluster-autoscaler.kubernetes.io/safe-to-evict"])
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	assert.Equal(t, int64(7200), *job.Spec.ActiveDeadlineSeconds)
+}
+
+func TestBackupReconciler_JobTemplatePrecedence(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Finalizers = []string{backupFinalizer}
+	backupDeadline := int64(600)
+	backup.Spec.JobTemplate = &databasesv1alpha1.JobOverrides{ActiveDeadlineSeconds: &backupDeadline}
+	storage := newTestStorage(testStorageName)
+	storage.Spec.JobTemplate = &databasesv1alpha1.JobTemplate{
+		ServiceAccountName: "s3-writer",
+		NodeSelector:       map[string]string{"pool": "backup"},
+	}
+	deadline, backoff := int64(3600), int32(5)
+
+	r := newTestReconciler(backup, newTestDatabase(testDBName, testNamespace, testClusterName),
+		newTestCluster(testClusterName), storage, newTestSecret(testSecretName, testOperatorNS))
+	r.JobDefaults = &databasesv1alpha1.JobTemplate{
+		ServiceAccountName: "default-sa", ActiveDeadlineSeconds: &deadline, BackoffLimit: &backoff,
+	}
+
+	job := reconcileBackupJob(t, r)
+	assert.Equal(t, "s3-writer", job.Spec.Template.Spec.ServiceAccountName, "storage overrides operator defaults")
+	assert.Equal(t, "backup", job.Spec.Template.Spec.NodeSelector["pool"])
+	assert.Equal(t, int64(600), *job.Spec.ActiveDeadlineSeconds, "backup overrides operator defaults")
+	assert.Equal(t, int32(5), *job.Spec.BackoffLimit, "operator defaults apply when not overridden")
+}
+
+func TestBackupReconciler_ClusterScheduleJobTemplate(t *testing.T) {
+	schedule := &databasesv1alpha1.ClusterBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", UID: "cluster-schedule-uid"},
+		Spec: databasesv1alpha1.ClusterBackupScheduleSpec{
+			ClusterRef:  databasesv1alpha1.ClusterReference{Name: testClusterName},
+			StorageRef:  databasesv1alpha1.StorageReference{Name: testStorageName},
+			Schedule:    "0 2 * * *",
+			JobTemplate: &databasesv1alpha1.JobTemplate{ServiceAccountName: "schedule-writer"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		uid    types.UID
+		wantSA string
+	}{
+		{"owned by the schedule", "cluster-schedule-uid", "schedule-writer"},
+		{"copied owner reference", "other-uid", DefaultJobServiceAccount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := newTestBackup(testBackupName, testNamespace)
+			backup.Finalizers = []string{backupFinalizer}
+			owner := schedule.DeepCopy()
+			owner.UID = tt.uid
+			backup.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(owner,
+				databasesv1alpha1.GroupVersion.WithKind("ClusterBackupSchedule"))}
+
+			r := newTestReconciler(backup, schedule, newTestDatabase(testDBName, testNamespace, testClusterName),
+				newTestCluster(testClusterName), newTestStorage(testStorageName), newTestSecret(testSecretName, testOperatorNS))
+
+			job := reconcileBackupJob(t, r)
+			assert.Equal(t, tt.wantSA, job.Spec.Template.Spec.ServiceAccountName)
+		})
+	}
+}
+
+// reconcileBackupJob reconciles the test Backup and returns the Job it created
+func reconcileBackupJob(t *testing.T, r *BackupReconciler) batchv1.Job {
+	t.Helper()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	require.Len(t, jobs.Items, 1)
+	return jobs.Items[0]
+}
+
+func TestRestoreReconciler_JobTemplate(t *testing.T) {
+	restore := &databasesv1alpha1.Restore{}
+	restore.Name, restore.Namespace = "restore-orders", testNamespace
+	restore.Spec.JobTemplate = &databasesv1alpha1.JobOverrides{Resources: &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+	}}
+	storage := newTestStorage(testStorageName)
+	storage.Spec.JobTemplate = &databasesv1alpha1.JobTemplate{ServiceAccountName: "restore-sa"}
+
+	r := &RestoreReconciler{Namespace: testOperatorNS, Image: testImage}
+	job, err := r.buildRestoreJob(restore, newTestDatabase(testDBName, testNamespace, testClusterName),
+		newTestCluster(testClusterName), storage, "path/backup.sql.gz", "abcd1234")
+	require.NoError(t, err)
+
+	assert.Equal(t, "restore-sa", job.Spec.Template.Spec.ServiceAccountName)
+	assert.Equal(t, "4Gi", job.Spec.Template.Spec.Containers[0].Resources.Limits.Memory().String())
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit, "restores do not retry by default")
+}
@@ -0,0 +1,44 @@
+package backup
+
+import (
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/notify"
+)
+
+// notifyBackup emits BackupCompleted or BackupFailed for a backup that reached a terminal phase.
+// The run ID is part of the dedup key so every scheduled run is delivered.
+func notifyBackup(n notify.Notifier, backup *databasesv1alpha1.Backup) {
+	if n == nil {
+		return
+	}
+	reason := databasesv1alpha1.EventBackupCompleted
+	if backup.Status.Phase == "Failed" {
+		reason = databasesv1alpha1.EventBackupFailed
+	}
+	n.Notify(notify.NewEvent(backup, "Backup", reason, backup.Status.Message,
+		backup.Status.SpecHash+"/"+backup.Status.RunID).WithData(
+		"database", backup.Spec.DatabaseRef.Name,
+		"job", backup.Status.JobName,
+		"path", backup.Status.Path,
+		"size", backup.Status.Size,
+		"duration", backup.Status.Duration,
+	))
+}
+
+// notifyRestore emits RestoreCompleted or RestoreFailed for a restore that reached a terminal phase
+func notifyRestore(n notify.Notifier, restore *databasesv1alpha1.Restore) {
+	if n == nil {
+		return
+	}
+	reason := databasesv1alpha1.EventRestoreCompleted
+	if restore.Status.Phase == "Failed" {
+		reason = databasesv1alpha1.EventRestoreFailed
+	}
+	n.Notify(notify.NewEvent(restore, "Restore", reason, restore.Status.Message,
+		restore.Status.SpecHash+"/"+restore.Status.RunID).WithData(
+		"database", restore.Spec.Target.DatabaseRef.Name,
+		"job", restore.Status.JobName,
+		"source", restore.Status.SourcePath,
+		"duration", restore.Status.Duration,
+	))
+}
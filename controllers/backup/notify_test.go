@@ -0,0 +1,112 @@
+package backup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/notify"
+)
+
+func TestBackupReconciler_NotifiesCompleted(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Status.RunID = "run-1"
+	r := newTestReconciler(backup)
+	notifier := &notify.MockNotifier{}
+	r.Notifier = notifier
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		"dbtether.io/backup-path":       "team-a/orders/2026-01-02.sql.gz",
+		"dbtether.io/backup-size-human": "1.2 MB",
+	}}}
+	_, err := r.updateStatusCompleted(context.Background(), backup, job, "hash")
+	require.NoError(t, err)
+
+	require.Len(t, notifier.Events, 1)
+	event := notifier.Events[0]
+	assert.Equal(t, databasesv1alpha1.EventBackupCompleted, event.Reason)
+	assert.Equal(t, "Backup", event.Kind)
+	assert.Equal(t, testNamespace, event.Namespace)
+	assert.Equal(t, "team-a/orders/2026-01-02.sql.gz", event.Data["path"])
+	assert.Equal(t, "1.2 MB", event.Data["size"])
+	assert.Equal(t, testDBName, event.Data["database"])
+}
+
+func TestBackupReconciler_NotifiesFailedOnce(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	r := newTestReconciler(backup)
+	notifier := &notify.MockNotifier{}
+	r.Notifier = notifier
+	ctx := context.Background()
+
+	_, err := r.updateStatus(ctx, backup, "Running", "backup job running", "hash")
+	require.NoError(t, err)
+	_, err = r.updateStatus(ctx, backup, "Failed", "backup job not found", "hash")
+	require.NoError(t, err)
+	_, err = r.updateStatus(ctx, backup, "Failed", "backup job not found", "hash")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{databasesv1alpha1.EventBackupFailed}, notifier.Reasons())
+	assert.Equal(t, "error", notifier.Events[0].Severity())
+	assert.Equal(t, "backup job not found", notifier.Events[0].Message)
+}
+
+func TestBackupReconciler_RunsHaveDistinctEventIDs(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	r := newTestReconciler(backup)
+	notifier := &notify.MockNotifier{}
+	r.Notifier = notifier
+
+	ctx := context.Background()
+	for _, runID := range []string{"run-1", "run-2"} {
+		_, err := r.updateStatusWithJobAndRunID(ctx, backup, "Running", "backup job running", "hash", "job-"+runID, runID)
+		require.NoError(t, err)
+		_, err = r.updateStatusFailed(ctx, backup, &batchv1.Job{}, "hash")
+		require.NoError(t, err)
+	}
+
+	require.Len(t, notifier.Events, 2)
+	assert.NotEqual(t, notifier.Events[0].ID, notifier.Events[1].ID)
+}
+
+func TestBackupReconciler_NilNotifier(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	r := newTestReconciler(backup)
+
+	_, err := r.updateStatusFailed(context.Background(), backup, &batchv1.Job{}, "hash")
+	assert.NoError(t, err)
+}
+
+func TestRestoreReconciler_NotifiesTerminalPhases(t *testing.T) {
+	restore := &databasesv1alpha1.Restore{}
+	restore.Name, restore.Namespace = "restore-orders", testNamespace
+	restore.Spec.Target.DatabaseRef.Name = testDBName
+
+	scheme := newTestScheme()
+	notifier := &notify.MockNotifier{}
+	r := &RestoreReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(restore).
+			WithStatusSubresource(&databasesv1alpha1.Restore{}).Build(),
+		Scheme:   scheme,
+		Notifier: notifier,
+	}
+	ctx := context.Background()
+
+	_, err := r.updateStatus(ctx, restore, "Failed", "target database not found", "hash")
+	require.NoError(t, err)
+	_, err = r.updateStatus(ctx, restore, "Failed", "target database not found", "hash")
+	require.NoError(t, err)
+	_, err = r.updateStatusCompleted(ctx, restore, "hash", "12s", nil, "", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{databasesv1alpha1.EventRestoreFailed, databasesv1alpha1.EventRestoreCompleted},
+		notifier.Reasons())
+	assert.Equal(t, testDBName, notifier.Events[1].Data["database"])
+	assert.Equal(t, "12s", notifier.Events[1].Data["duration"])
+}
@@ -0,0 +1,94 @@
+package backup
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultBundledPGMajor is the major version of the PostgreSQL client in the operator image
+const DefaultBundledPGMajor = 18
+
+// PGClient is the pg_dump/psql client used for one server major version
+type PGClient struct {
+	// Image for the Job; empty uses the operator image
+	Image string `json:"image,omitempty"`
+	// BinDir with pg_dump/psql inside the image; empty uses PATH
+	BinDir string `json:"binDir,omitempty"`
+}
+
+// PGClientConfig selects the client for backup and restore Jobs by server major version
+type PGClientConfig struct {
+	// BundledMajor is the client version in the operator image (default: DefaultBundledPGMajor)
+	BundledMajor int
+	// Clients maps server major versions to a client, taking precedence over the bundled one
+	Clients map[int]PGClient
+}
+
+var postgresVersionRegex = regexp.MustCompile(`^PostgreSQL (\d+)`)
+
+// parsePostgresMajor extracts the major version from DBClusterStatus.PostgresVersion
+// (e.g. "PostgreSQL 16.11 on x86_64-pc-linux-gnu" -> 16)
+func parsePostgresMajor(version string) (int, bool) {
+	m := postgresVersionRegex.FindStringSubmatch(version)
+	if m == nil {
+		return 0, false
+	}
+	major, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return major, true
+}
+
+func (c PGClientConfig) bundledMajor() int {
+	if c.BundledMajor <= 0 {
+		return DefaultBundledPGMajor
+	}
+	return c.BundledMajor
+}
+
+// forBackup returns the client for dumping a server. pg_dump refuses servers newer than itself,
+// so servers above the bundled version need a configured client. Unknown versions use the bundled client.
+func (c PGClientConfig) forBackup(serverVersion string) (PGClient, error) {
+	major, ok := parsePostgresMajor(serverVersion)
+	if !ok {
+		return PGClient{}, nil
+	}
+	if client, found := c.Clients[major]; found {
+		return client, nil
+	}
+	if major > c.bundledMajor() {
+		return PGClient{}, fmt.Errorf("no pg_dump client for PostgreSQL %d: bundled client is %d, configure an image for %d in backup.pgClients",
+			major, c.bundledMajor(), major)
+	}
+	return PGClient{}, nil
+}
+
+// forRestore returns the client for restoring into a server. psql works against newer servers,
+// so only an explicit mapping changes the client.
+func (c PGClientConfig) forRestore(serverVersion string) PGClient {
+	major, ok := parsePostgresMajor(serverVersion)
+	if !ok {
+		return PGClient{}
+	}
+	return c.Clients[major]
+}
+
+// image returns the client image, falling back to the operator image
+func (p PGClient) image(defaultImage string) string {
+	if p.Image != "" {
+		return p.Image
+	}
+	return defaultImage
+}
+
+// env returns the environment for the job runner to find the client
+func (p PGClient) env() []corev1.EnvVar {
+	if p.BinDir == "" {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: "PG_BIN_DIR", Value: p.BinDir}}
+}
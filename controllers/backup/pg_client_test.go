@@ -0,0 +1,132 @@
+package backup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+const testPG19Version = "PostgreSQL 19.1 on x86_64-pc-linux-gnu, compiled by gcc"
+
+func TestParsePostgresMajor(t *testing.T) {
+	tests := []struct {
+		version string
+		major   int
+		ok      bool
+	}{
+		{"PostgreSQL 16.11 on x86_64-pc-linux-gnu, compiled by gcc", 16, true},
+		{"PostgreSQL 9.6.24 on x86_64-pc-linux-gnu", 9, true},
+		{"PostgreSQL 18beta1 on aarch64-unknown-linux-gnu", 18, true},
+		{"", 0, false},
+		{"CockroachDB CCL v23.1", 0, false},
+	}
+	for _, tt := range tests {
+		major, ok := parsePostgresMajor(tt.version)
+		assert.Equal(t, tt.major, major, tt.version)
+		assert.Equal(t, tt.ok, ok, tt.version)
+	}
+}
+
+func TestPGClientConfig_ForBackup(t *testing.T) {
+	cfg := PGClientConfig{Clients: map[int]PGClient{
+		13: {BinDir: "/usr/libexec/postgresql13"},
+		20: {Image: "dbtether:pg20"},
+	}}
+
+	client, err := cfg.forBackup("PostgreSQL 16.4 on x86_64-pc-linux-gnu")
+	require.NoError(t, err)
+	assert.Equal(t, PGClient{}, client, "bundled client handles older servers")
+
+	client, err = cfg.forBackup("PostgreSQL 13.2 on x86_64-pc-linux-gnu")
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/libexec/postgresql13", client.BinDir)
+
+	client, err = cfg.forBackup("PostgreSQL 20.0 on x86_64-pc-linux-gnu")
+	require.NoError(t, err)
+	assert.Equal(t, "dbtether:pg20", client.image(testImage))
+
+	_, err = cfg.forBackup(testPG19Version)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no pg_dump client for PostgreSQL 19")
+
+	client, err = cfg.forBackup("")
+	require.NoError(t, err, "unknown server version should not block backups")
+	assert.Equal(t, testImage, client.image(testImage))
+
+	_, err = PGClientConfig{BundledMajor: 19}.forBackup(testPG19Version)
+	assert.NoError(t, err)
+}
+
+func TestPGClientConfig_ForRestore(t *testing.T) {
+	cfg := PGClientConfig{Clients: map[int]PGClient{20: {Image: "dbtether:pg20"}}}
+	assert.Equal(t, PGClient{}, cfg.forRestore(testPG19Version), "psql can restore into newer servers")
+	assert.Equal(t, "dbtether:pg20", cfg.forRestore("PostgreSQL 20.0").Image)
+}
+
+func reconcileBackupForCluster(t *testing.T, version string, clients PGClientConfig) (*BackupReconciler, *databasesv1alpha1.Backup) {
+	t.Helper()
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Finalizers = []string{backupFinalizer}
+	cluster := newTestCluster(testClusterName)
+	cluster.Status.PostgresVersion = version
+
+	r := newTestReconciler(backup, newTestDatabase(testDBName, testNamespace, testClusterName),
+		cluster, newTestStorage(testStorageName), newTestSecret(testSecretName, testOperatorNS))
+	r.PGClients = clients
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.Background(), req.NamespacedName, backup))
+	return r, backup
+}
+
+func TestBackupReconciler_NoCompatibleClient(t *testing.T) {
+	r, backup := reconcileBackupForCluster(t, testPG19Version, PGClientConfig{})
+
+	assert.Equal(t, "Failed", backup.Status.Phase)
+	assert.Contains(t, backup.Status.Message, "no pg_dump client for PostgreSQL 19")
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	assert.Empty(t, jobs.Items, "no Job should be created")
+}
+
+func TestBackupReconciler_ClientForServerVersion(t *testing.T) {
+	r, backup := reconcileBackupForCluster(t, testPG19Version, PGClientConfig{
+		Clients: map[int]PGClient{19: {Image: "dbtether:pg19", BinDir: "/usr/lib/postgresql/19/bin"}},
+	})
+	assert.Equal(t, "Running", backup.Status.Phase)
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	require.Len(t, jobs.Items, 1)
+
+	container := jobs.Items[0].Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "dbtether:pg19", container.Image)
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "PG_BIN_DIR", Value: "/usr/lib/postgresql/19/bin"})
+}
+
+func TestRestoreReconciler_ClientForServerVersion(t *testing.T) {
+	restore := &databasesv1alpha1.Restore{}
+	restore.Name, restore.Namespace = "restore-orders", testNamespace
+	cluster := newTestCluster(testClusterName)
+	cluster.Status.PostgresVersion = testPG19Version
+
+	r := &RestoreReconciler{Namespace: testOperatorNS, Image: testImage, PGClients: PGClientConfig{
+		Clients: map[int]PGClient{19: {Image: "dbtether:pg19"}},
+	}}
+	job, err := r.buildRestoreJob(restore, newTestDatabase(testDBName, testNamespace, testClusterName),
+		cluster, newTestStorage(testStorageName), "path/backup.sql.gz", "abcd1234")
+	require.NoError(t, err)
+	assert.Equal(t, "dbtether:pg19", job.Spec.Template.Spec.Containers[0].Image)
+}
@@ -0,0 +1,159 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
+)
+
+// mountPVCStorages mounts the claims of pvc storages into a backup or restore Job: the primary
+// storage at pkgbackup.PVCMountPath and each replica at pkgbackup.ReplicaPVCMountPath.
+// primary may be nil to mount only replicas.
+func mountPVCStorages(job *batchv1.Job, primary *databasesv1alpha1.BackupStorage,
+	replicas []*databasesv1alpha1.BackupStorage) {
+
+	if primary != nil && primary.Spec.PVC != nil {
+		mountPVC(job, primary.Spec.PVC, "backup-storage", pkgbackup.PVCMountPath)
+	}
+	for i, replica := range replicas {
+		if replica.Spec.PVC != nil {
+			mountPVC(job, replica.Spec.PVC, fmt.Sprintf("replica-storage-%d", i), pkgbackup.ReplicaPVCMountPath(i))
+		}
+	}
+}
+
+// claimLabelPrefix prefixes the pod label of each claim a Job is pinned for, e.g. pvc.dbtether.io/backups
+const claimLabelPrefix = "pvc.dbtether.io/"
+
+// pinClaimJobs pins a Job that mounts claims which are not ReadWriteMany to the node where other
+// Jobs mount them: the pod gets a label per claim and a required pod affinity to pods with that
+// label on the same node. The first Job schedules freely, since a pod that matches its own
+// affinity terms is placed when no other pod matches them. Call it after applyJobTemplate.
+func pinClaimJobs(ctx context.Context, c client.Reader, job *batchv1.Job) error {
+	podSpec := &job.Spec.Template.Spec
+	for _, volume := range podSpec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		var claim corev1.PersistentVolumeClaim
+		if err := c.Get(ctx, types.NamespacedName{Name: volume.PersistentVolumeClaim.ClaimName, Namespace: job.Namespace}, &claim); err != nil {
+			if errors.IsNotFound(err) {
+				continue // the BackupStorage reports the missing claim
+			}
+			return fmt.Errorf("failed to get persistentvolumeclaim %s: %w", volume.PersistentVolumeClaim.ClaimName, err)
+		}
+		if slices.Contains(claim.Spec.AccessModes, corev1.ReadWriteMany) {
+			continue
+		}
+
+		label := claimLabel(claim.Name)
+		if job.Spec.Template.Labels == nil {
+			job.Spec.Template.Labels = map[string]string{}
+		}
+		job.Spec.Template.Labels[label] = "true"
+
+		// The affinity may be shared with the jobTemplate it came from
+		podSpec.Affinity = podSpec.Affinity.DeepCopy()
+		if podSpec.Affinity == nil {
+			podSpec.Affinity = &corev1.Affinity{}
+		}
+		if podSpec.Affinity.PodAffinity == nil {
+			podSpec.Affinity.PodAffinity = &corev1.PodAffinity{}
+		}
+		podSpec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+			podSpec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution, corev1.PodAffinityTerm{
+				LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: label, Operator: metav1.LabelSelectorOpExists},
+				}},
+				TopologyKey: corev1.LabelHostname,
+			})
+	}
+	return nil
+}
+
+// claimLabel returns the pod label of a claim; label names are limited to 63 characters
+func claimLabel(claimName string) string {
+	if len(claimName) <= 63 {
+		return claimLabelPrefix + claimName
+	}
+	sum := sha256.Sum256([]byte(claimName))
+	return claimLabelPrefix + claimName[:54] + "-" + hex.EncodeToString(sum[:4])
+}
+
+func mountPVC(job *batchv1.Job, pvc *databasesv1alpha1.PVCStorageConfig, volumeName, mountPath string) {
+	podSpec := &job.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.ClaimName},
+		},
+	})
+	for i := range podSpec.Containers {
+		podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: mountPath,
+			SubPath:   pvc.SubPath, // created by the kubelet if missing
+		})
+	}
+}
+
+// jobRetention returns the schedule retention the backup Job applies to pvc and sftp storages,
+// which the schedule controllers do not clean up (see pkgbackup.CleanedUpByJob): the primary
+// storage's and the replicas' by storage name. Backups of a ClusterBackupSchedule get its retention
+// through their owner reference; Backups not created by a schedule have no retention.
+func (r *BackupReconciler) jobRetention(ctx context.Context, backup *databasesv1alpha1.Backup,
+	storage *databasesv1alpha1.BackupStorage, replicas []*databasesv1alpha1.BackupStorage) (
+	*databasesv1alpha1.RetentionPolicy, map[string]*databasesv1alpha1.RetentionPolicy) {
+
+	if clusterSchedule, err := r.owningClusterSchedule(ctx, backup); err != nil || clusterSchedule != nil {
+		if clusterSchedule == nil || !pkgbackup.CleanedUpByJob(storage.GetProvider()) {
+			return nil, nil
+		}
+		return clusterSchedule.Spec.Retention, nil
+	}
+
+	name, namespace := backup.Labels[LabelScheduleName], backup.Labels[LabelScheduleNS]
+	if name == "" || namespace == "" {
+		return nil, nil
+	}
+	var schedule databasesv1alpha1.BackupSchedule
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &schedule); err != nil {
+		return nil, nil
+	}
+
+	var primary *databasesv1alpha1.RetentionPolicy
+	if pkgbackup.CleanedUpByJob(storage.GetProvider()) {
+		primary = schedule.Spec.Retention
+	}
+	byStorage := map[string]*databasesv1alpha1.RetentionPolicy{}
+	for _, target := range retentionTargets(&schedule) {
+		for _, replica := range replicas {
+			if replica.Name == target.storage && pkgbackup.CleanedUpByJob(replica.GetProvider()) {
+				byStorage[replica.Name] = target.retention
+			}
+		}
+	}
+	return primary, byStorage
+}
+
+// retentionEnv passes the retention of a pvc or sftp primary storage to the backup Job
+func retentionEnv(retention *databasesv1alpha1.RetentionPolicy) []corev1.EnvVar {
+	if retention == nil {
+		return nil
+	}
+	data, _ := json.Marshal(retention)
+	return []corev1.EnvVar{{Name: "RETENTION", Value: string(data)}}
+}
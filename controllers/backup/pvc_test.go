@@ -0,0 +1,325 @@
+package backup
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+func newTestPVCStorage(name, claimName string) *databasesv1alpha1.BackupStorage {
+	storage := newTestStorage(name)
+	storage.Spec.S3 = nil
+	storage.Spec.PVC = &databasesv1alpha1.PVCStorageConfig{ClaimName: claimName}
+	return storage
+}
+
+func newTestClaim(name string, modes ...corev1.PersistentVolumeAccessMode) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testOperatorNS},
+		Spec:       corev1.PersistentVolumeClaimSpec{AccessModes: modes},
+	}
+}
+
+func jobEnv(job *batchv1.Job) map[string]string {
+	env := map[string]string{}
+	for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	return env
+}
+
+func TestBackupReconciler_PVCStorage(t *testing.T) {
+	keepLast, keepDaily := 7, 3
+	schedule := &databasesv1alpha1.BackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: testNamespace},
+		Spec: databasesv1alpha1.BackupScheduleSpec{
+			Retention: &databasesv1alpha1.RetentionPolicy{KeepLast: &keepLast},
+			Replicas: []databasesv1alpha1.BackupReplica{
+				{StorageRef: databasesv1alpha1.StorageReference{Name: "nfs-dr"},
+					Retention: &databasesv1alpha1.RetentionPolicy{KeepDaily: &keepDaily}},
+				{StorageRef: databasesv1alpha1.StorageReference{Name: "dr-eu"}},
+			},
+		},
+	}
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Finalizers = []string{backupFinalizer}
+	backup.Labels = map[string]string{LabelScheduleName: "nightly", LabelScheduleNS: testNamespace}
+	backup.Spec.Replicas = []databasesv1alpha1.StorageReference{{Name: "nfs-dr"}, {Name: "dr-eu"}}
+
+	storage := newTestPVCStorage(testStorageName, "backups")
+	storage.Spec.PVC.SubPath = "postgres"
+	r := newTestReconciler(backup, schedule, newTestDatabase(testDBName, testNamespace, testClusterName),
+		newTestCluster(testClusterName), storage, newTestPVCStorage("nfs-dr", "dr-backups"),
+		newTestReplicaStorage("dr-eu"), newTestSecret(testSecretName, testOperatorNS),
+		newTestClaim("backups", corev1.ReadWriteOnce), newTestClaim("dr-backups", corev1.ReadWriteMany))
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	require.Len(t, jobs.Items, 1)
+	job := &jobs.Items[0]
+
+	env := jobEnv(job)
+	assert.Equal(t, "pvc", env["STORAGE_TYPE"])
+	assert.JSONEq(t, `{"keepLast":7}`, env["RETENTION"])
+	assert.JSONEq(t, `[{"name":"nfs-dr","pvc":{"claimName":"dr-backups"},"pathTemplate":"{{ .ClusterName }}/{{ .DatabaseName }}","retention":{"keepDaily":3}},`+
+		`{"name":"dr-eu","s3":{"bucket":"dr-bucket","region":"eu-west-1"},"pathTemplate":"replica/{{ .DatabaseName }}"}]`,
+		env["REPLICAS"])
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, []corev1.Volume{
+		{Name: "backup-storage", VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "backups"}}},
+		{Name: "replica-storage-0", VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "dr-backups"}}},
+	}, podSpec.Volumes)
+	assert.Equal(t, []corev1.VolumeMount{
+		{Name: "backup-storage", MountPath: "/var/lib/dbtether/backups", SubPath: "postgres"},
+		{Name: "replica-storage-0", MountPath: "/var/lib/dbtether/replicas/0"},
+	}, podSpec.Containers[0].VolumeMounts)
+	assert.Equal(t, "true", job.Spec.Template.Labels["pvc.dbtether.io/backups"], "ReadWriteOnce claims pin the Job")
+	assert.NotContains(t, job.Spec.Template.Labels, "pvc.dbtether.io/dr-backups")
+}
+
+func TestBackupReconciler_PVCStorageClusterScheduleRetention(t *testing.T) {
+	keepLast := 5
+	schedule := &databasesv1alpha1.ClusterBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", UID: "cluster-schedule-uid"},
+		Spec: databasesv1alpha1.ClusterBackupScheduleSpec{
+			ClusterRef: databasesv1alpha1.ClusterReference{Name: testClusterName},
+			StorageRef: databasesv1alpha1.StorageReference{Name: testStorageName},
+			Schedule:   "0 2 * * *",
+			Retention:  &databasesv1alpha1.RetentionPolicy{KeepLast: &keepLast},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		uid           types.UID
+		wantRetention bool
+	}{
+		{"owned by the schedule", "cluster-schedule-uid", true},
+		{"copied owner reference", "other-uid", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := newTestBackup(testBackupName, testNamespace)
+			backup.Finalizers = []string{backupFinalizer}
+			owner := schedule.DeepCopy()
+			owner.UID = tt.uid
+			backup.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(owner,
+				databasesv1alpha1.GroupVersion.WithKind("ClusterBackupSchedule"))}
+
+			r := newTestReconciler(backup, schedule, newTestDatabase(testDBName, testNamespace, testClusterName),
+				newTestCluster(testClusterName), newTestPVCStorage(testStorageName, "backups"),
+				newTestSecret(testSecretName, testOperatorNS), newTestClaim("backups", corev1.ReadWriteMany))
+
+			job := reconcileBackupJob(t, r)
+			if tt.wantRetention {
+				assert.JSONEq(t, `{"keepLast":5}`, jobEnv(&job)["RETENTION"])
+			} else {
+				assert.NotContains(t, jobEnv(&job), "RETENTION")
+			}
+		})
+	}
+}
+
+func TestBackupReconciler_PVCStorageWithoutSchedule(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Finalizers = []string{backupFinalizer}
+
+	r := newTestReconciler(backup, newTestDatabase(testDBName, testNamespace, testClusterName),
+		newTestCluster(testClusterName), newTestPVCStorage(testStorageName, "backups"),
+		newTestSecret(testSecretName, testOperatorNS))
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	require.Len(t, jobs.Items, 1)
+	assert.NotContains(t, jobEnv(&jobs.Items[0]), "RETENTION", "one-off backups have no retention")
+	assert.Len(t, jobs.Items[0].Spec.Template.Spec.Volumes, 1)
+}
+
+func TestRestoreReconciler_PVCStorage(t *testing.T) {
+	r := &RestoreReconciler{Namespace: testOperatorNS, Image: testImage}
+	restore := &databasesv1alpha1.Restore{}
+	restore.Name, restore.Namespace = "restore-orders", testNamespace
+	db := newTestDatabase(testDBName, testNamespace, testClusterName)
+	db.Status.DatabaseName = "orders"
+
+	job, err := r.buildRestoreJob(restore, db, newTestCluster(testClusterName),
+		newTestPVCStorage(testStorageName, "backups"), "main/orders/backup.sql.gz", "abc12345")
+	require.NoError(t, err)
+
+	assert.Equal(t, "pvc", jobEnv(job)["STORAGE_TYPE"])
+	require.Len(t, job.Spec.Template.Spec.Volumes, 1)
+	assert.Equal(t, "backups", job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, "/var/lib/dbtether/backups", job.Spec.Template.Spec.Containers[0].VolumeMounts[0].MountPath)
+}
+
+func TestBackupStorageReconciler_ValidateClaim(t *testing.T) {
+	scheduleUsing := func(name, storage, replica string) *databasesv1alpha1.BackupSchedule {
+		schedule := &databasesv1alpha1.BackupSchedule{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace}}
+		schedule.Spec.StorageRef.Name = storage
+		if replica != "" {
+			schedule.Spec.Replicas = []databasesv1alpha1.BackupReplica{{StorageRef: databasesv1alpha1.StorageReference{Name: replica}}}
+		}
+		return schedule
+	}
+
+	clusterSchedule := &databasesv1alpha1.ClusterBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "all"},
+		Spec:       databasesv1alpha1.ClusterBackupScheduleSpec{StorageRef: databasesv1alpha1.StorageReference{Name: "nfs"}},
+	}
+
+	tests := []struct {
+		name          string
+		objects       []client.Object
+		maxConcurrent int
+		wantErr       string
+	}{
+		{
+			name:    "claim not found",
+			wantErr: "persistentvolumeclaim dbtether/backups not found",
+		},
+		{
+			name:          "ReadWriteOnce used by one schedule",
+			objects:       []client.Object{newTestClaim("backups", corev1.ReadWriteOnce), scheduleUsing("a", "nfs", "")},
+			maxConcurrent: 1,
+		},
+		{
+			name:    "ReadWriteOnce with parallel backups",
+			objects: []client.Object{newTestClaim("backups", corev1.ReadWriteOnce), scheduleUsing("a", "nfs", "")},
+			wantErr: "persistentvolumeclaim backups must be ReadWriteMany: up to 3 backup Jobs per DBCluster " +
+				"run at the same time (backup.maxConcurrentPerCluster)",
+		},
+		{
+			name: "ReadWriteOnce shared by schedules",
+			objects: []client.Object{newTestClaim("backups", corev1.ReadWriteOnce),
+				scheduleUsing("a", "nfs", ""), scheduleUsing("b", "s3", "nfs"), scheduleUsing("c", "s3", "")},
+			maxConcurrent: 1,
+			wantErr:       "persistentvolumeclaim backups is shared by 2 schedules and must be ReadWriteMany",
+		},
+		{
+			name:          "ReadWriteOnce used by a cluster schedule",
+			objects:       []client.Object{newTestClaim("backups", corev1.ReadWriteOnce), clusterSchedule},
+			maxConcurrent: 1,
+			wantErr:       "persistentvolumeclaim backups is used by ClusterBackupSchedule all and must be ReadWriteMany",
+		},
+		{
+			name: "ReadWriteMany shared by schedules",
+			objects: []client.Object{newTestClaim("backups", corev1.ReadWriteMany),
+				scheduleUsing("a", "nfs", ""), scheduleUsing("b", "nfs", ""), clusterSchedule},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &BackupStorageReconciler{
+				Client:               fake.NewClientBuilder().WithScheme(newTestScheme()).WithObjects(tt.objects...).Build(),
+				Namespace:            testOperatorNS,
+				MaxConcurrentBackups: tt.maxConcurrent,
+			}
+
+			err := r.validateClaim(context.Background(), newTestPVCStorage("nfs", "backups"))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBackupStorageReconciler_RevalidatesClaimOnScheduleChange(t *testing.T) {
+	storage := newTestPVCStorage("nfs", "backups")
+	storage.Generation = 1
+	storage.Status = databasesv1alpha1.BackupStorageStatus{
+		Phase: "Ready", ObservedGeneration: 1, LastValidation: metav1.Now(),
+	}
+	schedule := &databasesv1alpha1.ClusterBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "all"},
+		Spec:       databasesv1alpha1.ClusterBackupScheduleSpec{StorageRef: databasesv1alpha1.StorageReference{Name: "nfs"}},
+	}
+	r := &BackupStorageReconciler{
+		Client: fake.NewClientBuilder().WithScheme(newTestScheme()).
+			WithObjects(storage, schedule, newTestClaim("backups", corev1.ReadWriteOnce)).
+			WithStatusSubresource(&databasesv1alpha1.BackupStorage{}).Build(),
+		Namespace:            testOperatorNS,
+		MaxConcurrentBackups: 1,
+	}
+
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "nfs"}}},
+		r.storagesForSchedule(context.Background(), schedule))
+
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "nfs"}})
+	require.NoError(t, err)
+
+	var updated databasesv1alpha1.BackupStorage
+	require.NoError(t, r.Get(context.Background(), types.NamespacedName{Name: "nfs"}, &updated))
+	assert.Equal(t, "Failed", updated.Status.Phase, "a new ClusterBackupSchedule must fail a ReadWriteOnce claim")
+	assert.Contains(t, updated.Status.Message, "ClusterBackupSchedule all")
+}
+
+func TestStoragesForSchedule(t *testing.T) {
+	r := &BackupStorageReconciler{}
+	schedule := &databasesv1alpha1.BackupSchedule{}
+	schedule.Spec.StorageRef.Name = "nfs"
+	schedule.Spec.Replicas = []databasesv1alpha1.BackupReplica{{StorageRef: databasesv1alpha1.StorageReference{Name: "dr-eu"}}}
+
+	assert.Equal(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "nfs"}},
+		{NamespacedName: types.NamespacedName{Name: "dr-eu"}},
+	}, r.storagesForSchedule(context.Background(), schedule))
+}
+
+func TestPinClaimJobs(t *testing.T) {
+	templateAffinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: testOperatorNS}}
+	mountPVCStorages(job, newTestPVCStorage("nfs", "backups"), []*databasesv1alpha1.BackupStorage{
+		newTestPVCStorage("shared", "shared-backups"), newTestPVCStorage("gone", "missing")})
+	job.Spec.Template.Spec.Affinity = templateAffinity
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme()).WithObjects(
+		newTestClaim("backups", corev1.ReadWriteOnce), newTestClaim("shared-backups", corev1.ReadWriteMany)).Build()
+	require.NoError(t, pinClaimJobs(context.Background(), c, job))
+
+	assert.Equal(t, map[string]string{"pvc.dbtether.io/backups": "true"}, job.Spec.Template.Labels)
+	affinity := job.Spec.Template.Spec.Affinity
+	require.NotNil(t, affinity.PodAffinity)
+	assert.NotNil(t, affinity.NodeAffinity, "the jobTemplate affinity is kept")
+	assert.Nil(t, templateAffinity.PodAffinity, "the jobTemplate affinity is not modified")
+	assert.Equal(t, []corev1.PodAffinityTerm{{
+		LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "pvc.dbtether.io/backups", Operator: metav1.LabelSelectorOpExists},
+		}},
+		TopologyKey: corev1.LabelHostname,
+	}}, affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+}
+
+func TestClaimLabel(t *testing.T) {
+	assert.Equal(t, "pvc.dbtether.io/backups", claimLabel("backups"))
+
+	long := claimLabel(strings.Repeat("a", 100))
+	assert.Len(t, strings.TrimPrefix(long, claimLabelPrefix), 63)
+	assert.NotEqual(t, long, claimLabel(strings.Repeat("a", 99)+"b"))
+}
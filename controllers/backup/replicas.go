@@ -0,0 +1,126 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
+)
+
+// getReplicaStorages returns the replica BackupStorages of a backup.
+// Every replica must exist and be Ready, like the primary storage.
+func getReplicaStorages(ctx context.Context, c client.Client, primary string,
+	refs []databasesv1alpha1.StorageReference) ([]*databasesv1alpha1.BackupStorage, error) {
+
+	seen := map[string]bool{primary: true}
+	storages := make([]*databasesv1alpha1.BackupStorage, 0, len(refs))
+	for _, ref := range refs {
+		if seen[ref.Name] {
+			return nil, fmt.Errorf("replica storage %s is used more than once", ref.Name)
+		}
+		seen[ref.Name] = true
+
+		var storage databasesv1alpha1.BackupStorage
+		if err := c.Get(ctx, types.NamespacedName{Name: ref.Name}, &storage); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, fmt.Errorf("replica storage %s not found", ref.Name)
+			}
+			return nil, err
+		}
+		if storage.Status.Phase != "Ready" {
+			return nil, fmt.Errorf("replica storage %s is not ready (phase: %s)", ref.Name, storage.Status.Phase)
+		}
+		storages = append(storages, &storage)
+	}
+	return storages, nil
+}
+
+// replicasEnv passes the replica storages to the Job as JSON, with the credentials secret keys
+// of each replica as REPLICA_<index>_* secret references. paths sets the backup file per replica
+// for restores and is nil for backups; retention holds the retention of replicas the Job cleans
+// up, by name.
+func replicasEnv(storages []*databasesv1alpha1.BackupStorage, paths []string,
+	retention map[string]*databasesv1alpha1.RetentionPolicy) []corev1.EnvVar {
+	if len(storages) == 0 {
+		return nil
+	}
+
+	var env []corev1.EnvVar
+	replicas := make([]pkgbackup.Replica, 0, len(storages))
+	for i, storage := range storages {
+		replica := pkgbackup.NewReplica(storage)
+		if paths != nil {
+			replica.Path = paths[i]
+		}
+		replica.Retention = retention[storage.Name]
+		replicas = append(replicas, replica)
+
+		env = append(env, credentialsEnv(storage, func(key string) string {
+			return pkgbackup.ReplicaCredentialEnv(i, key)
+		})...)
+	}
+
+	data, _ := json.Marshal(replicas)
+	return append([]corev1.EnvVar{{Name: "REPLICAS", Value: string(data)}}, env...)
+}
+
+// replicaResultsFromJob reads the replica results the Job reported in its annotations
+func replicaResultsFromJob(job *batchv1.Job) []databasesv1alpha1.ReplicaStatus {
+	data := job.Annotations[pkgbackup.ReplicasAnnotation]
+	if data == "" {
+		return nil
+	}
+	var results []databasesv1alpha1.ReplicaStatus
+	if err := json.Unmarshal([]byte(data), &results); err != nil {
+		return nil
+	}
+	return results
+}
+
+// failedReplicasSuffix lists failed replicas for the status message, e.g. " (failed replicas: dr-eu)"
+func failedReplicasSuffix(results []databasesv1alpha1.ReplicaStatus) string {
+	var failed []string
+	for _, result := range results {
+		if result.Phase == databasesv1alpha1.ReplicaFailed {
+			failed = append(failed, result.Storage)
+		}
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (failed replicas: %s)", strings.Join(failed, ", "))
+}
+
+// completedReplicaStorages returns the Ready storages holding a completed replica of a backup,
+// with the path of the backup in each. Unavailable storages are skipped: replicas are only a
+// fallback and must not block a restore from the primary storage.
+func completedReplicaStorages(ctx context.Context, c client.Client,
+	backup *databasesv1alpha1.Backup) ([]*databasesv1alpha1.BackupStorage, []string) {
+
+	var storages []*databasesv1alpha1.BackupStorage
+	var paths []string
+	for _, replica := range backup.Status.Replicas {
+		if replica.Phase != databasesv1alpha1.ReplicaCompleted || replica.Path == "" {
+			continue
+		}
+		var storage databasesv1alpha1.BackupStorage
+		if err := c.Get(ctx, types.NamespacedName{Name: replica.Storage}, &storage); err != nil {
+			continue
+		}
+		if storage.Status.Phase != "Ready" {
+			continue
+		}
+		storages = append(storages, &storage)
+		paths = append(paths, replica.Path)
+	}
+	return storages, paths
+}
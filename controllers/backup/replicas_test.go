@@ -0,0 +1,306 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
+)
+
+func newTestReplicaStorage(name string) *databasesv1alpha1.BackupStorage {
+	storage := newTestStorage(name)
+	storage.Spec.S3.Bucket = "dr-bucket"
+	storage.Spec.S3.Region = "eu-west-1"
+	storage.Spec.PathTemplate = "replica/{{ .DatabaseName }}"
+	storage.Spec.CredentialsSecretRef = &databasesv1alpha1.SecretReference{Name: "dr-credentials"}
+	return storage
+}
+
+func TestBackupReconciler_ReplicasPassedToJob(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Finalizers = []string{backupFinalizer}
+	backup.Spec.Replicas = []databasesv1alpha1.StorageReference{{Name: "dr-eu"}}
+
+	r := newTestReconciler(backup, newTestDatabase(testDBName, testNamespace, testClusterName),
+		newTestCluster(testClusterName), newTestStorage(testStorageName), newTestReplicaStorage("dr-eu"),
+		newTestSecret(testSecretName, testOperatorNS))
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	require.Len(t, jobs.Items, 1)
+
+	env := map[string]corev1.EnvVar{}
+	for _, e := range jobs.Items[0].Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e
+	}
+	assert.JSONEq(t, `[{"name":"dr-eu","s3":{"bucket":"dr-bucket","region":"eu-west-1"},`+
+		`"pathTemplate":"replica/{{ .DatabaseName }}"}]`, env["REPLICAS"].Value)
+
+	accessKey := env["REPLICA_0_AWS_ACCESS_KEY_ID"]
+	require.NotNil(t, accessKey.ValueFrom)
+	assert.Equal(t, "dr-credentials", accessKey.ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "AWS_ACCESS_KEY_ID", accessKey.ValueFrom.SecretKeyRef.Key)
+	assert.Contains(t, env, "REPLICA_0_AWS_SECRET_ACCESS_KEY")
+}
+
+func TestBackupReconciler_InvalidReplicas(t *testing.T) {
+	notReady := newTestReplicaStorage("dr-pending")
+	notReady.Status.Phase = "Failed"
+
+	tests := []struct {
+		name        string
+		replicas    []string
+		wantMessage string
+	}{
+		{name: "missing", replicas: []string{"dr-missing"}, wantMessage: "replica storage dr-missing not found"},
+		{name: "not ready", replicas: []string{"dr-pending"}, wantMessage: "replica storage dr-pending is not ready (phase: Failed)"},
+		{name: "primary storage", replicas: []string{testStorageName}, wantMessage: "replica storage " + testStorageName + " is used more than once"},
+		{name: "duplicate", replicas: []string{"dr-eu", "dr-eu"}, wantMessage: "replica storage dr-eu is used more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := newTestBackup(testBackupName, testNamespace)
+			backup.Finalizers = []string{backupFinalizer}
+			for _, name := range tt.replicas {
+				backup.Spec.Replicas = append(backup.Spec.Replicas, databasesv1alpha1.StorageReference{Name: name})
+			}
+
+			r := newTestReconciler(backup, newTestDatabase(testDBName, testNamespace, testClusterName),
+				newTestCluster(testClusterName), newTestStorage(testStorageName), newTestReplicaStorage("dr-eu"), notReady)
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+			_, err := r.Reconcile(context.Background(), req)
+			require.NoError(t, err)
+
+			var updated databasesv1alpha1.Backup
+			require.NoError(t, r.Get(context.Background(), req.NamespacedName, &updated))
+			assert.Equal(t, "Failed", updated.Status.Phase)
+			assert.Equal(t, tt.wantMessage, updated.Status.Message)
+
+			var jobs batchv1.JobList
+			require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+			assert.Empty(t, jobs.Items)
+		})
+	}
+}
+
+func TestBackupReconciler_ReplicaResultsInStatus(t *testing.T) {
+	backup := newTestBackup(testBackupName, testNamespace)
+	r := newTestReconciler(backup)
+
+	results := []databasesv1alpha1.ReplicaStatus{
+		{Storage: "dr-eu", Phase: databasesv1alpha1.ReplicaCompleted, Path: "replica/orders/backup.sql.gz"},
+		{Storage: "dr-gcs", Phase: databasesv1alpha1.ReplicaFailed, Message: "GCS upload failed: permission denied"},
+	}
+	data, err := json.Marshal(results)
+	require.NoError(t, err)
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		"dbtether.io/backup-path":    "main/orders/backup.sql.gz",
+		pkgbackup.ReplicasAnnotation: string(data),
+	}}}
+
+	_, err = r.updateStatusCompleted(context.Background(), backup, job, "hash")
+	require.NoError(t, err)
+
+	var updated databasesv1alpha1.Backup
+	require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(backup), &updated))
+	assert.Equal(t, "Completed", updated.Status.Phase, "a failed replica does not fail the backup")
+	assert.Equal(t, "backup completed successfully (failed replicas: dr-gcs)", updated.Status.Message)
+	assert.Equal(t, results, updated.Status.Replicas)
+}
+
+func TestReplicaResultsFromJob_Invalid(t *testing.T) {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		pkgbackup.ReplicasAnnotation: "not json",
+	}}}
+	assert.Nil(t, replicaResultsFromJob(job))
+	assert.Nil(t, replicaResultsFromJob(&batchv1.Job{}))
+	assert.Empty(t, failedReplicasSuffix(nil))
+}
+
+func TestRestoreReconciler_PassesCompletedReplicas(t *testing.T) {
+	now := metav1.Now()
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Status = databasesv1alpha1.BackupStatus{
+		Phase:       "Completed",
+		Path:        "main/orders/backup.sql.gz",
+		CompletedAt: &now,
+		Replicas: []databasesv1alpha1.ReplicaStatus{
+			{Storage: "dr-gcs", Phase: databasesv1alpha1.ReplicaFailed, Message: "GCS upload failed"},
+			{Storage: "dr-deleted", Phase: databasesv1alpha1.ReplicaCompleted, Path: "replica/orders/backup.sql.gz"},
+			{Storage: "dr-eu", Phase: databasesv1alpha1.ReplicaCompleted, Path: "replica/orders/backup.sql.gz"},
+		},
+	}
+
+	restore := &databasesv1alpha1.Restore{}
+	restore.Name, restore.Namespace = "restore-orders", testNamespace
+	restore.Spec.Source.BackupRef = &databasesv1alpha1.BackupReference{Name: testBackupName}
+	restore.Spec.Target.DatabaseRef.Name = testDBName
+	restore.Spec.ReplicaFallback = databasesv1alpha1.ReplicaFallbackAny
+
+	scheme := newTestScheme()
+	r := &RestoreReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(restore, backup, newTestDatabase(testDBName, testNamespace, testClusterName),
+				newTestCluster(testClusterName), newTestStorage(testStorageName), newTestReplicaStorage("dr-eu"),
+				newTestReplicaStorage("dr-gcs")).
+			WithStatusSubresource(&databasesv1alpha1.Restore{}).Build(),
+		Scheme:    scheme,
+		Namespace: testOperatorNS,
+		Image:     testImage,
+	}
+
+	_, err := r.createRestoreJob(context.Background(), restore, "hash", logr.Discard())
+	require.NoError(t, err)
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	require.Len(t, jobs.Items, 1)
+
+	env := map[string]string{}
+	for _, e := range jobs.Items[0].Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	assert.Equal(t, "main/orders/backup.sql.gz", env["SOURCE_PATH"])
+	assert.JSONEq(t, `[{"name":"dr-eu","s3":{"bucket":"dr-bucket","region":"eu-west-1"},`+
+		`"pathTemplate":"replica/{{ .DatabaseName }}","path":"replica/orders/backup.sql.gz"}]`, env["REPLICAS"])
+	assert.Contains(t, env, "REPLICA_0_AWS_ACCESS_KEY_ID")
+	assert.Equal(t, databasesv1alpha1.ReplicaFallbackAny, env["REPLICA_FALLBACK"])
+}
+
+func TestRestoreReconciler_DirectPathHasNoReplicas(t *testing.T) {
+	restore := &databasesv1alpha1.Restore{}
+	restore.Name, restore.Namespace = "restore-orders", testNamespace
+	restore.Spec.Source.Path = "main/orders/backup.sql.gz"
+	restore.Spec.Source.StorageRef = &databasesv1alpha1.StorageReference{Name: testStorageName}
+	restore.Spec.Target.DatabaseRef.Name = testDBName
+
+	scheme := newTestScheme()
+	r := &RestoreReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(restore, newTestDatabase(testDBName, testNamespace, testClusterName),
+				newTestCluster(testClusterName), newTestStorage(testStorageName),
+				newTestArtifact("backup-storage-0123456789", testNamespace, testStorageName,
+					"main/orders/backup.sql.gz", "20260120-020000")).
+			WithStatusSubresource(&databasesv1alpha1.Restore{}).Build(),
+		Scheme:    scheme,
+		Namespace: testOperatorNS,
+	}
+
+	_, err := r.createRestoreJob(context.Background(), restore, "hash", logr.Discard())
+	require.NoError(t, err)
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	require.Len(t, jobs.Items, 1)
+	for _, e := range jobs.Items[0].Spec.Template.Spec.Containers[0].Env {
+		assert.NotEqual(t, "REPLICAS", e.Name)
+	}
+}
+
+func TestRestoreReconciler_CompletedFromReplica(t *testing.T) {
+	restore := &databasesv1alpha1.Restore{}
+	restore.Name, restore.Namespace = "restore-orders", testNamespace
+
+	scheme := newTestScheme()
+	r := &RestoreReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(restore).
+			WithStatusSubresource(&databasesv1alpha1.Restore{}).Build(),
+		Scheme: scheme,
+	}
+
+	_, err := r.updateStatusCompleted(context.Background(), restore, "hash", "12s", nil, "dr-eu",
+		"object not found: main/orders/backup.sql.gz")
+	require.NoError(t, err)
+
+	var updated databasesv1alpha1.Restore
+	require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(restore), &updated))
+	assert.Equal(t, "dr-eu", updated.Status.SourceReplica)
+	assert.Equal(t, "object not found: main/orders/backup.sql.gz", updated.Status.PrimaryError)
+	assert.Equal(t, "restore completed successfully from replica dr-eu "+
+		"(primary storage: object not found: main/orders/backup.sql.gz)", updated.Status.Message)
+}
+
+func TestScheduleReconciler_CreateBackup_InheritsReplicas(t *testing.T) {
+	scheme := newTestScheme()
+	schedule := &databasesv1alpha1.BackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: testNamespace, UID: testUID},
+		Spec: databasesv1alpha1.BackupScheduleSpec{
+			DatabaseRef: databasesv1alpha1.DatabaseReference{Name: testDBName},
+			StorageRef:  databasesv1alpha1.StorageReference{Name: testStorageName},
+			Schedule:    testCronSchedule,
+			Replicas: []databasesv1alpha1.BackupReplica{
+				{StorageRef: databasesv1alpha1.StorageReference{Name: "dr-eu"}},
+				{StorageRef: databasesv1alpha1.StorageReference{Name: "archive"},
+					Retention: &databasesv1alpha1.RetentionPolicy{KeepLast: intPtr(30)}},
+			},
+		},
+	}
+	r := &BackupScheduleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(schedule).Build(),
+		Scheme: scheme,
+	}
+
+	backup, err := r.createBackup(context.Background(), schedule, "nightly-20260120-0200")
+	require.NoError(t, err)
+	assert.Equal(t, []databasesv1alpha1.StorageReference{{Name: "dr-eu"}, {Name: "archive"}}, backup.Spec.Replicas)
+}
+
+func TestRetentionTargets(t *testing.T) {
+	primary := &databasesv1alpha1.RetentionPolicy{KeepLast: intPtr(7)}
+	archive := &databasesv1alpha1.RetentionPolicy{KeepLast: intPtr(90)}
+	replicas := []databasesv1alpha1.BackupReplica{
+		{StorageRef: databasesv1alpha1.StorageReference{Name: "dr-eu"}},
+		{StorageRef: databasesv1alpha1.StorageReference{Name: "archive"}, Retention: archive},
+	}
+
+	tests := []struct {
+		name      string
+		retention *databasesv1alpha1.RetentionPolicy
+		replicas  []databasesv1alpha1.BackupReplica
+		want      []retentionTarget
+	}{
+		{name: "no retention"},
+		{name: "primary only", retention: primary, want: []retentionTarget{{testStorageName, primary}}},
+		{
+			name:      "replicas inherit or override",
+			retention: primary,
+			replicas:  replicas,
+			want:      []retentionTarget{{testStorageName, primary}, {"dr-eu", primary}, {"archive", archive}},
+		},
+		{
+			name:     "replica retention without schedule retention",
+			replicas: replicas,
+			want:     []retentionTarget{{"archive", archive}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := &databasesv1alpha1.BackupSchedule{Spec: databasesv1alpha1.BackupScheduleSpec{
+				StorageRef: databasesv1alpha1.StorageReference{Name: testStorageName},
+				Retention:  tt.retention,
+				Replicas:   tt.replicas,
+			}}
+			assert.Equal(t, tt.want, retentionTargets(schedule))
+		})
+	}
+}
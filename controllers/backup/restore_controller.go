@@ -21,6 +21,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
+	"github.com/certainty3452/dbtether/pkg/notify"
 )
 
 const restoreFinalizer = "dbtether.io/restore-job"
@@ -36,11 +38,19 @@ type RestoreReconciler struct {
 	Scheme    *runtime.Scheme
 	Image     string
 	Namespace string
+	// JobDefaults are operator-wide Job pod settings, overridden by BackupStorage and Restore jobTemplate
+	JobDefaults *databasesv1alpha1.JobTemplate
+	// PGClients selects the psql client by server major version
+	PGClients PGClientConfig
+	// Notifier receives RestoreCompleted and RestoreFailed events, nil disables notifications
+	Notifier notify.Notifier
 }
 
 // +kubebuilder:rbac:groups=dbtether.io,resources=restores,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=dbtether.io,resources=restores/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=dbtether.io,resources=restores/finalizers,verbs=update
+// +kubebuilder:rbac:groups=dbtether.io,resources=databasereferencegrants,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbtether.io,resources=backupartifacts,verbs=get;list;watch
 
 func (r *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -106,7 +116,7 @@ func (r *RestoreReconciler) createRestoreJob(
 	logger logr.Logger,
 ) (ctrl.Result, error) {
 	// Resolve source path
-	sourcePath, storageRef, err := r.resolveSource(ctx, restore)
+	sourcePath, storageRef, sourceBackup, err := r.resolveSourceBackup(ctx, restore)
 	if err != nil {
 		return r.updateStatus(ctx, restore, "Failed", fmt.Sprintf("failed to resolve source: %v", err), specHash)
 	}
@@ -126,6 +136,10 @@ func (r *RestoreReconciler) createRestoreJob(
 		return r.updateStatus(ctx, restore, "Failed", fmt.Sprintf("cluster not found: %v", err), specHash)
 	}
 
+	if err := validateHooks(restore.Spec.PreHooks, restore.Spec.PostHooks, &cluster); err != nil {
+		return r.updateStatus(ctx, restore, "Failed", err.Error(), specHash)
+	}
+
 	// Get BackupStorage
 	var storage databasesv1alpha1.BackupStorage
 	if err := r.Get(ctx, types.NamespacedName{Name: storageRef}, &storage); err != nil {
@@ -141,6 +155,21 @@ func (r *RestoreReconciler) createRestoreJob(
 		return r.updateStatus(ctx, restore, "Failed", fmt.Sprintf("failed to build job: %v", err), specHash)
 	}
 
+	// Replicas of the source backup are tried when the primary object is missing
+	// (or on any primary error with replicaFallback: Any)
+	if sourceBackup != nil {
+		replicas, paths := completedReplicaStorages(ctx, r.Client, sourceBackup)
+		container := &job.Spec.Template.Spec.Containers[0]
+		container.Env = append(container.Env, replicasEnv(replicas, paths, nil)...)
+		if len(replicas) > 0 && restore.Spec.ReplicaFallback != "" {
+			container.Env = append(container.Env, corev1.EnvVar{Name: "REPLICA_FALLBACK", Value: restore.Spec.ReplicaFallback})
+		}
+		mountPVCStorages(job, nil, replicas)
+	}
+	if err := pinClaimJobs(ctx, r.Client, job); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Note: No owner reference set because Job runs in operator namespace,
 	// while Restore CRD is in user namespace. Cleanup handled by TTL and finalizer.
 
@@ -158,72 +187,111 @@ func (r *RestoreReconciler) createRestoreJob(
 }
 
 func (r *RestoreReconciler) resolveSource(ctx context.Context, restore *databasesv1alpha1.Restore) (sourcePath, storageRefName string, err error) {
+	sourcePath, storageRefName, _, err = r.resolveSourceBackup(ctx, restore)
+	return sourcePath, storageRefName, err
+}
+
+// resolveSourceBackup resolves the source path and storage, and the Backup they come from
+// (nil for a BackupArtifact or a direct path without a Backup)
+func (r *RestoreReconciler) resolveSourceBackup(ctx context.Context, restore *databasesv1alpha1.Restore) (
+	sourcePath, storageRefName string, backup *databasesv1alpha1.Backup, err error) {
+
 	source := restore.Spec.Source
 
+	switch {
 	// Option 1: BackupRef - get path from existing Backup
-	if source.BackupRef != nil {
-		return r.resolveFromBackupRef(ctx, restore, source.BackupRef)
-	}
+	case source.BackupRef != nil:
+		backup, err = r.resolveFromBackupRef(ctx, restore, source.BackupRef)
 
 	// Option 2: LatestFrom - find latest successful backup for a database
-	if source.LatestFrom != nil {
-		return r.resolveFromLatest(ctx, restore, source.LatestFrom)
-	}
+	case source.LatestFrom != nil:
+		backup, err = r.resolveFromLatest(ctx, restore, source.LatestFrom)
+		if err == nil && backup == nil {
+			// The Backup resources may have been cleaned up while their files are still stored
+			artifact, err := r.resolveLatestArtifact(ctx, restore, source.LatestFrom)
+			if err != nil {
+				return "", "", nil, err
+			}
+			return artifact.Spec.Path, artifact.Spec.StorageRef.Name, nil, nil
+		}
 
-	// Option 3: Direct path
-	if source.Path != "" {
+	// Option 3: ArtifactRef - get path from a BackupArtifact
+	case source.ArtifactRef != nil:
+		artifact, err := r.resolveFromArtifactRef(ctx, restore, source.ArtifactRef)
+		if err != nil {
+			return "", "", nil, err
+		}
+		return artifact.Spec.Path, artifact.Spec.StorageRef.Name, nil, nil
+
+	// Option 4: Direct path
+	case source.Path != "":
 		if source.StorageRef == nil {
-			return "", "", fmt.Errorf("storageRef is required when using path")
+			return "", "", nil, fmt.Errorf("storageRef is required when using path")
+		}
+		backup, err = r.resolveFromPath(ctx, restore, source.Path, source.StorageRef.Name)
+		if err == nil && backup == nil {
+			return source.Path, source.StorageRef.Name, nil, nil
 		}
-		return source.Path, source.StorageRef.Name, nil
+
+	default:
+		return "", "", nil, fmt.Errorf("either backupRef, latestFrom, artifactRef, or path must be specified")
 	}
 
-	return "", "", fmt.Errorf("either backupRef, latestFrom, or path must be specified")
+	if err != nil {
+		return "", "", nil, err
+	}
+	return backup.Status.Path, backup.Spec.StorageRef.Name, backup, nil
 }
 
 func (r *RestoreReconciler) resolveFromBackupRef(
 	ctx context.Context,
 	restore *databasesv1alpha1.Restore,
 	ref *databasesv1alpha1.BackupReference,
-) (sourcePath, storageRefName string, err error) {
+) (*databasesv1alpha1.Backup, error) {
 	ns := ref.Namespace
 	if ns == "" {
 		ns = restore.Namespace
 	}
+	if err := r.checkSourceReference(ctx, restore, ns, databasesv1alpha1.ReferenceKindBackup, ref.Name); err != nil {
+		return nil, err
+	}
 
 	var backup databasesv1alpha1.Backup
 	if err := r.Get(ctx, types.NamespacedName{
 		Name:      ref.Name,
 		Namespace: ns,
 	}, &backup); err != nil {
-		return "", "", fmt.Errorf("backup not found: %w", err)
+		return nil, fmt.Errorf("backup not found: %w", err)
 	}
 
 	if backup.Status.Phase != "Completed" {
-		return "", "", fmt.Errorf("backup is not completed (phase: %s)", backup.Status.Phase)
+		return nil, fmt.Errorf("backup is not completed (phase: %s)", backup.Status.Phase)
 	}
 
 	if backup.Status.Path == "" {
-		return "", "", fmt.Errorf("backup has no path in status")
+		return nil, fmt.Errorf("backup has no path in status")
 	}
 
-	return backup.Status.Path, backup.Spec.StorageRef.Name, nil
+	return &backup, nil
 }
 
 func (r *RestoreReconciler) resolveFromLatest(
 	ctx context.Context,
 	restore *databasesv1alpha1.Restore,
 	latestFrom *databasesv1alpha1.LatestFromSource,
-) (sourcePath, storageRefName string, err error) {
+) (*databasesv1alpha1.Backup, error) {
 	ns := latestFrom.Namespace
 	if ns == "" {
 		ns = restore.Namespace
 	}
+	if err := r.checkSourceReference(ctx, restore, ns, databasesv1alpha1.ReferenceKindDatabase, latestFrom.DatabaseRef.Name); err != nil {
+		return nil, err
+	}
 
 	// List all backups in the namespace
 	var backupList databasesv1alpha1.BackupList
 	if err := r.List(ctx, &backupList, client.InNamespace(ns)); err != nil {
-		return "", "", fmt.Errorf("failed to list backups: %w", err)
+		return nil, fmt.Errorf("failed to list backups: %w", err)
 	}
 
 	// Filter by database and find the latest completed one
@@ -259,11 +327,153 @@ func (r *RestoreReconciler) resolveFromLatest(
 		}
 	}
 
-	if latestBackup == nil {
-		return "", "", fmt.Errorf("no completed backup found for database %s", latestFrom.DatabaseRef.Name)
+	// nil: the caller falls back to BackupArtifacts
+	return latestBackup, nil
+}
+
+// resolveLatestArtifact finds the newest BackupArtifact of a database when no completed Backup is
+// left. Artifacts carry the PostgreSQL database and cluster names from the object tags, so they are
+// matched through the Database resource.
+func (r *RestoreReconciler) resolveLatestArtifact(
+	ctx context.Context,
+	restore *databasesv1alpha1.Restore,
+	latestFrom *databasesv1alpha1.LatestFromSource,
+) (*databasesv1alpha1.BackupArtifact, error) {
+	ns := latestFrom.Namespace
+	if ns == "" {
+		ns = restore.Namespace
+	}
+	notFound := fmt.Errorf("no completed backup found for database %s", latestFrom.DatabaseRef.Name)
+
+	var db databasesv1alpha1.Database
+	if err := r.Get(ctx, types.NamespacedName{Name: latestFrom.DatabaseRef.Name, Namespace: ns}, &db); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, notFound
+		}
+		return nil, fmt.Errorf("failed to get database: %w", err)
+	}
+	if db.Status.DatabaseName == "" {
+		return nil, notFound
+	}
+
+	var artifacts databasesv1alpha1.BackupArtifactList
+	if err := r.List(ctx, &artifacts, client.InNamespace(ns)); err != nil {
+		return nil, fmt.Errorf("failed to list backup artifacts: %w", err)
 	}
 
-	return latestBackup.Status.Path, latestBackup.Spec.StorageRef.Name, nil
+	var latest *databasesv1alpha1.BackupArtifact
+	for i := range artifacts.Items {
+		artifact := &artifacts.Items[i]
+		if artifact.Spec.Database != db.Status.DatabaseName || artifact.Spec.Cluster != db.Spec.ClusterRef.Name {
+			continue
+		}
+		if latest == nil || newerArtifact(artifact, latest) {
+			latest = artifact
+		}
+	}
+	if latest == nil {
+		return nil, notFound
+	}
+	return latest, nil
+}
+
+// newerArtifact orders artifacts by backup timestamp. Replicas of a backup share the timestamp; the
+// copy written first, in the primary storage, is preferred.
+func newerArtifact(a, b *databasesv1alpha1.BackupArtifact) bool {
+	if a.Spec.Timestamp != b.Spec.Timestamp {
+		return a.Spec.Timestamp > b.Spec.Timestamp
+	}
+	return a.Spec.LastModified.Before(&b.Spec.LastModified)
+}
+
+func (r *RestoreReconciler) resolveFromArtifactRef(
+	ctx context.Context,
+	restore *databasesv1alpha1.Restore,
+	ref *databasesv1alpha1.ArtifactReference,
+) (*databasesv1alpha1.BackupArtifact, error) {
+	ns := ref.Namespace
+	if ns == "" {
+		ns = restore.Namespace
+	}
+	if err := r.checkSourceReference(ctx, restore, ns, databasesv1alpha1.ReferenceKindBackupArtifact, ref.Name); err != nil {
+		return nil, err
+	}
+
+	var artifact databasesv1alpha1.BackupArtifact
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ns}, &artifact); err != nil {
+		return nil, fmt.Errorf("backup artifact not found: %w", err)
+	}
+	return &artifact, nil
+}
+
+// resolveFromPath authorizes a direct path like the reference it stands for: the Backup or BackupArtifact
+// recording that path in the storage, which needs a grant when it lives in another namespace. Paths no
+// resource records are only accepted from Restores in the operator namespace, otherwise any namespace
+// could restore another tenant's dump by guessing its path. Returns the Backup when one matches.
+func (r *RestoreReconciler) resolveFromPath(ctx context.Context, restore *databasesv1alpha1.Restore,
+	sourcePath, storageRefName string) (*databasesv1alpha1.Backup, error) {
+
+	var backups databasesv1alpha1.BackupList
+	if err := r.List(ctx, &backups); err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	var denied error
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.Status.Path != sourcePath || backup.Spec.StorageRef.Name != storageRefName {
+			continue
+		}
+		err := r.checkSourceReference(ctx, restore, backup.Namespace, databasesv1alpha1.ReferenceKindBackup, backup.Name)
+		if err == nil {
+			return backup, nil
+		}
+		denied = err
+	}
+
+	var artifacts databasesv1alpha1.BackupArtifactList
+	if err := r.List(ctx, &artifacts); err != nil {
+		return nil, fmt.Errorf("failed to list backup artifacts: %w", err)
+	}
+	for i := range artifacts.Items {
+		artifact := &artifacts.Items[i]
+		if artifact.Spec.Path != sourcePath || artifact.Spec.StorageRef.Name != storageRefName {
+			continue
+		}
+		err := r.checkSourceReference(ctx, restore, artifact.Namespace, databasesv1alpha1.ReferenceKindBackupArtifact, artifact.Name)
+		if err == nil {
+			return nil, nil
+		}
+		denied = err
+	}
+
+	if denied != nil {
+		return nil, denied
+	}
+	if restore.Namespace != r.Namespace {
+		return nil, fmt.Errorf("path %s in storage %s does not belong to a Backup or BackupArtifact; "+
+			"restores from unrecorded paths are only allowed in namespace %s", sourcePath, storageRefName, r.Namespace)
+	}
+	return nil, nil
+}
+
+// checkSourceReference requires a DatabaseReferenceGrant allowing restoreSource when the backups
+// live in another namespace than the Restore
+func (r *RestoreReconciler) checkSourceReference(ctx context.Context, restore *databasesv1alpha1.Restore,
+	namespace, kind, name string) error {
+
+	if namespace == restore.Namespace {
+		return nil
+	}
+
+	var grants databasesv1alpha1.DatabaseReferenceGrantList
+	if err := r.List(ctx, &grants, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to list DatabaseReferenceGrants: %w", err)
+	}
+	if !grants.Allows(restore.Namespace, databasesv1alpha1.ReferencePurposeRestoreSource, kind, name) {
+		return fmt.Errorf("%s '%s/%s' is not shared with namespace %s (no DatabaseReferenceGrant allows restoreSource)",
+			kind, namespace, name, restore.Namespace)
+	}
+	return nil
 }
 
 func (r *RestoreReconciler) buildRestoreJob(
@@ -278,6 +488,14 @@ func (r *RestoreReconciler) buildRestoreJob(
 
 	// Build environment variables
 	env := r.buildEnvVars(db, cluster, storage, sourcePath, restore.Spec.OnConflict)
+	pgClient := r.PGClients.forRestore(cluster.Status.PostgresVersion)
+	env = append(env, pgClient.env()...)
+	env = append(env, hooksEnv(restore.Spec.PreHooks, restore.Spec.PostHooks, cluster, runID)...)
+	// Job info for reporting hook results
+	env = append(env,
+		corev1.EnvVar{Name: "JOB_NAME", Value: jobName},
+		corev1.EnvVar{Name: "JOB_NAMESPACE", Value: r.Namespace},
+	)
 
 	backoffLimit := int32(0)
 	ttlSeconds := int32(3600) // 1 hour
@@ -303,12 +521,11 @@ func (r *RestoreReconciler) buildRestoreJob(
 					},
 				},
 				Spec: corev1.PodSpec{
-					RestartPolicy:      corev1.RestartPolicyNever,
-					ServiceAccountName: "dbtether", // Uses operator's SA for IRSA
+					RestartPolicy: corev1.RestartPolicyNever,
 					Containers: []corev1.Container{
 						{
 							Name:  "restore",
-							Image: r.Image,
+							Image: pgClient.image(r.Image),
 							Args:  []string{"--mode=restore"},
 							Env:   env,
 						},
@@ -318,6 +535,9 @@ func (r *RestoreReconciler) buildRestoreJob(
 		},
 	}
 
+	mountPVCStorages(job, storage, nil)
+	applyJobTemplate(job, databasesv1alpha1.MergeJobTemplates(r.JobDefaults, storage.Spec.JobTemplate, restore.Spec.JobTemplate.JobTemplate()))
+
 	return job, nil
 }
 
@@ -365,34 +585,7 @@ func (r *RestoreReconciler) buildEnvVars(
 	}
 
 	// Add storage config
-	if storage.Spec.S3 != nil {
-		env = append(env,
-			corev1.EnvVar{Name: "STORAGE_TYPE", Value: "s3"},
-			corev1.EnvVar{Name: "S3_BUCKET", Value: storage.Spec.S3.Bucket},
-			corev1.EnvVar{Name: "S3_REGION", Value: storage.Spec.S3.Region},
-		)
-		if storage.Spec.S3.Endpoint != "" {
-			env = append(env, corev1.EnvVar{Name: "S3_ENDPOINT", Value: storage.Spec.S3.Endpoint})
-		}
-	}
-
-	if storage.Spec.GCS != nil {
-		env = append(env,
-			corev1.EnvVar{Name: "STORAGE_TYPE", Value: "gcs"},
-			corev1.EnvVar{Name: "GCS_BUCKET", Value: storage.Spec.GCS.Bucket},
-		)
-		if storage.Spec.GCS.Project != "" {
-			env = append(env, corev1.EnvVar{Name: "GCS_PROJECT", Value: storage.Spec.GCS.Project})
-		}
-	}
-
-	if storage.Spec.Azure != nil {
-		env = append(env,
-			corev1.EnvVar{Name: "STORAGE_TYPE", Value: "azure"},
-			corev1.EnvVar{Name: "AZURE_CONTAINER", Value: storage.Spec.Azure.Container},
-			corev1.EnvVar{Name: "AZURE_ACCOUNT", Value: storage.Spec.Azure.StorageAccount},
-		)
-	}
+	env = append(env, storageEnv(storage)...)
 
 	return env
 }
@@ -422,7 +615,8 @@ func (r *RestoreReconciler) evaluateJobStatus(ctx context.Context, restore *data
 			duration = time.Since(restore.Status.StartedAt.Time).Round(time.Second).String()
 		}
 		logger.Info("restore completed successfully", "duration", duration)
-		return r.updateStatusCompleted(ctx, restore, specHash, duration)
+		return r.updateStatusCompleted(ctx, restore, specHash, duration, hookResultsFromJob(job),
+			job.Annotations[pkgbackup.RestoredFromAnnotation], job.Annotations[pkgbackup.PrimaryErrorAnnotation])
 	}
 
 	if job.Status.Failed > 0 {
@@ -431,8 +625,10 @@ func (r *RestoreReconciler) evaluateJobStatus(ctx context.Context, restore *data
 		if reason := r.getJobFailureReason(ctx, job); reason != "" {
 			message = reason
 		}
+		hooks := hookResultsFromJob(job)
+		message += failedHooksSuffix(hooks)
 		logger.Error(nil, "restore failed", "reason", message)
-		return r.updateStatus(ctx, restore, "Failed", message, specHash)
+		return r.updateStatusFailed(ctx, restore, message, specHash, hooks)
 	}
 
 	// Still running
@@ -495,6 +691,7 @@ func (r *RestoreReconciler) updateStatus(
 	restore *databasesv1alpha1.Restore,
 	phase, message, specHash string,
 ) (ctrl.Result, error) {
+	wasFailed := restore.Status.Phase == "Failed"
 	patch := client.MergeFrom(restore.DeepCopy())
 
 	restore.Status.Phase = phase
@@ -512,6 +709,9 @@ func (r *RestoreReconciler) updateStatus(
 	}
 
 	if phase == "Failed" {
+		if !wasFailed {
+			notifyRestore(r.Notifier, restore)
+		}
 		return ctrl.Result{}, nil
 	}
 	return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
@@ -546,11 +746,23 @@ func (r *RestoreReconciler) updateStatusCompleted(
 	ctx context.Context,
 	restore *databasesv1alpha1.Restore,
 	specHash, duration string,
+	hooks []databasesv1alpha1.HookResult,
+	sourceReplica, primaryError string,
 ) (ctrl.Result, error) {
 	patch := client.MergeFrom(restore.DeepCopy())
 
 	restore.Status.Phase = "Completed"
 	restore.Status.Message = "restore completed successfully"
+	if sourceReplica != "" {
+		restore.Status.Message += " from replica " + sourceReplica
+		if primaryError != "" {
+			restore.Status.Message += " (primary storage: " + primaryError + ")"
+		}
+	}
+	restore.Status.Message += failedHooksSuffix(hooks)
+	restore.Status.SourceReplica = sourceReplica
+	restore.Status.PrimaryError = primaryError
+	restore.Status.Hooks = hooks
 	restore.Status.SpecHash = specHash
 	restore.Status.Duration = duration
 	restore.Status.ObservedGeneration = restore.Generation
@@ -562,6 +774,33 @@ func (r *RestoreReconciler) updateStatusCompleted(
 		return ctrl.Result{}, err
 	}
 
+	notifyRestore(r.Notifier, restore)
+	return ctrl.Result{}, nil
+}
+
+// updateStatusFailed marks the restore failed after its Job failed, keeping the hook results
+func (r *RestoreReconciler) updateStatusFailed(
+	ctx context.Context,
+	restore *databasesv1alpha1.Restore,
+	message, specHash string,
+	hooks []databasesv1alpha1.HookResult,
+) (ctrl.Result, error) {
+	patch := client.MergeFrom(restore.DeepCopy())
+
+	restore.Status.Phase = "Failed"
+	restore.Status.Message = message
+	restore.Status.Hooks = hooks
+	restore.Status.SpecHash = specHash
+	restore.Status.ObservedGeneration = restore.Generation
+
+	now := metav1.Now()
+	restore.Status.CompletedAt = &now
+
+	if err := r.Status().Patch(ctx, restore, patch); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	notifyRestore(r.Notifier, restore)
 	return ctrl.Result{}, nil
 }
 
@@ -665,6 +665,95 @@ func TestResolveSource_LatestFrom_NoBackups(t *testing.T) {
 	assert.Contains(t, err.Error(), "no completed backup found")
 }
 
+func newTestArtifact(name, namespace, storageName, path, timestamp string) *dbtether.BackupArtifact {
+	return &dbtether.BackupArtifact{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: dbtether.BackupArtifactSpec{
+			StorageRef: dbtether.StorageReference{Name: storageName},
+			Path:       path,
+			Cluster:    "main-cluster",
+			Database:   "orders",
+			Timestamp:  timestamp,
+		},
+	}
+}
+
+func TestResolveSource_ArtifactRef(t *testing.T) {
+	ctx := context.Background()
+	artifact := newTestArtifact("company-s3-a1b2c3d4e5", "default", "company-s3",
+		"main-cluster/orders/20260120-020000.sql.gz", "20260120-020000")
+	r := newFakeRestoreReconciler(artifact)
+
+	restore := &dbtether.Restore{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-restore", Namespace: "default"},
+		Spec: dbtether.RestoreSpec{
+			Source: dbtether.RestoreSource{
+				ArtifactRef: &dbtether.ArtifactReference{Name: "company-s3-a1b2c3d4e5"},
+			},
+		},
+	}
+
+	path, storageRef, err := r.resolveSource(ctx, restore)
+	require.NoError(t, err)
+	assert.Equal(t, "main-cluster/orders/20260120-020000.sql.gz", path)
+	assert.Equal(t, "company-s3", storageRef)
+
+	restore.Spec.Source.ArtifactRef.Name = "missing"
+	_, _, err = r.resolveSource(ctx, restore)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "backup artifact not found")
+}
+
+func TestResolveSource_LatestFrom_FallsBackToArtifacts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase("orders-db", "default", "main-cluster")
+	db.Status.DatabaseName = "orders"
+
+	older := newTestArtifact("company-s3-older", "default", "company-s3",
+		"main-cluster/orders/20260119-020000.sql.gz", "20260119-020000")
+	primary := newTestArtifact("company-s3-newest", "default", "company-s3",
+		"main-cluster/orders/20260120-020000.sql.gz", "20260120-020000")
+	primary.Spec.LastModified = metav1.NewTime(time.Date(2026, 1, 20, 2, 1, 0, 0, time.UTC))
+	replica := newTestArtifact("dr-gcs-newest", "default", "dr-gcs",
+		"main-cluster/orders/20260120-020000.sql.gz", "20260120-020000")
+	replica.Spec.LastModified = metav1.NewTime(time.Date(2026, 1, 20, 2, 2, 0, 0, time.UTC))
+	otherCluster := newTestArtifact("company-s3-other", "default", "company-s3",
+		"staging/orders/20260121-020000.sql.gz", "20260121-020000")
+	otherCluster.Spec.Cluster = "staging"
+
+	restore := &dbtether.Restore{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-restore", Namespace: "default"},
+		Spec: dbtether.RestoreSpec{
+			Source: dbtether.RestoreSource{
+				LatestFrom: &dbtether.LatestFromSource{
+					DatabaseRef: dbtether.DatabaseReference{Name: "orders-db"},
+				},
+			},
+		},
+	}
+
+	r := newFakeRestoreReconciler(db, older, replica, primary, otherCluster)
+	path, storageRef, err := r.resolveSource(ctx, restore)
+	require.NoError(t, err)
+	assert.Equal(t, "main-cluster/orders/20260120-020000.sql.gz", path)
+	assert.Equal(t, "company-s3", storageRef)
+
+	// A completed Backup resource is still preferred
+	now := metav1.Now()
+	backup := &dbtether.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders-backup", Namespace: "default"},
+		Spec: dbtether.BackupSpec{
+			DatabaseRef: dbtether.DatabaseReference{Name: "orders-db"},
+			StorageRef:  dbtether.StorageReference{Name: "company-s3"},
+		},
+		Status: dbtether.BackupStatus{Phase: "Completed", Path: "main-cluster/orders/manual.sql.gz", CompletedAt: &now},
+	}
+	r = newFakeRestoreReconciler(db, primary, backup)
+	path, _, err = r.resolveSource(ctx, restore)
+	require.NoError(t, err)
+	assert.Equal(t, "main-cluster/orders/manual.sql.gz", path)
+}
+
 func TestResolveSource_LatestFrom_CrossNamespace(t *testing.T) {
 	ctx := context.Background()
 	now := metav1.Now()
@@ -685,7 +774,17 @@ func TestResolveSource_LatestFrom_CrossNamespace(t *testing.T) {
 		},
 	}
 
-	r := newFakeRestoreReconciler(backup)
+	// prod shares its backups with dev
+	grant := &dbtether.DatabaseReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev-restores", Namespace: "prod"},
+		Spec: dbtether.DatabaseReferenceGrantSpec{
+			From: []dbtether.ReferenceGrantFrom{
+				{Namespace: "dev", Purposes: []dbtether.ReferencePurpose{dbtether.ReferencePurposeRestoreSource}},
+			},
+		},
+	}
+
+	r := newFakeRestoreReconciler(backup, grant)
 
 	restore := &dbtether.Restore{
 		ObjectMeta: metav1.ObjectMeta{
@@ -708,9 +807,55 @@ func TestResolveSource_LatestFrom_CrossNamespace(t *testing.T) {
 	assert.Equal(t, "prod-storage", storageRef)
 }
 
+func TestResolveSource_CrossNamespaceRequiresGrant(t *testing.T) {
+	ctx := context.Background()
+	now := metav1.Now()
+
+	backup := &dbtether.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod-backup", Namespace: "prod"},
+		Spec: dbtether.BackupSpec{
+			DatabaseRef: dbtether.DatabaseReference{Name: "prod-db"},
+			StorageRef:  dbtether.StorageReference{Name: "prod-storage"},
+		},
+		Status: dbtether.BackupStatus{Phase: "Completed", Path: "prod/backup.sql.gz", CompletedAt: &now},
+	}
+	userAccessOnly := &dbtether.DatabaseReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev-users", Namespace: "prod"},
+		Spec: dbtether.DatabaseReferenceGrantSpec{
+			From: []dbtether.ReferenceGrantFrom{
+				{Namespace: "dev", Purposes: []dbtether.ReferencePurpose{dbtether.ReferencePurposeUserAccess}},
+			},
+		},
+	}
+
+	sources := map[string]dbtether.RestoreSource{
+		"backupRef": {BackupRef: &dbtether.BackupReference{Name: "prod-backup", Namespace: "prod"}},
+		"latestFrom": {LatestFrom: &dbtether.LatestFromSource{
+			DatabaseRef: dbtether.DatabaseReference{Name: "prod-db"},
+			Namespace:   "prod",
+		}},
+		"artifactRef": {ArtifactRef: &dbtether.ArtifactReference{Name: "prod-storage-0123456789", Namespace: "prod"}},
+		"path":        {Path: "prod/backup.sql.gz", StorageRef: &dbtether.StorageReference{Name: "prod-storage"}},
+	}
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			r := newFakeRestoreReconciler(backup, userAccessOnly)
+			restore := &dbtether.Restore{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-restore", Namespace: "dev"},
+				Spec:       dbtether.RestoreSpec{Source: source},
+			}
+
+			_, _, err := r.resolveSource(ctx, restore)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "not shared with namespace dev")
+		})
+	}
+}
+
 func TestResolveSource_DirectPath(t *testing.T) {
 	ctx := context.Background()
-	r := newFakeRestoreReconciler()
+	r := newFakeRestoreReconciler(newTestArtifact("my-storage-0123456789", "default", "my-storage",
+		"direct/path/backup.sql.gz", "20260120-020000"))
 
 	restore := &dbtether.Restore{
 		ObjectMeta: metav1.ObjectMeta{
@@ -731,6 +876,61 @@ func TestResolveSource_DirectPath(t *testing.T) {
 	assert.Equal(t, "my-storage", storageRef)
 }
 
+func TestResolveSource_DirectPath_CrossNamespaceArtifact(t *testing.T) {
+	ctx := context.Background()
+	artifact := newTestArtifact("prod-storage-0123456789", "prod", "prod-storage", "prod/orders.sql.gz", "20260120-020000")
+	restore := &dbtether.Restore{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev-restore", Namespace: "dev"},
+		Spec: dbtether.RestoreSpec{Source: dbtether.RestoreSource{
+			Path:       "prod/orders.sql.gz",
+			StorageRef: &dbtether.StorageReference{Name: "prod-storage"},
+		}},
+	}
+
+	r := newFakeRestoreReconciler(artifact)
+	_, _, err := r.resolveSource(ctx, restore)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "BackupArtifact 'prod/prod-storage-0123456789' is not shared with namespace dev")
+
+	grant := &dbtether.DatabaseReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev-restores", Namespace: "prod"},
+		Spec: dbtether.DatabaseReferenceGrantSpec{
+			From: []dbtether.ReferenceGrantFrom{
+				{Namespace: "dev", Purposes: []dbtether.ReferencePurpose{dbtether.ReferencePurposeRestoreSource}},
+			},
+		},
+	}
+	r = newFakeRestoreReconciler(artifact, grant)
+	path, storageRef, err := r.resolveSource(ctx, restore)
+	require.NoError(t, err)
+	assert.Equal(t, "prod/orders.sql.gz", path)
+	assert.Equal(t, "prod-storage", storageRef)
+}
+
+func TestResolveSource_DirectPath_Unrecorded(t *testing.T) {
+	ctx := context.Background()
+	r := newFakeRestoreReconciler()
+	r.Namespace = "dbtether"
+
+	restore := &dbtether.Restore{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-restore", Namespace: "default"},
+		Spec: dbtether.RestoreSpec{Source: dbtether.RestoreSource{
+			Path:       "other-team/orders.sql.gz",
+			StorageRef: &dbtether.StorageReference{Name: "my-storage"},
+		}},
+	}
+
+	_, _, err := r.resolveSource(ctx, restore)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only allowed in namespace dbtether")
+
+	// The operator namespace may restore any path, e.g. files copied in from outside dbtether
+	restore.Namespace = "dbtether"
+	path, _, err := r.resolveSource(ctx, restore)
+	require.NoError(t, err)
+	assert.Equal(t, "other-team/orders.sql.gz", path)
+}
+
 func TestResolveSource_DirectPath_MissingStorageRef(t *testing.T) {
 	ctx := context.Background()
 	r := newFakeRestoreReconciler()
@@ -769,5 +969,5 @@ func TestResolveSource_EmptySource(t *testing.T) {
 
 	_, _, err := r.resolveSource(ctx, restore)
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), "either backupRef, latestFrom, or path must be specified")
+	assert.Contains(t, err.Error(), "either backupRef, latestFrom, artifactRef, or path must be specified")
 }
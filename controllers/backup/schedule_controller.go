@@ -1,12 +1,9 @@
 package backup
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"log/slog"
 	"sort"
-	"text/template"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -184,6 +181,7 @@ func (r *BackupScheduleReconciler) generateBackupName(schedule *dbtether.BackupS
 }
 
 func (r *BackupScheduleReconciler) createBackup(ctx context.Context, schedule *dbtether.BackupSchedule, backupName string) (*dbtether.Backup, error) {
+	spec := schedule.Spec.DeepCopy()
 	backup := &dbtether.Backup{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      backupName,
@@ -196,6 +194,11 @@ func (r *BackupScheduleReconciler) createBackup(ctx context.Context, schedule *d
 		Spec: dbtether.BackupSpec{
 			DatabaseRef: schedule.Spec.DatabaseRef,
 			StorageRef:  schedule.Spec.StorageRef,
+			Replicas:    replicaRefs(spec.Replicas),
+			Compression: spec.Compression,
+			JobTemplate: spec.JobTemplate,
+			PreHooks:    spec.PreHooks,
+			PostHooks:   spec.PostHooks,
 		},
 	}
 
@@ -204,6 +207,11 @@ func (r *BackupScheduleReconciler) createBackup(ctx context.Context, schedule *d
 		backup.Spec.FilenameTemplate = schedule.Spec.FilenameTemplate
 	}
 
+	// Backups notify the channels the schedule subscribed to
+	if channels := schedule.Annotations[dbtether.NotifyAnnotation]; channels != "" {
+		backup.Annotations = map[string]string{dbtether.NotifyAnnotation: channels}
+	}
+
 	// Set owner reference for garbage collection
 	if err := controllerutil.SetControllerReference(schedule, backup, r.Scheme); err != nil {
 		return nil, fmt.Errorf("failed to set owner reference: %w", err)
@@ -217,7 +225,8 @@ func (r *BackupScheduleReconciler) createBackup(ctx context.Context, schedule *d
 }
 
 func (r *BackupScheduleReconciler) runRetentionCleanup(ctx context.Context, schedule *dbtether.BackupSchedule, log *zap.SugaredLogger) {
-	if schedule.Spec.Retention == nil {
+	targets := retentionTargets(schedule)
+	if len(targets) == 0 {
 		return // No retention policy configured
 	}
 
@@ -233,22 +242,59 @@ func (r *BackupScheduleReconciler) runRetentionCleanup(ctx context.Context, sche
 		return
 	}
 
-	// Get resources needed for cleanup
-	s3Client, prefix, err := r.prepareRetentionCleanup(ctx, schedule, log)
-	if err != nil || s3Client == nil {
-		return // Error already logged or storage not supported
+	// Apply retention policy and delete old files in the primary storage and each replica
+	for _, target := range targets {
+		storageClient, closeClient, prefix, err := r.prepareRetentionCleanup(ctx, schedule, target.storage, log)
+		if err != nil || storageClient == nil {
+			continue // Error already logged or storage cleaned up by the Job
+		}
+		r.executeRetentionCleanup(ctx, storageClient, prefix, target.retention, log)
+		closeClient()
 	}
 
-	// Apply retention policy and delete old files
-	r.executeRetentionCleanup(ctx, s3Client, prefix, schedule, log)
-
 	// Also cleanup old Backup CRDs
 	r.cleanupBackupCRDs(ctx, schedule, log)
 }
 
-// prepareRetentionCleanup fetches resources and creates S3 client for retention cleanup.
-// Returns nil client if storage is not S3 (which is currently the only supported type).
-func (r *BackupScheduleReconciler) prepareRetentionCleanup(ctx context.Context, schedule *dbtether.BackupSchedule, log *zap.SugaredLogger) (*storage.S3Client, string, error) {
+// retentionTarget is a storage of a schedule and the retention applied to it
+type retentionTarget struct {
+	storage   string
+	retention *dbtether.RetentionPolicy
+}
+
+// retentionTargets returns the storages with a retention policy: the primary storage and the
+// replicas, which inherit the schedule's retention unless they set their own
+func retentionTargets(schedule *dbtether.BackupSchedule) []retentionTarget {
+	var targets []retentionTarget
+	if schedule.Spec.Retention != nil {
+		targets = append(targets, retentionTarget{schedule.Spec.StorageRef.Name, schedule.Spec.Retention})
+	}
+	for i := range schedule.Spec.Replicas {
+		replica := &schedule.Spec.Replicas[i]
+		if retention := schedule.Spec.ReplicaRetention(replica); retention != nil {
+			targets = append(targets, retentionTarget{replica.StorageRef.Name, retention})
+		}
+	}
+	return targets
+}
+
+// replicaRefs returns the storages of the schedule's replicas
+func replicaRefs(replicas []dbtether.BackupReplica) []dbtether.StorageReference {
+	if len(replicas) == 0 {
+		return nil
+	}
+	refs := make([]dbtether.StorageReference, 0, len(replicas))
+	for _, replica := range replicas {
+		refs = append(refs, replica.StorageRef)
+	}
+	return refs
+}
+
+// prepareRetentionCleanup fetches resources and creates a storage client for retention cleanup,
+// authenticated with the storage's credentials secret when it has one. Returns a nil client for
+// pvc and sftp storages, which are cleaned up by the backup Job instead.
+func (r *BackupScheduleReconciler) prepareRetentionCleanup(ctx context.Context, schedule *dbtether.BackupSchedule,
+	storageName string, log *zap.SugaredLogger) (storage.StorageClient, func(), string, error) {
 	// Get Database to build path
 	var db dbtether.Database
 	if err := r.Get(ctx, types.NamespacedName{
@@ -258,7 +304,7 @@ func (r *BackupScheduleReconciler) prepareRetentionCleanup(ctx context.Context,
 		if !errors.IsNotFound(err) {
 			log.Warnw("retention cleanup: failed to get database", "error", err)
 		}
-		return nil, "", err
+		return nil, nil, "", err
 	}
 
 	// Get DBCluster for cluster name
@@ -267,63 +313,63 @@ func (r *BackupScheduleReconciler) prepareRetentionCleanup(ctx context.Context,
 		if !errors.IsNotFound(err) {
 			log.Warnw("retention cleanup: failed to get cluster", "error", err)
 		}
-		return nil, "", err
+		return nil, nil, "", err
 	}
 
 	// Get BackupStorage
 	var backupStorage dbtether.BackupStorage
-	if err := r.Get(ctx, types.NamespacedName{Name: schedule.Spec.StorageRef.Name}, &backupStorage); err != nil {
+	if err := r.Get(ctx, types.NamespacedName{Name: storageName}, &backupStorage); err != nil {
 		if !errors.IsNotFound(err) {
-			log.Warnw("retention cleanup: failed to get backup storage", "error", err)
+			log.Warnw("retention cleanup: failed to get backup storage", "storage", storageName, "error", err)
 		}
-		return nil, "", err
+		return nil, nil, "", err
 	}
 
-	// Only S3 is supported for now
-	if backupStorage.Spec.S3 == nil {
-		log.Debugw("retention cleanup: only S3 storage is supported", "storage", backupStorage.Name)
-		return nil, "", nil
+	if provider := backupStorage.GetProvider(); pkgbackup.CleanedUpByJob(provider) {
+		log.Debugw("retention cleanup: storage is cleaned up by the backup job",
+			"storage", backupStorage.Name, "provider", provider)
+		return nil, nil, "", nil
 	}
 
-	// Create S3 client
-	s3Cfg := &storage.S3Config{
-		Bucket:   backupStorage.Spec.S3.Bucket,
-		Region:   backupStorage.Spec.S3.Region,
-		Endpoint: backupStorage.Spec.S3.Endpoint,
+	// Build prefix from path template
+	prefix, err := r.buildStoragePath(&backupStorage, &cluster, &db)
+	if err != nil {
+		log.Warnw("retention cleanup: failed to build storage path", "error", err)
+		return nil, nil, "", err
 	}
 
-	s3Client, err := storage.NewS3Client(ctx, s3Cfg, slog.Default())
+	credentials, err := storageCredentials(ctx, r.Client, r.Namespace, &backupStorage)
 	if err != nil {
-		log.Warnw("retention cleanup: failed to create S3 client", "error", err)
-		return nil, "", err
+		log.Warnw("retention cleanup: failed to read storage credentials", "storage", backupStorage.Name, "error", err)
+		return nil, nil, "", err
 	}
 
-	// Build prefix from path template
-	prefix, err := r.buildStoragePath(&backupStorage, &cluster, &db)
+	storageClient, closeClient, err := openStorageClient(ctx, &backupStorage, credentials)
 	if err != nil {
-		log.Warnw("retention cleanup: failed to build storage path", "error", err)
-		return nil, "", err
+		log.Warnw("retention cleanup: failed to create storage client", "storage", backupStorage.Name, "error", err)
+		return nil, nil, "", err
 	}
 
-	return s3Client, prefix, nil
+	return storageClient, closeClient, prefix, nil
 }
 
-// executeRetentionCleanup applies retention policy and deletes old S3 files.
-func (r *BackupScheduleReconciler) executeRetentionCleanup(ctx context.Context, s3Client *storage.S3Client, prefix string, schedule *dbtether.BackupSchedule, log *zap.SugaredLogger) {
+// executeRetentionCleanup applies retention policy and deletes old backup files.
+func (r *BackupScheduleReconciler) executeRetentionCleanup(ctx context.Context, storageClient storage.StorageClient, prefix string,
+	retention *dbtether.RetentionPolicy, log *zap.SugaredLogger) {
 	retentionManager := pkgbackup.NewRetentionManager(log)
-	toDelete, err := retentionManager.ApplyRetention(ctx, s3Client, prefix, schedule.Spec.Retention)
+	toDelete, err := retentionManager.ApplyRetention(ctx, storageClient, prefix, retention)
 	if err != nil {
 		log.Warnw("retention cleanup: failed to apply retention policy", "error", err)
 		return
 	}
 
 	if len(toDelete) == 0 {
-		log.Debugw("retention cleanup: no files to delete", "prefix", prefix, "retention", schedule.Spec.Retention)
+		log.Debugw("retention cleanup: no files to delete", "prefix", prefix, "retention", retention)
 		return
 	}
 
-	if err := retentionManager.DeleteFiles(ctx, s3Client, toDelete); err != nil {
-		log.Warnw("retention cleanup: failed to delete some S3 files", "error", err)
+	if err := retentionManager.DeleteFiles(ctx, storageClient, toDelete); err != nil {
+		log.Warnw("retention cleanup: failed to delete some backup files", "error", err)
 	}
 }
 
@@ -444,28 +490,17 @@ func (r *BackupScheduleReconciler) buildStoragePath(
 	cluster *dbtether.DBCluster,
 	db *dbtether.Database,
 ) (string, error) {
-	pathTemplate := backupStorage.Spec.PathTemplate
-	if pathTemplate == "" {
-		// Default: ClusterName/DatabaseName
-		return fmt.Sprintf("%s/%s", cluster.Name, db.Status.DatabaseName), nil
-	}
+	return storagePrefix(backupStorage, cluster.Name, db.Status.DatabaseName)
+}
 
-	tmpl, err := template.New("path").Parse(pathTemplate)
+// storagePrefix renders the storage's pathTemplate for one database of a cluster up to its first date
+// or run variable, the prefix the retention of a schedule is applied under (see pkgbackup.DatabasePrefix)
+func storagePrefix(backupStorage *dbtether.BackupStorage, clusterName, databaseName string) (string, error) {
+	prefix, err := pkgbackup.DatabasePrefix(backupStorage.Spec.PathTemplate, clusterName, databaseName)
 	if err != nil {
 		return "", fmt.Errorf("invalid path template: %w", err)
 	}
-
-	data := map[string]string{
-		"ClusterName":  cluster.Name,
-		"DatabaseName": db.Status.DatabaseName,
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to execute path template: %w", err)
-	}
-
-	return buf.String(), nil
+	return prefix, nil
 }
 
 func (r *BackupScheduleReconciler) handleDeletion(ctx context.Context, schedule *dbtether.BackupSchedule) (ctrl.Result, error) {
@@ -1,6 +1,7 @@
 package backup
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
 )
@@ -680,3 +682,77 @@ func TestAnnotationLastRetentionClean_Constant(t *testing.T) {
 	// Verify annotation name constant
 	assert.Equal(t, "dbtether.io/last-retention-cleanup", AnnotationLastRetentionClean)
 }
+
+func TestScheduleReconciler_CreateBackup_InheritsJobTemplate(t *testing.T) {
+	scheme := newTestScheme()
+	deadline := int64(7200)
+	schedule := &dbtether.BackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: testNamespace, UID: testUID},
+		Spec: dbtether.BackupScheduleSpec{
+			DatabaseRef: dbtether.DatabaseReference{Name: testDBName},
+			StorageRef:  dbtether.StorageReference{Name: testStorageName},
+			Schedule:    testCronSchedule,
+			JobTemplate: &dbtether.JobOverrides{ActiveDeadlineSeconds: &deadline},
+		},
+	}
+	r := &BackupScheduleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(schedule).Build(),
+		Scheme: scheme,
+	}
+
+	backup, err := r.createBackup(context.Background(), schedule, "nightly-20260120-0200")
+	require.NoError(t, err)
+	require.NotNil(t, backup.Spec.JobTemplate)
+	assert.Equal(t, int64(7200), *backup.Spec.JobTemplate.ActiveDeadlineSeconds)
+	assert.NotSame(t, schedule.Spec.JobTemplate, backup.Spec.JobTemplate)
+}
+
+func TestScheduleReconciler_CreateBackup_InheritsHooks(t *testing.T) {
+	scheme := newTestScheme()
+	schedule := &dbtether.BackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: testNamespace, UID: testUID},
+		Spec: dbtether.BackupScheduleSpec{
+			DatabaseRef: dbtether.DatabaseReference{Name: testDBName},
+			StorageRef:  dbtether.StorageReference{Name: testStorageName},
+			Schedule:    testCronSchedule,
+			PreHooks:    []dbtether.Hook{{Name: "switch-wal", SQL: "SELECT pg_catalog.pg_switch_wal()"}},
+			PostHooks: []dbtether.Hook{{Name: "notify", OnFailure: "Continue",
+				HTTP: &dbtether.HTTPHook{URL: "http://notifier/backup-done"}}},
+		},
+	}
+	r := &BackupScheduleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(schedule).Build(),
+		Scheme: scheme,
+	}
+
+	backup, err := r.createBackup(context.Background(), schedule, "nightly-20260120-0200")
+	require.NoError(t, err)
+	assert.Equal(t, schedule.Spec.PreHooks, backup.Spec.PreHooks)
+	assert.Equal(t, schedule.Spec.PostHooks, backup.Spec.PostHooks)
+	assert.NotSame(t, schedule.Spec.PostHooks[0].HTTP, backup.Spec.PostHooks[0].HTTP)
+}
+
+func TestScheduleReconciler_CreateBackup_InheritsNotifyAnnotation(t *testing.T) {
+	scheme := newTestScheme()
+	schedule := &dbtether.BackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nightly",
+			Namespace:   testNamespace,
+			UID:         testUID,
+			Annotations: map[string]string{dbtether.NotifyAnnotation: "team-webhook", "other": "value"},
+		},
+		Spec: dbtether.BackupScheduleSpec{
+			DatabaseRef: dbtether.DatabaseReference{Name: testDBName},
+			StorageRef:  dbtether.StorageReference{Name: testStorageName},
+			Schedule:    testCronSchedule,
+		},
+	}
+	r := &BackupScheduleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(schedule).Build(),
+		Scheme: scheme,
+	}
+
+	backup, err := r.createBackup(context.Background(), schedule, "nightly-20260120-0200")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{dbtether.NotifyAnnotation: "team-webhook"}, backup.Annotations)
+}
@@ -0,0 +1,27 @@
+package backup
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+// sftpEnv passes an sftp storage to a backup or restore Job. The private key is passed by
+// storageEnv from the SSH_PRIVATE_KEY key of the credentials secret, which validation requires.
+func sftpEnv(storage *databasesv1alpha1.BackupStorage) []corev1.EnvVar {
+	sftp := storage.Spec.SFTP
+	port := sftp.Port
+	if port == 0 {
+		port = 22
+	}
+	return []corev1.EnvVar{
+		{Name: "STORAGE_TYPE", Value: "sftp"},
+		{Name: "SFTP_HOST", Value: sftp.Host},
+		{Name: "SFTP_PORT", Value: strconv.Itoa(int(port))},
+		{Name: "SFTP_USER", Value: sftp.User},
+		{Name: "SFTP_PATH", Value: sftp.Path},
+		{Name: "SFTP_KNOWN_HOSTS", Value: sftp.KnownHosts},
+	}
+}
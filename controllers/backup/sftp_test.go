@@ -0,0 +1,115 @@
+package backup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+func newTestSFTPStorage(name, secretName string) *databasesv1alpha1.BackupStorage {
+	storage := newTestStorage(name)
+	storage.Spec.S3 = nil
+	storage.Spec.SFTP = testSFTPConfig()
+	storage.Spec.CredentialsSecretRef = &databasesv1alpha1.SecretReference{Name: secretName, Namespace: testOperatorNS}
+	return storage
+}
+
+// secretKeyRefs returns the secret and key of each env var read from a secret
+func secretKeyRefs(job *batchv1.Job) map[string]string {
+	refs := map[string]string{}
+	for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+		if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+			refs[e.Name] = e.ValueFrom.SecretKeyRef.Name + "/" + e.ValueFrom.SecretKeyRef.Key
+		}
+	}
+	return refs
+}
+
+func TestBackupReconciler_SFTPStorage(t *testing.T) {
+	keepLast := 7
+	schedule := &databasesv1alpha1.BackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: testNamespace},
+		Spec: databasesv1alpha1.BackupScheduleSpec{
+			Retention: &databasesv1alpha1.RetentionPolicy{KeepLast: &keepLast},
+			Replicas: []databasesv1alpha1.BackupReplica{
+				{StorageRef: databasesv1alpha1.StorageReference{Name: "archive"}},
+			},
+		},
+	}
+	backup := newTestBackup(testBackupName, testNamespace)
+	backup.Finalizers = []string{backupFinalizer}
+	backup.Labels = map[string]string{LabelScheduleName: "nightly", LabelScheduleNS: testNamespace}
+	backup.Spec.Replicas = []databasesv1alpha1.StorageReference{{Name: "archive"}}
+
+	archive := newTestSFTPStorage("archive", "archive-key")
+	archive.Spec.SFTP.Port = 2222
+	r := newTestReconciler(backup, schedule, newTestDatabase(testDBName, testNamespace, testClusterName),
+		newTestCluster(testClusterName), newTestSFTPStorage(testStorageName, "sftp-key"), archive,
+		newTestSecret(testSecretName, testOperatorNS))
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: testBackupName, Namespace: testNamespace}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	var jobs batchv1.JobList
+	require.NoError(t, r.List(context.Background(), &jobs, client.InNamespace(testOperatorNS)))
+	require.Len(t, jobs.Items, 1)
+	job := &jobs.Items[0]
+
+	env := jobEnv(job)
+	assert.Equal(t, "sftp", env["STORAGE_TYPE"])
+	assert.Equal(t, "sftp.example.com", env["SFTP_HOST"])
+	assert.Equal(t, "22", env["SFTP_PORT"])
+	assert.Equal(t, "backup", env["SFTP_USER"])
+	assert.Equal(t, "/srv/backups", env["SFTP_PATH"])
+	assert.Equal(t, testSFTPConfig().KnownHosts, env["SFTP_KNOWN_HOSTS"])
+	assert.JSONEq(t, `{"keepLast":7}`, env["RETENTION"], "sftp storages are cleaned up by the Job")
+	assert.JSONEq(t, `[{"name":"archive","sftp":{"host":"sftp.example.com","port":2222,"user":"backup",`+
+		`"path":"/srv/backups","knownHosts":"`+testSFTPConfig().KnownHosts+`"},`+
+		`"pathTemplate":"{{ .ClusterName }}/{{ .DatabaseName }}","retention":{"keepLast":7}}]`, env["REPLICAS"])
+
+	refs := secretKeyRefs(job)
+	assert.Equal(t, "sftp-key/SSH_PRIVATE_KEY", refs["SSH_PRIVATE_KEY"])
+	assert.Equal(t, "archive-key/SSH_PRIVATE_KEY", refs["REPLICA_0_SSH_PRIVATE_KEY"])
+	assert.Empty(t, job.Spec.Template.Spec.Volumes)
+}
+
+func TestRestoreReconciler_SFTPStorage(t *testing.T) {
+	r := &RestoreReconciler{Namespace: testOperatorNS, Image: testImage}
+	restore := &databasesv1alpha1.Restore{}
+	restore.Name, restore.Namespace = "restore-orders", testNamespace
+	db := newTestDatabase(testDBName, testNamespace, testClusterName)
+	db.Status.DatabaseName = "orders"
+
+	job, err := r.buildRestoreJob(restore, db, newTestCluster(testClusterName),
+		newTestSFTPStorage(testStorageName, "sftp-key"), "main/orders/backup.sql.gz", "abc12345")
+	require.NoError(t, err)
+
+	env := jobEnv(job)
+	assert.Equal(t, "sftp", env["STORAGE_TYPE"])
+	assert.Equal(t, "sftp.example.com", env["SFTP_HOST"])
+	assert.Equal(t, "/srv/backups", env["SFTP_PATH"])
+	assert.Equal(t, "sftp-key/SSH_PRIVATE_KEY", secretKeyRefs(job)["SSH_PRIVATE_KEY"])
+}
+
+func TestSFTPEnv_DefaultPort(t *testing.T) {
+	storage := newTestSFTPStorage(testStorageName, "sftp-key")
+	storage.Spec.SFTP.Port = 0
+
+	for _, e := range sftpEnv(storage) {
+		if e.Name == "SFTP_PORT" {
+			assert.Equal(t, "22", e.Value)
+			return
+		}
+	}
+	t.Error("SFTP_PORT not set")
+}
@@ -0,0 +1,104 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
+	"github.com/certainty3452/dbtether/pkg/storage"
+)
+
+// storageCredentials reads the credentials secret of a storage. Jobs run in the operator namespace
+// and read the secret by name there, so the operator does the same.
+func storageCredentials(ctx context.Context, c client.Reader, namespace string,
+	bs *databasesv1alpha1.BackupStorage) (map[string][]byte, error) {
+
+	ref := bs.Spec.CredentialsSecretRef
+	if ref == nil {
+		return nil, nil
+	}
+	if namespace == "" {
+		namespace = ref.Namespace
+	}
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get credentials secret %s/%s: %w", namespace, ref.Name, err)
+	}
+	return secret.Data, nil
+}
+
+// operatorAccessSkipReason explains why the operator cannot access a storage the way backup Jobs
+// do; empty when it can
+func operatorAccessSkipReason(bs *databasesv1alpha1.BackupStorage) string {
+	if bs.Spec.PVC != nil {
+		return "the operator does not mount the claim"
+	}
+	if bs.Spec.CredentialsSecretRef == nil && bs.Spec.JobTemplate != nil && bs.Spec.JobTemplate.ServiceAccountName != "" {
+		return fmt.Sprintf("backup Jobs authenticate as service account %s", bs.Spec.JobTemplate.ServiceAccountName)
+	}
+	return ""
+}
+
+// openStorageClient creates a client for a storage from the operator, authenticating with the keys
+// of its credentials secret like the Jobs do; close releases it
+func openStorageClient(ctx context.Context, bs *databasesv1alpha1.BackupStorage,
+	credentials map[string][]byte) (storage.StorageClient, func(), error) {
+
+	logger := slog.Default()
+	switch {
+	case bs.Spec.S3 != nil:
+		client, err := storage.NewS3Client(ctx, &storage.S3Config{
+			Bucket:    bs.Spec.S3.Bucket,
+			Region:    bs.Spec.S3.Region,
+			Endpoint:  bs.Spec.S3.Endpoint,
+			AccessKey: string(credentials["AWS_ACCESS_KEY_ID"]),
+			SecretKey: string(credentials["AWS_SECRET_ACCESS_KEY"]),
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create S3 client: %w", err)
+		}
+		return client, func() {}, nil
+	case bs.Spec.GCS != nil:
+		client, err := storage.NewGCSClient(ctx, &storage.GCSConfig{
+			Bucket:          bs.Spec.GCS.Bucket,
+			Project:         bs.Spec.GCS.Project,
+			CredentialsJSON: credentials["GCS_SERVICE_ACCOUNT_JSON"],
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create GCS client: %w", err)
+		}
+		return client, func() { _ = client.Close() }, nil
+	case bs.Spec.Azure != nil:
+		cfg := &storage.AzureConfig{
+			Container:      bs.Spec.Azure.Container,
+			StorageAccount: bs.Spec.Azure.StorageAccount,
+		}
+		pkgbackup.SetAzureCredentials(cfg, func(key string) string { return string(credentials[key]) })
+		client, err := storage.NewAzureClient(ctx, cfg, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create Azure client: %w", err)
+		}
+		return client, func() {}, nil
+	case bs.Spec.SFTP != nil:
+		client, err := storage.NewSFTPClient(ctx, &storage.SFTPConfig{
+			Host:       bs.Spec.SFTP.Host,
+			Port:       int(bs.Spec.SFTP.Port),
+			User:       bs.Spec.SFTP.User,
+			Path:       bs.Spec.SFTP.Path,
+			PrivateKey: string(credentials["SSH_PRIVATE_KEY"]),
+			KnownHosts: bs.Spec.SFTP.KnownHosts,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to SFTP server: %w", err)
+		}
+		return client, func() { _ = client.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported storage provider %q", bs.GetProvider())
+	}
+}
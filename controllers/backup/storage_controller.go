@@ -3,27 +3,51 @@ package backup
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"slices"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
 )
 
 const StorageValidationInterval = 30 * time.Minute
 
+// storageRetryInterval is how often a failed storage is validated again
+const storageRetryInterval = 60 * time.Second
+
 type BackupStorageReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Namespace where backup Jobs run; pvc storages must use a claim in it and the access probe
+	// reads the credentials secret from it
+	Namespace string
+
+	// Probe checks access to the storage; nil uses the provider's client
+	Probe StorageProbeFunc
+
+	// MaxConcurrentBackups is the BackupReconciler's limit per DBCluster; above 1, backup Jobs of
+	// a pvc storage may run at the same time
+	MaxConcurrentBackups int
 }
 
 // +kubebuilder:rbac:groups=dbtether.io,resources=backupstorages,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=dbtether.io,resources=backupstorages/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=dbtether.io,resources=backupstorages/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 
 func (r *BackupStorageReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -33,15 +57,55 @@ func (r *BackupStorageReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// Each validation updates the status, which triggers another reconcile: only validate when
+	// the spec changed or the last validation is due
+	if wait := validationDue(&storage, time.Now()); wait > 0 {
+		// Schedules using the claim are not part of the spec: check it again when they change
+		if storage.Spec.PVC != nil && storage.Status.Phase == "Ready" {
+			if err := r.validateClaim(ctx, &storage); err != nil {
+				logger.Error(err, "storage validation failed")
+				return r.updateStatus(ctx, storage.DeepCopy(), &storage, "Failed", err.Error())
+			}
+		}
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
 	logger.V(1).Info("reconciling backup storage", "name", storage.Name)
+	original := storage.DeepCopy()
 
 	if err := r.validateStorage(&storage); err != nil {
 		logger.Error(err, "storage validation failed")
-		return r.updateStatus(ctx, &storage, "Failed", err.Error())
+		return r.updateStatus(ctx, original, &storage, "Failed", err.Error())
+	}
+
+	if storage.Spec.PVC != nil {
+		if err := r.validateClaim(ctx, &storage); err != nil {
+			logger.Error(err, "storage validation failed")
+			return r.updateStatus(ctx, original, &storage, "Failed", err.Error())
+		}
+	}
+
+	message, failed := setProbeConditions(&storage, r.probe(ctx, &storage))
+	if failed {
+		logger.Info("backup storage probe failed", "provider", storage.GetProvider(), "message", message)
+		return r.updateStatus(ctx, original, &storage, "Failed", message)
 	}
 
 	logger.Info("backup storage ready", "provider", storage.GetProvider())
-	return r.updateStatus(ctx, &storage, "Ready", "storage validated successfully")
+	return r.updateStatus(ctx, original, &storage, "Ready", message)
+}
+
+// validationDue returns how long until the storage must be validated again; zero or less means now.
+// Ready storages are re-probed every StorageValidationInterval, failed ones every storageRetryInterval.
+func validationDue(storage *databasesv1alpha1.BackupStorage, now time.Time) time.Duration {
+	if storage.Status.ObservedGeneration != storage.Generation || storage.Status.LastValidation.IsZero() {
+		return 0
+	}
+	interval := StorageValidationInterval
+	if storage.Status.Phase != "Ready" {
+		interval = storageRetryInterval
+	}
+	return storage.Status.LastValidation.Add(interval).Sub(now)
 }
 
 func (r *BackupStorageReconciler) validateStorage(storage *databasesv1alpha1.BackupStorage) error {
@@ -55,41 +119,157 @@ func (r *BackupStorageReconciler) validateStorage(storage *databasesv1alpha1.Bac
 	if storage.Spec.Azure != nil {
 		providers++
 	}
+	if storage.Spec.PVC != nil {
+		providers++
+	}
+	if storage.Spec.SFTP != nil {
+		providers++
+	}
 
 	if providers == 0 {
-		return fmt.Errorf("one of s3, gcs, or azure must be specified")
+		return fmt.Errorf("one of s3, gcs, azure, pvc, or sftp must be specified")
 	}
 	if providers > 1 {
-		return fmt.Errorf("only one of s3, gcs, or azure can be specified")
+		return fmt.Errorf("only one of s3, gcs, azure, pvc, or sftp can be specified")
+	}
+
+	if pvc := storage.Spec.PVC; pvc != nil && pvc.SubPath != "" && !filepath.IsLocal(pvc.SubPath) {
+		return fmt.Errorf("pvc.subPath must be a relative path inside the volume")
+	}
+
+	if err := pkgbackup.ValidatePathTemplate(storage.Spec.PathTemplate); err != nil {
+		return err
+	}
+
+	// There is no cloud-native auth for SFTP: the key must come from a secret
+	if storage.Spec.SFTP != nil && storage.Spec.CredentialsSecretRef == nil {
+		return fmt.Errorf("sftp requires credentialsSecretRef with an SSH_PRIVATE_KEY key")
+	}
+
+	if immutability := storage.Spec.Immutability; immutability != nil {
+		if provider := storage.GetProvider(); provider == "pvc" || provider == "sftp" {
+			return fmt.Errorf("immutability is not supported for %s storage", provider)
+		}
+		if immutability.RetainFor == nil && !immutability.LegalHold {
+			return fmt.Errorf("immutability requires retainFor or legalHold")
+		}
+		if immutability.RetainFor != nil && immutability.RetainFor.Duration <= 0 {
+			return fmt.Errorf("immutability.retainFor must be positive")
+		}
 	}
 
 	return nil
 }
 
-func (r *BackupStorageReconciler) updateStatus(ctx context.Context, storage *databasesv1alpha1.BackupStorage,
-	phase, message string) (ctrl.Result, error) {
+// validateClaim checks that the claim of a pvc storage exists in the operator namespace. A claim
+// that is not ReadWriteMany is mounted on one node at a time, and the Jobs using it are pinned to
+// that node (see pinClaimJobs). That only works for occasional overlaps, so the claim must be
+// ReadWriteMany when backups of it run in parallel: ClusterBackupSchedules (one Backup per
+// database), several schedules or more than one concurrent backup Job per DBCluster.
+func (r *BackupStorageReconciler) validateClaim(ctx context.Context, storage *databasesv1alpha1.BackupStorage) error {
+	var claim corev1.PersistentVolumeClaim
+	if err := r.Get(ctx, types.NamespacedName{Name: storage.Spec.PVC.ClaimName, Namespace: r.Namespace}, &claim); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("persistentvolumeclaim %s/%s not found", r.Namespace, storage.Spec.PVC.ClaimName)
+		}
+		return err
+	}
+	if slices.Contains(claim.Spec.AccessModes, corev1.ReadWriteMany) {
+		return nil
+	}
+
+	schedules, clusterSchedules, err := r.claimSchedules(ctx, storage.Name)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(clusterSchedules) > 0:
+		return fmt.Errorf("persistentvolumeclaim %s is used by ClusterBackupSchedule %s and must be ReadWriteMany",
+			claim.Name, clusterSchedules[0])
+	case schedules > 1:
+		return fmt.Errorf("persistentvolumeclaim %s is shared by %d schedules and must be ReadWriteMany",
+			claim.Name, schedules)
+	case maxConcurrentJobs(r.MaxConcurrentBackups) > 1:
+		return fmt.Errorf("persistentvolumeclaim %s must be ReadWriteMany: up to %d backup Jobs per DBCluster "+
+			"run at the same time (backup.maxConcurrentPerCluster)", claim.Name, maxConcurrentJobs(r.MaxConcurrentBackups))
+	}
+	return nil
+}
+
+// claimSchedules counts the BackupSchedules writing to a storage, as primary storage or replica,
+// and names the ClusterBackupSchedules writing to it
+func (r *BackupStorageReconciler) claimSchedules(ctx context.Context, storageName string) (int, []string, error) {
+	count := 0
+
+	var schedules databasesv1alpha1.BackupScheduleList
+	if err := r.List(ctx, &schedules); err != nil {
+		return 0, nil, err
+	}
+	for i := range schedules.Items {
+		if slices.Contains(scheduleStorages(&schedules.Items[i]), storageName) {
+			count++
+		}
+	}
 
-	// Check if status actually changed to avoid triggering unnecessary reconciliations
-	statusChanged := storage.Status.Phase != phase ||
-		storage.Status.Message != message ||
-		storage.Status.Provider != storage.GetProvider() ||
-		storage.Status.ObservedGeneration != storage.Generation
-
-	if statusChanged {
-		patch := client.MergeFrom(storage.DeepCopy())
-		storage.Status.Phase = phase
-		storage.Status.Message = message
-		storage.Status.Provider = storage.GetProvider()
-		storage.Status.ObservedGeneration = storage.Generation
-		storage.Status.LastValidation = metav1.Now()
-
-		if err := r.Status().Patch(ctx, storage, patch); err != nil {
-			return ctrl.Result{}, err
+	var clusterSchedules databasesv1alpha1.ClusterBackupScheduleList
+	if err := r.List(ctx, &clusterSchedules); err != nil {
+		return 0, nil, err
+	}
+	var names []string
+	for i := range clusterSchedules.Items {
+		if clusterSchedules.Items[i].Spec.StorageRef.Name == storageName {
+			names = append(names, clusterSchedules.Items[i].Name)
 		}
 	}
+	return count, names, nil
+}
+
+// scheduleStorages returns the storages a BackupSchedule writes to
+func scheduleStorages(schedule *databasesv1alpha1.BackupSchedule) []string {
+	names := []string{schedule.Spec.StorageRef.Name}
+	for _, replica := range schedule.Spec.Replicas {
+		names = append(names, replica.StorageRef.Name)
+	}
+	return names
+}
+
+// storagesForSchedule enqueues the storages of a BackupSchedule or ClusterBackupSchedule, so
+// their claims are checked again when schedules are added, changed or deleted
+func (r *BackupStorageReconciler) storagesForSchedule(_ context.Context, obj client.Object) []reconcile.Request {
+	var names []string
+	switch schedule := obj.(type) {
+	case *databasesv1alpha1.BackupSchedule:
+		names = scheduleStorages(schedule)
+	case *databasesv1alpha1.ClusterBackupSchedule:
+		names = []string{schedule.Spec.StorageRef.Name}
+	}
+
+	requests := make([]reconcile.Request, 0, len(names))
+	for _, name := range names {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+	return requests
+}
+
+// updateStatus records a validation. LastValidation changes every time, so the status is always
+// patched; validationDue keeps the resulting reconcile from validating again. original is the
+// storage as read, before the probe conditions were set.
+func (r *BackupStorageReconciler) updateStatus(ctx context.Context, original, storage *databasesv1alpha1.BackupStorage,
+	phase, message string) (ctrl.Result, error) {
+
+	patch := client.MergeFrom(original)
+	storage.Status.Phase = phase
+	storage.Status.Message = message
+	storage.Status.Provider = storage.GetProvider()
+	storage.Status.ObservedGeneration = storage.Generation
+	storage.Status.LastValidation = metav1.Now()
+
+	if err := r.Status().Patch(ctx, storage, patch); err != nil {
+		return ctrl.Result{}, err
+	}
 
 	if phase == "Failed" {
-		return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: storageRetryInterval}, nil
 	}
 
 	return ctrl.Result{RequeueAfter: StorageValidationInterval}, nil
@@ -98,5 +278,7 @@ func (r *BackupStorageReconciler) updateStatus(ctx context.Context, storage *dat
 func (r *BackupStorageReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&databasesv1alpha1.BackupStorage{}).
+		Watches(&databasesv1alpha1.BackupSchedule{}, handler.EnqueueRequestsFromMapFunc(r.storagesForSchedule)).
+		Watches(&databasesv1alpha1.ClusterBackupSchedule{}, handler.EnqueueRequestsFromMapFunc(r.storagesForSchedule)).
 		Complete(r)
 }
@@ -2,12 +2,25 @@ package backup
 
 import (
 	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
 )
 
 const testBucketName = "my-bucket"
 
+func testSFTPConfig() *databasesv1alpha1.SFTPStorageConfig {
+	return &databasesv1alpha1.SFTPStorageConfig{
+		Host:       "sftp.example.com",
+		Port:       22,
+		User:       "backup",
+		Path:       "/srv/backups",
+		KnownHosts: "sftp.example.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIExample",
+	}
+}
+
 func TestBackupStorageReconciler_ValidateStorage(t *testing.T) {
 	r := &BackupStorageReconciler{}
 
@@ -59,7 +72,7 @@ func TestBackupStorageReconciler_ValidateStorage(t *testing.T) {
 				Spec: databasesv1alpha1.BackupStorageSpec{},
 			},
 			wantErr: true,
-			errMsg:  "one of s3, gcs, or azure must be specified",
+			errMsg:  "one of s3, gcs, azure, pvc, or sftp must be specified",
 		},
 		{
 			name: "multiple providers specified",
@@ -76,7 +89,128 @@ func TestBackupStorageReconciler_ValidateStorage(t *testing.T) {
 				},
 			},
 			wantErr: true,
-			errMsg:  "only one of s3, gcs, or azure can be specified",
+			errMsg:  "only one of s3, gcs, azure, pvc, or sftp can be specified",
+		},
+		{
+			name: "valid PVC config",
+			storage: &databasesv1alpha1.BackupStorage{
+				Spec: databasesv1alpha1.BackupStorageSpec{
+					PVC: &databasesv1alpha1.PVCStorageConfig{ClaimName: "backups", SubPath: "postgres/prod"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "PVC subPath outside the volume",
+			storage: &databasesv1alpha1.BackupStorage{
+				Spec: databasesv1alpha1.BackupStorageSpec{
+					PVC: &databasesv1alpha1.PVCStorageConfig{ClaimName: "backups", SubPath: "../other"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "pvc.subPath must be a relative path inside the volume",
+		},
+		{
+			name: "PVC with immutability",
+			storage: &databasesv1alpha1.BackupStorage{
+				Spec: databasesv1alpha1.BackupStorageSpec{
+					PVC:          &databasesv1alpha1.PVCStorageConfig{ClaimName: "backups"},
+					Immutability: &databasesv1alpha1.ImmutabilityConfig{LegalHold: true},
+				},
+			},
+			wantErr: true,
+			errMsg:  "immutability is not supported for pvc storage",
+		},
+		{
+			name: "valid SFTP config",
+			storage: &databasesv1alpha1.BackupStorage{
+				Spec: databasesv1alpha1.BackupStorageSpec{
+					SFTP:                 testSFTPConfig(),
+					CredentialsSecretRef: &databasesv1alpha1.SecretReference{Name: "sftp-key", Namespace: "dbtether"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "SFTP without credentials",
+			storage: &databasesv1alpha1.BackupStorage{
+				Spec: databasesv1alpha1.BackupStorageSpec{
+					SFTP: testSFTPConfig(),
+				},
+			},
+			wantErr: true,
+			errMsg:  "sftp requires credentialsSecretRef with an SSH_PRIVATE_KEY key",
+		},
+		{
+			name: "SFTP with immutability",
+			storage: &databasesv1alpha1.BackupStorage{
+				Spec: databasesv1alpha1.BackupStorageSpec{
+					SFTP:                 testSFTPConfig(),
+					CredentialsSecretRef: &databasesv1alpha1.SecretReference{Name: "sftp-key", Namespace: "dbtether"},
+					Immutability:         &databasesv1alpha1.ImmutabilityConfig{LegalHold: true},
+				},
+			},
+			wantErr: true,
+			errMsg:  "immutability is not supported for sftp storage",
+		},
+		{
+			name: "invalid path template",
+			storage: &databasesv1alpha1.BackupStorage{
+				Spec: databasesv1alpha1.BackupStorageSpec{
+					S3:           &databasesv1alpha1.S3StorageConfig{Bucket: testBucketName, Region: "eu-central-1"},
+					PathTemplate: "{{ .Cluster }}/{{ .DatabaseName }}",
+				},
+			},
+			wantErr: true,
+			errMsg: `invalid pathTemplate: template: :1:3: executing "" at <.Cluster>: ` +
+				`can't evaluate field Cluster in type *backup.TemplateData`,
+		},
+		{
+			name: "valid immutability",
+			storage: &databasesv1alpha1.BackupStorage{
+				Spec: databasesv1alpha1.BackupStorageSpec{
+					S3: &databasesv1alpha1.S3StorageConfig{
+						Bucket: testBucketName,
+						Region: "eu-central-1",
+					},
+					Immutability: &databasesv1alpha1.ImmutabilityConfig{
+						Mode:      databasesv1alpha1.ImmutabilityCompliance,
+						RetainFor: &metav1.Duration{Duration: 30 * 24 * time.Hour},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "immutability without retention or legal hold",
+			storage: &databasesv1alpha1.BackupStorage{
+				Spec: databasesv1alpha1.BackupStorageSpec{
+					S3: &databasesv1alpha1.S3StorageConfig{
+						Bucket: testBucketName,
+						Region: "eu-central-1",
+					},
+					Immutability: &databasesv1alpha1.ImmutabilityConfig{},
+				},
+			},
+			wantErr: true,
+			errMsg:  "immutability requires retainFor or legalHold",
+		},
+		{
+			name: "immutability with negative retention",
+			storage: &databasesv1alpha1.BackupStorage{
+				Spec: databasesv1alpha1.BackupStorageSpec{
+					GCS: &databasesv1alpha1.GCSStorageConfig{
+						Bucket:  testBucketName,
+						Project: "my-project",
+					},
+					Immutability: &databasesv1alpha1.ImmutabilityConfig{
+						RetainFor: &metav1.Duration{Duration: -time.Hour},
+						LegalHold: true,
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "immutability.retainFor must be positive",
 		},
 	}
 
@@ -150,100 +284,60 @@ func TestBackupStorage_GetProvider(t *testing.T) {
 	}
 }
 
-// TestBackupStorageStatusChangeDetection verifies that status is only updated when meaningful changes occur
-// This prevents unnecessary reconciliation loops caused by status patches
-func TestBackupStorageStatusChangeDetection(t *testing.T) {
+// TestValidationDue verifies that the reconcile triggered by a status patch does not validate
+// (and probe) the storage again, which would loop
+func TestValidationDue(t *testing.T) {
+	now := time.Date(2026, 1, 20, 12, 0, 0, 0, time.UTC)
+	validatedAt := func(ago time.Duration) metav1.Time { return metav1.NewTime(now.Add(-ago)) }
+
 	tests := []struct {
-		name          string
-		currentStatus databasesv1alpha1.BackupStorageStatus
-		generation    int64
-		newPhase      string
-		newMessage    string
-		newProvider   string
-		expectChanged bool
+		name     string
+		status   databasesv1alpha1.BackupStorageStatus
+		want     time.Duration
+		validate bool
 	}{
 		{
-			name: "no change - same phase, message, provider",
-			currentStatus: databasesv1alpha1.BackupStorageStatus{
-				Phase:              "Ready",
-				Message:            "storage validated",
-				Provider:           "s3",
-				ObservedGeneration: 1,
-			},
-			generation:    1,
-			newPhase:      "Ready",
-			newMessage:    "storage validated",
-			newProvider:   "s3",
-			expectChanged: false,
-		},
-		{
-			name: "phase changed",
-			currentStatus: databasesv1alpha1.BackupStorageStatus{
-				Phase:              "Ready",
-				Message:            "storage validated",
-				Provider:           "s3",
-				ObservedGeneration: 1,
-			},
-			generation:    1,
-			newPhase:      "Failed",
-			newMessage:    "validation failed",
-			newProvider:   "s3",
-			expectChanged: true,
-		},
-		{
-			name: "message changed",
-			currentStatus: databasesv1alpha1.BackupStorageStatus{
-				Phase:              "Failed",
-				Message:            "old error",
-				Provider:           "s3",
-				ObservedGeneration: 1,
-			},
-			generation:    1,
-			newPhase:      "Failed",
-			newMessage:    "new error",
-			newProvider:   "s3",
-			expectChanged: true,
-		},
-		{
-			name: "generation changed",
-			currentStatus: databasesv1alpha1.BackupStorageStatus{
-				Phase:              "Ready",
-				Message:            "storage validated",
-				Provider:           "s3",
-				ObservedGeneration: 1,
-			},
-			generation:    2,
-			newPhase:      "Ready",
-			newMessage:    "storage validated",
-			newProvider:   "s3",
-			expectChanged: true,
-		},
-		{
-			name: "provider changed",
-			currentStatus: databasesv1alpha1.BackupStorageStatus{
-				Phase:              "Ready",
-				Message:            "storage validated",
-				Provider:           "s3",
-				ObservedGeneration: 1,
-			},
-			generation:    1,
-			newPhase:      "Ready",
-			newMessage:    "storage validated",
-			newProvider:   "gcs",
-			expectChanged: true,
+			name:     "never validated",
+			validate: true,
+		},
+		{
+			name: "spec changed",
+			status: databasesv1alpha1.BackupStorageStatus{
+				Phase: "Ready", ObservedGeneration: 1, LastValidation: validatedAt(time.Minute)},
+			validate: true,
+		},
+		{
+			name: "ready, validated recently",
+			status: databasesv1alpha1.BackupStorageStatus{
+				Phase: "Ready", ObservedGeneration: 2, LastValidation: validatedAt(10 * time.Minute)},
+			want: 20 * time.Minute,
+		},
+		{
+			name: "ready, interval elapsed",
+			status: databasesv1alpha1.BackupStorageStatus{
+				Phase: "Ready", ObservedGeneration: 2, LastValidation: validatedAt(StorageValidationInterval)},
+			validate: true,
+		},
+		{
+			name: "failed, retried sooner",
+			status: databasesv1alpha1.BackupStorageStatus{
+				Phase: "Failed", ObservedGeneration: 2, LastValidation: validatedAt(20 * time.Second)},
+			want: 40 * time.Second,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Simulate the statusChanged check from updateStatus
-			statusChanged := tt.currentStatus.Phase != tt.newPhase ||
-				tt.currentStatus.Message != tt.newMessage ||
-				tt.currentStatus.Provider != tt.newProvider ||
-				tt.currentStatus.ObservedGeneration != tt.generation
+			storage := &databasesv1alpha1.BackupStorage{Status: tt.status}
+			storage.Generation = 2
 
-			if statusChanged != tt.expectChanged {
-				t.Errorf("statusChanged = %v, want %v", statusChanged, tt.expectChanged)
+			got := validationDue(storage, now)
+			if tt.validate {
+				if got > 0 {
+					t.Errorf("validationDue() = %v, want validation now", got)
+				}
+			} else if got != tt.want {
+				t.Errorf("validationDue() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,86 @@
+package backup
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+// storageEnv passes the primary storage of a backup or restore Job, with the keys of its
+// credentials secret as env vars of the same name
+func storageEnv(storage *databasesv1alpha1.BackupStorage) []corev1.EnvVar {
+	var env []corev1.EnvVar
+
+	switch {
+	case storage.Spec.S3 != nil:
+		env = append(env,
+			corev1.EnvVar{Name: "STORAGE_TYPE", Value: "s3"},
+			corev1.EnvVar{Name: "S3_BUCKET", Value: storage.Spec.S3.Bucket},
+			corev1.EnvVar{Name: "S3_REGION", Value: storage.Spec.S3.Region},
+		)
+		if storage.Spec.S3.Endpoint != "" {
+			env = append(env, corev1.EnvVar{Name: "S3_ENDPOINT", Value: storage.Spec.S3.Endpoint})
+		}
+	case storage.Spec.GCS != nil:
+		env = append(env,
+			corev1.EnvVar{Name: "STORAGE_TYPE", Value: "gcs"},
+			corev1.EnvVar{Name: "GCS_BUCKET", Value: storage.Spec.GCS.Bucket},
+		)
+		if storage.Spec.GCS.Project != "" {
+			env = append(env, corev1.EnvVar{Name: "GCS_PROJECT", Value: storage.Spec.GCS.Project})
+		}
+	case storage.Spec.Azure != nil:
+		env = append(env,
+			corev1.EnvVar{Name: "STORAGE_TYPE", Value: "azure"},
+			corev1.EnvVar{Name: "AZURE_CONTAINER", Value: storage.Spec.Azure.Container},
+			corev1.EnvVar{Name: "AZURE_ACCOUNT", Value: storage.Spec.Azure.StorageAccount},
+		)
+	case storage.Spec.PVC != nil:
+		env = append(env, corev1.EnvVar{Name: "STORAGE_TYPE", Value: "pvc"})
+	case storage.Spec.SFTP != nil:
+		env = append(env, sftpEnv(storage)...)
+	}
+
+	// Without a secret, cloud storages use IRSA/Pod Identity, Workload Identity or Managed Identity
+	return append(env, credentialsEnv(storage, func(key string) string { return key })...)
+}
+
+// credentialKeys returns the keys of the credentials secret a Job reads for a storage
+func credentialKeys(storage *databasesv1alpha1.BackupStorage) []string {
+	switch {
+	case storage.Spec.S3 != nil:
+		return []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"}
+	case storage.Spec.GCS != nil:
+		return []string{"GCS_SERVICE_ACCOUNT_JSON"}
+	case storage.Spec.Azure != nil:
+		return []string{"AZURE_STORAGE_CONNECTION_STRING", "AZURE_STORAGE_SAS_TOKEN",
+			"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET"}
+	case storage.Spec.SFTP != nil:
+		return []string{"SSH_PRIVATE_KEY"}
+	}
+	return nil
+}
+
+// credentialsEnv references the credentials secret keys of a storage as env vars named by name.
+// The Azure keys are optional: a secret holds a connection string, a SAS token or a service
+// principal, not all of them.
+func credentialsEnv(storage *databasesv1alpha1.BackupStorage, name func(key string) string) []corev1.EnvVar {
+	ref := storage.Spec.CredentialsSecretRef
+	if ref == nil {
+		return nil
+	}
+
+	var env []corev1.EnvVar
+	for _, key := range credentialKeys(storage) {
+		selector := &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+			Key:                  key,
+		}
+		if storage.Spec.Azure != nil {
+			optional := true
+			selector.Optional = &optional
+		}
+		env = append(env, corev1.EnvVar{Name: name(key), ValueFrom: &corev1.EnvVarSource{SecretKeyRef: selector}})
+	}
+	return env
+}
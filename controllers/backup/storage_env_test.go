@@ -0,0 +1,94 @@
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+func envByName(env []corev1.EnvVar) map[string]corev1.EnvVar {
+	byName := map[string]corev1.EnvVar{}
+	for _, e := range env {
+		byName[e.Name] = e
+	}
+	return byName
+}
+
+func TestStorageEnv_GCSCredentials(t *testing.T) {
+	storage := &databasesv1alpha1.BackupStorage{Spec: databasesv1alpha1.BackupStorageSpec{
+		GCS:                  &databasesv1alpha1.GCSStorageConfig{Bucket: "backups", Project: "acme"},
+		CredentialsSecretRef: &databasesv1alpha1.SecretReference{Name: "gcs-key", Namespace: testOperatorNS},
+	}}
+
+	env := envByName(storageEnv(storage))
+
+	assert.Equal(t, "gcs", env["STORAGE_TYPE"].Value)
+	assert.Equal(t, "backups", env["GCS_BUCKET"].Value)
+	assert.Equal(t, "acme", env["GCS_PROJECT"].Value)
+	key := env["GCS_SERVICE_ACCOUNT_JSON"].ValueFrom
+	require.NotNil(t, key)
+	assert.Equal(t, "gcs-key", key.SecretKeyRef.Name)
+	assert.Nil(t, key.SecretKeyRef.Optional, "the key is required when a secret is set")
+}
+
+func TestStorageEnv_AzureCredentials(t *testing.T) {
+	storage := &databasesv1alpha1.BackupStorage{Spec: databasesv1alpha1.BackupStorageSpec{
+		Azure:                &databasesv1alpha1.AzureStorageConfig{Container: "backups", StorageAccount: "acme"},
+		CredentialsSecretRef: &databasesv1alpha1.SecretReference{Name: "azure-creds", Namespace: testOperatorNS},
+	}}
+
+	env := envByName(storageEnv(storage))
+
+	assert.Equal(t, "azure", env["STORAGE_TYPE"].Value)
+	assert.Equal(t, "acme", env["AZURE_ACCOUNT"].Value)
+	for _, key := range []string{"AZURE_STORAGE_CONNECTION_STRING", "AZURE_STORAGE_SAS_TOKEN",
+		"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET"} {
+		ref := env[key].ValueFrom
+		require.NotNil(t, ref, key)
+		assert.Equal(t, "azure-creds", ref.SecretKeyRef.Name, key)
+		assert.Equal(t, key, ref.SecretKeyRef.Key)
+		require.NotNil(t, ref.SecretKeyRef.Optional, key)
+		assert.True(t, *ref.SecretKeyRef.Optional, "a secret holds only one kind of Azure credential")
+	}
+}
+
+func TestStorageEnv_WorkloadIdentity(t *testing.T) {
+	storage := &databasesv1alpha1.BackupStorage{Spec: databasesv1alpha1.BackupStorageSpec{
+		Azure: &databasesv1alpha1.AzureStorageConfig{Container: "backups", StorageAccount: "acme"},
+	}}
+
+	for _, e := range storageEnv(storage) {
+		assert.Nil(t, e.ValueFrom, "%s read from a secret without credentialsSecretRef", e.Name)
+	}
+}
+
+func TestRestoreEnv_S3Credentials(t *testing.T) {
+	r := &RestoreReconciler{}
+	storage := newTestStorage(testStorageName)
+	storage.Spec.CredentialsSecretRef = &databasesv1alpha1.SecretReference{Name: "s3-credentials", Namespace: testOperatorNS}
+	db := newTestDatabase(testDBName, testNamespace, testClusterName)
+
+	env := envByName(r.buildEnvVars(db, newTestCluster(testClusterName), storage, "path/backup.sql.gz", "fail"))
+
+	require.NotNil(t, env["AWS_SECRET_ACCESS_KEY"].ValueFrom)
+	assert.Equal(t, "s3-credentials", env["AWS_SECRET_ACCESS_KEY"].ValueFrom.SecretKeyRef.Name)
+}
+
+func TestReplicasEnv_GCSCredentials(t *testing.T) {
+	replica := &databasesv1alpha1.BackupStorage{Spec: databasesv1alpha1.BackupStorageSpec{
+		GCS:                  &databasesv1alpha1.GCSStorageConfig{Bucket: "dr"},
+		CredentialsSecretRef: &databasesv1alpha1.SecretReference{Name: "dr-key", Namespace: testOperatorNS},
+	}}
+	replica.Name = "dr-gcs"
+
+	env := envByName(replicasEnv([]*databasesv1alpha1.BackupStorage{newTestStorage("dr-s3"), replica}, nil, nil))
+
+	ref := env["REPLICA_1_GCS_SERVICE_ACCOUNT_JSON"].ValueFrom
+	require.NotNil(t, ref)
+	assert.Equal(t, "dr-key", ref.SecretKeyRef.Name)
+	assert.Equal(t, "GCS_SERVICE_ACCOUNT_JSON", ref.SecretKeyRef.Key)
+}
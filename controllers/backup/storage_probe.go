@@ -0,0 +1,127 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	pkgbackup "github.com/certainty3452/dbtether/pkg/backup"
+	"github.com/certainty3452/dbtether/pkg/storage"
+)
+
+// storageProbeTimeout bounds a probe, so an unreachable endpoint does not block the controller
+const storageProbeTimeout = 30 * time.Second
+
+// StorageProbeFunc writes, reads, lists and deletes a test object under prefix with the given
+// credentials (keys of the credentials secret)
+type StorageProbeFunc func(ctx context.Context, bs *databasesv1alpha1.BackupStorage, prefix string,
+	credentials map[string][]byte) (*storage.ProbeResult, error)
+
+// probeOutcome is what a validation found out about the storage's access
+type probeOutcome struct {
+	result     *storage.ProbeResult
+	err        error  // the probe could not run, e.g. the client could not be created
+	skipReason string // the operator cannot probe the storage the way backup Jobs access it
+}
+
+// probe checks the storage with the credentials backup Jobs use, under the static part of its
+// pathTemplate
+func (r *BackupStorageReconciler) probe(ctx context.Context, bs *databasesv1alpha1.BackupStorage) probeOutcome {
+	if reason := operatorAccessSkipReason(bs); reason != "" {
+		return probeOutcome{skipReason: reason}
+	}
+
+	credentials, err := storageCredentials(ctx, r.Client, r.Namespace, bs)
+	if err != nil {
+		return probeOutcome{err: err}
+	}
+
+	probeFn := r.Probe
+	if probeFn == nil {
+		probeFn = probeStorage
+	}
+	ctx, cancel := context.WithTimeout(ctx, storageProbeTimeout)
+	defer cancel()
+	result, err := probeFn(ctx, bs, pkgbackup.StaticPrefix(bs.Spec.PathTemplate), credentials)
+	return probeOutcome{result: result, err: err}
+}
+
+// probeStorage probes the storage with the provider's client
+func probeStorage(ctx context.Context, bs *databasesv1alpha1.BackupStorage, prefix string,
+	credentials map[string][]byte) (*storage.ProbeResult, error) {
+
+	client, closeClient, err := openStorageClient(ctx, bs, credentials)
+	if err != nil {
+		return nil, err
+	}
+	defer closeClient()
+	return storage.Probe(ctx, client, prefix), nil
+}
+
+// setProbeConditions records the outcome with one condition per permission and returns the
+// status message; failed reports whether the storage is unusable
+func setProbeConditions(bs *databasesv1alpha1.BackupStorage, outcome probeOutcome) (message string, failed bool) {
+	steps := []struct {
+		conditionType string
+		err           func(*storage.ProbeResult) error
+	}{
+		{databasesv1alpha1.StorageConditionWritable, func(r *storage.ProbeResult) error { return r.Write }},
+		{databasesv1alpha1.StorageConditionReadable, func(r *storage.ProbeResult) error { return r.Read }},
+		{databasesv1alpha1.StorageConditionListable, func(r *storage.ProbeResult) error { return r.List }},
+		{databasesv1alpha1.StorageConditionDeletable, func(r *storage.ProbeResult) error { return r.Delete }},
+	}
+
+	for _, step := range steps {
+		condition := metav1.Condition{Type: step.conditionType, ObservedGeneration: bs.Generation}
+		switch {
+		case outcome.skipReason != "":
+			condition.Status = metav1.ConditionUnknown
+			condition.Reason = databasesv1alpha1.StorageReasonNotProbed
+			condition.Message = outcome.skipReason
+		case outcome.err != nil:
+			condition.Status = metav1.ConditionUnknown
+			condition.Reason = databasesv1alpha1.StorageReasonProbeFailed
+			condition.Message = outcome.err.Error()
+		default:
+			err := step.err(outcome.result)
+			switch {
+			case err == nil:
+				condition.Status = metav1.ConditionTrue
+				condition.Reason = databasesv1alpha1.StorageReasonProbeSucceeded
+			case storage.IsPermissionError(err):
+				condition.Status = metav1.ConditionFalse
+				condition.Reason = databasesv1alpha1.StorageReasonPermissionDenied
+				condition.Message = err.Error()
+			default:
+				condition.Status = metav1.ConditionFalse
+				condition.Reason = databasesv1alpha1.StorageReasonProbeFailed
+				condition.Message = err.Error()
+			}
+		}
+		meta.SetStatusCondition(&bs.Status.Conditions, condition)
+	}
+
+	switch {
+	case outcome.skipReason != "":
+		return fmt.Sprintf("storage validated, access not probed: %s", outcome.skipReason), false
+	case outcome.err != nil:
+		return fmt.Sprintf("storage probe failed: %v", outcome.err), true
+	case !outcome.result.Failed():
+		return "storage validated successfully", false
+	}
+
+	if missing := outcome.result.MissingPermissions(); len(missing) > 0 {
+		return fmt.Sprintf("missing permissions: %s", strings.Join(missing, ", ")), true
+	}
+	for _, step := range steps {
+		if err := step.err(outcome.result); err != nil && err != storage.ErrProbeSkipped {
+			return fmt.Sprintf("storage probe failed (%s): %v", step.conditionType, err), true
+		}
+	}
+	return "storage probe failed", true
+}
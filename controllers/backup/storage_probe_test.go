@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/storage"
+)
+
+// fakeProbe records the calls of a BackupStorageReconciler probe and returns a fixed result
+type fakeProbe struct {
+	result      *storage.ProbeResult
+	err         error
+	calls       int
+	prefix      string
+	credentials map[string][]byte
+}
+
+func (p *fakeProbe) probe(_ context.Context, _ *databasesv1alpha1.BackupStorage, prefix string,
+	credentials map[string][]byte) (*storage.ProbeResult, error) {
+	p.calls++
+	p.prefix = prefix
+	p.credentials = credentials
+	return p.result, p.err
+}
+
+func reconcileStorage(t *testing.T, probe *fakeProbe, bs *databasesv1alpha1.BackupStorage,
+	objs ...client.Object) *databasesv1alpha1.BackupStorage {
+	t.Helper()
+
+	r := &BackupStorageReconciler{
+		Client: fake.NewClientBuilder().WithScheme(newTestScheme()).
+			WithObjects(append(objs, bs)...).
+			WithStatusSubresource(&databasesv1alpha1.BackupStorage{}).
+			Build(),
+		Namespace: testOperatorNS,
+		Probe:     probe.probe,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: bs.Name}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	var updated databasesv1alpha1.BackupStorage
+	require.NoError(t, r.Get(context.Background(), req.NamespacedName, &updated))
+
+	// The status patch triggers another reconcile, which must not probe again
+	calls := probe.calls
+	_, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, calls, probe.calls, "storage probed again right after validation")
+
+	return &updated
+}
+
+func conditionStatus(bs *databasesv1alpha1.BackupStorage, conditionType string) (metav1.ConditionStatus, string) {
+	condition := meta.FindStatusCondition(bs.Status.Conditions, conditionType)
+	if condition == nil {
+		return "", ""
+	}
+	return condition.Status, condition.Reason
+}
+
+func TestBackupStorageReconciler_ProbeSucceeded(t *testing.T) {
+	bs := newTestStorage(testStorageName)
+	bs.Status = databasesv1alpha1.BackupStorageStatus{}
+	bs.Spec.PathTemplate = "backups/{{ .ClusterName }}/{{ .DatabaseName }}"
+	bs.Spec.CredentialsSecretRef = &databasesv1alpha1.SecretReference{Name: "s3-credentials", Namespace: "elsewhere"}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3-credentials", Namespace: testOperatorNS},
+		Data:       map[string][]byte{"AWS_ACCESS_KEY_ID": []byte("AKIA")},
+	}
+	probe := &fakeProbe{result: &storage.ProbeResult{}}
+
+	updated := reconcileStorage(t, probe, bs, secret)
+
+	assert.Equal(t, "Ready", updated.Status.Phase)
+	assert.Equal(t, "storage validated successfully", updated.Status.Message)
+	assert.Equal(t, "backups", probe.prefix)
+	assert.Equal(t, "AKIA", string(probe.credentials["AWS_ACCESS_KEY_ID"]), "Jobs read the secret in the operator namespace")
+	for _, conditionType := range []string{
+		databasesv1alpha1.StorageConditionWritable, databasesv1alpha1.StorageConditionReadable,
+		databasesv1alpha1.StorageConditionListable, databasesv1alpha1.StorageConditionDeletable,
+	} {
+		status, reason := conditionStatus(updated, conditionType)
+		assert.Equal(t, metav1.ConditionTrue, status, conditionType)
+		assert.Equal(t, databasesv1alpha1.StorageReasonProbeSucceeded, reason, conditionType)
+	}
+}
+
+func TestBackupStorageReconciler_ProbeFailed(t *testing.T) {
+	denied := errors.New("api error AccessDenied: Access Denied")
+
+	tests := []struct {
+		name          string
+		probe         *fakeProbe
+		wantMessage   string
+		wantWritable  metav1.ConditionStatus
+		wantReason    string
+		wantDeletable metav1.ConditionStatus
+	}{
+		{
+			name: "missing permissions",
+			probe: &fakeProbe{result: &storage.ProbeResult{
+				Write: denied, Read: storage.ErrProbeSkipped, Delete: storage.ErrProbeSkipped}},
+			wantMessage:   "missing permissions: write",
+			wantWritable:  metav1.ConditionFalse,
+			wantReason:    databasesv1alpha1.StorageReasonPermissionDenied,
+			wantDeletable: metav1.ConditionFalse,
+		},
+		{
+			name:          "other error",
+			probe:         &fakeProbe{result: &storage.ProbeResult{Delete: errors.New("NoSuchBucket")}},
+			wantMessage:   "storage probe failed (Deletable): NoSuchBucket",
+			wantWritable:  metav1.ConditionTrue,
+			wantReason:    databasesv1alpha1.StorageReasonProbeSucceeded,
+			wantDeletable: metav1.ConditionFalse,
+		},
+		{
+			name:          "client not created",
+			probe:         &fakeProbe{err: errors.New("failed to create S3 client: no region")},
+			wantMessage:   "storage probe failed: failed to create S3 client: no region",
+			wantWritable:  metav1.ConditionUnknown,
+			wantReason:    databasesv1alpha1.StorageReasonProbeFailed,
+			wantDeletable: metav1.ConditionUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := newTestStorage(testStorageName)
+			bs.Status = databasesv1alpha1.BackupStorageStatus{}
+
+			updated := reconcileStorage(t, tt.probe, bs)
+
+			assert.Equal(t, "Failed", updated.Status.Phase)
+			assert.Equal(t, tt.wantMessage, updated.Status.Message)
+			status, reason := conditionStatus(updated, databasesv1alpha1.StorageConditionWritable)
+			assert.Equal(t, tt.wantWritable, status)
+			assert.Equal(t, tt.wantReason, reason)
+			status, _ = conditionStatus(updated, databasesv1alpha1.StorageConditionDeletable)
+			assert.Equal(t, tt.wantDeletable, status)
+		})
+	}
+}
+
+func TestBackupStorageReconciler_ProbeSkipped(t *testing.T) {
+	tests := []struct {
+		name        string
+		storage     func() *databasesv1alpha1.BackupStorage
+		objects     []client.Object
+		wantMessage string
+	}{
+		{
+			name:        "pvc",
+			storage:     func() *databasesv1alpha1.BackupStorage { return newTestPVCStorage(testStorageName, "backups") },
+			objects:     []client.Object{newTestClaim("backups", corev1.ReadWriteMany)},
+			wantMessage: "storage validated, access not probed: the operator does not mount the claim",
+		},
+		{
+			name: "jobs use their own service account",
+			storage: func() *databasesv1alpha1.BackupStorage {
+				bs := newTestStorage(testStorageName)
+				bs.Spec.JobTemplate = &databasesv1alpha1.JobTemplate{ServiceAccountName: "backup-writer"}
+				return bs
+			},
+			wantMessage: "storage validated, access not probed: backup Jobs authenticate as service account backup-writer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := tt.storage()
+			bs.Status = databasesv1alpha1.BackupStorageStatus{}
+			probe := &fakeProbe{}
+
+			updated := reconcileStorage(t, probe, bs, tt.objects...)
+
+			assert.Zero(t, probe.calls)
+			assert.Equal(t, "Ready", updated.Status.Phase)
+			assert.Equal(t, tt.wantMessage, updated.Status.Message)
+			status, reason := conditionStatus(updated, databasesv1alpha1.StorageConditionWritable)
+			assert.Equal(t, metav1.ConditionUnknown, status)
+			assert.Equal(t, databasesv1alpha1.StorageReasonNotProbed, reason)
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/storage"
 )
 
 var (
@@ -75,6 +76,10 @@ var _ = BeforeSuite(func() {
 	err = (&BackupStorageReconciler{
 		Client: k8sManager.GetClient(),
 		Scheme: k8sManager.GetScheme(),
+		// The test storages point at no real bucket
+		Probe: func(context.Context, *databasesv1alpha1.BackupStorage, string, map[string][]byte) (*storage.ProbeResult, error) {
+			return &storage.ProbeResult{}, nil
+		},
 	}).SetupWithManager(k8sManager)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -6,7 +6,6 @@ import (
 	"strings"
 	"time"
 
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -248,27 +247,7 @@ func (r *DatabaseReconciler) clearDatabaseOwnerIfPossible(ctx context.Context, d
 }
 
 func (r *DatabaseReconciler) getPostgresClient(ctx context.Context, cluster *databasesv1alpha1.DBCluster) (postgres.ClientInterface, error) {
-	var secret corev1.Secret
-	if err := r.Get(ctx, types.NamespacedName{
-		Name:      cluster.Spec.CredentialsSecretRef.Name,
-		Namespace: cluster.Spec.CredentialsSecretRef.Namespace,
-	}, &secret); err != nil {
-		return nil, fmt.Errorf("failed to get credentials secret: %w", err)
-	}
-
-	username := string(secret.Data["username"])
-	password := string(secret.Data["password"])
-	if username == "" || password == "" {
-		return nil, fmt.Errorf("credentials secret must contain 'username' and 'password' keys")
-	}
-
-	return r.PGClientCache.Get(ctx, cluster.Name, postgres.Config{
-		Host:     cluster.Spec.Endpoint,
-		Port:     cluster.Spec.Port,
-		Username: username,
-		Password: password,
-		Database: "postgres",
-	})
+	return getClusterPostgresClient(ctx, r.Client, r.PGClientCache, cluster)
 }
 
 func (r *DatabaseReconciler) setStatus(ctx context.Context, db *databasesv1alpha1.Database, phase, message string) (ctrl.Result, error) {
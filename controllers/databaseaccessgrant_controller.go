@@ -0,0 +1,422 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+const (
+	GrantFinalizerName = "databaseaccessgrants.dbtether.io/finalizer"
+
+	// GeneratedGrantRolePrefix prefixes roles created for grants without userRef
+	GeneratedGrantRolePrefix = "grant_"
+
+	grantPhasePending = "Pending"
+	grantPhaseActive  = "Active"
+	grantPhaseExpired = "Expired"
+	grantPhaseFailed  = "Failed"
+)
+
+type DatabaseAccessGrantReconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	PGClientCache postgres.ClientCacheInterface
+}
+
+// +kubebuilder:rbac:groups=dbtether.io,resources=databaseaccessgrants,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=dbtether.io,resources=databaseaccessgrants/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=dbtether.io,resources=databaseaccessgrants/finalizers,verbs=update
+// +kubebuilder:rbac:groups=dbtether.io,resources=databases,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbtether.io,resources=databaseusers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbtether.io,resources=dbclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+
+func (r *DatabaseAccessGrantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var grant databasesv1alpha1.DatabaseAccessGrant
+	if err := r.Get(ctx, req.NamespacedName, &grant); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !grant.DeletionTimestamp.IsZero() {
+		return r.handleDeletion(ctx, &grant)
+	}
+
+	// Expired and withdrawn grants are kept as an audit record
+	if grant.Status.Phase == grantPhaseExpired || grant.Status.RevokedAt != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(&grant, GrantFinalizerName) {
+		controllerutil.AddFinalizer(&grant, GrantFinalizerName)
+		return ctrl.Result{}, r.Update(ctx, &grant)
+	}
+
+	original := grant.DeepCopy()
+	result, err := r.reconcileGrant(ctx, &grant)
+
+	grant.Status.ObservedGeneration = grant.Generation
+	if !equality.Semantic.DeepEqual(original.Status, grant.Status) {
+		if patchErr := r.Status().Patch(ctx, &grant, client.MergeFrom(original)); patchErr != nil {
+			return ctrl.Result{}, patchErr
+		}
+	}
+	return result, err
+}
+
+// reconcileGrant grants or expires the privileges, recording the outcome in grant.Status
+//
+//nolint:gocyclo,funlen // grant lifecycle requires multiple steps
+func (r *DatabaseAccessGrantReconciler) reconcileGrant(ctx context.Context,
+	grant *databasesv1alpha1.DatabaseAccessGrant) (ctrl.Result, error) {
+
+	logger := log.FromContext(ctx)
+
+	if grant.Spec.Duration.Duration <= 0 {
+		r.setPhase(grant, grantPhaseFailed, "spec.duration must be positive")
+		return ctrl.Result{}, nil
+	}
+
+	// Expiry is checked first, revocation must not depend on the Database still being Ready
+	if grant.Status.GrantedAt != nil {
+		expiresAt := grant.Status.GrantedAt.Add(grant.Spec.Duration.Duration)
+		if !time.Now().Before(expiresAt) {
+			return r.expireGrant(ctx, grant)
+		}
+
+		// A withdrawn DatabaseReferenceGrant ends the grant before it expires
+		denied, err := checkDatabaseReferenceByName(ctx, r.Client, grant.Namespace, grant.Spec.Database)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if denied != "" {
+			return r.withdrawGrant(ctx, grant, denied)
+		}
+	}
+
+	db, cluster, pending, err := getReadyDatabase(ctx, r.Client, grant.Namespace, grant.Spec.Database)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if pending != "" {
+		r.setPhase(grant, grantPhasePending, pending)
+		return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
+	}
+	dbName := getDatabaseName(db)
+
+	username, pending, err := r.resolveUsername(ctx, grant, cluster.Name)
+	if err != nil {
+		r.setPhase(grant, grantPhaseFailed, err.Error())
+		return ctrl.Result{}, nil
+	}
+	if pending != "" {
+		r.setPhase(grant, grantPhasePending, pending)
+		return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
+	}
+
+	pgClient, err := getClusterPostgresClient(ctx, r.Client, r.PGClientCache, cluster)
+	if err != nil {
+		r.setPhase(grant, grantPhaseFailed, fmt.Sprintf("connection error: %s", err.Error()))
+		return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+	}
+
+	grantedAt := metav1.Now()
+	if grant.Status.GrantedAt != nil {
+		grantedAt = *grant.Status.GrantedAt
+	}
+	expiresAt := metav1.NewTime(grantedAt.Add(grant.Spec.Duration.Duration))
+
+	if grant.Spec.UserRef == nil {
+		secretName, owned, err := r.ensureGeneratedRole(ctx, pgClient, grant, cluster, dbName, username, expiresAt)
+		if owned {
+			grant.Status.Generated = true
+			grant.Status.SecretName = secretName
+		}
+		if err != nil {
+			// A role created before the failure is ours; record it so revocation drops it
+			if owned {
+				r.recordGrantTarget(grant, cluster.Name, dbName, username)
+			}
+			r.setPhase(grant, grantPhaseFailed, err.Error())
+			return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+		}
+	}
+
+	// Recorded before granting, so privileges from a partially applied grant are revoked too
+	r.recordGrantTarget(grant, cluster.Name, dbName, username)
+	if err := r.applyGrant(ctx, pgClient, grant, username, dbName); err != nil {
+		r.setPhase(grant, grantPhaseFailed, err.Error())
+		return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+	}
+
+	if grant.Status.GrantedAt == nil {
+		logger.Info("access granted", "username", username, "database", dbName,
+			"privileges", grant.Spec.Privileges, "approvedBy", grant.Spec.Approval.ApprovedBy, "expiresAt", expiresAt)
+		approval := grant.Spec.Approval
+		grant.Status.Approval = &approval
+		grant.Status.GrantedAt = &grantedAt
+	}
+	grant.Status.ExpiresAt = &expiresAt
+	r.setPhase(grant, grantPhaseActive, fmt.Sprintf("%s access on %s until %s",
+		r.getPrivileges(grant), dbName, expiresAt.UTC().Format(time.RFC3339)))
+
+	return ctrl.Result{RequeueAfter: time.Until(expiresAt.Time)}, nil
+}
+
+func (r *DatabaseAccessGrantReconciler) getPrivileges(grant *databasesv1alpha1.DatabaseAccessGrant) string {
+	if grant.Spec.Privileges != "" {
+		return grant.Spec.Privileges
+	}
+	return "readwrite"
+}
+
+// recordGrantTarget records the role and database revokeGrant cleans up
+func (r *DatabaseAccessGrantReconciler) recordGrantTarget(grant *databasesv1alpha1.DatabaseAccessGrant,
+	clusterName, dbName, username string) {
+
+	grant.Status.ClusterName = clusterName
+	grant.Status.DatabaseName = dbName
+	grant.Status.Username = username
+}
+
+// getGeneratedUsername returns the role name used when no userRef is set. Names longer than the
+// 63 byte identifier limit are shortened with a hash of the grant name, so they stay distinct.
+func (r *DatabaseAccessGrantReconciler) getGeneratedUsername(grant *databasesv1alpha1.DatabaseAccessGrant) string {
+	name := GeneratedGrantRolePrefix + strings.ReplaceAll(grant.Name, "-", "_")
+	if len(name) <= 63 {
+		return name
+	}
+	sum := sha256.Sum256([]byte(grant.Name))
+	return strings.TrimRight(name[:54], "_") + "_" + hex.EncodeToString(sum[:4])
+}
+
+func (r *DatabaseAccessGrantReconciler) getSecretName(grant *databasesv1alpha1.DatabaseAccessGrant) string {
+	return grant.Name + "-credentials"
+}
+
+// setPhase updates phase and message, turning Pending into Failed after PendingTimeout
+func (r *DatabaseAccessGrantReconciler) setPhase(grant *databasesv1alpha1.DatabaseAccessGrant, phase, message string) {
+	grant.Status.Phase, grant.Status.Message = applyPendingTimeout(&grant.Status.PendingSince, phase, message,
+		grantPhasePending, grantPhaseFailed)
+}
+
+// resolveUsername returns the PostgreSQL role of the referenced DatabaseUser or the generated role name
+func (r *DatabaseAccessGrantReconciler) resolveUsername(ctx context.Context, grant *databasesv1alpha1.DatabaseAccessGrant,
+	clusterName string) (username, pending string, err error) {
+
+	if grant.Spec.UserRef == nil {
+		// Keep the role already created, even if it was named by an earlier scheme
+		if grant.Status.Generated && grant.Status.Username != "" {
+			return grant.Status.Username, "", nil
+		}
+		return r.getGeneratedUsername(grant), "", nil
+	}
+
+	var user databasesv1alpha1.DatabaseUser
+	if err := r.Get(ctx, types.NamespacedName{Name: grant.Spec.UserRef.Name, Namespace: grant.Namespace}, &user); err != nil {
+		if errors.IsNotFound(err) {
+			return "", fmt.Sprintf("waiting for DatabaseUser '%s'", grant.Spec.UserRef.Name), nil
+		}
+		return "", "", err
+	}
+	if user.Status.Phase != "Ready" || user.Status.Username == "" {
+		return "", fmt.Sprintf("waiting for DatabaseUser '%s' to be ready", user.Name), nil
+	}
+	if user.Status.ClusterName != clusterName {
+		return "", "", fmt.Errorf("DatabaseUser '%s' is on cluster '%s', but the database is on '%s'",
+			user.Name, user.Status.ClusterName, clusterName)
+	}
+	return user.Status.Username, "", nil
+}
+
+// ensureGeneratedRole creates the temporary role for grants without userRef
+func (r *DatabaseAccessGrantReconciler) ensureGeneratedRole(ctx context.Context, pgClient postgres.ClientInterface,
+	grant *databasesv1alpha1.DatabaseAccessGrant, cluster *databasesv1alpha1.DBCluster,
+	dbName, username string, expiresAt metav1.Time) (secretName string, owned bool, err error) {
+
+	secretName = r.getSecretName(grant)
+	owned, err = ensureTemporaryRole(ctx, r.Client, r.Scheme, pgClient, temporaryRole{
+		Owner:      grant,
+		Username:   username,
+		SecretName: secretName,
+		ExpiresAt:  expiresAt,
+		SecretData: map[string][]byte{
+			"host":     []byte(cluster.Spec.Endpoint),
+			"port":     []byte(strconv.Itoa(cluster.Spec.Port)),
+			"database": []byte(dbName),
+			"user":     []byte(username),
+		},
+	})
+	return secretName, owned, err
+}
+
+func (r *DatabaseAccessGrantReconciler) applyGrant(ctx context.Context, pgClient postgres.ClientInterface,
+	grant *databasesv1alpha1.DatabaseAccessGrant, username, dbName string) error {
+
+	if err := pgClient.GrantDatabaseAccess(ctx, username, dbName); err != nil {
+		return fmt.Errorf("failed to grant database access: %w", err)
+	}
+	if err := pgClient.ApplyPrivileges(ctx, username, dbName, r.getPrivileges(grant),
+		toTableGrants(grant.Spec.AdditionalGrants)); err != nil {
+		return fmt.Errorf("failed to apply privileges: %w", err)
+	}
+	return nil
+}
+
+// expireGrant revokes the privileges and marks the grant Expired
+func (r *DatabaseAccessGrantReconciler) expireGrant(ctx context.Context,
+	grant *databasesv1alpha1.DatabaseAccessGrant) (ctrl.Result, error) {
+
+	if err := r.revokeGrant(ctx, grant); err != nil {
+		r.setPhase(grant, grantPhaseFailed, fmt.Sprintf("failed to revoke expired grant: %s", err.Error()))
+		return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+	}
+
+	now := metav1.Now()
+	grant.Status.RevokedAt = &now
+	grant.Status.SecretName = ""
+	r.setPhase(grant, grantPhaseExpired, "grant expired, privileges revoked")
+	log.FromContext(ctx).Info("access grant expired", "username", grant.Status.Username, "database", grant.Status.DatabaseName)
+	return ctrl.Result{}, nil
+}
+
+// withdrawGrant revokes the privileges of a grant whose Database is no longer shared with its namespace
+func (r *DatabaseAccessGrantReconciler) withdrawGrant(ctx context.Context,
+	grant *databasesv1alpha1.DatabaseAccessGrant, denied string) (ctrl.Result, error) {
+
+	if err := r.revokeGrant(ctx, grant); err != nil {
+		r.setPhase(grant, grantPhaseFailed, fmt.Sprintf("%s; failed to revoke privileges: %s", denied, err.Error()))
+		return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+	}
+
+	now := metav1.Now()
+	grant.Status.RevokedAt = &now
+	grant.Status.SecretName = ""
+	r.setPhase(grant, grantPhaseFailed, denied+"; privileges revoked")
+	log.FromContext(ctx).Info("access grant revoked, database no longer shared", "username", grant.Status.Username,
+		"database", grant.Status.DatabaseName)
+	return ctrl.Result{}, nil
+}
+
+// revokeGrant removes the granted privileges. Generated roles are dropped, existing
+// DatabaseUsers get their own privileges on the database re-applied.
+func (r *DatabaseAccessGrantReconciler) revokeGrant(ctx context.Context, grant *databasesv1alpha1.DatabaseAccessGrant) error {
+	logger := log.FromContext(ctx)
+	username, dbName := grant.Status.Username, grant.Status.DatabaseName
+	if username == "" || dbName == "" {
+		return nil // never granted
+	}
+
+	var cluster databasesv1alpha1.DBCluster
+	if err := r.Get(ctx, types.NamespacedName{Name: grant.Status.ClusterName}, &cluster); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("cluster not found, skipping revocation", "cluster", grant.Status.ClusterName)
+			return nil
+		}
+		return err
+	}
+
+	pgClient, err := getClusterPostgresClient(ctx, r.Client, r.PGClientCache, &cluster)
+	if err != nil {
+		return fmt.Errorf("failed to get postgres client: %w", err)
+	}
+
+	if grant.Status.Generated {
+		return dropTemporaryRole(ctx, r.Client, pgClient, grant, username, dbName, r.getSecretName(grant))
+	}
+
+	if err := pgClient.RevokePrivilegesInDatabase(ctx, username, dbName); err != nil {
+		return err
+	}
+	return r.restoreUserPrivileges(ctx, pgClient, grant, dbName)
+}
+
+// restoreUserPrivileges re-applies the DatabaseUser's own privileges if the granted database is one of its databases
+func (r *DatabaseAccessGrantReconciler) restoreUserPrivileges(ctx context.Context, pgClient postgres.ClientInterface,
+	grant *databasesv1alpha1.DatabaseAccessGrant, dbName string) error {
+
+	if grant.Spec.UserRef == nil {
+		return nil
+	}
+	var user databasesv1alpha1.DatabaseUser
+	if err := r.Get(ctx, types.NamespacedName{Name: grant.Spec.UserRef.Name, Namespace: grant.Namespace}, &user); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	for _, access := range user.Status.Databases {
+		if access.DatabaseName != dbName || access.Phase != "Ready" {
+			continue
+		}
+		if err := pgClient.GrantDatabaseAccess(ctx, grant.Status.Username, dbName); err != nil {
+			return err
+		}
+		return pgClient.ApplyPrivileges(ctx, grant.Status.Username, dbName, access.Privileges,
+			toTableGrants(user.Spec.AdditionalGrants))
+	}
+	return nil
+}
+
+func (r *DatabaseAccessGrantReconciler) handleDeletion(ctx context.Context,
+	grant *databasesv1alpha1.DatabaseAccessGrant) (ctrl.Result, error) {
+
+	if !controllerutil.ContainsFinalizer(grant, GrantFinalizerName) {
+		return ctrl.Result{}, nil
+	}
+
+	// Revoke early if the grant is deleted before it expired
+	if grant.Status.RevokedAt == nil {
+		if err := r.revokeGrant(ctx, grant); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to revoke grant: %w", err)
+		}
+		log.FromContext(ctx).Info("access grant revoked on deletion", "username", grant.Status.Username)
+	}
+
+	controllerutil.RemoveFinalizer(grant, GrantFinalizerName)
+	return ctrl.Result{}, r.Update(ctx, grant)
+}
+
+func (r *DatabaseAccessGrantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&databasesv1alpha1.DatabaseAccessGrant{}).
+		Owns(&corev1.Secret{}).
+		Watches(&databasesv1alpha1.DatabaseReferenceGrant{}, handler.EnqueueRequestsFromMapFunc(r.findGrantsForReferenceGrant)).
+		Complete(r)
+}
+
+// getDatabaseName returns the PostgreSQL database name of a Database resource
+func getDatabaseName(db *databasesv1alpha1.Database) string {
+	if db.Spec.DatabaseName != "" {
+		return db.Spec.DatabaseName
+	}
+	return strings.ReplaceAll(db.Name, "-", "_")
+}
+
+func toTableGrants(grants []databasesv1alpha1.TableGrant) []postgres.TableGrant {
+	result := make([]postgres.TableGrant, len(grants))
+	for i, g := range grants {
+		result[i] = postgres.TableGrant{
+			Tables:     g.Tables,
+			Privileges: g.Privileges,
+		}
+	}
+	return result
+}
@@ -0,0 +1,367 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+const testGrantNamespace = "team-alpha"
+
+func newGrantTestObjects() []client.Object {
+	return []client.Object{
+		&databasesv1alpha1.DBCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "main"},
+			Spec: databasesv1alpha1.DBClusterSpec{
+				Endpoint:             "db.example.com",
+				Port:                 5432,
+				CredentialsSecretRef: &databasesv1alpha1.SecretReference{Name: "admin", Namespace: "dbtether"},
+			},
+			Status: databasesv1alpha1.DBClusterStatus{Phase: "Connected"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: "dbtether"},
+			Data:       map[string][]byte{"username": []byte("postgres"), "password": []byte("secret")},
+		},
+		&databasesv1alpha1.Database{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders-db", Namespace: testGrantNamespace},
+			Spec:       databasesv1alpha1.DatabaseSpec{ClusterRef: databasesv1alpha1.ClusterReference{Name: "main"}},
+			Status:     databasesv1alpha1.DatabaseStatus{Phase: "Ready"},
+		},
+		&databasesv1alpha1.DatabaseUser{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders-api", Namespace: testGrantNamespace},
+			Spec: databasesv1alpha1.DatabaseUserSpec{
+				Database:   &databasesv1alpha1.DatabaseAccess{Name: "orders-db"},
+				Privileges: "readonly",
+			},
+			Status: databasesv1alpha1.DatabaseUserStatus{
+				Phase:       "Ready",
+				ClusterName: "main",
+				Username:    "orders_api",
+				Databases: []databasesv1alpha1.DatabaseAccessStatus{
+					{Name: "orders-db", DatabaseName: "orders_db", Phase: "Ready", Privileges: "readonly"},
+				},
+			},
+		},
+	}
+}
+
+func newTestGrant(name string, userRef *databasesv1alpha1.UserReference) *databasesv1alpha1.DatabaseAccessGrant {
+	return &databasesv1alpha1.DatabaseAccessGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testGrantNamespace},
+		Spec: databasesv1alpha1.DatabaseAccessGrantSpec{
+			Database:   databasesv1alpha1.DatabaseReference{Name: "orders-db"},
+			UserRef:    userRef,
+			Privileges: "readwrite",
+			Duration:   metav1.Duration{Duration: 4 * time.Hour},
+			Approval:   databasesv1alpha1.GrantApproval{ApprovedBy: "jane", Reason: "INC-1", Ticket: "INC-1"},
+		},
+	}
+}
+
+func newGrantTestReconciler(pgClient *postgres.MockClient, objects ...client.Object) *DatabaseAccessGrantReconciler {
+	c, scheme, cache := newTemporaryRoleTestClient(pgClient, &databasesv1alpha1.DatabaseAccessGrant{}, objects...)
+	return &DatabaseAccessGrantReconciler{Client: c, Scheme: scheme, PGClientCache: cache}
+}
+
+// reconcileTestGrant runs Reconcile (finalizer + grant) and returns the updated grant
+func reconcileTestGrant(t *testing.T, r *DatabaseAccessGrantReconciler, name string) (*databasesv1alpha1.DatabaseAccessGrant, ctrl.Result) {
+	t.Helper()
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: testGrantNamespace}}
+
+	var result ctrl.Result
+	for i := 0; i < 2; i++ {
+		var err error
+		if result, err = r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+
+	var grant databasesv1alpha1.DatabaseAccessGrant
+	if err := r.Get(ctx, req.NamespacedName, &grant); err != nil {
+		t.Fatalf("failed to get grant: %v", err)
+	}
+	return &grant, result
+}
+
+// backdateGrant moves grantedAt into the past so the grant is expired on the next reconcile
+func backdateGrant(t *testing.T, r *DatabaseAccessGrantReconciler, grant *databasesv1alpha1.DatabaseAccessGrant) {
+	t.Helper()
+	past := metav1.NewTime(time.Now().Add(-5 * time.Hour))
+	grant.Status.GrantedAt = &past
+	if err := r.Status().Update(context.Background(), grant); err != nil {
+		t.Fatalf("failed to backdate grant: %v", err)
+	}
+}
+
+func hasDatabaseAccess(t *testing.T, pgClient *postgres.MockClient, username, dbName string) bool {
+	t.Helper()
+	databases, _ := pgClient.GetUserDatabaseAccess(context.Background(), username)
+	for _, db := range databases {
+		if db == dbName {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDatabaseAccessGrantReconciler_ExistingUser(t *testing.T) {
+	pgClient := postgres.NewMockClient()
+	pgClient.AddUser("orders_api", "pw")
+
+	grant := newTestGrant("inc-1", &databasesv1alpha1.UserReference{Name: "orders-api"})
+	r := newGrantTestReconciler(pgClient, append(newGrantTestObjects(), grant)...)
+
+	grant, result := reconcileTestGrant(t, r, "inc-1")
+	if grant.Status.Phase != "Active" {
+		t.Fatalf("phase = %s (%s), want Active", grant.Status.Phase, grant.Status.Message)
+	}
+	if grant.Status.Username != "orders_api" || grant.Status.DatabaseName != "orders_db" || grant.Status.Generated {
+		t.Errorf("unexpected status: %+v", grant.Status)
+	}
+	if grant.Status.Approval == nil || grant.Status.Approval.ApprovedBy != "jane" {
+		t.Errorf("approval not recorded: %+v", grant.Status.Approval)
+	}
+	if grant.Status.ExpiresAt == nil || !grant.Status.ExpiresAt.Equal(&metav1.Time{Time: grant.Status.GrantedAt.Add(4 * time.Hour)}) {
+		t.Errorf("expiresAt = %v, want grantedAt + 4h", grant.Status.ExpiresAt)
+	}
+	if result.RequeueAfter <= 3*time.Hour || result.RequeueAfter > 4*time.Hour {
+		t.Errorf("RequeueAfter = %v, want about 4h", result.RequeueAfter)
+	}
+	if !hasDatabaseAccess(t, pgClient, "orders_api", "orders_db") {
+		t.Error("expected CONNECT on orders_db")
+	}
+
+	backdateGrant(t, r, grant)
+	grant, _ = reconcileTestGrant(t, r, "inc-1")
+	if grant.Status.Phase != "Expired" || grant.Status.RevokedAt == nil {
+		t.Fatalf("status = %+v, want Expired with revokedAt", grant.Status)
+	}
+	// orders_db is one of the user's own databases, so its access is restored
+	if !hasDatabaseAccess(t, pgClient, "orders_api", "orders_db") {
+		t.Error("DatabaseUser access to its own database should be restored after expiry")
+	}
+	if !pgClientHasUser(pgClient, "orders_api") {
+		t.Error("existing DatabaseUser role must not be dropped")
+	}
+}
+
+func TestDatabaseAccessGrantReconciler_ClusterCredentialsFromEnv(t *testing.T) {
+	t.Setenv("PG_ADMIN_USER", "postgres")
+	t.Setenv("PG_ADMIN_PASSWORD", "secret")
+
+	pgClient := postgres.NewMockClient()
+	pgClient.AddUser("orders_api", "pw")
+
+	objects := newGrantTestObjects()
+	cluster := objects[0].(*databasesv1alpha1.DBCluster)
+	cluster.Spec.CredentialsSecretRef = nil
+	cluster.Spec.CredentialsFromEnv = &databasesv1alpha1.CredentialsFromEnv{
+		Username: "PG_ADMIN_USER", Password: "PG_ADMIN_PASSWORD",
+	}
+	grant := newTestGrant("inc-1", &databasesv1alpha1.UserReference{Name: "orders-api"})
+	r := newGrantTestReconciler(pgClient, append(objects, grant)...)
+
+	grant, _ = reconcileTestGrant(t, r, "inc-1")
+	if grant.Status.Phase != "Active" {
+		t.Fatalf("phase = %s (%s), want Active", grant.Status.Phase, grant.Status.Message)
+	}
+
+	// Without any credential source the grant fails instead of panicking
+	cluster.Spec.CredentialsFromEnv = nil
+	if _, err := getClusterPostgresClient(context.Background(), r.Client, r.PGClientCache, cluster); err == nil {
+		t.Error("getClusterPostgresClient() should fail without credentials")
+	}
+}
+
+func pgClientHasUser(pgClient *postgres.MockClient, username string) bool {
+	for _, u := range pgClient.GetUsers() {
+		if u == username {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDatabaseAccessGrantReconciler_GeneratedRole(t *testing.T) {
+	ctx := context.Background()
+	pgClient := postgres.NewMockClient()
+
+	grant := newTestGrant("inc-2-debug", nil)
+	r := newGrantTestReconciler(pgClient, append(newGrantTestObjects(), grant)...)
+
+	grant, _ = reconcileTestGrant(t, r, "inc-2-debug")
+	if grant.Status.Phase != "Active" {
+		t.Fatalf("phase = %s (%s), want Active", grant.Status.Phase, grant.Status.Message)
+	}
+	if grant.Status.Username != "grant_inc_2_debug" || !grant.Status.Generated {
+		t.Errorf("unexpected status: %+v", grant.Status)
+	}
+	if !pgClientHasUser(pgClient, "grant_inc_2_debug") {
+		t.Fatal("expected generated role")
+	}
+	if attrs := pgClient.GetRoleAttributes("grant_inc_2_debug"); attrs.ValidUntil != grant.Status.ExpiresAt.UTC().Format(time.RFC3339) {
+		t.Errorf("VALID UNTIL = %q, want expiresAt", attrs.ValidUntil)
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: "inc-2-debug-credentials", Namespace: testGrantNamespace}, &secret); err != nil {
+		t.Fatalf("expected credentials secret: %v", err)
+	}
+	if string(secret.Data["user"]) != "grant_inc_2_debug" || string(secret.Data["database"]) != "orders_db" ||
+		string(secret.Data["host"]) != "db.example.com" || len(secret.Data["password"]) == 0 {
+		t.Errorf("unexpected secret data: %v", secret.Data)
+	}
+	if !metav1.IsControlledBy(&secret, grant) {
+		t.Error("secret should be owned by the grant")
+	}
+
+	backdateGrant(t, r, grant)
+	grant, _ = reconcileTestGrant(t, r, "inc-2-debug")
+	if grant.Status.Phase != "Expired" {
+		t.Fatalf("phase = %s, want Expired", grant.Status.Phase)
+	}
+	if pgClientHasUser(pgClient, "grant_inc_2_debug") {
+		t.Error("generated role should be dropped on expiry")
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "inc-2-debug-credentials", Namespace: testGrantNamespace}, &secret); err == nil {
+		t.Error("credentials secret should be deleted on expiry")
+	}
+}
+
+func TestDatabaseAccessGrantReconciler_GeneratedRoleConflict(t *testing.T) {
+	pgClient := postgres.NewMockClient()
+	pgClient.AddUser("grant_inc_3", "someone-elses")
+
+	grant := newTestGrant("inc-3", nil)
+	r := newGrantTestReconciler(pgClient, append(newGrantTestObjects(), grant)...)
+
+	grant, _ = reconcileTestGrant(t, r, "inc-3")
+	if grant.Status.Phase != "Failed" || !strings.Contains(grant.Status.Message, "already exists") {
+		t.Errorf("status = %s (%s), want Failed for foreign role", grant.Status.Phase, grant.Status.Message)
+	}
+}
+
+func TestDatabaseAccessGrantReconciler_FailedGrantDropsGeneratedRole(t *testing.T) {
+	ctx := context.Background()
+	pgClient := postgres.NewMockClient()
+	pgClient.GrantErrors = map[string]error{"orders_db": fmt.Errorf("permission denied")}
+
+	grant := newTestGrant("inc-6", nil)
+	r := newGrantTestReconciler(pgClient, append(newGrantTestObjects(), grant)...)
+
+	grant, _ = reconcileTestGrant(t, r, "inc-6")
+	if grant.Status.Phase != "Failed" {
+		t.Fatalf("phase = %s (%s), want Failed", grant.Status.Phase, grant.Status.Message)
+	}
+	if !pgClientHasUser(pgClient, "grant_inc_6") {
+		t.Fatal("expected the role to be created before the grant failed")
+	}
+	if grant.Status.Username != "grant_inc_6" || grant.Status.DatabaseName != "orders_db" ||
+		grant.Status.ClusterName != "main" || !grant.Status.Generated {
+		t.Errorf("role not recorded for cleanup: %+v", grant.Status)
+	}
+
+	if err := r.Delete(ctx, grant); err != nil {
+		t.Fatalf("failed to delete grant: %v", err)
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "inc-6", Namespace: testGrantNamespace}}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if pgClientHasUser(pgClient, "grant_inc_6") {
+		t.Error("generated role of a failed grant should be dropped when the grant is deleted")
+	}
+}
+
+func TestDatabaseAccessGrantReconciler_GeneratedUsernameLongNames(t *testing.T) {
+	r := &DatabaseAccessGrantReconciler{}
+	stem := strings.Repeat("incident-", 7)
+
+	first := r.getGeneratedUsername(newTestGrant(stem+"first", nil))
+	second := r.getGeneratedUsername(newTestGrant(stem+"second", nil))
+	if first == second {
+		t.Errorf("grants with a shared 57 character stem map to the same role %q", first)
+	}
+	for _, name := range []string{first, second} {
+		if len(name) > 63 || !strings.HasPrefix(name, GeneratedGrantRolePrefix+"incident_") {
+			t.Errorf("generated username %q", name)
+		}
+	}
+	if got := r.getGeneratedUsername(newTestGrant(stem+"first", nil)); got != first {
+		t.Errorf("generated username is not stable: %q != %q", got, first)
+	}
+	if got := r.getGeneratedUsername(newTestGrant("inc-7", nil)); got != "grant_inc_7" {
+		t.Errorf("short names are kept as is, got %q", got)
+	}
+}
+
+func TestDatabaseAccessGrantReconciler_PendingUntilUserReady(t *testing.T) {
+	pgClient := postgres.NewMockClient()
+	objects := newGrantTestObjects()
+	objects[3].(*databasesv1alpha1.DatabaseUser).Status.Phase = "Pending"
+
+	grant := newTestGrant("inc-4", &databasesv1alpha1.UserReference{Name: "orders-api"})
+	r := newGrantTestReconciler(pgClient, append(objects, grant)...)
+
+	grant, result := reconcileTestGrant(t, r, "inc-4")
+	if grant.Status.Phase != "Pending" || grant.Status.GrantedAt != nil {
+		t.Errorf("status = %+v, want Pending without grantedAt", grant.Status)
+	}
+	if result.RequeueAfter == 0 {
+		t.Error("expected requeue while pending")
+	}
+}
+
+func TestDatabaseAccessGrantReconciler_DeleteActiveGrant(t *testing.T) {
+	ctx := context.Background()
+	pgClient := postgres.NewMockClient()
+
+	grant := newTestGrant("inc-5", nil)
+	r := newGrantTestReconciler(pgClient, append(newGrantTestObjects(), grant)...)
+
+	grant, _ = reconcileTestGrant(t, r, "inc-5")
+	if grant.Status.Phase != "Active" {
+		t.Fatalf("phase = %s, want Active", grant.Status.Phase)
+	}
+
+	if err := r.Delete(ctx, grant); err != nil {
+		t.Fatalf("failed to delete grant: %v", err)
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "inc-5", Namespace: testGrantNamespace}}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if pgClientHasUser(pgClient, "grant_inc_5") {
+		t.Error("generated role should be dropped when the grant is deleted")
+	}
+}
+
+func TestDatabaseUserReconciler_GetActiveGrantDatabases(t *testing.T) {
+	user := &databasesv1alpha1.DatabaseUser{ObjectMeta: metav1.ObjectMeta{Name: "orders-api", Namespace: testGrantNamespace}}
+
+	active := newTestGrant("active", &databasesv1alpha1.UserReference{Name: "orders-api"})
+	active.Status = databasesv1alpha1.DatabaseAccessGrantStatus{Phase: "Active", DatabaseName: "billing_db"}
+	expired := newTestGrant("expired", &databasesv1alpha1.UserReference{Name: "orders-api"})
+	expired.Status = databasesv1alpha1.DatabaseAccessGrantStatus{Phase: "Expired", DatabaseName: "audit_db"}
+	otherUser := newTestGrant("other", &databasesv1alpha1.UserReference{Name: "someone"})
+	otherUser.Status = databasesv1alpha1.DatabaseAccessGrantStatus{Phase: "Active", DatabaseName: "hr_db"}
+
+	r := newTestReconciler(user, active, expired, otherUser)
+	got := r.getActiveGrantDatabases(context.Background(), user)
+	if len(got) != 1 || got[0] != "billing_db" {
+		t.Errorf("getActiveGrantDatabases() = %v, want [billing_db]", got)
+	}
+}
@@ -0,0 +1,553 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+const (
+	SessionFinalizerName = "databasesessions.dbtether.io/finalizer"
+
+	// SessionRolePrefix prefixes roles created for DatabaseSessions
+	SessionRolePrefix = "session_"
+
+	// SessionLabel is set on the proxy Pod and selected by the proxy Service
+	SessionLabel = "dbtether.io/session"
+
+	DefaultSessionSocatImage     = "alpine/socat:1.8.0.1"
+	DefaultSessionPgBouncerImage = "edoburu/pgbouncer:v1.24.1-p1"
+	DefaultSessionMaxTTL         = 8 * time.Hour
+
+	sessionProxySocat     = "socat"
+	sessionProxyPgBouncer = "pgbouncer"
+
+	sessionPhasePending = "Pending"
+	sessionPhaseReady   = "Ready"
+	sessionPhaseExpired = "Expired"
+	sessionPhaseFailed  = "Failed"
+)
+
+type DatabaseSessionReconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	PGClientCache postgres.ClientCacheInterface
+
+	// SocatImage and PgBouncerImage are the default proxy images
+	SocatImage     string
+	PgBouncerImage string
+
+	// MaxTTL is the longest TTL a session may request
+	MaxTTL time.Duration
+}
+
+// +kubebuilder:rbac:groups=dbtether.io,resources=databasesessions,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=dbtether.io,resources=databasesessions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=dbtether.io,resources=databasesessions/finalizers,verbs=update
+// +kubebuilder:rbac:groups=dbtether.io,resources=databases,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbtether.io,resources=dbclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;delete
+
+func (r *DatabaseSessionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var session databasesv1alpha1.DatabaseSession
+	if err := r.Get(ctx, req.NamespacedName, &session); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !session.DeletionTimestamp.IsZero() {
+		return r.handleDeletion(ctx, &session)
+	}
+
+	// Ended sessions are kept as a record of who had access
+	if session.Status.Phase == sessionPhaseExpired || session.Status.EndedAt != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(&session, SessionFinalizerName) {
+		controllerutil.AddFinalizer(&session, SessionFinalizerName)
+		return ctrl.Result{}, r.Update(ctx, &session)
+	}
+
+	original := session.DeepCopy()
+	result, err := r.reconcileSession(ctx, &session)
+
+	session.Status.ObservedGeneration = session.Generation
+	if !equality.Semantic.DeepEqual(original.Status, session.Status) {
+		if patchErr := r.Status().Patch(ctx, &session, client.MergeFrom(original)); patchErr != nil {
+			return ctrl.Result{}, patchErr
+		}
+	}
+	return result, err
+}
+
+// reconcileSession provisions the role and proxy or tears them down once the TTL expired
+//
+//nolint:gocyclo,funlen // session lifecycle requires multiple steps
+func (r *DatabaseSessionReconciler) reconcileSession(ctx context.Context,
+	session *databasesv1alpha1.DatabaseSession) (ctrl.Result, error) {
+
+	if session.Spec.TTL.Duration <= 0 {
+		r.setPhase(session, sessionPhaseFailed, "spec.ttl must be positive")
+		return ctrl.Result{}, nil
+	}
+	if maxTTL := r.getMaxTTL(); session.Spec.TTL.Duration > maxTTL {
+		r.setPhase(session, sessionPhaseFailed, fmt.Sprintf("spec.ttl %s exceeds the maximum session TTL of %s",
+			session.Spec.TTL.Duration, maxTTL))
+		return ctrl.Result{}, nil
+	}
+
+	// Expiry is checked first, teardown must not depend on the Database still being Ready
+	if session.Status.StartedAt != nil {
+		expiresAt := session.Status.StartedAt.Add(session.Spec.TTL.Duration)
+		if !time.Now().Before(expiresAt) {
+			return r.expireSession(ctx, session)
+		}
+
+		// A withdrawn DatabaseReferenceGrant ends the session before its TTL
+		denied, err := checkDatabaseReferenceByName(ctx, r.Client, session.Namespace, session.Spec.Database)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if denied != "" {
+			return r.endUnsharedSession(ctx, session, denied)
+		}
+	}
+
+	db, cluster, pending, err := getReadyDatabase(ctx, r.Client, session.Namespace, session.Spec.Database)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if pending != "" {
+		r.setPhase(session, sessionPhasePending, pending)
+		return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
+	}
+	dbName := getDatabaseName(db)
+	username := r.getUsername(session)
+
+	pgClient, err := getClusterPostgresClient(ctx, r.Client, r.PGClientCache, cluster)
+	if err != nil {
+		r.setPhase(session, sessionPhaseFailed, fmt.Sprintf("connection error: %s", err.Error()))
+		return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+	}
+
+	startedAt := metav1.Now()
+	if session.Status.StartedAt != nil {
+		startedAt = *session.Status.StartedAt
+	}
+	expiresAt := metav1.NewTime(startedAt.Add(session.Spec.TTL.Duration))
+
+	if err := r.ensureRole(ctx, pgClient, session, dbName, username, expiresAt); err != nil {
+		r.setPhase(session, sessionPhaseFailed, err.Error())
+		return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+	}
+
+	pod, err := r.ensureProxyPod(ctx, session, cluster)
+	if err != nil {
+		r.setPhase(session, sessionPhaseFailed, fmt.Sprintf("failed to create proxy pod: %s", err.Error()))
+		return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+	}
+	if err := r.ensureProxyService(ctx, session); err != nil {
+		r.setPhase(session, sessionPhaseFailed, fmt.Sprintf("failed to create proxy service: %s", err.Error()))
+		return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+	}
+
+	if session.Status.StartedAt == nil {
+		log.FromContext(ctx).Info("database session started", "username", username, "database", dbName,
+			"privileges", r.getPrivileges(session), "reason", session.Spec.Reason, "expiresAt", expiresAt)
+		session.Status.StartedAt = &startedAt
+	}
+	session.Status.ClusterName = cluster.Name
+	session.Status.DatabaseName = dbName
+	session.Status.Username = username
+	session.Status.SecretName = r.getSecretName(session)
+	session.Status.ServiceName = r.getProxyName(session)
+	session.Status.ExpiresAt = &expiresAt
+
+	if !isPodReady(pod) {
+		r.setPhase(session, sessionPhasePending, "waiting for proxy pod to be ready")
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	r.setPhase(session, sessionPhaseReady, fmt.Sprintf("kubectl port-forward -n %s svc/%s %d until %s",
+		session.Namespace, r.getProxyName(session), r.getProxyPort(session), expiresAt.UTC().Format(time.RFC3339)))
+	return ctrl.Result{RequeueAfter: time.Until(expiresAt.Time)}, nil
+}
+
+func (r *DatabaseSessionReconciler) getMaxTTL() time.Duration {
+	if r.MaxTTL > 0 {
+		return r.MaxTTL
+	}
+	return DefaultSessionMaxTTL
+}
+
+func (r *DatabaseSessionReconciler) getPrivileges(session *databasesv1alpha1.DatabaseSession) string {
+	if session.Spec.Privileges != "" {
+		return session.Spec.Privileges
+	}
+	return "readonly"
+}
+
+func (r *DatabaseSessionReconciler) getUsername(session *databasesv1alpha1.DatabaseSession) string {
+	name := SessionRolePrefix + strings.ReplaceAll(session.Name, "-", "_")
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return name
+}
+
+func (r *DatabaseSessionReconciler) getSecretName(session *databasesv1alpha1.DatabaseSession) string {
+	return session.Name + "-credentials"
+}
+
+// getProxyName is the name of both the proxy Pod and Service
+func (r *DatabaseSessionReconciler) getProxyName(session *databasesv1alpha1.DatabaseSession) string {
+	return session.Name + "-proxy"
+}
+
+func (r *DatabaseSessionReconciler) getProxyType(session *databasesv1alpha1.DatabaseSession) string {
+	if session.Spec.Proxy.Type != "" {
+		return session.Spec.Proxy.Type
+	}
+	return sessionProxySocat
+}
+
+func (r *DatabaseSessionReconciler) getProxyPort(session *databasesv1alpha1.DatabaseSession) int {
+	if session.Spec.Proxy.Port > 0 {
+		return session.Spec.Proxy.Port
+	}
+	return 5432
+}
+
+func (r *DatabaseSessionReconciler) getProxyImage(session *databasesv1alpha1.DatabaseSession) string {
+	if session.Spec.Proxy.Image != "" {
+		return session.Spec.Proxy.Image
+	}
+	if r.getProxyType(session) == sessionProxyPgBouncer {
+		if r.PgBouncerImage != "" {
+			return r.PgBouncerImage
+		}
+		return DefaultSessionPgBouncerImage
+	}
+	if r.SocatImage != "" {
+		return r.SocatImage
+	}
+	return DefaultSessionSocatImage
+}
+
+// setPhase updates phase and message, turning Pending into Failed after PendingTimeout
+func (r *DatabaseSessionReconciler) setPhase(session *databasesv1alpha1.DatabaseSession, phase, message string) {
+	session.Status.Phase, session.Status.Message = applyPendingTimeout(&session.Status.PendingSince, phase, message,
+		sessionPhasePending, sessionPhaseFailed)
+}
+
+// ensureRole creates the temporary role with a Secret pointing at the proxy Service and grants the privileges
+func (r *DatabaseSessionReconciler) ensureRole(ctx context.Context, pgClient postgres.ClientInterface,
+	session *databasesv1alpha1.DatabaseSession, dbName, username string, expiresAt metav1.Time) error {
+
+	_, err := ensureTemporaryRole(ctx, r.Client, r.Scheme, pgClient, temporaryRole{
+		Owner:      session,
+		Username:   username,
+		SecretName: r.getSecretName(session),
+		ExpiresAt:  expiresAt,
+		SecretData: map[string][]byte{
+			"host":     []byte(fmt.Sprintf("%s.%s.svc", r.getProxyName(session), session.Namespace)),
+			"port":     []byte(strconv.Itoa(r.getProxyPort(session))),
+			"database": []byte(dbName),
+			"user":     []byte(username),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := pgClient.GrantDatabaseAccess(ctx, username, dbName); err != nil {
+		return fmt.Errorf("failed to grant database access: %w", err)
+	}
+	if err := pgClient.ApplyPrivileges(ctx, username, dbName, r.getPrivileges(session), nil); err != nil {
+		return fmt.Errorf("failed to apply privileges: %w", err)
+	}
+	return nil
+}
+
+// ensureProxyPod creates the proxy Pod if it does not exist. A Pod that terminated is recreated.
+func (r *DatabaseSessionReconciler) ensureProxyPod(ctx context.Context, session *databasesv1alpha1.DatabaseSession,
+	cluster *databasesv1alpha1.DBCluster) (*corev1.Pod, error) {
+
+	var pod corev1.Pod
+	err := r.Get(ctx, types.NamespacedName{Name: r.getProxyName(session), Namespace: session.Namespace}, &pod)
+	if err == nil {
+		if !metav1.IsControlledBy(&pod, session) {
+			return nil, fmt.Errorf("pod '%s' already exists and is not owned by %s", pod.Name, session.Name)
+		}
+		if pod.Status.Phase != corev1.PodFailed && pod.Status.Phase != corev1.PodSucceeded {
+			return &pod, nil
+		}
+		if err := r.Delete(ctx, &pod); err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	desired := r.buildProxyPod(session, cluster)
+	if err := controllerutil.SetControllerReference(session, desired, r.Scheme); err != nil {
+		return nil, err
+	}
+	if err := r.Create(ctx, desired); err != nil {
+		return nil, err
+	}
+	log.FromContext(ctx).Info("created session proxy pod", "pod", desired.Name, "type", r.getProxyType(session))
+	return desired, nil
+}
+
+func (r *DatabaseSessionReconciler) buildProxyPod(session *databasesv1alpha1.DatabaseSession,
+	cluster *databasesv1alpha1.DBCluster) *corev1.Pod {
+
+	port := r.getProxyPort(session)
+	allowPrivilegeEscalation := false
+	automountToken := false
+	runAsNonRoot := true
+	runAsUser := int64(65534) // nobody
+	container := corev1.Container{
+		Name:           "proxy",
+		Image:          r.getProxyImage(session),
+		Ports:          []corev1.ContainerPort{{Name: "postgres", ContainerPort: int32(port), Protocol: corev1.ProtocolTCP}},
+		ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(port)}}},
+		SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		},
+	}
+	if session.Spec.Proxy.Resources != nil {
+		container.Resources = *session.Spec.Proxy.Resources
+	}
+
+	if r.getProxyType(session) == sessionProxyPgBouncer {
+		// pgbouncer authenticates clients with the same credentials it uses for the server
+		secretRef := func(key string) *corev1.EnvVarSource {
+			return &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: r.getSecretName(session)},
+				Key:                  key,
+			}}
+		}
+		container.Env = []corev1.EnvVar{
+			{Name: "DB_HOST", Value: cluster.Spec.Endpoint},
+			{Name: "DB_PORT", Value: strconv.Itoa(cluster.Spec.Port)},
+			{Name: "DB_NAME", ValueFrom: secretRef("database")},
+			{Name: "DB_USER", ValueFrom: secretRef("user")},
+			{Name: "DB_PASSWORD", ValueFrom: secretRef("password")},
+			{Name: "LISTEN_PORT", Value: strconv.Itoa(port)},
+			{Name: "AUTH_TYPE", Value: "scram-sha-256"},
+			{Name: "POOL_MODE", Value: "session"},
+			{Name: "SERVER_TLS_SSLMODE", Value: "prefer"},
+		}
+		runAsUser = 70 // postgres user of the pgbouncer image
+	} else {
+		container.Args = []string{
+			fmt.Sprintf("TCP-LISTEN:%d,fork,reuseaddr", port),
+			fmt.Sprintf("TCP:%s:%d", cluster.Spec.Endpoint, cluster.Spec.Port),
+		}
+	}
+	container.SecurityContext.RunAsUser = &runAsUser
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.getProxyName(session),
+			Namespace: session.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "dbtether",
+				"app.kubernetes.io/component":  "session-proxy",
+				SessionLabel:                   session.Name,
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers:                   []corev1.Container{container},
+			RestartPolicy:                corev1.RestartPolicyAlways,
+			AutomountServiceAccountToken: &automountToken,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &runAsNonRoot,
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+		},
+	}
+}
+
+func (r *DatabaseSessionReconciler) ensureProxyService(ctx context.Context, session *databasesv1alpha1.DatabaseSession) error {
+	var svc corev1.Service
+	err := r.Get(ctx, types.NamespacedName{Name: r.getProxyName(session), Namespace: session.Namespace}, &svc)
+	if err == nil {
+		if !metav1.IsControlledBy(&svc, session) {
+			return fmt.Errorf("service '%s' already exists and is not owned by %s", svc.Name, session.Name)
+		}
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	port := r.getProxyPort(session)
+	svc = corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.getProxyName(session),
+			Namespace: session.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "dbtether"},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{SessionLabel: session.Name},
+			Ports: []corev1.ServicePort{{
+				Name:       "postgres",
+				Port:       int32(port),
+				TargetPort: intstr.FromInt(port),
+				Protocol:   corev1.ProtocolTCP,
+			}},
+		},
+	}
+	if err := controllerutil.SetControllerReference(session, &svc, r.Scheme); err != nil {
+		return err
+	}
+	return r.Create(ctx, &svc)
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// expireSession tears the session down and marks it Expired
+func (r *DatabaseSessionReconciler) expireSession(ctx context.Context,
+	session *databasesv1alpha1.DatabaseSession) (ctrl.Result, error) {
+
+	if err := r.teardown(ctx, session); err != nil {
+		r.setPhase(session, sessionPhaseFailed, fmt.Sprintf("failed to tear down expired session: %s", err.Error()))
+		return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+	}
+
+	now := metav1.Now()
+	session.Status.EndedAt = &now
+	session.Status.SecretName = ""
+	session.Status.ServiceName = ""
+	r.setPhase(session, sessionPhaseExpired, "session expired, proxy and role removed")
+	log.FromContext(ctx).Info("database session expired", "username", session.Status.Username,
+		"database", session.Status.DatabaseName)
+	return ctrl.Result{}, nil
+}
+
+// endUnsharedSession tears down a session whose Database is no longer shared with its namespace
+func (r *DatabaseSessionReconciler) endUnsharedSession(ctx context.Context,
+	session *databasesv1alpha1.DatabaseSession, denied string) (ctrl.Result, error) {
+
+	if err := r.teardown(ctx, session); err != nil {
+		r.setPhase(session, sessionPhaseFailed, fmt.Sprintf("%s; failed to tear down session: %s", denied, err.Error()))
+		return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+	}
+
+	now := metav1.Now()
+	session.Status.EndedAt = &now
+	session.Status.SecretName = ""
+	session.Status.ServiceName = ""
+	r.setPhase(session, sessionPhaseFailed, denied+"; proxy and role removed")
+	log.FromContext(ctx).Info("database session ended, database no longer shared", "username", session.Status.Username,
+		"database", session.Status.DatabaseName)
+	return ctrl.Result{}, nil
+}
+
+// teardown deletes the proxy Pod and Service, then drops the role and its Secret
+func (r *DatabaseSessionReconciler) teardown(ctx context.Context, session *databasesv1alpha1.DatabaseSession) error {
+	if err := r.deleteOwned(ctx, session, &corev1.Pod{}); err != nil {
+		return fmt.Errorf("failed to delete proxy pod: %w", err)
+	}
+	if err := r.deleteOwned(ctx, session, &corev1.Service{}); err != nil {
+		return fmt.Errorf("failed to delete proxy service: %w", err)
+	}
+
+	username, dbName := session.Status.Username, session.Status.DatabaseName
+	if username == "" || dbName == "" {
+		deleteOwnedSecret(ctx, r.Client, session, r.getSecretName(session))
+		return nil
+	}
+
+	var cluster databasesv1alpha1.DBCluster
+	if err := r.Get(ctx, types.NamespacedName{Name: session.Status.ClusterName}, &cluster); err != nil {
+		if errors.IsNotFound(err) {
+			log.FromContext(ctx).Info("cluster not found, skipping role cleanup", "cluster", session.Status.ClusterName)
+			deleteOwnedSecret(ctx, r.Client, session, r.getSecretName(session))
+			return nil
+		}
+		return err
+	}
+
+	pgClient, err := getClusterPostgresClient(ctx, r.Client, r.PGClientCache, &cluster)
+	if err != nil {
+		return fmt.Errorf("failed to get postgres client: %w", err)
+	}
+	return dropTemporaryRole(ctx, r.Client, pgClient, session, username, dbName, r.getSecretName(session))
+}
+
+// deleteOwned deletes the proxy object of the given type if it is controlled by the session
+func (r *DatabaseSessionReconciler) deleteOwned(ctx context.Context, session *databasesv1alpha1.DatabaseSession,
+	obj client.Object) error {
+
+	if err := r.Get(ctx, types.NamespacedName{Name: r.getProxyName(session), Namespace: session.Namespace}, obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, session) {
+		return nil
+	}
+	return client.IgnoreNotFound(r.Delete(ctx, obj))
+}
+
+func (r *DatabaseSessionReconciler) handleDeletion(ctx context.Context,
+	session *databasesv1alpha1.DatabaseSession) (ctrl.Result, error) {
+
+	if !controllerutil.ContainsFinalizer(session, SessionFinalizerName) {
+		return ctrl.Result{}, nil
+	}
+
+	if session.Status.EndedAt == nil {
+		if err := r.teardown(ctx, session); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to tear down session: %w", err)
+		}
+		log.FromContext(ctx).Info("database session ended on deletion", "username", session.Status.Username)
+	}
+
+	controllerutil.RemoveFinalizer(session, SessionFinalizerName)
+	return ctrl.Result{}, r.Update(ctx, session)
+}
+
+func (r *DatabaseSessionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&databasesv1alpha1.DatabaseSession{}).
+		Owns(&corev1.Pod{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.Secret{}).
+		Watches(&databasesv1alpha1.DatabaseReferenceGrant{}, handler.EnqueueRequestsFromMapFunc(r.findSessionsForReferenceGrant)).
+		Complete(r)
+}
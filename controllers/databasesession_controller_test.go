@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+func newTestSession(name string, ttl time.Duration) *databasesv1alpha1.DatabaseSession {
+	return &databasesv1alpha1.DatabaseSession{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testGrantNamespace},
+		Spec: databasesv1alpha1.DatabaseSessionSpec{
+			Database:   databasesv1alpha1.DatabaseReference{Name: "orders-db"},
+			Privileges: "readonly",
+			TTL:        metav1.Duration{Duration: ttl},
+			Reason:     "debugging slow checkout query",
+		},
+	}
+}
+
+func newSessionTestReconciler(pgClient *postgres.MockClient, objects ...client.Object) *DatabaseSessionReconciler {
+	c, scheme, cache := newTemporaryRoleTestClient(pgClient, &databasesv1alpha1.DatabaseSession{}, objects...)
+	return &DatabaseSessionReconciler{Client: c, Scheme: scheme, PGClientCache: cache}
+}
+
+// reconcileTestSession runs Reconcile (finalizer + session) and returns the updated session
+func reconcileTestSession(t *testing.T, r *DatabaseSessionReconciler, name string) (*databasesv1alpha1.DatabaseSession, ctrl.Result) {
+	t.Helper()
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: testGrantNamespace}}
+
+	var result ctrl.Result
+	for i := 0; i < 2; i++ {
+		var err error
+		if result, err = r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+
+	var session databasesv1alpha1.DatabaseSession
+	if err := r.Get(ctx, req.NamespacedName, &session); err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	return &session, result
+}
+
+// markProxyReady sets the proxy pod status as the kubelet would once the readiness probe passes
+func markProxyReady(t *testing.T, r *DatabaseSessionReconciler, name string) {
+	t.Helper()
+	ctx := context.Background()
+	var pod corev1.Pod
+	if err := r.Get(ctx, types.NamespacedName{Name: name + "-proxy", Namespace: testGrantNamespace}, &pod); err != nil {
+		t.Fatalf("expected proxy pod: %v", err)
+	}
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	if err := r.Status().Update(ctx, &pod); err != nil {
+		t.Fatalf("failed to update pod status: %v", err)
+	}
+}
+
+func TestDatabaseSessionReconciler_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	pgClient := postgres.NewMockClient()
+
+	session := newTestSession("jane-debug", 2*time.Hour)
+	r := newSessionTestReconciler(pgClient, append(newGrantTestObjects(), session)...)
+
+	session, _ = reconcileTestSession(t, r, "jane-debug")
+	if session.Status.Phase != "Pending" || session.Status.StartedAt == nil {
+		t.Fatalf("status = %s (%s), want Pending with startedAt until the proxy is ready",
+			session.Status.Phase, session.Status.Message)
+	}
+	if !pgClientHasUser(pgClient, "session_jane_debug") {
+		t.Fatal("expected temporary role")
+	}
+	if !hasDatabaseAccess(t, pgClient, "session_jane_debug", "orders_db") {
+		t.Error("expected access to orders_db")
+	}
+	if attrs := pgClient.GetRoleAttributes("session_jane_debug"); attrs.ValidUntil != session.Status.ExpiresAt.UTC().Format(time.RFC3339) {
+		t.Errorf("VALID UNTIL = %q, want expiresAt", attrs.ValidUntil)
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: "jane-debug-credentials", Namespace: testGrantNamespace}, &secret); err != nil {
+		t.Fatalf("expected credentials secret: %v", err)
+	}
+	if string(secret.Data["host"]) != "jane-debug-proxy.team-alpha.svc" || string(secret.Data["port"]) != "5432" ||
+		string(secret.Data["user"]) != "session_jane_debug" || len(secret.Data["password"]) == 0 {
+		t.Errorf("unexpected secret data: %v", secret.Data)
+	}
+
+	var svc corev1.Service
+	if err := r.Get(ctx, types.NamespacedName{Name: "jane-debug-proxy", Namespace: testGrantNamespace}, &svc); err != nil {
+		t.Fatalf("expected proxy service: %v", err)
+	}
+	if svc.Spec.Selector[SessionLabel] != "jane-debug" || !metav1.IsControlledBy(&svc, session) {
+		t.Errorf("unexpected service: %+v", svc.ObjectMeta)
+	}
+
+	markProxyReady(t, r, "jane-debug")
+	session, result := reconcileTestSession(t, r, "jane-debug")
+	if session.Status.Phase != "Ready" || !strings.Contains(session.Status.Message, "svc/jane-debug-proxy") {
+		t.Fatalf("status = %s (%s), want Ready with port-forward hint", session.Status.Phase, session.Status.Message)
+	}
+	if result.RequeueAfter <= 0 || result.RequeueAfter > 2*time.Hour {
+		t.Errorf("RequeueAfter = %s, want time until expiry", result.RequeueAfter)
+	}
+
+	past := metav1.NewTime(time.Now().Add(-3 * time.Hour))
+	session.Status.StartedAt = &past
+	if err := r.Status().Update(ctx, session); err != nil {
+		t.Fatalf("failed to backdate session: %v", err)
+	}
+	session, _ = reconcileTestSession(t, r, "jane-debug")
+	if session.Status.Phase != "Expired" || session.Status.EndedAt == nil {
+		t.Fatalf("status = %s, want Expired with endedAt", session.Status.Phase)
+	}
+	if pgClientHasUser(pgClient, "session_jane_debug") {
+		t.Error("temporary role should be dropped on expiry")
+	}
+	for _, obj := range []client.Object{&corev1.Pod{}, &corev1.Service{}} {
+		if err := r.Get(ctx, types.NamespacedName{Name: "jane-debug-proxy", Namespace: testGrantNamespace}, obj); err == nil {
+			t.Errorf("%T should be deleted on expiry", obj)
+		}
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "jane-debug-credentials", Namespace: testGrantNamespace}, &secret); err == nil {
+		t.Error("credentials secret should be deleted on expiry")
+	}
+}
+
+func TestDatabaseSessionReconciler_BuildProxyPod(t *testing.T) {
+	r := &DatabaseSessionReconciler{SocatImage: "socat:test"}
+	cluster := newGrantTestObjects()[0].(*databasesv1alpha1.DBCluster)
+
+	session := newTestSession("socat", time.Hour)
+	pod := r.buildProxyPod(session, cluster)
+	container := pod.Spec.Containers[0]
+	if container.Image != "socat:test" {
+		t.Errorf("image = %s, want operator default", container.Image)
+	}
+	if strings.Join(container.Args, " ") != "TCP-LISTEN:5432,fork,reuseaddr TCP:db.example.com:5432" {
+		t.Errorf("args = %v", container.Args)
+	}
+
+	session = newTestSession("bouncer", time.Hour)
+	session.Spec.Proxy = databasesv1alpha1.SessionProxy{Type: "pgbouncer", Port: 6432}
+	pod = r.buildProxyPod(session, cluster)
+	container = pod.Spec.Containers[0]
+	if container.Image != DefaultSessionPgBouncerImage {
+		t.Errorf("image = %s, want %s", container.Image, DefaultSessionPgBouncerImage)
+	}
+	env := map[string]corev1.EnvVar{}
+	for _, e := range container.Env {
+		env[e.Name] = e
+	}
+	if env["DB_HOST"].Value != "db.example.com" || env["LISTEN_PORT"].Value != "6432" {
+		t.Errorf("unexpected env: %v", container.Env)
+	}
+	if ref := env["DB_PASSWORD"].ValueFrom; ref == nil || ref.SecretKeyRef.Name != "bouncer-credentials" {
+		t.Error("DB_PASSWORD should come from the session secret")
+	}
+}
+
+func TestDatabaseSessionReconciler_TTLExceedsMax(t *testing.T) {
+	pgClient := postgres.NewMockClient()
+	session := newTestSession("too-long", 24*time.Hour)
+	r := newSessionTestReconciler(pgClient, append(newGrantTestObjects(), session)...)
+
+	session, _ = reconcileTestSession(t, r, "too-long")
+	if session.Status.Phase != "Failed" || !strings.Contains(session.Status.Message, "maximum session TTL") {
+		t.Errorf("status = %s (%s), want Failed", session.Status.Phase, session.Status.Message)
+	}
+	if pgClientHasUser(pgClient, "session_too_long") {
+		t.Error("no role should be created for a rejected session")
+	}
+}
+
+func TestDatabaseSessionReconciler_DeleteActiveSession(t *testing.T) {
+	ctx := context.Background()
+	pgClient := postgres.NewMockClient()
+
+	session := newTestSession("short", time.Hour)
+	r := newSessionTestReconciler(pgClient, append(newGrantTestObjects(), session)...)
+
+	session, _ = reconcileTestSession(t, r, "short")
+	if !pgClientHasUser(pgClient, "session_short") {
+		t.Fatal("expected temporary role")
+	}
+
+	if err := r.Delete(ctx, session); err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "short", Namespace: testGrantNamespace}}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if pgClientHasUser(pgClient, "session_short") {
+		t.Error("temporary role should be dropped when the session is deleted")
+	}
+	var pod corev1.Pod
+	if err := r.Get(ctx, types.NamespacedName{Name: "short-proxy", Namespace: testGrantNamespace}, &pod); err == nil {
+		t.Error("proxy pod should be deleted with the session")
+	}
+}
@@ -3,21 +3,30 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/notify"
 	"github.com/certainty3452/dbtether/pkg/postgres"
+	"github.com/certainty3452/dbtether/pkg/secretstore"
 )
 
 const UserFinalizerName = "databaseusers.dbtether.io/finalizer"
@@ -26,6 +35,20 @@ type DatabaseUserReconciler struct {
 	client.Client
 	Scheme        *runtime.Scheme
 	PGClientCache postgres.ClientCacheInterface
+
+	// SecretStores is the admin-owned Vault/AWS configuration; users only choose a path
+	SecretStores secretstore.Settings
+
+	// SecretStoreFactory creates external secret store clients (defaults to secretstore.NewStore)
+	SecretStoreFactory secretstore.Factory
+
+	// Notifier receives PasswordRotated events, nil disables notifications
+	Notifier notify.Notifier
+
+	// stores caches secret store clients across reconciles, so a Vault login is reused until its
+	// lease runs out instead of being repeated on every sync
+	storesMu sync.Mutex
+	stores   map[string]secretstore.Store
 }
 
 // +kubebuilder:rbac:groups=dbtether.io,resources=databaseusers,verbs=get;list;watch;create;update;patch;delete
@@ -33,6 +56,7 @@ type DatabaseUserReconciler struct {
 // +kubebuilder:rbac:groups=dbtether.io,resources=databaseusers/finalizers,verbs=update
 // +kubebuilder:rbac:groups=dbtether.io,resources=databases,verbs=get;list;watch
 // +kubebuilder:rbac:groups=dbtether.io,resources=dbclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbtether.io,resources=databaseaccessgrants,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 
 func (r *DatabaseUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -45,8 +69,12 @@ func (r *DatabaseUserReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	username := r.getUsername(&user)
 
-	// Check if secret still exists before early exit
-	if user.Status.Phase == "Ready" && user.Status.ObservedGeneration == user.Generation {
+	// Check if secret still exists before early exit (keep polling PushSecret status until synced,
+	// and re-read a referenced password Secret or DatabaseReferenceGrant, which is what triggers most
+	// reconciles of such users)
+	if user.Status.Phase == "Ready" && user.Status.ObservedGeneration == user.Generation &&
+		user.DeletionTimestamp.IsZero() && !r.isSecretStorePending(&user) && user.Spec.Password.SecretRef == nil &&
+		!hasCrossNamespaceDatabases(&user) && user.Status.PendingRolloutAt == nil && r.roleAttributesPolicyApplied(ctx, &user) {
 		secretName := r.getSecretName(&user)
 		var secret corev1.Secret
 		if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: user.Namespace}, &secret); err == nil {
@@ -89,7 +117,17 @@ func (r *DatabaseUserReconciler) validateSpec(user *databasesv1alpha1.DatabaseUs
 	if !user.Spec.HasDatabases() {
 		return fmt.Errorf("must specify either 'database' or 'databases'")
 	}
-	return nil
+	if user.Spec.Secret != nil {
+		for key, tmpl := range user.Spec.Secret.Data {
+			if _, err := template.New(key).Funcs(secretTemplateFuncs).Parse(tmpl); err != nil {
+				return fmt.Errorf("invalid secret.data template for key '%s': %w", key, err)
+			}
+		}
+	}
+	if err := r.validateRoleAttributes(user); err != nil {
+		return err
+	}
+	return r.validateSecretStore(user)
 }
 
 // validateAndFetchDatabases fetches all databases and validates they are on the same cluster
@@ -120,6 +158,18 @@ func (r *DatabaseUserReconciler) validateAndFetchDatabases(ctx context.Context,
 			return nil, nil, &ctrl.Result{}, err
 		}
 
+		denied, err := checkDatabaseReference(ctx, r.Client, user.Namespace, &db)
+		if err != nil {
+			return nil, nil, &ctrl.Result{}, err
+		}
+		if denied != "" {
+			r.revokeUnsharedDatabase(ctx, user, &db)
+			result, err := r.setStatus(ctx, user, &statusUpdate{
+				Phase: "Failed", Message: denied, RequeueAfter: 60 * time.Second,
+			})
+			return nil, nil, &result, err
+		}
+
 		if db.Status.Phase != "Ready" {
 			result, err := r.setStatus(ctx, user, &statusUpdate{
 				Phase: "Pending", Message: fmt.Sprintf("waiting for Database '%s' to be ready", db.Name), RequeueAfter: 20 * time.Second,
@@ -163,6 +213,43 @@ func (r *DatabaseUserReconciler) validateAndFetchDatabases(ctx context.Context,
 	return databases, &cluster, nil, nil
 }
 
+// revokeUnsharedDatabase removes access granted earlier when the DatabaseReferenceGrant was withdrawn
+func (r *DatabaseUserReconciler) revokeUnsharedDatabase(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
+	db *databasesv1alpha1.Database) {
+
+	granted := false
+	for _, status := range user.Status.Databases {
+		if status.Name == db.Name && status.Namespace == db.Namespace && status.Phase == "Ready" {
+			granted = true
+		}
+	}
+	if !granted {
+		return
+	}
+
+	logger := log.FromContext(ctx)
+	var cluster databasesv1alpha1.DBCluster
+	if err := r.Get(ctx, types.NamespacedName{Name: db.Spec.ClusterRef.Name}, &cluster); err != nil {
+		logger.Error(err, "failed to get cluster to revoke unshared database")
+		return
+	}
+	pgClient, err := r.getPostgresClient(ctx, &cluster)
+	if err != nil {
+		logger.Error(err, "failed to get postgres client to revoke unshared database")
+		return
+	}
+
+	username, dbName := r.getUsername(user), r.getDatabaseNameFromSpec(db)
+	if err := pgClient.RevokePrivilegesInDatabase(ctx, username, dbName); err != nil {
+		logger.Error(err, "failed to revoke privileges", "database", dbName)
+	}
+	if err := pgClient.RevokeDatabaseAccess(ctx, username, dbName); err != nil {
+		logger.Error(err, "failed to revoke database access", "database", dbName)
+		return
+	}
+	logger.Info("revoked access to database no longer shared with this namespace", "username", username, "database", dbName)
+}
+
 func (r *DatabaseUserReconciler) getUsername(user *databasesv1alpha1.DatabaseUser) string {
 	if user.Spec.Username != "" {
 		return user.Spec.Username
@@ -231,6 +318,123 @@ func (r *DatabaseUserReconciler) applyCustomKeys(k *databasesv1alpha1.SecretKeys
 	return
 }
 
+// secretTemplateData is passed to spec.secret.data templates
+type secretTemplateData struct {
+	Host      string
+	Port      string
+	Database  string
+	User      string
+	Password  string
+	SSLMode   string
+	Databases []string
+}
+
+var secretTemplateFuncs = template.FuncMap{
+	"urlencode": urlEscape,
+	"userinfo":  userinfo,
+	"pgpass":    pgpassEscape,
+	"join":      strings.Join,
+}
+
+// urlEscape percent-encodes everything but unreserved characters (RFC 3986), so the result is safe in
+// the userinfo, path and query of a URL. url.QueryEscape would turn a space into "+", which is only
+// decoded in query strings.
+func urlEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// userinfo renders the user:password part of a connection URL
+func userinfo(user, password string) string {
+	return url.UserPassword(user, password).String()
+}
+
+// pgpassEscape escapes a .pgpass field: backslash and colon are prefixed with a backslash
+func pgpassEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `:`, `\:`).Replace(s)
+}
+
+func (r *DatabaseUserReconciler) getSSLMode(user *databasesv1alpha1.DatabaseUser) string {
+	if user.Spec.Secret != nil && user.Spec.Secret.SSLMode != "" {
+		return user.Spec.Secret.SSLMode
+	}
+	return "require"
+}
+
+// renderSecretData renders spec.secret.data templates, returns nil if none are configured
+func (r *DatabaseUserReconciler) renderSecretData(user *databasesv1alpha1.DatabaseUser, cluster *databasesv1alpha1.DBCluster,
+	database string, databases []string, username, password string) (map[string]string, error) {
+
+	if user.Spec.Secret == nil || len(user.Spec.Secret.Data) == 0 {
+		return nil, nil
+	}
+
+	data := secretTemplateData{
+		Host:      cluster.Spec.Endpoint,
+		Port:      fmt.Sprintf("%d", cluster.Spec.Port),
+		Database:  database,
+		User:      username,
+		Password:  password,
+		SSLMode:   r.getSSLMode(user),
+		Databases: databases,
+	}
+
+	rendered := make(map[string]string, len(user.Spec.Secret.Data))
+	for key, tmplText := range user.Spec.Secret.Data {
+		tmpl, err := template.New(key).Funcs(secretTemplateFuncs).Parse(tmplText)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse secret.data template for key '%s': %w", key, err)
+		}
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render secret.data template for key '%s': %w", key, err)
+		}
+		rendered[key] = buf.String()
+	}
+	return rendered, nil
+}
+
+func (r *DatabaseUserReconciler) getDatabaseNames(databases []*databasesv1alpha1.Database) []string {
+	names := make([]string, len(databases))
+	for i, db := range databases {
+		names[i] = r.getDatabaseNameFromSpec(db)
+	}
+	return names
+}
+
+// applyRenderedSecretData renders spec.secret.data into secret.Data, returns true if any value changed
+func (r *DatabaseUserReconciler) applyRenderedSecretData(user *databasesv1alpha1.DatabaseUser, secret *corev1.Secret,
+	cluster *databasesv1alpha1.DBCluster, databases []*databasesv1alpha1.Database, username, password string) (bool, error) {
+
+	primaryDB := ""
+	if len(databases) > 0 {
+		primaryDB = r.getDatabaseNameFromSpec(databases[0])
+	}
+
+	rendered, err := r.renderSecretData(user, cluster, primaryDB, r.getDatabaseNames(databases), username, password)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for k, v := range rendered {
+		if string(secret.Data[k]) != v {
+			secret.Data[k] = []byte(v)
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
 // shouldIncludeDatabasesList returns true if the secret should include a "databases" field
 // Only for: secretGeneration=primary + template=raw (or empty) + more than 1 database
 func (r *DatabaseUserReconciler) shouldIncludeDatabasesList(user *databasesv1alpha1.DatabaseUser, dbCount int) bool {
@@ -304,6 +508,15 @@ func (r *DatabaseUserReconciler) reconcileUser(ctx context.Context, user *databa
 		return r.setStatus(ctx, user, &baseStatus)
 	}
 
+	// Rotation and password.secretRef change the password while ensuring secrets,
+	// so a role owned by another DatabaseUser must be rejected before that
+	if _, err := r.checkRoleOwner(ctx, pgClient, user, username); err != nil {
+		baseStatus.Phase = "Failed"
+		baseStatus.Message = err.Error()
+		baseStatus.RequeueAfter = 60 * time.Second
+		return r.setStatus(ctx, user, &baseStatus)
+	}
+
 	// Ensure secrets and get password
 	password, secretName, passwordChanged, err := r.ensureSecrets(ctx, user, databases, cluster, pgClient)
 	if err != nil {
@@ -312,19 +525,44 @@ func (r *DatabaseUserReconciler) reconcileUser(ctx context.Context, user *databa
 		return r.setStatus(ctx, user, &baseStatus)
 	}
 
+	if err := r.markRolloutPending(ctx, user, passwordChanged); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if user.Status.SecretName != "" && user.Status.SecretName != secretName {
 		r.deleteOldSecret(ctx, user.Namespace, user.Status.SecretName, user)
 	}
 
-	if err := r.ensureUserInPostgres(ctx, pgClient, username, password); err != nil {
+	if err := r.ensureUserInPostgres(ctx, pgClient, user, username, password); err != nil {
+		baseStatus.Phase = "Failed"
+		baseStatus.Message = err.Error()
+		baseStatus.SecretName = secretName
+		return r.setStatus(ctx, user, &baseStatus)
+	}
+
+	roleAttrs, err := r.ensureRoleAttributes(ctx, pgClient, user, cluster, username, passwordChanged)
+	if err != nil {
 		baseStatus.Phase = "Failed"
 		baseStatus.Message = err.Error()
 		baseStatus.SecretName = secretName
 		return r.setStatus(ctx, user, &baseStatus)
 	}
 
-	// Sync database access (grant to allowed, revoke from others)
-	if err := pgClient.SyncDatabaseAccess(ctx, username, dbNames); err != nil {
+	// Copy credentials to the external secret store
+	storeStatus, err := r.syncSecretStore(ctx, user, secretName, passwordChanged)
+	if err != nil {
+		baseStatus.Phase = "Failed"
+		baseStatus.Message = fmt.Sprintf("secret store error: %s", err.Error())
+		baseStatus.SecretName = secretName
+		baseStatus.RequeueAfter = 60 * time.Second
+		return r.setStatus(ctx, user, &baseStatus)
+	}
+	baseStatus.SecretStore = storeStatus
+
+	// Sync database access (grant to allowed, revoke from others).
+	// Databases of active DatabaseAccessGrants are kept until the grant expires.
+	allowedDBNames := append(dbNames, r.getActiveGrantDatabases(ctx, user)...)
+	if err := pgClient.SyncDatabaseAccess(ctx, username, allowedDBNames); err != nil {
 		baseStatus.Phase = "Failed"
 		baseStatus.Message = fmt.Sprintf("failed to sync database access: %s", err.Error())
 		baseStatus.SecretName = secretName
@@ -386,21 +624,48 @@ func (r *DatabaseUserReconciler) reconcileUser(ctx context.Context, user *databa
 	}
 
 	// Verify isolation
-	r.verifyIsolation(ctx, pgClient, username, dbNames)
+	r.verifyIsolation(ctx, pgClient, username, allowedDBNames)
+
+	// Restart consuming workloads so they pick up the new password
+	rolledOut, err := r.rolloutWorkloads(ctx, user, secretName, passwordChanged)
+	if err != nil {
+		baseStatus.Phase = "Failed"
+		baseStatus.Message = fmt.Sprintf("rollout error: %s", err.Error())
+		baseStatus.SecretName = secretName
+		baseStatus.PasswordUpdated = passwordChanged
+		baseStatus.Databases = dbStatuses
+		baseStatus.RoleAttributes = roleAttrs
+		baseStatus.RolledOutWorkloads = rolledOut
+		baseStatus.RequeueAfter = 30 * time.Second
+		return r.setStatus(ctx, user, &baseStatus)
+	}
 
 	logger.Info("user ready", "username", username, "databases", len(databases))
 
 	baseStatus.Phase = "Ready"
 	baseStatus.Message = fmt.Sprintf("user created with access to %d database(s)", len(databases))
+	if user.Spec.Password.SecretRef != nil {
+		baseStatus.Message += "; " + r.passwordSourceMessage(user)
+	}
+	if cond := roleAttrs.Condition; cond != nil && cond.Status == metav1.ConditionFalse {
+		baseStatus.Message += "; " + cond.Message
+	}
 	baseStatus.SecretName = secretName
 	baseStatus.PasswordUpdated = passwordChanged
 	baseStatus.Databases = dbStatuses
+	baseStatus.RolledOutWorkloads = rolledOut
+	baseStatus.RolloutApplied = true
+	baseStatus.RoleAttributes = roleAttrs
 	baseStatus.RequeueAfter = r.calculateRequeueAfter(user)
+	if storeStatus != nil && storeStatus.Phase != secretStorePhaseSynced &&
+		(baseStatus.RequeueAfter == 0 || baseStatus.RequeueAfter > PushSecretStatusInterval) {
+		baseStatus.RequeueAfter = PushSecretStatusInterval
+	}
 	return r.setStatus(ctx, user, &baseStatus)
 }
 
 func (r *DatabaseUserReconciler) shouldRotatePassword(user *databasesv1alpha1.DatabaseUser) bool {
-	if user.Spec.Rotation == nil || user.Spec.Rotation.Days == 0 {
+	if !r.rotationEnabled(user) {
 		return false
 	}
 	if user.Status.PasswordUpdatedAt == nil {
@@ -412,7 +677,7 @@ func (r *DatabaseUserReconciler) shouldRotatePassword(user *databasesv1alpha1.Da
 }
 
 func (r *DatabaseUserReconciler) calculateRequeueAfter(user *databasesv1alpha1.DatabaseUser) time.Duration {
-	if user.Spec.Rotation == nil || user.Spec.Rotation.Days == 0 {
+	if !r.rotationEnabled(user) {
 		return 0
 	}
 	if user.Status.PasswordUpdatedAt == nil {
@@ -429,8 +694,10 @@ func (r *DatabaseUserReconciler) calculateRequeueAfter(user *databasesv1alpha1.D
 	return requeue
 }
 
+// ensureUserInPostgres creates the role or sets its password. Roles carry an ownership comment,
+// an existing role owned by another DatabaseUser is left untouched.
 func (r *DatabaseUserReconciler) ensureUserInPostgres(ctx context.Context, pgClient postgres.ClientInterface,
-	username, password string) error {
+	user *databasesv1alpha1.DatabaseUser, username, password string) error {
 
 	exists, err := pgClient.UserExists(ctx, username)
 	if err != nil {
@@ -438,6 +705,13 @@ func (r *DatabaseUserReconciler) ensureUserInPostgres(ctx context.Context, pgCli
 	}
 
 	if exists {
+		claim, err := r.checkRoleOwner(ctx, pgClient, user, username)
+		if err != nil {
+			return err
+		}
+		if claim {
+			r.claimRole(ctx, pgClient, user, username)
+		}
 		if err := pgClient.SetPassword(ctx, username, password); err != nil {
 			return fmt.Errorf("failed to set password: %s", err.Error())
 		}
@@ -447,9 +721,59 @@ func (r *DatabaseUserReconciler) ensureUserInPostgres(ctx context.Context, pgCli
 	if err := pgClient.CreateUser(ctx, username, password); err != nil {
 		return fmt.Errorf("failed to create user: %s", err.Error())
 	}
+	r.claimRole(ctx, pgClient, user, username)
 	return nil
 }
 
+// checkRoleOwner fails when the role is owned by another DatabaseUser. claim is true when the
+// ownership comment should be written: legacy role without one, or force-adopt.
+func (r *DatabaseUserReconciler) checkRoleOwner(ctx context.Context, pgClient postgres.ClientInterface,
+	user *databasesv1alpha1.DatabaseUser, username string) (claim bool, err error) {
+
+	ns, name, err := pgClient.GetUserOwner(ctx, username)
+	if err != nil {
+		return false, fmt.Errorf("failed to check role ownership: %s", err.Error())
+	}
+	if ns == user.Namespace && name == user.Name {
+		return false, nil
+	}
+	if ns == "" && name == "" || user.Annotations[forceAdoptAnnotation] == "true" {
+		return true, nil
+	}
+	return false, fmt.Errorf("role %s is owned by DatabaseUser %s/%s, cannot be managed by %s/%s (use annotation %s to override)",
+		username, ns, name, user.Namespace, user.Name, forceAdoptAnnotation)
+}
+
+// claimRole writes the ownership comment (best-effort: COMMENT ON ROLE needs ADMIN OPTION on the
+// role, which the admin user may lack for roles it did not create)
+func (r *DatabaseUserReconciler) claimRole(ctx context.Context, pgClient postgres.ClientInterface,
+	user *databasesv1alpha1.DatabaseUser, username string) {
+
+	if err := pgClient.SetUserOwner(ctx, username, user.Namespace, user.Name); err != nil {
+		log.FromContext(ctx).V(1).Info("failed to set role ownership", "username", username, "error", err.Error())
+	}
+}
+
+// getActiveGrantDatabases returns databases temporarily granted to the user by active DatabaseAccessGrants
+func (r *DatabaseUserReconciler) getActiveGrantDatabases(ctx context.Context, user *databasesv1alpha1.DatabaseUser) []string {
+	var grants databasesv1alpha1.DatabaseAccessGrantList
+	if err := r.List(ctx, &grants, client.InNamespace(user.Namespace)); err != nil {
+		log.FromContext(ctx).V(1).Info("failed to list access grants", "error", err.Error())
+		return nil
+	}
+
+	var names []string
+	for _, grant := range grants.Items {
+		if grant.Spec.UserRef == nil || grant.Spec.UserRef.Name != user.Name {
+			continue
+		}
+		if grant.Status.Phase == grantPhaseActive && grant.Status.DatabaseName != "" {
+			names = append(names, grant.Status.DatabaseName)
+		}
+	}
+	return names
+}
+
 func (r *DatabaseUserReconciler) verifyIsolation(ctx context.Context, pgClient postgres.ClientInterface,
 	username string, expectedDatabases []string) {
 
@@ -494,6 +818,11 @@ func (r *DatabaseUserReconciler) ensureSecrets(ctx context.Context, user *databa
 			if r.shouldRotatePassword(user) {
 				return r.rotatePassword(ctx, user, &primarySecret, cluster, databases, pgClient, username)
 			}
+			if user.Spec.Password.SecretRef != nil {
+				if password, changed, err := r.syncReferencedPassword(ctx, user, &primarySecret, cluster, databases, pgClient, username); err != nil || changed {
+					return password, primarySecretName, changed, err
+				}
+			}
 			_, _, _, _, pwdKey := r.getSecretKeys(user)
 			password = string(primarySecret.Data[pwdKey])
 			// Update secret with current databases list
@@ -519,16 +848,12 @@ func (r *DatabaseUserReconciler) ensureSecrets(ctx context.Context, user *databa
 		return "", "", false, err
 	}
 
-	// Secret is missing - generate new password
+	// Secret is missing - generate new password (or take it from password.secretRef)
 	isRegeneration := user.Status.Phase == "Ready"
 
-	length := user.Spec.Password.Length
-	if length == 0 {
-		length = postgres.DefaultPasswordLength
-	}
-	password, err = postgres.GeneratePassword(length)
+	password, err = r.newPassword(ctx, user)
 	if err != nil {
-		return "", "", false, fmt.Errorf("failed to generate password: %w", err)
+		return "", "", false, err
 	}
 
 	// If regenerating, update password in PostgreSQL first
@@ -591,6 +916,14 @@ func (r *DatabaseUserReconciler) createPrimarySecret(ctx context.Context, user *
 		secretData["databases"] = strings.Join(dbNames, ",")
 	}
 
+	rendered, err := r.renderSecretData(user, cluster, primaryDB, r.getDatabaseNames(databases), username, password)
+	if err != nil {
+		return err
+	}
+	for k, v := range rendered {
+		secretData[k] = v
+	}
+
 	secret := corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      secretName,
@@ -618,6 +951,23 @@ func (r *DatabaseUserReconciler) createDatabaseSecret(ctx context.Context, user
 	username, password string) error {
 
 	hostKey, portKey, dbKey, userKey, pwdKey := r.getSecretKeys(user)
+	dbName := r.getDatabaseNameFromSpec(db)
+
+	secretData := map[string]string{
+		hostKey: cluster.Spec.Endpoint,
+		portKey: fmt.Sprintf("%d", cluster.Spec.Port),
+		dbKey:   dbName,
+		userKey: username,
+		pwdKey:  password,
+	}
+
+	rendered, err := r.renderSecretData(user, cluster, dbName, []string{dbName}, username, password)
+	if err != nil {
+		return err
+	}
+	for k, v := range rendered {
+		secretData[k] = v
+	}
 
 	secret := corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -628,13 +978,7 @@ func (r *DatabaseUserReconciler) createDatabaseSecret(ctx context.Context, user
 				"dbtether.io/database":   db.Name,
 			},
 		},
-		StringData: map[string]string{
-			hostKey: cluster.Spec.Endpoint,
-			portKey: fmt.Sprintf("%d", cluster.Spec.Port),
-			dbKey:   r.getDatabaseNameFromSpec(db),
-			userKey: username,
-			pwdKey:  password,
-		},
+		StringData: secretData,
 	}
 
 	if err := controllerutil.SetControllerReference(user, &secret, r.Scheme); err != nil {
@@ -660,7 +1004,17 @@ func (r *DatabaseUserReconciler) updateSecretDatabases(ctx context.Context, user
 	secret *corev1.Secret, cluster *databasesv1alpha1.DBCluster, databases []*databasesv1alpha1.Database,
 	username string) error {
 
-	hostKey, portKey, dbKey, userKey, _ := r.getSecretKeys(user)
+	hostKey, portKey, dbKey, userKey, pwdKey := r.getSecretKeys(user)
+
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+
+	// Re-render templated keys (database list or template may have changed)
+	templatesChanged, err := r.applyRenderedSecretData(user, secret, cluster, databases, username, string(secret.Data[pwdKey]))
+	if err != nil {
+		return err
+	}
 
 	// Primary database is the first one
 	primaryDB := ""
@@ -681,7 +1035,7 @@ func (r *DatabaseUserReconciler) updateSecretDatabases(ctx context.Context, user
 	// Check if update is needed
 	currentDBs := string(secret.Data["databases"])
 	currentPrimaryDB := string(secret.Data[dbKey])
-	if currentDBs == expectedDatabasesList && currentPrimaryDB == primaryDB {
+	if currentDBs == expectedDatabasesList && currentPrimaryDB == primaryDB && !templatesChanged {
 		return nil
 	}
 
@@ -705,22 +1059,46 @@ func (r *DatabaseUserReconciler) rotatePassword(ctx context.Context, user *datab
 	pgClient postgres.ClientInterface, username string) (password, secretName string, passwordChanged bool, err error) {
 
 	logger := log.FromContext(ctx)
-	secretName = secret.Name
-
 	logger.Info("rotating password", "username", username, "days", user.Spec.Rotation.Days)
 
-	length := user.Spec.Password.Length
-	if length == 0 {
-		length = postgres.DefaultPasswordLength
+	password, err = r.newPassword(ctx, user)
+	if err != nil {
+		return "", "", false, err
 	}
-	password, err = postgres.GeneratePassword(length)
+
+	secretName, err = r.updatePassword(ctx, user, secret, cluster, databases, pgClient, username, password)
 	if err != nil {
-		return "", "", false, fmt.Errorf("failed to generate password: %w", err)
+		return "", "", false, err
 	}
 
+	logger.Info("password rotated successfully", "username", username)
+	if r.Notifier != nil {
+		// The rotation replaces the password set at PasswordUpdatedAt, which identifies it
+		var dedupKey string
+		if user.Status.PasswordUpdatedAt != nil {
+			dedupKey = user.Status.PasswordUpdatedAt.UTC().Format(time.RFC3339Nano)
+		}
+		r.Notifier.Notify(notify.NewEvent(user, "DatabaseUser", databasesv1alpha1.EventPasswordRotated,
+			fmt.Sprintf("password of %s rotated", username), dedupKey).WithData(
+			"username", username,
+			"cluster", cluster.Name,
+			"secret", secretName,
+		))
+	}
+	return password, secretName, true, nil
+}
+
+// updatePassword sets the password in PostgreSQL, then writes it to the user's secrets
+func (r *DatabaseUserReconciler) updatePassword(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
+	secret *corev1.Secret, cluster *databasesv1alpha1.DBCluster, databases []*databasesv1alpha1.Database,
+	pgClient postgres.ClientInterface, username, password string) (secretName string, err error) {
+
+	logger := log.FromContext(ctx)
+	secretName = secret.Name
+
 	// Update PostgreSQL first
 	if err := pgClient.SetPassword(ctx, username, password); err != nil {
-		return "", "", false, fmt.Errorf("failed to update password in PostgreSQL: %w", err)
+		return "", fmt.Errorf("failed to update password in PostgreSQL: %w", err)
 	}
 
 	// Update secrets based on generation strategy
@@ -728,7 +1106,7 @@ func (r *DatabaseUserReconciler) rotatePassword(ctx context.Context, user *datab
 		for _, db := range databases {
 			dbSecretName := r.getSecretNameForDatabase(user, db.Name)
 			if err := r.createDatabaseSecret(ctx, user, dbSecretName, cluster, db, username, password); err != nil {
-				logger.Error(err, "failed to update database secret during rotation", "secret", dbSecretName)
+				logger.Error(err, "failed to update database secret with new password", "secret", dbSecretName)
 			}
 		}
 		if len(databases) > 0 {
@@ -737,6 +1115,9 @@ func (r *DatabaseUserReconciler) rotatePassword(ctx context.Context, user *datab
 	} else {
 		// Update primary secret
 		hostKey, portKey, dbKey, userKey, pwdKey := r.getSecretKeys(user)
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
 
 		primaryDB := ""
 		if len(databases) > 0 {
@@ -760,13 +1141,16 @@ func (r *DatabaseUserReconciler) rotatePassword(ctx context.Context, user *datab
 			delete(secret.Data, "databases")
 		}
 
+		if _, err := r.applyRenderedSecretData(user, secret, cluster, databases, username, password); err != nil {
+			return "", err
+		}
+
 		if err := r.Update(ctx, secret); err != nil {
-			return "", "", false, fmt.Errorf("failed to update secret: %w", err)
+			return "", fmt.Errorf("failed to update secret: %w", err)
 		}
 	}
 
-	logger.Info("password rotated successfully", "username", username)
-	return password, secretName, true, nil
+	return secretName, nil
 }
 
 func (r *DatabaseUserReconciler) adoptSecret(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
@@ -776,13 +1160,9 @@ func (r *DatabaseUserReconciler) adoptSecret(ctx context.Context, user *database
 	logger := log.FromContext(ctx)
 	logger.Info("adopting existing secret", "secret", secret.Name)
 
-	length := user.Spec.Password.Length
-	if length == 0 {
-		length = postgres.DefaultPasswordLength
-	}
-	password, err = postgres.GeneratePassword(length)
+	password, err = r.newPassword(ctx, user)
 	if err != nil {
-		return "", "", false, fmt.Errorf("failed to generate password: %w", err)
+		return "", "", false, err
 	}
 
 	if err = pgClient.SetPassword(ctx, username, password); err != nil {
@@ -817,6 +1197,10 @@ func (r *DatabaseUserReconciler) adoptSecret(ctx context.Context, user *database
 		secret.Data["databases"] = []byte(strings.Join(dbNames, ","))
 	}
 
+	if _, err = r.applyRenderedSecretData(user, secret, cluster, databases, username, password); err != nil {
+		return "", "", false, err
+	}
+
 	if err = r.Update(ctx, secret); err != nil {
 		return "", "", false, fmt.Errorf("failed to update secret during adopt: %w", err)
 	}
@@ -831,13 +1215,9 @@ func (r *DatabaseUserReconciler) mergeSecret(ctx context.Context, user *database
 	logger := log.FromContext(ctx)
 	logger.Info("merging into existing secret", "secret", secret.Name)
 
-	length := user.Spec.Password.Length
-	if length == 0 {
-		length = postgres.DefaultPasswordLength
-	}
-	password, err = postgres.GeneratePassword(length)
+	password, err = r.newPassword(ctx, user)
 	if err != nil {
-		return "", "", false, fmt.Errorf("failed to generate password: %w", err)
+		return "", "", false, err
 	}
 
 	if err = pgClient.SetPassword(ctx, username, password); err != nil {
@@ -876,6 +1256,10 @@ func (r *DatabaseUserReconciler) mergeSecret(ctx context.Context, user *database
 		delete(secret.Data, "databases")
 	}
 
+	if _, err = r.applyRenderedSecretData(user, secret, cluster, databases, username, password); err != nil {
+		return "", "", false, err
+	}
+
 	if err = r.Update(ctx, secret); err != nil {
 		return "", "", false, fmt.Errorf("failed to update secret during merge: %w", err)
 	}
@@ -893,9 +1277,23 @@ func (r *DatabaseUserReconciler) handleDeletion(ctx context.Context, user *datab
 	logger.Info("handling deletion", "username", username)
 
 	if user.Spec.DeletionPolicy != "Retain" {
-		r.dropUserFromPostgres(ctx, user, username)
+		// Before the role is dropped, so a failure leaves everything in place for the retry
+		if err := r.deleteFromSecretStore(ctx, user); err != nil {
+			logger.Error(err, "failed to clean up secret store, keeping finalizer", "username", username)
+			return ctrl.Result{RequeueAfter: 60 * time.Second},
+				r.setDeletionStatus(ctx, user, nil, fmt.Errorf("secret store error: %w", err))
+		}
+		if user.Spec.Deletion.Reassign() {
+			if progress, err := r.reassignAndDropUser(ctx, user, username); err != nil {
+				logger.Error(err, "failed to drop user, keeping finalizer", "username", username)
+				return ctrl.Result{RequeueAfter: 60 * time.Second}, r.setDeletionStatus(ctx, user, progress, err)
+			}
+		} else {
+			r.dropUserFromPostgres(ctx, user, username)
+		}
 	} else {
 		logger.Info("retaining user in PostgreSQL due to deletionPolicy", "username", username)
+		r.releaseRole(ctx, user, username)
 	}
 
 	controllerutil.RemoveFinalizer(user, UserFinalizerName)
@@ -907,21 +1305,17 @@ func (r *DatabaseUserReconciler) dropUserFromPostgres(ctx context.Context, user
 
 	logger := log.FromContext(ctx)
 
-	clusterName, databaseNames := r.getClusterAndDatabasesForDeletion(ctx, user)
-	if clusterName == "" {
-		logger.Error(nil, "cannot determine cluster for cleanup - user will remain in PostgreSQL")
+	pgClient, databaseNames, err := r.getPostgresClientForDeletion(ctx, user)
+	if err != nil {
+		logger.Error(err, "failed to get postgres client for cleanup")
 		return
 	}
-
-	var cluster databasesv1alpha1.DBCluster
-	if err := r.Get(ctx, types.NamespacedName{Name: clusterName}, &cluster); err != nil {
-		logger.Error(err, "failed to get cluster for cleanup")
+	if pgClient == nil {
 		return
 	}
 
-	pgClient, err := r.getPostgresClient(ctx, &cluster)
-	if err != nil {
-		logger.Error(err, "failed to get postgres client for cleanup")
+	if _, err := r.checkRoleOwner(ctx, pgClient, user, username); err != nil {
+		logger.Info("not dropping role", "username", username, "reason", err.Error())
 		return
 	}
 
@@ -939,6 +1333,27 @@ func (r *DatabaseUserReconciler) dropUserFromPostgres(ctx context.Context, user
 	}
 }
 
+// releaseRole clears the ownership comment of a retained role so another DatabaseUser can adopt it
+func (r *DatabaseUserReconciler) releaseRole(ctx context.Context, user *databasesv1alpha1.DatabaseUser, username string) {
+	logger := log.FromContext(ctx)
+
+	pgClient, _, err := r.getPostgresClientForDeletion(ctx, user)
+	if err != nil {
+		logger.Error(err, "failed to get postgres client to release role")
+		return
+	}
+	if pgClient == nil {
+		return
+	}
+
+	if claim, err := r.checkRoleOwner(ctx, pgClient, user, username); err != nil || claim {
+		return // not ours to release
+	}
+	if err := pgClient.ClearUserOwner(ctx, username); err != nil {
+		logger.Error(err, "failed to clear role ownership during retention")
+	}
+}
+
 func (r *DatabaseUserReconciler) getClusterAndDatabasesForDeletion(ctx context.Context, user *databasesv1alpha1.DatabaseUser) (clusterName string, databaseNames []string) {
 	// First try to get from status
 	if user.Status.ClusterName != "" {
@@ -999,27 +1414,7 @@ func (r *DatabaseUserReconciler) deleteOldSecret(ctx context.Context, namespace,
 }
 
 func (r *DatabaseUserReconciler) getPostgresClient(ctx context.Context, cluster *databasesv1alpha1.DBCluster) (postgres.ClientInterface, error) {
-	var secret corev1.Secret
-	if err := r.Get(ctx, types.NamespacedName{
-		Name:      cluster.Spec.CredentialsSecretRef.Name,
-		Namespace: cluster.Spec.CredentialsSecretRef.Namespace,
-	}, &secret); err != nil {
-		return nil, fmt.Errorf("failed to get credentials secret: %w", err)
-	}
-
-	username := string(secret.Data["username"])
-	password := string(secret.Data["password"])
-	if username == "" || password == "" {
-		return nil, fmt.Errorf("credentials secret must contain 'username' and 'password' keys")
-	}
-
-	return r.PGClientCache.Get(ctx, cluster.Name, postgres.Config{
-		Host:     cluster.Spec.Endpoint,
-		Port:     cluster.Spec.Port,
-		Username: username,
-		Password: password,
-		Database: "postgres",
-	})
+	return getClusterPostgresClient(ctx, r.Client, r.PGClientCache, cluster)
 }
 
 // statusUpdate contains all parameters for updating DatabaseUser status
@@ -1032,6 +1427,13 @@ type statusUpdate struct {
 	ClusterName     string
 	Username        string
 	Databases       []databasesv1alpha1.DatabaseAccessStatus
+	// RolledOutWorkloads is set when rolloutTargets were patched in this reconcile
+	RolledOutWorkloads []string
+	// RolloutApplied clears a pending rollout once rolloutTargets were patched
+	RolloutApplied bool
+	SecretStore    *databasesv1alpha1.SecretStoreStatus
+	// RoleAttributes is set once ALTER ROLE succeeded
+	RoleAttributes *appliedRoleAttributes
 }
 
 func (r *DatabaseUserReconciler) setStatus(ctx context.Context, user *databasesv1alpha1.DatabaseUser, update *statusUpdate) (ctrl.Result, error) {
@@ -1046,7 +1448,12 @@ func (r *DatabaseUserReconciler) setStatus(ctx context.Context, user *databasesv
 		(update.Username != "" && user.Status.Username != update.Username) ||
 		(update.SecretName != "" && user.Status.SecretName != update.SecretName) ||
 		update.PasswordUpdated ||
-		len(update.Databases) > 0
+		len(update.Databases) > 0 ||
+		len(update.RolledOutWorkloads) > 0 ||
+		(update.RolloutApplied && user.Status.PendingRolloutAt != nil) ||
+		secretStoreStatusChanged(user.Status.SecretStore, update.SecretStore) ||
+		(update.Phase == "Ready" && update.SecretStore == nil && user.Status.SecretStore != nil) ||
+		roleAttributesStatusChanged(&user.Status, update.RoleAttributes)
 
 	if statusChanged {
 		patch := client.MergeFrom(user.DeepCopy())
@@ -1100,6 +1507,30 @@ func (r *DatabaseUserReconciler) applyStatusFields(user *databasesv1alpha1.Datab
 		now := metav1.Now()
 		user.Status.PasswordUpdatedAt = &now
 	}
+	if len(update.RolledOutWorkloads) > 0 {
+		now := metav1.Now()
+		user.Status.LastRolloutAt = &now
+		user.Status.RolledOutWorkloads = update.RolledOutWorkloads
+	}
+	if update.RolloutApplied {
+		user.Status.PendingRolloutAt = nil
+	}
+	if update.SecretStore != nil {
+		user.Status.SecretStore = update.SecretStore
+	} else if update.Phase == "Ready" {
+		// secretStore was removed from spec
+		user.Status.SecretStore = nil
+	}
+	if update.RoleAttributes != nil {
+		user.Status.RoleAttributes = update.RoleAttributes.Enabled
+		user.Status.RoleAttributesPolicyHash = update.RoleAttributes.PolicyHash
+		user.Status.ValidUntil = update.RoleAttributes.ValidUntil
+		if cond := update.RoleAttributes.Condition; cond != nil {
+			meta.SetStatusCondition(&user.Status.Conditions, *cond)
+		} else {
+			meta.RemoveStatusCondition(&user.Status.Conditions, databasesv1alpha1.UserConditionRoleAttributesAllowed)
+		}
+	}
 }
 
 func (r *DatabaseUserReconciler) buildDatabasesSummary(databases []databasesv1alpha1.DatabaseAccessStatus) string {
@@ -1116,5 +1547,9 @@ func (r *DatabaseUserReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&databasesv1alpha1.DatabaseUser{}).
 		Owns(&corev1.Secret{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findUsersForPasswordSecret)).
+		Watches(&databasesv1alpha1.DatabaseReferenceGrant{}, handler.EnqueueRequestsFromMapFunc(r.findUsersForReferenceGrant)).
+		Watches(&databasesv1alpha1.DBCluster{}, handler.EnqueueRequestsFromMapFunc(r.findUsersForCluster),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Complete(r)
 }
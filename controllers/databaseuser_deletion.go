@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+const (
+	cleanupPhaseDone   = "Done"
+	cleanupPhaseFailed = "Failed"
+)
+
+// errReassignTargetNotAllowed marks a deletion.reassignTo role the user's namespace does not control
+var errReassignTargetNotAllowed = errors.New("deletion.reassignTo not allowed")
+
+// reassignAndDropUser implements the reassignTo deletion strategy: REASSIGN OWNED and DROP OWNED
+// run in every database the role touched, then the role is dropped. Returns the per-database
+// progress for status; on error the caller keeps the finalizer and retries.
+func (r *DatabaseUserReconciler) reassignAndDropUser(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
+	username string) ([]databasesv1alpha1.DatabaseCleanupStatus, error) {
+
+	logger := log.FromContext(ctx)
+
+	pgClient, _, err := r.getPostgresClientForDeletion(ctx, user)
+	if err != nil || pgClient == nil {
+		return nil, err
+	}
+
+	exists, err := pgClient.UserExists(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+	if _, err := r.checkRoleOwner(ctx, pgClient, user, username); err != nil {
+		logger.Info("not dropping role", "username", username, "reason", err.Error())
+		return nil, nil
+	}
+
+	// pg_shdepend is authoritative: it also covers databases the operator never granted access to
+	databases, err := pgClient.GetRoleDatabases(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkReassignTarget(ctx, pgClient, user, username, databases); err != nil {
+		return nil, err
+	}
+
+	progress := r.reassignOwnedObjects(ctx, pgClient, user, username, databases)
+
+	var failed []string
+	for _, p := range progress {
+		if p.Phase == cleanupPhaseFailed {
+			failed = append(failed, fmt.Sprintf("%s: %s", p.DatabaseName, p.Message))
+		}
+	}
+	if len(failed) > 0 {
+		return progress, fmt.Errorf("reassigning owned objects failed in %d of %d databases (%s)",
+			len(failed), len(progress), strings.Join(failed, "; "))
+	}
+
+	if err := pgClient.DropUser(ctx, username); err != nil {
+		return progress, err
+	}
+	logger.Info("user dropped after reassigning owned objects", "username", username, "databases", len(databases))
+	return progress, nil
+}
+
+// getPostgresClientForDeletion resolves the cluster from status (or spec) and returns the databases
+// the user had access to. The client is nil when the cluster is unknown or gone, leaving nothing to clean up.
+func (r *DatabaseUserReconciler) getPostgresClientForDeletion(ctx context.Context,
+	user *databasesv1alpha1.DatabaseUser) (postgres.ClientInterface, []string, error) {
+
+	logger := log.FromContext(ctx)
+
+	clusterName, databaseNames := r.getClusterAndDatabasesForDeletion(ctx, user)
+	if clusterName == "" {
+		logger.Error(nil, "cannot determine cluster for cleanup - user will remain in PostgreSQL")
+		return nil, nil, nil
+	}
+
+	var cluster databasesv1alpha1.DBCluster
+	if err := r.Get(ctx, types.NamespacedName{Name: clusterName}, &cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("DBCluster not found, skipping role cleanup", "cluster", clusterName)
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get DBCluster '%s': %w", clusterName, err)
+	}
+
+	pgClient, err := r.getPostgresClient(ctx, &cluster)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+	return pgClient, databaseNames, nil
+}
+
+// reassignOwnedObjects runs ReassignOwned per database. Databases finished in an earlier attempt
+// no longer show up in pg_shdepend, their status entries are carried over.
+func (r *DatabaseUserReconciler) reassignOwnedObjects(ctx context.Context, pgClient postgres.ClientInterface,
+	user *databasesv1alpha1.DatabaseUser, username string, databases []string) []databasesv1alpha1.DatabaseCleanupStatus {
+
+	logger := log.FromContext(ctx)
+
+	var progress []databasesv1alpha1.DatabaseCleanupStatus
+	for _, previous := range user.Status.Deletion {
+		if previous.Phase == cleanupPhaseDone && !slices.Contains(databases, previous.DatabaseName) {
+			progress = append(progress, previous)
+		}
+	}
+
+	for _, dbName := range databases {
+		entry := databasesv1alpha1.DatabaseCleanupStatus{DatabaseName: dbName}
+
+		newOwner, err := r.getReassignTarget(ctx, pgClient, user, dbName)
+		if err == nil && newOwner == username {
+			err = fmt.Errorf("role owns database %s, set deletion.reassignTo to another role", dbName)
+		}
+		if err == nil {
+			entry.ReassignedTo = newOwner
+			err = pgClient.ReassignOwned(ctx, username, dbName, newOwner)
+		}
+
+		if err != nil {
+			logger.Error(err, "failed to reassign owned objects", "database", dbName)
+			entry.Phase = cleanupPhaseFailed
+			entry.Message = err.Error()
+		} else {
+			entry.Phase = cleanupPhaseDone
+		}
+		progress = append(progress, entry)
+	}
+	return progress
+}
+
+// getReassignTarget returns deletion.reassignTo, or the owner of the database when unset
+func (r *DatabaseUserReconciler) getReassignTarget(ctx context.Context, pgClient postgres.ClientInterface,
+	user *databasesv1alpha1.DatabaseUser, dbName string) (string, error) {
+
+	if user.Spec.Deletion.ReassignTo != "" {
+		return user.Spec.Deletion.ReassignTo, nil
+	}
+	return pgClient.GetDatabaseOwnerRole(ctx, dbName)
+}
+
+// checkReassignTarget rejects a deletion.reassignTo role outside the tenant's reach. REASSIGN OWNED runs
+// as the cluster admin, so any role would be accepted by PostgreSQL, including superusers and the roles of
+// other namespaces. Allowed are the owner of every affected database and the roles of other DatabaseUsers
+// in the same namespace on the same cluster.
+func (r *DatabaseUserReconciler) checkReassignTarget(ctx context.Context, pgClient postgres.ClientInterface,
+	user *databasesv1alpha1.DatabaseUser, username string, databases []string) error {
+
+	target := user.Spec.Deletion.ReassignTo
+	if target == "" {
+		return nil
+	}
+	if target == username {
+		return fmt.Errorf("%w: role %s cannot receive its own objects", errReassignTargetNotAllowed, target)
+	}
+
+	clusterName, _ := r.getClusterAndDatabasesForDeletion(ctx, user)
+	var users databasesv1alpha1.DatabaseUserList
+	if err := r.List(ctx, &users, client.InNamespace(user.Namespace)); err != nil {
+		return fmt.Errorf("failed to list DatabaseUsers: %w", err)
+	}
+	for i := range users.Items {
+		other := &users.Items[i]
+		if other.Name != user.Name && other.Status.ClusterName == clusterName && r.getUsername(other) == target {
+			return nil
+		}
+	}
+
+	for _, dbName := range databases {
+		owner, err := pgClient.GetDatabaseOwnerRole(ctx, dbName)
+		if err != nil {
+			return err
+		}
+		if owner != target {
+			return fmt.Errorf("%w: role %s is neither the owner of database %s nor managed by a DatabaseUser "+
+				"in namespace %s on cluster %s", errReassignTargetNotAllowed, target, dbName, user.Namespace, clusterName)
+		}
+	}
+	return nil
+}
+
+// setDeletionStatus reports a blocked deletion; the DatabaseUser keeps its finalizer. A rejected
+// reassignTo target sets the ReassignTargetAllowed condition to False.
+func (r *DatabaseUserReconciler) setDeletionStatus(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
+	progress []databasesv1alpha1.DatabaseCleanupStatus, cause error) error {
+
+	patch := client.MergeFrom(user.DeepCopy())
+	user.Status.Phase = "Deleting"
+	user.Status.Message = cause.Error()
+	if progress != nil {
+		user.Status.Deletion = progress
+	}
+	if errors.Is(cause, errReassignTargetNotAllowed) {
+		meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+			Type:               databasesv1alpha1.UserConditionReassignTargetAllowed,
+			Status:             metav1.ConditionFalse,
+			Reason:             databasesv1alpha1.UserReasonNotAllowed,
+			Message:            cause.Error(),
+			ObservedGeneration: user.Generation,
+		})
+	} else {
+		meta.RemoveStatusCondition(&user.Status.Conditions, databasesv1alpha1.UserConditionReassignTargetAllowed)
+	}
+	return client.IgnoreNotFound(r.Status().Patch(ctx, user, patch))
+}
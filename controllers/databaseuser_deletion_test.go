@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+// newDeletingUser returns a Ready DatabaseUser that is being deleted with the reassignTo strategy
+func newDeletingUser(reassignTo string) *databasesv1alpha1.DatabaseUser {
+	now := metav1.Now()
+	return &databasesv1alpha1.DatabaseUser{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "reporting",
+			Namespace:         testGrantNamespace,
+			Generation:        1,
+			Finalizers:        []string{UserFinalizerName},
+			DeletionTimestamp: &now,
+		},
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			Database: &databasesv1alpha1.DatabaseAccess{Name: "orders-db"},
+			Deletion: &databasesv1alpha1.UserDeletionConfig{
+				Strategy:   databasesv1alpha1.UserDeletionStrategyReassign,
+				ReassignTo: reassignTo,
+			},
+		},
+		Status: databasesv1alpha1.DatabaseUserStatus{
+			Phase:              "Ready",
+			ObservedGeneration: 1,
+			ClusterName:        "main",
+			Username:           "reporting",
+			Databases:          []databasesv1alpha1.DatabaseAccessStatus{{Name: "orders-db", DatabaseName: "orders_db"}},
+		},
+	}
+}
+
+func newDeletionTestReconciler(pgClient *postgres.MockClient, user *databasesv1alpha1.DatabaseUser,
+	objects ...client.Object) *DatabaseUserReconciler {
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "reporting-credentials", Namespace: testGrantNamespace}}
+	return newPasswordRefTestReconciler(pgClient, append(append(newGrantTestObjects(), secret, user), objects...)...)
+}
+
+// newClusterUser returns a Ready DatabaseUser whose role lives on the given cluster
+func newClusterUser(name, namespace, clusterName string) *databasesv1alpha1.DatabaseUser {
+	return &databasesv1alpha1.DatabaseUser{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       databasesv1alpha1.DatabaseUserSpec{Database: &databasesv1alpha1.DatabaseAccess{Name: "orders-db"}},
+		Status:     databasesv1alpha1.DatabaseUserStatus{Phase: "Ready", ClusterName: clusterName},
+	}
+}
+
+func reconcileDeletingUser(t *testing.T, r *DatabaseUserReconciler) (*databasesv1alpha1.DatabaseUser, ctrl.Result) {
+	t.Helper()
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "reporting", Namespace: testGrantNamespace}}
+	result, err := r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	var user databasesv1alpha1.DatabaseUser
+	if err := r.Get(ctx, req.NamespacedName, &user); err != nil {
+		return nil, result
+	}
+	return &user, result
+}
+
+func TestDatabaseUserReconciler_ReassignToDatabaseOwner(t *testing.T) {
+	pgClient := postgres.NewMockClient()
+	pgClient.AddUser("reporting", "secret")
+	pgClient.AddOwnedObjects("reporting", "orders_db")
+	pgClient.AddOwnedObjects("reporting", "analytics")
+	pgClient.SetDatabaseOwnerRole("orders_db", "orders_owner")
+
+	r := newDeletionTestReconciler(pgClient, newDeletingUser(""))
+	if user, _ := reconcileDeletingUser(t, r); user != nil {
+		t.Fatalf("user should be gone, status = %s (%s)", user.Status.Phase, user.Status.Message)
+	}
+
+	if pgClientHasUser(pgClient, "reporting") {
+		t.Error("role should be dropped")
+	}
+	if got := pgClient.GetReassignedTo("reporting", "orders_db"); got != "orders_owner" {
+		t.Errorf("orders_db objects reassigned to %q, want database owner", got)
+	}
+	if got := pgClient.GetReassignedTo("reporting", "analytics"); got != "postgres" {
+		t.Errorf("analytics objects reassigned to %q, want database owner", got)
+	}
+}
+
+func TestDatabaseUserReconciler_ReassignToRole(t *testing.T) {
+	pgClient := postgres.NewMockClient()
+	pgClient.AddUser("reporting", "secret")
+	pgClient.AddOwnedObjects("reporting", "orders_db")
+
+	r := newDeletionTestReconciler(pgClient, newDeletingUser("app_owner"),
+		newClusterUser("app-owner", testGrantNamespace, "main"))
+	if user, _ := reconcileDeletingUser(t, r); user != nil {
+		t.Fatalf("user should be gone, status = %s (%s)", user.Status.Phase, user.Status.Message)
+	}
+	if got := pgClient.GetReassignedTo("reporting", "orders_db"); got != "app_owner" {
+		t.Errorf("objects reassigned to %q, want app_owner", got)
+	}
+}
+
+func TestDatabaseUserReconciler_ReassignToRejectedTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		reassignTo string
+		objects    []client.Object
+	}{
+		{name: "unmanaged role", reassignTo: "cluster_admin"},
+		{
+			name:       "DatabaseUser in another namespace",
+			reassignTo: "billing",
+			objects:    []client.Object{newClusterUser("billing", "team-beta", "main")},
+		},
+		{
+			name:       "DatabaseUser on another cluster",
+			reassignTo: "billing",
+			objects:    []client.Object{newClusterUser("billing", testGrantNamespace, "other")},
+		},
+		{name: "the role itself", reassignTo: "reporting"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pgClient := postgres.NewMockClient()
+			pgClient.AddUser("reporting", "secret")
+			pgClient.AddOwnedObjects("reporting", "orders_db")
+			pgClient.SetDatabaseOwnerRole("orders_db", "orders_owner")
+
+			r := newDeletionTestReconciler(pgClient, newDeletingUser(tt.reassignTo), tt.objects...)
+			user, result := reconcileDeletingUser(t, r)
+			if user == nil {
+				t.Fatal("user should keep its finalizer when the target is rejected")
+			}
+			if user.Status.Phase != "Deleting" || !strings.Contains(user.Status.Message, "reassignTo not allowed") {
+				t.Errorf("status = %s (%s), want Deleting with the rejection", user.Status.Phase, user.Status.Message)
+			}
+			cond := meta.FindStatusCondition(user.Status.Conditions, databasesv1alpha1.UserConditionReassignTargetAllowed)
+			if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != databasesv1alpha1.UserReasonNotAllowed {
+				t.Errorf("ReassignTargetAllowed condition = %+v, want False/NotAllowed", cond)
+			}
+			if result.RequeueAfter != 60*time.Second {
+				t.Errorf("RequeueAfter = %s, want 60s", result.RequeueAfter)
+			}
+			if got := pgClient.GetReassignedTo("reporting", "orders_db"); got != "" {
+				t.Errorf("objects reassigned to %q, want nothing reassigned", got)
+			}
+			if !pgClientHasUser(pgClient, "reporting") {
+				t.Error("role should not be dropped")
+			}
+		})
+	}
+}
+
+func TestDatabaseUserReconciler_ReassignToExplicitDatabaseOwner(t *testing.T) {
+	pgClient := postgres.NewMockClient()
+	pgClient.AddUser("reporting", "secret")
+	pgClient.AddOwnedObjects("reporting", "orders_db")
+	pgClient.SetDatabaseOwnerRole("orders_db", "orders_owner")
+
+	r := newDeletionTestReconciler(pgClient, newDeletingUser("orders_owner"))
+	if user, _ := reconcileDeletingUser(t, r); user != nil {
+		t.Fatalf("user should be gone, status = %s (%s)", user.Status.Phase, user.Status.Message)
+	}
+	if got := pgClient.GetReassignedTo("reporting", "orders_db"); got != "orders_owner" {
+		t.Errorf("objects reassigned to %q, want orders_owner", got)
+	}
+}
+
+func TestDatabaseUserReconciler_ReassignFailureKeepsFinalizer(t *testing.T) {
+	pgClient := postgres.NewMockClient()
+	pgClient.AddUser("reporting", "secret")
+	pgClient.AddOwnedObjects("reporting", "orders_db")
+	pgClient.AddOwnedObjects("reporting", "analytics")
+	pgClient.ReassignErrors = map[string]error{"analytics": errors.New("permission denied to reassign objects")}
+
+	r := newDeletionTestReconciler(pgClient, newDeletingUser("app_owner"),
+		newClusterUser("app-owner", testGrantNamespace, "main"))
+	user, result := reconcileDeletingUser(t, r)
+	if user == nil {
+		t.Fatal("user should keep its finalizer while a database fails")
+	}
+	if user.Status.Phase != "Deleting" || !strings.Contains(user.Status.Message, "permission denied") {
+		t.Errorf("status = %s (%s), want Deleting with the error", user.Status.Phase, user.Status.Message)
+	}
+	if result.RequeueAfter != 60*time.Second {
+		t.Errorf("RequeueAfter = %s, want 60s", result.RequeueAfter)
+	}
+	phases := map[string]string{}
+	for _, p := range user.Status.Deletion {
+		phases[p.DatabaseName] = p.Phase
+	}
+	if phases["orders_db"] != "Done" || phases["analytics"] != "Failed" {
+		t.Errorf("deletion progress = %v", user.Status.Deletion)
+	}
+	if !pgClientHasUser(pgClient, "reporting") {
+		t.Fatal("role should not be dropped while objects remain")
+	}
+
+	pgClient.ReassignErrors = nil
+	if user, _ = reconcileDeletingUser(t, r); user != nil {
+		t.Fatalf("user should be gone after retry, status = %s (%s)", user.Status.Phase, user.Status.Message)
+	}
+	if pgClientHasUser(pgClient, "reporting") {
+		t.Error("role should be dropped after retry")
+	}
+}
+
+func TestDatabaseUserReconciler_ReassignRoleOwnsDatabase(t *testing.T) {
+	pgClient := postgres.NewMockClient()
+	pgClient.AddUser("reporting", "secret")
+	pgClient.AddOwnedObjects("reporting", "reporting_db")
+	pgClient.SetDatabaseOwnerRole("reporting_db", "reporting")
+
+	r := newDeletionTestReconciler(pgClient, newDeletingUser(""))
+	user, _ := reconcileDeletingUser(t, r)
+	if user == nil || len(user.Status.Deletion) != 1 || user.Status.Deletion[0].Phase != "Failed" {
+		t.Fatalf("expected failed cleanup for reporting_db, got %+v", user)
+	}
+	if !strings.Contains(user.Status.Deletion[0].Message, "deletion.reassignTo") {
+		t.Errorf("message = %q, want hint to set reassignTo", user.Status.Deletion[0].Message)
+	}
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+// rotationEnabled is false without spec.rotation and for passwords taken from password.secretRef
+func (r *DatabaseUserReconciler) rotationEnabled(user *databasesv1alpha1.DatabaseUser) bool {
+	if user.Spec.Password.SecretRef != nil {
+		return false
+	}
+	return user.Spec.Rotation != nil && user.Spec.Rotation.Days > 0
+}
+
+func (r *DatabaseUserReconciler) getPasswordSecretKey(user *databasesv1alpha1.DatabaseUser) string {
+	if ref := user.Spec.Password.SecretRef; ref != nil && ref.Key != "" {
+		return ref.Key
+	}
+	return "password"
+}
+
+// passwordSourceMessage is appended to the Ready message of users with password.secretRef
+func (r *DatabaseUserReconciler) passwordSourceMessage(user *databasesv1alpha1.DatabaseUser) string {
+	ref := user.Spec.Password.SecretRef
+	if user.Spec.Rotation != nil && user.Spec.Rotation.Days > 0 {
+		return fmt.Sprintf("rotation disabled: password is managed in secret '%s' (spec.rotation is ignored)", ref.Name)
+	}
+	return fmt.Sprintf("password from secret '%s', rotation disabled", ref.Name)
+}
+
+// newPassword returns the referenced password if password.secretRef is set, otherwise a generated one
+func (r *DatabaseUserReconciler) newPassword(ctx context.Context, user *databasesv1alpha1.DatabaseUser) (string, error) {
+	if user.Spec.Password.SecretRef != nil {
+		return r.getReferencedPassword(ctx, user)
+	}
+
+	password, err := postgres.GeneratePasswordWithPolicy(postgres.PasswordPolicy{
+		Length:       user.Spec.Password.Length,
+		Charset:      user.Spec.Password.Charset,
+		ExcludeChars: user.Spec.Password.ExcludeChars,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return password, nil
+}
+
+// getReferencedPassword reads the password from password.secretRef
+func (r *DatabaseUserReconciler) getReferencedPassword(ctx context.Context, user *databasesv1alpha1.DatabaseUser) (string, error) {
+	ref := user.Spec.Password.SecretRef
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: user.Namespace}, &secret); err != nil {
+		if errors.IsNotFound(err) {
+			return "", fmt.Errorf("password secret '%s' not found", ref.Name)
+		}
+		return "", fmt.Errorf("failed to get password secret: %w", err)
+	}
+
+	key := r.getPasswordSecretKey(user)
+	password := string(secret.Data[key])
+	if password == "" {
+		return "", fmt.Errorf("password secret '%s' has no key '%s'", ref.Name, key)
+	}
+	return password, nil
+}
+
+// syncReferencedPassword applies the referenced password if it differs from the one in the user's secret
+func (r *DatabaseUserReconciler) syncReferencedPassword(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
+	secret *corev1.Secret, cluster *databasesv1alpha1.DBCluster, databases []*databasesv1alpha1.Database,
+	pgClient postgres.ClientInterface, username string) (password string, changed bool, err error) {
+
+	password, err = r.getReferencedPassword(ctx, user)
+	if err != nil {
+		return "", false, err
+	}
+
+	_, _, _, _, pwdKey := r.getSecretKeys(user)
+	if string(secret.Data[pwdKey]) == password {
+		return "", false, nil
+	}
+
+	log.FromContext(ctx).Info("password changed in referenced secret", "username", username,
+		"secret", user.Spec.Password.SecretRef.Name)
+	if _, err := r.updatePassword(ctx, user, secret, cluster, databases, pgClient, username, password); err != nil {
+		return "", false, err
+	}
+	return password, true, nil
+}
+
+// findUsersForPasswordSecret maps a Secret to the DatabaseUsers referencing it in password.secretRef
+func (r *DatabaseUserReconciler) findUsersForPasswordSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var users databasesv1alpha1.DatabaseUserList
+	if err := r.List(ctx, &users, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list DatabaseUsers for password secret", "secret", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, user := range users.Items {
+		if ref := user.Spec.Password.SecretRef; ref != nil && ref.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: user.Name, Namespace: user.Namespace},
+			})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+func newPasswordRefTestReconciler(pgClient *postgres.MockClient, objects ...client.Object) *DatabaseUserReconciler {
+	scheme := runtime.NewScheme()
+	_ = databasesv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&databasesv1alpha1.DatabaseUser{}).
+		Build()
+
+	cache := postgres.NewMockClientCache()
+	cache.SetClient("main", pgClient)
+
+	return &DatabaseUserReconciler{
+		Client:        fakeClient,
+		Scheme:        scheme,
+		PGClientCache: cache,
+	}
+}
+
+func newPasswordRefUser() *databasesv1alpha1.DatabaseUser {
+	return &databasesv1alpha1.DatabaseUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy-app", Namespace: testGrantNamespace},
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			Database:   &databasesv1alpha1.DatabaseAccess{Name: "orders-db"},
+			Privileges: "readwrite",
+			Password: databasesv1alpha1.PasswordConfig{
+				SecretRef: &databasesv1alpha1.PasswordSecretRef{Name: "legacy-app-db", Key: "DB_PASSWORD"},
+			},
+			Rotation: &databasesv1alpha1.RotationConfig{Days: 30},
+		},
+	}
+}
+
+// reconcileTestUser runs Reconcile until the finalizer is set and the user is reconciled once
+func reconcileTestUser(t *testing.T, r *DatabaseUserReconciler, name string) *databasesv1alpha1.DatabaseUser {
+	t.Helper()
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: testGrantNamespace}}
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+	var user databasesv1alpha1.DatabaseUser
+	if err := r.Get(ctx, req.NamespacedName, &user); err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	return &user
+}
+
+func TestDatabaseUserReconciler_PasswordSecretRef(t *testing.T) {
+	ctx := context.Background()
+	pgClient := postgres.NewMockClient()
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy-app-db", Namespace: testGrantNamespace},
+		Data:       map[string][]byte{"DB_PASSWORD": []byte("current-app-password")},
+	}
+	r := newPasswordRefTestReconciler(pgClient, append(newGrantTestObjects(), source, newPasswordRefUser())...)
+
+	user := reconcileTestUser(t, r, "legacy-app")
+	if user.Status.Phase != "Ready" {
+		t.Fatalf("phase = %s (%s), want Ready", user.Status.Phase, user.Status.Message)
+	}
+	if !strings.Contains(user.Status.Message, "rotation disabled") {
+		t.Errorf("message = %q, want rotation disabled notice", user.Status.Message)
+	}
+	if got := pgClient.GetPassword("legacy_app"); got != "current-app-password" {
+		t.Errorf("postgres password = %q, want referenced password", got)
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: "legacy-app-credentials", Namespace: testGrantNamespace}, &secret); err != nil {
+		t.Fatalf("expected credentials secret: %v", err)
+	}
+	if secret.StringData["password"] != "current-app-password" {
+		t.Errorf("credentials secret password = %q", secret.StringData["password"])
+	}
+
+	// Changing the referenced Secret is applied on the next reconcile, even though the user is Ready
+	source.Data["DB_PASSWORD"] = []byte("new-app-password")
+	if err := r.Update(ctx, source); err != nil {
+		t.Fatalf("failed to update source secret: %v", err)
+	}
+	user = reconcileTestUser(t, r, "legacy-app")
+	if got := pgClient.GetPassword("legacy_app"); got != "new-app-password" {
+		t.Errorf("postgres password = %q, want updated referenced password", got)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "legacy-app-credentials", Namespace: testGrantNamespace}, &secret); err != nil {
+		t.Fatalf("expected credentials secret: %v", err)
+	}
+	if string(secret.Data["password"]) != "new-app-password" {
+		t.Errorf("credentials secret password = %q, want updated password", secret.Data["password"])
+	}
+	if user.Status.PasswordUpdatedAt == nil {
+		t.Error("passwordUpdatedAt should be set")
+	}
+}
+
+func TestDatabaseUserReconciler_PasswordSecretRefMissing(t *testing.T) {
+	pgClient := postgres.NewMockClient()
+	r := newPasswordRefTestReconciler(pgClient, append(newGrantTestObjects(), newPasswordRefUser())...)
+
+	user := reconcileTestUser(t, r, "legacy-app")
+	if user.Status.Phase != "Failed" || !strings.Contains(user.Status.Message, "password secret 'legacy-app-db' not found") {
+		t.Errorf("status = %s (%s), want Failed for missing password secret", user.Status.Phase, user.Status.Message)
+	}
+	if pgClientHasUser(pgClient, "legacy_app") {
+		t.Error("role should not be created without a password")
+	}
+}
+
+func TestDatabaseUserReconciler_PasswordSecretRefDisablesRotation(t *testing.T) {
+	r := &DatabaseUserReconciler{}
+	user := newPasswordRefUser()
+	past := metav1.NewTime(time.Now().Add(-60 * 24 * time.Hour))
+	user.Status.PasswordUpdatedAt = &past
+
+	if r.shouldRotatePassword(user) {
+		t.Error("shouldRotatePassword() = true, want false with password.secretRef")
+	}
+	if got := r.calculateRequeueAfter(user); got != 0 {
+		t.Errorf("calculateRequeueAfter() = %s, want 0", got)
+	}
+
+	user.Spec.RoleAttributes = &databasesv1alpha1.RoleAttributes{ValidUntilRotation: true}
+	if err := r.validateRoleAttributes(user); err == nil {
+		t.Error("expected validUntilRotation to be rejected with password.secretRef")
+	}
+}
+
+func TestDatabaseUserReconciler_FindUsersForPasswordSecret(t *testing.T) {
+	other := newPasswordRefUser()
+	other.Name = "other"
+	other.Spec.Password.SecretRef = &databasesv1alpha1.PasswordSecretRef{Name: "unrelated"}
+	generated := newPasswordRefUser()
+	generated.Name = "generated"
+	generated.Spec.Password.SecretRef = nil
+
+	r := newPasswordRefTestReconciler(postgres.NewMockClient(), newPasswordRefUser(), other, generated)
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "legacy-app-db", Namespace: testGrantNamespace}}
+
+	requests := r.findUsersForPasswordSecret(context.Background(), secret)
+	if len(requests) != 1 || requests[0].Name != "legacy-app" {
+		t.Errorf("requests = %v, want only legacy-app", requests)
+	}
+}
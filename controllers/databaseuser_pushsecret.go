@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+// PushSecretGVK is the External Secrets Operator PushSecret kind.
+// Unstructured objects are used so that ESO is not a build or runtime dependency.
+var PushSecretGVK = schema.GroupVersionKind{
+	Group:   "external-secrets.io",
+	Version: "v1alpha1",
+	Kind:    "PushSecret",
+}
+
+const (
+	secretStorePhaseSynced  = "Synced"
+	secretStorePhasePending = "Pending"
+	secretStorePhaseFailed  = "Failed"
+
+	// PushSecretStatusInterval is how often PushSecret status is re-read until it is synced
+	PushSecretStatusInterval = 30 * time.Second
+)
+
+// +kubebuilder:rbac:groups=external-secrets.io,resources=pushsecrets,verbs=get;list;watch;create;update;patch;delete
+
+func (r *DatabaseUserReconciler) getPushSecretName(user *databasesv1alpha1.DatabaseUser) string {
+	return user.Name + "-push"
+}
+
+func (r *DatabaseUserReconciler) getPushSecretLocation(cfg *databasesv1alpha1.ExternalSecretsPush) string {
+	kind := cfg.SecretStoreRef.Kind
+	if kind == "" {
+		kind = "SecretStore"
+	}
+	return fmt.Sprintf("external-secrets:%s/%s:%s", kind, cfg.SecretStoreRef.Name, cfg.RemoteKey)
+}
+
+// buildPushSecretSpec returns the PushSecret spec pushing the whole credentials secret to RemoteKey
+func (r *DatabaseUserReconciler) buildPushSecretSpec(cfg *databasesv1alpha1.ExternalSecretsPush, secretName string) map[string]interface{} {
+	kind := cfg.SecretStoreRef.Kind
+	if kind == "" {
+		kind = "SecretStore"
+	}
+	refreshInterval := cfg.RefreshInterval
+	if refreshInterval == "" {
+		refreshInterval = "1h"
+	}
+	deletionPolicy := cfg.DeletionPolicy
+	if deletionPolicy == "" {
+		deletionPolicy = "Delete"
+	}
+
+	return map[string]interface{}{
+		"refreshInterval": refreshInterval,
+		"deletionPolicy":  deletionPolicy,
+		"secretStoreRefs": []interface{}{
+			map[string]interface{}{
+				"name": cfg.SecretStoreRef.Name,
+				"kind": kind,
+			},
+		},
+		"selector": map[string]interface{}{
+			"secret": map[string]interface{}{
+				"name": secretName,
+			},
+		},
+		"data": []interface{}{
+			map[string]interface{}{
+				"match": map[string]interface{}{
+					"remoteRef": map[string]interface{}{
+						"remoteKey": cfg.RemoteKey,
+					},
+				},
+			},
+		},
+	}
+}
+
+// syncPushSecret creates or updates the PushSecret and reflects its Ready condition
+func (r *DatabaseUserReconciler) syncPushSecret(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
+	secretName string) (*databasesv1alpha1.SecretStoreStatus, error) {
+
+	cfg := user.Spec.SecretStore.ExternalSecrets
+	location := r.getPushSecretLocation(cfg)
+
+	push := &unstructured.Unstructured{}
+	push.SetGroupVersionKind(PushSecretGVK)
+	push.SetName(r.getPushSecretName(user))
+	push.SetNamespace(user.Namespace)
+
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, push, func() error {
+		annotations := push.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations["dbtether.io/managed-by"] = user.Name
+		push.SetAnnotations(annotations)
+
+		if err := unstructured.SetNestedMap(push.Object, r.buildPushSecretSpec(cfg, secretName), "spec"); err != nil {
+			return err
+		}
+		return controllerutil.SetControllerReference(user, push, r.Scheme)
+	})
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, fmt.Errorf("PushSecret CRD not found, is the External Secrets Operator installed?")
+		}
+		return nil, fmt.Errorf("failed to apply PushSecret: %w", err)
+	}
+	if op != controllerutil.OperationResultNone {
+		log.FromContext(ctx).Info("PushSecret applied", "name", push.GetName(), "operation", op)
+	}
+
+	status := &databasesv1alpha1.SecretStoreStatus{
+		Type:     user.Spec.SecretStore.Type,
+		Location: location,
+		Phase:    secretStorePhasePending,
+		Message:  "waiting for External Secrets Operator to push the secret",
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(push.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+		message, _ := cond["message"].(string)
+		switch cond["status"] {
+		case string(metav1.ConditionTrue):
+			status.Phase = secretStorePhaseSynced
+			status.Message = message
+			if ts, ok := cond["lastTransitionTime"].(string); ok {
+				if t, err := time.Parse(time.RFC3339, ts); err == nil {
+					synced := metav1.NewTime(t)
+					status.LastSyncedAt = &synced
+				}
+			}
+		case string(metav1.ConditionFalse):
+			status.Phase = secretStorePhaseFailed
+			status.Message = message
+		}
+	}
+
+	return status, nil
+}
+
+// deletePushSecret removes the PushSecret after secretStore was switched away from external-secrets
+func (r *DatabaseUserReconciler) deletePushSecret(ctx context.Context, user *databasesv1alpha1.DatabaseUser) {
+	push := &unstructured.Unstructured{}
+	push.SetGroupVersionKind(PushSecretGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: r.getPushSecretName(user), Namespace: user.Namespace}, push); err != nil {
+		return
+	}
+	if !metav1.IsControlledBy(push, user) {
+		return
+	}
+	if err := r.Delete(ctx, push); err != nil && !errors.IsNotFound(err) {
+		log.FromContext(ctx).Error(err, "failed to delete PushSecret", "name", push.GetName())
+	}
+}
+
+// isSecretStorePending returns true while an asynchronous store (ESO) has not confirmed the push
+func (r *DatabaseUserReconciler) isSecretStorePending(user *databasesv1alpha1.DatabaseUser) bool {
+	s := user.Status.SecretStore
+	return s != nil && s.Phase != "" && s.Phase != secretStorePhaseSynced
+}
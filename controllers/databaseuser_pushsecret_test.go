@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newPushSecretTestUser() *databasesv1alpha1.DatabaseUser {
+	return &databasesv1alpha1.DatabaseUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "user-uid"},
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			Database: &databasesv1alpha1.DatabaseAccess{Name: "orders"},
+			SecretStore: &databasesv1alpha1.SecretStoreConfig{
+				Type: "external-secrets",
+				ExternalSecrets: &databasesv1alpha1.ExternalSecretsPush{
+					SecretStoreRef: databasesv1alpha1.ExternalSecretStoreRef{Name: "aws-store", Kind: "ClusterSecretStore"},
+					RemoteKey:      "/myapp/db-credentials",
+				},
+			},
+		},
+	}
+}
+
+func getTestPushSecret(t *testing.T, r *DatabaseUserReconciler, name string) *unstructured.Unstructured {
+	t.Helper()
+	push := &unstructured.Unstructured{}
+	push.SetGroupVersionKind(PushSecretGVK)
+	if err := r.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, push); err != nil {
+		t.Fatalf("failed to get PushSecret: %v", err)
+	}
+	return push
+}
+
+func TestDatabaseUserReconciler_SyncPushSecret(t *testing.T) {
+	ctx := context.Background()
+	user := newPushSecretTestUser()
+	r := newTestReconciler(user)
+
+	status, err := r.syncSecretStore(ctx, user, "app-credentials", true)
+	if err != nil {
+		t.Fatalf("syncSecretStore() error = %v", err)
+	}
+	if status.Phase != "Pending" {
+		t.Errorf("phase = %s, want Pending before ESO reports status", status.Phase)
+	}
+	if status.Location != "external-secrets:ClusterSecretStore/aws-store:/myapp/db-credentials" {
+		t.Errorf("location = %s", status.Location)
+	}
+
+	push := getTestPushSecret(t, r, "app-push")
+	secretName, _, _ := unstructured.NestedString(push.Object, "spec", "selector", "secret", "name")
+	if secretName != "app-credentials" {
+		t.Errorf("selector.secret.name = %s, want app-credentials", secretName)
+	}
+	refs, _, _ := unstructured.NestedSlice(push.Object, "spec", "secretStoreRefs")
+	if len(refs) != 1 || refs[0].(map[string]interface{})["kind"] != "ClusterSecretStore" {
+		t.Errorf("secretStoreRefs = %v", refs)
+	}
+	if policy, _, _ := unstructured.NestedString(push.Object, "spec", "deletionPolicy"); policy != "Delete" {
+		t.Errorf("deletionPolicy = %s, want Delete", policy)
+	}
+	if !metav1.IsControlledBy(push, user) {
+		t.Error("PushSecret should be owned by the DatabaseUser")
+	}
+
+	// ESO reports success
+	_ = unstructured.SetNestedSlice(push.Object, []interface{}{
+		map[string]interface{}{
+			"type":               "Ready",
+			"status":             "True",
+			"message":            "PushSecret synced successfully",
+			"lastTransitionTime": "2026-01-01T00:00:00Z",
+		},
+	}, "status", "conditions")
+	if err := r.Update(ctx, push); err != nil {
+		t.Fatalf("failed to update PushSecret status: %v", err)
+	}
+
+	status, err = r.syncSecretStore(ctx, user, "app-credentials", false)
+	if err != nil {
+		t.Fatalf("syncSecretStore() error = %v", err)
+	}
+	if status.Phase != "Synced" || status.LastSyncedAt == nil {
+		t.Errorf("status = %+v, want Synced with lastSyncedAt", status)
+	}
+
+	// ESO reports failure
+	_ = unstructured.SetNestedSlice(push.Object, []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "False", "message": "access denied"},
+	}, "status", "conditions")
+	_ = r.Update(ctx, push)
+
+	status, _ = r.syncSecretStore(ctx, user, "app-credentials", false)
+	if status.Phase != "Failed" || status.Message != "access denied" {
+		t.Errorf("status = %+v, want Failed with ESO message", status)
+	}
+}
+
+func TestDatabaseUserReconciler_PushSecretRemovedWhenSwitchingStore(t *testing.T) {
+	ctx := context.Background()
+	user := newPushSecretTestUser()
+	r := newTestReconciler(user, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("pw")},
+	})
+
+	status, err := r.syncSecretStore(ctx, user, "app-credentials", true)
+	if err != nil {
+		t.Fatalf("syncSecretStore() error = %v", err)
+	}
+	user.Status.SecretStore = status
+	if !r.isSecretStorePending(user) {
+		t.Error("user should be pending until ESO reports Ready")
+	}
+
+	user.Spec.SecretStore = nil
+	status, err = r.syncSecretStore(ctx, user, "app-credentials", false)
+	if err != nil || status != nil {
+		t.Fatalf("syncSecretStore() = %v, %v", status, err)
+	}
+
+	push := &unstructured.Unstructured{}
+	push.SetGroupVersionKind(PushSecretGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "app-push", Namespace: "default"}, push); err == nil {
+		t.Error("PushSecret should be deleted after switching away from external-secrets")
+	}
+}
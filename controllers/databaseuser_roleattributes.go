@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+// ValidUntilRotationGrace is added to the next rotation time when validUntilRotation is set,
+// leaving room for the rotation itself before the old password expires
+const ValidUntilRotationGrace = 24 * time.Hour
+
+// appliedRoleAttributes is the role attribute state written to status after ALTER ROLE succeeded
+type appliedRoleAttributes struct {
+	Enabled    []string
+	ValidUntil *metav1.Time
+
+	// PolicyHash is roleAttributesPolicyHash of the DBCluster the attributes were checked against
+	PolicyHash string
+
+	// Condition is RoleAttributesAllowed, nil when spec.roleAttributes requests no attributes
+	Condition *metav1.Condition
+}
+
+func (r *DatabaseUserReconciler) validateRoleAttributes(user *databasesv1alpha1.DatabaseUser) error {
+	attrs := user.Spec.RoleAttributes
+	if attrs == nil {
+		return nil
+	}
+	if attrs.ValidUntil != nil && attrs.ValidUntilRotation {
+		return fmt.Errorf("roleAttributes.validUntil and roleAttributes.validUntilRotation are mutually exclusive")
+	}
+	if attrs.ValidUntilRotation && (user.Spec.Rotation == nil || user.Spec.Rotation.Days == 0) {
+		return fmt.Errorf("roleAttributes.validUntilRotation requires spec.rotation")
+	}
+	if attrs.ValidUntilRotation && user.Spec.Password.SecretRef != nil {
+		return fmt.Errorf("roleAttributes.validUntilRotation cannot be used with password.secretRef (rotation is disabled)")
+	}
+	return nil
+}
+
+// deniedRoleAttributes returns the requested attributes missing from the DBCluster allow-list
+func (r *DatabaseUserReconciler) deniedRoleAttributes(user *databasesv1alpha1.DatabaseUser,
+	cluster *databasesv1alpha1.DBCluster) []string {
+
+	var denied []string
+	for _, name := range user.Spec.RoleAttributes.Enabled() {
+		if !slices.Contains(cluster.Spec.AllowedRoleAttributes, name) {
+			denied = append(denied, name)
+		}
+	}
+	return denied
+}
+
+// roleAttributesPolicyHash returns a stable hash of the DBCluster allow-list
+func roleAttributesPolicyHash(cluster *databasesv1alpha1.DBCluster) string {
+	allowed := slices.Clone(cluster.Spec.AllowedRoleAttributes)
+	slices.Sort(allowed)
+	sum := sha256.Sum256([]byte(strings.Join(slices.Compact(allowed), ",")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// roleAttributesPolicyApplied reports whether the user was last reconciled against the current
+// DBCluster allow-list. Users that neither request nor hold attributes do not depend on it.
+func (r *DatabaseUserReconciler) roleAttributesPolicyApplied(ctx context.Context, user *databasesv1alpha1.DatabaseUser) bool {
+	if len(user.Spec.RoleAttributes.Enabled()) == 0 && len(user.Status.RoleAttributes) == 0 {
+		return true
+	}
+	var cluster databasesv1alpha1.DBCluster
+	if err := r.Get(ctx, types.NamespacedName{Name: user.Status.ClusterName}, &cluster); err != nil {
+		return false
+	}
+	return user.Status.RoleAttributesPolicyHash == roleAttributesPolicyHash(&cluster)
+}
+
+// findUsersForCluster enqueues the users of a DBCluster whose spec changed, so a narrowed
+// allowedRoleAttributes is revoked right away
+func (r *DatabaseUserReconciler) findUsersForCluster(ctx context.Context, obj client.Object) []reconcile.Request {
+	var users databasesv1alpha1.DatabaseUserList
+	if err := r.List(ctx, &users); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list DatabaseUsers for cluster", "cluster", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, user := range users.Items {
+		if user.Status.ClusterName == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: user.Name, Namespace: user.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// roleAttributesDeniedMessage describes attributes that were revoked because the DBCluster does not allow them
+func roleAttributesDeniedMessage(denied []string, cluster *databasesv1alpha1.DBCluster) string {
+	return fmt.Sprintf("role attributes %s not allowed by DBCluster %s (spec.allowedRoleAttributes), revoked",
+		strings.Join(denied, ", "), cluster.Name)
+}
+
+// getValidUntil returns the desired password expiry, nil for none
+func (r *DatabaseUserReconciler) getValidUntil(user *databasesv1alpha1.DatabaseUser, passwordChanged bool) *metav1.Time {
+	attrs := user.Spec.RoleAttributes
+	if attrs == nil {
+		return nil
+	}
+	if attrs.ValidUntil != nil {
+		return attrs.ValidUntil
+	}
+	if !attrs.ValidUntilRotation || user.Spec.Rotation == nil || user.Spec.Rotation.Days == 0 {
+		return nil
+	}
+
+	updatedAt := time.Now()
+	if !passwordChanged && user.Status.PasswordUpdatedAt != nil {
+		updatedAt = user.Status.PasswordUpdatedAt.Time
+	}
+	rotationPeriod := time.Duration(user.Spec.Rotation.Days) * 24 * time.Hour
+	expiry := metav1.NewTime(updatedAt.Add(rotationPeriod + ValidUntilRotationGrace).Truncate(time.Second))
+	return &expiry
+}
+
+// buildRoleAttributes turns spec.roleAttributes into ALTER ROLE options. Attributes are only
+// touched when requested or previously granted by the operator, so users without roleAttributes
+// never need an ALTER ROLE (which may require superuser for REPLICATION/BYPASSRLS). Denied
+// attributes are always revoked: they may have been granted before the DBCluster stopped allowing them.
+func (r *DatabaseUserReconciler) buildRoleAttributes(user *databasesv1alpha1.DatabaseUser, denied []string,
+	passwordChanged bool) (postgres.RoleAttributes, *appliedRoleAttributes) {
+
+	var enabled []string
+	for _, name := range user.Spec.RoleAttributes.Enabled() {
+		if !slices.Contains(denied, name) {
+			enabled = append(enabled, name)
+		}
+	}
+	previous := user.Status.RoleAttributes
+
+	desired := func(name string) *bool {
+		on := slices.Contains(enabled, name)
+		if !on && !slices.Contains(previous, name) && !slices.Contains(denied, name) {
+			return nil
+		}
+		return &on
+	}
+
+	attrs := postgres.RoleAttributes{
+		Replication: desired(databasesv1alpha1.RoleAttributeReplication),
+		BypassRLS:   desired(databasesv1alpha1.RoleAttributeBypassRLS),
+		CreateDB:    desired(databasesv1alpha1.RoleAttributeCreateDB),
+		CreateRole:  desired(databasesv1alpha1.RoleAttributeCreateRole),
+	}
+
+	validUntil := r.getValidUntil(user, passwordChanged)
+	if validUntil != nil {
+		attrs.ValidUntil = validUntil.UTC().Format(time.RFC3339)
+	} else if user.Status.ValidUntil != nil {
+		attrs.ValidUntil = "infinity"
+	}
+
+	return attrs, &appliedRoleAttributes{Enabled: enabled, ValidUntil: validUntil}
+}
+
+// ensureRoleAttributes applies role attributes with ALTER ROLE, revoking those the DBCluster does not
+// allow, and returns the state for status
+func (r *DatabaseUserReconciler) ensureRoleAttributes(ctx context.Context, pgClient postgres.ClientInterface,
+	user *databasesv1alpha1.DatabaseUser, cluster *databasesv1alpha1.DBCluster, username string,
+	passwordChanged bool) (*appliedRoleAttributes, error) {
+
+	denied := r.deniedRoleAttributes(user, cluster)
+	attrs, applied := r.buildRoleAttributes(user, denied, passwordChanged)
+	if err := pgClient.SetRoleAttributes(ctx, username, attrs); err != nil {
+		return nil, err
+	}
+	applied.PolicyHash = roleAttributesPolicyHash(cluster)
+
+	if len(user.Spec.RoleAttributes.Enabled()) > 0 {
+		applied.Condition = &metav1.Condition{
+			Type:               databasesv1alpha1.UserConditionRoleAttributesAllowed,
+			Status:             metav1.ConditionTrue,
+			Reason:             databasesv1alpha1.UserReasonAllowed,
+			ObservedGeneration: user.Generation,
+		}
+		if len(denied) > 0 {
+			applied.Condition.Status = metav1.ConditionFalse
+			applied.Condition.Reason = databasesv1alpha1.UserReasonNotAllowed
+			applied.Condition.Message = roleAttributesDeniedMessage(denied, cluster)
+		}
+	}
+	return applied, nil
+}
+
+func roleAttributesStatusChanged(status *databasesv1alpha1.DatabaseUserStatus, applied *appliedRoleAttributes) bool {
+	if applied == nil {
+		return false
+	}
+	if !slices.Equal(status.RoleAttributes, applied.Enabled) || status.RoleAttributesPolicyHash != applied.PolicyHash {
+		return true
+	}
+	current := meta.FindStatusCondition(status.Conditions, databasesv1alpha1.UserConditionRoleAttributesAllowed)
+	if (current == nil) != (applied.Condition == nil) || current != nil && (current.Status != applied.Condition.Status ||
+		current.Message != applied.Condition.Message || current.ObservedGeneration != applied.Condition.ObservedGeneration) {
+		return true
+	}
+	if status.ValidUntil == nil || applied.ValidUntil == nil {
+		return status.ValidUntil != applied.ValidUntil
+	}
+	return !status.ValidUntil.Equal(applied.ValidUntil)
+}
@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+func TestDatabaseUserReconciler_ValidateRoleAttributes(t *testing.T) {
+	validUntil := metav1.NewTime(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name        string
+		attrs       *databasesv1alpha1.RoleAttributes
+		rotation    *databasesv1alpha1.RotationConfig
+		expectError bool
+	}{
+		{name: "no attributes", attrs: nil},
+		{name: "replication", attrs: &databasesv1alpha1.RoleAttributes{Replication: true}},
+		{name: "fixed expiry", attrs: &databasesv1alpha1.RoleAttributes{ValidUntil: &validUntil}},
+		{
+			name:     "expiry from rotation",
+			attrs:    &databasesv1alpha1.RoleAttributes{ValidUntilRotation: true},
+			rotation: &databasesv1alpha1.RotationConfig{Days: 30},
+		},
+		{
+			name:        "expiry from rotation without rotation",
+			attrs:       &databasesv1alpha1.RoleAttributes{ValidUntilRotation: true},
+			expectError: true,
+		},
+		{
+			name:        "both expiry modes",
+			attrs:       &databasesv1alpha1.RoleAttributes{ValidUntil: &validUntil, ValidUntilRotation: true},
+			rotation:    &databasesv1alpha1.RotationConfig{Days: 30},
+			expectError: true,
+		},
+	}
+
+	r := &DatabaseUserReconciler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &databasesv1alpha1.DatabaseUser{
+				Spec: databasesv1alpha1.DatabaseUserSpec{RoleAttributes: tt.attrs, Rotation: tt.rotation},
+			}
+			err := r.validateRoleAttributes(user)
+			if (err != nil) != tt.expectError {
+				t.Errorf("validateRoleAttributes() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestDatabaseUserReconciler_DeniedRoleAttributes(t *testing.T) {
+	tests := []struct {
+		name    string
+		attrs   *databasesv1alpha1.RoleAttributes
+		allowed []string
+		want    []string
+	}{
+		{name: "nothing requested", attrs: nil},
+		{name: "only expiry needs no allow-list", attrs: &databasesv1alpha1.RoleAttributes{ValidUntilRotation: true}},
+		{
+			name:    "allowed",
+			attrs:   &databasesv1alpha1.RoleAttributes{Replication: true},
+			allowed: []string{"Replication"},
+		},
+		{
+			name:    "not allowed",
+			attrs:   &databasesv1alpha1.RoleAttributes{Replication: true, BypassRLS: true},
+			allowed: []string{"Replication"},
+			want:    []string{"BypassRLS"},
+		},
+		{
+			name:  "empty allow-list",
+			attrs: &databasesv1alpha1.RoleAttributes{CreateDB: true},
+			want:  []string{"CreateDB"},
+		},
+	}
+
+	r := &DatabaseUserReconciler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &databasesv1alpha1.DatabaseUser{Spec: databasesv1alpha1.DatabaseUserSpec{RoleAttributes: tt.attrs}}
+			cluster := &databasesv1alpha1.DBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "main"},
+				Spec:       databasesv1alpha1.DBClusterSpec{AllowedRoleAttributes: tt.allowed},
+			}
+			if got := r.deniedRoleAttributes(user, cluster); !slices.Equal(got, tt.want) {
+				t.Errorf("deniedRoleAttributes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDatabaseUserReconciler_EnsureRoleAttributesRevokesDenied(t *testing.T) {
+	ctx := context.Background()
+	r := &DatabaseUserReconciler{}
+	pgClient := postgres.NewMockClient()
+	pgClient.AddUser("cdc", "pw")
+
+	user := &databasesv1alpha1.DatabaseUser{
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			RoleAttributes: &databasesv1alpha1.RoleAttributes{Replication: true, CreateDB: true},
+		},
+	}
+	cluster := &databasesv1alpha1.DBCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "main"},
+		Spec:       databasesv1alpha1.DBClusterSpec{AllowedRoleAttributes: []string{"Replication", "CreateDB"}},
+	}
+
+	applied, err := r.ensureRoleAttributes(ctx, pgClient, user, cluster, "cdc", false)
+	if err != nil {
+		t.Fatalf("ensureRoleAttributes() error = %v", err)
+	}
+	if applied.Condition == nil || applied.Condition.Status != metav1.ConditionTrue {
+		t.Errorf("condition = %+v, want RoleAttributesAllowed True", applied.Condition)
+	}
+	user.Status.RoleAttributes = applied.Enabled
+
+	// The admin withdraws Replication: it is revoked, CreateDB stays
+	cluster.Spec.AllowedRoleAttributes = []string{"CreateDB"}
+	applied, err = r.ensureRoleAttributes(ctx, pgClient, user, cluster, "cdc", false)
+	if err != nil {
+		t.Fatalf("ensureRoleAttributes() error = %v", err)
+	}
+	attrs := pgClient.GetRoleAttributes("cdc")
+	if attrs.Replication == nil || *attrs.Replication {
+		t.Error("expected NOREPLICATION after the DBCluster stopped allowing it")
+	}
+	if attrs.CreateDB == nil || !*attrs.CreateDB {
+		t.Error("expected CREATEDB to stay granted")
+	}
+	if !slices.Equal(applied.Enabled, []string{"CreateDB"}) {
+		t.Errorf("applied.Enabled = %v, want [CreateDB]", applied.Enabled)
+	}
+	cond := applied.Condition
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != databasesv1alpha1.UserReasonNotAllowed {
+		t.Fatalf("condition = %+v, want RoleAttributesAllowed False", cond)
+	}
+	if want := "role attributes Replication not allowed by DBCluster main (spec.allowedRoleAttributes), revoked"; cond.Message != want {
+		t.Errorf("condition message = %q, want %q", cond.Message, want)
+	}
+	if !roleAttributesStatusChanged(&user.Status, applied) {
+		t.Error("status should change when attributes are revoked")
+	}
+
+	// Denied attributes are revoked even if the status never recorded them
+	pgClient.AddUser("fresh", "pw")
+	fresh := &databasesv1alpha1.DatabaseUser{
+		Spec: databasesv1alpha1.DatabaseUserSpec{RoleAttributes: &databasesv1alpha1.RoleAttributes{BypassRLS: true}},
+	}
+	if _, err := r.ensureRoleAttributes(ctx, pgClient, fresh, cluster, "fresh", false); err != nil {
+		t.Fatalf("ensureRoleAttributes() error = %v", err)
+	}
+	if attrs := pgClient.GetRoleAttributes("fresh"); attrs.BypassRLS == nil || *attrs.BypassRLS {
+		t.Error("expected NOBYPASSRLS for a denied attribute")
+	}
+}
+
+func TestDatabaseUserReconciler_GetValidUntil(t *testing.T) {
+	r := &DatabaseUserReconciler{}
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	user := &databasesv1alpha1.DatabaseUser{
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			Rotation:       &databasesv1alpha1.RotationConfig{Days: 30},
+			RoleAttributes: &databasesv1alpha1.RoleAttributes{ValidUntilRotation: true},
+		},
+		Status: databasesv1alpha1.DatabaseUserStatus{PasswordUpdatedAt: &metav1.Time{Time: updatedAt}},
+	}
+
+	got := r.getValidUntil(user, false)
+	want := updatedAt.Add(30*24*time.Hour + ValidUntilRotationGrace)
+	if got == nil || !got.Time.Equal(want) {
+		t.Errorf("getValidUntil() = %v, want %v", got, want)
+	}
+
+	// After a rotation the expiry moves with the new password
+	got = r.getValidUntil(user, true)
+	if got == nil || !got.After(time.Now().Add(30*24*time.Hour)) {
+		t.Errorf("getValidUntil() after rotation = %v, want about 31 days from now", got)
+	}
+
+	user.Spec.RoleAttributes = nil
+	if got := r.getValidUntil(user, false); got != nil {
+		t.Errorf("getValidUntil() without roleAttributes = %v, want nil", got)
+	}
+}
+
+func TestDatabaseUserReconciler_EnsureRoleAttributes(t *testing.T) {
+	ctx := context.Background()
+	r := &DatabaseUserReconciler{}
+	pgClient := postgres.NewMockClient()
+	pgClient.AddUser("cdc", "pw")
+
+	validUntil := metav1.NewTime(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	user := &databasesv1alpha1.DatabaseUser{
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			RoleAttributes: &databasesv1alpha1.RoleAttributes{Replication: true, ValidUntil: &validUntil},
+		},
+	}
+
+	cluster := &databasesv1alpha1.DBCluster{
+		Spec: databasesv1alpha1.DBClusterSpec{AllowedRoleAttributes: []string{"Replication"}},
+	}
+
+	applied, err := r.ensureRoleAttributes(ctx, pgClient, user, cluster, "cdc", false)
+	if err != nil {
+		t.Fatalf("ensureRoleAttributes() error = %v", err)
+	}
+	attrs := pgClient.GetRoleAttributes("cdc")
+	if attrs.Replication == nil || !*attrs.Replication {
+		t.Error("expected REPLICATION to be granted")
+	}
+	if attrs.BypassRLS != nil {
+		t.Error("BYPASSRLS should not be touched when never requested")
+	}
+	if attrs.ValidUntil != "2027-01-01T00:00:00Z" {
+		t.Errorf("ValidUntil = %q", attrs.ValidUntil)
+	}
+	if !roleAttributesStatusChanged(&user.Status, applied) {
+		t.Error("status should change after granting attributes")
+	}
+
+	// Record status, then remove roleAttributes from spec: previously granted attributes are revoked
+	user.Status.RoleAttributes = applied.Enabled
+	user.Status.ValidUntil = applied.ValidUntil
+	user.Status.RoleAttributesPolicyHash = applied.PolicyHash
+	meta.SetStatusCondition(&user.Status.Conditions, *applied.Condition)
+	if roleAttributesStatusChanged(&user.Status, applied) {
+		t.Error("status should not change when attributes are unchanged")
+	}
+	user.Spec.RoleAttributes = nil
+
+	applied, err = r.ensureRoleAttributes(ctx, pgClient, user, cluster, "cdc", false)
+	if err != nil {
+		t.Fatalf("ensureRoleAttributes() error = %v", err)
+	}
+	attrs = pgClient.GetRoleAttributes("cdc")
+	if attrs.Replication == nil || *attrs.Replication {
+		t.Error("expected NOREPLICATION after removing the attribute")
+	}
+	if attrs.ValidUntil != "infinity" {
+		t.Errorf("ValidUntil = %q, want infinity", attrs.ValidUntil)
+	}
+	if len(applied.Enabled) != 0 || applied.ValidUntil != nil {
+		t.Errorf("applied = %+v, want empty", applied)
+	}
+}
+
+func TestDatabaseUserReconciler_EnsureRoleAttributesUntouchedByDefault(t *testing.T) {
+	r := &DatabaseUserReconciler{}
+
+	// Users without roleAttributes must not need ALTER ROLE (REPLICATION may require superuser)
+	user := &databasesv1alpha1.DatabaseUser{}
+	attrs, _ := r.buildRoleAttributes(user, nil, false)
+	if !attrs.IsEmpty() {
+		t.Errorf("buildRoleAttributes() = %+v, want empty for users without roleAttributes", attrs.Options())
+	}
+}
+
+func TestDatabaseUserReconciler_NarrowedAllowListRevokesFromReadyUser(t *testing.T) {
+	ctx := context.Background()
+	pgClient := postgres.NewMockClient()
+
+	objects := newGrantTestObjects()
+	cluster := objects[0].(*databasesv1alpha1.DBCluster)
+	cluster.Spec.AllowedRoleAttributes = []string{"Replication"}
+	user := &databasesv1alpha1.DatabaseUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "cdc", Namespace: testGrantNamespace},
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			Database:       &databasesv1alpha1.DatabaseAccess{Name: "orders-db"},
+			RoleAttributes: &databasesv1alpha1.RoleAttributes{Replication: true},
+		},
+	}
+	r := newPasswordRefTestReconciler(pgClient, append(objects, user)...)
+
+	ready := reconcileTestUser(t, r, "cdc")
+	if ready.Status.Phase != "Ready" || !slices.Equal(ready.Status.RoleAttributes, []string{"Replication"}) {
+		t.Fatalf("status = %s %v (%s), want Ready with Replication", ready.Status.Phase,
+			ready.Status.RoleAttributes, ready.Status.Message)
+	}
+
+	// The DBCluster watch enqueues the user; the Ready early exit must not skip the revocation
+	var current databasesv1alpha1.DBCluster
+	if err := r.Get(ctx, types.NamespacedName{Name: "main"}, &current); err != nil {
+		t.Fatalf("failed to get cluster: %v", err)
+	}
+	current.Spec.AllowedRoleAttributes = nil
+	if err := r.Update(ctx, &current); err != nil {
+		t.Fatalf("failed to update cluster: %v", err)
+	}
+	if requests := r.findUsersForCluster(ctx, &current); !slices.ContainsFunc(requests, func(req reconcile.Request) bool {
+		return req.Name == "cdc"
+	}) {
+		t.Errorf("findUsersForCluster() = %v, want the cluster's users", requests)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cdc", Namespace: testGrantNamespace}}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if attrs := pgClient.GetRoleAttributes("cdc"); attrs.Replication == nil || *attrs.Replication {
+		t.Error("expected NOREPLICATION after the DBCluster stopped allowing it")
+	}
+
+	var revoked databasesv1alpha1.DatabaseUser
+	_ = r.Get(ctx, req.NamespacedName, &revoked)
+	if len(revoked.Status.RoleAttributes) != 0 {
+		t.Errorf("status.roleAttributes = %v, want none", revoked.Status.RoleAttributes)
+	}
+	cond := meta.FindStatusCondition(revoked.Status.Conditions, databasesv1alpha1.UserConditionRoleAttributesAllowed)
+	if cond == nil || cond.Status != metav1.ConditionFalse {
+		t.Errorf("condition = %+v, want RoleAttributesAllowed False", cond)
+	}
+	if !r.roleAttributesPolicyApplied(ctx, &revoked) {
+		t.Error("policy hash should match the narrowed allow-list after the reconcile")
+	}
+}
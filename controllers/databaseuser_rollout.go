@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+const (
+	// RestartedAtAnnotation is set on pod templates to trigger a rolling restart
+	RestartedAtAnnotation = "dbtether.io/restartedAt"
+	// SecretHashAnnotation carries the credentials content hash on the Secret and pod templates
+	SecretHashAnnotation = "dbtether.io/secret-hash"
+
+	rolloutStrategyRestart = "restart"
+	rolloutStrategyHash    = "hash"
+)
+
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;patch
+
+func (r *DatabaseUserReconciler) getRolloutStrategy(user *databasesv1alpha1.DatabaseUser) string {
+	if user.Spec.RolloutTargets != nil && user.Spec.RolloutTargets.Strategy != "" {
+		return user.Spec.RolloutTargets.Strategy
+	}
+	return rolloutStrategyRestart
+}
+
+// rolloutWorkloads patches the pod templates of the configured rolloutTargets so that
+// consumers pick up new credentials. Returns the workloads that were patched ("Kind/name").
+func (r *DatabaseUserReconciler) rolloutWorkloads(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
+	secretName string, passwordChanged bool) ([]string, error) {
+
+	if user.Spec.RolloutTargets == nil {
+		return nil, nil
+	}
+
+	var key, value string
+	switch r.getRolloutStrategy(user) {
+	case rolloutStrategyHash:
+		hash, err := r.ensureSecretHash(ctx, user.Namespace, secretName)
+		if err != nil {
+			return nil, err
+		}
+		key, value = SecretHashAnnotation, hash
+	default:
+		// A pending rollout reuses its timestamp, so retries do not restart workloads twice
+		if pending := user.Status.PendingRolloutAt; pending != nil {
+			key, value = RestartedAtAnnotation, pending.UTC().Format(time.RFC3339)
+			break
+		}
+		// First password for a new user - nothing is running with stale credentials yet
+		if !passwordChanged || user.Status.PasswordUpdatedAt == nil {
+			return nil, nil
+		}
+		key, value = RestartedAtAnnotation, time.Now().UTC().Format(time.RFC3339)
+	}
+
+	workloads, err := r.collectRolloutWorkloads(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := log.FromContext(ctx)
+	var patched []string
+	var errs []error
+	for _, obj := range workloads {
+		name := fmt.Sprintf("%s/%s", workloadKind(obj), obj.GetName())
+		changed, err := r.patchPodTemplateAnnotation(ctx, obj, key, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to patch %s: %w", name, err))
+			continue
+		}
+		if changed {
+			logger.Info("rolled out workload after credentials change", "workload", name)
+			patched = append(patched, name)
+		}
+	}
+
+	return patched, utilerrors.NewAggregate(errs)
+}
+
+// markRolloutPending records a password change in status before anything else can fail, so the
+// restart of rolloutTargets is retried on later reconciles until it succeeds
+func (r *DatabaseUserReconciler) markRolloutPending(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
+	passwordChanged bool) error {
+
+	if !passwordChanged || user.Spec.RolloutTargets == nil || r.getRolloutStrategy(user) != rolloutStrategyRestart ||
+		user.Status.PasswordUpdatedAt == nil {
+		return nil
+	}
+
+	patch := client.MergeFrom(user.DeepCopy())
+	now := metav1.Now()
+	user.Status.PendingRolloutAt = &now
+	if err := r.Status().Patch(ctx, user, patch); err != nil {
+		return fmt.Errorf("failed to record pending rollout: %w", err)
+	}
+	return nil
+}
+
+// collectRolloutWorkloads resolves the selector and explicit references into a de-duplicated list
+//
+//nolint:gocyclo // two workload kinds, selector and explicit references
+func (r *DatabaseUserReconciler) collectRolloutWorkloads(ctx context.Context,
+	user *databasesv1alpha1.DatabaseUser) ([]client.Object, error) {
+
+	targets := user.Spec.RolloutTargets
+	seen := make(map[string]bool)
+	var result []client.Object
+
+	add := func(obj client.Object) {
+		id := workloadKind(obj) + "/" + obj.GetName()
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, obj)
+		}
+	}
+
+	if targets.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(targets.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rolloutTargets selector: %w", err)
+		}
+		opts := []client.ListOption{client.InNamespace(user.Namespace), client.MatchingLabelsSelector{Selector: selector}}
+
+		var deployments appsv1.DeploymentList
+		if err := r.List(ctx, &deployments, opts...); err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+		for i := range deployments.Items {
+			add(&deployments.Items[i])
+		}
+
+		var statefulSets appsv1.StatefulSetList
+		if err := r.List(ctx, &statefulSets, opts...); err != nil {
+			return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+		}
+		for i := range statefulSets.Items {
+			add(&statefulSets.Items[i])
+		}
+	}
+
+	logger := log.FromContext(ctx)
+	for _, ref := range targets.Workloads {
+		var obj client.Object
+		switch ref.Kind {
+		case "Deployment":
+			obj = &appsv1.Deployment{}
+		case "StatefulSet":
+			obj = &appsv1.StatefulSet{}
+		default:
+			return nil, fmt.Errorf("unsupported workload kind '%s'", ref.Kind)
+		}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: user.Namespace}, obj); err != nil {
+			if errors.IsNotFound(err) {
+				logger.Info("rollout target not found, skipping", "kind", ref.Kind, "name", ref.Name)
+				continue
+			}
+			return nil, err
+		}
+		add(obj)
+	}
+
+	return result, nil
+}
+
+// patchPodTemplateAnnotation sets key=value on the workload's pod template, returns false if already set
+func (r *DatabaseUserReconciler) patchPodTemplateAnnotation(ctx context.Context, obj client.Object,
+	key, value string) (bool, error) {
+
+	template := podTemplateOf(obj)
+	if template == nil {
+		return false, fmt.Errorf("unsupported workload type %T", obj)
+	}
+	if template.Annotations[key] == value {
+		return false, nil
+	}
+
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[key] = value
+
+	if err := r.Patch(ctx, obj, patch); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ensureSecretHash computes the credentials hash and records it on the Secret
+func (r *DatabaseUserReconciler) ensureSecretHash(ctx context.Context, namespace, secretName string) (string, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, &secret); err != nil {
+		return "", fmt.Errorf("failed to get secret for hashing: %w", err)
+	}
+
+	hash := secretContentHash(&secret)
+	if secret.Annotations[SecretHashAnnotation] == hash {
+		return hash, nil
+	}
+
+	patch := client.MergeFrom(secret.DeepCopy())
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[SecretHashAnnotation] = hash
+	if err := r.Patch(ctx, &secret, patch); err != nil {
+		return "", fmt.Errorf("failed to annotate secret with hash: %w", err)
+	}
+	return hash, nil
+}
+
+// secretDataMap merges Data and StringData (StringData wins, as on the API server)
+func secretDataMap(secret *corev1.Secret) map[string][]byte {
+	data := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for k, v := range secret.Data {
+		data[k] = v
+	}
+	for k, v := range secret.StringData {
+		data[k] = []byte(v)
+	}
+	return data
+}
+
+// secretContentHash returns a stable SHA-256 over the secret's keys and values
+func secretContentHash(secret *corev1.Secret) string {
+	data := secretDataMap(secret)
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write(data[k])
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func podTemplateOf(obj client.Object) *corev1.PodTemplateSpec {
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		return &w.Spec.Template
+	case *appsv1.StatefulSet:
+		return &w.Spec.Template
+	}
+	return nil
+}
+
+func workloadKind(obj client.Object) string {
+	switch obj.(type) {
+	case *appsv1.Deployment:
+		return "Deployment"
+	case *appsv1.StatefulSet:
+		return "StatefulSet"
+	}
+	return "Unknown"
+}
@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newRolloutTestReconciler(objects ...runtime.Object) *DatabaseUserReconciler {
+	scheme := runtime.NewScheme()
+	_ = databasesv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+
+	return &DatabaseUserReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build(),
+		Scheme: scheme,
+	}
+}
+
+func newTestDeployment(name string, labels map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+	}
+}
+
+func TestDatabaseUserReconciler_RolloutWorkloads(t *testing.T) {
+	ctx := context.Background()
+	rotatedAt := metav1.Now()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+
+	tests := []struct {
+		name            string
+		targets         *databasesv1alpha1.RolloutTargets
+		passwordChanged bool
+		firstPassword   bool
+		wantPatched     []string
+		wantAnnotation  string
+	}{
+		{
+			name:            "no targets configured",
+			targets:         nil,
+			passwordChanged: true,
+			wantPatched:     nil,
+		},
+		{
+			name: "restart skipped when password unchanged",
+			targets: &databasesv1alpha1.RolloutTargets{
+				Workloads: []databasesv1alpha1.WorkloadReference{{Kind: "Deployment", Name: "api"}},
+			},
+			passwordChanged: false,
+			wantPatched:     nil,
+		},
+		{
+			name: "restart skipped for first password",
+			targets: &databasesv1alpha1.RolloutTargets{
+				Workloads: []databasesv1alpha1.WorkloadReference{{Kind: "Deployment", Name: "api"}},
+			},
+			passwordChanged: true,
+			firstPassword:   true,
+			wantPatched:     nil,
+		},
+		{
+			name: "restart explicit references",
+			targets: &databasesv1alpha1.RolloutTargets{
+				Workloads: []databasesv1alpha1.WorkloadReference{
+					{Kind: "Deployment", Name: "api"},
+					{Kind: "StatefulSet", Name: "worker"},
+					{Kind: "Deployment", Name: "missing"},
+				},
+			},
+			passwordChanged: true,
+			wantPatched:     []string{"Deployment/api", "StatefulSet/worker"},
+			wantAnnotation:  RestartedAtAnnotation,
+		},
+		{
+			name: "restart by selector deduplicates explicit references",
+			targets: &databasesv1alpha1.RolloutTargets{
+				Selector:  &metav1.LabelSelector{MatchLabels: map[string]string{"uses-db": "orders"}},
+				Workloads: []databasesv1alpha1.WorkloadReference{{Kind: "Deployment", Name: "api"}},
+			},
+			passwordChanged: true,
+			wantPatched:     []string{"Deployment/api"},
+			wantAnnotation:  RestartedAtAnnotation,
+		},
+		{
+			name: "hash strategy stamps hash even without password change",
+			targets: &databasesv1alpha1.RolloutTargets{
+				Strategy:  "hash",
+				Workloads: []databasesv1alpha1.WorkloadReference{{Kind: "Deployment", Name: "api"}},
+			},
+			passwordChanged: false,
+			wantPatched:     []string{"Deployment/api"},
+			wantAnnotation:  SecretHashAnnotation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRolloutTestReconciler(
+				secret.DeepCopy(),
+				newTestDeployment("api", map[string]string{"uses-db": "orders"}),
+				newTestDeployment("other", nil),
+				&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "default"}},
+			)
+
+			user := &databasesv1alpha1.DatabaseUser{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+				Spec:       databasesv1alpha1.DatabaseUserSpec{RolloutTargets: tt.targets},
+			}
+			if !tt.firstPassword {
+				user.Status.PasswordUpdatedAt = &rotatedAt
+			}
+
+			patched, err := r.rolloutWorkloads(ctx, user, "app-credentials", tt.passwordChanged)
+			if err != nil {
+				t.Fatalf("rolloutWorkloads() error = %v", err)
+			}
+			if len(patched) != len(tt.wantPatched) {
+				t.Fatalf("rolloutWorkloads() patched = %v, want %v", patched, tt.wantPatched)
+			}
+			for i := range patched {
+				if patched[i] != tt.wantPatched[i] {
+					t.Errorf("patched[%d] = %s, want %s", i, patched[i], tt.wantPatched[i])
+				}
+			}
+
+			if tt.wantAnnotation == "" {
+				return
+			}
+			var deploy appsv1.Deployment
+			if err := r.Get(ctx, types.NamespacedName{Name: "api", Namespace: "default"}, &deploy); err != nil {
+				t.Fatalf("failed to get deployment: %v", err)
+			}
+			if deploy.Spec.Template.Annotations[tt.wantAnnotation] == "" {
+				t.Errorf("expected pod template annotation %s to be set", tt.wantAnnotation)
+			}
+
+			var other appsv1.Deployment
+			_ = r.Get(ctx, types.NamespacedName{Name: "other", Namespace: "default"}, &other)
+			if len(other.Spec.Template.Annotations) != 0 {
+				t.Errorf("unselected deployment should not be patched, got %v", other.Spec.Template.Annotations)
+			}
+		})
+	}
+}
+
+func TestDatabaseUserReconciler_RolloutHashIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	r := newRolloutTestReconciler(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-credentials", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("v1")},
+		},
+		newTestDeployment("api", nil),
+	)
+	user := &databasesv1alpha1.DatabaseUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			RolloutTargets: &databasesv1alpha1.RolloutTargets{
+				Strategy:  "hash",
+				Workloads: []databasesv1alpha1.WorkloadReference{{Kind: "Deployment", Name: "api"}},
+			},
+		},
+	}
+
+	patched, err := r.rolloutWorkloads(ctx, user, "app-credentials", true)
+	if err != nil || len(patched) != 1 {
+		t.Fatalf("first rollout: patched = %v, err = %v", patched, err)
+	}
+
+	patched, err = r.rolloutWorkloads(ctx, user, "app-credentials", false)
+	if err != nil || len(patched) != 0 {
+		t.Fatalf("second rollout should be a no-op: patched = %v, err = %v", patched, err)
+	}
+
+	var secret corev1.Secret
+	_ = r.Get(ctx, types.NamespacedName{Name: "app-credentials", Namespace: "default"}, &secret)
+	secret.Data["password"] = []byte("v2")
+	if err := r.Update(ctx, &secret); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+
+	patched, err = r.rolloutWorkloads(ctx, user, "app-credentials", true)
+	if err != nil || len(patched) != 1 {
+		t.Fatalf("rollout after content change: patched = %v, err = %v", patched, err)
+	}
+
+	var deploy appsv1.Deployment
+	_ = r.Get(ctx, types.NamespacedName{Name: "api", Namespace: "default"}, &deploy)
+	_ = r.Get(ctx, types.NamespacedName{Name: "app-credentials", Namespace: "default"}, &secret)
+	if deploy.Spec.Template.Annotations[SecretHashAnnotation] != secret.Annotations[SecretHashAnnotation] {
+		t.Errorf("pod template hash %q does not match secret hash %q",
+			deploy.Spec.Template.Annotations[SecretHashAnnotation], secret.Annotations[SecretHashAnnotation])
+	}
+}
+
+func TestDatabaseUserReconciler_PendingRolloutIsRetried(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = databasesv1alpha1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+
+	rotatedAt := metav1.Now()
+	user := &databasesv1alpha1.DatabaseUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			RolloutTargets: &databasesv1alpha1.RolloutTargets{
+				Workloads: []databasesv1alpha1.WorkloadReference{{Kind: "Deployment", Name: "api"}},
+			},
+		},
+		Status: databasesv1alpha1.DatabaseUserStatus{PasswordUpdatedAt: &rotatedAt},
+	}
+	r := &DatabaseUserReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(user, newTestDeployment("api", nil)).
+			WithStatusSubresource(user).Build(),
+		Scheme: scheme,
+	}
+
+	if err := r.markRolloutPending(ctx, user, true); err != nil {
+		t.Fatalf("markRolloutPending() error = %v", err)
+	}
+	var stored databasesv1alpha1.DatabaseUser
+	_ = r.Get(ctx, types.NamespacedName{Name: "app", Namespace: "default"}, &stored)
+	if stored.Status.PendingRolloutAt == nil {
+		t.Fatal("expected pendingRolloutAt to be recorded")
+	}
+
+	// The reconcile that rotated the password failed before the rollout, the next one
+	// sees no password change but must still restart the workloads
+	patched, err := r.rolloutWorkloads(ctx, &stored, "app-credentials", false)
+	if err != nil || len(patched) != 1 {
+		t.Fatalf("pending rollout: patched = %v, err = %v", patched, err)
+	}
+
+	var deploy appsv1.Deployment
+	_ = r.Get(ctx, types.NamespacedName{Name: "api", Namespace: "default"}, &deploy)
+	want := stored.Status.PendingRolloutAt.UTC().Format(time.RFC3339)
+	if got := deploy.Spec.Template.Annotations[RestartedAtAnnotation]; got != want {
+		t.Errorf("restartedAt = %q, want pending timestamp %q", got, want)
+	}
+
+	patched, err = r.rolloutWorkloads(ctx, &stored, "app-credentials", false)
+	if err != nil || len(patched) != 0 {
+		t.Fatalf("retry of an applied rollout should be a no-op: patched = %v, err = %v", patched, err)
+	}
+}
+
+func TestSecretContentHash(t *testing.T) {
+	a := &corev1.Secret{Data: map[string][]byte{"user": []byte("app"), "password": []byte("x")}}
+	b := &corev1.Secret{Data: map[string][]byte{"password": []byte("x"), "user": []byte("app")}}
+	c := &corev1.Secret{Data: map[string][]byte{"user": []byte("app"), "password": []byte("y")}}
+	d := &corev1.Secret{StringData: map[string]string{"user": "app", "password": "x"}}
+
+	if secretContentHash(a) != secretContentHash(b) {
+		t.Error("hash should not depend on key order")
+	}
+	if secretContentHash(a) == secretContentHash(c) {
+		t.Error("hash should change when a value changes")
+	}
+	if secretContentHash(a) != secretContentHash(d) {
+		t.Error("hash should treat StringData like Data")
+	}
+}
@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/secretstore"
+)
+
+// usesExternalSecretStore returns true if credentials are copied to a store other than Kubernetes
+func (r *DatabaseUserReconciler) usesExternalSecretStore(user *databasesv1alpha1.DatabaseUser) bool {
+	cfg := user.Spec.SecretStore
+	return cfg != nil && cfg.Type != "" && cfg.Type != secretstore.TypeKubernetes
+}
+
+func (r *DatabaseUserReconciler) validateSecretStore(user *databasesv1alpha1.DatabaseUser) error {
+	if !r.usesExternalSecretStore(user) {
+		return nil
+	}
+	cfg := user.Spec.SecretStore
+	switch cfg.Type {
+	case secretstore.TypeVault:
+		if cfg.Vault == nil {
+			return fmt.Errorf("secretStore.vault is required for type 'vault'")
+		}
+		if r.SecretStores.Vault == nil {
+			return fmt.Errorf("secret store 'vault' is not configured in the operator")
+		}
+	case secretstore.TypeAWSSecretsManager:
+		if cfg.AWS == nil {
+			return fmt.Errorf("secretStore.aws is required for type 'aws-secretsmanager'")
+		}
+		if r.SecretStores.AWS == nil {
+			return fmt.Errorf("secret store 'aws-secretsmanager' is not configured in the operator")
+		}
+	case secretstore.TypeExternalSecrets:
+		if cfg.ExternalSecrets == nil {
+			return fmt.Errorf("secretStore.externalSecrets is required for type 'external-secrets'")
+		}
+		return nil
+	}
+	_, _, err := r.getSecretStoreTarget(user)
+	return err
+}
+
+// getSecretStoreTarget returns the secret name inside the store, scoped below the operator's prefix and
+// the user's namespace, and a display location for status
+func (r *DatabaseUserReconciler) getSecretStoreTarget(user *databasesv1alpha1.DatabaseUser) (name, location string, err error) {
+	cfg := user.Spec.SecretStore
+	switch cfg.Type {
+	case secretstore.TypeVault:
+		settings := r.SecretStores.Vault
+		if name, err = secretstore.ScopedName(settings.PathPrefix, user.Namespace, cfg.Vault.Path); err != nil {
+			return "", "", fmt.Errorf("invalid secretStore.vault.path: %w", err)
+		}
+		mount := settings.Mount
+		if mount == "" {
+			mount = "secret"
+		}
+		return name, fmt.Sprintf("vault:%s/%s", mount, name), nil
+	case secretstore.TypeAWSSecretsManager:
+		if name, err = secretstore.ScopedName(r.SecretStores.AWS.NamePrefix, user.Namespace, cfg.AWS.SecretName); err != nil {
+			return "", "", fmt.Errorf("invalid secretStore.aws.secretName: %w", err)
+		}
+		return name, fmt.Sprintf("aws-secretsmanager:%s", name), nil
+	}
+	return "", "", nil
+}
+
+// newSecretStore returns the client of storeType built from the operator's configuration. Clients
+// are cached per type (and AWS recovery window), so reconciles share one client and its credentials.
+func (r *DatabaseUserReconciler) newSecretStore(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
+	storeType string) (secretstore.Store, error) {
+
+	key := storeType
+	cfg := &secretstore.Config{Type: storeType}
+	switch storeType {
+	case secretstore.TypeVault:
+		if r.SecretStores.Vault == nil {
+			return nil, fmt.Errorf("secret store 'vault' is not configured in the operator")
+		}
+		vault := *r.SecretStores.Vault
+		cfg.Vault = &vault
+	case secretstore.TypeAWSSecretsManager:
+		if r.SecretStores.AWS == nil {
+			return nil, fmt.Errorf("secret store 'aws-secretsmanager' is not configured in the operator")
+		}
+		aws := *r.SecretStores.AWS
+		if spec := user.Spec.SecretStore; spec != nil && spec.AWS != nil {
+			aws.RecoveryWindowDays = spec.AWS.RecoveryWindowDays
+		}
+		cfg.AWS = &aws
+		key = fmt.Sprintf("%s/%d", storeType, aws.RecoveryWindowDays)
+	}
+
+	r.storesMu.Lock()
+	defer r.storesMu.Unlock()
+	if store, ok := r.stores[key]; ok {
+		return store, nil
+	}
+
+	factory := r.SecretStoreFactory
+	if factory == nil {
+		factory = secretstore.NewStore
+	}
+	store, err := factory(ctx, cfg)
+	if err != nil || store == nil {
+		return store, err
+	}
+	if r.stores == nil {
+		r.stores = make(map[string]secretstore.Store)
+	}
+	r.stores[key] = store
+	return store, nil
+}
+
+// syncSecretStore writes the credentials secret to the external store when its content changed
+// since the last sync (creation, rotation, template or database changes). A previous location
+// (changed path, secret name or store type) is deleted once the new one is written.
+func (r *DatabaseUserReconciler) syncSecretStore(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
+	secretName string, passwordChanged bool) (*databasesv1alpha1.SecretStoreStatus, error) {
+
+	// Clean up the PushSecret if the user switched away from external-secrets
+	if prev := user.Status.SecretStore; prev != nil && prev.Type == secretstore.TypeExternalSecrets &&
+		(user.Spec.SecretStore == nil || user.Spec.SecretStore.Type != secretstore.TypeExternalSecrets) {
+		r.deletePushSecret(ctx, user)
+	}
+
+	if !r.usesExternalSecretStore(user) {
+		return nil, r.deletePreviousSecretStoreLocation(ctx, user, "")
+	}
+	if user.Spec.SecretStore.Type == secretstore.TypeExternalSecrets {
+		status, err := r.syncPushSecret(ctx, user, secretName)
+		if err != nil {
+			return nil, err
+		}
+		return status, r.deletePreviousSecretStoreLocation(ctx, user, "")
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: user.Namespace}, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get credentials secret: %w", err)
+	}
+
+	name, location, err := r.getSecretStoreTarget(user)
+	if err != nil {
+		return nil, err
+	}
+	hash := secretContentHash(&secret)
+	current := user.Status.SecretStore
+	if !passwordChanged && current != nil && current.ContentHash == hash && current.Location == location {
+		return current, nil
+	}
+
+	store, err := r.newSecretStore(ctx, user, user.Spec.SecretStore.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]string)
+	for k, v := range secretDataMap(&secret) {
+		data[k] = string(v)
+	}
+	if err := store.Put(ctx, name, data); err != nil {
+		return nil, err
+	}
+	log.FromContext(ctx).Info("credentials written to secret store", "location", location)
+
+	if err := r.deletePreviousSecretStoreLocation(ctx, user, location); err != nil {
+		return nil, err
+	}
+
+	now := metav1.Now()
+	return &databasesv1alpha1.SecretStoreStatus{
+		Type:         user.Spec.SecretStore.Type,
+		Location:     location,
+		ContentHash:  hash,
+		Phase:        secretStorePhaseSynced,
+		LastSyncedAt: &now,
+	}, nil
+}
+
+// deletePreviousSecretStoreLocation removes the Vault/AWS secret recorded in status when it is not
+// the current location, so moved credentials are not left behind in the store
+func (r *DatabaseUserReconciler) deletePreviousSecretStoreLocation(ctx context.Context,
+	user *databasesv1alpha1.DatabaseUser, location string) error {
+
+	prev := user.Status.SecretStore
+	if prev == nil || prev.Location == "" || prev.Location == location {
+		return nil
+	}
+	name, ok := r.parseSecretStoreLocation(prev)
+	if !ok {
+		return nil
+	}
+	if err := r.deleteStoredSecret(ctx, user, prev.Type, name, prev.Location); err != nil {
+		return fmt.Errorf("failed to delete previous location %s: %w", prev.Location, err)
+	}
+	return nil
+}
+
+// parseSecretStoreLocation returns the secret name inside the store from a status location,
+// false for PushSecrets and locations the operator's configuration no longer covers
+func (r *DatabaseUserReconciler) parseSecretStoreLocation(status *databasesv1alpha1.SecretStoreStatus) (string, bool) {
+	var prefix string
+	switch status.Type {
+	case secretstore.TypeVault:
+		if r.SecretStores.Vault == nil {
+			return "", false
+		}
+		mount := r.SecretStores.Vault.Mount
+		if mount == "" {
+			mount = "secret"
+		}
+		prefix = fmt.Sprintf("vault:%s/", mount)
+	case secretstore.TypeAWSSecretsManager:
+		if r.SecretStores.AWS == nil {
+			return "", false
+		}
+		prefix = "aws-secretsmanager:"
+	default:
+		return "", false
+	}
+	name, found := strings.CutPrefix(status.Location, prefix)
+	return name, found && name != ""
+}
+
+// deleteStoredSecret deletes one secret from the store; secrets not created by dbtether are left in place
+func (r *DatabaseUserReconciler) deleteStoredSecret(ctx context.Context, user *databasesv1alpha1.DatabaseUser,
+	storeType, name, location string) error {
+
+	logger := log.FromContext(ctx)
+	store, err := r.newSecretStore(ctx, user, storeType)
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(ctx, name); errors.Is(err, secretstore.ErrNotManaged) {
+		logger.Info("secret in store was not created by dbtether, leaving it in place", "location", location)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	logger.Info("credentials deleted from secret store", "location", location)
+	return nil
+}
+
+// deleteFromSecretStore removes the credentials from the external store on user deletion, including
+// a previous location that was not cleaned up yet. Errors keep the finalizer so the delete is retried.
+// PushSecrets are garbage collected through their owner reference, ESO handles the remote side.
+func (r *DatabaseUserReconciler) deleteFromSecretStore(ctx context.Context, user *databasesv1alpha1.DatabaseUser) error {
+	location := ""
+	if r.usesExternalSecretStore(user) && r.validateSecretStore(user) == nil &&
+		user.Spec.SecretStore.Type != secretstore.TypeExternalSecrets {
+
+		var name string
+		name, location, _ = r.getSecretStoreTarget(user)
+		if err := r.deleteStoredSecret(ctx, user, user.Spec.SecretStore.Type, name, location); err != nil {
+			return fmt.Errorf("failed to delete credentials from %s: %w", location, err)
+		}
+	}
+	return r.deletePreviousSecretStoreLocation(ctx, user, location)
+}
+
+func secretStoreStatusChanged(current, updated *databasesv1alpha1.SecretStoreStatus) bool {
+	if updated == nil {
+		return false
+	}
+	return current == nil || current.ContentHash != updated.ContentHash || current.Location != updated.Location ||
+		current.Phase != updated.Phase || current.Message != updated.Message
+}
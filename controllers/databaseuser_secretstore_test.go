@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/secretstore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newSecretStoreTestUser(store *databasesv1alpha1.SecretStoreConfig) *databasesv1alpha1.DatabaseUser {
+	return &databasesv1alpha1.DatabaseUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			Database:    &databasesv1alpha1.DatabaseAccess{Name: "orders"},
+			SecretStore: store,
+		},
+	}
+}
+
+// testSecretStores is the operator's secret store configuration in tests
+var testSecretStores = secretstore.Settings{
+	Vault: &secretstore.VaultConfig{Address: "https://vault:8200", KubernetesRole: "dbtether", PathPrefix: "k8s"},
+	AWS:   &secretstore.AWSConfig{Region: "eu-west-1", NamePrefix: "/dbtether"},
+}
+
+func TestDatabaseUserReconciler_ValidateSecretStore(t *testing.T) {
+	r := &DatabaseUserReconciler{SecretStores: testSecretStores}
+
+	tests := []struct {
+		name     string
+		store    *databasesv1alpha1.SecretStoreConfig
+		settings *secretstore.Settings
+		wantErr  bool
+	}{
+		{name: "not configured"},
+		{name: "kubernetes", store: &databasesv1alpha1.SecretStoreConfig{Type: "kubernetes"}},
+		{name: "vault without section", store: &databasesv1alpha1.SecretStoreConfig{Type: "vault"}, wantErr: true},
+		{
+			name: "vault",
+			store: &databasesv1alpha1.SecretStoreConfig{
+				Type: "vault", Vault: &databasesv1alpha1.VaultSecretStore{Path: "app/db"},
+			},
+		},
+		{
+			name: "vault path leaving the namespace",
+			store: &databasesv1alpha1.SecretStoreConfig{
+				Type: "vault", Vault: &databasesv1alpha1.VaultSecretStore{Path: "../other-team/db"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "vault not configured in the operator",
+			store: &databasesv1alpha1.SecretStoreConfig{
+				Type: "vault", Vault: &databasesv1alpha1.VaultSecretStore{Path: "app/db"},
+			},
+			settings: &secretstore.Settings{},
+			wantErr:  true,
+		},
+		{name: "aws without section", store: &databasesv1alpha1.SecretStoreConfig{Type: "aws-secretsmanager"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.SecretStores = testSecretStores
+			if tt.settings != nil {
+				r.SecretStores = *tt.settings
+			}
+			err := r.validateSpec(newSecretStoreTestUser(tt.store))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatabaseUserReconciler_SyncSecretStore(t *testing.T) {
+	ctx := context.Background()
+
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"user": []byte("app"), "password": []byte("pw1")},
+	}
+	storeCfg := &databasesv1alpha1.SecretStoreConfig{
+		Type:  "vault",
+		Vault: &databasesv1alpha1.VaultSecretStore{Path: "apps/orders"},
+	}
+
+	mock := secretstore.NewMockStore()
+	var gotCfg *secretstore.Config
+	r := newTestReconciler(credentials)
+	r.SecretStores = testSecretStores
+	r.SecretStoreFactory = func(_ context.Context, cfg *secretstore.Config) (secretstore.Store, error) {
+		gotCfg = cfg
+		return mock, nil
+	}
+
+	user := newSecretStoreTestUser(storeCfg)
+
+	status, err := r.syncSecretStore(ctx, user, "app-credentials", true)
+	if err != nil {
+		t.Fatalf("syncSecretStore() error = %v", err)
+	}
+	if gotCfg.Vault.Address != "https://vault:8200" || gotCfg.Vault.KubernetesRole != "dbtether" {
+		t.Errorf("vault config = %+v, want the operator's configuration", gotCfg.Vault)
+	}
+	data, ok := mock.Get("k8s/default/apps/orders")
+	if !ok || data["password"] != "pw1" {
+		t.Fatalf("store data = %v, want password pw1 below the prefix and namespace", data)
+	}
+	if status.Location != "vault:secret/k8s/default/apps/orders" || status.ContentHash == "" || status.LastSyncedAt == nil {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// Unchanged content is not written again
+	user.Status.SecretStore = status
+	if _, err := r.syncSecretStore(ctx, user, "app-credentials", false); err != nil {
+		t.Fatalf("syncSecretStore() error = %v", err)
+	}
+	if mock.PutCount != 1 {
+		t.Errorf("PutCount = %d, want 1", mock.PutCount)
+	}
+
+	// Store failures are reported
+	mock.PutError = errors.New("vault sealed")
+	if _, err := r.syncSecretStore(ctx, user, "app-credentials", true); err == nil {
+		t.Error("syncSecretStore() should return store errors")
+	}
+
+	// Secrets not created by dbtether are not overwritten
+	mock.PutError = nil
+	mock.PutForeign("k8s/default/apps/orders", map[string]string{"key": "original"})
+	if _, err := r.syncSecretStore(ctx, user, "app-credentials", true); !errors.Is(err, secretstore.ErrNotManaged) {
+		t.Errorf("syncSecretStore() error = %v, want ErrNotManaged", err)
+	}
+
+	// Kubernetes type never calls the factory
+	if status, err := r.syncSecretStore(ctx, newSecretStoreTestUser(nil), "app-credentials", true); err != nil || status != nil {
+		t.Errorf("syncSecretStore() without store = %v, %v", status, err)
+	}
+}
+
+func TestDatabaseUserReconciler_DeleteFromSecretStore(t *testing.T) {
+	ctx := context.Background()
+
+	mock := secretstore.NewMockStore()
+	_ = mock.Put(ctx, "/dbtether/default/myapp/db", map[string]string{"password": "pw"})
+	mock.PutForeign("/dbtether/default/shared", map[string]string{"password": "pw"})
+
+	var gotCfg *secretstore.Config
+	r := newTestReconciler()
+	r.SecretStores = testSecretStores
+	r.SecretStoreFactory = func(_ context.Context, cfg *secretstore.Config) (secretstore.Store, error) {
+		gotCfg = cfg
+		return mock, nil
+	}
+
+	user := newSecretStoreTestUser(&databasesv1alpha1.SecretStoreConfig{
+		Type: "aws-secretsmanager",
+		AWS:  &databasesv1alpha1.AWSSecretStore{SecretName: "myapp/db", RecoveryWindowDays: 14},
+	})
+	if err := r.deleteFromSecretStore(ctx, user); err != nil {
+		t.Fatalf("deleteFromSecretStore() error = %v", err)
+	}
+
+	if _, ok := mock.Get("/dbtether/default/myapp/db"); ok {
+		t.Error("secret should be deleted from store")
+	}
+	if gotCfg.AWS.Region != "eu-west-1" || gotCfg.AWS.RecoveryWindowDays != 14 {
+		t.Errorf("aws config = %+v, want operator region and the user's recovery window", gotCfg.AWS)
+	}
+
+	// A secret not created by dbtether is left in place
+	user.Spec.SecretStore.AWS.SecretName = "shared"
+	if err := r.deleteFromSecretStore(ctx, user); err != nil {
+		t.Fatalf("deleteFromSecretStore() error = %v", err)
+	}
+	if _, ok := mock.Get("/dbtether/default/shared"); !ok {
+		t.Error("foreign secret should not be deleted")
+	}
+
+	// Store errors are returned so the finalizer is kept
+	mock.DeleteError = errors.New("access denied")
+	user.Spec.SecretStore.AWS.SecretName = "myapp/db"
+	if err := r.deleteFromSecretStore(ctx, user); err == nil {
+		t.Error("deleteFromSecretStore() should return store errors")
+	}
+}
+
+func TestDatabaseUserReconciler_SecretStoreClientReused(t *testing.T) {
+	ctx := context.Background()
+
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"user": []byte("app"), "password": []byte("pw1")},
+	}
+	var calls int
+	factoryErr := errors.New("vault unreachable")
+	r := newTestReconciler(credentials)
+	r.SecretStores = testSecretStores
+	r.SecretStoreFactory = func(_ context.Context, _ *secretstore.Config) (secretstore.Store, error) {
+		calls++
+		if factoryErr != nil {
+			return nil, factoryErr
+		}
+		return secretstore.NewMockStore(), nil
+	}
+
+	user := newSecretStoreTestUser(&databasesv1alpha1.SecretStoreConfig{
+		Type:  "vault",
+		Vault: &databasesv1alpha1.VaultSecretStore{Path: "apps/orders"},
+	})
+
+	// A failed client is not cached
+	if _, err := r.syncSecretStore(ctx, user, "app-credentials", true); !errors.Is(err, factoryErr) {
+		t.Fatalf("syncSecretStore() error = %v, want the factory error", err)
+	}
+	factoryErr = nil
+	for range 3 {
+		if _, err := r.syncSecretStore(ctx, user, "app-credentials", true); err != nil {
+			t.Fatalf("syncSecretStore() error = %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("factory calls = %d, want 2 (one failure, then one cached client)", calls)
+	}
+
+	// AWS clients differ by the user's recovery window
+	for _, days := range []int{7, 14, 7} {
+		aws := newSecretStoreTestUser(&databasesv1alpha1.SecretStoreConfig{
+			Type: "aws-secretsmanager",
+			AWS:  &databasesv1alpha1.AWSSecretStore{SecretName: "myapp/db", RecoveryWindowDays: days},
+		})
+		if err := r.deleteFromSecretStore(ctx, aws); err != nil {
+			t.Fatalf("deleteFromSecretStore() error = %v", err)
+		}
+	}
+	if calls != 4 {
+		t.Errorf("factory calls = %d, want 4 (one per recovery window)", calls)
+	}
+}
+
+func TestDatabaseUserReconciler_SyncSecretStoreDeletesPreviousLocation(t *testing.T) {
+	ctx := context.Background()
+
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"user": []byte("app"), "password": []byte("pw1")},
+	}
+	mock := secretstore.NewMockStore()
+	r := newTestReconciler(credentials)
+	r.SecretStores = testSecretStores
+	r.SecretStoreFactory = func(_ context.Context, _ *secretstore.Config) (secretstore.Store, error) {
+		return mock, nil
+	}
+
+	user := newSecretStoreTestUser(&databasesv1alpha1.SecretStoreConfig{
+		Type:  "vault",
+		Vault: &databasesv1alpha1.VaultSecretStore{Path: "apps/orders"},
+	})
+	status, err := r.syncSecretStore(ctx, user, "app-credentials", true)
+	if err != nil {
+		t.Fatalf("syncSecretStore() error = %v", err)
+	}
+	user.Status.SecretStore = status
+
+	// Moving the path writes the new location before deleting the old one
+	user.Spec.SecretStore.Vault.Path = "apps/orders-v2"
+	status, err = r.syncSecretStore(ctx, user, "app-credentials", false)
+	if err != nil {
+		t.Fatalf("syncSecretStore() after path change error = %v", err)
+	}
+	if _, ok := mock.Get("k8s/default/apps/orders"); ok {
+		t.Error("previous vault location should be deleted")
+	}
+	if _, ok := mock.Get("k8s/default/apps/orders-v2"); !ok {
+		t.Error("new vault location should be written")
+	}
+	user.Status.SecretStore = status
+
+	// Switching to AWS removes the Vault secret; a failed delete is reported and retried
+	user.Spec.SecretStore = &databasesv1alpha1.SecretStoreConfig{
+		Type: "aws-secretsmanager",
+		AWS:  &databasesv1alpha1.AWSSecretStore{SecretName: "orders"},
+	}
+	mock.DeleteError = errors.New("permission denied")
+	if _, err := r.syncSecretStore(ctx, user, "app-credentials", false); err == nil {
+		t.Fatal("syncSecretStore() should fail while the previous location cannot be deleted")
+	}
+	mock.DeleteError = nil
+	status, err = r.syncSecretStore(ctx, user, "app-credentials", false)
+	if err != nil || status.Location != "aws-secretsmanager:/dbtether/default/orders" {
+		t.Fatalf("syncSecretStore() after store type change = %+v, %v", status, err)
+	}
+	if _, ok := mock.Get("k8s/default/apps/orders-v2"); ok {
+		t.Error("vault secret should be deleted after switching to aws")
+	}
+	user.Status.SecretStore = status
+
+	// Removing the store deletes the last location
+	user.Spec.SecretStore = nil
+	if _, err := r.syncSecretStore(ctx, user, "app-credentials", false); err != nil {
+		t.Fatalf("syncSecretStore() after removing the store error = %v", err)
+	}
+	if _, ok := mock.Get("/dbtether/default/orders"); ok {
+		t.Error("aws secret should be deleted after removing secretStore")
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/notify"
 	"github.com/certainty3452/dbtether/pkg/postgres"
 )
 
@@ -25,6 +26,9 @@ type DBClusterReconciler struct {
 	client.Client
 	Scheme        *runtime.Scheme
 	PGClientCache postgres.ClientCacheInterface
+
+	// Notifier receives ClusterDisconnected and ClusterConnected events, nil disables notifications
+	Notifier notify.Notifier
 }
 
 // +kubebuilder:rbac:groups=dbtether.io,resources=dbclusters,verbs=get;list;watch;create;update;patch;delete
@@ -58,6 +62,13 @@ func (r *DBClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		Username: username,
 		Password: password,
 		Database: "postgres",
+
+		ServerSidePasswordEncryption: cluster.Spec.PasswordEncryption.ServerSide(),
+	}
+
+	// Cached clients keep the config they were created with, reconnect after spec changes
+	if cluster.Status.ObservedGeneration != 0 && cluster.Status.ObservedGeneration != cluster.Generation {
+		r.PGClientCache.Remove(cluster.Name)
 	}
 
 	pgClient, err := r.PGClientCache.Get(ctx, cluster.Name, pgConfig)
@@ -74,6 +85,10 @@ func (r *DBClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	version, _ := pgClient.GetVersion(ctx)
 
+	if err := r.checkPasswordEncryption(ctx, &cluster, pgClient); err != nil {
+		return r.updateStatus(ctx, &cluster, "Failed", err.Error(), version)
+	}
+
 	wasConnected := cluster.Status.Phase == "Connected"
 	if _, err := r.updateStatus(ctx, &cluster, "Connected", "connection successful", version); err != nil {
 		return ctrl.Result{}, err
@@ -86,27 +101,49 @@ func (r *DBClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	return ctrl.Result{RequeueAfter: HealthCheckInterval}, nil
 }
 
+// checkPasswordEncryption enforces passwordEncryption.requireSCRAM
+func (r *DBClusterReconciler) checkPasswordEncryption(ctx context.Context, cluster *databasesv1alpha1.DBCluster,
+	pgClient postgres.ClientInterface) error {
+
+	if cluster.Spec.PasswordEncryption == nil || !cluster.Spec.PasswordEncryption.RequireSCRAM {
+		return nil
+	}
+	encryption, err := pgClient.GetPasswordEncryption(ctx)
+	if err != nil {
+		return err
+	}
+	if encryption != "scram-sha-256" {
+		return fmt.Errorf("password_encryption is '%s', but passwordEncryption.requireSCRAM is set", encryption)
+	}
+	return nil
+}
+
 func (r *DBClusterReconciler) getCredentials(ctx context.Context, cluster *databasesv1alpha1.DBCluster) (username, password string, err error) {
+	return getClusterCredentials(ctx, r.Client, cluster)
+}
+
+// getClusterCredentials returns the admin credentials from credentialsFromEnv or credentialsSecretRef
+func getClusterCredentials(ctx context.Context, c client.Reader, cluster *databasesv1alpha1.DBCluster) (username, password string, err error) {
 	logger := log.FromContext(ctx)
 	hasSecretRef := cluster.Spec.CredentialsSecretRef != nil
 	hasEnvRef := cluster.Spec.CredentialsFromEnv != nil
 
 	if hasSecretRef && hasEnvRef {
-		logger.Info("both credentialsSecretRef and credentialsFromEnv specified, using credentialsFromEnv")
+		logger.V(1).Info("both credentialsSecretRef and credentialsFromEnv specified, using credentialsFromEnv")
 	}
 
 	if hasEnvRef {
-		return r.getCredentialsFromEnv(cluster.Spec.CredentialsFromEnv)
+		return getCredentialsFromEnv(cluster.Spec.CredentialsFromEnv)
 	}
 
 	if hasSecretRef {
-		return r.getCredentialsFromSecret(ctx, cluster.Spec.CredentialsSecretRef)
+		return getCredentialsFromSecret(ctx, c, cluster.Spec.CredentialsSecretRef)
 	}
 
 	return "", "", fmt.Errorf("either credentialsSecretRef or credentialsFromEnv must be specified")
 }
 
-func (r *DBClusterReconciler) getCredentialsFromEnv(cfg *databasesv1alpha1.CredentialsFromEnv) (username, password string, err error) {
+func getCredentialsFromEnv(cfg *databasesv1alpha1.CredentialsFromEnv) (username, password string, err error) {
 	username = os.Getenv(cfg.Username)
 	if username == "" {
 		return "", "", fmt.Errorf("environment variable %s not set or empty", cfg.Username)
@@ -120,9 +157,9 @@ func (r *DBClusterReconciler) getCredentialsFromEnv(cfg *databasesv1alpha1.Crede
 	return username, password, nil
 }
 
-func (r *DBClusterReconciler) getCredentialsFromSecret(ctx context.Context, ref *databasesv1alpha1.SecretReference) (username, password string, err error) {
+func getCredentialsFromSecret(ctx context.Context, c client.Reader, ref *databasesv1alpha1.SecretReference) (username, password string, err error) {
 	var secret corev1.Secret
-	err = r.Get(ctx, types.NamespacedName{
+	err = c.Get(ctx, types.NamespacedName{
 		Name:      ref.Name,
 		Namespace: ref.Namespace,
 	}, &secret)
@@ -140,6 +177,27 @@ func (r *DBClusterReconciler) getCredentialsFromSecret(ctx context.Context, ref
 	return username, password, nil
 }
 
+// getClusterPostgresClient connects to the cluster with its admin credentials. Shared by all
+// controllers that act on a DBCluster, so both credential sources work everywhere.
+func getClusterPostgresClient(ctx context.Context, c client.Reader, cache postgres.ClientCacheInterface,
+	cluster *databasesv1alpha1.DBCluster) (postgres.ClientInterface, error) {
+
+	username, password, err := getClusterCredentials(ctx, c, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster credentials: %w", err)
+	}
+
+	return cache.Get(ctx, cluster.Name, postgres.Config{
+		Host:     cluster.Spec.Endpoint,
+		Port:     cluster.Spec.Port,
+		Username: username,
+		Password: password,
+		Database: "postgres",
+
+		ServerSidePasswordEncryption: cluster.Spec.PasswordEncryption.ServerSide(),
+	})
+}
+
 func (r *DBClusterReconciler) updateStatus(ctx context.Context, cluster *databasesv1alpha1.DBCluster, phase, message, version string) (ctrl.Result, error) {
 	// Check if status actually changed to avoid triggering unnecessary reconciliations
 	statusChanged := cluster.Status.Phase != phase ||
@@ -148,6 +206,7 @@ func (r *DBClusterReconciler) updateStatus(ctx context.Context, cluster *databas
 		(version != "" && cluster.Status.PostgresVersion != version)
 
 	if statusChanged {
+		previousPhase := cluster.Status.Phase
 		patch := client.MergeFrom(cluster.DeepCopy())
 		cluster.Status.Phase = phase
 		cluster.Status.Message = message
@@ -161,6 +220,7 @@ func (r *DBClusterReconciler) updateStatus(ctx context.Context, cluster *databas
 		if err := r.Status().Patch(ctx, cluster, patch); err != nil {
 			return ctrl.Result{}, err
 		}
+		r.notifyTransition(cluster, previousPhase)
 	}
 
 	if phase == "Failed" {
@@ -170,6 +230,31 @@ func (r *DBClusterReconciler) updateStatus(ctx context.Context, cluster *databas
 	return ctrl.Result{}, nil
 }
 
+// notifyTransition emits ClusterDisconnected when a connected cluster fails its health check
+// and ClusterConnected when it recovers. The first check of a new cluster is not an event.
+func (r *DBClusterReconciler) notifyTransition(cluster *databasesv1alpha1.DBCluster, previousPhase string) {
+	if r.Notifier == nil || previousPhase == cluster.Status.Phase {
+		return
+	}
+
+	var reason string
+	switch {
+	case previousPhase == "Connected" && cluster.Status.Phase == "Failed":
+		reason = databasesv1alpha1.EventClusterDisconnected
+	case previousPhase == "Failed" && cluster.Status.Phase == "Connected":
+		reason = databasesv1alpha1.EventClusterConnected
+	default:
+		return
+	}
+
+	// The resource version of the status write that changed the phase identifies the transition;
+	// LastCheckTime is stored with second precision and repeats when the cluster flaps quickly
+	r.Notifier.Notify(notify.NewEvent(cluster, "DBCluster", reason, cluster.Status.Message, cluster.ResourceVersion).WithData(
+		"endpoint", cluster.Spec.Endpoint,
+		"version", cluster.Status.PostgresVersion,
+	))
+}
+
 func (r *DBClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&databasesv1alpha1.DBCluster{}).
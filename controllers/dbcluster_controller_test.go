@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
 )
 
 var _ = Describe("DBCluster Controller", func() {
@@ -140,8 +141,6 @@ var _ = Describe("DBCluster Controller", func() {
 
 // Unit tests for getCredentialsFromEnv
 func TestGetCredentialsFromEnv(t *testing.T) {
-	r := &DBClusterReconciler{}
-
 	t.Run("returns credentials from ENV", func(t *testing.T) {
 		t.Setenv("TEST_USER", "myuser")
 		t.Setenv("TEST_PASS", "mypass")
@@ -151,7 +150,7 @@ func TestGetCredentialsFromEnv(t *testing.T) {
 			Password: "TEST_PASS",
 		}
 
-		user, pass, err := r.getCredentialsFromEnv(cfg)
+		user, pass, err := getCredentialsFromEnv(cfg)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -171,7 +170,7 @@ func TestGetCredentialsFromEnv(t *testing.T) {
 			Password: "TEST_PASS",
 		}
 
-		_, _, err := r.getCredentialsFromEnv(cfg)
+		_, _, err := getCredentialsFromEnv(cfg)
 		if err == nil {
 			t.Fatal("expected error, got nil")
 		}
@@ -185,7 +184,7 @@ func TestGetCredentialsFromEnv(t *testing.T) {
 			Password: "MISSING_PASS",
 		}
 
-		_, _, err := r.getCredentialsFromEnv(cfg)
+		_, _, err := getCredentialsFromEnv(cfg)
 		if err == nil {
 			t.Fatal("expected error, got nil")
 		}
@@ -241,6 +240,29 @@ func TestGetCredentials_BothSourcesSpecified_UsesEnv(t *testing.T) {
 	}
 }
 
+func TestCheckPasswordEncryption(t *testing.T) {
+	r := &DBClusterReconciler{}
+	pgClient := postgres.NewMockClient()
+	pgClient.PasswordEncryption = "md5"
+
+	cluster := &databasesv1alpha1.DBCluster{
+		Spec: databasesv1alpha1.DBClusterSpec{Endpoint: "localhost", Port: 5432},
+	}
+	if err := r.checkPasswordEncryption(context.TODO(), cluster, pgClient); err != nil {
+		t.Errorf("unexpected error without requireSCRAM: %v", err)
+	}
+
+	cluster.Spec.PasswordEncryption = &databasesv1alpha1.PasswordEncryptionConfig{RequireSCRAM: true}
+	if err := r.checkPasswordEncryption(context.TODO(), cluster, pgClient); err == nil {
+		t.Error("expected error for md5 password_encryption with requireSCRAM")
+	}
+
+	pgClient.PasswordEncryption = "scram-sha-256"
+	if err := r.checkPasswordEncryption(context.TODO(), cluster, pgClient); err != nil {
+		t.Errorf("unexpected error for scram-sha-256: %v", err)
+	}
+}
+
 // TestStatusChangeDetection verifies that status is only updated when meaningful changes occur
 // This prevents unnecessary reconciliation loops caused by status patches
 func TestStatusChangeDetection(t *testing.T) {
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/notify"
+)
+
+// NotificationChannelReconciler validates channels; deliveries are done by notify.Dispatcher
+type NotificationChannelReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=dbtether.io,resources=notificationchannels,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=dbtether.io,resources=notificationchannels/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+func (r *NotificationChannelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var channel databasesv1alpha1.NotificationChannel
+	if err := r.Get(ctx, req.NamespacedName, &channel); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if err := r.validate(ctx, &channel); err != nil {
+		logger.Info("notification channel invalid", "error", err.Error())
+		return r.updateStatus(ctx, &channel, "Failed", err.Error())
+	}
+	return r.updateStatus(ctx, &channel, "Ready", "channel ready")
+}
+
+func (r *NotificationChannelReconciler) validate(ctx context.Context, channel *databasesv1alpha1.NotificationChannel) error {
+	endpoint, err := notify.ResolveURL(ctx, r.Client, &channel.Spec)
+	if err != nil {
+		return err
+	}
+	if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid endpoint URL: must be an absolute http(s) URL")
+	}
+
+	for i, sub := range channel.Spec.Subscriptions {
+		if sub.LabelSelector == nil {
+			continue
+		}
+		if _, err := metav1.LabelSelectorAsSelector(sub.LabelSelector); err != nil {
+			return fmt.Errorf("subscriptions[%d].labelSelector: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// updateStatus patches only phase and message, leaving the dispatcher's delivery counters alone
+func (r *NotificationChannelReconciler) updateStatus(ctx context.Context, channel *databasesv1alpha1.NotificationChannel,
+	phase, message string) (ctrl.Result, error) {
+
+	if channel.Status.Phase != phase || channel.Status.Message != message ||
+		channel.Status.ObservedGeneration != channel.Generation {
+		patch := client.MergeFrom(channel.DeepCopy())
+		channel.Status.Phase = phase
+		channel.Status.Message = message
+		channel.Status.ObservedGeneration = channel.Generation
+		if err := r.Status().Patch(ctx, channel, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if phase == "Failed" {
+		// The URL secret may be created later
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *NotificationChannelReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&databasesv1alpha1.NotificationChannel{}).
+		Complete(r)
+}
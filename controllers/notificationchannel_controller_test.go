@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/notify"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+func newNotifyTestClient(objects ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	_ = databasesv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&databasesv1alpha1.NotificationChannel{}, &databasesv1alpha1.DBCluster{}).
+		Build()
+}
+
+func TestNotificationChannelReconciler_Validation(t *testing.T) {
+	urlSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "slack-webhook", Namespace: "dbtether"},
+		Data:       map[string][]byte{"url": []byte("https://hooks.slack.com/services/T/B/X")},
+	}
+
+	tests := []struct {
+		name        string
+		spec        databasesv1alpha1.NotificationChannelSpec
+		wantPhase   string
+		wantMessage string
+	}{
+		{
+			name:        "inline url",
+			spec:        databasesv1alpha1.NotificationChannelSpec{Type: "webhook", URL: "https://example.com/hook"},
+			wantPhase:   "Ready",
+			wantMessage: "channel ready",
+		},
+		{
+			name: "url from secret",
+			spec: databasesv1alpha1.NotificationChannelSpec{
+				Type:         "slack",
+				URLSecretRef: &databasesv1alpha1.SecretReference{Name: "slack-webhook", Namespace: "dbtether"},
+			},
+			wantPhase:   "Ready",
+			wantMessage: "channel ready",
+		},
+		{
+			name: "missing secret",
+			spec: databasesv1alpha1.NotificationChannelSpec{
+				Type:         "slack",
+				URLSecretRef: &databasesv1alpha1.SecretReference{Name: "missing", Namespace: "dbtether"},
+			},
+			wantPhase:   "Failed",
+			wantMessage: "url secret dbtether/missing",
+		},
+		{
+			name:        "no url",
+			spec:        databasesv1alpha1.NotificationChannelSpec{Type: "webhook"},
+			wantPhase:   "Failed",
+			wantMessage: "one of url or urlSecretRef must be set",
+		},
+		{
+			name: "invalid label selector",
+			spec: databasesv1alpha1.NotificationChannelSpec{
+				Type: "webhook",
+				URL:  "https://example.com/hook",
+				Subscriptions: []databasesv1alpha1.NotificationSubscription{{
+					LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "env", Operator: "Near"},
+					}},
+				}},
+			},
+			wantPhase:   "Failed",
+			wantMessage: "subscriptions[0].labelSelector",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := &databasesv1alpha1.NotificationChannel{
+				ObjectMeta: metav1.ObjectMeta{Name: "ops", Generation: 2},
+				Spec:       tt.spec,
+			}
+			r := &NotificationChannelReconciler{Client: newNotifyTestClient(channel, urlSecret)}
+
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "ops"}})
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			var got databasesv1alpha1.NotificationChannel
+			if err := r.Get(context.Background(), types.NamespacedName{Name: "ops"}, &got); err != nil {
+				t.Fatalf("failed to get channel: %v", err)
+			}
+			if got.Status.Phase != tt.wantPhase || !strings.Contains(got.Status.Message, tt.wantMessage) {
+				t.Errorf("status = %s %q, want %s %q", got.Status.Phase, got.Status.Message, tt.wantPhase, tt.wantMessage)
+			}
+			if got.Status.ObservedGeneration != 2 {
+				t.Errorf("ObservedGeneration = %d, want 2", got.Status.ObservedGeneration)
+			}
+			if wantRequeue := tt.wantPhase == "Failed"; (result.RequeueAfter > 0) != wantRequeue {
+				t.Errorf("RequeueAfter = %v, want requeue %v", result.RequeueAfter, wantRequeue)
+			}
+		})
+	}
+}
+
+func TestNotificationChannelReconciler_KeepsDeliveryCounters(t *testing.T) {
+	channel := &databasesv1alpha1.NotificationChannel{
+		ObjectMeta: metav1.ObjectMeta{Name: "ops"},
+		Spec:       databasesv1alpha1.NotificationChannelSpec{Type: "webhook", URL: "https://example.com/hook"},
+		Status:     databasesv1alpha1.NotificationChannelStatus{Delivered: 7, Failed: 1},
+	}
+	r := &NotificationChannelReconciler{Client: newNotifyTestClient(channel)}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "ops"}}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	var got databasesv1alpha1.NotificationChannel
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "ops"}, &got); err != nil {
+		t.Fatalf("failed to get channel: %v", err)
+	}
+	if got.Status.Delivered != 7 || got.Status.Failed != 1 {
+		t.Errorf("counters = %d/%d, want 7/1", got.Status.Delivered, got.Status.Failed)
+	}
+}
+
+func TestDBClusterReconciler_NotifiesTransitions(t *testing.T) {
+	cluster := &databasesv1alpha1.DBCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "main", Labels: map[string]string{"env": "prod"}},
+		Spec:       databasesv1alpha1.DBClusterSpec{Endpoint: "db.example.com", Port: 5432},
+	}
+	notifier := &notify.MockNotifier{}
+	r := &DBClusterReconciler{Client: newNotifyTestClient(cluster), Notifier: notifier}
+	ctx := context.Background()
+
+	steps := []struct{ phase, message string }{
+		{"Connected", "connected"},         // first check: no event
+		{"Connected", "connected"},         // unchanged
+		{"Failed", "connection refused"},   // disconnected
+		{"Failed", "connection timed out"}, // still failed, new message
+		{"Connected", "connected"},         // recovered
+	}
+	for _, step := range steps {
+		if _, err := r.updateStatus(ctx, cluster, step.phase, step.message, "16.4"); err != nil {
+			t.Fatalf("updateStatus(%s) error = %v", step.phase, err)
+		}
+	}
+
+	got := notifier.Reasons()
+	want := []string{databasesv1alpha1.EventClusterDisconnected, databasesv1alpha1.EventClusterConnected}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+
+	disconnected := notifier.Events[0]
+	if disconnected.Kind != "DBCluster" || disconnected.Name != "main" || disconnected.Message != "connection refused" {
+		t.Errorf("unexpected event: %+v", disconnected)
+	}
+	if disconnected.Data["endpoint"] != "db.example.com" || disconnected.Labels["env"] != "prod" {
+		t.Errorf("unexpected event details: data=%v labels=%v", disconnected.Data, disconnected.Labels)
+	}
+
+	// A second outage is a new transition, not a duplicate of the first
+	for _, phase := range []string{"Failed", "Connected"} {
+		if _, err := r.updateStatus(ctx, cluster, phase, phase, "16.4"); err != nil {
+			t.Fatalf("updateStatus(%s) error = %v", phase, err)
+		}
+	}
+	ids := map[string]bool{}
+	for _, event := range notifier.Events {
+		ids[event.ID] = true
+	}
+	if len(ids) != len(notifier.Events) {
+		t.Errorf("events share IDs: %d unique of %d", len(ids), len(notifier.Events))
+	}
+}
+
+func TestDBClusterReconciler_NilNotifier(t *testing.T) {
+	cluster := &databasesv1alpha1.DBCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "main"},
+		Status:     databasesv1alpha1.DBClusterStatus{Phase: "Connected"},
+	}
+	r := &DBClusterReconciler{Client: newNotifyTestClient(cluster)}
+
+	result, err := r.updateStatus(context.Background(), cluster, "Failed", "connection refused", "")
+	if err != nil {
+		t.Fatalf("updateStatus() error = %v", err)
+	}
+	if result.RequeueAfter != 30*time.Second {
+		t.Errorf("RequeueAfter = %v, want 30s", result.RequeueAfter)
+	}
+}
+
+func TestDatabaseUserReconciler_RotatePasswordNotifies(t *testing.T) {
+	user := &databasesv1alpha1.DatabaseUser{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app",
+			Namespace:   "default",
+			Annotations: map[string]string{databasesv1alpha1.NotifyAnnotation: "security"},
+		},
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			Rotation: &databasesv1alpha1.RotationConfig{Days: 30},
+		},
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "app-credentials", Namespace: "default"}}
+	cluster := &databasesv1alpha1.DBCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "main"},
+		Spec:       databasesv1alpha1.DBClusterSpec{Endpoint: "db.example.com", Port: 5432},
+	}
+
+	pgClient := postgres.NewMockClient()
+	r := newPasswordRefTestReconciler(pgClient, user, secret, cluster)
+	notifier := &notify.MockNotifier{}
+	r.Notifier = notifier
+
+	_, _, changed, err := r.rotatePassword(context.Background(), user, secret, cluster, nil, pgClient, "app_user")
+	if err != nil || !changed {
+		t.Fatalf("rotatePassword() = %v, %v", changed, err)
+	}
+
+	if len(notifier.Events) != 1 {
+		t.Fatalf("events = %v, want one PasswordRotated", notifier.Reasons())
+	}
+	event := notifier.Events[0]
+	if event.Reason != databasesv1alpha1.EventPasswordRotated || event.Data["username"] != "app_user" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if len(event.Channels) != 1 || event.Channels[0] != "security" {
+		t.Errorf("Channels = %v, want [security]", event.Channels)
+	}
+	if _, ok := event.Data["password"]; ok {
+		t.Error("event must not contain the password")
+	}
+
+	// The next rotation replaces a different password and is not deduplicated against the first
+	updatedAt := metav1.NewTime(time.Now().Add(-31 * 24 * time.Hour))
+	user.Status.PasswordUpdatedAt = &updatedAt
+	if _, _, _, err := r.rotatePassword(context.Background(), user, secret, cluster, nil, pgClient, "app_user"); err != nil {
+		t.Fatalf("second rotatePassword() error = %v", err)
+	}
+	if len(notifier.Events) != 2 || notifier.Events[1].ID == event.ID {
+		t.Errorf("second rotation events = %v, want a new event ID", notifier.Reasons())
+	}
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups=dbtether.io,resources=databasereferencegrants,verbs=get;list;watch
+
+// checkDatabaseReference returns a non-empty message when a Database in another namespace is referenced
+// without a DatabaseReferenceGrant in that namespace allowing userAccess from fromNamespace
+func checkDatabaseReference(ctx context.Context, c client.Reader, fromNamespace string,
+	db *databasesv1alpha1.Database) (string, error) {
+
+	if db.Namespace == fromNamespace {
+		return "", nil
+	}
+
+	var grants databasesv1alpha1.DatabaseReferenceGrantList
+	if err := c.List(ctx, &grants, client.InNamespace(db.Namespace)); err != nil {
+		return "", fmt.Errorf("failed to list DatabaseReferenceGrants: %w", err)
+	}
+	if grants.Allows(fromNamespace, databasesv1alpha1.ReferencePurposeUserAccess, databasesv1alpha1.ReferenceKindDatabase, db.Name) {
+		return "", nil
+	}
+	return fmt.Sprintf("Database '%s/%s' is not shared with namespace %s (no DatabaseReferenceGrant allows userAccess)",
+		db.Namespace, db.Name, fromNamespace), nil
+}
+
+// checkDatabaseReferenceByName is checkDatabaseReference for a reference that was already granted:
+// the DatabaseReferenceGrant is re-checked even if the Database itself is gone
+func checkDatabaseReferenceByName(ctx context.Context, c client.Reader, fromNamespace string,
+	ref databasesv1alpha1.DatabaseReference) (string, error) {
+
+	dbNamespace := ref.Namespace
+	if dbNamespace == "" {
+		dbNamespace = fromNamespace
+	}
+	return checkDatabaseReference(ctx, c, fromNamespace, &databasesv1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{Name: ref.Name, Namespace: dbNamespace},
+	})
+}
+
+// hasCrossNamespaceDatabases returns true if the user references Databases in other namespaces
+func hasCrossNamespaceDatabases(user *databasesv1alpha1.DatabaseUser) bool {
+	for _, dbAccess := range user.Spec.GetDatabases() {
+		if dbAccess.Namespace != "" && dbAccess.Namespace != user.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// findUsersForReferenceGrant maps a DatabaseReferenceGrant to the DatabaseUsers referencing Databases
+// in its namespace. All namespaces are considered, since an update may have removed a namespace from the grant.
+func (r *DatabaseUserReconciler) findUsersForReferenceGrant(ctx context.Context, obj client.Object) []reconcile.Request {
+	var users databasesv1alpha1.DatabaseUserList
+	if err := r.List(ctx, &users); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list DatabaseUsers for reference grant", "grant", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, user := range users.Items {
+		if user.Namespace == obj.GetNamespace() {
+			continue
+		}
+		for _, dbAccess := range user.Spec.GetDatabases() {
+			if dbAccess.Namespace == obj.GetNamespace() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: user.Name, Namespace: user.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// findGrantsForReferenceGrant maps a DatabaseReferenceGrant to the DatabaseAccessGrants of other namespaces
+// using a Database in its namespace, so withdrawing it revokes their privileges before they expire
+func (r *DatabaseAccessGrantReconciler) findGrantsForReferenceGrant(ctx context.Context, obj client.Object) []reconcile.Request {
+	var grants databasesv1alpha1.DatabaseAccessGrantList
+	if err := r.List(ctx, &grants); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list DatabaseAccessGrants for reference grant", "grant", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, grant := range grants.Items {
+		if grant.Namespace != obj.GetNamespace() && grant.Spec.Database.Namespace == obj.GetNamespace() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: grant.Name, Namespace: grant.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// findSessionsForReferenceGrant maps a DatabaseReferenceGrant to the DatabaseSessions of other namespaces
+// using a Database in its namespace, so withdrawing it tears their proxies down before the TTL expires
+func (r *DatabaseSessionReconciler) findSessionsForReferenceGrant(ctx context.Context, obj client.Object) []reconcile.Request {
+	var sessions databasesv1alpha1.DatabaseSessionList
+	if err := r.List(ctx, &sessions); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list DatabaseSessions for reference grant", "grant", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, session := range sessions.Items {
+		if session.Namespace != obj.GetNamespace() && session.Spec.Database.Namespace == obj.GetNamespace() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: session.Name, Namespace: session.Namespace},
+			})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+const testReferencingNamespace = "analytics"
+
+func newTestReferenceGrant(purposes ...databasesv1alpha1.ReferencePurpose) *databasesv1alpha1.DatabaseReferenceGrant {
+	return &databasesv1alpha1.DatabaseReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "analytics", Namespace: testGrantNamespace},
+		Spec: databasesv1alpha1.DatabaseReferenceGrantSpec{
+			From: []databasesv1alpha1.ReferenceGrantFrom{{Namespace: testReferencingNamespace, Purposes: purposes}},
+		},
+	}
+}
+
+func newCrossNamespaceUser() *databasesv1alpha1.DatabaseUser {
+	return &databasesv1alpha1.DatabaseUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: testReferencingNamespace},
+		Spec: databasesv1alpha1.DatabaseUserSpec{
+			Database:   &databasesv1alpha1.DatabaseAccess{Name: "orders-db", Namespace: testGrantNamespace},
+			Privileges: "readonly",
+		},
+	}
+}
+
+func reconcileCrossNamespaceUser(t *testing.T, r *DatabaseUserReconciler) *databasesv1alpha1.DatabaseUser {
+	t.Helper()
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "reader", Namespace: testReferencingNamespace}}
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+	var user databasesv1alpha1.DatabaseUser
+	if err := r.Get(ctx, req.NamespacedName, &user); err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	return &user
+}
+
+func TestDatabaseUserReconciler_CrossNamespaceReference(t *testing.T) {
+	t.Run("denied without grant", func(t *testing.T) {
+		pgClient := postgres.NewMockClient()
+		r := newPasswordRefTestReconciler(pgClient, append(newGrantTestObjects(), newCrossNamespaceUser())...)
+
+		user := reconcileCrossNamespaceUser(t, r)
+		if user.Status.Phase != "Failed" || !strings.Contains(user.Status.Message, "not shared with namespace analytics") {
+			t.Errorf("status = %s (%s), want Failed", user.Status.Phase, user.Status.Message)
+		}
+		if pgClientHasUser(pgClient, "reader") {
+			t.Error("role should not be created")
+		}
+	})
+
+	t.Run("grant for another purpose is not enough", func(t *testing.T) {
+		pgClient := postgres.NewMockClient()
+		grant := newTestReferenceGrant(databasesv1alpha1.ReferencePurposeRestoreSource)
+		r := newPasswordRefTestReconciler(pgClient, append(newGrantTestObjects(), grant, newCrossNamespaceUser())...)
+
+		if user := reconcileCrossNamespaceUser(t, r); user.Status.Phase != "Failed" {
+			t.Errorf("status = %s, want Failed", user.Status.Phase)
+		}
+	})
+
+	t.Run("allowed with grant", func(t *testing.T) {
+		pgClient := postgres.NewMockClient()
+		grant := newTestReferenceGrant(databasesv1alpha1.ReferencePurposeUserAccess)
+		r := newPasswordRefTestReconciler(pgClient, append(newGrantTestObjects(), grant, newCrossNamespaceUser())...)
+
+		user := reconcileCrossNamespaceUser(t, r)
+		if user.Status.Phase != "Ready" {
+			t.Fatalf("status = %s (%s), want Ready", user.Status.Phase, user.Status.Message)
+		}
+		if !hasDatabaseAccess(t, pgClient, "reader", "orders_db") {
+			t.Error("expected access to orders_db")
+		}
+	})
+
+	t.Run("access revoked when grant is withdrawn", func(t *testing.T) {
+		ctx := context.Background()
+		pgClient := postgres.NewMockClient()
+		grant := newTestReferenceGrant(databasesv1alpha1.ReferencePurposeUserAccess)
+		r := newPasswordRefTestReconciler(pgClient, append(newGrantTestObjects(), grant, newCrossNamespaceUser())...)
+
+		if user := reconcileCrossNamespaceUser(t, r); user.Status.Phase != "Ready" {
+			t.Fatalf("status = %s (%s), want Ready", user.Status.Phase, user.Status.Message)
+		}
+		if err := r.Delete(ctx, grant); err != nil {
+			t.Fatalf("failed to delete grant: %v", err)
+		}
+
+		user := reconcileCrossNamespaceUser(t, r)
+		if user.Status.Phase != "Failed" {
+			t.Errorf("status = %s, want Failed", user.Status.Phase)
+		}
+		if hasDatabaseAccess(t, pgClient, "reader", "orders_db") {
+			t.Error("access should be revoked once the grant is gone")
+		}
+	})
+}
+
+func TestDatabaseAccessGrantReconciler_CrossNamespaceReference(t *testing.T) {
+	pgClient := postgres.NewMockClient()
+	grant := &databasesv1alpha1.DatabaseAccessGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "incident", Namespace: testReferencingNamespace},
+		Spec: databasesv1alpha1.DatabaseAccessGrantSpec{
+			Database:   databasesv1alpha1.DatabaseReference{Name: "orders-db", Namespace: testGrantNamespace},
+			Privileges: "readonly",
+			Duration:   metav1.Duration{Duration: time.Hour},
+			Approval:   databasesv1alpha1.GrantApproval{ApprovedBy: "oncall", Reason: "incident"},
+		},
+	}
+	r := newGrantTestReconciler(pgClient, append(newGrantTestObjects(), grant)...)
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "incident", Namespace: testReferencingNamespace}}
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+	if err := r.Get(ctx, req.NamespacedName, grant); err != nil {
+		t.Fatalf("failed to get grant: %v", err)
+	}
+	if grant.Status.Phase != grantPhasePending || !strings.Contains(grant.Status.Message, "not shared") {
+		t.Errorf("status = %s (%s), want Pending until the Database is shared", grant.Status.Phase, grant.Status.Message)
+	}
+}
+
+func TestDatabaseUserReconciler_FindUsersForReferenceGrant(t *testing.T) {
+	sameNamespace := newCrossNamespaceUser()
+	sameNamespace.Name, sameNamespace.Namespace = "local", testGrantNamespace
+	other := newCrossNamespaceUser()
+	other.Name = "unrelated"
+	other.Spec.Database = &databasesv1alpha1.DatabaseAccess{Name: "reports-db"}
+
+	r := newPasswordRefTestReconciler(postgres.NewMockClient(), newCrossNamespaceUser(), sameNamespace, other)
+	requests := r.findUsersForReferenceGrant(context.Background(), newTestReferenceGrant())
+	if len(requests) != 1 || requests[0].Name != "reader" {
+		t.Errorf("requests = %v, want only analytics/reader", requests)
+	}
+}
+
+func TestDatabaseAccessGrantReconciler_RevokedWhenReferenceGrantWithdrawn(t *testing.T) {
+	ctx := context.Background()
+	pgClient := postgres.NewMockClient()
+	refGrant := newTestReferenceGrant(databasesv1alpha1.ReferencePurposeUserAccess)
+	grant := &databasesv1alpha1.DatabaseAccessGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "incident", Namespace: testReferencingNamespace},
+		Spec: databasesv1alpha1.DatabaseAccessGrantSpec{
+			Database:   databasesv1alpha1.DatabaseReference{Name: "orders-db", Namespace: testGrantNamespace},
+			Privileges: "readonly",
+			Duration:   metav1.Duration{Duration: time.Hour},
+			Approval:   databasesv1alpha1.GrantApproval{ApprovedBy: "oncall", Reason: "incident"},
+		},
+	}
+	r := newGrantTestReconciler(pgClient, append(newGrantTestObjects(), refGrant, grant)...)
+
+	if requests := r.findGrantsForReferenceGrant(ctx, refGrant); len(requests) != 1 || requests[0].Name != "incident" {
+		t.Errorf("findGrantsForReferenceGrant() = %v, want analytics/incident", requests)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "incident", Namespace: testReferencingNamespace}}
+	refresh := func() {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		if err := r.Get(ctx, req.NamespacedName, grant); err != nil {
+			t.Fatalf("failed to get grant: %v", err)
+		}
+	}
+	refresh()
+	refresh()
+	if grant.Status.Phase != grantPhaseActive {
+		t.Fatalf("status = %s (%s), want Active", grant.Status.Phase, grant.Status.Message)
+	}
+	username := grant.Status.Username
+	if !hasDatabaseAccess(t, pgClient, username, "orders_db") {
+		t.Fatal("expected access to orders_db")
+	}
+
+	if err := r.Delete(ctx, refGrant); err != nil {
+		t.Fatalf("failed to delete reference grant: %v", err)
+	}
+	refresh()
+	if grant.Status.Phase != grantPhaseFailed || grant.Status.RevokedAt == nil ||
+		!strings.Contains(grant.Status.Message, "privileges revoked") {
+		t.Errorf("status = %s (%s), want Failed with privileges revoked", grant.Status.Phase, grant.Status.Message)
+	}
+	if pgClientHasUser(pgClient, username) {
+		t.Error("generated role should be dropped once the Database is no longer shared")
+	}
+
+	// Restoring the reference grant does not re-activate a withdrawn grant
+	restored := newTestReferenceGrant(databasesv1alpha1.ReferencePurposeUserAccess)
+	if err := r.Create(ctx, restored); err != nil {
+		t.Fatalf("failed to recreate reference grant: %v", err)
+	}
+	refresh()
+	if grant.Status.Phase != grantPhaseFailed || pgClientHasUser(pgClient, username) {
+		t.Errorf("status = %s, withdrawn grant must stay revoked", grant.Status.Phase)
+	}
+}
+
+func TestDatabaseSessionReconciler_EndedWhenReferenceGrantWithdrawn(t *testing.T) {
+	ctx := context.Background()
+	pgClient := postgres.NewMockClient()
+	refGrant := newTestReferenceGrant(databasesv1alpha1.ReferencePurposeUserAccess)
+	session := newTestSession("debug", time.Hour)
+	session.Namespace = testReferencingNamespace
+	session.Spec.Database.Namespace = testGrantNamespace
+	r := newSessionTestReconciler(pgClient, append(newGrantTestObjects(), refGrant, session)...)
+
+	if requests := r.findSessionsForReferenceGrant(ctx, refGrant); len(requests) != 1 || requests[0].Name != "debug" {
+		t.Errorf("findSessionsForReferenceGrant() = %v, want analytics/debug", requests)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "debug", Namespace: testReferencingNamespace}}
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+	if err := r.Get(ctx, req.NamespacedName, session); err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if session.Status.StartedAt == nil || !pgClientHasUser(pgClient, "session_debug") {
+		t.Fatalf("status = %s (%s), want a started session", session.Status.Phase, session.Status.Message)
+	}
+
+	if err := r.Delete(ctx, refGrant); err != nil {
+		t.Fatalf("failed to delete reference grant: %v", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := r.Get(ctx, req.NamespacedName, session); err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if session.Status.Phase != sessionPhaseFailed || session.Status.EndedAt == nil {
+		t.Errorf("status = %s (%s), want Failed with endedAt", session.Status.Phase, session.Status.Message)
+	}
+	if pgClientHasUser(pgClient, "session_debug") {
+		t.Error("session role should be dropped once the Database is no longer shared")
+	}
+	var pod corev1.Pod
+	if err := r.Get(ctx, types.NamespacedName{Name: "debug-proxy", Namespace: testReferencingNamespace}, &pod); err == nil {
+		t.Error("proxy pod should be deleted once the Database is no longer shared")
+	}
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+const temporaryRolePasswordLength = 24
+
+// temporaryRole is a PostgreSQL role created for a DatabaseAccessGrant or DatabaseSession.
+// Its credentials Secret is owned by the resource and written before the role, so an existing
+// role is only reused when the resource owns the Secret.
+type temporaryRole struct {
+	Owner      client.Object
+	Username   string
+	SecretName string
+	ExpiresAt  metav1.Time
+
+	// SecretData holds the connection keys stored next to the generated password
+	SecretData map[string][]byte
+}
+
+// ensureTemporaryRole creates the credentials Secret and the role, and sets VALID UNTIL to the
+// expiry so the password stops working even if teardown is delayed. owned reports whether the
+// role belongs to the owner, which holds from the moment its Secret exists, even if a later step fails.
+func ensureTemporaryRole(ctx context.Context, c client.Client, scheme *runtime.Scheme,
+	pgClient postgres.ClientInterface, role temporaryRole) (owned bool, err error) {
+
+	var secret corev1.Secret
+	err = c.Get(ctx, types.NamespacedName{Name: role.SecretName, Namespace: role.Owner.GetNamespace()}, &secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get secret: %w", err)
+	}
+	secretExists := err == nil
+	if secretExists && !metav1.IsControlledBy(&secret, role.Owner) {
+		return false, fmt.Errorf("secret '%s' already exists and is not owned by %s", role.SecretName, role.Owner.GetName())
+	}
+
+	exists, err := pgClient.UserExists(ctx, role.Username)
+	if err != nil {
+		return secretExists, fmt.Errorf("failed to check user: %w", err)
+	}
+	if exists && !secretExists {
+		return false, fmt.Errorf("role '%s' already exists and was not created by %s", role.Username, role.Owner.GetName())
+	}
+
+	if !secretExists {
+		password, err := postgres.GeneratePassword(temporaryRolePasswordLength)
+		if err != nil {
+			return false, fmt.Errorf("failed to generate password: %w", err)
+		}
+		data := map[string][]byte{"password": []byte(password)}
+		for k, v := range role.SecretData {
+			data[k] = v
+		}
+		secret = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      role.SecretName,
+				Namespace: role.Owner.GetNamespace(),
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "dbtether"},
+			},
+			Data: data,
+		}
+		if err := controllerutil.SetControllerReference(role.Owner, &secret, scheme); err != nil {
+			return false, err
+		}
+		if err := c.Create(ctx, &secret); err != nil {
+			return false, fmt.Errorf("failed to create secret: %w", err)
+		}
+	}
+
+	if !exists {
+		if err := pgClient.CreateUser(ctx, role.Username, string(secretDataMap(&secret)["password"])); err != nil {
+			return true, err
+		}
+		log.FromContext(ctx).Info("created temporary role", "username", role.Username, "secret", role.SecretName)
+	}
+
+	return true, pgClient.SetRoleAttributes(ctx, role.Username, postgres.RoleAttributes{
+		ValidUntil: role.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// dropTemporaryRole revokes the role's privileges in dbName, drops it and deletes its Secret
+func dropTemporaryRole(ctx context.Context, c client.Client, pgClient postgres.ClientInterface,
+	owner client.Object, username, dbName, secretName string) error {
+
+	if err := pgClient.RevokePrivilegesInDatabase(ctx, username, dbName); err != nil {
+		return err
+	}
+	if err := pgClient.DropUser(ctx, username); err != nil {
+		return err
+	}
+	deleteOwnedSecret(ctx, c, owner, secretName)
+	return nil
+}
+
+// deleteOwnedSecret deletes the Secret if it is controlled by owner
+func deleteOwnedSecret(ctx context.Context, c client.Client, owner client.Object, name string) {
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: owner.GetNamespace()}, &secret); err != nil {
+		return
+	}
+	if !metav1.IsControlledBy(&secret, owner) {
+		return
+	}
+	if err := c.Delete(ctx, &secret); err != nil && !errors.IsNotFound(err) {
+		log.FromContext(ctx).Error(err, "failed to delete secret", "secret", name)
+	}
+}
+
+// applyPendingTimeout returns the phase and message to record, turning pendingPhase into failedPhase once
+// the resource has been pending for longer than PendingTimeout. pendingSince is set while pending and
+// cleared otherwise.
+func applyPendingTimeout(pendingSince **metav1.Time, phase, message, pendingPhase, failedPhase string) (string, string) {
+	if phase != pendingPhase {
+		*pendingSince = nil
+		return phase, message
+	}
+	now := metav1.Now()
+	if *pendingSince == nil {
+		*pendingSince = &now
+	} else if now.Sub((*pendingSince).Time) > PendingTimeout {
+		return failedPhase, fmt.Sprintf("timeout: %s (pending for over %d minutes)", message, int(PendingTimeout.Minutes()))
+	}
+	return phase, message
+}
+
+// getReadyDatabase returns the referenced Database and its cluster, or a pending message while either
+// is not ready. A Database in another namespace needs a DatabaseReferenceGrant.
+func getReadyDatabase(ctx context.Context, c client.Client, namespace string, ref databasesv1alpha1.DatabaseReference) (
+	*databasesv1alpha1.Database, *databasesv1alpha1.DBCluster, string, error) {
+
+	dbNamespace := ref.Namespace
+	if dbNamespace == "" {
+		dbNamespace = namespace
+	}
+
+	var db databasesv1alpha1.Database
+	if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: dbNamespace}, &db); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, fmt.Sprintf("waiting for Database '%s'", ref.Name), nil
+		}
+		return nil, nil, "", err
+	}
+	if denied, err := checkDatabaseReference(ctx, c, namespace, &db); denied != "" || err != nil {
+		return nil, nil, denied, err
+	}
+	if db.Status.Phase != "Ready" {
+		return nil, nil, fmt.Sprintf("waiting for Database '%s' to be ready", db.Name), nil
+	}
+
+	var cluster databasesv1alpha1.DBCluster
+	if err := c.Get(ctx, types.NamespacedName{Name: db.Spec.ClusterRef.Name}, &cluster); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, fmt.Sprintf("waiting for DBCluster '%s'", db.Spec.ClusterRef.Name), nil
+		}
+		return nil, nil, "", err
+	}
+	if cluster.Status.Phase != "Connected" {
+		return nil, nil, fmt.Sprintf("waiting for DBCluster '%s' to be connected", cluster.Name), nil
+	}
+
+	return &db, &cluster, "", nil
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	databasesv1alpha1 "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+// newTemporaryRoleTestClient builds the fake client, scheme and client cache shared by the
+// DatabaseAccessGrant and DatabaseSession tests; pgClient serves the "main" cluster
+func newTemporaryRoleTestClient(pgClient *postgres.MockClient, statusObject client.Object,
+	objects ...client.Object) (client.Client, *runtime.Scheme, *postgres.MockClientCache) {
+
+	scheme := runtime.NewScheme()
+	_ = databasesv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(statusObject).
+		Build()
+
+	cache := postgres.NewMockClientCache()
+	cache.SetClient("main", pgClient)
+	return fakeClient, scheme, cache
+}
+
+func TestApplyPendingTimeout(t *testing.T) {
+	var pendingSince *metav1.Time
+
+	phase, message := applyPendingTimeout(&pendingSince, "Pending", "waiting for Database 'orders'", "Pending", "Failed")
+	if phase != "Pending" || message != "waiting for Database 'orders'" || pendingSince == nil {
+		t.Fatalf("first pending = %s, %q, pendingSince %v", phase, message, pendingSince)
+	}
+
+	expired := metav1.NewTime(time.Now().Add(-PendingTimeout - time.Minute))
+	pendingSince = &expired
+	phase, message = applyPendingTimeout(&pendingSince, "Pending", "waiting for Database 'orders'", "Pending", "Failed")
+	if phase != "Failed" || message != "timeout: waiting for Database 'orders' (pending for over 10 minutes)" {
+		t.Errorf("expired pending = %s, %q", phase, message)
+	}
+
+	phase, _ = applyPendingTimeout(&pendingSince, "Active", "", "Pending", "Failed")
+	if phase != "Active" || pendingSince != nil {
+		t.Errorf("active = %s, pendingSince %v, want cleared", phase, pendingSince)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -24,7 +25,9 @@ import (
 	"github.com/certainty3452/dbtether/controllers"
 	"github.com/certainty3452/dbtether/controllers/backup"
 	backuppkg "github.com/certainty3452/dbtether/pkg/backup"
+	"github.com/certainty3452/dbtether/pkg/notify"
 	"github.com/certainty3452/dbtether/pkg/postgres"
+	"github.com/certainty3452/dbtether/pkg/secretstore"
 	"github.com/certainty3452/dbtether/pkg/storage"
 )
 
@@ -86,8 +89,9 @@ func runController(metricsAddr, probeAddr string, enableLeaderElection bool, ope
 		os.Exit(1)
 	}
 
-	setupMainControllers(mgr)
-	setupBackupControllers(mgr, operatorNamespace)
+	notifier := setupNotifications(mgr)
+	setupMainControllers(mgr, notifier)
+	setupBackupControllers(mgr, operatorNamespace, notifier)
 	setupHealthChecks(mgr)
 
 	setupLog.Info("starting manager")
@@ -97,13 +101,14 @@ func runController(metricsAddr, probeAddr string, enableLeaderElection bool, ope
 	}
 }
 
-func setupMainControllers(mgr ctrl.Manager) {
+func setupMainControllers(mgr ctrl.Manager, notifier notify.Notifier) {
 	pgClientCache := postgres.NewClientCache()
 
 	if err := (&controllers.DBClusterReconciler{
 		Client:        mgr.GetClient(),
 		Scheme:        mgr.GetScheme(),
 		PGClientCache: pgClientCache,
+		Notifier:      notifier,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, errUnableToCreateController, "controller", "DBCluster")
 		os.Exit(1)
@@ -122,26 +127,69 @@ func setupMainControllers(mgr ctrl.Manager) {
 		Client:        mgr.GetClient(),
 		Scheme:        mgr.GetScheme(),
 		PGClientCache: pgClientCache,
+		SecretStores:  getEnvSecretStores("SECRET_STORES"),
+		Notifier:      notifier,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, errUnableToCreateController, "controller", "DatabaseUser")
 		os.Exit(1)
 	}
+
+	if err := (&controllers.DatabaseAccessGrantReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		PGClientCache: pgClientCache,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, errUnableToCreateController, "controller", "DatabaseAccessGrant")
+		os.Exit(1)
+	}
+
+	if err := (&controllers.DatabaseSessionReconciler{
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		PGClientCache:  pgClientCache,
+		SocatImage:     getEnv("SESSION_SOCAT_IMAGE", controllers.DefaultSessionSocatImage),
+		PgBouncerImage: getEnv("SESSION_PGBOUNCER_IMAGE", controllers.DefaultSessionPgBouncerImage),
+		MaxTTL:         getEnvDuration("SESSION_MAX_TTL", controllers.DefaultSessionMaxTTL),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, errUnableToCreateController, "controller", "DatabaseSession")
+		os.Exit(1)
+	}
 }
 
-func setupBackupControllers(mgr ctrl.Manager, operatorNamespace string) {
+func setupBackupControllers(mgr ctrl.Manager, operatorNamespace string, notifier notify.Notifier) {
+	maxConcurrentBackups := getEnvInt("BACKUP_MAX_CONCURRENT_PER_CLUSTER", 3)
 	if err := (&backup.BackupStorageReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:               mgr.GetClient(),
+		Scheme:               mgr.GetScheme(),
+		Namespace:            operatorNamespace,
+		MaxConcurrentBackups: maxConcurrentBackups,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, errUnableToCreateController, "controller", "BackupStorage")
 		os.Exit(1)
 	}
 
+	// An interval of "0" disables the BackupArtifact inventory
+	if d, err := time.ParseDuration(os.Getenv("BACKUP_ARTIFACT_SYNC_INTERVAL")); err != nil || d != 0 {
+		if err := (&backup.BackupArtifactReconciler{
+			Client:    mgr.GetClient(),
+			Scheme:    mgr.GetScheme(),
+			Namespace: operatorNamespace,
+			Interval:  getEnvDuration("BACKUP_ARTIFACT_SYNC_INTERVAL", backup.DefaultArtifactSyncInterval),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, errUnableToCreateController, "controller", "BackupArtifact")
+			os.Exit(1)
+		}
+	}
+
 	operatorImage := os.Getenv("OPERATOR_IMAGE")
 	if operatorImage == "" {
 		operatorImage = "certainty3452/dbtether:latest"
 	}
-	maxConcurrentBackups := getEnvInt("BACKUP_MAX_CONCURRENT_PER_CLUSTER", 3)
+	jobDefaults := getEnvJobTemplate("BACKUP_JOB_TEMPLATE")
+	pgClients := backup.PGClientConfig{
+		BundledMajor: getEnvInt("BACKUP_PG_BUNDLED_MAJOR", backup.DefaultBundledPGMajor),
+		Clients:      getEnvPGClients("BACKUP_PG_CLIENTS"),
+	}
 
 	if err := (&backup.BackupReconciler{
 		Client:               mgr.GetClient(),
@@ -149,6 +197,9 @@ func setupBackupControllers(mgr ctrl.Manager, operatorNamespace string) {
 		Image:                operatorImage,
 		Namespace:            operatorNamespace,
 		MaxConcurrentBackups: maxConcurrentBackups,
+		JobDefaults:          jobDefaults,
+		PGClients:            pgClients,
+		Notifier:             notifier,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, errUnableToCreateController, "controller", "Backup")
 		os.Exit(1)
@@ -165,17 +216,51 @@ func setupBackupControllers(mgr ctrl.Manager, operatorNamespace string) {
 		os.Exit(1)
 	}
 
-	if err := (&backup.RestoreReconciler{
+	if err := (&backup.ClusterBackupScheduleReconciler{
 		Client:    mgr.GetClient(),
 		Scheme:    mgr.GetScheme(),
-		Image:     operatorImage,
+		Log:       sugarLog.Sugar(),
 		Namespace: operatorNamespace,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, errUnableToCreateController, "controller", "ClusterBackupSchedule")
+		os.Exit(1)
+	}
+
+	if err := (&backup.RestoreReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		Image:       operatorImage,
+		Namespace:   operatorNamespace,
+		JobDefaults: jobDefaults,
+		PGClients:   pgClients,
+		Notifier:    notifier,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, errUnableToCreateController, "controller", "Restore")
 		os.Exit(1)
 	}
 }
 
+// setupNotifications registers the NotificationChannel controller and the dispatcher
+// delivering events emitted by the other controllers
+func setupNotifications(mgr ctrl.Manager) notify.Notifier {
+	if err := (&controllers.NotificationChannelReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, errUnableToCreateController, "controller", "NotificationChannel")
+		os.Exit(1)
+	}
+
+	dispatcher := notify.NewDispatcher(mgr.GetClient())
+	dispatcher.MaxAttempts = getEnvInt("NOTIFY_MAX_ATTEMPTS", notify.DefaultMaxAttempts)
+	dispatcher.DedupWindow = getEnvDuration("NOTIFY_DEDUP_WINDOW", notify.DefaultDedupWindow)
+	if err := mgr.Add(dispatcher); err != nil {
+		setupLog.Error(err, "unable to add notification dispatcher")
+		os.Exit(1)
+	}
+	return dispatcher
+}
+
 func setupHealthChecks(mgr ctrl.Manager) {
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
@@ -205,11 +290,19 @@ func runBackupJob() {
 			Endpoint:  os.Getenv("S3_ENDPOINT"),
 			AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
 			SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			Lock:      getEnvObjectLock("IMMUTABILITY"),
 		},
+		GCSConfig:        getEnvGCSConfig(),
+		AzureConfig:      getEnvAzureConfig(),
+		FilesystemConfig: storage.FilesystemConfig{Root: backuppkg.PVCMountPath},
+		SFTPConfig:       getEnvSFTPConfig(),
+		Retention:        getEnvRetention("RETENTION"),
 
 		// Templates
 		PathTemplate:     getEnv("PATH_TEMPLATE", "{{ .ClusterName }}/{{ .DatabaseName }}"),
 		FilenameTemplate: getEnv("FILENAME_TEMPLATE", "{{ .Timestamp }}.sql.gz"),
+		Compression:      os.Getenv("COMPRESSION"),
+		CompressionLevel: getEnvInt("COMPRESSION_LEVEL", 0),
 
 		// Metadata for templates and tags
 		ClusterName:  getEnvRequired("CLUSTER_NAME"),
@@ -217,6 +310,10 @@ func runBackupJob() {
 		BackupName:   getEnv("BACKUP_NAME", ""),
 		Namespace:    getEnv("BACKUP_NAMESPACE", ""),
 		RunID:        getEnvRequired("RUN_ID"),
+
+		BinDir:   os.Getenv("PG_BIN_DIR"),
+		Globals:  os.Getenv("DUMP_GLOBALS") == "true",
+		Replicas: getEnvReplicas("REPLICAS"),
 	}
 
 	setupLog.Info("starting backup job",
@@ -225,13 +322,42 @@ func runBackupJob() {
 		"cluster", cfg.ClusterName,
 	)
 
+	hooks := newHookRunner(backuppkg.HookConn{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Database: cfg.Database,
+		BinDir:   cfg.BinDir,
+	}, cfg.Username, cfg.Password)
+
 	ctx := context.Background()
-	result, err := backuppkg.RunBackup(ctx, &cfg)
+	var result *backuppkg.BackupResult
+	hookResults, err := hooks.RunAround(ctx, getEnvHooks("PRE_HOOKS"), getEnvHooks("POST_HOOKS"), func() error {
+		var backupErr error
+		result, backupErr = backuppkg.RunBackup(ctx, &cfg)
+		return backupErr
+	})
+
+	// Update Job annotations with results (for controller to read)
+	annotations := hookAnnotations(hookResults)
+	if result != nil {
+		for k, v := range backupResultAnnotations(result) {
+			annotations[k] = v
+		}
+	}
+	if err := updateJobAnnotations(ctx, annotations); err != nil {
+		setupLog.Error(err, "failed to update job annotations (non-fatal)")
+		// Non-fatal: just can't report details back
+	}
+
 	if err != nil {
 		setupLog.Error(err, "backup failed")
 		os.Exit(1)
 	}
 
+	// pvc and sftp storages are cleaned up here instead of by the schedule controller
+	retentionLog, _ := zap.NewDevelopment()
+	backuppkg.ApplyJobRetention(ctx, &cfg, result, retentionLog.Sugar())
+
 	setupLog.Info("backup completed successfully",
 		"path", result.Path,
 		"size", formatBytes(result.Size),
@@ -239,12 +365,6 @@ func runBackupJob() {
 		"duration", result.Duration.Round(time.Millisecond).String(),
 		"compressionRatio", fmt.Sprintf("%.1f%%", float64(result.Size)/float64(result.UncompressedSize)*100),
 	)
-
-	// Update Job annotations with results (for controller to read)
-	if err := updateJobAnnotations(ctx, result); err != nil {
-		setupLog.Error(err, "failed to update job annotations (non-fatal)")
-		// Non-fatal: backup succeeded, just can't report details back
-	}
 }
 
 func runRestoreJob() {
@@ -265,6 +385,11 @@ func runRestoreJob() {
 
 		// Conflict handling
 		OnConflict: getEnv("ON_CONFLICT", "fail"),
+
+		BinDir: os.Getenv("PG_BIN_DIR"),
+
+		Replicas:        getEnvReplicas("REPLICAS"),
+		ReplicaFallback: getEnv("REPLICA_FALLBACK", databasesv1alpha1.ReplicaFallbackNotFound),
 	}
 
 	// Configure storage based on type
@@ -278,15 +403,16 @@ func runRestoreJob() {
 			SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
 		}
 	case "gcs":
-		cfg.GCSConfig = &storage.GCSConfig{
-			Bucket:  os.Getenv("GCS_BUCKET"),
-			Project: os.Getenv("GCS_PROJECT"),
-		}
+		gcsCfg := getEnvGCSConfig()
+		cfg.GCSConfig = &gcsCfg
 	case "azure":
-		cfg.AzureConfig = &storage.AzureConfig{
-			Container:      os.Getenv("AZURE_CONTAINER"),
-			StorageAccount: os.Getenv("AZURE_ACCOUNT"),
-		}
+		azureCfg := getEnvAzureConfig()
+		cfg.AzureConfig = &azureCfg
+	case "pvc":
+		cfg.FilesystemConfig = &storage.FilesystemConfig{Root: backuppkg.PVCMountPath}
+	case "sftp":
+		sftpCfg := getEnvSFTPConfig()
+		cfg.SFTPConfig = &sftpCfg
 	}
 
 	setupLog.Info("starting restore job",
@@ -296,15 +422,39 @@ func runRestoreJob() {
 		"onConflict", cfg.OnConflict,
 	)
 
+	hooks := newHookRunner(backuppkg.HookConn{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Database: cfg.Database,
+		SSLMode:  cfg.SSLMode,
+		BinDir:   cfg.BinDir,
+	}, cfg.User, cfg.Password)
+
 	ctx := context.Background()
-	if err := backuppkg.RunRestore(ctx, &cfg); err != nil {
+	var result *backuppkg.RestoreResult
+	hookResults, err := hooks.RunAround(ctx, getEnvHooks("PRE_HOOKS"), getEnvHooks("POST_HOOKS"), func() error {
+		var restoreErr error
+		result, restoreErr = backuppkg.RunRestore(ctx, &cfg)
+		return restoreErr
+	})
+
+	annotations := hookAnnotations(hookResults)
+	if result != nil && result.Storage != "" {
+		annotations[backuppkg.RestoredFromAnnotation] = result.Storage
+		annotations[backuppkg.PrimaryErrorAnnotation] = result.PrimaryError
+	}
+	if err := updateJobAnnotations(ctx, annotations); err != nil {
+		setupLog.Error(err, "failed to update job annotations (non-fatal)")
+	}
+	if err != nil {
 		setupLog.Error(err, "restore failed")
 		os.Exit(1)
 	}
 
 	setupLog.Info("restore completed successfully",
 		"database", cfg.Database,
-		"source", cfg.SourcePath,
+		"source", result.Path,
+		"replica", result.Storage,
 	)
 }
 
@@ -336,6 +486,62 @@ func getEnvInt(key string, defaultVal int) int {
 	return i
 }
 
+func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		return defaultVal
+	}
+	return d
+}
+
+// getEnvJobTemplate parses operator-wide Job pod defaults from a JSON environment variable
+func getEnvJobTemplate(key string) *databasesv1alpha1.JobTemplate {
+	val := os.Getenv(key)
+	if val == "" {
+		return nil
+	}
+	var tmpl databasesv1alpha1.JobTemplate
+	if err := json.Unmarshal([]byte(val), &tmpl); err != nil {
+		setupLog.Error(err, "invalid job template, ignoring", "key", key)
+		return nil
+	}
+	return &tmpl
+}
+
+// getEnvSecretStores parses the Vault/AWS secret store configuration from a JSON environment variable.
+// The Vault token, if any, is read from VAULT_TOKEN.
+func getEnvSecretStores(key string) secretstore.Settings {
+	var settings secretstore.Settings
+	if val := os.Getenv(key); val != "" {
+		if err := json.Unmarshal([]byte(val), &settings); err != nil {
+			setupLog.Error(err, "invalid secret store configuration, ignoring", "key", key)
+			return secretstore.Settings{}
+		}
+	}
+	if settings.Vault != nil {
+		settings.Vault.Token = os.Getenv("VAULT_TOKEN")
+	}
+	return settings
+}
+
+// getEnvPGClients parses the PostgreSQL client per server major version from a JSON environment variable
+func getEnvPGClients(key string) map[int]backup.PGClient {
+	val := os.Getenv(key)
+	if val == "" {
+		return nil
+	}
+	var clients map[int]backup.PGClient
+	if err := json.Unmarshal([]byte(val), &clients); err != nil {
+		setupLog.Error(err, "invalid PostgreSQL client mapping, ignoring", "key", key)
+		return nil
+	}
+	return clients
+}
+
 // formatBytes formats bytes as human-readable string
 func formatBytes(bytes int64) string {
 	const unit = 1024
@@ -350,8 +556,162 @@ func formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-// updateJobAnnotations updates the Job with backup result annotations
-func updateJobAnnotations(ctx context.Context, result *backuppkg.BackupResult) error {
+// newHookRunner configures the hooks of a backup or restore Job. The admin credentials only create
+// and drop HOOK_ROLE, the role SQL hooks run as; HTTP hooks may only reach HOOK_ALLOWED_HTTP_HOSTS.
+func newHookRunner(conn backuppkg.HookConn, adminUser, adminPassword string) *backuppkg.HookRunner {
+	var allowedHosts []string
+	if val := os.Getenv("HOOK_ALLOWED_HTTP_HOSTS"); val != "" {
+		if err := json.Unmarshal([]byte(val), &allowedHosts); err != nil {
+			setupLog.Error(err, "invalid HOOK_ALLOWED_HTTP_HOSTS, must be a JSON list")
+			os.Exit(1)
+		}
+	}
+
+	return &backuppkg.HookRunner{
+		Conn: conn,
+		Admin: postgres.Config{
+			Host:     conn.Host,
+			Port:     conn.Port,
+			Username: adminUser,
+			Password: adminPassword,
+
+			ServerSidePasswordEncryption: os.Getenv("HOOK_PASSWORD_ENCRYPTION") == databasesv1alpha1.PasswordEncryptionServer,
+		},
+		RoleName:         os.Getenv("HOOK_ROLE"),
+		AllowedHTTPHosts: allowedHosts,
+	}
+}
+
+// getEnvHooks parses pre/post hooks passed by the controller as JSON
+func getEnvHooks(key string) []databasesv1alpha1.Hook {
+	val := os.Getenv(key)
+	if val == "" {
+		return nil
+	}
+	var hooks []databasesv1alpha1.Hook
+	if err := json.Unmarshal([]byte(val), &hooks); err != nil {
+		setupLog.Error(err, "invalid hooks, must be JSON", "key", key)
+		os.Exit(1)
+	}
+	return hooks
+}
+
+// getEnvObjectLock parses the storage immutability settings passed by the controller as JSON
+func getEnvObjectLock(key string) *storage.ObjectLock {
+	val := os.Getenv(key)
+	if val == "" {
+		return nil
+	}
+	var cfg databasesv1alpha1.ImmutabilityConfig
+	if err := json.Unmarshal([]byte(val), &cfg); err != nil {
+		setupLog.Error(err, "invalid immutability, must be JSON", "key", key)
+		os.Exit(1)
+	}
+	return backuppkg.ObjectLock(&cfg)
+}
+
+// getEnvGCSConfig reads the GCS storage settings; the key comes from the credentials secret
+func getEnvGCSConfig() storage.GCSConfig {
+	return storage.GCSConfig{
+		Bucket:          os.Getenv("GCS_BUCKET"),
+		Project:         os.Getenv("GCS_PROJECT"),
+		CredentialsJSON: []byte(os.Getenv("GCS_SERVICE_ACCOUNT_JSON")),
+		Lock:            getEnvObjectLock("IMMUTABILITY"),
+	}
+}
+
+// getEnvAzureConfig reads the Azure storage settings; credentials come from the credentials secret
+func getEnvAzureConfig() storage.AzureConfig {
+	cfg := storage.AzureConfig{
+		Container:      os.Getenv("AZURE_CONTAINER"),
+		StorageAccount: os.Getenv("AZURE_ACCOUNT"),
+		Lock:           getEnvObjectLock("IMMUTABILITY"),
+	}
+	backuppkg.SetAzureCredentials(&cfg, os.Getenv)
+	return cfg
+}
+
+// getEnvSFTPConfig reads the SFTP storage settings; the key comes from the credentials secret
+func getEnvSFTPConfig() storage.SFTPConfig {
+	return storage.SFTPConfig{
+		Host:       os.Getenv("SFTP_HOST"),
+		Port:       getEnvInt("SFTP_PORT", 22),
+		User:       os.Getenv("SFTP_USER"),
+		Path:       os.Getenv("SFTP_PATH"),
+		PrivateKey: os.Getenv("SSH_PRIVATE_KEY"),
+		KnownHosts: os.Getenv("SFTP_KNOWN_HOSTS"),
+	}
+}
+
+// getEnvRetention parses the schedule retention passed by the controller as JSON
+func getEnvRetention(key string) *databasesv1alpha1.RetentionPolicy {
+	val := os.Getenv(key)
+	if val == "" {
+		return nil
+	}
+	var retention databasesv1alpha1.RetentionPolicy
+	if err := json.Unmarshal([]byte(val), &retention); err != nil {
+		setupLog.Error(err, "invalid retention, must be JSON", "key", key)
+		os.Exit(1)
+	}
+	return &retention
+}
+
+// getEnvReplicas parses the replica storages passed by the controller as JSON
+func getEnvReplicas(key string) []backuppkg.StorageTarget {
+	val := os.Getenv(key)
+	if val == "" {
+		return nil
+	}
+	var replicas []backuppkg.Replica
+	if err := json.Unmarshal([]byte(val), &replicas); err != nil {
+		setupLog.Error(err, "invalid replicas, must be JSON", "key", key)
+		os.Exit(1)
+	}
+	targets := make([]backuppkg.StorageTarget, 0, len(replicas))
+	for i := range replicas {
+		targets = append(targets, replicas[i].Target(i, os.Getenv))
+	}
+	return targets
+}
+
+// backupResultAnnotations returns the Job annotations describing a finished backup
+func backupResultAnnotations(result *backuppkg.BackupResult) map[string]string {
+	annotations := map[string]string{
+		"dbtether.io/backup-path":              result.Path,
+		"dbtether.io/backup-size":              strconv.FormatInt(result.Size, 10),
+		"dbtether.io/backup-size-human":        formatBytes(result.Size),
+		"dbtether.io/backup-uncompressed-size": strconv.FormatInt(result.UncompressedSize, 10),
+		"dbtether.io/backup-duration":          result.Duration.Round(time.Millisecond).String(),
+	}
+	if len(result.Replicas) > 0 {
+		if data, err := json.Marshal(result.Replicas); err == nil {
+			annotations[backuppkg.ReplicasAnnotation] = string(data)
+		}
+	}
+	return annotations
+}
+
+// hookAnnotations returns the Job annotation with hook results (empty without hooks)
+func hookAnnotations(results []databasesv1alpha1.HookResult) map[string]string {
+	annotations := map[string]string{}
+	if len(results) == 0 {
+		return annotations
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		setupLog.Error(err, "failed to encode hook results")
+		return annotations
+	}
+	annotations[backuppkg.HookResultsAnnotation] = string(data)
+	return annotations
+}
+
+// updateJobAnnotations adds result annotations to the Job running this process
+func updateJobAnnotations(ctx context.Context, annotations map[string]string) error {
+	if len(annotations) == 0 {
+		return nil
+	}
 	jobName := os.Getenv("JOB_NAME")
 	jobNamespace := os.Getenv("JOB_NAMESPACE")
 	if jobName == "" || jobNamespace == "" {
@@ -368,26 +728,18 @@ func updateJobAnnotations(ctx context.Context, result *backuppkg.BackupResult) e
 		return fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
-	// Prepare patch with annotations
-	patch := fmt.Sprintf(`{"metadata":{"annotations":{
-		"dbtether.io/backup-path": %q,
-		"dbtether.io/backup-size": "%d",
-		"dbtether.io/backup-size-human": %q,
-		"dbtether.io/backup-uncompressed-size": "%d",
-		"dbtether.io/backup-duration": %q
-	}}}`,
-		result.Path,
-		result.Size,
-		formatBytes(result.Size),
-		result.UncompressedSize,
-		result.Duration.Round(time.Millisecond).String(),
-	)
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{"annotations": annotations},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode patch: %w", err)
+	}
 
 	_, err = clientset.BatchV1().Jobs(jobNamespace).Patch(
 		ctx,
 		jobName,
 		types.MergePatchType,
-		[]byte(patch),
+		patch,
 		metav1.PatchOptions{},
 	)
 	if err != nil {
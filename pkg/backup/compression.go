@@ -0,0 +1,181 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+// Magic bytes at the start of a compressed backup, used by restores to detect the codec
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	lz4Magic  = []byte{0x04, 0x22, 0x4d, 0x18}
+)
+
+// maxCompressionLevel is the highest level of each codec; none has no levels
+var maxCompressionLevel = map[string]int{
+	dbtether.CompressionGzip: gzip.BestCompression,
+	dbtether.CompressionZstd: 22,
+	dbtether.CompressionLZ4:  9,
+	dbtether.CompressionNone: 0,
+}
+
+// lz4Levels maps levels 1-9 to lz4 compression levels
+var lz4Levels = []lz4.CompressionLevel{
+	lz4.Level1, lz4.Level2, lz4.Level3, lz4.Level4, lz4.Level5, lz4.Level6, lz4.Level7, lz4.Level8, lz4.Level9,
+}
+
+// ValidateCompression checks a codec and level; an empty codec is gzip and level 0 the codec's
+// default level
+func ValidateCompression(codec string, level int) error {
+	if codec == "" {
+		codec = dbtether.CompressionGzip
+	}
+	maxLevel, ok := maxCompressionLevel[codec]
+	if !ok {
+		return fmt.Errorf("unsupported compression codec %q (gzip, zstd, lz4 or none)", codec)
+	}
+	if level == 0 {
+		return nil
+	}
+	if maxLevel == 0 {
+		return fmt.Errorf("compression level is not supported with codec %s", codec)
+	}
+	if level < 1 || level > maxLevel {
+		return fmt.Errorf("compression level %d out of range for %s (1-%d)", level, codec, maxLevel)
+	}
+	return nil
+}
+
+// CompressionExtension returns the file extension of a codec, e.g. ".zst"
+func CompressionExtension(codec string) string {
+	switch codec {
+	case dbtether.CompressionZstd:
+		return ".zst"
+	case dbtether.CompressionLZ4:
+		return ".lz4"
+	case dbtether.CompressionNone:
+		return ""
+	default:
+		return ".gz"
+	}
+}
+
+// compressedFilename gives a filename ending in .gz, like the default filename template, the
+// extension of the codec; other filenames are kept as the user wrote them
+func compressedFilename(filename, codec string) string {
+	if !strings.HasSuffix(filename, ".gz") {
+		return filename
+	}
+	return strings.TrimSuffix(filename, ".gz") + CompressionExtension(codec)
+}
+
+// compress compresses data with codec at level (0: the codec's default level)
+func compress(data []byte, codec string, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+
+	switch codec {
+	case "", dbtether.CompressionGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		gz, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, fmt.Errorf("gzip compression failed: %w", err)
+		}
+		w = gz
+	case dbtether.CompressionZstd:
+		encoderLevel := zstd.SpeedDefault
+		if level > 0 {
+			encoderLevel = zstd.EncoderLevelFromZstd(level)
+		}
+		enc, err := zstd.NewWriter(&buf, zstd.WithEncoderLevel(encoderLevel))
+		if err != nil {
+			return nil, fmt.Errorf("zstd compression failed: %w", err)
+		}
+		w = enc
+	case dbtether.CompressionLZ4:
+		lw := lz4.NewWriter(&buf)
+		if level > 0 {
+			if err := lw.Apply(lz4.CompressionLevelOption(lz4Levels[level-1])); err != nil {
+				return nil, fmt.Errorf("lz4 compression failed: %w", err)
+			}
+		}
+		w = lw
+	case dbtether.CompressionNone:
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression codec %q", codec)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("%s compression failed: %w", codecName(codec), err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("%s close failed: %w", codecName(codec), err)
+	}
+	return buf.Bytes(), nil
+}
+
+func codecName(codec string) string {
+	if codec == "" {
+		return dbtether.CompressionGzip
+	}
+	return codec
+}
+
+// DetectCompression returns the codec of a backup from its first bytes. Anything that is not
+// gzip, zstd or lz4 is treated as an uncompressed SQL dump.
+func DetectCompression(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return dbtether.CompressionGzip
+	case bytes.HasPrefix(header, zstdMagic):
+		return dbtether.CompressionZstd
+	case bytes.HasPrefix(header, lz4Magic):
+		return dbtether.CompressionLZ4
+	default:
+		return dbtether.CompressionNone
+	}
+}
+
+// decompressReader returns the decompressed backup and its codec, detected from the content so
+// restores do not depend on the file extension
+func decompressReader(r io.Reader) (io.ReadCloser, string, error) {
+	br := bufio.NewReader(r)
+	// A short file is an (almost) empty dump; Peek then returns fewer bytes and io.EOF
+	header, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, "", fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	codec := DetectCompression(header)
+	switch codec {
+	case dbtether.CompressionGzip:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, codec, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		return gz, codec, nil
+	case dbtether.CompressionZstd:
+		dec, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, codec, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		return dec.IOReadCloser(), codec, nil
+	case dbtether.CompressionLZ4:
+		return io.NopCloser(lz4.NewReader(br)), codec, nil
+	default:
+		return io.NopCloser(br), codec, nil
+	}
+}
@@ -0,0 +1,117 @@
+package backup
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	dump := []byte(strings.Repeat("INSERT INTO orders VALUES (1, 'pending');\n", 1000))
+
+	tests := []struct {
+		codec string
+		level int
+	}{
+		{"", 0},
+		{dbtether.CompressionGzip, 1},
+		{dbtether.CompressionZstd, 0},
+		{dbtether.CompressionZstd, 19},
+		{dbtether.CompressionLZ4, 0},
+		{dbtether.CompressionLZ4, 9},
+		{dbtether.CompressionNone, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			compressed, err := compress(dump, tt.codec, tt.level)
+			if err != nil {
+				t.Fatalf("compress() error = %v", err)
+			}
+			if tt.codec != dbtether.CompressionNone && len(compressed) >= len(dump) {
+				t.Errorf("compressed size %d, want less than %d", len(compressed), len(dump))
+			}
+
+			reader, codec, err := decompressReader(bytes.NewReader(compressed))
+			if err != nil {
+				t.Fatalf("decompressReader() error = %v", err)
+			}
+			defer func() { _ = reader.Close() }()
+			if want := codecName(tt.codec); codec != want {
+				t.Errorf("detected codec = %s, want %s", codec, want)
+			}
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("read error = %v", err)
+			}
+			if !bytes.Equal(got, dump) {
+				t.Error("decompressed dump differs from the original")
+			}
+		})
+	}
+}
+
+func TestDecompressReader_ShortPlainDump(t *testing.T) {
+	reader, codec, err := decompressReader(strings.NewReader("--\n"))
+	if err != nil {
+		t.Fatalf("decompressReader() error = %v", err)
+	}
+	got, _ := io.ReadAll(reader)
+	if codec != dbtether.CompressionNone || string(got) != "--\n" {
+		t.Errorf("codec = %s, content = %q", codec, got)
+	}
+}
+
+func TestValidateCompression(t *testing.T) {
+	tests := []struct {
+		codec   string
+		level   int
+		wantErr string
+	}{
+		{codec: "", level: 0},
+		{codec: "gzip", level: 9},
+		{codec: "zstd", level: 22},
+		{codec: "lz4", level: 1},
+		{codec: "none"},
+		{codec: "gzip", level: 10, wantErr: "compression level 10 out of range for gzip (1-9)"},
+		{codec: "lz4", level: 12, wantErr: "compression level 12 out of range for lz4 (1-9)"},
+		{codec: "none", level: 3, wantErr: "compression level is not supported with codec none"},
+		{codec: "brotli", wantErr: `unsupported compression codec "brotli" (gzip, zstd, lz4 or none)`},
+	}
+
+	for _, tt := range tests {
+		err := ValidateCompression(tt.codec, tt.level)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateCompression(%q, %d) error = %v", tt.codec, tt.level, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("ValidateCompression(%q, %d) error = %v, want %q", tt.codec, tt.level, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCompressedFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		codec    string
+		want     string
+	}{
+		{"20260120-140000.sql.gz", "", "20260120-140000.sql.gz"},
+		{"20260120-140000.sql.gz", "zstd", "20260120-140000.sql.zst"},
+		{"20260120-140000.sql.gz", "lz4", "20260120-140000.sql.lz4"},
+		{"20260120-140000.sql.gz", "none", "20260120-140000.sql"},
+		{"orders-20260120.dump", "zstd", "orders-20260120.dump"},
+	}
+
+	for _, tt := range tests {
+		if got := compressedFilename(tt.filename, tt.codec); got != tt.want {
+			t.Errorf("compressedFilename(%q, %q) = %q, want %q", tt.filename, tt.codec, got, tt.want)
+		}
+	}
+}
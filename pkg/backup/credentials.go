@@ -0,0 +1,13 @@
+package backup
+
+import "github.com/certainty3452/dbtether/pkg/storage"
+
+// SetAzureCredentials fills the credentials of cfg from the credentials secret keys the controller
+// passes to the Job, read with getenv. Keys missing from the secret leave their field empty.
+func SetAzureCredentials(cfg *storage.AzureConfig, getenv func(key string) string) {
+	cfg.ConnectionString = getenv("AZURE_STORAGE_CONNECTION_STRING")
+	cfg.SASToken = getenv("AZURE_STORAGE_SAS_TOKEN")
+	cfg.TenantID = getenv("AZURE_TENANT_ID")
+	cfg.ClientID = getenv("AZURE_CLIENT_ID")
+	cfg.ClientSecret = getenv("AZURE_CLIENT_SECRET")
+}
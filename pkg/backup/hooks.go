@@ -0,0 +1,324 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+// DefaultHookTimeout limits hooks without an explicit timeout
+const DefaultHookTimeout = 30 * time.Second
+
+// Hook stages and result phases
+const (
+	HookStagePre  = "pre"
+	HookStagePost = "post"
+
+	HookSucceeded = "Succeeded"
+	HookFailed    = "Failed"
+	HookSkipped   = "Skipped"
+)
+
+// HookResultsAnnotation carries the JSON-encoded hook results from the Job to the controller
+const HookResultsAnnotation = "dbtether.io/hook-results"
+
+// maxHookMessage limits output and response bodies kept in hook results
+const maxHookMessage = 256
+
+// HookRoleTTL is the VALID UNTIL of the hook role, so a role left behind by a killed Job stops working
+const HookRoleTTL = 24 * time.Hour
+
+// hookRolePrivileges is the privilege preset of the hook role in the target database
+const hookRolePrivileges = "readwrite"
+
+// hookRolePasswordLength is the length of the generated hook role password
+const hookRolePasswordLength = 24
+
+// HookRoleName returns the name of the role SQL hooks of a Job run as
+func HookRoleName(runID string) string {
+	return "dbtether_hook_" + runID
+}
+
+// HookConn is the database SQL hooks run against
+type HookConn struct {
+	Host     string
+	Port     int
+	Database string
+	SSLMode  string
+
+	// Directory with psql; empty uses PATH
+	BinDir string
+}
+
+// HookRunner executes pre/post hooks inside backup and restore Jobs
+type HookRunner struct {
+	Conn HookConn
+
+	// Admin connects with the cluster admin credentials to create and drop the hook role.
+	// SQL hooks themselves log in as RoleName, which only has privileges on Conn.Database.
+	Admin postgres.Config
+	// RoleName is the per-Job role SQL hooks run as (see HookRoleName)
+	RoleName string
+
+	// AllowedHTTPHosts are the hosts HTTP hooks and their redirects may reach
+	// (DBCluster spec.hooks.allowedHTTPHosts)
+	AllowedHTTPHosts []string
+	HTTPClient       *http.Client
+
+	// rolePassword is the generated password of RoleName while the role exists
+	rolePassword string
+	// adminClient replaces the connection from Admin (tests)
+	adminClient postgres.ClientInterface
+	// runSQL executes a SQL hook; defaults to psql against Conn
+	runSQL func(ctx context.Context, sql string) error
+}
+
+// RunAround runs the pre hooks, then fn unless a pre hook aborted, then the post hooks.
+// Post hooks run even when fn or a pre hook failed, so they can undo what pre hooks did
+// (e.g. resume a paused consumer). The first error from pre hooks, fn or post hooks is returned.
+// With SQL hooks, the hook role is created first and dropped at the end; fn does not run when
+// the role cannot be created.
+func (r *HookRunner) RunAround(ctx context.Context, pre, post []dbtether.Hook, fn func() error) ([]dbtether.HookResult, error) {
+	if !hasSQLHook(pre) && !hasSQLHook(post) {
+		return r.runAround(ctx, pre, post, fn)
+	}
+
+	admin := r.adminClient
+	if admin == nil {
+		client, err := postgres.NewClient(ctx, r.Admin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to create the hook role: %w", err)
+		}
+		defer client.Close()
+		admin = client
+	}
+	if err := r.createRole(ctx, admin); err != nil {
+		return nil, err
+	}
+	defer r.dropRole(ctx, admin)
+
+	return r.runAround(ctx, pre, post, fn)
+}
+
+func (r *HookRunner) runAround(ctx context.Context, pre, post []dbtether.Hook, fn func() error) ([]dbtether.HookResult, error) {
+	results, err := r.Run(ctx, HookStagePre, pre)
+	if err == nil {
+		err = fn()
+	}
+
+	postResults, postErr := r.Run(ctx, HookStagePost, post)
+	results = append(results, postResults...)
+	if err == nil {
+		err = postErr
+	}
+	return results, err
+}
+
+// createRole creates the hook role (or resets the password of the role left by an earlier
+// attempt of the same Job) with CONNECT and readwrite privileges on the target database
+func (r *HookRunner) createRole(ctx context.Context, admin postgres.ClientInterface) error {
+	if r.RoleName == "" {
+		return fmt.Errorf("no role configured for SQL hooks")
+	}
+	password, err := postgres.GeneratePassword(hookRolePasswordLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate hook role password: %w", err)
+	}
+
+	exists, err := admin.UserExists(ctx, r.RoleName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = admin.SetPassword(ctx, r.RoleName, password)
+	} else {
+		err = admin.CreateUser(ctx, r.RoleName, password)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create hook role: %w", err)
+	}
+	r.rolePassword = password
+
+	if err := admin.SetRoleAttributes(ctx, r.RoleName, postgres.RoleAttributes{
+		ValidUntil: time.Now().Add(HookRoleTTL).UTC().Format(time.RFC3339),
+	}); err != nil {
+		return fmt.Errorf("failed to set hook role expiry: %w", err)
+	}
+	if err := admin.GrantDatabaseAccess(ctx, r.RoleName, r.Conn.Database); err != nil {
+		return fmt.Errorf("failed to grant hook role access: %w", err)
+	}
+	if err := admin.ApplyPrivileges(ctx, r.RoleName, r.Conn.Database, hookRolePrivileges, nil); err != nil {
+		return fmt.Errorf("failed to grant hook role privileges: %w", err)
+	}
+	return nil
+}
+
+// dropRole removes the hook role; a failure only leaves a role that expires after HookRoleTTL
+func (r *HookRunner) dropRole(ctx context.Context, admin postgres.ClientInterface) {
+	r.rolePassword = ""
+	if err := admin.RevokePrivilegesInDatabase(ctx, r.RoleName, r.Conn.Database); err != nil {
+		return
+	}
+	_ = admin.DropUser(ctx, r.RoleName)
+}
+
+func hasSQLHook(hooks []dbtether.Hook) bool {
+	for _, hook := range hooks {
+		if hook.SQL != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes hooks in order. A failing hook with onFailure Abort stops the stage:
+// the remaining hooks are reported as Skipped and an error is returned.
+func (r *HookRunner) Run(ctx context.Context, stage string, hooks []dbtether.Hook) ([]dbtether.HookResult, error) {
+	results := make([]dbtether.HookResult, 0, len(hooks))
+	var abortErr error
+
+	for i := range hooks {
+		hook := &hooks[i]
+		result := dbtether.HookResult{Name: hook.Name, Stage: stage}
+
+		if abortErr != nil {
+			result.Phase = HookSkipped
+			results = append(results, result)
+			continue
+		}
+
+		start := time.Now()
+		err := r.runHook(ctx, hook)
+		result.Duration = time.Since(start).Round(time.Millisecond).String()
+
+		if err != nil {
+			result.Phase = HookFailed
+			result.Message = truncate(err.Error(), maxHookMessage)
+			if hook.AbortsOnFailure() {
+				abortErr = fmt.Errorf("%s hook %q failed: %w", stage, hook.Name, err)
+			}
+		} else {
+			result.Phase = HookSucceeded
+		}
+		results = append(results, result)
+	}
+
+	return results, abortErr
+}
+
+func (r *HookRunner) runHook(ctx context.Context, hook *dbtether.Hook) error {
+	timeout := DefaultHookTimeout
+	if hook.Timeout != nil && hook.Timeout.Duration > 0 {
+		timeout = hook.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch {
+	case hook.SQL != "" && hook.HTTP == nil:
+		runSQL := r.runSQL
+		if runSQL == nil {
+			runSQL = r.runPsql
+		}
+		return runSQL(ctx, hook.SQL)
+	case hook.HTTP != nil && hook.SQL == "":
+		return r.runHTTP(ctx, hook.HTTP)
+	default:
+		return fmt.Errorf("exactly one of sql or http must be set")
+	}
+}
+
+// runPsql runs SQL with psql, stopping at the first error
+func (r *HookRunner) runPsql(ctx context.Context, sql string) error {
+	if r.rolePassword == "" {
+		return fmt.Errorf("hook role %s was not created", r.RoleName)
+	}
+	// #nosec G204 -- args from trusted config (CRD spec), not user input
+	cmd := exec.CommandContext(ctx, pgBinary(r.Conn.BinDir, "psql"),
+		"--host", r.Conn.Host,
+		"--port", fmt.Sprintf("%d", r.Conn.Port),
+		"--dbname", r.Conn.Database,
+		"--username", r.RoleName,
+		"--no-psqlrc",
+		"--quiet",
+		"--set", "ON_ERROR_STOP=1",
+		"--command", sql,
+	)
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+r.rolePassword)
+	if r.Conn.SSLMode != "" {
+		cmd.Env = append(cmd.Env, "PGSSLMODE="+r.Conn.SSLMode)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out: %w", ctx.Err())
+		}
+		return fmt.Errorf("psql: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+func (r *HookRunner) runHTTP(ctx context.Context, spec *dbtether.HTTPHook) error {
+	method := spec.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	var body io.Reader
+	if spec.Body != "" {
+		body = strings.NewReader(spec.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, spec.URL, body)
+	if err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	if !dbtether.MatchHTTPHost(r.AllowedHTTPHosts, req.URL.Host) {
+		return fmt.Errorf("host %s is not allowed for HTTP hooks", req.URL.Host)
+	}
+	for k, v := range spec.Headers {
+		req.Header.Set(k, v)
+	}
+
+	httpClient := http.DefaultClient
+	if r.HTTPClient != nil {
+		httpClient = r.HTTPClient
+	}
+	// Copy the client so redirects are checked against the allowed hosts too
+	checked := *httpClient
+	checked.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !dbtether.MatchHTTPHost(r.AllowedHTTPHosts, req.URL.Host) {
+			return fmt.Errorf("redirect to %s is not allowed for HTTP hooks", req.URL.Host)
+		}
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		return nil
+	}
+	resp, err := checked.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxHookMessage))
+		return fmt.Errorf("%s %s returned %d: %s", method, spec.URL, resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,300 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/postgres"
+)
+
+// fakeSQL records executed statements and fails those listed in failing
+func fakeSQL(executed *[]string, failing ...string) func(ctx context.Context, sql string) error {
+	return func(_ context.Context, sql string) error {
+		*executed = append(*executed, sql)
+		for _, f := range failing {
+			if sql == f {
+				return errors.New("ERROR: permission denied")
+			}
+		}
+		return nil
+	}
+}
+
+// newSQLHookRunner returns a runner whose hook role is managed by a mock admin client
+func newSQLHookRunner(executed *[]string, failing ...string) (*HookRunner, *postgres.MockClient) {
+	admin := postgres.NewMockClient()
+	return &HookRunner{
+		Conn:        HookConn{Database: "orders"},
+		RoleName:    HookRoleName("abcd1234"),
+		adminClient: admin,
+		runSQL:      fakeSQL(executed, failing...),
+	}, admin
+}
+
+func phases(results []dbtether.HookResult) string {
+	parts := make([]string, 0, len(results))
+	for _, r := range results {
+		parts = append(parts, r.Stage+"/"+r.Name+"="+r.Phase)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestHookRunner_Run(t *testing.T) {
+	tests := []struct {
+		name       string
+		hooks      []dbtether.Hook
+		failing    []string
+		wantPhases string
+		wantErr    bool
+	}{
+		{
+			name:       "all succeed",
+			hooks:      []dbtether.Hook{{Name: "a", SQL: "SELECT 1"}, {Name: "b", SQL: "SELECT 2"}},
+			wantPhases: "pre/a=Succeeded pre/b=Succeeded",
+		},
+		{
+			name:       "abort skips remaining hooks",
+			hooks:      []dbtether.Hook{{Name: "a", SQL: "SELECT 1"}, {Name: "b", SQL: "SELECT 2"}},
+			failing:    []string{"SELECT 1"},
+			wantPhases: "pre/a=Failed pre/b=Skipped",
+			wantErr:    true,
+		},
+		{
+			name:       "continue records failure",
+			hooks:      []dbtether.Hook{{Name: "a", SQL: "SELECT 1", OnFailure: "Continue"}, {Name: "b", SQL: "SELECT 2"}},
+			failing:    []string{"SELECT 1"},
+			wantPhases: "pre/a=Failed pre/b=Succeeded",
+		},
+		{
+			name:       "invalid hook fails",
+			hooks:      []dbtether.Hook{{Name: "empty"}},
+			wantPhases: "pre/empty=Failed",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executed []string
+			r := &HookRunner{runSQL: fakeSQL(&executed, tt.failing...)}
+
+			results, err := r.Run(context.Background(), HookStagePre, tt.hooks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := phases(results); got != tt.wantPhases {
+				t.Errorf("phases = %q, want %q", got, tt.wantPhases)
+			}
+		})
+	}
+}
+
+func TestHookRunner_RunAround(t *testing.T) {
+	pre := []dbtether.Hook{{Name: "pause", SQL: "pause"}}
+	post := []dbtether.Hook{{Name: "resume", SQL: "resume"}}
+
+	t.Run("post hooks run after a failed backup", func(t *testing.T) {
+		var executed []string
+		r, _ := newSQLHookRunner(&executed)
+
+		results, err := r.RunAround(context.Background(), pre, post, func() error {
+			return errors.New("pg_dump failed")
+		})
+		if err == nil || err.Error() != "pg_dump failed" {
+			t.Fatalf("expected the backup error, got %v", err)
+		}
+		if got := phases(results); got != "pre/pause=Succeeded post/resume=Succeeded" {
+			t.Errorf("phases = %q", got)
+		}
+	})
+
+	t.Run("aborted pre hook skips the backup but not post hooks", func(t *testing.T) {
+		var executed []string
+		r, _ := newSQLHookRunner(&executed, "pause")
+		called := false
+
+		_, err := r.RunAround(context.Background(), pre, post, func() error {
+			called = true
+			return nil
+		})
+		if err == nil || !strings.Contains(err.Error(), `pre hook "pause" failed`) {
+			t.Fatalf("expected pre hook error, got %v", err)
+		}
+		if called {
+			t.Error("backup must not run after an aborted pre hook")
+		}
+		if strings.Join(executed, ",") != "pause,resume" {
+			t.Errorf("executed = %v", executed)
+		}
+	})
+
+	t.Run("aborted post hook fails a successful backup", func(t *testing.T) {
+		var executed []string
+		r, _ := newSQLHookRunner(&executed, "resume")
+
+		_, err := r.RunAround(context.Background(), pre, post, func() error { return nil })
+		if err == nil || !strings.Contains(err.Error(), `post hook "resume" failed`) {
+			t.Fatalf("expected post hook error, got %v", err)
+		}
+	})
+}
+
+func TestHookRunner_HookRole(t *testing.T) {
+	pre := []dbtether.Hook{{Name: "pause", SQL: "pause"}}
+
+	t.Run("created for the target database and dropped afterwards", func(t *testing.T) {
+		var executed []string
+		r, admin := newSQLHookRunner(&executed)
+
+		_, err := r.RunAround(context.Background(), pre, nil, func() error {
+			if admin.GetPassword("dbtether_hook_abcd1234") == "" || r.rolePassword == "" {
+				t.Error("hook role does not exist while the hooks run")
+			}
+			access, _ := admin.GetUserDatabaseAccess(context.Background(), "dbtether_hook_abcd1234")
+			if strings.Join(access, ",") != "orders" {
+				t.Errorf("hook role database access = %v, want [orders]", access)
+			}
+			if admin.GetRoleAttributes("dbtether_hook_abcd1234").ValidUntil == "" {
+				t.Error("hook role has no VALID UNTIL")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("RunAround() error = %v", err)
+		}
+		if users := admin.GetUsers(); len(users) != 0 {
+			t.Errorf("hook role not dropped: %v", users)
+		}
+	})
+
+	t.Run("no role without SQL hooks", func(t *testing.T) {
+		var executed []string
+		r, admin := newSQLHookRunner(&executed)
+		r.adminClient = nil
+		r.Admin = postgres.Config{Host: "unreachable.invalid"}
+
+		if _, err := r.RunAround(context.Background(), nil, nil, func() error { return nil }); err != nil {
+			t.Fatalf("RunAround() error = %v", err)
+		}
+		if users := admin.GetUsers(); len(users) != 0 {
+			t.Errorf("unexpected roles: %v", users)
+		}
+	})
+
+	t.Run("backup does not run without the role", func(t *testing.T) {
+		var executed []string
+		r, admin := newSQLHookRunner(&executed)
+		admin.ShouldFail = true
+		admin.FailError = errors.New("permission denied to create role")
+		called := false
+
+		_, err := r.RunAround(context.Background(), pre, nil, func() error {
+			called = true
+			return nil
+		})
+		if err == nil || called || len(executed) != 0 {
+			t.Errorf("RunAround() error = %v, backup ran = %v, executed = %v", err, called, executed)
+		}
+	})
+}
+
+func TestHookRunner_HTTP(t *testing.T) {
+	var gotMethod, gotBody, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotMethod = req.Method
+		gotHeader = req.Header.Get("X-Reason")
+		body, _ := io.ReadAll(req.Body)
+		gotBody = string(body)
+		if req.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("consumer busy"))
+		}
+	}))
+	defer server.Close()
+
+	r := &HookRunner{HTTPClient: server.Client(), AllowedHTTPHosts: []string{strings.TrimPrefix(server.URL, "http://")}}
+	results, err := r.Run(context.Background(), HookStagePost, []dbtether.Hook{
+		{Name: "resume", HTTP: &dbtether.HTTPHook{
+			URL:     server.URL + "/resume",
+			Headers: map[string]string{"X-Reason": "backup"},
+			Body:    `{"consumer":"orders"}`,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if results[0].Phase != HookSucceeded {
+		t.Errorf("phase = %s", results[0].Phase)
+	}
+	if gotMethod != http.MethodPost || gotHeader != "backup" || gotBody != `{"consumer":"orders"}` {
+		t.Errorf("request = %s %q %q", gotMethod, gotHeader, gotBody)
+	}
+
+	results, err = r.Run(context.Background(), HookStagePost, []dbtether.Hook{
+		{Name: "fail", HTTP: &dbtether.HTTPHook{URL: server.URL + "/fail", Method: http.MethodGet}},
+	})
+	if err == nil {
+		t.Fatal("expected error for 503 response")
+	}
+	if !strings.Contains(results[0].Message, "returned 503: consumer busy") {
+		t.Errorf("message = %q", results[0].Message)
+	}
+}
+
+func TestHookRunner_HTTPAllowedHosts(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer internal.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, internal.URL+"/metadata", http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	r := &HookRunner{AllowedHTTPHosts: []string{strings.TrimPrefix(redirect.URL, "http://")}}
+
+	tests := []struct {
+		name, url, wantMessage string
+	}{
+		{"host not allowed", internal.URL, "is not allowed for HTTP hooks"},
+		{"redirect to a host that is not allowed", redirect.URL, "redirect to " + strings.TrimPrefix(internal.URL, "http://")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := r.Run(context.Background(), HookStagePre, []dbtether.Hook{
+				{Name: "call", HTTP: &dbtether.HTTPHook{URL: tt.url}},
+			})
+			if err == nil || !strings.Contains(results[0].Message, tt.wantMessage) {
+				t.Errorf("Run() error = %v, message = %q, want %q", err, results[0].Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestHookRunner_Timeout(t *testing.T) {
+	r := &HookRunner{runSQL: func(ctx context.Context, _ string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	start := time.Now()
+	results, err := r.Run(context.Background(), HookStagePre, []dbtether.Hook{
+		{Name: "slow", SQL: "SELECT pg_sleep(60)", Timeout: &metav1.Duration{Duration: 50 * time.Millisecond}},
+	})
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("timeout not applied")
+	}
+	if results[0].Phase != HookFailed {
+		t.Errorf("phase = %s", results[0].Phase)
+	}
+}
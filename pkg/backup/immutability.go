@@ -0,0 +1,21 @@
+package backup
+
+import (
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/storage"
+)
+
+// ObjectLock converts the immutability settings of a BackupStorage for the storage clients
+func ObjectLock(cfg *dbtether.ImmutabilityConfig) *storage.ObjectLock {
+	if cfg == nil {
+		return nil
+	}
+	lock := &storage.ObjectLock{Mode: storage.LockModeGovernance, LegalHold: cfg.LegalHold}
+	if cfg.Mode == dbtether.ImmutabilityCompliance {
+		lock.Mode = storage.LockModeCompliance
+	}
+	if cfg.RetainFor != nil {
+		lock.RetainFor = cfg.RetainFor.Duration
+	}
+	return lock
+}
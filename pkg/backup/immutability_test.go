@@ -0,0 +1,62 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/storage"
+)
+
+func TestObjectLock(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *dbtether.ImmutabilityConfig
+		want *storage.ObjectLock
+	}{
+		{name: "disabled"},
+		{
+			name: "defaults to governance",
+			cfg:  &dbtether.ImmutabilityConfig{RetainFor: &metav1.Duration{Duration: time.Hour}},
+			want: &storage.ObjectLock{Mode: storage.LockModeGovernance, RetainFor: time.Hour},
+		},
+		{
+			name: "compliance",
+			cfg: &dbtether.ImmutabilityConfig{
+				Mode:      dbtether.ImmutabilityCompliance,
+				RetainFor: &metav1.Duration{Duration: 720 * time.Hour},
+			},
+			want: &storage.ObjectLock{Mode: storage.LockModeCompliance, RetainFor: 720 * time.Hour},
+		},
+		{
+			name: "legal hold only",
+			cfg:  &dbtether.ImmutabilityConfig{Mode: dbtether.ImmutabilityGovernance, LegalHold: true},
+			want: &storage.ObjectLock{Mode: storage.LockModeGovernance, LegalHold: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ObjectLock(tt.cfg)
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("ObjectLock() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplica_TargetImmutability(t *testing.T) {
+	replica := Replica{
+		Name:         "vault",
+		S3:           &dbtether.S3StorageConfig{Bucket: "vault", Region: "eu-west-1"},
+		Immutability: &dbtether.ImmutabilityConfig{LegalHold: true},
+	}
+
+	target := replica.Target(0, func(string) string { return "" })
+
+	if target.S3Config.Lock == nil || !target.S3Config.Lock.LegalHold {
+		t.Errorf("S3Config.Lock = %+v, want legal hold", target.S3Config.Lock)
+	}
+}
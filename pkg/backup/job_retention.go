@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/storage"
+)
+
+// CleanedUpByJob reports whether the backup Job applies retention to a storage type instead of the
+// schedule controller: pvc claims are only mounted into Jobs, and SFTP hosts are often only
+// reachable from them, with the key mounted from a Secret.
+func CleanedUpByJob(storageType string) bool {
+	return storageType == "pvc" || storageType == "sftp"
+}
+
+// ApplyJobRetention applies the schedule's retention to the storages of a finished backup that
+// are cleaned up by the Job (see CleanedUpByJob). Each storage is cleaned up under the database's
+// prefix (see DatabasePrefix), the same prefix the schedule controllers use for object storages.
+func ApplyJobRetention(ctx context.Context, cfg *BackupConfig, result *BackupResult, log *zap.SugaredLogger) {
+	if target := cfg.primaryTarget(); CleanedUpByJob(target.StorageType) && target.Retention != nil {
+		cleanupTarget(ctx, target, cfg.PathTemplate, cfg, log)
+	}
+	for i := range cfg.Replicas {
+		replica := &cfg.Replicas[i]
+		if !CleanedUpByJob(replica.StorageType) || replica.Retention == nil || i >= len(result.Replicas) ||
+			result.Replicas[i].Phase != dbtether.ReplicaCompleted {
+			continue
+		}
+		cleanupTarget(ctx, replica, replica.PathTemplate, cfg, log)
+	}
+}
+
+func cleanupTarget(ctx context.Context, target *StorageTarget, pathTemplate string, cfg *BackupConfig,
+	log *zap.SugaredLogger) {
+
+	log = log.With("storage", target.Name)
+	prefix, err := DatabasePrefix(pathTemplate, cfg.ClusterName, cfg.DatabaseName)
+	if err != nil {
+		log.Warnw("retention cleanup: failed to render path template", "error", err)
+		return
+	}
+	if prefix == "" {
+		// The whole storage would be counted as one database
+		log.Warnw("retention cleanup: skipped, pathTemplate starts with a date or run variable")
+		return
+	}
+	log = log.With("prefix", prefix)
+
+	client, closeClient, err := openRetentionClient(ctx, target)
+	if err != nil {
+		log.Warnw("retention cleanup: failed to open storage", "error", err)
+		return
+	}
+	defer closeClient()
+
+	retentionManager := NewRetentionManager(log)
+	toDelete, err := retentionManager.ApplyRetention(ctx, client, prefix+"/", target.Retention)
+	if err != nil {
+		log.Warnw("retention cleanup: failed to apply retention policy", "error", err)
+		return
+	}
+	if err := retentionManager.DeleteFiles(ctx, client, toDelete); err != nil {
+		log.Warnw("retention cleanup: failed to delete some files", "error", err)
+	}
+}
+
+func openRetentionClient(ctx context.Context, target *StorageTarget) (storage.StorageClient, func(), error) {
+	switch target.StorageType {
+	case "pvc":
+		client, err := storage.NewFilesystemClient(&target.FilesystemConfig, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() {}, nil
+	case "sftp":
+		client, err := storage.NewSFTPClient(ctx, &target.SFTPConfig, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() { _ = client.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported storage type: %s", target.StorageType)
+	}
+}
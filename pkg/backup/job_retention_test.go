@@ -0,0 +1,143 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+// writeBackups creates backup files under root and returns a function listing what is left
+func writeBackups(t *testing.T, root string, keys ...string) func() []string {
+	t.Helper()
+	for _, key := range keys {
+		path := filepath.Join(root, filepath.FromSlash(key))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return func() []string {
+		var left []string
+		_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				rel, _ := filepath.Rel(root, path)
+				left = append(left, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		sort.Strings(left)
+		return left
+	}
+}
+
+func TestApplyJobRetention(t *testing.T) {
+	keepLast := 1
+	retention := &dbtether.RetentionPolicy{KeepLast: &keepLast}
+
+	primaryRoot, replicaRoot, skippedRoot := t.TempDir(), t.TempDir(), t.TempDir()
+	primaryLeft := writeBackups(t, primaryRoot,
+		"main/orders/20260101-000000.sql.gz", "main/orders/20260102-000000.sql.gz", "main/users/20260101-000000.sql.gz")
+	replicaLeft := writeBackups(t, replicaRoot,
+		"dr/orders/20260101-000000.sql.gz", "dr/orders/20260102-000000.sql.gz")
+	skippedLeft := writeBackups(t, skippedRoot,
+		"main/orders/20260101-000000.sql.gz", "main/orders/20260102-000000.sql.gz")
+
+	cfg := &BackupConfig{StorageType: "pvc", Retention: retention, ClusterName: "main", DatabaseName: "orders"}
+	cfg.FilesystemConfig.Root = primaryRoot
+	cfg.Replicas = []StorageTarget{
+		{Name: "dr", StorageType: "pvc", Retention: retention, PathTemplate: "dr/{{ .DatabaseName }}"},
+		{Name: "failed", StorageType: "pvc", Retention: retention},
+	}
+	cfg.Replicas[0].FilesystemConfig.Root = replicaRoot
+	cfg.Replicas[1].FilesystemConfig.Root = skippedRoot
+	result := &BackupResult{
+		Path: "main/orders/20260102-000000.sql.gz",
+		Replicas: []dbtether.ReplicaStatus{
+			{Storage: "dr", Phase: dbtether.ReplicaCompleted, Path: "dr/orders/20260102-000000.sql.gz"},
+			{Storage: "failed", Phase: dbtether.ReplicaFailed, Path: "main/orders/20260102-000000.sql.gz"},
+		},
+	}
+
+	ApplyJobRetention(context.Background(), cfg, result, zap.NewNop().Sugar())
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"primary, other databases untouched", primaryLeft(),
+			[]string{"main/orders/20260102-000000.sql.gz", "main/users/20260101-000000.sql.gz"}},
+		{"completed replica", replicaLeft(), []string{"dr/orders/20260102-000000.sql.gz"}},
+		{"failed replica", skippedLeft(),
+			[]string{"main/orders/20260101-000000.sql.gz", "main/orders/20260102-000000.sql.gz"}},
+	}
+	for _, tt := range tests {
+		if strings.Join(tt.got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: files = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestApplyJobRetention_DatedPathTemplate(t *testing.T) {
+	keepLast := 2
+	root := t.TempDir()
+	left := writeBackups(t, root,
+		"main/orders/2025/12/20251230-000000.sql.gz",
+		"main/orders/2025/12/20251231-000000.sql.gz",
+		"main/orders/2026/01/20260101-000000.sql.gz",
+		"main/orders/2026/01/20260102-000000.sql.gz",
+		"main/orders-archive/2025/12/20251230-000000.sql.gz",
+		"main/users/2025/12/20251230-000000.sql.gz")
+
+	cfg := &BackupConfig{
+		StorageType:  "pvc",
+		Retention:    &dbtether.RetentionPolicy{KeepLast: &keepLast},
+		ClusterName:  "main",
+		DatabaseName: "orders",
+		PathTemplate: "{{ .ClusterName }}/{{ .DatabaseName }}/{{ .Year }}/{{ .Month }}",
+	}
+	cfg.FilesystemConfig.Root = root
+	ApplyJobRetention(context.Background(), cfg, &BackupResult{Path: "main/orders/2026/01/20260102-000000.sql.gz"},
+		zap.NewNop().Sugar())
+
+	want := []string{
+		"main/orders-archive/2025/12/20251230-000000.sql.gz",
+		"main/orders/2026/01/20260101-000000.sql.gz",
+		"main/orders/2026/01/20260102-000000.sql.gz",
+		"main/users/2025/12/20251230-000000.sql.gz",
+	}
+	if got := left(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("files = %v, want %v (older months of the database are cleaned up too)", got, want)
+	}
+}
+
+func TestApplyJobRetention_NoRetention(t *testing.T) {
+	root := t.TempDir()
+	left := writeBackups(t, root, "main/orders/20260101-000000.sql.gz", "main/orders/20260102-000000.sql.gz")
+
+	cfg := &BackupConfig{StorageType: "pvc"}
+	cfg.FilesystemConfig.Root = root
+	ApplyJobRetention(context.Background(), cfg, &BackupResult{Path: "main/orders/20260102-000000.sql.gz"},
+		zap.NewNop().Sugar())
+
+	if got := left(); len(got) != 2 {
+		t.Errorf("files = %v, nothing should be deleted without retention", got)
+	}
+}
+
+func TestCleanedUpByJob(t *testing.T) {
+	for storageType, want := range map[string]bool{"pvc": true, "sftp": true, "s3": false, "gcs": false, "azure": false} {
+		if got := CleanedUpByJob(storageType); got != want {
+			t.Errorf("CleanedUpByJob(%q) = %v, want %v", storageType, got, want)
+		}
+	}
+}
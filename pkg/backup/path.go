@@ -0,0 +1,86 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// runVariables are the pathTemplate variables that change between backups of one database
+var runVariables = []string{"Year", "Month", "Day", "Timestamp", "RunID"}
+
+// runMarker stands in for runVariables when rendering a database prefix
+const runMarker = "\x00"
+
+// ValidatePathTemplate renders a BackupStorage pathTemplate with sample values, which catches
+// syntax errors and unknown fields before a backup Job runs into them
+func ValidatePathTemplate(tmpl string) error {
+	if tmpl == "" {
+		return nil
+	}
+	now := time.Now().UTC()
+	rendered, err := executeTemplate(tmpl, &TemplateData{
+		ClusterName:  "cluster",
+		DatabaseName: "database",
+		Year:         now.Format("2006"),
+		Month:        now.Format("01"),
+		Day:          now.Format("02"),
+		Timestamp:    now.Format("20060102-150405"),
+		RunID:        "run",
+	})
+	if err != nil {
+		return fmt.Errorf("invalid pathTemplate: %w", err)
+	}
+	if strings.TrimSpace(strings.Trim(rendered, "/")) == "" {
+		return fmt.Errorf("invalid pathTemplate: renders to an empty path")
+	}
+	return nil
+}
+
+// StaticPrefix returns the directory of a pathTemplate before its first variable, e.g.
+// "backups/prod" for "backups/prod/{{ .ClusterName }}". Every backup of the storage is under it.
+func StaticPrefix(tmpl string) string {
+	if tmpl == "" {
+		tmpl = defaultPathTemplate
+	}
+	if i := strings.Index(tmpl, "{{"); i >= 0 {
+		tmpl = path.Dir(tmpl[:i] + "x")
+	}
+	if tmpl == "." {
+		return ""
+	}
+	return strings.Trim(tmpl, "/")
+}
+
+// DatabasePrefix renders a pathTemplate for one database up to the first directory that changes
+// between runs, e.g. "main/orders" for "{{ .ClusterName }}/{{ .DatabaseName }}/{{ .Year }}/{{ .Month }}".
+// Retention applied below it sees every backup of the database, not only the current dated directory.
+func DatabasePrefix(tmpl, clusterName, databaseName string) (string, error) {
+	if tmpl == "" {
+		tmpl = defaultPathTemplate
+	}
+	t, err := template.New("path").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	data := map[string]string{"ClusterName": clusterName, "DatabaseName": databaseName}
+	for _, name := range runVariables {
+		data[name] = runMarker
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	segments := strings.Split(buf.String(), "/")
+	for i, segment := range segments {
+		if strings.Contains(segment, runMarker) {
+			segments = segments[:i]
+			break
+		}
+	}
+	return strings.TrimSuffix(strings.Join(segments, "/"), "/"), nil
+}
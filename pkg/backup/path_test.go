@@ -0,0 +1,62 @@
+package backup
+
+import "testing"
+
+func TestValidatePathTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		wantErr bool
+	}{
+		{"", false},
+		{"{{ .ClusterName }}/{{ .DatabaseName }}", false},
+		{"backups/{{ .Year }}/{{ .Month }}/{{ .Day }}/{{ .DatabaseName }}", false},
+		{"{{ .ClusterName }/{{ .DatabaseName }}", true},
+		{"{{ .Cluster }}/{{ .DatabaseName }}", true},
+		{"/", true},
+	}
+	for _, tt := range tests {
+		if err := ValidatePathTemplate(tt.tmpl); (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePathTemplate(%q) error = %v, wantErr %v", tt.tmpl, err, tt.wantErr)
+		}
+	}
+}
+
+func TestStaticPrefix(t *testing.T) {
+	tests := map[string]string{
+		"":                                       "",
+		"{{ .ClusterName }}/{{ .DatabaseName }}": "",
+		"backups/prod/{{ .ClusterName }}":        "backups/prod",
+		"backups/prod-{{ .ClusterName }}":        "backups",
+		"/archive/":                              "archive",
+	}
+	for tmpl, want := range tests {
+		if got := StaticPrefix(tmpl); got != want {
+			t.Errorf("StaticPrefix(%q) = %q, want %q", tmpl, got, want)
+		}
+	}
+}
+
+func TestDatabasePrefix(t *testing.T) {
+	tests := map[string]string{
+		"":                                       "main/orders",
+		"{{ .ClusterName }}/{{ .DatabaseName }}": "main/orders",
+		"backups/{{ .ClusterName }}/{{ .DatabaseName }}/{{ .Year }}/{{ .Month }}": "backups/main/orders",
+		"{{ .DatabaseName }}/{{ .Year }}-{{ .Month }}/{{ .RunID }}":               "orders",
+		"{{ .DatabaseName }}-{{ .Timestamp }}":                                    "",
+		"backups/{{ .ClusterName }}/":                                             "backups/main",
+	}
+	for tmpl, want := range tests {
+		got, err := DatabasePrefix(tmpl, "main", "orders")
+		if err != nil {
+			t.Errorf("DatabasePrefix(%q) error = %v", tmpl, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("DatabasePrefix(%q) = %q, want %q", tmpl, got, want)
+		}
+	}
+
+	if _, err := DatabasePrefix("{{ .ClusterName }", "main", "orders"); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+}
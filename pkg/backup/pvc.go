@@ -0,0 +1,11 @@
+package backup
+
+import "fmt"
+
+// PVCMountPath is where the claim of a pvc BackupStorage is mounted in backup and restore Jobs
+const PVCMountPath = "/var/lib/dbtether/backups"
+
+// ReplicaPVCMountPath returns where the claim of the pvc replica at index is mounted
+func ReplicaPVCMountPath(index int) string {
+	return fmt.Sprintf("/var/lib/dbtether/replicas/%d", index)
+}
@@ -0,0 +1,25 @@
+package backup
+
+import (
+	"testing"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+func TestReplica_TargetPVC(t *testing.T) {
+	keepLast := 3
+	replica := Replica{
+		Name:      "nfs",
+		PVC:       &dbtether.PVCStorageConfig{ClaimName: "backups"},
+		Retention: &dbtether.RetentionPolicy{KeepLast: &keepLast},
+	}
+
+	target := replica.Target(2, func(string) string { return "" })
+
+	if target.StorageType != "pvc" || target.FilesystemConfig.Root != "/var/lib/dbtether/replicas/2" {
+		t.Errorf("target = %s %+v", target.StorageType, target.FilesystemConfig)
+	}
+	if target.Retention == nil || *target.Retention.KeepLast != 3 {
+		t.Errorf("Retention = %+v, want keepLast 3", target.Retention)
+	}
+}
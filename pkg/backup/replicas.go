@@ -0,0 +1,318 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/storage"
+)
+
+// ReplicasAnnotation holds the replica results (JSON) on the backup Job
+const ReplicasAnnotation = "dbtether.io/backup-replicas"
+
+// RestoredFromAnnotation names the replica a restore Job downloaded the backup from
+const RestoredFromAnnotation = "dbtether.io/restored-from"
+
+// PrimaryErrorAnnotation holds the primary download error of a restore Job that used a replica
+const PrimaryErrorAnnotation = "dbtether.io/primary-error"
+
+// Replica is a replica storage passed to the Job as JSON in the REPLICAS env var.
+// Its credentials are passed separately, see ReplicaCredentialEnv.
+type Replica struct {
+	Name         string                       `json:"name"`
+	S3           *dbtether.S3StorageConfig    `json:"s3,omitempty"`
+	GCS          *dbtether.GCSStorageConfig   `json:"gcs,omitempty"`
+	Azure        *dbtether.AzureStorageConfig `json:"azure,omitempty"`
+	PVC          *dbtether.PVCStorageConfig   `json:"pvc,omitempty"`
+	SFTP         *dbtether.SFTPStorageConfig  `json:"sftp,omitempty"`
+	PathTemplate string                       `json:"pathTemplate,omitempty"`
+	Immutability *dbtether.ImmutabilityConfig `json:"immutability,omitempty"`
+
+	// Path of the backup file in the replica (restore only)
+	Path string `json:"path,omitempty"`
+
+	// Retention the Job applies after the upload (pvc and sftp replicas of a schedule only)
+	Retention *dbtether.RetentionPolicy `json:"retention,omitempty"`
+}
+
+// NewReplica describes a BackupStorage for the Job
+func NewReplica(storage *dbtether.BackupStorage) Replica {
+	return Replica{
+		Name:         storage.Name,
+		S3:           storage.Spec.S3,
+		GCS:          storage.Spec.GCS,
+		Azure:        storage.Spec.Azure,
+		PVC:          storage.Spec.PVC,
+		SFTP:         storage.Spec.SFTP,
+		PathTemplate: storage.Spec.PathTemplate,
+		Immutability: storage.Spec.Immutability,
+	}
+}
+
+// ReplicaCredentialEnv returns the env var holding a credential of the replica at index,
+// e.g. REPLICA_0_AWS_ACCESS_KEY_ID
+func ReplicaCredentialEnv(index int, key string) string {
+	return fmt.Sprintf("REPLICA_%d_%s", index, key)
+}
+
+// Target converts the replica into a StorageTarget, reading credentials with getenv
+func (r *Replica) Target(index int, getenv func(string) string) StorageTarget {
+	target := StorageTarget{Name: r.Name, PathTemplate: r.PathTemplate, Path: r.Path, Retention: r.Retention}
+	lock := ObjectLock(r.Immutability)
+	switch {
+	case r.S3 != nil:
+		target.StorageType = "s3"
+		target.S3Config = storage.S3Config{
+			Bucket:    r.S3.Bucket,
+			Region:    r.S3.Region,
+			Endpoint:  r.S3.Endpoint,
+			AccessKey: getenv(ReplicaCredentialEnv(index, "AWS_ACCESS_KEY_ID")),
+			SecretKey: getenv(ReplicaCredentialEnv(index, "AWS_SECRET_ACCESS_KEY")),
+			Lock:      lock,
+		}
+	case r.GCS != nil:
+		target.StorageType = "gcs"
+		target.GCSConfig = storage.GCSConfig{
+			Bucket:          r.GCS.Bucket,
+			Project:         r.GCS.Project,
+			CredentialsJSON: []byte(getenv(ReplicaCredentialEnv(index, "GCS_SERVICE_ACCOUNT_JSON"))),
+			Lock:            lock,
+		}
+	case r.Azure != nil:
+		target.StorageType = "azure"
+		target.AzureConfig = storage.AzureConfig{
+			Container:      r.Azure.Container,
+			StorageAccount: r.Azure.StorageAccount,
+			Lock:           lock,
+		}
+		SetAzureCredentials(&target.AzureConfig, func(key string) string {
+			return getenv(ReplicaCredentialEnv(index, key))
+		})
+	case r.PVC != nil:
+		target.StorageType = "pvc"
+		target.FilesystemConfig = storage.FilesystemConfig{Root: ReplicaPVCMountPath(index)}
+	case r.SFTP != nil:
+		target.StorageType = "sftp"
+		target.SFTPConfig = storage.SFTPConfig{
+			Host:       r.SFTP.Host,
+			Port:       int(r.SFTP.Port),
+			User:       r.SFTP.User,
+			Path:       r.SFTP.Path,
+			KnownHosts: r.SFTP.KnownHosts,
+			PrivateKey: getenv(ReplicaCredentialEnv(index, "SSH_PRIVATE_KEY")),
+		}
+	}
+	return target
+}
+
+// StorageTarget is a storage a backup is uploaded to or restored from
+type StorageTarget struct {
+	// Name of the BackupStorage
+	Name string
+
+	StorageType      string // "s3", "gcs", "azure", "pvc", "sftp"
+	S3Config         storage.S3Config
+	GCSConfig        storage.GCSConfig
+	AzureConfig      storage.AzureConfig
+	FilesystemConfig storage.FilesystemConfig
+	SFTPConfig       storage.SFTPConfig
+
+	// PathTemplate of the storage; empty uses {{ .ClusterName }}/{{ .DatabaseName }} like the primary
+	PathTemplate string
+
+	// Path of the backup file in this storage (restore only)
+	Path string
+
+	// Retention applied by the Job after the upload; only set for storages CleanedUpByJob
+	Retention *dbtether.RetentionPolicy
+}
+
+// objectStore is the part of a storage client used by backups and restores
+type objectStore interface {
+	UploadWithTags(ctx context.Context, key string, body io.Reader, tags *storage.ObjectTags) error
+	Download(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// openStoreFunc creates a client for a storage target; close releases it
+type openStoreFunc func(ctx context.Context, target *StorageTarget, logger *slog.Logger) (store objectStore, close func(), err error)
+
+func openStore(ctx context.Context, target *StorageTarget, logger *slog.Logger) (objectStore, func(), error) {
+	switch target.StorageType {
+	case "s3":
+		client, err := storage.NewS3Client(ctx, &target.S3Config, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create S3 client: %w", err)
+		}
+		return client, func() {}, nil
+	case "gcs":
+		client, err := storage.NewGCSClient(ctx, &target.GCSConfig, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create GCS client: %w", err)
+		}
+		return client, func() { _ = client.Close() }, nil
+	case "azure":
+		client, err := storage.NewAzureClient(ctx, &target.AzureConfig, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create Azure client: %w", err)
+		}
+		return client, func() {}, nil
+	case "pvc":
+		client, err := storage.NewFilesystemClient(&target.FilesystemConfig, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open PVC storage: %w", err)
+		}
+		return client, func() {}, nil
+	case "sftp":
+		client, err := storage.NewSFTPClient(ctx, &target.SFTPConfig, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to SFTP storage: %w", err)
+		}
+		return client, func() { _ = client.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported storage type: %s", target.StorageType)
+	}
+}
+
+// replicate uploads the backup to every replica. A failed replica does not fail the backup;
+// its error is reported in the result so the primary upload is not repeated by a Job retry.
+func replicate(ctx context.Context, open openStoreFunc, replicas []StorageTarget, data *TemplateData,
+	filename string, body []byte, tags *storage.ObjectTags) []dbtether.ReplicaStatus {
+
+	results := make([]dbtether.ReplicaStatus, 0, len(replicas))
+	for i := range replicas {
+		replica := &replicas[i]
+		result := dbtether.ReplicaStatus{Storage: replica.Name}
+
+		path, err := replicaPath(replica, data, filename)
+		if err == nil {
+			result.Path = path
+			err = uploadTo(ctx, open, replica, path, body, tags)
+		}
+		if err != nil {
+			result.Phase = dbtether.ReplicaFailed
+			result.Message = err.Error()
+			slog.Default().Warn("replica upload failed", "storage", replica.Name, "error", err)
+		} else {
+			result.Phase = dbtether.ReplicaCompleted
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// defaultPathTemplate is the BackupStorage pathTemplate default
+const defaultPathTemplate = "{{ .ClusterName }}/{{ .DatabaseName }}"
+
+// replicaPath renders the path of the backup file in a replica storage. Each storage keeps its own
+// layout so the schedule's retention finds replicas under the storage's prefix.
+func replicaPath(replica *StorageTarget, data *TemplateData, filename string) (string, error) {
+	tmpl := replica.PathTemplate
+	if tmpl == "" {
+		tmpl = defaultPathTemplate
+	}
+	path, err := executeTemplate(tmpl, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute path template: %w", err)
+	}
+	return strings.TrimSuffix(path, "/") + "/" + filename, nil
+}
+
+func uploadTo(ctx context.Context, open openStoreFunc, target *StorageTarget, key string,
+	body []byte, tags *storage.ObjectTags) error {
+
+	store, closeStore, err := open(ctx, target, nil)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	if err := store.UploadWithTags(ctx, key, bytes.NewReader(body), tags); err != nil {
+		return fmt.Errorf("%s upload failed: %w", uploadErrorPrefix(target.StorageType), err)
+	}
+	return nil
+}
+
+func uploadErrorPrefix(storageType string) string {
+	switch storageType {
+	case "s3":
+		return "S3"
+	case "gcs":
+		return "GCS"
+	case "pvc":
+		return "PVC"
+	case "sftp":
+		return "SFTP"
+	default:
+		return "azure blob"
+	}
+}
+
+// download is a backup file opened by downloadWithFallback
+type download struct {
+	Body io.ReadCloser
+
+	// Source is the storage the backup is read from
+	Source *StorageTarget
+
+	// PrimaryErr is the primary storage error when Source is a replica
+	PrimaryErr error
+}
+
+// downloadWithFallback downloads the backup from the primary storage, then from each replica in
+// order when the primary object is missing, or on any primary error with anyError. Otherwise, and
+// when no replica has the backup, it returns the primary error.
+func downloadWithFallback(ctx context.Context, open openStoreFunc, primary *StorageTarget,
+	replicas []StorageTarget, anyError bool, logger *slog.Logger) (*download, error) {
+
+	body, primaryErr := downloadFrom(ctx, open, primary, logger)
+	if primaryErr == nil {
+		return &download{Body: body, Source: primary}, nil
+	}
+	if !anyError && !errors.Is(primaryErr, storage.ErrNotFound) {
+		// A denied or unreachable primary storage needs fixing, not a silent switch to a replica
+		return nil, primaryErr
+	}
+
+	for i := range replicas {
+		replica := &replicas[i]
+		logger.Warn("backup unavailable, trying replica",
+			"error", primaryErr, "replica", replica.Name, "path", replica.Path)
+		body, err := downloadFrom(ctx, open, replica, logger)
+		if err == nil {
+			return &download{Body: body, Source: replica, PrimaryErr: primaryErr}, nil
+		}
+		logger.Warn("replica download failed", "replica", replica.Name, "error", err)
+	}
+	return nil, primaryErr
+}
+
+func downloadFrom(ctx context.Context, open openStoreFunc, target *StorageTarget, logger *slog.Logger) (io.ReadCloser, error) {
+	store, closeStore, err := open(ctx, target, logger)
+	if err != nil {
+		return nil, err
+	}
+	body, err := store.Download(ctx, target.Path)
+	if err != nil {
+		closeStore()
+		return nil, err
+	}
+	return &closingReader{ReadCloser: body, release: closeStore}, nil
+}
+
+// closingReader releases the storage client when the download is closed
+type closingReader struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *closingReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
@@ -0,0 +1,260 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+	"github.com/certainty3452/dbtether/pkg/storage"
+)
+
+// fakeStores opens mock clients by storage name; closed counts released clients
+type fakeStores struct {
+	clients map[string]*storage.MockClient
+	closed  int
+}
+
+func (f *fakeStores) open(_ context.Context, target *StorageTarget, _ *slog.Logger) (objectStore, func(), error) {
+	client, ok := f.clients[target.Name]
+	if !ok {
+		return nil, nil, errors.New("failed to create S3 client: no credentials")
+	}
+	return client, func() { f.closed++ }, nil
+}
+
+func TestReplicate(t *testing.T) {
+	failing := storage.NewMockClient()
+	failing.UploadError = errors.New("AccessDenied")
+	stores := &fakeStores{clients: map[string]*storage.MockClient{
+		"dr-eu":   storage.NewMockClient(),
+		"dr-gcs":  failing,
+		"archive": storage.NewMockClient(),
+	}}
+	replicas := []StorageTarget{
+		{Name: "dr-eu", StorageType: "s3", PathTemplate: "replica/{{ .DatabaseName }}"},
+		{Name: "dr-gcs", StorageType: "gcs"},
+		{Name: "archive", StorageType: "s3"},
+		{Name: "missing", StorageType: "s3"},
+	}
+	data := &TemplateData{ClusterName: "main", DatabaseName: "orders"}
+
+	results := replicate(context.Background(), stores.open, replicas, data, "20260102-030405.sql.gz",
+		[]byte("dump"), &storage.ObjectTags{Database: "orders"})
+
+	want := []dbtether.ReplicaStatus{
+		{Storage: "dr-eu", Phase: dbtether.ReplicaCompleted, Path: "replica/orders/20260102-030405.sql.gz"},
+		{Storage: "dr-gcs", Phase: dbtether.ReplicaFailed, Path: "main/orders/20260102-030405.sql.gz",
+			Message: "GCS upload failed: AccessDenied"},
+		{Storage: "archive", Phase: dbtether.ReplicaCompleted, Path: "main/orders/20260102-030405.sql.gz"},
+		{Storage: "missing", Phase: dbtether.ReplicaFailed, Path: "main/orders/20260102-030405.sql.gz",
+			Message: "failed to create S3 client: no credentials"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("results[%d] = %+v, want %+v", i, results[i], want[i])
+		}
+	}
+
+	if got, ok := stores.clients["dr-eu"].GetObject("replica/orders/20260102-030405.sql.gz"); !ok || string(got) != "dump" {
+		t.Errorf("dr-eu object = %q, %v", got, ok)
+	}
+	if tags, _ := stores.clients["archive"].GetTags("main/orders/20260102-030405.sql.gz"); tags == nil || tags.Database != "orders" {
+		t.Errorf("archive tags = %+v, want database orders", tags)
+	}
+	if stores.closed != 3 {
+		t.Errorf("closed %d clients, want 3", stores.closed)
+	}
+}
+
+func TestReplicate_InvalidPathTemplate(t *testing.T) {
+	stores := &fakeStores{clients: map[string]*storage.MockClient{"dr-eu": storage.NewMockClient()}}
+	replicas := []StorageTarget{{Name: "dr-eu", StorageType: "s3", PathTemplate: "{{ .Nope"}}
+
+	results := replicate(context.Background(), stores.open, replicas, &TemplateData{}, "backup.sql.gz", nil, nil)
+
+	if len(results) != 1 || results[0].Phase != dbtether.ReplicaFailed || results[0].Path != "" {
+		t.Fatalf("results = %+v, want one failed replica without path", results)
+	}
+	if stores.clients["dr-eu"].Count() != 0 {
+		t.Error("nothing should be uploaded with an invalid path template")
+	}
+}
+
+func TestDownloadWithFallback(t *testing.T) {
+	newStores := func(primaryErr error) *fakeStores {
+		primary := storage.NewMockClient()
+		primary.AddObject("main/orders/backup.sql.gz", []byte("primary"), time.Now())
+		primary.DownloadError = primaryErr
+		replica := storage.NewMockClient()
+		replica.AddObject("dr/orders/backup.sql.gz", []byte("replica"), time.Now())
+		return &fakeStores{clients: map[string]*storage.MockClient{
+			"":      primary,
+			"empty": storage.NewMockClient(),
+			"dr-eu": replica,
+		}}
+	}
+	const notFound = "object not found: main/orders/gone.sql.gz"
+	const denied = "failed to download from S3: AccessDenied"
+	replicas := []StorageTarget{
+		{Name: "empty", Path: "main/orders/backup.sql.gz"},
+		{Name: "dr-eu", Path: "dr/orders/backup.sql.gz"},
+	}
+
+	tests := []struct {
+		name           string
+		primaryPath    string
+		primaryErr     error
+		anyError       bool
+		replicas       []StorageTarget
+		wantBody       string
+		wantStorage    string
+		wantPrimaryErr string
+		wantErr        string
+		wantClosed     int // clients released, including the one used after the body is closed
+	}{
+		{name: "primary", primaryPath: "main/orders/backup.sql.gz", replicas: replicas, wantBody: "primary",
+			wantClosed: 1},
+		{name: "fallback to second replica", primaryPath: "main/orders/gone.sql.gz", replicas: replicas,
+			wantBody: "replica", wantStorage: "dr-eu", wantPrimaryErr: notFound, wantClosed: 3},
+		{name: "no replicas", primaryPath: "main/orders/gone.sql.gz", wantErr: notFound, wantClosed: 1},
+		{name: "all missing", primaryPath: "main/orders/gone.sql.gz", replicas: replicas[:1], wantErr: notFound,
+			wantClosed: 2},
+		{name: "primary denied", primaryPath: "main/orders/backup.sql.gz", primaryErr: errors.New(denied),
+			replicas: replicas, wantErr: denied, wantClosed: 1},
+		{name: "primary denied, fallback on any error", primaryPath: "main/orders/backup.sql.gz",
+			primaryErr: errors.New(denied), anyError: true, replicas: replicas, wantBody: "replica",
+			wantStorage: "dr-eu", wantPrimaryErr: denied, wantClosed: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := newStores(tt.primaryErr)
+			primary := &StorageTarget{Path: tt.primaryPath}
+
+			got, err := downloadWithFallback(context.Background(), stores.open, primary, tt.replicas, tt.anyError, slog.Default())
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want the primary download error %q", err, tt.wantErr)
+				}
+				if stores.closed != tt.wantClosed {
+					t.Errorf("closed %d clients, want %d", stores.closed, tt.wantClosed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("downloadWithFallback() error = %v", err)
+			}
+
+			body, _ := io.ReadAll(got.Body)
+			if string(body) != tt.wantBody || got.Source.Name != tt.wantStorage {
+				t.Errorf("got %q from %q, want %q from %q", body, got.Source.Name, tt.wantBody, tt.wantStorage)
+			}
+			var primaryErr string
+			if got.PrimaryErr != nil {
+				primaryErr = got.PrimaryErr.Error()
+			}
+			if primaryErr != tt.wantPrimaryErr {
+				t.Errorf("PrimaryErr = %q, want %q", primaryErr, tt.wantPrimaryErr)
+			}
+			_ = got.Body.Close()
+			if stores.closed != tt.wantClosed {
+				t.Errorf("closed %d clients, want %d", stores.closed, tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestReplica_Target(t *testing.T) {
+	env := map[string]string{
+		"REPLICA_1_AWS_ACCESS_KEY_ID":     "AKIA",
+		"REPLICA_1_AWS_SECRET_ACCESS_KEY": "secret",
+	}
+	replica := Replica{
+		Name:         "dr-eu",
+		S3:           &dbtether.S3StorageConfig{Bucket: "dr", Region: "eu-west-1", Endpoint: "https://minio"},
+		PathTemplate: "{{ .DatabaseName }}",
+		Path:         "orders/backup.sql.gz",
+	}
+
+	target := replica.Target(1, func(key string) string { return env[key] })
+
+	want := storage.S3Config{Bucket: "dr", Region: "eu-west-1", Endpoint: "https://minio", AccessKey: "AKIA", SecretKey: "secret"}
+	if target.StorageType != "s3" || target.S3Config != want {
+		t.Errorf("target = %s %+v, want s3 %+v", target.StorageType, target.S3Config, want)
+	}
+	if target.Name != "dr-eu" || target.PathTemplate != "{{ .DatabaseName }}" || target.Path != "orders/backup.sql.gz" {
+		t.Errorf("unexpected target: %+v", target)
+	}
+
+	gcs := Replica{Name: "dr-gcs", GCS: &dbtether.GCSStorageConfig{Bucket: "dr", Project: "acme"}}
+	if target := gcs.Target(0, func(string) string { return "" }); target.StorageType != "gcs" || target.GCSConfig.Project != "acme" {
+		t.Errorf("gcs target = %+v", target)
+	}
+
+	azure := Replica{Name: "dr-azure", Azure: &dbtether.AzureStorageConfig{Container: "dr", StorageAccount: "acme"}}
+	if target := azure.Target(0, func(string) string { return "" }); target.StorageType != "azure" || target.AzureConfig.StorageAccount != "acme" {
+		t.Errorf("azure target = %+v", target)
+	}
+}
+
+func TestReplica_TargetCloudCredentials(t *testing.T) {
+	env := map[string]string{
+		"REPLICA_0_GCS_SERVICE_ACCOUNT_JSON": `{"type":"service_account"}`,
+		"REPLICA_1_AZURE_STORAGE_SAS_TOKEN":  "sv=2022-11-02&sig=abc",
+		"REPLICA_1_AZURE_CLIENT_SECRET":      "not-mine",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	gcs := Replica{Name: "dr-gcs", GCS: &dbtether.GCSStorageConfig{Bucket: "dr"}}
+	if target := gcs.Target(0, getenv); string(target.GCSConfig.CredentialsJSON) != `{"type":"service_account"}` {
+		t.Errorf("gcs credentials = %q", target.GCSConfig.CredentialsJSON)
+	}
+
+	azure := Replica{Name: "dr-azure", Azure: &dbtether.AzureStorageConfig{Container: "dr", StorageAccount: "acme"}}
+	target := azure.Target(1, getenv)
+	if target.AzureConfig.SASToken != "sv=2022-11-02&sig=abc" || target.AzureConfig.ClientSecret != "not-mine" {
+		t.Errorf("azure credentials = %+v", target.AzureConfig)
+	}
+	if target.AzureConfig.ConnectionString != "" {
+		t.Errorf("connection string = %q, want empty", target.AzureConfig.ConnectionString)
+	}
+}
+
+func TestReplica_TargetSFTP(t *testing.T) {
+	replica := Replica{
+		Name: "archive",
+		SFTP: &dbtether.SFTPStorageConfig{
+			Host:       "sftp.example.com",
+			Port:       2222,
+			User:       "backup",
+			Path:       "/srv/backups",
+			KnownHosts: "sftp.example.com ssh-ed25519 AAAA",
+		},
+	}
+
+	target := replica.Target(1, func(key string) string {
+		if key == "REPLICA_1_SSH_PRIVATE_KEY" {
+			return "key"
+		}
+		return ""
+	})
+
+	want := storage.SFTPConfig{
+		Host:       "sftp.example.com",
+		Port:       2222,
+		User:       "backup",
+		Path:       "/srv/backups",
+		PrivateKey: "key",
+		KnownHosts: "sftp.example.com ssh-ed25519 AAAA",
+	}
+	if target.StorageType != "sftp" || target.SFTPConfig != want {
+		t.Errorf("target = %s %+v, want sftp %+v", target.StorageType, target.SFTPConfig, want)
+	}
+}
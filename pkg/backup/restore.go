@@ -1,7 +1,6 @@
 package backup
 
 import (
-	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
@@ -10,6 +9,7 @@ import (
 	"os/exec"
 	"strings"
 
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
 	"github.com/certainty3452/dbtether/pkg/storage"
 )
 
@@ -38,14 +38,39 @@ type RestoreConfig struct {
 	// Azure config
 	AzureConfig *storage.AzureConfig
 
+	// PVC config
+	FilesystemConfig *storage.FilesystemConfig
+
+	// SFTP config
+	SFTPConfig *storage.SFTPConfig
+
 	// Conflict handling: fail, drop, overwrite
 	OnConflict string
 
+	// Directory with psql; empty uses PATH
+	BinDir string
+
+	// Replicas of the backup, tried in order when the source is missing
+	Replicas []StorageTarget
+
+	// ReplicaFallback "Any" also tries the replicas when the source is unreadable
+	ReplicaFallback string
+
 	Logger *slog.Logger
 }
 
+// RestoreResult reports where the backup was restored from
+type RestoreResult struct {
+	// Storage is the replica the backup was downloaded from; empty for the primary storage
+	Storage string
+	Path    string
+
+	// PrimaryError is why the primary storage was not used, set with Storage
+	PrimaryError string
+}
+
 // RunRestore executes the restore operation
-func RunRestore(ctx context.Context, cfg *RestoreConfig) error {
+func RunRestore(ctx context.Context, cfg *RestoreConfig) (*RestoreResult, error) {
 	logger := cfg.Logger
 	if logger == nil {
 		logger = slog.Default()
@@ -57,10 +82,17 @@ func RunRestore(ctx context.Context, cfg *RestoreConfig) error {
 		"onConflict", cfg.OnConflict,
 	)
 
-	// Download backup file from storage
-	backupData, err := downloadBackup(ctx, cfg, logger)
+	// Download backup file from storage, falling back to the replicas
+	logger.Info("downloading backup", "path", cfg.SourcePath, "storageType", cfg.StorageType)
+	anyError := cfg.ReplicaFallback == dbtether.ReplicaFallbackAny
+	downloaded, err := downloadWithFallback(ctx, openStore, cfg.primaryTarget(), cfg.Replicas, anyError, logger)
 	if err != nil {
-		return fmt.Errorf("failed to download backup: %w", err)
+		return nil, fmt.Errorf("failed to download backup: %w", err)
+	}
+	backupData := downloaded.Body
+	result := &RestoreResult{Storage: downloaded.Source.Name, Path: downloaded.Source.Path}
+	if downloaded.PrimaryErr != nil {
+		result.PrimaryError = downloaded.PrimaryErr.Error()
 	}
 	defer func() {
 		if closeErr := backupData.Close(); closeErr != nil {
@@ -72,15 +104,15 @@ func RunRestore(ctx context.Context, cfg *RestoreConfig) error {
 	switch cfg.OnConflict {
 	case "drop":
 		if err := dropAndRecreateDatabase(ctx, cfg, logger); err != nil {
-			return fmt.Errorf("failed to drop/recreate database: %w", err)
+			return nil, fmt.Errorf("failed to drop/recreate database: %w", err)
 		}
 	case "fail":
 		isEmpty, err := isDatabaseEmpty(ctx, cfg)
 		if err != nil {
-			return fmt.Errorf("failed to check if database is empty: %w", err)
+			return nil, fmt.Errorf("failed to check if database is empty: %w", err)
 		}
 		if !isEmpty {
-			return fmt.Errorf("database is not empty and onConflict=fail")
+			return nil, fmt.Errorf("database is not empty and onConflict=fail")
 		}
 	case "overwrite":
 		// Just proceed with restore
@@ -88,41 +120,32 @@ func RunRestore(ctx context.Context, cfg *RestoreConfig) error {
 
 	// Restore using psql
 	if err := restoreWithPsql(ctx, cfg, backupData, logger); err != nil {
-		return fmt.Errorf("restore failed: %w", err)
+		return nil, fmt.Errorf("restore failed: %w", err)
 	}
 
 	logger.Info("restore completed successfully", "database", cfg.Database)
-	return nil
+	return result, nil
 }
 
-func downloadBackup(ctx context.Context, cfg *RestoreConfig, logger *slog.Logger) (io.ReadCloser, error) {
-	logger.Info("downloading backup", "path", cfg.SourcePath, "storageType", cfg.StorageType)
-
-	switch cfg.StorageType {
-	case "s3":
-		client, err := storage.NewS3Client(ctx, cfg.S3Config, logger)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create S3 client: %w", err)
-		}
-		return client.Download(ctx, cfg.SourcePath)
-
-	case "gcs":
-		client, err := storage.NewGCSClient(ctx, cfg.GCSConfig, logger)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create GCS client: %w", err)
-		}
-		return client.Download(ctx, cfg.SourcePath)
-
-	case "azure":
-		client, err := storage.NewAzureClient(ctx, cfg.AzureConfig, logger)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create Azure client: %w", err)
-		}
-		return client.Download(ctx, cfg.SourcePath)
-
-	default:
-		return nil, fmt.Errorf("unsupported storage type: %s", cfg.StorageType)
+// primaryTarget returns the source storage as a StorageTarget
+func (cfg *RestoreConfig) primaryTarget() *StorageTarget {
+	target := &StorageTarget{StorageType: cfg.StorageType, Path: cfg.SourcePath}
+	if cfg.S3Config != nil {
+		target.S3Config = *cfg.S3Config
+	}
+	if cfg.GCSConfig != nil {
+		target.GCSConfig = *cfg.GCSConfig
+	}
+	if cfg.AzureConfig != nil {
+		target.AzureConfig = *cfg.AzureConfig
 	}
+	if cfg.FilesystemConfig != nil {
+		target.FilesystemConfig = *cfg.FilesystemConfig
+	}
+	if cfg.SFTPConfig != nil {
+		target.SFTPConfig = *cfg.SFTPConfig
+	}
+	return target
 }
 
 func dropAndRecreateDatabase(ctx context.Context, cfg *RestoreConfig, logger *slog.Logger) error {
@@ -141,7 +164,7 @@ func dropAndRecreateDatabase(ctx context.Context, cfg *RestoreConfig, logger *sl
 		WHERE datname = '%s' AND pid <> pg_backend_pid()
 	`, cfg.Database)
 
-	cmd := exec.CommandContext(ctx, "psql", connStr, "-c", dropConnsSQL) //nolint:gosec // intentional variable-based command
+	cmd := exec.CommandContext(ctx, pgBinary(cfg.BinDir, "psql"), connStr, "-c", dropConnsSQL) //nolint:gosec // intentional variable-based command
 	cmd.Env = append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", cfg.Password))
 	if output, err := cmd.CombinedOutput(); err != nil {
 		logger.Warn("failed to terminate connections", "output", string(output))
@@ -149,7 +172,7 @@ func dropAndRecreateDatabase(ctx context.Context, cfg *RestoreConfig, logger *sl
 
 	// Drop database
 	dropSQL := fmt.Sprintf("DROP DATABASE IF EXISTS %s", quoteIdentifier(cfg.Database))
-	cmd = exec.CommandContext(ctx, "psql", connStr, "-c", dropSQL) //nolint:gosec // intentional variable-based command
+	cmd = exec.CommandContext(ctx, pgBinary(cfg.BinDir, "psql"), connStr, "-c", dropSQL) //nolint:gosec // intentional variable-based command
 	cmd.Env = append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", cfg.Password))
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to drop database: %s: %w", string(output), err)
@@ -157,7 +180,7 @@ func dropAndRecreateDatabase(ctx context.Context, cfg *RestoreConfig, logger *sl
 
 	// Create database
 	createSQL := fmt.Sprintf("CREATE DATABASE %s", quoteIdentifier(cfg.Database))
-	cmd = exec.CommandContext(ctx, "psql", connStr, "-c", createSQL) //nolint:gosec // intentional variable-based command
+	cmd = exec.CommandContext(ctx, pgBinary(cfg.BinDir, "psql"), connStr, "-c", createSQL) //nolint:gosec // intentional variable-based command
 	cmd.Env = append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", cfg.Password))
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to create database: %s: %w", string(output), err)
@@ -176,7 +199,7 @@ func isDatabaseEmpty(ctx context.Context, cfg *RestoreConfig) (bool, error) {
 	// Count tables in public schema
 	sql := `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public'`
 
-	cmd := exec.CommandContext(ctx, "psql", connStr, "-t", "-c", sql) //nolint:gosec // intentional variable-based command
+	cmd := exec.CommandContext(ctx, pgBinary(cfg.BinDir, "psql"), connStr, "-t", "-c", sql) //nolint:gosec // intentional variable-based command
 	cmd.Env = append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", cfg.Password))
 	output, err := cmd.Output()
 	if err != nil {
@@ -195,22 +218,18 @@ func restoreWithPsql(ctx context.Context, cfg *RestoreConfig, backupData io.Read
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database, cfg.SSLMode,
 	)
 
-	// Decompress if gzipped
-	var reader io.Reader = backupData
-	if strings.HasSuffix(cfg.SourcePath, ".gz") {
-		gzReader, err := gzip.NewReader(backupData)
-		if err != nil {
-			return fmt.Errorf("failed to create gzip reader: %w", err)
-		}
-		defer func() {
-			if closeErr := gzReader.Close(); closeErr != nil {
-				logger.Warn("failed to close gzip reader", "error", closeErr)
-			}
-		}()
-		reader = gzReader
+	reader, codec, err := decompressReader(backupData)
+	if err != nil {
+		return err
 	}
+	defer func() {
+		if closeErr := reader.Close(); closeErr != nil {
+			logger.Warn("failed to close decompressor", "error", closeErr)
+		}
+	}()
+	logger.Info("detected backup compression", "codec", codec)
 
-	cmd := exec.CommandContext(ctx, "psql", connStr) //nolint:gosec // intentional variable-based command
+	cmd := exec.CommandContext(ctx, pgBinary(cfg.BinDir, "psql"), connStr) //nolint:gosec // intentional variable-based command
 	cmd.Stdin = reader
 	cmd.Env = append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", cfg.Password))
 
@@ -2,6 +2,7 @@ package backup
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"sort"
@@ -86,16 +87,26 @@ func (m *RetentionManager) ApplyRetention(
 	return toDelete, nil
 }
 
-// DeleteFiles deletes the specified files from storage
+// DeleteFiles deletes the specified files from storage.
+// Files still protected by an object lock or legal hold are skipped; the next cleanup retries them.
 func (m *RetentionManager) DeleteFiles(ctx context.Context, storageClient storage.StorageClient, keys []string) error {
+	locked := 0
 	for _, key := range keys {
-		if err := storageClient.Delete(ctx, key); err != nil {
+		err := storageClient.Delete(ctx, key)
+		switch {
+		case errors.Is(err, storage.ErrObjectLocked):
+			locked++
+			m.Log.Debugw("skipping locked backup file", "key", key, "reason", err)
+		case err != nil:
 			m.Log.Warnw("failed to delete backup file", "key", key, "error", err)
 			// Continue with other deletions
-		} else {
+		default:
 			m.Log.Infow("deleted backup file", "key", key)
 		}
 	}
+	if locked > 0 {
+		m.Log.Infow("retention: skipped locked backup files", "count", locked)
+	}
 	return nil
 }
 
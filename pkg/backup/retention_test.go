@@ -562,3 +562,19 @@ func TestMockClient_Clear(t *testing.T) {
 
 	assert.Equal(t, 0, mockClient.Count())
 }
+
+func TestDeleteFiles_SkipsLockedFiles(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	rm := NewRetentionManager(logger.Sugar())
+	ctx := context.Background()
+
+	mockClient := storage.NewMockClient()
+	mockClient.AddObject("locked.sql.gz", []byte("data"), time.Now())
+	mockClient.AddObject("expired.sql.gz", []byte("data"), time.Now())
+	mockClient.LockObject("locked.sql.gz", time.Now().Add(24*time.Hour))
+
+	err := rm.DeleteFiles(ctx, mockClient, []string{"locked.sql.gz", "expired.sql.gz"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"locked.sql.gz"}, mockClient.Keys(), "locked file should be kept, the rest deleted")
+}
@@ -2,15 +2,16 @@ package backup
 
 import (
 	"bytes"
-	"compress/gzip"
 	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"text/template"
 	"time"
 
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
 	"github.com/certainty3452/dbtether/pkg/storage"
 )
 
@@ -23,21 +24,52 @@ type BackupConfig struct {
 	Password string
 
 	// Storage
-	StorageType string // "s3", "gcs", "azure"
-	S3Config    storage.S3Config
-	GCSConfig   storage.GCSConfig
-	AzureConfig storage.AzureConfig
+	StorageType      string // "s3", "gcs", "azure", "pvc", "sftp"
+	S3Config         storage.S3Config
+	GCSConfig        storage.GCSConfig
+	AzureConfig      storage.AzureConfig
+	FilesystemConfig storage.FilesystemConfig
+	SFTPConfig       storage.SFTPConfig
+
+	// Retention of the schedule, applied by the Job to a pvc or sftp storage
+	Retention *dbtether.RetentionPolicy
 
 	// Output
 	PathTemplate     string
 	FilenameTemplate string
 
+	// Compression codec ("gzip", "zstd", "lz4", "none"; empty is gzip) and level (0: codec default)
+	Compression      string
+	CompressionLevel int
+
 	// Metadata for templates and tags
 	ClusterName  string
 	DatabaseName string
 	BackupName   string
 	Namespace    string
 	RunID        string // Unique identifier for this backup run
+
+	// Directory with pg_dump; empty uses PATH
+	BinDir string
+
+	// Globals dumps roles and tablespaces with pg_dumpall instead of the database
+	Globals bool
+
+	// Replicas the backup is copied to after the primary upload
+	Replicas []StorageTarget
+}
+
+// primaryTarget returns the primary storage as a StorageTarget
+func (cfg *BackupConfig) primaryTarget() *StorageTarget {
+	return &StorageTarget{
+		StorageType:      cfg.StorageType,
+		S3Config:         cfg.S3Config,
+		GCSConfig:        cfg.GCSConfig,
+		AzureConfig:      cfg.AzureConfig,
+		FilesystemConfig: cfg.FilesystemConfig,
+		SFTPConfig:       cfg.SFTPConfig,
+		Retention:        cfg.Retention,
+	}
 }
 
 type TemplateData struct {
@@ -56,6 +88,9 @@ type BackupResult struct {
 	Size             int64  // Size of compressed backup in bytes
 	UncompressedSize int64  // Size before compression
 	Duration         time.Duration
+
+	// Outcome of the copy to each replica storage
+	Replicas []dbtether.ReplicaStatus
 }
 
 func RunBackup(ctx context.Context, cfg *BackupConfig) (*BackupResult, error) {
@@ -82,73 +117,50 @@ func RunBackup(ctx context.Context, cfg *BackupConfig) (*BackupResult, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute filename template: %w", err)
 	}
+	filename = compressedFilename(filename, cfg.Compression)
 
 	fullPath := strings.TrimSuffix(path, "/") + "/" + filename
 
-	// Run pg_dump
-	dumpData, err := runPgDump(ctx, cfg)
+	// Run pg_dump (or pg_dumpall for globals)
+	dump := runPgDump
+	if cfg.Globals {
+		dump = runPgDumpAllGlobals
+	}
+	dumpData, err := dump(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("pg_dump failed: %w", err)
 	}
 	uncompressedSize := int64(len(dumpData))
 
-	// Compress with gzip
-	var compressed bytes.Buffer
-	gzWriter := gzip.NewWriter(&compressed)
-	if _, err := gzWriter.Write(dumpData); err != nil {
-		return nil, fmt.Errorf("gzip compression failed: %w", err)
-	}
-	if err := gzWriter.Close(); err != nil {
-		return nil, fmt.Errorf("gzip close failed: %w", err)
+	compressed, err := compress(dumpData, cfg.Compression, cfg.CompressionLevel)
+	if err != nil {
+		return nil, err
 	}
-	compressedSize := int64(compressed.Len())
+	compressedSize := int64(len(compressed))
 
 	// Build tags for object metadata
 	tags := &storage.ObjectTags{
-		Database:   cfg.DatabaseName,
-		Cluster:    cfg.ClusterName,
-		BackupName: cfg.BackupName,
-		Namespace:  cfg.Namespace,
-		Timestamp:  data.Timestamp,
-		CreatedBy:  "dbtether",
+		Database:    cfg.DatabaseName,
+		Cluster:     cfg.ClusterName,
+		BackupName:  cfg.BackupName,
+		Namespace:   cfg.Namespace,
+		Timestamp:   data.Timestamp,
+		CreatedBy:   "dbtether",
+		Compression: codecName(cfg.Compression),
 	}
 
-	// Upload to storage
-	switch cfg.StorageType {
-	case "s3":
-		s3Client, err := storage.NewS3Client(ctx, &cfg.S3Config, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create S3 client: %w", err)
-		}
-		if err := s3Client.UploadWithTags(ctx, fullPath, bytes.NewReader(compressed.Bytes()), tags); err != nil {
-			return nil, fmt.Errorf("S3 upload failed: %w", err)
-		}
-	case "gcs":
-		gcsClient, err := storage.NewGCSClient(ctx, &cfg.GCSConfig, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create GCS client: %w", err)
-		}
-		defer func() { _ = gcsClient.Close() }()
-		if err := gcsClient.UploadWithTags(ctx, fullPath, bytes.NewReader(compressed.Bytes()), tags); err != nil {
-			return nil, fmt.Errorf("GCS upload failed: %w", err)
-		}
-	case "azure":
-		azureClient, err := storage.NewAzureClient(ctx, &cfg.AzureConfig, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create Azure client: %w", err)
-		}
-		if err := azureClient.UploadWithTags(ctx, fullPath, bytes.NewReader(compressed.Bytes()), tags); err != nil {
-			return nil, fmt.Errorf("azure blob upload failed: %w", err)
-		}
-	default:
-		return nil, fmt.Errorf("unsupported storage type: %s", cfg.StorageType)
+	// Upload to storage, then to the replicas
+	if err := uploadTo(ctx, openStore, cfg.primaryTarget(), fullPath, compressed, tags); err != nil {
+		return nil, err
 	}
+	replicas := replicate(ctx, openStore, cfg.Replicas, &data, filename, compressed, tags)
 
 	return &BackupResult{
 		Path:             fullPath,
 		Size:             compressedSize,
 		UncompressedSize: uncompressedSize,
 		Duration:         time.Since(startTime),
+		Replicas:         replicas,
 	}, nil
 }
 
@@ -156,7 +168,7 @@ func runPgDump(ctx context.Context, cfg *BackupConfig) ([]byte, error) {
 	// Use separate arguments instead of connection string for security
 	// Each argument is isolated and properly escaped by exec.CommandContext
 	// #nosec G204 -- args from trusted config (CRD spec), not user input
-	cmd := exec.CommandContext(ctx, "pg_dump",
+	cmd := exec.CommandContext(ctx, pgBinary(cfg.BinDir, "pg_dump"),
 		"--host", cfg.Host,
 		"--port", fmt.Sprintf("%d", cfg.Port),
 		"--dbname", cfg.Database,
@@ -180,6 +192,39 @@ func runPgDump(ctx context.Context, cfg *BackupConfig) ([]byte, error) {
 	return stdout.Bytes(), nil
 }
 
+// runPgDumpAllGlobals dumps roles and tablespaces. Role passwords are skipped because
+// managed services (RDS, Cloud SQL) do not allow reading pg_authid.
+func runPgDumpAllGlobals(ctx context.Context, cfg *BackupConfig) ([]byte, error) {
+	// #nosec G204 -- args from trusted config (CRD spec), not user input
+	cmd := exec.CommandContext(ctx, pgBinary(cfg.BinDir, "pg_dumpall"),
+		"--host", cfg.Host,
+		"--port", fmt.Sprintf("%d", cfg.Port),
+		"--database", cfg.Database,
+		"--username", cfg.Username,
+		"--globals-only",
+		"--no-role-passwords",
+	)
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+cfg.Password)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pg_dumpall error: %s, stderr: %s", err, stderr.String())
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// pgBinary returns the path of a PostgreSQL client binary, looked up in PATH when binDir is empty
+func pgBinary(binDir, name string) string {
+	if binDir == "" {
+		return name
+	}
+	return filepath.Join(binDir, name)
+}
+
 func executeTemplate(tmpl string, data *TemplateData) (string, error) {
 	t, err := template.New("").Parse(tmpl)
 	if err != nil {
@@ -153,3 +153,12 @@ type validationError struct {
 func (e *validationError) Error() string {
 	return e.field + ": " + e.msg
 }
+
+func TestPgBinary(t *testing.T) {
+	if got := pgBinary("", "pg_dump"); got != "pg_dump" {
+		t.Errorf("pgBinary without dir = %q, want PATH lookup", got)
+	}
+	if got := pgBinary("/usr/libexec/postgresql13", "psql"); got != "/usr/libexec/postgresql13/psql" {
+		t.Errorf("pgBinary = %q", got)
+	}
+}
@@ -0,0 +1,310 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+const (
+	DefaultMaxAttempts  = 5
+	DefaultRetryBackoff = 2 * time.Second
+	DefaultDedupWindow  = 10 * time.Minute
+
+	// URLSecretKey is the key of the endpoint URL in a channel's urlSecretRef
+	URLSecretKey = "url"
+
+	queueSize      = 1000
+	requestTimeout = 10 * time.Second
+)
+
+// Dispatcher delivers events to NotificationChannels in the background.
+// It runs as a manager Runnable on the leader only, like the controllers emitting events.
+type Dispatcher struct {
+	Client     client.Client
+	HTTPClient *http.Client
+
+	// MaxAttempts per channel and event, with exponential backoff starting at RetryBackoff
+	MaxAttempts  int
+	RetryBackoff time.Duration
+
+	// DedupWindow drops events with an ID already seen within the window
+	DedupWindow time.Duration
+
+	queue    chan Event
+	mu       sync.Mutex
+	seen     map[string]time.Time
+	inflight sync.WaitGroup
+}
+
+func NewDispatcher(c client.Client) *Dispatcher {
+	return &Dispatcher{
+		Client:       c,
+		HTTPClient:   &http.Client{Timeout: requestTimeout},
+		MaxAttempts:  DefaultMaxAttempts,
+		RetryBackoff: DefaultRetryBackoff,
+		DedupWindow:  DefaultDedupWindow,
+		queue:        make(chan Event, queueSize),
+		seen:         map[string]time.Time{},
+	}
+}
+
+// Notify queues an event without blocking; events are dropped when the queue is full
+func (d *Dispatcher) Notify(event Event) {
+	select {
+	case d.queue <- event:
+	default:
+		log.Log.WithName("notify").Info("notification queue full, dropping event",
+			"reason", event.Reason, "kind", event.Kind, "name", event.Name)
+	}
+}
+
+// Start processes queued events until ctx is done, then waits for running deliveries
+func (d *Dispatcher) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			d.inflight.Wait()
+			return nil
+		case event := <-d.queue:
+			d.dispatch(ctx, event)
+		}
+	}
+}
+
+// NeedLeaderElection makes the dispatcher run on the leader only
+func (d *Dispatcher) NeedLeaderElection() bool {
+	return true
+}
+
+// dispatch starts one delivery per matching channel
+func (d *Dispatcher) dispatch(ctx context.Context, event Event) {
+	logger := log.FromContext(ctx).WithName("notify")
+	if d.duplicate(event) {
+		logger.V(1).Info("duplicate event dropped", "id", event.ID, "reason", event.Reason)
+		return
+	}
+
+	var channels dbtether.NotificationChannelList
+	if err := d.Client.List(ctx, &channels); err != nil {
+		logger.Error(err, "failed to list notification channels")
+		return
+	}
+
+	for i := range channels.Items {
+		channel := &channels.Items[i]
+		if !Matches(channel, event) {
+			continue
+		}
+		d.inflight.Add(1)
+		go func() {
+			defer d.inflight.Done()
+			d.deliver(ctx, channel, event)
+		}()
+	}
+}
+
+// duplicate records the event ID and reports whether it was seen within the dedup window
+func (d *Dispatcher) duplicate(event Event) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for id, seenAt := range d.seen {
+		if now.Sub(seenAt) > d.DedupWindow {
+			delete(d.seen, id)
+		}
+	}
+	if _, ok := d.seen[event.ID]; ok {
+		return true
+	}
+	d.seen[event.ID] = now
+	return false
+}
+
+// Matches returns true if the channel delivers the event
+func Matches(channel *dbtether.NotificationChannel, event Event) bool {
+	spec := &channel.Spec
+	if spec.Suspend || !spec.Deliver(event.Reason) {
+		return false
+	}
+	if len(spec.Subscriptions) == 0 {
+		return true
+	}
+	if slices.Contains(event.Channels, channel.Name) && spec.AllowsOptIn(event.Namespace) {
+		return true
+	}
+	for i := range spec.Subscriptions {
+		if subscriptionMatches(&spec.Subscriptions[i], event) {
+			return true
+		}
+	}
+	return false
+}
+
+func subscriptionMatches(sub *dbtether.NotificationSubscription, event Event) bool {
+	if len(sub.Kinds) > 0 && !slices.Contains(sub.Kinds, event.Kind) {
+		return false
+	}
+	if len(sub.Namespaces) > 0 && !slices.Contains(sub.Namespaces, event.Namespace) {
+		return false
+	}
+	if len(sub.Names) > 0 && !slices.Contains(sub.Names, event.Name) {
+		return false
+	}
+	if sub.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(sub.LabelSelector)
+		if err != nil || !selector.Matches(labels.Set(event.Labels)) {
+			return false
+		}
+	}
+	return true
+}
+
+// deliver sends the event to one channel, retrying temporary failures, and records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, channel *dbtether.NotificationChannel, event Event) {
+	logger := log.FromContext(ctx).WithName("notify").WithValues("channel", channel.Name, "reason", event.Reason)
+
+	err := d.send(ctx, channel, event)
+	if err != nil {
+		logger.Error(err, "notification not delivered")
+	} else {
+		logger.V(1).Info("notification delivered")
+	}
+
+	if statusErr := d.recordDelivery(ctx, channel.Name, err); statusErr != nil {
+		logger.Error(statusErr, "failed to update notification channel status")
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, channel *dbtether.NotificationChannel, event Event) error {
+	url, err := ResolveURL(ctx, d.Client, &channel.Spec)
+	if err != nil {
+		return err
+	}
+	body, contentType, err := renderPayload(channel.Spec.Type, event)
+	if err != nil {
+		return err
+	}
+
+	backoff := d.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err = d.post(ctx, url, contentType, channel.Spec.Headers, body)
+		if err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= d.MaxAttempts {
+			return fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// permanentError is a response that retrying does not fix (4xx other than 408/429)
+type permanentError struct {
+	status int
+	body   string
+}
+
+func (e *permanentError) Error() string {
+	if e.status == 0 {
+		return e.body
+	}
+	return fmt.Sprintf("endpoint returned %d: %s", e.status, e.body)
+}
+
+func (d *Dispatcher) post(ctx context.Context, url, contentType string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{body: err.Error()}
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{status: resp.StatusCode, body: string(bytes.TrimSpace(respBody))}
+	}
+	return fmt.Errorf("endpoint returned %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+}
+
+// recordDelivery updates the channel's delivery counters. Counters are incremented
+// with optimistic locking so concurrent deliveries do not overwrite each other.
+func (d *Dispatcher) recordDelivery(ctx context.Context, name string, deliveryErr error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var channel dbtether.NotificationChannel
+		if err := d.Client.Get(ctx, types.NamespacedName{Name: name}, &channel); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		patch := client.MergeFromWithOptions(channel.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		now := metav1.Now()
+		if deliveryErr == nil {
+			channel.Status.Delivered++
+			channel.Status.LastDeliveryTime = &now
+		} else {
+			channel.Status.Failed++
+			channel.Status.LastError = deliveryErr.Error()
+			channel.Status.LastErrorTime = &now
+		}
+		return d.Client.Status().Patch(ctx, &channel, patch)
+	})
+}
+
+// ResolveURL returns the channel's endpoint URL, reading urlSecretRef if set
+func ResolveURL(ctx context.Context, c client.Client, spec *dbtether.NotificationChannelSpec) (string, error) {
+	switch {
+	case spec.URL != "" && spec.URLSecretRef != nil:
+		return "", fmt.Errorf("url and urlSecretRef are mutually exclusive")
+	case spec.URL != "":
+		return spec.URL, nil
+	case spec.URLSecretRef != nil:
+		var secret corev1.Secret
+		ref := spec.URLSecretRef
+		if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, &secret); err != nil {
+			return "", fmt.Errorf("url secret %s/%s: %w", ref.Namespace, ref.Name, err)
+		}
+		url := string(bytes.TrimSpace(secret.Data[URLSecretKey]))
+		if url == "" {
+			return "", fmt.Errorf("url secret %s/%s has no key %q", ref.Namespace, ref.Name, URLSecretKey)
+		}
+		return url, nil
+	default:
+		return "", fmt.Errorf("one of url or urlSecretRef must be set")
+	}
+}
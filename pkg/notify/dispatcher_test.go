@@ -0,0 +1,399 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+// fakeEndpoint answers with the queued status codes, then 200
+type fakeEndpoint struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (f *fakeEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	f.requests = append(f.requests, r)
+	f.bodies = append(f.bodies, string(body))
+
+	status := http.StatusOK
+	if len(f.statuses) > 0 {
+		status, f.statuses = f.statuses[0], f.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (f *fakeEndpoint) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+func newTestDispatcher(t *testing.T, objs ...client.Object) *Dispatcher {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = dbtether.AddToScheme(scheme)
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&dbtether.NotificationChannel{}).
+		Build()
+
+	d := NewDispatcher(c)
+	d.RetryBackoff = time.Millisecond
+	return d
+}
+
+func newTestChannel(name, url string) *dbtether.NotificationChannel {
+	return &dbtether.NotificationChannel{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       dbtether.NotificationChannelSpec{Type: TypeWebhook, URL: url},
+	}
+}
+
+func getChannel(t *testing.T, d *Dispatcher, name string) *dbtether.NotificationChannel {
+	t.Helper()
+	var channel dbtether.NotificationChannel
+	if err := d.Client.Get(context.Background(), types.NamespacedName{Name: name}, &channel); err != nil {
+		t.Fatalf("get channel: %v", err)
+	}
+	return &channel
+}
+
+func TestMatches(t *testing.T) {
+	event := testEvent(dbtether.EventBackupFailed)
+
+	tests := []struct {
+		name string
+		spec dbtether.NotificationChannelSpec
+		want bool
+	}{
+		{name: "no subscriptions matches all", want: true},
+		{name: "suspended", spec: dbtether.NotificationChannelSpec{Suspend: true}},
+		{
+			name: "event filtered out",
+			spec: dbtether.NotificationChannelSpec{Events: []string{dbtether.EventBackupCompleted}},
+		},
+		{
+			name: "event selected",
+			spec: dbtether.NotificationChannelSpec{Events: []string{dbtether.EventBackupFailed}},
+			want: true,
+		},
+		{
+			name: "kind and namespace",
+			spec: dbtether.NotificationChannelSpec{Subscriptions: []dbtether.NotificationSubscription{
+				{Kinds: []string{"Backup"}, Namespaces: []string{"team-a"}},
+			}},
+			want: true,
+		},
+		{
+			name: "other namespace",
+			spec: dbtether.NotificationChannelSpec{Subscriptions: []dbtether.NotificationSubscription{
+				{Namespaces: []string{"team-b"}},
+			}},
+		},
+		{
+			name: "other name",
+			spec: dbtether.NotificationChannelSpec{Subscriptions: []dbtether.NotificationSubscription{
+				{Names: []string{"weekly"}},
+			}},
+		},
+		{
+			name: "label selector",
+			spec: dbtether.NotificationChannelSpec{Subscriptions: []dbtether.NotificationSubscription{
+				{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}},
+			}},
+			want: true,
+		},
+		{
+			name: "label selector mismatch, second subscription matches",
+			spec: dbtether.NotificationChannelSpec{Subscriptions: []dbtether.NotificationSubscription{
+				{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}}},
+				{Kinds: []string{"Backup"}},
+			}},
+			want: true,
+		},
+		{
+			name: "label selector mismatch",
+			spec: dbtether.NotificationChannelSpec{Subscriptions: []dbtether.NotificationSubscription{
+				{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := &dbtether.NotificationChannel{ObjectMeta: metav1.ObjectMeta{Name: "ops"}, Spec: tt.spec}
+			if got := Matches(channel, event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatches_AnnotationOptIn(t *testing.T) {
+	event := testEvent(dbtether.EventBackupFailed)
+	subscriptions := []dbtether.NotificationSubscription{{Namespaces: []string{"team-b"}}}
+
+	tests := []struct {
+		name string
+		spec dbtether.NotificationChannelSpec
+		want bool
+	}{
+		{
+			name: "namespace not allowed to opt in",
+			spec: dbtether.NotificationChannelSpec{Subscriptions: subscriptions},
+		},
+		{
+			name: "namespace allowed to opt in",
+			spec: dbtether.NotificationChannelSpec{Subscriptions: subscriptions, AllowedNamespaces: []string{"team-a"}},
+			want: true,
+		},
+		{
+			name: "all namespaces allowed to opt in",
+			spec: dbtether.NotificationChannelSpec{Subscriptions: subscriptions, AllowedNamespaces: []string{"*"}},
+			want: true,
+		},
+		{
+			name: "events filter applies to opted-in resources",
+			spec: dbtether.NotificationChannelSpec{
+				Subscriptions: subscriptions, AllowedNamespaces: []string{"team-a"},
+				Events: []string{dbtether.EventBackupCompleted},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := &dbtether.NotificationChannel{ObjectMeta: metav1.ObjectMeta{Name: "oncall"}, Spec: tt.spec}
+			if got := Matches(channel, event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	clusterEvent := event
+	clusterEvent.Namespace = ""
+	channel := &dbtether.NotificationChannel{ObjectMeta: metav1.ObjectMeta{Name: "oncall"},
+		Spec: dbtether.NotificationChannelSpec{Subscriptions: subscriptions}}
+	if !Matches(channel, clusterEvent) {
+		t.Error("cluster-scoped resources can always opt in")
+	}
+}
+
+func TestDispatch_DeliversAndRecords(t *testing.T) {
+	endpoint := &fakeEndpoint{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	channel := newTestChannel("ops", server.URL)
+	channel.Spec.Headers = map[string]string{"Authorization": "Bearer token"}
+	other := newTestChannel("dev", server.URL)
+	other.Spec.Subscriptions = []dbtether.NotificationSubscription{{Namespaces: []string{"team-b"}}}
+	d := newTestDispatcher(t, channel, other)
+
+	d.dispatch(context.Background(), testEvent(dbtether.EventBackupFailed))
+	d.inflight.Wait()
+
+	if endpoint.count() != 1 {
+		t.Fatalf("requests = %d, want 1", endpoint.count())
+	}
+	if got := endpoint.requests[0].Header.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if !strings.Contains(endpoint.bodies[0], `"reason":"BackupFailed"`) {
+		t.Errorf("body = %s", endpoint.bodies[0])
+	}
+
+	status := getChannel(t, d, "ops").Status
+	if status.Delivered != 1 || status.Failed != 0 || status.LastDeliveryTime == nil {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if getChannel(t, d, "dev").Status.Delivered != 0 {
+		t.Error("unsubscribed channel should not be counted")
+	}
+}
+
+func TestDispatch_Dedup(t *testing.T) {
+	endpoint := &fakeEndpoint{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	d := newTestDispatcher(t, newTestChannel("ops", server.URL))
+
+	for range 3 {
+		d.dispatch(context.Background(), testEvent(dbtether.EventBackupFailed))
+	}
+	d.inflight.Wait()
+	if endpoint.count() != 1 {
+		t.Errorf("requests = %d, want 1 (duplicates dropped)", endpoint.count())
+	}
+
+	d.DedupWindow = 0
+	time.Sleep(time.Millisecond)
+	d.dispatch(context.Background(), testEvent(dbtether.EventBackupFailed))
+	d.inflight.Wait()
+	if endpoint.count() != 2 {
+		t.Errorf("requests = %d, want 2 after the window expired", endpoint.count())
+	}
+}
+
+func TestDispatch_RetriesTemporaryErrors(t *testing.T) {
+	endpoint := &fakeEndpoint{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	d := newTestDispatcher(t, newTestChannel("ops", server.URL))
+	d.dispatch(context.Background(), testEvent(dbtether.EventBackupFailed))
+	d.inflight.Wait()
+
+	if endpoint.count() != 3 {
+		t.Errorf("requests = %d, want 3", endpoint.count())
+	}
+	if status := getChannel(t, d, "ops").Status; status.Delivered != 1 || status.Failed != 0 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestDispatch_GivesUp(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantRequests int
+	}{
+		{name: "client error is not retried", statuses: []int{http.StatusBadRequest}, wantRequests: 1},
+		{
+			name:         "attempts exhausted",
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			wantRequests: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := &fakeEndpoint{statuses: tt.statuses}
+			server := httptest.NewServer(endpoint)
+			defer server.Close()
+
+			d := newTestDispatcher(t, newTestChannel("ops", server.URL))
+			d.MaxAttempts = 3
+			d.dispatch(context.Background(), testEvent(dbtether.EventBackupFailed))
+			d.inflight.Wait()
+
+			if endpoint.count() != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", endpoint.count(), tt.wantRequests)
+			}
+			status := getChannel(t, d, "ops").Status
+			if status.Failed != 1 || status.Delivered != 0 || status.LastErrorTime == nil {
+				t.Errorf("unexpected status: %+v", status)
+			}
+			if !strings.Contains(status.LastError, "endpoint returned") {
+				t.Errorf("LastError = %q", status.LastError)
+			}
+		})
+	}
+}
+
+func TestNotify_QueueFull(t *testing.T) {
+	d := newTestDispatcher(t)
+	d.queue = make(chan Event, 1)
+
+	d.Notify(testEvent(dbtether.EventBackupFailed))
+	d.Notify(testEvent(dbtether.EventBackupCompleted))
+
+	if len(d.queue) != 1 {
+		t.Errorf("queued = %d, want 1", len(d.queue))
+	}
+}
+
+func TestStart_ProcessesQueue(t *testing.T) {
+	endpoint := &fakeEndpoint{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	d := newTestDispatcher(t, newTestChannel("ops", server.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- d.Start(ctx) }()
+
+	d.Notify(testEvent(dbtether.EventBackupFailed))
+	deadline := time.Now().Add(5 * time.Second)
+	for endpoint.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Start() error = %v", err)
+	}
+	if endpoint.count() != 1 {
+		t.Errorf("requests = %d, want 1", endpoint.count())
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "slack", Namespace: "dbtether"},
+		Data:       map[string][]byte{URLSecretKey: []byte("https://hooks.slack.com/services/T/B/X\n")},
+	}
+	d := newTestDispatcher(t, secret)
+	ref := &dbtether.SecretReference{Name: "slack", Namespace: "dbtether"}
+
+	tests := []struct {
+		name    string
+		spec    dbtether.NotificationChannelSpec
+		want    string
+		wantErr string
+	}{
+		{name: "inline", spec: dbtether.NotificationChannelSpec{URL: "https://example.com/hook"}, want: "https://example.com/hook"},
+		{name: "secret", spec: dbtether.NotificationChannelSpec{URLSecretRef: ref}, want: "https://hooks.slack.com/services/T/B/X"},
+		{name: "neither", wantErr: "must be set"},
+		{
+			name:    "both",
+			spec:    dbtether.NotificationChannelSpec{URL: "https://example.com", URLSecretRef: ref},
+			wantErr: "mutually exclusive",
+		},
+		{
+			name:    "missing secret",
+			spec:    dbtether.NotificationChannelSpec{URLSecretRef: &dbtether.SecretReference{Name: "nope", Namespace: "dbtether"}},
+			wantErr: "not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveURL(context.Background(), d.Client, &tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ResolveURL() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ResolveURL() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
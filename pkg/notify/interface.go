@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+// Notifier delivers operator events to the matching NotificationChannels.
+// Notify must not block reconciles; delivery happens asynchronously.
+type Notifier interface {
+	Notify(event Event)
+}
+
+// Event is a status transition of a dbtether resource
+type Event struct {
+	// ID identifies the transition; the same ID is delivered once per dedup window
+	ID string `json:"id"`
+
+	Reason    string    `json:"reason"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+
+	// Details of the transition (backup path, size, error, ...)
+	Data map[string]string `json:"data,omitempty"`
+
+	// Labels of the resource, for label-selected subscriptions
+	Labels map[string]string `json:"-"`
+
+	// Channels the resource opted in to with the dbtether.io/notify annotation
+	Channels []string `json:"-"`
+}
+
+// NewEvent builds an event for obj. dedupKey distinguishes transitions with the same reason
+// (e.g. the backup run ID); an empty key deduplicates by reason alone within the dedup window.
+func NewEvent(obj client.Object, kind, reason, message, dedupKey string) Event {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		kind, obj.GetNamespace(), obj.GetName(), string(obj.GetUID()), reason, dedupKey,
+	}, "/")))
+
+	return Event{
+		ID:        hex.EncodeToString(sum[:8]),
+		Reason:    reason,
+		Kind:      kind,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Message:   message,
+		Time:      time.Now().UTC(),
+		Labels:    obj.GetLabels(),
+		Channels:  channelsFromAnnotation(obj.GetAnnotations()[dbtether.NotifyAnnotation]),
+	}
+}
+
+// WithData adds details to the event
+func (e Event) WithData(kv ...string) Event {
+	if e.Data == nil {
+		e.Data = map[string]string{}
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] != "" {
+			e.Data[kv[i]] = kv[i+1]
+		}
+	}
+	return e
+}
+
+// Severity returns "error" for failures and disconnects, "info" otherwise
+func (e Event) Severity() string {
+	switch e.Reason {
+	case dbtether.EventBackupFailed, dbtether.EventRestoreFailed, dbtether.EventClusterDisconnected:
+		return "error"
+	}
+	return "info"
+}
+
+func channelsFromAnnotation(value string) []string {
+	var channels []string
+	for _, c := range strings.Split(value, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			channels = append(channels, c)
+		}
+	}
+	return channels
+}
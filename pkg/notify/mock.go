@@ -0,0 +1,26 @@
+package notify
+
+import "sync"
+
+// MockNotifier records events for tests
+type MockNotifier struct {
+	mu     sync.Mutex
+	Events []Event
+}
+
+func (m *MockNotifier) Notify(event Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Events = append(m.Events, event)
+}
+
+// Reasons returns the reasons of the recorded events in order
+func (m *MockNotifier) Reasons() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	reasons := make([]string, 0, len(m.Events))
+	for _, e := range m.Events {
+		reasons = append(reasons, e.Reason)
+	}
+	return reasons
+}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Channel types
+const (
+	TypeWebhook     = "webhook"
+	TypeSlack       = "slack"
+	TypeCloudEvents = "cloudevents"
+)
+
+// CloudEventTypePrefix prefixes the CloudEvents type, e.g. io.dbtether.backupfailed
+const CloudEventTypePrefix = "io.dbtether."
+
+type webhookPayload struct {
+	Event
+	Severity string `json:"severity"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Text   string       `json:"text"`
+	Fields []slackField `json:"fields,omitempty"`
+	Ts     int64        `json:"ts"`
+}
+
+type slackPayload struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type cloudEvent struct {
+	SpecVersion     string         `json:"specversion"`
+	ID              string         `json:"id"`
+	Source          string         `json:"source"`
+	Type            string         `json:"type"`
+	Subject         string         `json:"subject"`
+	Time            string         `json:"time"`
+	DataContentType string         `json:"datacontenttype"`
+	Data            webhookPayload `json:"data"`
+}
+
+// renderPayload returns the request body and content type of an event for a channel type
+func renderPayload(channelType string, e Event) ([]byte, string, error) {
+	switch channelType {
+	case TypeWebhook:
+		body, err := json.Marshal(webhookPayload{Event: e, Severity: e.Severity()})
+		return body, "application/json", err
+	case TypeSlack:
+		body, err := json.Marshal(slackMessage(e))
+		return body, "application/json", err
+	case TypeCloudEvents:
+		body, err := json.Marshal(cloudEvent{
+			SpecVersion:     "1.0",
+			ID:              e.ID,
+			Source:          eventSource(e),
+			Type:            CloudEventTypePrefix + strings.ToLower(e.Reason),
+			Subject:         e.Name,
+			Time:            e.Time.Format(time.RFC3339),
+			DataContentType: "application/json",
+			Data:            webhookPayload{Event: e, Severity: e.Severity()},
+		})
+		return body, "application/cloudevents+json", err
+	default:
+		return nil, "", fmt.Errorf("unknown channel type %q", channelType)
+	}
+}
+
+func slackMessage(e Event) slackPayload {
+	color := "good"
+	if e.Severity() == "error" {
+		color = "danger"
+	}
+
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]slackField, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, slackField{Title: k, Value: e.Data[k], Short: len(e.Data[k]) < 40})
+	}
+
+	return slackPayload{
+		Text: fmt.Sprintf("*%s* %s %s", e.Reason, e.Kind, resourceName(e)),
+		Attachments: []slackAttachment{{
+			Color:  color,
+			Text:   e.Message,
+			Fields: fields,
+			Ts:     e.Time.Unix(),
+		}},
+	}
+}
+
+// eventSource identifies the resource as a CloudEvents source URI reference
+func eventSource(e Event) string {
+	if e.Namespace == "" {
+		return fmt.Sprintf("/dbtether/%s/%s", strings.ToLower(e.Kind), e.Name)
+	}
+	return fmt.Sprintf("/dbtether/namespaces/%s/%s/%s", e.Namespace, strings.ToLower(e.Kind), e.Name)
+}
+
+func resourceName(e Event) string {
+	if e.Namespace == "" {
+		return e.Name
+	}
+	return e.Namespace + "/" + e.Name
+}
@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dbtether "github.com/certainty3452/dbtether/api/v1alpha1"
+)
+
+func testEvent(reason string) Event {
+	backup := &dbtether.Backup{ObjectMeta: metav1.ObjectMeta{
+		Name:        "nightly",
+		Namespace:   "team-a",
+		UID:         "uid-1",
+		Labels:      map[string]string{"env": "prod"},
+		Annotations: map[string]string{dbtether.NotifyAnnotation: "oncall, audit"},
+	}}
+	e := NewEvent(backup, "Backup", reason, "backup job failed", "hash/run-1")
+	e.Time = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return e.WithData("database", "orders", "path", "")
+}
+
+func TestNewEvent(t *testing.T) {
+	e := testEvent(dbtether.EventBackupFailed)
+
+	if e.Namespace != "team-a" || e.Name != "nightly" || e.Kind != "Backup" {
+		t.Errorf("unexpected resource: %s %s/%s", e.Kind, e.Namespace, e.Name)
+	}
+	if len(e.Channels) != 2 || e.Channels[0] != "oncall" || e.Channels[1] != "audit" {
+		t.Errorf("Channels = %v, want [oncall audit]", e.Channels)
+	}
+	if _, ok := e.Data["path"]; ok {
+		t.Error("empty data values should be skipped")
+	}
+	if e.Data["database"] != "orders" {
+		t.Errorf("Data[database] = %q, want orders", e.Data["database"])
+	}
+	if e.Severity() != "error" {
+		t.Errorf("Severity() = %q, want error", e.Severity())
+	}
+
+	if again := testEvent(dbtether.EventBackupFailed); again.ID != e.ID {
+		t.Error("same transition should have the same ID")
+	}
+	if other := testEvent(dbtether.EventBackupCompleted); other.ID == e.ID {
+		t.Error("different reasons should have different IDs")
+	}
+}
+
+func TestRenderPayload_Webhook(t *testing.T) {
+	body, contentType, err := renderPayload(TypeWebhook, testEvent(dbtether.EventBackupFailed))
+	if err != nil {
+		t.Fatalf("renderPayload() error = %v", err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("content type = %q", contentType)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got["reason"] != "BackupFailed" || got["severity"] != "error" || got["namespace"] != "team-a" {
+		t.Errorf("unexpected payload: %s", body)
+	}
+	if _, ok := got["Labels"]; ok {
+		t.Error("labels should not be serialized")
+	}
+}
+
+func TestRenderPayload_Slack(t *testing.T) {
+	body, _, err := renderPayload(TypeSlack, testEvent(dbtether.EventBackupFailed))
+	if err != nil {
+		t.Fatalf("renderPayload() error = %v", err)
+	}
+
+	var got slackPayload
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Text != "*BackupFailed* Backup team-a/nightly" {
+		t.Errorf("Text = %q", got.Text)
+	}
+	if len(got.Attachments) != 1 || got.Attachments[0].Color != "danger" {
+		t.Fatalf("unexpected attachments: %+v", got.Attachments)
+	}
+	if fields := got.Attachments[0].Fields; len(fields) != 1 || fields[0].Title != "database" {
+		t.Errorf("Fields = %+v", fields)
+	}
+}
+
+func TestRenderPayload_CloudEvents(t *testing.T) {
+	e := testEvent(dbtether.EventBackupCompleted)
+	body, contentType, err := renderPayload(TypeCloudEvents, e)
+	if err != nil {
+		t.Fatalf("renderPayload() error = %v", err)
+	}
+	if contentType != "application/cloudevents+json" {
+		t.Errorf("content type = %q", contentType)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := map[string]string{
+		"specversion": "1.0",
+		"id":          e.ID,
+		"type":        "io.dbtether.backupcompleted",
+		"source":      "/dbtether/namespaces/team-a/backup/nightly",
+		"subject":     "nightly",
+		"time":        "2026-01-02T03:04:05Z",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %s", k, got[k], v)
+		}
+	}
+}
+
+func TestRenderPayload_ClusterScopedSource(t *testing.T) {
+	e := Event{Reason: dbtether.EventClusterConnected, Kind: "DBCluster", Name: "main"}
+	if got := eventSource(e); got != "/dbtether/dbcluster/main" {
+		t.Errorf("eventSource() = %q", got)
+	}
+	if got := slackMessage(e).Attachments[0].Color; got != "good" {
+		t.Errorf("color = %q, want good", got)
+	}
+}
+
+func TestRenderPayload_UnknownType(t *testing.T) {
+	if _, _, err := renderPayload("email", testEvent(dbtether.EventBackupFailed)); err == nil {
+		t.Error("expected error for unknown channel type")
+	}
+}
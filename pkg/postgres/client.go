@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -18,6 +19,10 @@ type Config struct {
 	Username string
 	Password string
 	Database string
+
+	// ServerSidePasswordEncryption sends plaintext role passwords for the server to hash,
+	// instead of SCRAM-SHA-256 verifiers computed by the client
+	ServerSidePasswordEncryption bool
 }
 
 type Client struct {
@@ -42,8 +47,16 @@ type ClientInterface interface {
 	UserExists(ctx context.Context, username string) (bool, error)
 	CreateUser(ctx context.Context, username, password string) error
 	SetPassword(ctx context.Context, username, password string) error
+	GetPasswordEncryption(ctx context.Context) (string, error)
 	SetConnectionLimit(ctx context.Context, username string, limit int) error
+	SetRoleAttributes(ctx context.Context, username string, attrs RoleAttributes) error
+	GetUserOwner(ctx context.Context, username string) (namespace, resourceName string, err error)
+	SetUserOwner(ctx context.Context, username, ownerNamespace, ownerName string) error
+	ClearUserOwner(ctx context.Context, username string) error
 	DropUser(ctx context.Context, username string) error
+	GetRoleDatabases(ctx context.Context, username string) ([]string, error)
+	GetDatabaseOwnerRole(ctx context.Context, database string) (string, error)
+	ReassignOwned(ctx context.Context, username, database, newOwner string) error
 	RevokeAllDatabaseAccess(ctx context.Context, username string) error
 	GrantDatabaseAccess(ctx context.Context, username, database string) error
 	RevokeDatabaseAccess(ctx context.Context, username, database string) error
@@ -185,7 +198,7 @@ func (c *Client) DatabaseExists(ctx context.Context, name string) (bool, error)
 	return exists, nil
 }
 
-// ownerComment formats the ownership comment for database metadata
+// ownerComment formats the ownership comment for database and role metadata
 func ownerComment(namespace, name string) string {
 	return fmt.Sprintf("dbtether:%s/%s", namespace, name)
 }
@@ -378,13 +391,30 @@ func (c *Client) UserExists(ctx context.Context, username string) (bool, error)
 	return exists, nil
 }
 
+// passwordLiteral returns the quoted SCRAM-SHA-256 verifier of the password, so the plaintext
+// never reaches the server (or its logs with log_statement), unless server-side encryption is configured
+func (c *Client) passwordLiteral(password string) (string, error) {
+	if c.config.ServerSidePasswordEncryption || !canPrecomputeVerifier(password) {
+		return pq.QuoteLiteral(password), nil
+	}
+	verifier, err := ScramSHA256Verifier(password)
+	if err != nil {
+		return "", err
+	}
+	return pq.QuoteLiteral(verifier), nil
+}
+
 func (c *Client) CreateUser(ctx context.Context, username, password string) error {
+	literal, err := c.passwordLiteral(password)
+	if err != nil {
+		return fmt.Errorf("failed to create user %s: %w", username, err)
+	}
 	query := fmt.Sprintf(
 		"CREATE USER %s WITH PASSWORD %s NOCREATEDB NOCREATEROLE NOINHERIT",
 		pq.QuoteIdentifier(username),
-		pq.QuoteLiteral(password),
+		literal,
 	)
-	_, err := c.pool.Exec(ctx, query)
+	_, err = c.pool.Exec(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to create user %s: %w", username, err)
 	}
@@ -392,18 +422,31 @@ func (c *Client) CreateUser(ctx context.Context, username, password string) erro
 }
 
 func (c *Client) SetPassword(ctx context.Context, username, password string) error {
+	literal, err := c.passwordLiteral(password)
+	if err != nil {
+		return fmt.Errorf("failed to set password for user %s: %w", username, err)
+	}
 	query := fmt.Sprintf(
 		"ALTER USER %s WITH PASSWORD %s",
 		pq.QuoteIdentifier(username),
-		pq.QuoteLiteral(password),
+		literal,
 	)
-	_, err := c.pool.Exec(ctx, query)
+	_, err = c.pool.Exec(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to set password for user %s: %w", username, err)
 	}
 	return nil
 }
 
+// GetPasswordEncryption returns the server's password_encryption setting (md5 or scram-sha-256)
+func (c *Client) GetPasswordEncryption(ctx context.Context) (string, error) {
+	var value string
+	if err := c.pool.QueryRow(ctx, "SHOW password_encryption").Scan(&value); err != nil {
+		return "", fmt.Errorf("failed to get password_encryption: %w", err)
+	}
+	return value, nil
+}
+
 func (c *Client) SetConnectionLimit(ctx context.Context, username string, limit int) error {
 	query := fmt.Sprintf(
 		"ALTER USER %s CONNECTION LIMIT %d",
@@ -417,6 +460,99 @@ func (c *Client) SetConnectionLimit(ctx context.Context, username string, limit
 	return nil
 }
 
+// RoleAttributes are role options reconciled via ALTER ROLE.
+// Nil flags and an empty ValidUntil leave the current setting unchanged.
+type RoleAttributes struct {
+	Replication *bool
+	BypassRLS   *bool
+	CreateDB    *bool
+	CreateRole  *bool
+
+	// ValidUntil is a timestamp accepted by PostgreSQL or "infinity"
+	ValidUntil string
+}
+
+// IsEmpty returns true if no attribute would be changed
+func (a RoleAttributes) IsEmpty() bool {
+	return a.Replication == nil && a.BypassRLS == nil && a.CreateDB == nil && a.CreateRole == nil && a.ValidUntil == ""
+}
+
+// Options returns the ALTER ROLE options for the attributes that are set
+func (a RoleAttributes) Options() []string {
+	var opts []string
+	flag := func(value *bool, on, off string) {
+		if value == nil {
+			return
+		}
+		if *value {
+			opts = append(opts, on)
+		} else {
+			opts = append(opts, off)
+		}
+	}
+	flag(a.Replication, "REPLICATION", "NOREPLICATION")
+	flag(a.BypassRLS, "BYPASSRLS", "NOBYPASSRLS")
+	flag(a.CreateDB, "CREATEDB", "NOCREATEDB")
+	flag(a.CreateRole, "CREATEROLE", "NOCREATEROLE")
+	if a.ValidUntil != "" {
+		opts = append(opts, "VALID UNTIL "+pq.QuoteLiteral(a.ValidUntil))
+	}
+	return opts
+}
+
+func (c *Client) SetRoleAttributes(ctx context.Context, username string, attrs RoleAttributes) error {
+	if attrs.IsEmpty() {
+		return nil
+	}
+	query := fmt.Sprintf(
+		"ALTER ROLE %s WITH %s",
+		pq.QuoteIdentifier(username),
+		strings.Join(attrs.Options(), " "),
+	)
+	_, err := c.pool.Exec(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to set role attributes for user %s: %w", username, err)
+	}
+	return nil
+}
+
+// GetUserOwner returns the DatabaseUser recorded in the role comment, empty for unmanaged roles
+func (c *Client) GetUserOwner(ctx context.Context, username string) (namespace, resourceName string, err error) {
+	var comment string
+	err = c.pool.QueryRow(ctx,
+		"SELECT COALESCE(shobj_description(oid, 'pg_authid'), '') FROM pg_roles WHERE rolname = $1",
+		username,
+	).Scan(&comment)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get role comment: %w", err)
+	}
+	ns, n, ok := parseOwnerComment(comment)
+	if !ok {
+		return "", "", nil // no owner set (legacy role) or comment not in our format
+	}
+	return ns, n, nil
+}
+
+func (c *Client) SetUserOwner(ctx context.Context, username, ownerNamespace, ownerName string) error {
+	query := fmt.Sprintf("COMMENT ON ROLE %s IS %s",
+		pq.QuoteIdentifier(username), pq.QuoteLiteral(ownerComment(ownerNamespace, ownerName)))
+	if _, err := c.pool.Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to set ownership of role %s: %w", username, err)
+	}
+	return nil
+}
+
+func (c *Client) ClearUserOwner(ctx context.Context, username string) error {
+	query := fmt.Sprintf("COMMENT ON ROLE %s IS NULL", pq.QuoteIdentifier(username))
+	if _, err := c.pool.Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to clear ownership of role %s: %w", username, err)
+	}
+	return nil
+}
+
 func (c *Client) DropUser(ctx context.Context, username string) error {
 	query := fmt.Sprintf("DROP USER IF EXISTS %s", pq.QuoteIdentifier(username))
 	_, err := c.pool.Exec(ctx, query)
@@ -426,6 +562,73 @@ func (c *Client) DropUser(ctx context.Context, username string) error {
 	return nil
 }
 
+// GetRoleDatabases lists databases where the role owns objects or holds privileges.
+// pg_shdepend records these per database; grants on the database itself are shared (dbid 0).
+func (c *Client) GetRoleDatabases(ctx context.Context, username string) ([]string, error) {
+	rows, err := c.pool.Query(ctx, `
+		SELECT d.datname FROM pg_database d, pg_roles r
+		WHERE r.rolname = $1 AND d.datallowconn AND (
+			d.datdba = r.oid OR EXISTS (
+				SELECT 1 FROM pg_shdepend s
+				WHERE s.refclassid = 'pg_authid'::regclass AND s.refobjid = r.oid
+				AND (s.dbid = d.oid OR (s.dbid = 0 AND s.classid = 'pg_database'::regclass AND s.objid = d.oid))
+			)
+		)
+		ORDER BY d.datname`, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases for role %s: %w", username, err)
+	}
+	defer rows.Close()
+
+	var databases []string
+	for rows.Next() {
+		var db string
+		if err := rows.Scan(&db); err != nil {
+			return nil, err
+		}
+		databases = append(databases, db)
+	}
+	return databases, rows.Err()
+}
+
+// GetDatabaseOwnerRole returns the PostgreSQL role owning the database
+func (c *Client) GetDatabaseOwnerRole(ctx context.Context, database string) (string, error) {
+	var owner string
+	err := c.pool.QueryRow(ctx,
+		"SELECT pg_get_userbyid(datdba) FROM pg_database WHERE datname = $1",
+		database,
+	).Scan(&owner)
+	if err != nil {
+		return "", fmt.Errorf("failed to get owner of database %s: %w", database, err)
+	}
+	return owner, nil
+}
+
+// ReassignOwned hands objects owned by username in the database over to newOwner and drops
+// its remaining privileges there, so the role can be dropped afterwards
+func (c *Client) ReassignOwned(ctx context.Context, username, database, newOwner string) error {
+	conn, err := c.connectToDatabase(ctx, database)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close(ctx) }() // error on close is not actionable
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction in %s: %w", database, err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // no-op after commit
+
+	quotedUser := pq.QuoteIdentifier(username)
+	if _, err := tx.Exec(ctx, fmt.Sprintf("REASSIGN OWNED BY %s TO %s", quotedUser, pq.QuoteIdentifier(newOwner))); err != nil {
+		return fmt.Errorf("failed to reassign objects owned by %s in %s: %w", username, database, err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf("DROP OWNED BY %s", quotedUser)); err != nil {
+		return fmt.Errorf("failed to drop privileges of %s in %s: %w", username, database, err)
+	}
+	return tx.Commit(ctx)
+}
+
 func (c *Client) RevokeAllDatabaseAccess(ctx context.Context, username string) error {
 	rows, err := c.pool.Query(ctx, "SELECT datname FROM pg_database WHERE datistemplate = false")
 	if err != nil {
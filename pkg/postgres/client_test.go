@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRoleAttributes_Options(t *testing.T) {
+	on, off := true, false
+
+	tests := []struct {
+		name     string
+		attrs    RoleAttributes
+		expected []string
+	}{
+		{
+			name:     "empty",
+			attrs:    RoleAttributes{},
+			expected: nil,
+		},
+		{
+			name:     "replication only",
+			attrs:    RoleAttributes{Replication: &on},
+			expected: []string{"REPLICATION"},
+		},
+		{
+			name:     "revoke previously granted",
+			attrs:    RoleAttributes{Replication: &off, BypassRLS: &off},
+			expected: []string{"NOREPLICATION", "NOBYPASSRLS"},
+		},
+		{
+			name:     "all flags and expiry",
+			attrs:    RoleAttributes{Replication: &on, BypassRLS: &on, CreateDB: &on, CreateRole: &off, ValidUntil: "2026-01-01T00:00:00Z"},
+			expected: []string{"REPLICATION", "BYPASSRLS", "CREATEDB", "NOCREATEROLE", "VALID UNTIL '2026-01-01T00:00:00Z'"},
+		},
+		{
+			name:     "clear expiry",
+			attrs:    RoleAttributes{ValidUntil: "infinity"},
+			expected: []string{"VALID UNTIL 'infinity'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.attrs.Options()
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Options() = %v, want %v", got, tt.expected)
+			}
+			if tt.attrs.IsEmpty() != (len(tt.expected) == 0) {
+				t.Errorf("IsEmpty() = %v for %v", tt.attrs.IsEmpty(), got)
+			}
+		})
+	}
+}
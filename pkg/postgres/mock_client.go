@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 )
 
@@ -13,11 +14,21 @@ type MockClient struct {
 	dbOwners   map[string]string          // database -> "namespace/name"
 	extensions map[string][]string        // database -> extensions
 	users      map[string]string          // username -> password
+	userOwners map[string]string          // username -> "namespace/name"
 	userAccess map[string]map[string]bool // username -> database -> hasAccess
+	roleAttrs  map[string]RoleAttributes  // username -> last applied attributes
+	owned      map[string]map[string]bool // username -> database -> owns objects
+	ownerRoles map[string]string          // database -> owner role
+	reassigned map[string]string          // "username/database" -> new owner
 
-	Version    string
-	ShouldFail bool
-	FailError  error
+	Version            string
+	PasswordEncryption string
+	ShouldFail         bool
+	FailError          error
+	// ReassignErrors fails ReassignOwned for the given databases
+	ReassignErrors map[string]error
+	// GrantErrors fails GrantDatabaseAccess for the given databases
+	GrantErrors map[string]error
 }
 
 func NewMockClient() *MockClient {
@@ -26,8 +37,15 @@ func NewMockClient() *MockClient {
 		dbOwners:   make(map[string]string),
 		extensions: make(map[string][]string),
 		users:      make(map[string]string),
+		userOwners: make(map[string]string),
 		userAccess: make(map[string]map[string]bool),
+		roleAttrs:  make(map[string]RoleAttributes),
+		owned:      make(map[string]map[string]bool),
+		ownerRoles: make(map[string]string),
+		reassigned: make(map[string]string),
 		Version:    "PostgreSQL 16.0 (mock)",
+		// PostgreSQL 14+ default
+		PasswordEncryption: "scram-sha-256",
 	}
 }
 
@@ -203,6 +221,13 @@ func (m *MockClient) SetPassword(ctx context.Context, username, password string)
 	return nil
 }
 
+func (m *MockClient) GetPasswordEncryption(ctx context.Context) (string, error) {
+	if m.ShouldFail {
+		return "", m.FailError
+	}
+	return m.PasswordEncryption, nil
+}
+
 func (m *MockClient) SetConnectionLimit(ctx context.Context, username string, limit int) error {
 	if m.ShouldFail {
 		return m.FailError
@@ -210,13 +235,125 @@ func (m *MockClient) SetConnectionLimit(ctx context.Context, username string, li
 	return nil
 }
 
+func (m *MockClient) SetRoleAttributes(ctx context.Context, username string, attrs RoleAttributes) error {
+	if m.ShouldFail {
+		return m.FailError
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current := m.roleAttrs[username]
+	if attrs.Replication != nil {
+		current.Replication = attrs.Replication
+	}
+	if attrs.BypassRLS != nil {
+		current.BypassRLS = attrs.BypassRLS
+	}
+	if attrs.CreateDB != nil {
+		current.CreateDB = attrs.CreateDB
+	}
+	if attrs.CreateRole != nil {
+		current.CreateRole = attrs.CreateRole
+	}
+	if attrs.ValidUntil != "" {
+		current.ValidUntil = attrs.ValidUntil
+	}
+	m.roleAttrs[username] = current
+	return nil
+}
+
+func (m *MockClient) GetUserOwner(ctx context.Context, username string) (namespace, resourceName string, err error) {
+	if m.ShouldFail {
+		return "", "", m.FailError
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	owner := m.userOwners[username]
+	if owner == "" {
+		return "", "", nil
+	}
+	parts := splitOwner(owner)
+	return parts[0], parts[1], nil
+}
+
+func (m *MockClient) SetUserOwner(ctx context.Context, username, ownerNamespace, ownerName string) error {
+	if m.ShouldFail {
+		return m.FailError
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.userOwners[username] = ownerNamespace + "/" + ownerName
+	return nil
+}
+
+func (m *MockClient) ClearUserOwner(ctx context.Context, username string) error {
+	if m.ShouldFail {
+		return m.FailError
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.userOwners, username)
+	return nil
+}
+
 func (m *MockClient) DropUser(ctx context.Context, username string) error {
 	if m.ShouldFail {
 		return m.FailError
 	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if len(m.owned[username]) > 0 {
+		return fmt.Errorf("role %q cannot be dropped because some objects depend on it", username)
+	}
 	delete(m.users, username)
+	delete(m.userOwners, username)
+	return nil
+}
+
+func (m *MockClient) GetRoleDatabases(ctx context.Context, username string) ([]string, error) {
+	if m.ShouldFail {
+		return nil, m.FailError
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var result []string
+	for db := range m.owned[username] {
+		result = append(result, db)
+	}
+	for db := range m.userAccess[username] {
+		if !m.owned[username][db] {
+			result = append(result, db)
+		}
+	}
+	slices.Sort(result)
+	return result, nil
+}
+
+func (m *MockClient) GetDatabaseOwnerRole(ctx context.Context, database string) (string, error) {
+	if m.ShouldFail {
+		return "", m.FailError
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if owner := m.ownerRoles[database]; owner != "" {
+		return owner, nil
+	}
+	return "postgres", nil
+}
+
+func (m *MockClient) ReassignOwned(ctx context.Context, username, database, newOwner string) error {
+	if m.ShouldFail {
+		return m.FailError
+	}
+	if err := m.ReassignErrors[database]; err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.owned[username], database)
+	if m.userAccess[username] != nil {
+		delete(m.userAccess[username], database)
+	}
+	m.reassigned[username+"/"+database] = newOwner
 	return nil
 }
 
@@ -231,6 +368,9 @@ func (m *MockClient) GrantDatabaseAccess(ctx context.Context, username, database
 	if m.ShouldFail {
 		return m.FailError
 	}
+	if err := m.GrantErrors[database]; err != nil {
+		return err
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if m.userAccess[username] == nil {
@@ -318,6 +458,11 @@ func (m *MockClient) RevokePrivilegesInDatabase(ctx context.Context, username, d
 	if m.ShouldFail {
 		return m.FailError
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.userAccess[username] != nil {
+		delete(m.userAccess[username], database)
+	}
 	return nil
 }
 
@@ -345,6 +490,43 @@ func (m *MockClient) GetDatabases() []string {
 	return result
 }
 
+// GetRoleAttributes returns the attributes applied to a user (for test assertions)
+func (m *MockClient) GetRoleAttributes(username string) RoleAttributes {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.roleAttrs[username]
+}
+
+// GetPassword returns the current password of a user (for test assertions)
+func (m *MockClient) GetPassword(username string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.users[username]
+}
+
+// AddOwnedObjects marks the user as owning objects in the database, which blocks DropUser
+func (m *MockClient) AddOwnedObjects(username, database string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owned[username] == nil {
+		m.owned[username] = make(map[string]bool)
+	}
+	m.owned[username][database] = true
+}
+
+func (m *MockClient) SetDatabaseOwnerRole(database, owner string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ownerRoles[database] = owner
+}
+
+// GetReassignedTo returns the role that received the user's objects in the database
+func (m *MockClient) GetReassignedTo(username, database string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.reassigned[username+"/"+database]
+}
+
 func (m *MockClient) GetUsers() []string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
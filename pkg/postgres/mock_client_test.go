@@ -177,6 +177,30 @@ func TestMockClient_ClearDatabaseOwner(t *testing.T) {
 	}
 }
 
+func TestMockClient_UserOwner(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockClient()
+	mock.AddUser("orders_api", "secret")
+
+	if ns, name, _ := mock.GetUserOwner(ctx, "orders_api"); ns != "" || name != "" {
+		t.Errorf("legacy role should have no owner, got %s/%s", ns, name)
+	}
+
+	if err := mock.SetUserOwner(ctx, "orders_api", "team-alpha", "orders-api"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ns, name, _ := mock.GetUserOwner(ctx, "orders_api"); ns != "team-alpha" || name != "orders-api" {
+		t.Errorf("owner: expected team-alpha/orders-api, got %s/%s", ns, name)
+	}
+
+	if err := mock.ClearUserOwner(ctx, "orders_api"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if owner := mock.userOwners["orders_api"]; owner != "" {
+		t.Errorf("owner should be empty after clear, got %q", owner)
+	}
+}
+
 func TestMockClient_ShouldFail(t *testing.T) {
 	ctx := context.Background()
 	mock := NewMockClient()
@@ -2,7 +2,9 @@ package postgres
 
 import (
 	"crypto/rand"
+	"fmt"
 	"math/big"
+	"strings"
 )
 
 const (
@@ -14,6 +16,8 @@ const (
 	upperChars   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	digitChars   = "0123456789"
 	specialChars = "._-,^"
+	urlSafeChars = "-._~"
+	symbolChars  = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
 
 	minLower   = 3
 	minUpper   = 3
@@ -21,7 +25,45 @@ const (
 	minSpecial = 3
 )
 
+// Password charsets, see PasswordPolicy
+const (
+	CharsetSafe         = "safe"
+	CharsetAlphanumeric = "alphanumeric"
+	CharsetURLSafe      = "urlSafe"
+	CharsetSymbols      = "symbols"
+)
+
+// PasswordPolicy controls generated passwords. Letters and digits are always used;
+// Charset selects the special characters and ExcludeChars removes characters from all sets.
+type PasswordPolicy struct {
+	Length       int
+	Charset      string
+	ExcludeChars string
+}
+
+func (p PasswordPolicy) specialChars() (string, error) {
+	switch p.Charset {
+	case "", CharsetSafe:
+		return specialChars, nil
+	case CharsetAlphanumeric:
+		return "", nil
+	case CharsetURLSafe:
+		return urlSafeChars, nil
+	case CharsetSymbols:
+		return symbolChars, nil
+	default:
+		return "", fmt.Errorf("unknown password charset %q", p.Charset)
+	}
+}
+
 func GeneratePassword(length int) (string, error) {
+	return GeneratePasswordWithPolicy(PasswordPolicy{Length: length})
+}
+
+// GeneratePasswordWithPolicy generates a password with at least 3 characters of each
+// class (lowercase, uppercase, digits, special) that is not empty after exclusions
+func GeneratePasswordWithPolicy(policy PasswordPolicy) (string, error) {
+	length := policy.Length
 	switch {
 	case length == 0:
 		length = DefaultPasswordLength
@@ -31,25 +73,39 @@ func GeneratePassword(length int) (string, error) {
 		length = MaxPasswordLength
 	}
 
+	special, err := policy.specialChars()
+	if err != nil {
+		return "", err
+	}
+	classes := []struct {
+		chars string
+		min   int
+	}{
+		{excludeChars(lowerChars, policy.ExcludeChars), minLower},
+		{excludeChars(upperChars, policy.ExcludeChars), minUpper},
+		{excludeChars(digitChars, policy.ExcludeChars), minDigit},
+		{excludeChars(special, policy.ExcludeChars), minSpecial},
+	}
+
 	result := make([]byte, length)
 	pos := 0
 
 	// Guarantee minimum of each character type
-	if err := fillFromCharset(result, &pos, lowerChars, minLower); err != nil {
-		return "", err
-	}
-	if err := fillFromCharset(result, &pos, upperChars, minUpper); err != nil {
-		return "", err
-	}
-	if err := fillFromCharset(result, &pos, digitChars, minDigit); err != nil {
-		return "", err
+	allChars := ""
+	for _, class := range classes {
+		if class.chars == "" {
+			continue
+		}
+		if err := fillFromCharset(result, &pos, class.chars, class.min); err != nil {
+			return "", err
+		}
+		allChars += class.chars
 	}
-	if err := fillFromCharset(result, &pos, specialChars, minSpecial); err != nil {
-		return "", err
+	if allChars == "" {
+		return "", fmt.Errorf("no characters left after excluding %q", policy.ExcludeChars)
 	}
 
 	// Fill remaining positions with random characters from all charsets
-	allChars := lowerChars + upperChars + digitChars + specialChars
 	if err := fillFromCharset(result, &pos, allChars, length-pos); err != nil {
 		return "", err
 	}
@@ -62,6 +118,18 @@ func GeneratePassword(length int) (string, error) {
 	return string(result), nil
 }
 
+func excludeChars(charset, exclude string) string {
+	if exclude == "" {
+		return charset
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(exclude, r) {
+			return -1
+		}
+		return r
+	}, charset)
+}
+
 func fillFromCharset(result []byte, pos *int, charset string, count int) error {
 	charsetLen := big.NewInt(int64(len(charset)))
 	for i := 0; i < count && *pos < len(result); i++ {
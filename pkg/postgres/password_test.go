@@ -164,3 +164,78 @@ func TestGeneratePassword_Shuffled(t *testing.T) {
 		t.Error("passwords not shuffled - all start with lowercase")
 	}
 }
+
+func TestGeneratePasswordWithPolicy_Charsets(t *testing.T) {
+	tests := []struct {
+		charset string
+		special string
+	}{
+		{"", specialChars},
+		{CharsetSafe, specialChars},
+		{CharsetAlphanumeric, ""},
+		{CharsetURLSafe, urlSafeChars},
+		{CharsetSymbols, symbolChars},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.charset, func(t *testing.T) {
+			allowed := lowerChars + upperChars + digitChars + tt.special
+			for i := 0; i < 50; i++ {
+				password, err := GeneratePasswordWithPolicy(PasswordPolicy{Length: 24, Charset: tt.charset})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(password) != 24 {
+					t.Errorf("expected length 24, got %d", len(password))
+				}
+				specialCount := 0
+				for _, r := range password {
+					if !strings.ContainsRune(allowed, r) {
+						t.Fatalf("password contains disallowed character %q: %s", r, password)
+					}
+					if strings.ContainsRune(tt.special, r) {
+						specialCount++
+					}
+				}
+				if tt.special != "" && specialCount < minSpecial {
+					t.Errorf("password has %d special chars, expected at least %d: %s", specialCount, minSpecial, password)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePasswordWithPolicy_ExcludeChars(t *testing.T) {
+	exclude := "lIO01,^"
+	for i := 0; i < 100; i++ {
+		password, err := GeneratePasswordWithPolicy(PasswordPolicy{Length: 32, ExcludeChars: exclude})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.ContainsAny(password, exclude) {
+			t.Fatalf("password contains excluded character: %s", password)
+		}
+	}
+}
+
+func TestGeneratePasswordWithPolicy_ExcludedClassIsSkipped(t *testing.T) {
+	password, err := GeneratePasswordWithPolicy(PasswordPolicy{Length: 16, Charset: CharsetURLSafe, ExcludeChars: urlSafeChars + digitChars})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, r := range password {
+		if !unicode.IsLetter(r) {
+			t.Fatalf("expected letters only, got %q in %s", r, password)
+		}
+	}
+}
+
+func TestGeneratePasswordWithPolicy_Errors(t *testing.T) {
+	if _, err := GeneratePasswordWithPolicy(PasswordPolicy{Charset: "emoji"}); err == nil {
+		t.Error("expected error for unknown charset")
+	}
+	all := lowerChars + upperChars + digitChars
+	if _, err := GeneratePasswordWithPolicy(PasswordPolicy{Charset: CharsetAlphanumeric, ExcludeChars: all}); err == nil {
+		t.Error("expected error when every character is excluded")
+	}
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+const (
+	// scramIterations matches PostgreSQL's default scram_iterations
+	scramIterations = 4096
+	scramSaltLength = 16
+)
+
+// ScramSHA256Verifier computes the SCRAM-SHA-256 verifier PostgreSQL stores in pg_authid,
+// so CREATE/ALTER ROLE can be sent without the plaintext password.
+// PostgreSQL normalizes passwords with SASLprep, which leaves printable ASCII unchanged;
+// callers should send other passwords in plaintext (see canPrecomputeVerifier).
+func ScramSHA256Verifier(password string) (string, error) {
+	salt := make([]byte, scramSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return scramSHA256Verifier(password, salt, scramIterations)
+}
+
+func scramSHA256Verifier(password string, salt []byte, iterations int) (string, error) {
+	saltedPassword, err := pbkdf2.Key(sha256.New, password, salt, iterations, sha256.Size)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	clientKey := hmacSHA256(saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	serverKey := hmacSHA256(saltedPassword, "Server Key")
+
+	enc := base64.StdEncoding
+	return fmt.Sprintf("SCRAM-SHA-256$%d:%s$%s:%s", iterations,
+		enc.EncodeToString(salt), enc.EncodeToString(storedKey[:]), enc.EncodeToString(serverKey)), nil
+}
+
+func hmacSHA256(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// canPrecomputeVerifier reports whether the password is printable ASCII, for which
+// SASLprep is a no-op and a verifier computed here matches the server's
+func canPrecomputeVerifier(password string) bool {
+	for i := 0; i < len(password); i++ {
+		if password[i] < 0x20 || password[i] > 0x7e {
+			return false
+		}
+	}
+	return password != ""
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScramSHA256Verifier_KnownValue(t *testing.T) {
+	salt := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	got, err := scramSHA256Verifier("pencil", salt, 4096)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Computed independently with Python's hashlib/hmac
+	want := "SCRAM-SHA-256$4096:AAECAwQFBgcICQoLDA0ODw==$zHCdol2044/ZyWzPLi7oxApCkamKw9Z+E4U/QApd/5Y=:dd5peBOitVnLNFu7VmwP+HiDaaw4OUCv396eVCWhYiE="
+	if got != want {
+		t.Errorf("verifier = %s, want %s", got, want)
+	}
+}
+
+func TestScramSHA256Verifier_RandomSalt(t *testing.T) {
+	first, err := ScramSHA256Verifier("secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := ScramSHA256Verifier("secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first == second {
+		t.Error("expected different salts for each verifier")
+	}
+	if !strings.HasPrefix(first, "SCRAM-SHA-256$4096:") || strings.Contains(first, "secret") {
+		t.Errorf("unexpected verifier format: %s", first)
+	}
+}
+
+func TestCanPrecomputeVerifier(t *testing.T) {
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"Abc123._-,^", true},
+		{"!#$%&()*+:;<=>?@[]{|}~ ", true},
+		{"pässword", false},
+		{"tab\there", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := canPrecomputeVerifier(tt.password); got != tt.want {
+			t.Errorf("canPrecomputeVerifier(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestClient_PasswordLiteral(t *testing.T) {
+	c := &Client{}
+	literal, err := c.passwordLiteral("Abc123._-,^xyz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(literal, "'SCRAM-SHA-256$") || strings.Contains(literal, "Abc123") {
+		t.Errorf("expected a SCRAM verifier literal, got %s", literal)
+	}
+
+	// Non-ASCII passwords need SASLprep on the server
+	if literal, _ := c.passwordLiteral("pässword"); literal != "'pässword'" {
+		t.Errorf("expected plaintext literal for non-ASCII password, got %s", literal)
+	}
+
+	c = &Client{config: Config{ServerSidePasswordEncryption: true}}
+	if literal, _ := c.passwordLiteral("it's"); literal != "'it''s'" {
+		t.Errorf("expected quoted plaintext with server-side encryption, got %s", literal)
+	}
+}
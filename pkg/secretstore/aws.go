@@ -0,0 +1,148 @@
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// DefaultRecoveryWindowDays is the recovery window of deleted secrets when none is configured
+const DefaultRecoveryWindowDays = 30
+
+// AWSConfig configures the AWS Secrets Manager store. The operator authenticates with its own
+// identity (IRSA/Pod Identity or AWS_* environment variables).
+type AWSConfig struct {
+	Region   string `json:"region"`
+	Endpoint string `json:"endpoint,omitempty"` // optional, for custom endpoints (e.g., LocalStack)
+	KMSKeyID string `json:"kmsKeyId,omitempty"` // optional, used when the secret is created
+
+	// NamePrefix is prepended to the secret names chosen in DatabaseUsers (default DefaultPrefix)
+	NamePrefix string `json:"namePrefix,omitempty"`
+
+	// RecoveryWindowDays is applied on delete (7-30, default DefaultRecoveryWindowDays)
+	RecoveryWindowDays int `json:"-"`
+}
+
+// AWSStore writes secrets to AWS Secrets Manager as a JSON SecretString
+type AWSStore struct {
+	client             *secretsmanager.Client
+	kmsKeyID           string
+	recoveryWindowDays int
+}
+
+func NewAWSStore(ctx context.Context, cfg *AWSConfig) (*AWSStore, error) {
+	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(cfg.Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	var clientOpts []func(*secretsmanager.Options)
+	if cfg.Endpoint != "" {
+		clientOpts = append(clientOpts, func(o *secretsmanager.Options) {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		})
+	}
+
+	recoveryWindowDays := cfg.RecoveryWindowDays
+	if recoveryWindowDays == 0 {
+		recoveryWindowDays = DefaultRecoveryWindowDays
+	}
+
+	return &AWSStore{
+		client:             secretsmanager.NewFromConfig(awsCfg, clientOpts...),
+		kmsKeyID:           cfg.KMSKeyID,
+		recoveryWindowDays: recoveryWindowDays,
+	}, nil
+}
+
+// Put creates the secret, or updates it if it carries the dbtether tag. A secret scheduled for
+// deletion is restored first.
+func (a *AWSStore) Put(ctx context.Context, name string, data map[string]string) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode secret: %w", err)
+	}
+
+	secret, err := a.describe(ctx, name)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		return a.create(ctx, name, string(payload))
+	}
+	if secret.DeletedDate != nil {
+		if _, err := a.client.RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{SecretId: aws.String(name)}); err != nil {
+			return fmt.Errorf("failed to restore secret %s: %w", name, err)
+		}
+	}
+
+	if _, err := a.client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(name),
+		SecretString: aws.String(string(payload)),
+	}); err != nil {
+		return fmt.Errorf("failed to put secret value %s: %w", name, err)
+	}
+	return nil
+}
+
+func (a *AWSStore) create(ctx context.Context, name, payload string) error {
+	input := &secretsmanager.CreateSecretInput{
+		Name:         aws.String(name),
+		SecretString: aws.String(payload),
+		Description:  aws.String("Database credentials managed by dbtether"),
+		Tags: []types.Tag{
+			{Key: aws.String(ManagedTagKey), Value: aws.String(ManagedTagValue)},
+		},
+	}
+	if a.kmsKeyID != "" {
+		input.KmsKeyId = aws.String(a.kmsKeyID)
+	}
+	if _, err := a.client.CreateSecret(ctx, input); err != nil {
+		return fmt.Errorf("failed to create secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// Delete schedules a secret created by dbtether for deletion after the recovery window
+func (a *AWSStore) Delete(ctx context.Context, name string) error {
+	secret, err := a.describe(ctx, name)
+	if err != nil || secret == nil || secret.DeletedDate != nil {
+		return err
+	}
+
+	if _, err := a.client.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId:             aws.String(name),
+		RecoveryWindowInDays: aws.Int64(int64(a.recoveryWindowDays)),
+	}); err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// describe returns the secret, nil if it does not exist, or ErrNotManaged if it has no dbtether tag
+func (a *AWSStore) describe(ctx context.Context, name string) (*secretsmanager.DescribeSecretOutput, error) {
+	secret, err := a.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: aws.String(name)})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to describe secret %s: %w", name, err)
+	}
+	for _, tag := range secret.Tags {
+		if aws.ToString(tag.Key) == ManagedTagKey && aws.ToString(tag.Value) == ManagedTagValue {
+			return secret, nil
+		}
+	}
+	return nil, fmt.Errorf("aws secret %s: %w", name, ErrNotManaged)
+}
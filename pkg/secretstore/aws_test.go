@@ -0,0 +1,275 @@
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSecret is a secret in fakeSecretsManager
+type fakeSecret struct {
+	value   string
+	tags    map[string]string
+	deleted bool
+}
+
+// fakeSecretsManager is a minimal in-memory AWS Secrets Manager (JSON 1.1 protocol)
+type fakeSecretsManager struct {
+	mu       sync.Mutex
+	secrets  map[string]*fakeSecret
+	deletes  []map[string]any
+	restores int
+}
+
+func newFakeSecretsManager() *fakeSecretsManager {
+	return &fakeSecretsManager{secrets: make(map[string]*fakeSecret)}
+}
+
+func (f *fakeSecretsManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+	fail := func(errType, message string) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"__type":"` + errType + `","message":"` + message + `"}`))
+	}
+	id, _ := body["SecretId"].(string)
+	secret := f.secrets[id]
+
+	target := r.Header.Get("X-Amz-Target")
+	switch {
+	case strings.HasSuffix(target, ".CreateSecret"):
+		name, _ := body["Name"].(string)
+		created := &fakeSecret{tags: map[string]string{}}
+		created.value, _ = body["SecretString"].(string)
+		tags, _ := body["Tags"].([]any)
+		for _, tag := range tags {
+			kv, _ := tag.(map[string]any)
+			key, _ := kv["Key"].(string)
+			created.tags[key], _ = kv["Value"].(string)
+		}
+		f.secrets[name] = created
+		_ = json.NewEncoder(w).Encode(map[string]any{"Name": name})
+		return
+	case secret == nil:
+		fail("ResourceNotFoundException", "Secrets Manager can't find the specified secret.")
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(target, ".DescribeSecret"):
+		var tags []map[string]string
+		for k, v := range secret.tags {
+			tags = append(tags, map[string]string{"Key": k, "Value": v})
+		}
+		resp := map[string]any{"Name": id, "Tags": tags}
+		if secret.deleted {
+			resp["DeletedDate"] = 1767225600
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	case strings.HasSuffix(target, ".PutSecretValue"):
+		if secret.deleted {
+			fail("InvalidRequestException", "secret is marked for deletion")
+			return
+		}
+		secret.value, _ = body["SecretString"].(string)
+		_ = json.NewEncoder(w).Encode(map[string]any{"Name": id})
+	case strings.HasSuffix(target, ".RestoreSecret"):
+		secret.deleted = false
+		f.restores++
+		_ = json.NewEncoder(w).Encode(map[string]any{"Name": id})
+	case strings.HasSuffix(target, ".DeleteSecret"):
+		secret.deleted = true
+		f.deletes = append(f.deletes, body)
+		_ = json.NewEncoder(w).Encode(map[string]any{"Name": id})
+	default:
+		fail("InvalidRequestException", "unsupported")
+	}
+}
+
+func newTestAWSStore(t *testing.T, url string, recoveryDays int) *AWSStore {
+	t.Helper()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	store, err := NewAWSStore(context.Background(), &AWSConfig{
+		Region:             "eu-west-1",
+		Endpoint:           url,
+		RecoveryWindowDays: recoveryDays,
+	})
+	if err != nil {
+		t.Fatalf("NewAWSStore() error = %v", err)
+	}
+	return store
+}
+
+func TestAWSStore_PutCreatesThenUpdates(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeSecretsManager()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store := newTestAWSStore(t, server.URL, 0)
+
+	if err := store.Put(ctx, "/myapp/db", map[string]string{"password": "v1"}); err != nil {
+		t.Fatalf("Put() create error = %v", err)
+	}
+	if got := fake.secrets["/myapp/db"].tags[ManagedTagKey]; got != ManagedTagValue {
+		t.Errorf("created secret tag %s = %q, want %q", ManagedTagKey, got, ManagedTagValue)
+	}
+	if err := store.Put(ctx, "/myapp/db", map[string]string{"password": "v2"}); err != nil {
+		t.Fatalf("Put() update error = %v", err)
+	}
+
+	var stored map[string]string
+	if err := json.Unmarshal([]byte(fake.secrets["/myapp/db"].value), &stored); err != nil {
+		t.Fatalf("stored secret is not JSON: %v", err)
+	}
+	if stored["password"] != "v2" {
+		t.Errorf("stored password = %q, want v2", stored["password"])
+	}
+}
+
+func TestAWSStore_PutRestoresDeletedSecret(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeSecretsManager()
+	fake.secrets["/myapp/db"] = &fakeSecret{value: "{}", tags: map[string]string{ManagedTagKey: ManagedTagValue}, deleted: true}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store := newTestAWSStore(t, server.URL, 0)
+	if err := store.Put(ctx, "/myapp/db", map[string]string{"password": "v1"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if fake.restores != 1 || fake.secrets["/myapp/db"].deleted {
+		t.Errorf("secret scheduled for deletion was not restored (restores = %d)", fake.restores)
+	}
+}
+
+func TestAWSStore_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		recoveryDays int
+		wantDays     float64
+	}{
+		{"default recovery window", 0, DefaultRecoveryWindowDays},
+		{"configured recovery window", 7, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeSecretsManager()
+			fake.secrets["/myapp/db"] = &fakeSecret{value: "{}", tags: map[string]string{ManagedTagKey: ManagedTagValue}}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			store := newTestAWSStore(t, server.URL, tt.recoveryDays)
+			if err := store.Delete(ctx, "/myapp/db"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if len(fake.deletes) != 1 {
+				t.Fatalf("expected 1 delete call, got %d", len(fake.deletes))
+			}
+			if force, _ := fake.deletes[0]["ForceDeleteWithoutRecovery"].(bool); force {
+				t.Error("secret deleted without recovery window")
+			}
+			if days, _ := fake.deletes[0]["RecoveryWindowInDays"].(float64); days != tt.wantDays {
+				t.Errorf("RecoveryWindowInDays = %v, want %v", days, tt.wantDays)
+			}
+
+			// Already scheduled and missing secrets are not errors
+			if err := store.Delete(ctx, "/myapp/db"); err != nil || len(fake.deletes) != 1 {
+				t.Errorf("Delete() of deleted secret error = %v, deletes = %d", err, len(fake.deletes))
+			}
+			delete(fake.secrets, "/myapp/db")
+			if err := store.Delete(ctx, "/myapp/db"); err != nil {
+				t.Errorf("Delete() of missing secret error = %v", err)
+			}
+		})
+	}
+}
+
+func TestAWSStore_ForeignSecret(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeSecretsManager()
+	fake.secrets["/prod/payments"] = &fakeSecret{value: `{"key":"original"}`, tags: map[string]string{"team": "payments"}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store := newTestAWSStore(t, server.URL, 0)
+	if err := store.Put(ctx, "/prod/payments", map[string]string{"password": "x"}); !errors.Is(err, ErrNotManaged) {
+		t.Errorf("Put() error = %v, want ErrNotManaged", err)
+	}
+	if err := store.Delete(ctx, "/prod/payments"); !errors.Is(err, ErrNotManaged) {
+		t.Errorf("Delete() error = %v, want ErrNotManaged", err)
+	}
+	if secret := fake.secrets["/prod/payments"]; secret.value != `{"key":"original"}` || secret.deleted {
+		t.Errorf("foreign secret was changed: %+v", secret)
+	}
+}
+
+func TestScopedName(t *testing.T) {
+	tests := []struct {
+		name, prefix, namespace, path string
+		want                          string
+		wantErr                       bool
+	}{
+		{name: "default prefix", namespace: "team-a", path: "orders/db", want: "dbtether/team-a/orders/db"},
+		{name: "rooted prefix", prefix: "/apps/", namespace: "team-a", path: "/orders", want: "/apps/team-a/orders"},
+		{name: "parent segment", prefix: "apps", namespace: "team-a", path: "../team-b/orders", wantErr: true},
+		{name: "empty segment", prefix: "apps", namespace: "team-a", path: "orders//db", wantErr: true},
+		{name: "empty", prefix: "apps", namespace: "team-a", path: "/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ScopedName(tt.prefix, tt.namespace, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ScopedName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ScopedName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantNil bool
+		wantErr bool
+	}{
+		{"empty type defaults to kubernetes", &Config{}, true, false},
+		{"kubernetes", &Config{Type: TypeKubernetes}, true, false},
+		{"vault without config", &Config{Type: TypeVault}, false, true},
+		{"aws without config", &Config{Type: TypeAWSSecretsManager}, false, true},
+		{"vault", &Config{Type: TypeVault, Vault: &VaultConfig{Address: "http://vault:8200", Token: "t"}}, false, false},
+		{"unknown type", &Config{Type: "gcp"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewStore(ctx, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (store == nil) != (tt.wantNil || tt.wantErr) {
+				t.Errorf("NewStore() store = %v, wantNil %v", store, tt.wantNil)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package secretstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	TypeKubernetes        = "kubernetes"
+	TypeVault             = "vault"
+	TypeAWSSecretsManager = "aws-secretsmanager"
+	TypeExternalSecrets   = "external-secrets"
+)
+
+// Secrets created by a Store carry this tag (AWS) or custom metadata entry (Vault KV v2);
+// secrets without it are never updated or deleted
+const (
+	ManagedTagKey   = "created-by"
+	ManagedTagValue = "dbtether"
+)
+
+// DefaultPrefix is the path prefix of secrets when the operator configuration sets none
+const DefaultPrefix = "dbtether"
+
+// ErrNotManaged is returned for an existing secret that was not created by dbtether
+var ErrNotManaged = errors.New("secret exists and was not created by dbtether")
+
+// Store is the interface for external secret stores that receive a copy of the credentials
+type Store interface {
+	// Put creates or replaces the secret at name with the given key/value data
+	Put(ctx context.Context, name string, data map[string]string) error
+
+	// Delete removes the secret at name. Deleting a missing secret is not an error.
+	Delete(ctx context.Context, name string) error
+}
+
+// Settings is the operator's store configuration, owned by the cluster admin (Helm value
+// secretStores). DatabaseUsers only choose a path below the prefix.
+type Settings struct {
+	Vault *VaultConfig `json:"vault,omitempty"`
+	AWS   *AWSConfig   `json:"aws,omitempty"`
+}
+
+// ScopedName places a user-chosen name below the admin prefix and the user's namespace, so tenants
+// cannot reach secrets of other namespaces or outside the prefix
+func ScopedName(prefix, namespace, name string) (string, error) {
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return "", fmt.Errorf("secret name is empty")
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("secret name %q must not contain empty, '.' or '..' segments", name)
+		}
+	}
+	rooted := strings.HasPrefix(prefix, "/")
+	if prefix = strings.Trim(prefix, "/"); prefix == "" {
+		prefix = DefaultPrefix
+	}
+	scoped := path.Join(prefix, namespace, name)
+	if rooted {
+		scoped = "/" + scoped
+	}
+	return scoped, nil
+}
+
+// Config selects and configures a Store implementation
+type Config struct {
+	Type  string
+	Vault *VaultConfig
+	AWS   *AWSConfig
+}
+
+// Factory creates a Store from Config (replaceable in tests)
+type Factory func(ctx context.Context, cfg *Config) (Store, error)
+
+// NewStore creates the Store for cfg.Type. Returns nil for the kubernetes type,
+// since the Kubernetes Secret is written by the controller itself, and for
+// external-secrets, where ESO pushes the Secret.
+func NewStore(ctx context.Context, cfg *Config) (Store, error) {
+	switch cfg.Type {
+	case "", TypeKubernetes, TypeExternalSecrets:
+		return nil, nil
+	case TypeVault:
+		if cfg.Vault == nil {
+			return nil, fmt.Errorf("vault configuration is required for type %s", TypeVault)
+		}
+		return NewVaultStore(ctx, cfg.Vault)
+	case TypeAWSSecretsManager:
+		if cfg.AWS == nil {
+			return nil, fmt.Errorf("aws configuration is required for type %s", TypeAWSSecretsManager)
+		}
+		return NewAWSStore(ctx, cfg.AWS)
+	default:
+		return nil, fmt.Errorf("unsupported secret store type: %s", cfg.Type)
+	}
+}
+
+// Verify implementations satisfy the interface
+var (
+	_ Store = (*VaultStore)(nil)
+	_ Store = (*AWSStore)(nil)
+	_ Store = (*MockStore)(nil)
+)
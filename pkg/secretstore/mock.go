@@ -0,0 +1,82 @@
+package secretstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MockStore is an in-memory secret store for testing
+type MockStore struct {
+	mu      sync.RWMutex
+	secrets map[string]map[string]string
+	foreign map[string]bool
+
+	// Error injection for testing error handling
+	PutError    error
+	DeleteError error
+
+	// PutCount counts successful Put calls
+	PutCount int
+}
+
+// NewMockStore creates a new in-memory mock secret store
+func NewMockStore() *MockStore {
+	return &MockStore{
+		secrets: make(map[string]map[string]string),
+		foreign: make(map[string]bool),
+	}
+}
+
+func (m *MockStore) Put(ctx context.Context, name string, data map[string]string) error {
+	if m.PutError != nil {
+		return m.PutError
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.foreign[name] {
+		return fmt.Errorf("mock %s: %w", name, ErrNotManaged)
+	}
+	copied := make(map[string]string, len(data))
+	for k, v := range data {
+		copied[k] = v
+	}
+	m.secrets[name] = copied
+	m.PutCount++
+	return nil
+}
+
+func (m *MockStore) Delete(ctx context.Context, name string) error {
+	if m.DeleteError != nil {
+		return m.DeleteError
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.foreign[name] {
+		return fmt.Errorf("mock %s: %w", name, ErrNotManaged)
+	}
+	delete(m.secrets, name)
+	return nil
+}
+
+// PutForeign stores a secret that was not created by dbtether (test helper)
+func (m *MockStore) PutForeign(name string, data map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.secrets[name] = data
+	m.foreign[name] = true
+}
+
+// Get returns the stored data (test helper)
+func (m *MockStore) Get(name string) (map[string]string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.secrets[name]
+	return data, ok
+}
@@ -0,0 +1,264 @@
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultServiceAccountTokenPath is where Kubernetes mounts the pod's service account token
+const DefaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token" //nolint:gosec // path, not a credential
+
+// VaultConfig configures the HashiCorp Vault KV v2 store. Only KV v2 is supported: custom metadata
+// marks the secrets written by dbtether.
+type VaultConfig struct {
+	Address   string `json:"address"`
+	Mount     string `json:"mount,omitempty"`     // KV engine mount, default "secret"
+	Namespace string `json:"namespace,omitempty"` // Vault Enterprise namespace, optional
+
+	// PathPrefix is prepended to the paths chosen in DatabaseUsers (default DefaultPrefix)
+	PathPrefix string `json:"pathPrefix,omitempty"`
+
+	// Token auth (takes precedence over Kubernetes auth), read from the operator's environment
+	Token string `json:"-"`
+
+	// Kubernetes auth
+	KubernetesRole          string `json:"kubernetesAuthRole,omitempty"`
+	KubernetesMountPath     string `json:"kubernetesAuthMount,omitempty"` // default "kubernetes"
+	ServiceAccountTokenPath string `json:"-"`                             // default DefaultServiceAccountTokenPath
+
+	// HTTPClient is optional, defaults to a client with a 30s timeout
+	HTTPClient *http.Client `json:"-"`
+}
+
+// VaultStore writes secrets to a Vault KV v2 engine over the HTTP API
+type VaultStore struct {
+	cfg    VaultConfig
+	client *http.Client
+
+	mu    sync.Mutex
+	token string
+	// tokenExpiry is when a Kubernetes auth token is replaced by a new login; zero for static tokens
+	// and tokens without a lease
+	tokenExpiry time.Time
+}
+
+func NewVaultStore(ctx context.Context, cfg *VaultConfig) (*VaultStore, error) {
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("vault address is required")
+	}
+	if cfg.Token == "" && cfg.KubernetesRole == "" {
+		return nil, fmt.Errorf("vault token or kubernetes auth role is required")
+	}
+
+	c := *cfg
+	c.Address = strings.TrimSuffix(c.Address, "/")
+	if c.Mount == "" {
+		c.Mount = "secret"
+	}
+	c.Mount = strings.Trim(c.Mount, "/")
+	if c.KubernetesMountPath == "" {
+		c.KubernetesMountPath = "kubernetes"
+	}
+	if c.ServiceAccountTokenPath == "" {
+		c.ServiceAccountTokenPath = DefaultServiceAccountTokenPath
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &VaultStore{
+		cfg:    c,
+		client: httpClient,
+		token:  c.Token,
+	}, nil
+}
+
+// Put writes the secret, after checking that an existing secret at name was created by dbtether.
+// The custom metadata is written first, so an interrupted write never leaves an unmarked secret.
+func (v *VaultStore) Put(ctx context.Context, name string, data map[string]string) error {
+	if _, err := v.checkManaged(ctx, name); err != nil {
+		return err
+	}
+	metadata := map[string]any{"custom_metadata": map[string]string{ManagedTagKey: ManagedTagValue}}
+	if _, _, err := v.do(ctx, http.MethodPost, v.metadataPath(name), metadata); err != nil {
+		return err
+	}
+	_, _, err := v.do(ctx, http.MethodPost, v.dataPath(name), map[string]any{"data": data})
+	return err
+}
+
+// Delete removes the metadata and all versions of a secret created by dbtether
+func (v *VaultStore) Delete(ctx context.Context, name string) error {
+	exists, err := v.checkManaged(ctx, name)
+	if err != nil || !exists {
+		return err
+	}
+	status, _, err := v.do(ctx, http.MethodDelete, v.metadataPath(name), nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// checkManaged reports whether a secret exists at name, and fails with ErrNotManaged if it
+// exists without the dbtether custom metadata
+func (v *VaultStore) checkManaged(ctx context.Context, name string) (bool, error) {
+	status, body, err := v.do(ctx, http.MethodGet, v.metadataPath(name), nil)
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var resp struct {
+		Data struct {
+			CustomMetadata map[string]string `json:"custom_metadata"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return false, fmt.Errorf("failed to decode vault metadata of %s: %w", name, err)
+	}
+	if resp.Data.CustomMetadata[ManagedTagKey] != ManagedTagValue {
+		return true, fmt.Errorf("vault %s/%s: %w", v.cfg.Mount, strings.Trim(name, "/"), ErrNotManaged)
+	}
+	return true, nil
+}
+
+// dataPath returns the API path for secret versions
+func (v *VaultStore) dataPath(name string) string {
+	return fmt.Sprintf("/v1/%s/data/%s", v.cfg.Mount, strings.Trim(name, "/"))
+}
+
+// metadataPath returns the API path for the metadata; deleting it removes all versions
+func (v *VaultStore) metadataPath(name string) string {
+	return fmt.Sprintf("/v1/%s/metadata/%s", v.cfg.Mount, strings.Trim(name, "/"))
+}
+
+func (v *VaultStore) do(ctx context.Context, method, path string, body any) (int, []byte, error) {
+	token, err := v.getToken(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status, respBody, err := v.request(ctx, method, path, token, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	// Kubernetes auth tokens expire - log in again once
+	if status == http.StatusForbidden && v.cfg.Token == "" {
+		v.mu.Lock()
+		v.token = ""
+		v.mu.Unlock()
+		if token, err = v.getToken(ctx); err != nil {
+			return 0, nil, err
+		}
+		if status, respBody, err = v.request(ctx, method, path, token, body); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if status >= 300 {
+		return status, respBody, fmt.Errorf("vault %s %s failed with status %d: %s", method, path, status, vaultErrors(respBody))
+	}
+	return status, respBody, nil
+}
+
+func (v *VaultStore) request(ctx context.Context, method, path, token string, body any) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to encode vault request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, v.cfg.Address+path, reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, respBody, nil
+}
+
+// getToken returns the static token or logs in with the Kubernetes auth method. The login token is
+// reused until its lease is nearly over, so a long-lived store logs in once per lease.
+func (v *VaultStore) getToken(ctx context.Context) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.token != "" && (v.tokenExpiry.IsZero() || time.Now().Before(v.tokenExpiry)) {
+		return v.token, nil
+	}
+
+	jwt, err := os.ReadFile(v.cfg.ServiceAccountTokenPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	path := fmt.Sprintf("/v1/auth/%s/login", strings.Trim(v.cfg.KubernetesMountPath, "/"))
+	status, respBody, err := v.request(ctx, http.MethodPost, path, "", map[string]string{
+		"role": v.cfg.KubernetesRole,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+	if err != nil {
+		return "", err
+	}
+	if status >= 300 {
+		return "", fmt.Errorf("vault kubernetes login failed with status %d: %s", status, vaultErrors(respBody))
+	}
+
+	var login struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int64  `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := json.Unmarshal(respBody, &login); err != nil || login.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault kubernetes login returned no token")
+	}
+
+	// Log in again shortly before the lease runs out instead of waiting for a denied request
+	v.token = login.Auth.ClientToken
+	v.tokenExpiry = time.Time{}
+	if lease := time.Duration(login.Auth.LeaseDuration) * time.Second; lease > 0 {
+		v.tokenExpiry = time.Now().Add(lease * 9 / 10)
+	}
+	return v.token, nil
+}
+
+// vaultErrors extracts the "errors" list from a Vault error response
+func vaultErrors(body []byte) string {
+	var resp struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && len(resp.Errors) > 0 {
+		return strings.Join(resp.Errors, "; ")
+	}
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,255 @@
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeVault is a minimal in-memory Vault KV v2 server
+type fakeVault struct {
+	mu       sync.Mutex
+	secrets  map[string]map[string]any // data path -> request body
+	metadata map[string]map[string]any // metadata path -> custom_metadata
+	token    string
+	lease    int // lease_duration of login tokens in seconds
+	logins   int
+	requests []string
+}
+
+func newFakeVault(token string) *fakeVault {
+	return &fakeVault{secrets: make(map[string]map[string]any), metadata: make(map[string]map[string]any), token: token}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	if r.URL.Path == "/v1/auth/kubernetes/login" {
+		var login map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&login)
+		if login["role"] != "dbtether" || login["jwt"] != "sa-jwt" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["invalid role or jwt"]}`))
+			return
+		}
+		f.logins++
+		_ = json.NewEncoder(w).Encode(map[string]any{"auth": map[string]any{"client_token": f.token, "lease_duration": f.lease}})
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") != f.token {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	isMetadata := strings.Contains(r.URL.Path, "/metadata/")
+	switch {
+	case r.Method == http.MethodGet && isMetadata:
+		custom, ok := f.metadata[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"custom_metadata": custom}})
+	case r.Method == http.MethodPost && isMetadata:
+		var body map[string]map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.metadata[r.URL.Path] = body["custom_metadata"]
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost:
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.secrets[r.URL.Path] = body
+		if metadataPath := strings.Replace(r.URL.Path, "/data/", "/metadata/", 1); f.metadata[metadataPath] == nil {
+			f.metadata[metadataPath] = map[string]any{}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && isMetadata:
+		if _, ok := f.metadata[r.URL.Path]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.metadata, r.URL.Path)
+		delete(f.secrets, strings.Replace(r.URL.Path, "/metadata/", "/data/", 1))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestVaultStore_PutAndDelete(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeVault("root-token")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := NewVaultStore(ctx, &VaultConfig{Address: server.URL + "/", Token: "root-token"})
+	if err != nil {
+		t.Fatalf("NewVaultStore() error = %v", err)
+	}
+
+	if err := store.Put(ctx, "/apps/orders/db", map[string]string{"user": "orders", "password": "secret"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	stored, ok := fake.secrets["/v1/secret/data/apps/orders/db"]
+	if !ok {
+		t.Fatalf("secret not written to KV v2 data path, requests: %v", fake.requests)
+	}
+	data, _ := stored["data"].(map[string]any)
+	if data["password"] != "secret" {
+		t.Errorf("stored password = %v, want secret", data["password"])
+	}
+	if got := fake.metadata["/v1/secret/metadata/apps/orders/db"][ManagedTagKey]; got != ManagedTagValue {
+		t.Errorf("custom metadata %s = %v, want %s", ManagedTagKey, got, ManagedTagValue)
+	}
+
+	// Updating a secret created by dbtether
+	if err := store.Put(ctx, "apps/orders/db", map[string]string{"password": "rotated"}); err != nil {
+		t.Fatalf("Put() update error = %v", err)
+	}
+
+	// Delete goes to the metadata path and removes all versions
+	if err := store.Delete(ctx, "apps/orders/db"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, ok := fake.metadata["/v1/secret/metadata/apps/orders/db"]; ok {
+		t.Error("metadata was not deleted")
+	}
+
+	// Deleting a missing secret is not an error
+	if err := store.Delete(ctx, "apps/orders/db"); err != nil {
+		t.Errorf("Delete() of missing secret error = %v", err)
+	}
+}
+
+func TestVaultStore_ForeignSecret(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeVault("root-token")
+	fake.secrets["/v1/secret/data/platform/root"] = map[string]any{"data": map[string]any{"key": "original"}}
+	fake.metadata["/v1/secret/metadata/platform/root"] = map[string]any{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, _ := NewVaultStore(ctx, &VaultConfig{Address: server.URL, Token: "root-token"})
+	if err := store.Put(ctx, "platform/root", map[string]string{"password": "x"}); !errors.Is(err, ErrNotManaged) {
+		t.Errorf("Put() error = %v, want ErrNotManaged", err)
+	}
+	if err := store.Delete(ctx, "platform/root"); !errors.Is(err, ErrNotManaged) {
+		t.Errorf("Delete() error = %v, want ErrNotManaged", err)
+	}
+	data, _ := fake.secrets["/v1/secret/data/platform/root"]["data"].(map[string]any)
+	if data["key"] != "original" {
+		t.Errorf("foreign secret was changed: %v", data)
+	}
+}
+
+func TestVaultStore_KubernetesAuth(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeVault("k8s-token")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	jwtPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(jwtPath, []byte("sa-jwt\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewVaultStore(ctx, &VaultConfig{
+		Address:                 server.URL,
+		KubernetesRole:          "dbtether",
+		ServiceAccountTokenPath: jwtPath,
+	})
+	if err != nil {
+		t.Fatalf("NewVaultStore() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := store.Put(ctx, "orders", map[string]string{"password": "secret"}); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+	if fake.logins != 1 {
+		t.Errorf("logins = %d, want 1 (token should be cached)", fake.logins)
+	}
+
+	// Expired token triggers a single re-login
+	fake.token = "rotated-token"
+	if err := store.Put(ctx, "orders", map[string]string{"password": "secret2"}); err != nil {
+		t.Fatalf("Put() after token expiry error = %v", err)
+	}
+	if fake.logins != 2 {
+		t.Errorf("logins = %d, want 2 after token expiry", fake.logins)
+	}
+}
+
+func TestVaultStore_KubernetesAuthLease(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeVault("k8s-token")
+	fake.lease = 3600
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	jwtPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(jwtPath, []byte("sa-jwt\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewVaultStore(ctx, &VaultConfig{
+		Address:                 server.URL,
+		KubernetesRole:          "dbtether",
+		ServiceAccountTokenPath: jwtPath,
+	})
+	if err != nil {
+		t.Fatalf("NewVaultStore() error = %v", err)
+	}
+
+	if err := store.Put(ctx, "orders", map[string]string{"password": "secret"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if until := time.Until(store.tokenExpiry); until <= 50*time.Minute || until > time.Hour {
+		t.Errorf("token renewed in %v, want shortly before the 1h lease ends", until)
+	}
+
+	// A token whose lease is nearly over is replaced before it is used
+	store.tokenExpiry = time.Now().Add(-time.Second)
+	if err := store.Put(ctx, "orders", map[string]string{"password": "secret2"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if fake.logins != 2 {
+		t.Errorf("logins = %d, want 2 after the lease ran out", fake.logins)
+	}
+}
+
+func TestVaultStore_Errors(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeVault("root-token")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, _ := NewVaultStore(ctx, &VaultConfig{Address: server.URL, Token: "wrong-token"})
+	err := store.Put(ctx, "orders", map[string]string{"password": "secret"})
+	if err == nil {
+		t.Fatal("Put() with wrong token should fail")
+	}
+	if want := "permission denied"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q should contain %q", err.Error(), want)
+	}
+
+	if _, err := NewVaultStore(ctx, &VaultConfig{Token: "x"}); err == nil {
+		t.Error("NewVaultStore() without address should fail")
+	}
+	if _, err := NewVaultStore(ctx, &VaultConfig{Address: server.URL}); err == nil {
+		t.Error("NewVaultStore() without auth should fail")
+	}
+}
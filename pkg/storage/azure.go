@@ -5,16 +5,21 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 )
 
 // AzureClient provides Azure Blob Storage operations
 type AzureClient struct {
 	client    *azblob.Client
 	container string
+	lock      *ObjectLock
 	logger    *slog.Logger
 }
 
@@ -22,10 +27,17 @@ type AzureClient struct {
 type AzureConfig struct {
 	Container      string
 	StorageAccount string
-	// Credentials via:
-	// - Azure Managed Identity (on AKS with Workload Identity)
-	// - AZURE_CLIENT_ID, AZURE_TENANT_ID, AZURE_CLIENT_SECRET env vars
-	// - Azure CLI credentials (local dev)
+
+	// Credentials, tried in this order; all empty uses DefaultAzureCredential
+	// (Managed Identity, Workload Identity, Azure CLI)
+	ConnectionString string // includes the account, which overrides StorageAccount
+	SASToken         string
+	TenantID         string // TenantID, ClientID and ClientSecret of a service principal
+	ClientID         string
+	ClientSecret     string
+
+	// Lock applied to uploaded blobs; requires version-level immutability support on the container
+	Lock *ObjectLock
 }
 
 // NewAzureClient creates a new Azure Blob Storage client
@@ -34,28 +46,63 @@ func NewAzureClient(ctx context.Context, cfg *AzureConfig, logger *slog.Logger)
 		logger = slog.Default()
 	}
 
-	// Uses DefaultAzureCredential which tries:
-	// 1. Environment credentials (AZURE_CLIENT_ID, AZURE_TENANT_ID, AZURE_CLIENT_SECRET)
-	// 2. Managed Identity (on Azure VMs, AKS, etc.)
-	// 3. Azure CLI credentials
-	cred, err := azidentity.NewDefaultAzureCredential(nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get Azure credentials: %w", err)
-	}
-
-	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", cfg.StorageAccount)
-	client, err := azblob.NewClient(serviceURL, cred, nil)
+	client, err := newAzureBlobClient(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Azure Blob client: %w", err)
+		return nil, err
 	}
 
 	return &AzureClient{
 		client:    client,
 		container: cfg.Container,
+		lock:      cfg.Lock,
 		logger:    logger,
 	}, nil
 }
 
+// newAzureBlobClient authenticates with the configured credentials
+func newAzureBlobClient(cfg *AzureConfig) (*azblob.Client, error) {
+	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", cfg.StorageAccount)
+
+	switch {
+	case cfg.ConnectionString != "":
+		client, err := azblob.NewClientFromConnectionString(cfg.ConnectionString, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure Blob client from connection string: %w", err)
+		}
+		return client, nil
+	case cfg.SASToken != "":
+		client, err := azblob.NewClientWithNoCredential(serviceURL+"?"+strings.TrimPrefix(cfg.SASToken, "?"), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure Blob client with SAS token: %w", err)
+		}
+		return client, nil
+	}
+
+	var cred azcore.TokenCredential
+	var err error
+	if cfg.ClientSecret != "" {
+		if cfg.TenantID == "" || cfg.ClientID == "" {
+			return nil, fmt.Errorf("azure client secret requires a tenant ID and client ID")
+		}
+		cred, err = azidentity.NewClientSecretCredential(cfg.TenantID, cfg.ClientID, cfg.ClientSecret, nil)
+	} else {
+		// DefaultAzureCredential tries:
+		// 1. Environment credentials (AZURE_CLIENT_ID, AZURE_TENANT_ID, AZURE_CLIENT_SECRET)
+		// 2. Managed Identity (on Azure VMs, AKS, etc.)
+		// 3. Azure CLI credentials
+		cred, err = azidentity.NewDefaultAzureCredential(nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Azure credentials: %w", err)
+	}
+
+	client, err := azblob.NewClient(serviceURL, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure Blob client: %w", err)
+	}
+	return client, nil
+}
+
 // Upload uploads data to Azure Blob Storage
 func (c *AzureClient) Upload(ctx context.Context, key string, data io.Reader) error {
 	// Read all data (Azure SDK requires seekable reader or bytes)
@@ -78,25 +125,74 @@ func (c *AzureClient) UploadWithTags(ctx context.Context, key string, data io.Re
 		return fmt.Errorf("failed to read data: %w", err)
 	}
 
-	opts := &azblob.UploadBufferOptions{}
+	var metadata map[string]*string
 	if tags != nil {
-		opts.Metadata = map[string]*string{
-			"database":   strPtr(tags.Database),
-			"cluster":    strPtr(tags.Cluster),
-			"backupname": strPtr(tags.BackupName), // Azure metadata keys can't have hyphens
-			"namespace":  strPtr(tags.Namespace),
-			"timestamp":  strPtr(tags.Timestamp),
-			"createdby":  strPtr(tags.CreatedBy),
+		metadata = map[string]*string{
+			"database":    strPtr(tags.Database),
+			"cluster":     strPtr(tags.Cluster),
+			"backupname":  strPtr(tags.BackupName), // Azure metadata keys can't have hyphens
+			"namespace":   strPtr(tags.Namespace),
+			"timestamp":   strPtr(tags.Timestamp),
+			"createdby":   strPtr(tags.CreatedBy),
+			"compression": strPtr(tags.Compression),
 		}
 	}
 
-	_, err = c.client.UploadBuffer(ctx, c.container, key, dataBytes, opts)
+	if c.lock != nil {
+		return c.uploadLocked(ctx, key, dataBytes, metadata)
+	}
+
+	_, err = c.client.UploadBuffer(ctx, c.container, key, dataBytes, &azblob.UploadBufferOptions{Metadata: metadata})
 	if err != nil {
 		return fmt.Errorf("failed to upload to Azure Blob: %w", err)
 	}
 	return nil
 }
 
+// uploadLocked uploads the blob like an unlocked upload (in blocks when it is larger than a single
+// Put Blob allows), then sets the immutability policy and legal hold on the committed blob
+func (c *AzureClient) uploadLocked(ctx context.Context, key string, data []byte, metadata map[string]*string) error {
+	if _, err := c.client.UploadBuffer(ctx, c.container, key, data, &azblob.UploadBufferOptions{Metadata: metadata}); err != nil {
+		return fmt.Errorf("failed to upload to Azure Blob: %w", err)
+	}
+
+	blobClient := c.client.ServiceClient().NewContainerClient(c.container).NewBlobClient(key)
+	lock := azureObjectLock(c.lock, time.Now())
+	if lock.policy != nil {
+		if _, err := blobClient.SetImmutabilityPolicy(ctx, lock.expiry, lock.policy); err != nil {
+			return fmt.Errorf("uploaded blob %s but failed to set its immutability policy: %w", key, err)
+		}
+	}
+	if lock.legalHold {
+		if _, err := blobClient.SetLegalHold(ctx, true, nil); err != nil {
+			return fmt.Errorf("uploaded blob %s but failed to set its legal hold: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// azureLock is the immutability policy and legal hold set on an uploaded blob
+type azureLock struct {
+	policy    *blob.SetImmutabilityPolicyOptions // nil: no immutability policy
+	expiry    time.Time
+	legalHold bool
+}
+
+// azureObjectLock returns the immutability policy (Unlocked for governance, Locked for compliance)
+// and legal hold of lock
+func azureObjectLock(lock *ObjectLock, now time.Time) azureLock {
+	result := azureLock{legalHold: lock.LegalHold}
+	if until := lock.retainUntil(now); !until.IsZero() {
+		mode := blob.ImmutabilityPolicySettingUnlocked
+		if lock.locked() {
+			mode = blob.ImmutabilityPolicySettingLocked
+		}
+		result.policy = &blob.SetImmutabilityPolicyOptions{Mode: &mode}
+		result.expiry = until
+	}
+	return result
+}
+
 func strPtr(s string) *string {
 	return &s
 }
@@ -104,6 +200,9 @@ func strPtr(s string) *string {
 // Download downloads data from Azure Blob Storage
 func (c *AzureClient) Download(ctx context.Context, key string) (io.ReadCloser, error) {
 	resp, err := c.client.DownloadStream(ctx, c.container, key, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to download from Azure Blob: %w", err)
 	}
@@ -114,18 +213,44 @@ func (c *AzureClient) Download(ctx context.Context, key string) (io.ReadCloser,
 func (c *AzureClient) Delete(ctx context.Context, key string) error {
 	_, err := c.client.DeleteBlob(ctx, c.container, key, nil)
 	if err != nil {
+		if isObjectLockedError(err) {
+			return fmt.Errorf("%w: %v", ErrObjectLocked, err)
+		}
 		return fmt.Errorf("failed to delete from Azure Blob: %w", err)
 	}
 	return nil
 }
 
-// AzureObject represents an object in Azure Blob Storage
-type AzureObject struct {
-	Key          string
-	Size         int64
-	LastModified time.Time
+// Exists checks if a blob exists in Azure Blob Storage
+func (c *AzureClient) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := c.client.ServiceClient().NewContainerClient(c.container).NewBlobClient(key).GetProperties(ctx, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check Azure blob: %w", err)
+	}
+	return true, nil
 }
 
+// ReadTags reads the blob metadata
+func (c *AzureClient) ReadTags(ctx context.Context, key string) (*ObjectTags, error) {
+	props, err := c.client.ServiceClient().NewContainerClient(c.container).NewBlobClient(key).GetProperties(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Azure blob metadata: %w", err)
+	}
+	values := make(map[string]string, len(props.Metadata))
+	for key, value := range props.Metadata {
+		if value != nil {
+			values[key] = *value
+		}
+	}
+	return tagsFromMap(values), nil
+}
+
+// AzureObject represents an object in Azure Blob Storage
+type AzureObject = StorageObject
+
 // List lists all blobs with the given prefix
 func (c *AzureClient) List(ctx context.Context, prefix string) ([]AzureObject, error) {
 	var objects []AzureObject
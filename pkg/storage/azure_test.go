@@ -1,7 +1,14 @@
 package storage
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestAzureConfig_Validation(t *testing.T) {
@@ -93,3 +100,109 @@ func TestStrPtr(t *testing.T) {
 		t.Errorf("strPtr value mismatch: got %q, want %q", *result, input)
 	}
 }
+
+func TestNewAzureBlobClient_Credentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     AzureConfig
+		wantURL string
+		wantErr string
+	}{
+		{
+			name: "connection string",
+			cfg: AzureConfig{
+				StorageAccount: "ignored",
+				ConnectionString: "DefaultEndpointsProtocol=https;AccountName=connaccount;" +
+					"AccountKey=ZHVtbXlrZXk=;EndpointSuffix=core.windows.net",
+			},
+			wantURL: "https://connaccount.blob.core.windows.net/",
+		},
+		{
+			name:    "SAS token",
+			cfg:     AzureConfig{StorageAccount: "sasaccount", SASToken: "?sv=2022-11-02&sp=rwdl&sig=abc"},
+			wantURL: "https://sasaccount.blob.core.windows.net/?sv=2022-11-02&sp=rwdl&sig=abc",
+		},
+		{
+			name: "client secret",
+			cfg: AzureConfig{
+				StorageAccount: "spaccount",
+				TenantID:       "00000000-0000-0000-0000-000000000001",
+				ClientID:       "00000000-0000-0000-0000-000000000002",
+				ClientSecret:   "secret",
+			},
+			wantURL: "https://spaccount.blob.core.windows.net/",
+		},
+		{
+			name:    "client secret without tenant",
+			cfg:     AzureConfig{StorageAccount: "spaccount", ClientID: "client", ClientSecret: "secret"},
+			wantErr: "azure client secret requires a tenant ID and client ID",
+		},
+		{
+			name:    "invalid connection string",
+			cfg:     AzureConfig{ConnectionString: "not-a-connection-string"},
+			wantErr: "failed to create Azure Blob client from connection string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := newAzureBlobClient(&tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newAzureBlobClient() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newAzureBlobClient() error = %v", err)
+			}
+			if got := client.URL(); got != tt.wantURL {
+				t.Errorf("client URL = %q, want %q", got, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestAzureClient_UploadLocked(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = io.Copy(io.Discard, r.Body)
+		request, status := r.Method, http.StatusOK
+		switch r.URL.Query().Get("comp") {
+		case "":
+			status = http.StatusCreated
+		case "immutabilityPolicies":
+			request += " immutabilityPolicies " + r.Header.Get("x-ms-immutability-policy-mode")
+		case "legalhold":
+			request += " legalhold " + r.Header.Get("x-ms-legal-hold")
+		}
+		requests = append(requests, request)
+		w.Header().Set("ETag", `"0x1"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client, err := NewAzureClient(context.Background(), &AzureConfig{
+		Container: "backups",
+		ConnectionString: "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;AccountKey=ZHVtbXlrZXk=;" +
+			"BlobEndpoint=" + server.URL + "/devstoreaccount1;",
+		Lock: &ObjectLock{Mode: LockModeCompliance, RetainFor: time.Hour, LegalHold: true},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewAzureClient() error = %v", err)
+	}
+
+	if err := client.UploadWithTags(context.Background(), "main/orders/backup.sql.gz", strings.NewReader("dump"),
+		&ObjectTags{Database: "orders"}); err != nil {
+		t.Fatalf("UploadWithTags() error = %v", err)
+	}
+
+	want := []string{"PUT", "PUT immutabilityPolicies Locked", "PUT legalhold true"}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tempFilePrefix marks files that are still being written; List skips them
+const tempFilePrefix = ".dbtether-upload-"
+
+// FilesystemClient stores objects as files under a directory, e.g. a mounted PersistentVolumeClaim
+type FilesystemClient struct {
+	root   string
+	logger *slog.Logger
+}
+
+// FilesystemConfig contains configuration for the filesystem client
+type FilesystemConfig struct {
+	// Root directory objects are stored under; keys are paths relative to it
+	Root string
+}
+
+// NewFilesystemClient creates a client for the given directory
+func NewFilesystemClient(cfg *FilesystemConfig, logger *slog.Logger) (*FilesystemClient, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.Root == "" {
+		return nil, fmt.Errorf("filesystem root is required")
+	}
+
+	info, err := os.Stat(cfg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access storage directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("storage path %s is not a directory", cfg.Root)
+	}
+
+	return &FilesystemClient{root: cfg.Root, logger: logger}, nil
+}
+
+// path maps a key to a file under the root, rejecting keys that would escape it
+func (c *FilesystemClient) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(c.root, filepath.FromSlash(key)), nil
+}
+
+func (c *FilesystemClient) Upload(ctx context.Context, key string, body io.Reader) error {
+	return c.UploadWithTags(ctx, key, body, nil)
+}
+
+// UploadWithTags writes the object through a temporary file, so an interrupted upload never
+// leaves a partial backup behind. Tags are written to a sidecar file (key + MetadataSuffix) after
+// the data, like on SFTP; List hides sidecars and Delete removes them with the object.
+func (c *FilesystemClient) UploadWithTags(_ context.Context, key string, body io.Reader, tags *ObjectTags) error {
+	path, err := c.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := writeFile(path, body); err != nil {
+		return err
+	}
+	if tags != nil {
+		metadata, err := json.Marshal(tags)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(path+MetadataSuffix, bytes.NewReader(metadata)); err != nil {
+			return fmt.Errorf("failed to write metadata: %w", err)
+		}
+	}
+
+	c.logger.Info("uploaded to filesystem", "path", path)
+	return nil
+}
+
+// writeFile writes body to a temporary file next to path and renames it into place
+func writeFile(path string, body io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), tempFilePrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // no-op after the rename
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	// Flush to the volume before the backup is reported as complete
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+	return nil
+}
+
+func (c *FilesystemClient) Download(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := c.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path) // #nosec G304 -- path is confined to the storage root
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return f, nil
+}
+
+// ReadTags reads the metadata sidecar; files uploaded without tags have none
+func (c *FilesystemClient) ReadTags(_ context.Context, key string) (*ObjectTags, error) {
+	path, err := c.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path + MetadataSuffix) // #nosec G304 -- path is confined to the storage root
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	var tags ObjectTags
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("failed to read metadata of %s: %w", key, err)
+	}
+	return &tags, nil
+}
+
+func (c *FilesystemClient) Exists(_ context.Context, key string) (bool, error) {
+	path, err := c.path(key)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes the file and its metadata. Deleting a missing file is not an error, like in
+// object stores.
+func (c *FilesystemClient) Delete(_ context.Context, key string) error {
+	path, err := c.path(key)
+	if err != nil {
+		return err
+	}
+	for _, p := range []string{path, path + MetadataSuffix} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
+	}
+	return nil
+}
+
+// List lists all files whose key starts with prefix, sorted by key.
+// Metadata sidecars and unfinished uploads are skipped.
+func (c *FilesystemClient) List(_ context.Context, prefix string) ([]StorageObject, error) {
+	// Only walk the deepest directory the prefix names, not the whole volume
+	startDir := c.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		startDir = filepath.Join(c.root, filepath.FromSlash(prefix[:i]))
+	}
+
+	var objects []StorageObject
+	err := filepath.WalkDir(startDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == startDir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		rel, err := filepath.Rel(c.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			// Siblings of the prefix's last segment cannot hold matching keys
+			if path != startDir && !strings.HasPrefix(key+"/", prefix) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(key, prefix) || strings.HasSuffix(key, MetadataSuffix) ||
+			strings.HasPrefix(d.Name(), tempFilePrefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, StorageObject{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
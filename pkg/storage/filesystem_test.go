@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestFilesystemClient(t *testing.T) (*FilesystemClient, string) {
+	t.Helper()
+	root := t.TempDir()
+	client, err := NewFilesystemClient(&FilesystemConfig{Root: root}, nil)
+	if err != nil {
+		t.Fatalf("NewFilesystemClient() error = %v", err)
+	}
+	return client, root
+}
+
+func TestNewFilesystemClient_InvalidRoot(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, root := range []string{"", filepath.Join(t.TempDir(), "missing"), file} {
+		if _, err := NewFilesystemClient(&FilesystemConfig{Root: root}, nil); err == nil {
+			t.Errorf("NewFilesystemClient(%q) expected error", root)
+		}
+	}
+}
+
+func TestFilesystemClient_UploadDownload(t *testing.T) {
+	ctx := context.Background()
+	client, root := newTestFilesystemClient(t)
+
+	key := "main/orders/20260120-020000.sql.gz"
+	if err := client.UploadWithTags(ctx, key, strings.NewReader("dump"), &ObjectTags{Database: "orders"}); err != nil {
+		t.Fatalf("UploadWithTags() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "main", "orders", "20260120-020000.sql.gz")); err != nil {
+		t.Errorf("backup file not written: %v", err)
+	}
+	exists, err := client.Exists(ctx, key)
+	if err != nil || !exists {
+		t.Errorf("Exists() = %v, %v", exists, err)
+	}
+
+	body, err := client.Download(ctx, key)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	defer func() { _ = body.Close() }()
+	if got, _ := io.ReadAll(body); string(got) != "dump" {
+		t.Errorf("Download() = %q, want dump", got)
+	}
+
+	// Overwrites replace the file
+	if err := client.Upload(ctx, key, bytes.NewReader([]byte("new"))); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, "main", "orders"))
+	if len(entries) != 2 {
+		t.Errorf("directory has %d entries, want the backup and its metadata; temp files must be renamed", len(entries))
+	}
+}
+
+func TestFilesystemClient_ReadTags(t *testing.T) {
+	ctx := context.Background()
+	client, root := newTestFilesystemClient(t)
+
+	tags := &ObjectTags{Database: "orders", Namespace: "team-alpha", CreatedBy: "dbtether", Compression: "zstd"}
+	if err := client.UploadWithTags(ctx, "main/orders/20260120-020000.sql.zst", strings.NewReader("dump"), tags); err != nil {
+		t.Fatalf("UploadWithTags() error = %v", err)
+	}
+	if err := client.Upload(ctx, "main/orders/manual.sql.gz", strings.NewReader("dump")); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "main", "orders", "20260120-020000.sql.zst"+MetadataSuffix)); err != nil {
+		t.Errorf("metadata not written: %v", err)
+	}
+	got, err := client.ReadTags(ctx, "main/orders/20260120-020000.sql.zst")
+	if err != nil || got == nil || *got != *tags {
+		t.Errorf("ReadTags() = %+v, %v; want %+v", got, err, tags)
+	}
+	if got, err := client.ReadTags(ctx, "main/orders/manual.sql.gz"); got != nil || err != nil {
+		t.Errorf("ReadTags() without metadata = %+v, %v; want nil", got, err)
+	}
+}
+
+func TestFilesystemClient_DownloadMissing(t *testing.T) {
+	client, _ := newTestFilesystemClient(t)
+
+	_, err := client.Download(context.Background(), "main/orders/gone.sql.gz")
+	if !errors.Is(err, ErrNotFound) || err.Error() != "object not found: main/orders/gone.sql.gz" {
+		t.Errorf("Download() error = %v", err)
+	}
+	if exists, err := client.Exists(context.Background(), "main/orders/gone.sql.gz"); exists || err != nil {
+		t.Errorf("Exists() = %v, %v", exists, err)
+	}
+}
+
+func TestFilesystemClient_RejectsKeysOutsideRoot(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestFilesystemClient(t)
+
+	for _, key := range []string{"../escape.sql.gz", "/etc/passwd", "a/../../escape.sql.gz", ""} {
+		if err := client.Upload(ctx, key, strings.NewReader("x")); err == nil {
+			t.Errorf("Upload(%q) expected error", key)
+		}
+		if err := client.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) expected error", key)
+		}
+	}
+}
+
+func TestFilesystemClient_ListAndDelete(t *testing.T) {
+	ctx := context.Background()
+	client, root := newTestFilesystemClient(t)
+
+	for _, key := range []string{
+		"main/orders/20260102-000000.sql.gz",
+		"main/orders/20260101-000000.sql.gz",
+		"main/orders-archive/20260101-000000.sql.gz",
+		"main/users/20260101-000000.sql.gz",
+	} {
+		if err := client.UploadWithTags(ctx, key, strings.NewReader("data"), &ObjectTags{Database: "orders"}); err != nil {
+			t.Fatalf("UploadWithTags(%q) error = %v", key, err)
+		}
+	}
+	// An upload in progress is not a backup
+	if err := os.WriteFile(filepath.Join(root, "main", "orders", tempFilePrefix+"123"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := client.List(ctx, "main/orders/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var keys []string
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+		if obj.Size != 4 || obj.LastModified.IsZero() {
+			t.Errorf("object %s: size %d, modified %v", obj.Key, obj.Size, obj.LastModified)
+		}
+	}
+	want := []string{"main/orders/20260101-000000.sql.gz", "main/orders/20260102-000000.sql.gz"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("List() = %v, want %v", keys, want)
+	}
+
+	if objects, _ := client.List(ctx, "main/ord"); len(objects) != 3 {
+		t.Errorf("List() of a partial directory name = %v, want orders and orders-archive", objects)
+	}
+
+	if err := client.Delete(ctx, "main/orders/20260101-000000.sql.gz"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "main", "orders", "20260101-000000.sql.gz"+MetadataSuffix)); !os.IsNotExist(err) {
+		t.Errorf("metadata not deleted: %v", err)
+	}
+	if err := client.Delete(ctx, "main/orders/20260101-000000.sql.gz"); err != nil {
+		t.Errorf("Delete() of a missing file error = %v", err)
+	}
+	if objects, _ := client.List(ctx, "main/orders/"); len(objects) != 1 {
+		t.Errorf("List() after delete = %v", objects)
+	}
+	if objects, err := client.List(ctx, "missing/"); err != nil || len(objects) != 0 {
+		t.Errorf("List() of a missing directory = %v, %v", objects, err)
+	}
+}
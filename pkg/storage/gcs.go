@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -9,12 +10,14 @@ import (
 
 	gcs "cloud.google.com/go/storage"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 )
 
 // GCSClient provides Google Cloud Storage operations
 type GCSClient struct {
 	client *gcs.Client
 	bucket string
+	lock   *ObjectLock
 	logger *slog.Logger
 }
 
@@ -22,7 +25,13 @@ type GCSClient struct {
 type GCSConfig struct {
 	Bucket  string
 	Project string
-	// Credentials via GOOGLE_APPLICATION_CREDENTIALS env var or Workload Identity
+
+	// CredentialsJSON is a service account key; empty uses Application Default Credentials
+	// (Workload Identity, GOOGLE_APPLICATION_CREDENTIALS)
+	CredentialsJSON []byte
+
+	// Lock applied to uploaded objects; retention requires a bucket with object retention enabled
+	Lock *ObjectLock
 }
 
 // NewGCSClient creates a new GCS client
@@ -31,11 +40,15 @@ func NewGCSClient(ctx context.Context, cfg *GCSConfig, logger *slog.Logger) (*GC
 		logger = slog.Default()
 	}
 
-	// Uses Application Default Credentials (ADC):
+	// Without a key, uses Application Default Credentials (ADC):
 	// - Workload Identity on GKE
 	// - GOOGLE_APPLICATION_CREDENTIALS env var
 	// - gcloud auth application-default login (local dev)
-	client, err := gcs.NewClient(ctx)
+	var opts []option.ClientOption
+	if len(cfg.CredentialsJSON) > 0 {
+		opts = append(opts, option.WithAuthCredentialsJSON(option.ServiceAccount, cfg.CredentialsJSON))
+	}
+	client, err := gcs.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GCS client: %w", err)
 	}
@@ -43,6 +56,7 @@ func NewGCSClient(ctx context.Context, cfg *GCSConfig, logger *slog.Logger) (*GC
 	return &GCSClient{
 		client: client,
 		bucket: cfg.Bucket,
+		lock:   cfg.Lock,
 		logger: logger,
 	}, nil
 }
@@ -62,6 +76,7 @@ func (c *GCSClient) Upload(ctx context.Context, key string, data io.Reader) erro
 // UploadWithTags uploads data with metadata labels
 func (c *GCSClient) UploadWithTags(ctx context.Context, key string, data io.Reader, tags *ObjectTags) error {
 	wc := c.client.Bucket(c.bucket).Object(key).NewWriter(ctx)
+	wc.ContentType = tags.ContentType()
 
 	// Set metadata (GCS equivalent of tags)
 	if tags != nil {
@@ -72,9 +87,14 @@ func (c *GCSClient) UploadWithTags(ctx context.Context, key string, data io.Read
 			"namespace":   tags.Namespace,
 			"timestamp":   tags.Timestamp,
 			"created-by":  tags.CreatedBy,
+			"compression": tags.Compression,
 		}
 	}
 
+	if c.lock != nil {
+		applyGCSObjectLock(&wc.ObjectAttrs, c.lock, time.Now())
+	}
+
 	if _, err := io.Copy(wc, data); err != nil {
 		return fmt.Errorf("failed to upload to GCS: %w", err)
 	}
@@ -84,9 +104,25 @@ func (c *GCSClient) UploadWithTags(ctx context.Context, key string, data io.Read
 	return nil
 }
 
+// applyGCSObjectLock sets object retention (Unlocked for governance, Locked for compliance)
+// and a temporary hold as legal hold
+func applyGCSObjectLock(attrs *gcs.ObjectAttrs, lock *ObjectLock, now time.Time) {
+	if until := lock.retainUntil(now); !until.IsZero() {
+		mode := "Unlocked"
+		if lock.locked() {
+			mode = "Locked"
+		}
+		attrs.Retention = &gcs.ObjectRetention{Mode: mode, RetainUntil: until}
+	}
+	attrs.TemporaryHold = lock.LegalHold
+}
+
 // Download downloads data from GCS
 func (c *GCSClient) Download(ctx context.Context, key string) (io.ReadCloser, error) {
 	rc, err := c.client.Bucket(c.bucket).Object(key).NewReader(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to download from GCS: %w", err)
 	}
@@ -96,18 +132,38 @@ func (c *GCSClient) Download(ctx context.Context, key string) (io.ReadCloser, er
 // Delete deletes an object from GCS
 func (c *GCSClient) Delete(ctx context.Context, key string) error {
 	if err := c.client.Bucket(c.bucket).Object(key).Delete(ctx); err != nil {
+		if isObjectLockedError(err) {
+			return fmt.Errorf("%w: %v", ErrObjectLocked, err)
+		}
 		return fmt.Errorf("failed to delete from GCS: %w", err)
 	}
 	return nil
 }
 
-// GCSObject represents an object in GCS
-type GCSObject struct {
-	Key          string
-	Size         int64
-	LastModified time.Time
+// Exists checks if an object exists in GCS
+func (c *GCSClient) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := c.client.Bucket(c.bucket).Object(key).Attrs(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check GCS object: %w", err)
+	}
+	return true, nil
+}
+
+// ReadTags reads the object metadata
+func (c *GCSClient) ReadTags(ctx context.Context, key string) (*ObjectTags, error) {
+	attrs, err := c.client.Bucket(c.bucket).Object(key).Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GCS object metadata: %w", err)
+	}
+	return tagsFromMap(attrs.Metadata), nil
 }
 
+// GCSObject represents an object in GCS
+type GCSObject = StorageObject
+
 // List lists all objects with the given prefix
 func (c *GCSClient) List(ctx context.Context, prefix string) ([]GCSObject, error) {
 	var objects []GCSObject
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"context"
+	"strings"
 	"testing"
 )
 
@@ -79,3 +81,13 @@ func TestGCSObject_Fields(t *testing.T) {
 		t.Errorf("size mismatch: got %d", obj.Size)
 	}
 }
+
+func TestNewGCSClient_InvalidCredentials(t *testing.T) {
+	_, err := NewGCSClient(context.Background(), &GCSConfig{
+		Bucket:          "my-bucket",
+		CredentialsJSON: []byte(`{"type": "authorized_user"}`),
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to create GCS client") {
+		t.Fatalf("NewGCSClient() error = %v, want a credentials error", err)
+	}
+}
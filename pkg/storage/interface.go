@@ -2,10 +2,14 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
 )
 
+// ErrNotFound is returned by Download when the object does not exist
+var ErrNotFound = errors.New("object not found")
+
 // StorageObject represents a generic storage object
 type StorageObject struct {
 	Key          string
@@ -32,11 +36,16 @@ type StorageClient interface {
 
 	// List lists all objects with the given prefix
 	List(ctx context.Context, prefix string) ([]StorageObject, error)
+
+	// ReadTags returns the tags written by UploadWithTags, nil if the object has none
+	ReadTags(ctx context.Context, key string) (*ObjectTags, error)
 }
 
 // Verify implementations satisfy the interface
 var (
 	_ StorageClient = (*S3Client)(nil)
-	// _ StorageClient = (*GCSClient)(nil)   // TODO: update GCS to match interface
-	// _ StorageClient = (*AzureClient)(nil) // TODO: update Azure to match interface
+	_ StorageClient = (*FilesystemClient)(nil)
+	_ StorageClient = (*SFTPClient)(nil)
+	_ StorageClient = (*GCSClient)(nil)
+	_ StorageClient = (*AzureClient)(nil)
 )
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+var lockNow = time.Date(2026, 1, 20, 2, 0, 0, 0, time.UTC)
+
+func TestApplyS3ObjectLock(t *testing.T) {
+	tests := []struct {
+		name          string
+		lock          ObjectLock
+		wantMode      types.ObjectLockMode
+		wantUntil     *time.Time
+		wantLegalHold types.ObjectLockLegalHoldStatus
+	}{
+		{
+			name:      "governance",
+			lock:      ObjectLock{Mode: LockModeGovernance, RetainFor: 24 * time.Hour},
+			wantMode:  types.ObjectLockModeGovernance,
+			wantUntil: aws.Time(lockNow.Add(24 * time.Hour)),
+		},
+		{
+			name:      "compliance",
+			lock:      ObjectLock{Mode: LockModeCompliance, RetainFor: time.Hour},
+			wantMode:  types.ObjectLockModeCompliance,
+			wantUntil: aws.Time(lockNow.Add(time.Hour)),
+		},
+		{
+			name:          "legal hold only",
+			lock:          ObjectLock{Mode: LockModeGovernance, LegalHold: true},
+			wantLegalHold: types.ObjectLockLegalHoldStatusOn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &s3.PutObjectInput{}
+			applyS3ObjectLock(input, &tt.lock, lockNow)
+
+			if input.ObjectLockMode != tt.wantMode || input.ObjectLockLegalHoldStatus != tt.wantLegalHold {
+				t.Errorf("mode = %q, legal hold = %q", input.ObjectLockMode, input.ObjectLockLegalHoldStatus)
+			}
+			if (input.ObjectLockRetainUntilDate == nil) != (tt.wantUntil == nil) ||
+				tt.wantUntil != nil && !input.ObjectLockRetainUntilDate.Equal(*tt.wantUntil) {
+				t.Errorf("retain until = %v, want %v", input.ObjectLockRetainUntilDate, tt.wantUntil)
+			}
+			if input.ChecksumAlgorithm != types.ChecksumAlgorithmCrc32 {
+				t.Errorf("ChecksumAlgorithm = %q, Object Lock uploads need a checksum", input.ChecksumAlgorithm)
+			}
+		})
+	}
+}
+
+func TestS3LockReason(t *testing.T) {
+	tests := []struct {
+		name string
+		head s3.HeadObjectOutput
+		want string
+	}{
+		{name: "not locked", want: ""},
+		{name: "retention expired", head: s3.HeadObjectOutput{ObjectLockRetainUntilDate: aws.Time(lockNow.Add(-time.Second))}, want: ""},
+		{
+			name: "retained",
+			head: s3.HeadObjectOutput{ObjectLockRetainUntilDate: aws.Time(lockNow.Add(48 * time.Hour))},
+			want: "retained until 2026-01-22T02:00:00Z",
+		},
+		{
+			name: "legal hold",
+			head: s3.HeadObjectOutput{ObjectLockLegalHoldStatus: types.ObjectLockLegalHoldStatusOn},
+			want: "legal hold",
+		},
+		{
+			name: "legal hold off",
+			head: s3.HeadObjectOutput{ObjectLockLegalHoldStatus: types.ObjectLockLegalHoldStatusOff},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s3LockReason(&tt.head, lockNow); got != tt.want {
+				t.Errorf("s3LockReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyGCSObjectLock(t *testing.T) {
+	attrs := &storage.ObjectAttrs{}
+	applyGCSObjectLock(attrs, &ObjectLock{Mode: LockModeCompliance, RetainFor: time.Hour, LegalHold: true}, lockNow)
+
+	if attrs.Retention == nil || attrs.Retention.Mode != "Locked" || !attrs.Retention.RetainUntil.Equal(lockNow.Add(time.Hour)) {
+		t.Errorf("Retention = %+v, want Locked until %v", attrs.Retention, lockNow.Add(time.Hour))
+	}
+	if !attrs.TemporaryHold {
+		t.Error("legal hold should set a temporary hold")
+	}
+
+	attrs = &storage.ObjectAttrs{}
+	applyGCSObjectLock(attrs, &ObjectLock{Mode: LockModeGovernance, RetainFor: time.Hour}, lockNow)
+	if attrs.Retention == nil || attrs.Retention.Mode != "Unlocked" || attrs.TemporaryHold {
+		t.Errorf("governance attrs = %+v", attrs)
+	}
+}
+
+func TestAzureObjectLock(t *testing.T) {
+	lock := azureObjectLock(&ObjectLock{Mode: LockModeGovernance, RetainFor: time.Hour}, lockNow)
+
+	if lock.policy == nil || lock.policy.Mode == nil || *lock.policy.Mode != blob.ImmutabilityPolicySettingUnlocked {
+		t.Errorf("policy = %+v, want Unlocked", lock.policy)
+	}
+	if !lock.expiry.Equal(lockNow.Add(time.Hour)) {
+		t.Errorf("expiry = %v, want %v", lock.expiry, lockNow.Add(time.Hour))
+	}
+	if lock.legalHold {
+		t.Error("legal hold should not be set")
+	}
+
+	lock = azureObjectLock(&ObjectLock{Mode: LockModeCompliance, RetainFor: time.Hour}, lockNow)
+	if lock.policy == nil || *lock.policy.Mode != blob.ImmutabilityPolicySettingLocked {
+		t.Errorf("compliance policy = %+v, want Locked", lock.policy)
+	}
+
+	lock = azureObjectLock(&ObjectLock{Mode: LockModeCompliance, LegalHold: true}, lockNow)
+	if lock.policy != nil || !lock.legalHold {
+		t.Errorf("legal hold only: policy = %+v, legal hold = %v", lock.policy, lock.legalHold)
+	}
+}
+
+func TestIsObjectLockedError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("RESPONSE 409: BlobImmutableDueToPolicy"), true},
+		{errors.New("RESPONSE 409: BlobImmutableDueToLegalHold"), true},
+		{errors.New("googleapi: Error 403: Object 'a/b.sql.gz' is subject to bucket's retention policy or object retention and cannot be deleted or overwritten until 2026-02-01"), true},
+		{errors.New("googleapi: Error 403: Object 'a/b.sql.gz' is under active Temporary hold and cannot be deleted, overwritten or archived until hold is removed"), true},
+		{errors.New("googleapi: Error 403: access denied"), false},
+		{errors.New("RESPONSE 404: BlobNotFound"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isObjectLockedError(tt.err); got != tt.want {
+			t.Errorf("isObjectLockedError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestMockClient_DeleteLocked(t *testing.T) {
+	mock := NewMockClient()
+	mock.AddObject("old.sql.gz", []byte("data"), lockNow)
+	mock.AddObject("expired.sql.gz", []byte("data"), lockNow)
+	mock.LockObject("old.sql.gz", time.Now().Add(time.Hour))
+	mock.LockObject("expired.sql.gz", time.Now().Add(-time.Hour))
+
+	if err := mock.Delete(context.Background(), "old.sql.gz"); !errors.Is(err, ErrObjectLocked) {
+		t.Errorf("Delete(locked) error = %v, want ErrObjectLocked", err)
+	}
+	if err := mock.Delete(context.Background(), "expired.sql.gz"); err != nil {
+		t.Errorf("Delete(expired lock) error = %v", err)
+	}
+	if keys := mock.Keys(); len(keys) != 1 || keys[0] != "old.sql.gz" {
+		t.Errorf("Keys() = %v, want [old.sql.gz]", keys)
+	}
+}
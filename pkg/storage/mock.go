@@ -20,12 +20,14 @@ type MockClient struct {
 	DeleteError   error
 	ListError     error
 	ExistsError   error
+	TagsError     error
 }
 
 type mockObject struct {
 	data         []byte
 	tags         *ObjectTags
 	lastModified time.Time
+	lockedUntil  time.Time
 }
 
 // NewMockClient creates a new in-memory mock storage client
@@ -71,7 +73,7 @@ func (m *MockClient) Download(ctx context.Context, key string) (io.ReadCloser, e
 
 	obj, ok := m.objects[key]
 	if !ok {
-		return nil, fmt.Errorf("object not found: %s", key)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
 	}
 
 	return io.NopCloser(bytes.NewReader(obj.data)), nil
@@ -97,6 +99,9 @@ func (m *MockClient) Delete(ctx context.Context, key string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if obj, ok := m.objects[key]; ok && obj.lockedUntil.After(time.Now()) {
+		return fmt.Errorf("%w: retained until %s", ErrObjectLocked, obj.lockedUntil.Format(time.RFC3339))
+	}
 	delete(m.objects, key)
 	return nil
 }
@@ -136,6 +141,16 @@ func (m *MockClient) AddObject(key string, data []byte, lastModified time.Time)
 	}
 }
 
+// LockObject makes Delete fail with ErrObjectLocked until the given time
+func (m *MockClient) LockObject(key string, until time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if obj, ok := m.objects[key]; ok {
+		obj.lockedUntil = until
+	}
+}
+
 // GetObject returns the raw data for a key (for test assertions)
 func (m *MockClient) GetObject(key string) ([]byte, bool) {
 	m.mu.RLock()
@@ -148,6 +163,25 @@ func (m *MockClient) GetObject(key string) ([]byte, bool) {
 	return obj.data, true
 }
 
+func (m *MockClient) ReadTags(ctx context.Context, key string) (*ObjectTags, error) {
+	if m.TagsError != nil {
+		return nil, m.TagsError
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	obj, ok := m.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("object not found: %s", key)
+	}
+	if obj.tags == nil {
+		return nil, nil
+	}
+	tags := *obj.tags
+	return &tags, nil
+}
+
 // GetTags returns the tags for a key (for test assertions)
 func (m *MockClient) GetTags(key string) (*ObjectTags, bool) {
 	m.mu.RLock()
@@ -85,7 +85,7 @@ func TestMockClient_Download_NotFound(t *testing.T) {
 
 	_, err := client.Download(ctx, "nonexistent")
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), "object not found")
+	assert.ErrorIs(t, err, ErrNotFound)
 }
 
 func TestMockClient_Download_Error(t *testing.T) {
@@ -194,6 +194,26 @@ func TestMockClient_GetObject_NotFound(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestMockClient_ReadTags(t *testing.T) {
+	ctx := context.Background()
+	client := NewMockClient()
+
+	err := client.UploadWithTags(ctx, "tagged.sql.gz", bytes.NewReader([]byte("dump")), &ObjectTags{Database: "mydb"})
+	require.NoError(t, err)
+	client.AddObject("untagged.sql.gz", []byte("dump"), time.Now())
+
+	tags, err := client.ReadTags(ctx, "tagged.sql.gz")
+	require.NoError(t, err)
+	assert.Equal(t, "mydb", tags.Database)
+
+	tags, err = client.ReadTags(ctx, "untagged.sql.gz")
+	require.NoError(t, err)
+	assert.Nil(t, tags)
+
+	_, err = client.ReadTags(ctx, "missing.sql.gz")
+	assert.Error(t, err)
+}
+
 func TestMockClient_GetTags_NotFound(t *testing.T) {
 	client := NewMockClient()
 
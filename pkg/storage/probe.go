@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/pkg/sftp"
+)
+
+// ProbeDir is the directory under the storage prefix that probe objects are written to
+const ProbeDir = ".dbtether-probe"
+
+// probeContent is the body of a probe object, checked when it is read back
+const probeContent = "dbtether storage probe\n"
+
+// ErrProbeSkipped marks a probe step that could not run because an earlier step failed
+var ErrProbeSkipped = errors.New("not checked: the probe object could not be written")
+
+// ProbeResult holds the outcome of each probe step; nil means the step succeeded
+type ProbeResult struct {
+	Write  error
+	Read   error
+	List   error
+	Delete error
+}
+
+// Failed reports whether any step failed
+func (r *ProbeResult) Failed() bool {
+	return r.Write != nil || r.Read != nil || r.List != nil || r.Delete != nil
+}
+
+// MissingPermissions returns the steps denied by the storage, e.g. ["write", "delete"]
+func (r *ProbeResult) MissingPermissions() []string {
+	var missing []string
+	for _, step := range []struct {
+		name string
+		err  error
+	}{{"write", r.Write}, {"read", r.Read}, {"list", r.List}, {"delete", r.Delete}} {
+		if IsPermissionError(step.err) {
+			missing = append(missing, step.name)
+		}
+	}
+	return missing
+}
+
+// Probe checks that backups can be written, read, listed and deleted under prefix by doing so
+// with a small object in ProbeDir. An object protected by the storage's retention counts as
+// deletable: the permission is there.
+func Probe(ctx context.Context, client StorageClient, prefix string) *ProbeResult {
+	dir := strings.TrimSuffix(prefix, "/")
+	if dir != "" {
+		dir += "/"
+	}
+	dir += ProbeDir + "/"
+	key := dir + randomProbeName()
+
+	result := &ProbeResult{}
+	if result.Write = client.Upload(ctx, key, strings.NewReader(probeContent)); result.Write != nil {
+		result.Read, result.Delete = ErrProbeSkipped, ErrProbeSkipped
+		_, result.List = client.List(ctx, dir)
+		return result
+	}
+
+	result.Read = probeRead(ctx, client, key)
+	result.List = probeList(ctx, client, dir, key)
+	if err := client.Delete(ctx, key); err != nil && !errors.Is(err, ErrObjectLocked) {
+		result.Delete = err
+	}
+	return result
+}
+
+func probeRead(ctx context.Context, client StorageClient, key string) error {
+	body, err := client.Download(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = body.Close() }()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(data, []byte(probeContent)) {
+		return fmt.Errorf("probe object %s was read back with different content", key)
+	}
+	return nil
+}
+
+func probeList(ctx context.Context, client StorageClient, dir, key string) error {
+	objects, err := client.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if obj.Key == key {
+			return nil
+		}
+	}
+	return fmt.Errorf("probe object %s is missing from the listing", key)
+}
+
+func randomProbeName() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// IsPermissionError reports whether a storage operation was denied, as opposed to failing for
+// another reason (network, missing bucket)
+func IsPermissionError(err error) bool {
+	if err == nil || errors.Is(err, ErrProbeSkipped) {
+		return false
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		return true
+	}
+	var statusErr *sftp.StatusError
+	if errors.As(err, &statusErr) && statusErr.FxCode() == sftp.ErrSSHFxPermissionDenied {
+		return true
+	}
+	errStr := err.Error()
+	return isAccessDeniedError(err) || // S3
+		strings.Contains(errStr, "AuthorizationPermissionMismatch") || // Azure
+		strings.Contains(errStr, "AuthorizationFailure") || // Azure
+		strings.Contains(errStr, "Forbidden") // GCS, S3-compatible
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+func TestProbe(t *testing.T) {
+	ctx := context.Background()
+	client := NewMockClient()
+	client.AddObject("main/orders/20260101-000000.sql.gz", []byte("dump"), lockNow)
+
+	result := Probe(ctx, client, "main/")
+	if result.Failed() {
+		t.Fatalf("Probe() = %+v", result)
+	}
+
+	objects, _ := client.List(ctx, "")
+	if len(objects) != 1 || objects[0].Key != "main/orders/20260101-000000.sql.gz" {
+		t.Errorf("probe object left behind or backup touched: %v", objects)
+	}
+}
+
+func TestProbe_Failures(t *testing.T) {
+	accessDenied := errors.New("operation error S3: PutObject, api error AccessDenied: Access Denied")
+
+	tests := []struct {
+		name        string
+		setup       func(c *MockClient)
+		wantMissing []string
+		check       func(t *testing.T, r *ProbeResult)
+	}{
+		{
+			name:        "write denied skips read and delete",
+			setup:       func(c *MockClient) { c.UploadError = accessDenied },
+			wantMissing: []string{"write"},
+			check: func(t *testing.T, r *ProbeResult) {
+				if !errors.Is(r.Read, ErrProbeSkipped) || !errors.Is(r.Delete, ErrProbeSkipped) || r.List != nil {
+					t.Errorf("result = %+v", r)
+				}
+			},
+		},
+		{
+			name: "list and delete denied",
+			setup: func(c *MockClient) {
+				c.ListError = fmt.Errorf("failed to list: %w", fs.ErrPermission)
+				c.DeleteError = errors.New("googleapi: Error 403: Forbidden")
+			},
+			wantMissing: []string{"list", "delete"},
+		},
+		{
+			name:  "network error is not a permission error",
+			setup: func(c *MockClient) { c.DownloadError = errors.New("dial tcp: i/o timeout") },
+			check: func(t *testing.T, r *ProbeResult) {
+				if r.Read == nil || !r.Failed() {
+					t.Errorf("result = %+v", r)
+				}
+			},
+		},
+		{
+			name:  "locked probe object counts as deletable",
+			setup: func(c *MockClient) { c.DeleteError = fmt.Errorf("%w: retention", ErrObjectLocked) },
+			check: func(t *testing.T, r *ProbeResult) {
+				if r.Failed() {
+					t.Errorf("result = %+v", r)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewMockClient()
+			tt.setup(client)
+
+			result := Probe(context.Background(), client, "")
+			if got := strings.Join(result.MissingPermissions(), ","); got != strings.Join(tt.wantMissing, ",") {
+				t.Errorf("MissingPermissions() = %q, want %q", got, tt.wantMissing)
+			}
+			if tt.check != nil {
+				tt.check(t, result)
+			}
+		})
+	}
+}
+
+func TestProbe_Filesystem(t *testing.T) {
+	client, root := newTestFilesystemClient(t)
+
+	if result := Probe(context.Background(), client, "backups"); result.Failed() {
+		t.Fatalf("Probe() = %+v", result)
+	}
+	if objects, _ := client.List(context.Background(), ""); len(objects) != 0 {
+		t.Errorf("probe object left in %s: %v", root, objects)
+	}
+}
+
+func TestIsPermissionError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{ErrProbeSkipped, false},
+		{errors.New("api error AccessDenied: Access Denied"), true},
+		{errors.New("RESPONSE 403: AuthorizationPermissionMismatch"), true},
+		{fmt.Errorf("failed to create file: %w", &sftp.StatusError{Code: uint32(sftp.ErrSSHFxPermissionDenied)}), true},
+		{errors.New("NoSuchBucket: The specified bucket does not exist"), false},
+	}
+	for _, tt := range tests {
+		if got := IsPermissionError(tt.err); got != tt.want {
+			t.Errorf("IsPermissionError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -12,11 +13,13 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 type S3Client struct {
 	client *s3.Client
 	bucket string
+	lock   *ObjectLock
 	logger *slog.Logger
 }
 
@@ -26,6 +29,9 @@ type S3Config struct {
 	Endpoint  string // optional, for custom endpoints
 	AccessKey string // optional, empty = use IRSA/Pod Identity
 	SecretKey string
+
+	// Lock applied to uploaded objects; the bucket must have Object Lock enabled
+	Lock *ObjectLock
 }
 
 func NewS3Client(ctx context.Context, cfg *S3Config, logger *slog.Logger) (*S3Client, error) {
@@ -65,6 +71,7 @@ func NewS3Client(ctx context.Context, cfg *S3Config, logger *slog.Logger) (*S3Cl
 	return &S3Client{
 		client: client,
 		bucket: cfg.Bucket,
+		lock:   cfg.Lock,
 		logger: logger,
 	}, nil
 }
@@ -77,6 +84,26 @@ type ObjectTags struct {
 	Namespace  string
 	Timestamp  string
 	CreatedBy  string
+
+	// Codec of the backup file ("gzip", "zstd", "lz4", "none"); empty for gzip
+	Compression string
+}
+
+// ContentType returns the MIME type of a backup file with these tags (gzip without tags)
+func (t *ObjectTags) ContentType() string {
+	if t == nil {
+		return "application/gzip"
+	}
+	switch t.Compression {
+	case "zstd":
+		return "application/zstd"
+	case "lz4":
+		return "application/x-lz4"
+	case "none":
+		return "application/sql"
+	default:
+		return "application/gzip"
+	}
 }
 
 func (c *S3Client) Upload(ctx context.Context, key string, body io.Reader) error {
@@ -88,23 +115,28 @@ func (c *S3Client) UploadWithTags(ctx context.Context, key string, body io.Reade
 		Bucket:      aws.String(c.bucket),
 		Key:         aws.String(key),
 		Body:        body,
-		ContentType: aws.String("application/gzip"),
+		ContentType: aws.String(tags.ContentType()),
 	}
 
 	if tags != nil {
 		// Build tagging string: Key1=Value1&Key2=Value2
 		tagging := fmt.Sprintf(
-			"database=%s&cluster=%s&backup-name=%s&namespace=%s&timestamp=%s&created-by=%s",
+			"database=%s&cluster=%s&backup-name=%s&namespace=%s&timestamp=%s&created-by=%s&compression=%s",
 			tags.Database,
 			tags.Cluster,
 			tags.BackupName,
 			tags.Namespace,
 			tags.Timestamp,
 			tags.CreatedBy,
+			tags.Compression,
 		)
 		input.Tagging = aws.String(tagging)
 	}
 
+	if c.lock != nil {
+		applyS3ObjectLock(input, c.lock, time.Now())
+	}
+
 	_, err := c.client.PutObject(ctx, input)
 	if err != nil {
 		// Best-effort: if tagging fails (403 AccessDenied on PutObjectTagging), retry without tags
@@ -125,6 +157,21 @@ func (c *S3Client) UploadWithTags(ctx context.Context, key string, body io.Reade
 	return nil
 }
 
+// applyS3ObjectLock sets the Object Lock headers. Object Lock uploads require a checksum.
+func applyS3ObjectLock(input *s3.PutObjectInput, lock *ObjectLock, now time.Time) {
+	input.ChecksumAlgorithm = types.ChecksumAlgorithmCrc32
+	if until := lock.retainUntil(now); !until.IsZero() {
+		input.ObjectLockMode = types.ObjectLockModeGovernance
+		if lock.Mode == LockModeCompliance {
+			input.ObjectLockMode = types.ObjectLockModeCompliance
+		}
+		input.ObjectLockRetainUntilDate = aws.Time(until)
+	}
+	if lock.LegalHold {
+		input.ObjectLockLegalHoldStatus = types.ObjectLockLegalHoldStatusOn
+	}
+}
+
 // isAccessDeniedError checks if the error is an S3 AccessDenied error
 func isAccessDeniedError(err error) bool {
 	if err == nil {
@@ -141,11 +188,30 @@ func (c *S3Client) Download(ctx context.Context, key string) (io.ReadCloser, err
 		Key:    aws.String(key),
 	})
 	if err != nil {
+		if noSuchKey := (*types.NoSuchKey)(nil); errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
 		return nil, fmt.Errorf("failed to download from S3: %w", err)
 	}
 	return result.Body, nil
 }
 
+// ReadTags reads the object tagging (needs s3:GetObjectTagging)
+func (c *S3Client) ReadTags(ctx context.Context, key string) (*ObjectTags, error) {
+	result, err := c.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read S3 object tags: %w", err)
+	}
+	values := make(map[string]string, len(result.TagSet))
+	for _, tag := range result.TagSet {
+		values[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tagsFromMap(values), nil
+}
+
 func (c *S3Client) Exists(ctx context.Context, key string) (bool, error) {
 	_, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(c.bucket),
@@ -158,8 +224,20 @@ func (c *S3Client) Exists(ctx context.Context, key string) (bool, error) {
 	return true, nil
 }
 
+// Delete deletes an object. Locked objects are skipped with ErrObjectLocked: in a bucket with Object Lock
+// (always versioned) a delete would only add a delete marker and hide the backup until the lock ends.
 func (c *S3Client) Delete(ctx context.Context, key string) error {
-	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+	head, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err == nil {
+		if reason := s3LockReason(head, time.Now()); reason != "" {
+			return fmt.Errorf("%w: %s", ErrObjectLocked, reason)
+		}
+	}
+
+	_, err = c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(c.bucket),
 		Key:    aws.String(key),
 	})
@@ -169,6 +247,18 @@ func (c *S3Client) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// s3LockReason describes why an object is locked, or returns "" if it can be deleted.
+// Lock headers are only returned with s3:GetObjectRetention and s3:GetObjectLegalHold permissions.
+func s3LockReason(head *s3.HeadObjectOutput, now time.Time) string {
+	if head.ObjectLockLegalHoldStatus == types.ObjectLockLegalHoldStatusOn {
+		return "legal hold"
+	}
+	if head.ObjectLockRetainUntilDate != nil && head.ObjectLockRetainUntilDate.After(now) {
+		return "retained until " + head.ObjectLockRetainUntilDate.UTC().Format(time.RFC3339)
+	}
+	return ""
+}
+
 // List lists all objects with the given prefix
 func (c *S3Client) List(ctx context.Context, prefix string) ([]StorageObject, error) {
 	var objects []StorageObject
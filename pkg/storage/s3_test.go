@@ -1,6 +1,10 @@
 package storage
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -95,6 +99,32 @@ func TestS3Config_UsesStaticCredentials(t *testing.T) {
 	}
 }
 
+func TestS3Client_DownloadNotFound(t *testing.T) {
+	status, code := http.StatusNotFound, "NoSuchKey"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`<Error><Code>` + code + `</Code><Message>test</Message></Error>`))
+	}))
+	defer server.Close()
+
+	client, err := NewS3Client(context.Background(), &S3Config{
+		Bucket: "backups", Region: "us-east-1", Endpoint: server.URL, AccessKey: "key", SecretKey: "secret",
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewS3Client() error = %v", err)
+	}
+
+	if _, err := client.Download(context.Background(), "main/orders/gone.sql.gz"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Download() of a missing object error = %v, want ErrNotFound", err)
+	}
+
+	status, code = http.StatusForbidden, "AccessDenied"
+	if _, err := client.Download(context.Background(), "main/orders/backup.sql.gz"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Download() with denied access error = %v, want a non-ErrNotFound error", err)
+	}
+}
+
 func TestIsAccessDeniedError(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -171,3 +201,22 @@ func TestObjectTags_Format(t *testing.T) {
 		t.Errorf("timestamp format unexpected: %s", tags.Timestamp)
 	}
 }
+
+func TestObjectTags_ContentType(t *testing.T) {
+	tests := []struct {
+		tags *ObjectTags
+		want string
+	}{
+		{nil, "application/gzip"},
+		{&ObjectTags{}, "application/gzip"},
+		{&ObjectTags{Compression: "zstd"}, "application/zstd"},
+		{&ObjectTags{Compression: "lz4"}, "application/x-lz4"},
+		{&ObjectTags{Compression: "none"}, "application/sql"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.tags.ContentType(); got != tt.want {
+			t.Errorf("ContentType(%+v) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTPClient stores objects as files on an SFTP server
+type SFTPClient struct {
+	ssh    *ssh.Client
+	sftp   *sftp.Client
+	root   string
+	logger *slog.Logger
+}
+
+// SFTPConfig contains configuration for the SFTP client
+type SFTPConfig struct {
+	Host string
+	Port int // 0 = 22
+	User string
+
+	// Root directory on the server objects are stored under
+	Path string
+
+	// PrivateKey is the PEM-encoded SSH private key of User
+	PrivateKey string
+
+	// KnownHosts holds known_hosts lines for the server; its host key is always verified
+	KnownHosts string
+}
+
+// posixRenameExtension replaces existing files on rename, which plain SFTP v3 rename refuses
+const posixRenameExtension = "posix-rename@openssh.com"
+
+// NewSFTPClient connects to the server and starts an SFTP session
+func NewSFTPClient(ctx context.Context, cfg *SFTPConfig, logger *slog.Logger) (*SFTPClient, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(cfg.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH private key: %w", err)
+	}
+	hostKeyCallback, err := knownHostsCallback(cfg.KnownHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+
+	var dialer net.Dialer
+	tcpConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	// The handshake and sftp init do not take a context; a hung server is cut off by closing the socket
+	stop := context.AfterFunc(ctx, func() { _ = tcpConn.Close() })
+	defer stop()
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(tcpConn, addr, &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		_ = tcpConn.Close()
+		return nil, fmt.Errorf("SSH handshake with %s failed: %w", addr, contextError(ctx, err))
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+
+	// Keep many reads and writes in flight so large dumps are not bound by the round-trip time
+	sftpClient, err := sftp.NewClient(sshClient, sftp.UseConcurrentWrites(true), sftp.UseConcurrentReads(true))
+	if err != nil {
+		_ = sshClient.Close()
+		return nil, fmt.Errorf("failed to start sftp subsystem: %w", contextError(ctx, err))
+	}
+
+	return &SFTPClient{
+		ssh:    sshClient,
+		sftp:   sftpClient,
+		root:   cfg.Path,
+		logger: logger,
+	}, nil
+}
+
+// watch closes the connection if ctx ends before the returned stop function is called, which
+// fails all outstanding requests. A cancelled Job or a hung server thus never blocks forever.
+// The client cannot be used after that.
+func (c *SFTPClient) watch(ctx context.Context) (stop func() bool) {
+	return context.AfterFunc(ctx, func() { _ = c.ssh.Close() })
+}
+
+// contextError reports the context error instead of the connection error it caused
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// knownHostsCallback verifies host keys against known_hosts content. The knownhosts package
+// only reads files, so the content goes through a temporary file that is removed right away.
+func knownHostsCallback(knownHosts string) (ssh.HostKeyCallback, error) {
+	if strings.TrimSpace(knownHosts) == "" {
+		return nil, errors.New("known_hosts is required to verify the SFTP server")
+	}
+	f, err := os.CreateTemp("", "known_hosts-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	if _, err := f.WriteString(knownHosts); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	callback, err := knownhosts.New(f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("invalid known_hosts: %w", err)
+	}
+	return callback, nil
+}
+
+// path maps a key to a file under the root, rejecting keys that would escape it
+func (c *SFTPClient) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return path.Join(c.root, key), nil
+}
+
+func (c *SFTPClient) Upload(ctx context.Context, key string, body io.Reader) error {
+	return c.UploadWithTags(ctx, key, body, nil)
+}
+
+// UploadWithTags uploads through a temporary file renamed when complete. Tags are written to a
+// sidecar file (key + MetadataSuffix) after the data, so a failed upload never leaves a
+// sidecar without its backup. List hides sidecars and Delete removes them with the object.
+func (c *SFTPClient) UploadWithTags(ctx context.Context, key string, body io.Reader, tags *ObjectTags) error {
+	remotePath, err := c.path(key)
+	if err != nil {
+		return err
+	}
+	stop := c.watch(ctx)
+	defer stop()
+
+	if err := c.sftp.MkdirAll(path.Dir(remotePath)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", contextError(ctx, err))
+	}
+	if err := c.writeFile(remotePath, body); err != nil {
+		return contextError(ctx, err)
+	}
+	if tags != nil {
+		metadata, err := json.Marshal(tags)
+		if err != nil {
+			return err
+		}
+		if err := c.writeFile(remotePath+MetadataSuffix, bytes.NewReader(metadata)); err != nil {
+			return fmt.Errorf("failed to write metadata: %w", contextError(ctx, err))
+		}
+	}
+
+	c.logger.Info("uploaded to SFTP", "path", remotePath)
+	return nil
+}
+
+// writeFile writes body to a temporary file next to remotePath and renames it into place
+func (c *SFTPClient) writeFile(remotePath string, body io.Reader) error {
+	tmpPath := path.Join(path.Dir(remotePath), tempFilePrefix+path.Base(remotePath))
+	f, err := c.sftp.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	// Concurrent writes may leave holes on error, which is fine since the file is discarded then
+	if _, err := f.ReadFromWithConcurrency(body, 0); err != nil {
+		_ = f.Close()
+		_ = c.sftp.Remove(tmpPath)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	// Servers flush on close; a failed close means the file may be incomplete
+	if err := f.Close(); err != nil {
+		_ = c.sftp.Remove(tmpPath)
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	if err := c.rename(tmpPath, remotePath); err != nil {
+		_ = c.sftp.Remove(tmpPath)
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+	return nil
+}
+
+// rename replaces newPath if it exists. Without the OpenSSH posix-rename extension the target
+// has to be removed first, as SFTP v3 rename does not overwrite.
+func (c *SFTPClient) rename(oldPath, newPath string) error {
+	if _, ok := c.sftp.HasExtension(posixRenameExtension); ok {
+		return c.sftp.PosixRename(oldPath, newPath)
+	}
+	if err := c.sftp.Remove(newPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return c.sftp.Rename(oldPath, newPath)
+}
+
+// Download opens the file for reading; the connection is closed if ctx ends before the
+// returned reader is closed
+func (c *SFTPClient) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	remotePath, err := c.path(key)
+	if err != nil {
+		return nil, err
+	}
+	stop := c.watch(ctx)
+	f, err := c.sftp.Open(remotePath)
+	if err != nil {
+		stop()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to download from SFTP: %w", contextError(ctx, err))
+	}
+	return &sftpFileReader{File: f, stop: stop}, nil
+}
+
+// sftpFileReader keeps the file's WriteTo, which reads ahead with concurrent requests
+type sftpFileReader struct {
+	*sftp.File
+	stop func() bool
+}
+
+func (r *sftpFileReader) Close() error {
+	r.stop()
+	return r.File.Close()
+}
+
+func (c *SFTPClient) Exists(ctx context.Context, key string) (bool, error) {
+	remotePath, err := c.path(key)
+	if err != nil {
+		return false, err
+	}
+	stop := c.watch(ctx)
+	defer stop()
+
+	if _, err := c.sftp.Stat(remotePath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, contextError(ctx, err)
+	}
+	return true, nil
+}
+
+// ReadTags reads the metadata sidecar; files uploaded without tags have none
+func (c *SFTPClient) ReadTags(ctx context.Context, key string) (*ObjectTags, error) {
+	remotePath, err := c.path(key)
+	if err != nil {
+		return nil, err
+	}
+	stop := c.watch(ctx)
+	defer stop()
+
+	f, err := c.sftp.Open(remotePath + MetadataSuffix)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read metadata: %w", contextError(ctx, err))
+	}
+	defer func() { _ = f.Close() }()
+
+	var tags ObjectTags
+	if err := json.NewDecoder(f).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to read metadata of %s: %w", key, contextError(ctx, err))
+	}
+	return &tags, nil
+}
+
+// Delete removes the file and its metadata. Deleting a missing file is not an error.
+func (c *SFTPClient) Delete(ctx context.Context, key string) error {
+	remotePath, err := c.path(key)
+	if err != nil {
+		return err
+	}
+	stop := c.watch(ctx)
+	defer stop()
+
+	for _, p := range []string{remotePath, remotePath + MetadataSuffix} {
+		if err := c.sftp.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete from SFTP: %w", contextError(ctx, err))
+		}
+	}
+	return nil
+}
+
+// List lists all files whose key starts with prefix, sorted by key.
+// Metadata sidecars and unfinished uploads are skipped.
+func (c *SFTPClient) List(ctx context.Context, prefix string) ([]StorageObject, error) {
+	// Only walk the deepest directory the prefix names
+	startDir := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		startDir = prefix[:i]
+	}
+	stop := c.watch(ctx)
+	defer stop()
+
+	var objects []StorageObject
+	if err := c.walk(ctx, startDir, prefix, &objects); err != nil {
+		return nil, fmt.Errorf("failed to list SFTP files: %w", contextError(ctx, err))
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (c *SFTPClient) walk(ctx context.Context, dir, prefix string, objects *[]StorageObject) error {
+	entries, err := c.sftp.ReadDirContext(ctx, path.Join(c.root, dir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		key := path.Join(dir, entry.Name())
+		if entry.IsDir() {
+			// Siblings of the prefix's last segment cannot hold matching keys
+			if !strings.HasPrefix(key+"/", prefix) {
+				continue
+			}
+			if err := c.walk(ctx, key, prefix, objects); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.HasPrefix(key, prefix) || strings.HasSuffix(key, MetadataSuffix) ||
+			strings.HasPrefix(entry.Name(), tempFilePrefix) {
+			continue
+		}
+		*objects = append(*objects, StorageObject{
+			Key:          key,
+			Size:         entry.Size(),
+			LastModified: entry.ModTime(),
+		})
+	}
+	return nil
+}
+
+// Close ends the SSH connection and with it the SFTP session. The session is not closed first
+// as that waits for outstanding responses, which a hung server never sends.
+func (c *SFTPClient) Close() error {
+	err := c.ssh.Close()
+	_ = c.sftp.Close()
+	return err
+}
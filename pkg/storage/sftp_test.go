@@ -0,0 +1,408 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer serves the sftp subsystem from a local directory
+type testSSHServer struct {
+	root       string
+	addr       string
+	hostKey    ssh.PublicKey
+	privateKey string // PEM-encoded client key
+
+	// hang makes the server stop answering sftp requests; closed on cleanup
+	hang    chan struct{}
+	hanging atomic.Bool
+}
+
+func newTestSSHServer(t *testing.T, posixRename bool) *testSSHServer {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientPub, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorized, err := ssh.NewPublicKey(clientPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	// The server's extensions are global; clients read them when the session starts
+	if !posixRename {
+		if err := sftp.SetSFTPExtensions("hardlink@openssh.com", "statvfs@openssh.com"); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = sftp.SetSFTPExtensions("hardlink@openssh.com", posixRenameExtension, "statvfs@openssh.com")
+		})
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	s := &testSSHServer{
+		root:       t.TempDir(),
+		addr:       listener.Addr().String(),
+		hostKey:    hostSigner.PublicKey(),
+		privateKey: string(pem.EncodeToMemory(block)),
+		hang:       make(chan struct{}),
+	}
+	t.Cleanup(func() { close(s.hang) })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handleConn(conn, config)
+		}
+	}()
+	return s
+}
+
+func (s *testSSHServer) handleConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if ok {
+					go func() {
+						server, err := sftp.NewServer(&hangingChannel{Channel: channel, server: s})
+						if err == nil {
+							_ = server.Serve()
+						}
+						_ = channel.Close()
+					}()
+				}
+			}
+		}()
+	}
+}
+
+// hangingChannel stops reading requests while the server hangs
+type hangingChannel struct {
+	ssh.Channel
+	server *testSSHServer
+}
+
+func (c *hangingChannel) Read(p []byte) (int, error) {
+	if c.server.hanging.Load() {
+		<-c.server.hang
+		return 0, io.EOF
+	}
+	return c.Channel.Read(p)
+}
+
+func (s *testSSHServer) config() *SFTPConfig {
+	host, port, _ := net.SplitHostPort(s.addr)
+	portNum, _ := strconv.Atoi(port)
+	return &SFTPConfig{
+		Host:       host,
+		Port:       portNum,
+		User:       "backup",
+		Path:       path.Join(filepath.ToSlash(s.root), "backups"),
+		PrivateKey: s.privateKey,
+		KnownHosts: knownhosts.Line([]string{knownhosts.Normalize(s.addr)}, s.hostKey),
+	}
+}
+
+func newTestSFTPClient(t *testing.T, posixRename bool) (*SFTPClient, string) {
+	t.Helper()
+	server := newTestSSHServer(t, posixRename)
+	client, err := NewSFTPClient(context.Background(), server.config(), nil)
+	if err != nil {
+		t.Fatalf("NewSFTPClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client, filepath.Join(server.root, "backups")
+}
+
+func TestSFTPClient_UploadDownload(t *testing.T) {
+	for _, posixRename := range []bool{true, false} {
+		t.Run("posix-rename="+strconv.FormatBool(posixRename), func(t *testing.T) {
+			ctx := context.Background()
+			client, root := newTestSFTPClient(t, posixRename)
+
+			key := "main/orders/20260120-020000.sql.gz"
+			// Many times the size of one request, so reads and writes are pipelined
+			dump := bytes.Repeat([]byte("dump"), 1<<18)
+			if err := client.UploadWithTags(ctx, key, bytes.NewReader(dump), &ObjectTags{Database: "orders"}); err != nil {
+				t.Fatalf("UploadWithTags() error = %v", err)
+			}
+
+			metadata, err := os.ReadFile(filepath.Join(root, "main", "orders", "20260120-020000.sql.gz"+MetadataSuffix))
+			if err != nil {
+				t.Fatalf("metadata not written: %v", err)
+			}
+			if !strings.Contains(string(metadata), `"orders"`) {
+				t.Errorf("metadata = %s", metadata)
+			}
+			exists, err := client.Exists(ctx, key)
+			if err != nil || !exists {
+				t.Errorf("Exists() = %v, %v", exists, err)
+			}
+
+			body, err := client.Download(ctx, key)
+			if err != nil {
+				t.Fatalf("Download() error = %v", err)
+			}
+			got, err := io.ReadAll(body)
+			_ = body.Close()
+			if err != nil || !bytes.Equal(got, dump) {
+				t.Errorf("Download() returned %d bytes, err %v; want %d bytes", len(got), err, len(dump))
+			}
+
+			// Overwrites replace the file and leave no temp files behind
+			if err := client.Upload(ctx, key, strings.NewReader("new")); err != nil {
+				t.Fatalf("Upload() error = %v", err)
+			}
+			if data, _ := os.ReadFile(filepath.Join(root, "main", "orders", "20260120-020000.sql.gz")); string(data) != "new" {
+				t.Errorf("file = %q, want new", data)
+			}
+			entries, _ := os.ReadDir(filepath.Join(root, "main", "orders"))
+			if len(entries) != 2 {
+				t.Errorf("directory has %d entries, want the backup and its metadata", len(entries))
+			}
+		})
+	}
+}
+
+func TestSFTPClient_ReadTags(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestSFTPClient(t, true)
+
+	tags := &ObjectTags{Database: "orders", Namespace: "team-alpha", CreatedBy: "dbtether", Compression: "zstd"}
+	if err := client.UploadWithTags(ctx, "main/orders/20260120-020000.sql.zst", strings.NewReader("dump"), tags); err != nil {
+		t.Fatalf("UploadWithTags() error = %v", err)
+	}
+	if err := client.Upload(ctx, "main/orders/manual.sql.gz", strings.NewReader("dump")); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	got, err := client.ReadTags(ctx, "main/orders/20260120-020000.sql.zst")
+	if err != nil || got == nil || *got != *tags {
+		t.Errorf("ReadTags() = %+v, %v; want %+v", got, err, tags)
+	}
+	if got, err := client.ReadTags(ctx, "main/orders/manual.sql.gz"); got != nil || err != nil {
+		t.Errorf("ReadTags() without metadata = %+v, %v; want nil", got, err)
+	}
+}
+
+func TestSFTPClient_DownloadMissing(t *testing.T) {
+	client, _ := newTestSFTPClient(t, true)
+
+	_, err := client.Download(context.Background(), "main/orders/gone.sql.gz")
+	if !errors.Is(err, ErrNotFound) || err.Error() != "object not found: main/orders/gone.sql.gz" {
+		t.Errorf("Download() error = %v", err)
+	}
+	if exists, err := client.Exists(context.Background(), "main/orders/gone.sql.gz"); exists || err != nil {
+		t.Errorf("Exists() = %v, %v", exists, err)
+	}
+}
+
+func TestSFTPClient_RejectsKeysOutsideRoot(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestSFTPClient(t, true)
+
+	for _, key := range []string{"../escape.sql.gz", "/etc/passwd", "a/../../escape.sql.gz", ""} {
+		if err := client.Upload(ctx, key, strings.NewReader("x")); err == nil {
+			t.Errorf("Upload(%q) expected error", key)
+		}
+		if err := client.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) expected error", key)
+		}
+	}
+}
+
+func TestSFTPClient_ListAndDelete(t *testing.T) {
+	ctx := context.Background()
+	client, root := newTestSFTPClient(t, true)
+
+	for _, key := range []string{
+		"main/orders/20260102-000000.sql.gz",
+		"main/orders/20260101-000000.sql.gz",
+		"main/orders-archive/20260101-000000.sql.gz",
+		"main/users/20260101-000000.sql.gz",
+	} {
+		if err := client.UploadWithTags(ctx, key, strings.NewReader("data"), &ObjectTags{Database: "orders"}); err != nil {
+			t.Fatalf("UploadWithTags(%q) error = %v", key, err)
+		}
+	}
+	// An upload in progress is not a backup
+	if err := os.WriteFile(filepath.Join(root, "main", "orders", tempFilePrefix+"123"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := client.List(ctx, "main/orders/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var keys []string
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+		if obj.Size != 4 || obj.LastModified.IsZero() {
+			t.Errorf("object %s: size %d, modified %v", obj.Key, obj.Size, obj.LastModified)
+		}
+	}
+	want := []string{"main/orders/20260101-000000.sql.gz", "main/orders/20260102-000000.sql.gz"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("List() = %v, want %v", keys, want)
+	}
+
+	if err := client.Delete(ctx, "main/orders/20260101-000000.sql.gz"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "main", "orders", "20260101-000000.sql.gz"+MetadataSuffix)); !os.IsNotExist(err) {
+		t.Errorf("metadata not deleted: %v", err)
+	}
+	if err := client.Delete(ctx, "main/orders/20260101-000000.sql.gz"); err != nil {
+		t.Errorf("Delete() of a missing file error = %v", err)
+	}
+	if objects, _ := client.List(ctx, "main/orders/"); len(objects) != 1 {
+		t.Errorf("List() after delete = %v", objects)
+	}
+	if objects, err := client.List(ctx, "missing/"); err != nil || len(objects) != 0 {
+		t.Errorf("List() of a missing directory = %v, %v", objects, err)
+	}
+}
+
+func TestSFTPClient_UploadWritesMetadataAfterData(t *testing.T) {
+	ctx := context.Background()
+	client, root := newTestSFTPClient(t, true)
+
+	err := client.UploadWithTags(ctx, "main/orders/20260120-020000.sql.gz", iotest.ErrReader(errors.New("dump failed")),
+		&ObjectTags{Database: "orders"})
+	if err == nil {
+		t.Fatal("UploadWithTags() expected error")
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, "main", "orders"))
+	if len(entries) != 0 {
+		t.Errorf("failed upload left %d files behind, want none", len(entries))
+	}
+}
+
+func TestSFTPClient_HungServer(t *testing.T) {
+	server := newTestSSHServer(t, true)
+	client, err := NewSFTPClient(context.Background(), server.config(), nil)
+	if err != nil {
+		t.Fatalf("NewSFTPClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	server.hanging.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.List(ctx, "main/orders/")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("List() error = %v, want deadline exceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("List() still blocked after its context ended")
+	}
+}
+
+func TestNewSFTPClient_VerifiesHostKey(t *testing.T) {
+	server := newTestSSHServer(t, true)
+
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ssh.NewPublicKey(otherPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, knownHosts := range map[string]string{
+		"empty":      "",
+		"other key":  knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, otherKey),
+		"other host": knownhosts.Line([]string{"sftp.example.com"}, server.hostKey),
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := server.config()
+			cfg.KnownHosts = knownHosts
+			if client, err := NewSFTPClient(context.Background(), cfg, nil); err == nil {
+				_ = client.Close()
+				t.Error("NewSFTPClient() expected host key error")
+			}
+		})
+	}
+}
+
+func TestNewSFTPClient_InvalidPrivateKey(t *testing.T) {
+	server := newTestSSHServer(t, true)
+	cfg := server.config()
+	cfg.PrivateKey = "not a key"
+
+	if _, err := NewSFTPClient(context.Background(), cfg, nil); err == nil {
+		t.Error("NewSFTPClient() expected error")
+	}
+}
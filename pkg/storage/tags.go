@@ -0,0 +1,29 @@
+package storage
+
+import "strings"
+
+// MetadataSuffix is appended to the key of an object to store its tags in a sidecar file, on
+// storages without object metadata (PVC, SFTP)
+const MetadataSuffix = ".meta.json"
+
+// tagsFromMap reads ObjectTags from object tags or metadata. Key spelling differs per provider
+// ("backup-name" on S3 and GCS, "backupname" on Azure, which may also change the case).
+// Returns nil when the object carries no backup tags.
+func tagsFromMap(values map[string]string) *ObjectTags {
+	normalized := make(map[string]string, len(values))
+	for key, value := range values {
+		normalized[strings.ReplaceAll(strings.ToLower(key), "-", "")] = value
+	}
+	if normalized["createdby"] == "" && normalized["database"] == "" {
+		return nil
+	}
+	return &ObjectTags{
+		Database:    normalized["database"],
+		Cluster:     normalized["cluster"],
+		BackupName:  normalized["backupname"],
+		Namespace:   normalized["namespace"],
+		Timestamp:   normalized["timestamp"],
+		CreatedBy:   normalized["createdby"],
+		Compression: normalized["compression"],
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagsFromMap(t *testing.T) {
+	want := &ObjectTags{
+		Database:    "orders",
+		Cluster:     "main",
+		BackupName:  "orders-nightly",
+		Namespace:   "team-alpha",
+		Timestamp:   "20260120-020000",
+		CreatedBy:   "dbtether",
+		Compression: "zstd",
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   *ObjectTags
+	}{
+		{
+			name: "s3 and gcs keys",
+			values: map[string]string{
+				"database": "orders", "cluster": "main", "backup-name": "orders-nightly", "namespace": "team-alpha",
+				"timestamp": "20260120-020000", "created-by": "dbtether", "compression": "zstd",
+			},
+			want: want,
+		},
+		{
+			name: "azure metadata with canonical header case",
+			values: map[string]string{
+				"Database": "orders", "Cluster": "main", "Backupname": "orders-nightly", "Namespace": "team-alpha",
+				"Timestamp": "20260120-020000", "Createdby": "dbtether", "Compression": "zstd",
+			},
+			want: want,
+		},
+		{name: "no backup tags", values: map[string]string{"owner": "platform"}, want: nil},
+		{name: "no tags", values: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tagsFromMap(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tagsFromMap() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}